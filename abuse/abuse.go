@@ -0,0 +1,242 @@
+// Package abuse scores the creators of links on signals of abuse when
+// ABUSE_SCORING=true: links an admin rejected or disabled, reports of their
+// links by visitors, and destinations refused or held by the safety checks.
+// Scores decay with a half-life of ABUSE_SCORE_HALF_LIFE, so creators
+// recover once the signals stop. The level a score reaches progressively
+// tightens the creator's rate limits, disables risky features and holds
+// new links for approval, unless an admin overrides it.
+package abuse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/storage"
+)
+
+// Half-life of abuse scores unless ABUSE_SCORE_HALF_LIFE says otherwise
+const defaultHalfLife = 7 * 24 * time.Hour
+
+// Scores from which each level applies
+const (
+	elevatedScore = 10
+	highScore     = 25
+	severeScore   = 50
+)
+
+// Signal is an event counting against a creator
+type Signal string
+
+// Signals, weighted by how strongly they suggest abuse
+const (
+	SignalReport  Signal = "report"  // a visitor reported a link
+	SignalReview  Signal = "review"  // a destination was held for review by a safety rule
+	SignalBlocked Signal = "blocked" // a destination was blocked by a safety rule
+	SignalUnsafe  Signal = "unsafe"  // a destination was flagged as malicious
+	SignalFlagged Signal = "flagged" // an admin rejected or disabled a link
+)
+
+var signalWeights = map[Signal]float64{
+	SignalReport:  2,
+	SignalReview:  1,
+	SignalBlocked: 3,
+	SignalUnsafe:  5,
+	SignalFlagged: 10,
+}
+
+// How long levels are reused by an instance before being read again
+const levelTTL = time.Minute
+
+// Levels read recently, per instance
+var (
+	levelsMu sync.Mutex
+	levels   = make(map[string]cachedLevel)
+)
+
+type cachedLevel struct {
+	level   string
+	expires time.Time
+}
+
+// Enabled reports whether creators are scored, as set by ABUSE_SCORING
+func Enabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("ABUSE_SCORING"))
+	return enabled
+}
+
+// HalfLife returns how long abuse scores take to halve, from
+// ABUSE_SCORE_HALF_LIFE
+func HalfLife() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("ABUSE_SCORE_HALF_LIFE")); err == nil && value > 0 {
+		return value
+	}
+	return defaultHalfLife
+}
+
+// Creator identifies who creates links: the user owning them, else the
+// client's IP address
+func Creator(ownerID *uint, clientIP string) string {
+	if ownerID != nil {
+		return fmt.Sprintf("user:%d", *ownerID)
+	}
+	if clientIP == "" {
+		return ""
+	}
+	return "ip:" + clientIP
+}
+
+// ValidCreator reports whether creator is user:<id> or ip:<address>
+func ValidCreator(creator string) bool {
+	if id, ok := strings.CutPrefix(creator, "user:"); ok {
+		_, err := strconv.ParseUint(id, 10, 64)
+		return err == nil
+	}
+	if ip, ok := strings.CutPrefix(creator, "ip:"); ok {
+		return net.ParseIP(ip) != nil
+	}
+	return false
+}
+
+// LinkCreator returns the creator urlRecord counts against, falling back to
+// its owner for links created before creators were recorded
+func LinkCreator(urlRecord *models.URL) string {
+	if urlRecord.Creator != "" {
+		return urlRecord.Creator
+	}
+	return Creator(urlRecord.OwnerID, "")
+}
+
+// Record counts signal against creator. Failures are logged, as signals are
+// recorded while handling requests that should not fail because of them.
+func Record(ctx context.Context, creator string, signal Signal) {
+	if !Enabled() || creator == "" || database.DB == nil {
+		return
+	}
+	if err := database.AddAbuseScore(ctx, creator, signalWeights[signal], HalfLife(), time.Now()); err != nil {
+		slog.ErrorContext(ctx, "Failed to record abuse signal", "signal", signal, "creator", creator, "error", err)
+		return
+	}
+	Forget(creator)
+}
+
+// Level returns the level in force for creator, reusing the one read in
+// the last minute. Lookup failures are logged and treated as normal.
+func Level(ctx context.Context, creator string) string {
+	if !Enabled() || creator == "" || database.DB == nil {
+		return models.AbuseLevelNormal
+	}
+
+	now := time.Now()
+	levelsMu.Lock()
+	cached, ok := levels[creator]
+	levelsMu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.level
+	}
+
+	level := models.AbuseLevelNormal
+	score, err := database.GetAbuseScore(ctx, creator)
+	switch {
+	case err == nil:
+		level = Current(score, HalfLife(), now).Level
+	case !errors.Is(err, storage.ErrNotFound):
+		slog.ErrorContext(ctx, "Failed to load the abuse score", "creator", creator, "error", err)
+		return level
+	}
+
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	// Drop expired levels so many creators don't grow the map forever
+	if len(levels) >= 10000 {
+		for key, entry := range levels {
+			if !now.Before(entry.expires) {
+				delete(levels, key)
+			}
+		}
+	}
+	levels[creator] = cachedLevel{level: level, expires: now.Add(levelTTL)}
+	return level
+}
+
+// Forget drops the level of creator read by this instance, so the next
+// request reads it again
+func Forget(creator string) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	delete(levels, creator)
+}
+
+// Current returns score decayed until now with halfLife, with the level in
+// force
+func Current(score *models.AbuseScore, halfLife time.Duration, now time.Time) models.AbuseScore {
+	current := *score
+	current.Score = Decay(score.Score, now.Sub(score.UpdatedAt), halfLife)
+	current.Level = LevelForScore(current.Score)
+	if score.Override != nil {
+		current.Level = *score.Override
+	}
+	return current
+}
+
+// Decay returns what is left of score after elapsed with halfLife
+func Decay(score float64, elapsed, halfLife time.Duration) float64 {
+	if elapsed <= 0 {
+		return score
+	}
+	return score * math.Pow(0.5, elapsed.Seconds()/halfLife.Seconds())
+}
+
+// LevelForScore returns the level a score reaches
+func LevelForScore(score float64) string {
+	switch {
+	case score >= severeScore:
+		return models.AbuseLevelSevere
+	case score >= highScore:
+		return models.AbuseLevelHigh
+	case score >= elevatedScore:
+		return models.AbuseLevelElevated
+	}
+	return models.AbuseLevelNormal
+}
+
+// AtLeast reports whether level is min or a stricter one
+func AtLeast(level, min string) bool {
+	return rank(level) >= rank(min)
+}
+
+// RateLimitDivisor returns how many times the rate limits of a creator at
+// level are divided
+func RateLimitDivisor(level string) int64 {
+	switch level {
+	case models.AbuseLevelElevated:
+		return 2
+	case models.AbuseLevelHigh:
+		return 4
+	case models.AbuseLevelSevere:
+		return 10
+	}
+	return 1
+}
+
+func rank(level string) int {
+	switch level {
+	case models.AbuseLevelElevated:
+		return 1
+	case models.AbuseLevelHigh:
+		return 2
+	case models.AbuseLevelSevere:
+		return 3
+	}
+	return 0
+}
@@ -0,0 +1,106 @@
+package abuse
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"url-shortener/models"
+)
+
+func TestDecay(t *testing.T) {
+	week := 7 * 24 * time.Hour
+	cases := []struct {
+		elapsed time.Duration
+		want    float64
+	}{
+		{0, 40},
+		{-time.Hour, 40},
+		{week, 20},
+		{2 * week, 10},
+		{week / 2, 40 / math.Sqrt2},
+	}
+	for _, tc := range cases {
+		if got := Decay(40, tc.elapsed, week); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("Decay(40, %v) = %v, want %v", tc.elapsed, got, tc.want)
+		}
+	}
+}
+
+func TestLevelForScore(t *testing.T) {
+	cases := map[float64]string{
+		0:     models.AbuseLevelNormal,
+		9.99:  models.AbuseLevelNormal,
+		10:    models.AbuseLevelElevated,
+		24.9:  models.AbuseLevelElevated,
+		25:    models.AbuseLevelHigh,
+		49:    models.AbuseLevelHigh,
+		50:    models.AbuseLevelSevere,
+		1000:  models.AbuseLevelSevere,
+		-1e-9: models.AbuseLevelNormal,
+	}
+	for score, want := range cases {
+		if got := LevelForScore(score); got != want {
+			t.Errorf("LevelForScore(%v) = %s, want %s", score, got, want)
+		}
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	now := time.Now()
+	score := &models.AbuseScore{Creator: "ip:203.0.113.7", Score: 60, UpdatedAt: now.Add(-time.Hour)}
+
+	// An hour-long half-life halves the severe score to a high one
+	current := Current(score, time.Hour, now)
+	if math.Abs(current.Score-30) > 1e-9 || current.Level != models.AbuseLevelHigh {
+		t.Errorf("Current = %v %s, want 30 high", current.Score, current.Level)
+	}
+	if score.Score != 60 {
+		t.Errorf("Current changed the stored score to %v", score.Score)
+	}
+
+	// Overrides apply whatever the score
+	normal := models.AbuseLevelNormal
+	score.Override = &normal
+	if current := Current(score, time.Hour, now); current.Level != models.AbuseLevelNormal {
+		t.Errorf("Current level = %s with a normal override", current.Level)
+	}
+}
+
+func TestAtLeast(t *testing.T) {
+	if !AtLeast(models.AbuseLevelSevere, models.AbuseLevelHigh) || !AtLeast(models.AbuseLevelHigh, models.AbuseLevelHigh) {
+		t.Error("stricter and equal levels should be at least high")
+	}
+	if AtLeast(models.AbuseLevelElevated, models.AbuseLevelHigh) || AtLeast(models.AbuseLevelNormal, models.AbuseLevelElevated) {
+		t.Error("milder levels should not be at least the stricter one")
+	}
+}
+
+func TestValidCreator(t *testing.T) {
+	for _, creator := range []string{"user:1", "user:42", "ip:203.0.113.7", "ip:2001:db8::1"} {
+		if !ValidCreator(creator) {
+			t.Errorf("ValidCreator(%q) = false", creator)
+		}
+	}
+	for _, creator := range []string{"", "user:", "user:-1", "user:x", "ip:", "ip:example.com", "key:1", "203.0.113.7"} {
+		if ValidCreator(creator) {
+			t.Errorf("ValidCreator(%q) = true", creator)
+		}
+	}
+}
+
+func TestCreator(t *testing.T) {
+	id := uint(7)
+	if got := Creator(&id, "203.0.113.7"); got != "user:7" {
+		t.Errorf("Creator with an owner = %q", got)
+	}
+	if got := Creator(nil, "203.0.113.7"); got != "ip:203.0.113.7" {
+		t.Errorf("Creator without an owner = %q", got)
+	}
+	if got := LinkCreator(&models.URL{OwnerID: &id}); got != "user:7" {
+		t.Errorf("LinkCreator of a link without a creator = %q", got)
+	}
+	if got := LinkCreator(&models.URL{}); got != "" {
+		t.Errorf("LinkCreator of an anonymous link without a creator = %q", got)
+	}
+}
@@ -0,0 +1,118 @@
+// Package artifacts keeps files generated in the background, such as link
+// exports and QR code batches, in an object storage bucket or a local
+// directory, and hands out expiring signed URLs downloading them, so large
+// files are not streamed through the API while the client waits.
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"url-shortener/objectstore"
+)
+
+// Files are generated within GenerationTimeout; those pending for longer
+// were interrupted, e.g. by a restart
+const GenerationTimeout = 30 * time.Minute
+
+// ErrNotConfigured is returned by FromEnv when neither ARTIFACTS_BUCKET nor
+// ARTIFACTS_DIR is set
+var ErrNotConfigured = errors.New("artifact storage is not configured")
+
+// Store keeps artifacts by key
+type Store interface {
+	// Put stores body as key, replacing any artifact with that key
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+	// Delete removes key; deleting a missing artifact succeeds
+	Delete(ctx context.Context, key string) error
+	// DownloadURL returns a URL downloading key as filename until expires
+	// has passed. URLs of the local directory are relative to the API.
+	DownloadURL(key, filename string, expires time.Duration) (string, error)
+}
+
+// The store configured by Init, nil when artifacts are not stored
+var current Store
+
+// Init configures the store from the environment, see FromEnv. Generated
+// files are unavailable when none is configured or the configuration is
+// invalid.
+func Init() {
+	store, err := FromEnv()
+	if errors.Is(err, ErrNotConfigured) {
+		return
+	}
+	if err != nil {
+		slog.Warn("Invalid artifact storage configuration, not storing generated files", "error", err)
+		return
+	}
+	current = store
+}
+
+// Current returns the store configured by Init, or nil
+func Current() Store {
+	return current
+}
+
+// FromEnv reads the store from the environment: the object storage bucket
+// in ARTIFACTS_BUCKET, with the other ARTIFACTS_ settings of
+// objectstore.FromEnv, or else the directory in ARTIFACTS_DIR, whose
+// download URLs are signed with ARTIFACTS_SIGNING_SECRET
+func FromEnv() (Store, error) {
+	config, err := objectstore.FromEnv("ARTIFACTS_")
+	if err == nil {
+		return bucketStore{objectstore.New(config)}, nil
+	}
+	if !errors.Is(err, objectstore.ErrNotConfigured) {
+		return nil, err
+	}
+
+	dir := os.Getenv("ARTIFACTS_DIR")
+	if dir == "" {
+		return nil, ErrNotConfigured
+	}
+	secret := os.Getenv("ARTIFACTS_SIGNING_SECRET")
+	if len(secret) < minSigningSecretLength {
+		return nil, fmt.Errorf("ARTIFACTS_SIGNING_SECRET must be at least %d characters long with ARTIFACTS_DIR", minSigningSecretLength)
+	}
+	return NewDiskStore(dir, secret), nil
+}
+
+// Retention reads ARTIFACT_RETENTION, how long generated files are kept
+// (default 24h)
+func Retention() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("ARTIFACT_RETENTION")); err == nil && value > 0 {
+		return value
+	}
+	return 24 * time.Hour
+}
+
+// URLExpiry reads ARTIFACT_URL_EXPIRY, how long download URLs work
+// (default 15m)
+func URLExpiry() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("ARTIFACT_URL_EXPIRY")); err == nil && value > 0 {
+		return value
+	}
+	return 15 * time.Minute
+}
+
+// bucketStore keeps artifacts in an object storage bucket, downloaded
+// straight from the bucket through presigned URLs
+type bucketStore struct {
+	client *objectstore.Client
+}
+
+func (s bucketStore) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	return s.client.Put(ctx, key, body, contentType)
+}
+
+func (s bucketStore) Delete(ctx context.Context, key string) error {
+	return s.client.Delete(ctx, key)
+}
+
+func (s bucketStore) DownloadURL(key, filename string, expires time.Duration) (string, error) {
+	return s.client.PresignGet(key, filename, expires)
+}
@@ -0,0 +1,125 @@
+package artifacts
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Shortest ARTIFACTS_SIGNING_SECRET accepted
+const minSigningSecretLength = 32
+
+// Path the API serves artifacts of a DiskStore on, followed by their key
+const DownloadPath = "/artifacts/"
+
+// Errors of DiskStore.Open
+var (
+	ErrInvalidSignature = errors.New("invalid download signature")
+	ErrURLExpired       = errors.New("download URL has expired")
+)
+
+// DiskStore keeps artifacts in a local directory, or one shared by every
+// instance such as a network volume, downloaded from the API through URLs
+// signed with HMAC-SHA256
+type DiskStore struct {
+	dir    string
+	secret []byte
+	now    func() time.Time
+}
+
+// NewDiskStore returns a store keeping artifacts under dir, signing their
+// download URLs with secret
+func NewDiskStore(dir, secret string) *DiskStore {
+	return &DiskStore{dir: dir, secret: []byte(secret), now: time.Now}
+}
+
+// Put writes body to a temporary file renamed into place, so a download
+// never sees half an artifact
+func (s *DiskStore) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(body); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+func (s *DiskStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// DownloadURL returns the path of key under DownloadPath with its expiry,
+// filename and signature in the query
+func (s *DiskStore) DownloadURL(key, filename string, expires time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	expiry := strconv.FormatInt(s.now().Add(expires).Unix(), 10)
+	query := url.Values{
+		"expires":   {expiry},
+		"filename":  {filename},
+		"signature": {s.sign(key, expiry, filename)},
+	}
+	return DownloadPath + (&url.URL{Path: key}).EscapedPath() + "?" + query.Encode(), nil
+}
+
+// Open checks the query of a download URL of key, returning the path of
+// the artifact and the filename to save it as
+func (s *DiskStore) Open(key string, query url.Values) (string, string, error) {
+	expiry, filename := query.Get("expires"), query.Get("filename")
+	if !hmac.Equal([]byte(query.Get("signature")), []byte(s.sign(key, expiry, filename))) {
+		return "", "", ErrInvalidSignature
+	}
+	if expires, err := strconv.ParseInt(expiry, 10, 64); err != nil || s.now().Unix() > expires {
+		return "", "", ErrURLExpired
+	}
+	path, err := s.path(key)
+	if err != nil {
+		return "", "", err
+	}
+	return path, filename, nil
+}
+
+func (s *DiskStore) sign(key, expiry, filename string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + expiry + "\n" + filename))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path returns where key is kept, refusing keys reaching out of the directory
+func (s *DiskStore) path(key string) (string, error) {
+	if key == "" || !filepath.IsLocal(key) || strings.Contains(key, `\`) {
+		return "", errors.New("invalid artifact key " + strconv.Quote(key))
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
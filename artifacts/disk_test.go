@@ -0,0 +1,69 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiskStoreDownloadURL(t *testing.T) {
+	store := NewDiskStore(t.TempDir(), strings.Repeat("s", minSigningSecretLength))
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	key := "exports/2024-05-01/abc/links.csv"
+	if err := store.Put(context.Background(), key, []byte("short_code\n"), "text/csv"); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := store.DownloadURL(key, "links.csv", 15*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	download, _ := url.Parse(raw)
+	if download.Path != DownloadPath+key {
+		t.Fatalf("download URL %s is not under %s", raw, DownloadPath+key)
+	}
+
+	path, filename, err := store.Open(key, download.Query())
+	if err != nil || filename != "links.csv" {
+		t.Fatalf("Open = %q, %v, want links.csv", filename, err)
+	}
+	if body, _ := os.ReadFile(path); string(body) != "short_code\n" {
+		t.Errorf("artifact holds %q", body)
+	}
+
+	// Another filename or key breaks the signature
+	tampered := download.Query()
+	tampered.Set("filename", "other.csv")
+	if _, _, err := store.Open(key, tampered); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Open with another filename = %v, want ErrInvalidSignature", err)
+	}
+	if _, _, err := store.Open("exports/2024-05-01/abc/other.csv", download.Query()); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Open of another key = %v, want ErrInvalidSignature", err)
+	}
+
+	now = now.Add(16 * time.Minute)
+	if _, _, err := store.Open(key, download.Query()); !errors.Is(err, ErrURLExpired) {
+		t.Errorf("Open after expiry = %v, want ErrURLExpired", err)
+	}
+
+	if err := store.Delete(context.Background(), key); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(context.Background(), key); err != nil {
+		t.Errorf("deleting a missing artifact = %v", err)
+	}
+}
+
+func TestDiskStoreRefusesKeysOutsideItsDirectory(t *testing.T) {
+	store := NewDiskStore(t.TempDir(), strings.Repeat("s", minSigningSecretLength))
+	for _, key := range []string{"", "../secret", "/etc/passwd", "a/../../b"} {
+		if err := store.Put(context.Background(), key, nil, "text/plain"); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Package autotag applies the tag rules set by admins, adding tags to links
+// based on their destination when they are created or backfilled
+package autotag
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/domains"
+	"url-shortener/models"
+	"url-shortener/utils"
+)
+
+// How long loaded rules are reused before being reloaded from the database
+const rulesCacheTTL = time.Minute
+
+// Most tags a link may carry, as accepted from clients
+const maxTags = 20
+
+type compiledRule struct {
+	rule   models.TagRule
+	regexp *regexp.Regexp
+}
+
+var (
+	mu       sync.RWMutex
+	rules    []compiledRule
+	loadedAt time.Time
+)
+
+// RuleSet is the enabled rules as loaded at one point in time, so a request
+// creating several links tags all of them alike
+type RuleSet []compiledRule
+
+// Current returns the enabled rules, reloading them once they are older than
+// a minute
+func Current() RuleSet {
+	return RuleSet(currentRules())
+}
+
+// Apply returns tags with those of the rules matching rawURL added, in rule
+// order, skipping the tags already there. Links keep at most 20 tags, so
+// tags past that are dropped.
+func (s RuleSet) Apply(rawURL string, tags []string) []string {
+	for _, compiled := range s {
+		if len(tags) >= maxTags {
+			break
+		}
+		if compiled.matches(rawURL) && !slices.Contains(tags, compiled.rule.Tag) {
+			tags = append(tags[:len(tags):len(tags)], compiled.rule.Tag)
+		}
+	}
+	return tags
+}
+
+// Validate checks a rule's pattern, normalizing domains
+func Validate(rule *models.TagRule) error {
+	switch rule.Type {
+	case models.TagRuleRegex:
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("invalid regex pattern: %v", err)
+		}
+	case models.TagRuleDomain:
+		wildcard := strings.HasPrefix(rule.Pattern, "*.")
+		domain, err := domains.Normalize(strings.TrimPrefix(rule.Pattern, "*."))
+		if err != nil {
+			return err
+		}
+		if rule.Pattern = domain; wildcard {
+			rule.Pattern = "*." + domain
+		}
+	}
+	return nil
+}
+
+// Invalidate drops the cached rules so the next evaluation reloads them
+func Invalidate() {
+	mu.Lock()
+	loadedAt = time.Time{}
+	mu.Unlock()
+}
+
+func currentRules() []compiledRule {
+	mu.RLock()
+	if time.Since(loadedAt) < rulesCacheTTL || database.DB == nil {
+		defer mu.RUnlock()
+		return rules
+	}
+	mu.RUnlock()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	var stored []models.TagRule
+	if err := database.DB.Where("enabled = ?", true).Order("id").Find(&stored).Error; err != nil {
+		slog.Error("Failed to load tag rules, using previous set", "error", err)
+		return rules
+	}
+
+	loaded := make([]compiledRule, 0, len(stored))
+	for _, rule := range stored {
+		compiled := compiledRule{rule: rule}
+		if rule.Type == models.TagRuleRegex {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				slog.Warn("Skipping tag rule with invalid regex", "rule_id", rule.ID, "error", err)
+				continue
+			}
+			compiled.regexp = re
+		}
+		loaded = append(loaded, compiled)
+	}
+
+	rules = loaded
+	loadedAt = time.Now()
+	return rules
+}
+
+func (r compiledRule) matches(rawURL string) bool {
+	switch r.rule.Type {
+	case models.TagRuleRegex:
+		return r.regexp.MatchString(rawURL)
+	case models.TagRuleDomain:
+		if parent, ok := strings.CutPrefix(r.rule.Pattern, "*."); ok {
+			u, err := url.Parse(rawURL)
+			return err == nil && strings.HasSuffix(strings.ToLower(u.Hostname()), "."+parent)
+		}
+		return utils.URLMatchesDomain(rawURL, r.rule.Pattern)
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package autotag
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"url-shortener/models"
+)
+
+func TestApply(t *testing.T) {
+	rules := RuleSet{
+		{rule: models.TagRule{Type: models.TagRuleDomain, Pattern: "*.shopify.com", Tag: "store"}},
+		{rule: models.TagRule{Type: models.TagRuleDomain, Pattern: "example.com", Tag: "example"}},
+		{rule: models.TagRule{Type: models.TagRuleRegex, Pattern: `/blog/`, Tag: "blog"}, regexp: regexp.MustCompile(`/blog/`)},
+	}
+
+	tests := []struct {
+		url  string
+		tags []string
+		want []string
+	}{
+		{url: "https://acme.shopify.com/products/1", want: []string{"store"}},
+		{url: "https://shopify.com/pricing", want: nil},
+		{url: "https://www.example.com/blog/post", tags: []string{"news"}, want: []string{"news", "example", "blog"}},
+		{url: "https://example.com/", tags: []string{"example"}, want: []string{"example"}},
+		{url: "https://other.org/", tags: []string{"news"}, want: []string{"news"}},
+	}
+	for _, test := range tests {
+		if got := rules.Apply(test.url, test.tags); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Apply(%q, %v) = %v, want %v", test.url, test.tags, got, test.want)
+		}
+	}
+
+	// Tags given by the request are left as they are
+	given := make([]string, 1, 4)
+	given[0] = "news"
+	rules.Apply("https://example.com/", given)
+	if given[:2][1] != "" {
+		t.Errorf("Apply changed the tags it was given: %v", given[:2])
+	}
+
+	full := make([]string, maxTags)
+	for i := range full {
+		full[i] = fmt.Sprintf("tag%d", i)
+	}
+	if got := rules.Apply("https://example.com/", full); len(got) != maxTags {
+		t.Errorf("Apply to %d tags returned %d tags", maxTags, len(got))
+	}
+}
+
+func TestValidateNormalizesDomains(t *testing.T) {
+	rule := models.TagRule{Type: models.TagRuleDomain, Pattern: "*.Shopify.com."}
+	if err := Validate(&rule); err != nil || rule.Pattern != "*.shopify.com" {
+		t.Errorf("Validate(*.Shopify.com.) = %v with pattern %q, want *.shopify.com", err, rule.Pattern)
+	}
+
+	for _, rule := range []models.TagRule{
+		{Type: models.TagRuleDomain, Pattern: "https://shopify.com/"},
+		{Type: models.TagRuleRegex, Pattern: "("},
+	} {
+		if err := Validate(&rule); err == nil {
+			t.Errorf("Validate(%s %q) = nil, want an error", rule.Type, rule.Pattern)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Package background runs fire-and-forget work started while handling a
+// request, such as recording when an API key was last used, so shutdown can
+// wait for it before closing the connections it needs.
+package background
+
+import (
+	"sync"
+	"time"
+)
+
+var pending sync.WaitGroup
+
+// Go runs fn in its own goroutine, tracked until it returns
+func Go(fn func()) {
+	pending.Add(1)
+	go func() {
+		defer pending.Done()
+		fn()
+	}()
+}
+
+// Wait waits up to timeout for the work started with Go to finish,
+// reporting whether it did
+func Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
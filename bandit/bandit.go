@@ -0,0 +1,81 @@
+// Package bandit allocates traffic between link variants with Thompson
+// sampling: each variant's conversion rate is modelled as a Beta
+// distribution over its clicks and conversions, and every visitor goes to
+// the variant whose sampled rate is highest. Variants that convert better
+// get more traffic as evidence builds up, while uncertain ones keep being
+// explored.
+package bandit
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Arm is the evidence for one variant
+type Arm struct {
+	Trials    int64 // clicks
+	Successes int64 // conversions
+}
+
+// Choose samples every arm's conversion rate and returns the index of the
+// highest, or -1 without arms
+func Choose(arms []Arm) int {
+	best, bestRate := -1, -1.0
+	for i, arm := range arms {
+		if rate := sampleRate(arm); rate > bestRate {
+			best, bestRate = i, rate
+		}
+	}
+	return best
+}
+
+// ProbabilityBest estimates with draws samples the probability that each
+// arm has the highest conversion rate. It is also the share of traffic
+// Choose currently sends to each arm.
+func ProbabilityBest(arms []Arm, draws int) []float64 {
+	wins := make([]float64, len(arms))
+	if len(arms) == 0 || draws <= 0 {
+		return wins
+	}
+	for i := 0; i < draws; i++ {
+		wins[Choose(arms)]++
+	}
+	for i := range wins {
+		wins[i] /= float64(draws)
+	}
+	return wins
+}
+
+// sampleRate draws from Beta(successes+1, failures+1), the posterior of
+// the conversion rate under a uniform prior
+func sampleRate(arm Arm) float64 {
+	successes := float64(arm.Successes)
+	// Conversions can outnumber counted clicks, e.g. when a pixel fires twice
+	failures := math.Max(float64(arm.Trials-arm.Successes), 0)
+	return sampleBeta(successes+1, failures+1)
+}
+
+func sampleBeta(a, b float64) float64 {
+	x := sampleGamma(a)
+	y := sampleGamma(b)
+	return x / (x + y)
+}
+
+// sampleGamma draws from Gamma(shape, 1) for shape >= 1 with the
+// Marsaglia-Tsang method
+func sampleGamma(shape float64) float64 {
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rand.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rand.Float64()
+		if u < 1-0.0331*x*x*x*x || math.Log(u) < 0.5*x*x+d*(1-v+math.Log(v)) {
+			return d * v
+		}
+	}
+}
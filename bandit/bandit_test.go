@@ -0,0 +1,50 @@
+package bandit
+
+import (
+	"math"
+	"testing"
+)
+
+func TestProbabilityBestFavorsBetterArm(t *testing.T) {
+	arms := []Arm{
+		{Trials: 1000, Successes: 20},
+		{Trials: 1000, Successes: 60},
+	}
+	probabilities := ProbabilityBest(arms, 5000)
+	if probabilities[1] < 0.99 {
+		t.Errorf("probability the better arm is best = %.3f, want above 0.99", probabilities[1])
+	}
+	if sum := probabilities[0] + probabilities[1]; math.Abs(sum-1) > 1e-9 {
+		t.Errorf("probabilities sum to %f, want 1", sum)
+	}
+}
+
+func TestProbabilityBestWithoutEvidence(t *testing.T) {
+	probabilities := ProbabilityBest([]Arm{{}, {}, {}}, 30000)
+	for i, probability := range probabilities {
+		if math.Abs(probability-1.0/3) > 0.03 {
+			t.Errorf("arm %d: probability %.3f, want about 1/3 without evidence", i, probability)
+		}
+	}
+}
+
+func TestChoose(t *testing.T) {
+	if got := Choose(nil); got != -1 {
+		t.Errorf("Choose(nil) = %d, want -1", got)
+	}
+	// More conversions than clicks must not break sampling
+	if got := Choose([]Arm{{Trials: 1, Successes: 3}}); got != 0 {
+		t.Errorf("Choose = %d, want 0", got)
+	}
+}
+
+func TestSampleBetaMean(t *testing.T) {
+	const draws = 20000
+	sum := 0.0
+	for i := 0; i < draws; i++ {
+		sum += sampleBeta(3, 7)
+	}
+	if mean := sum / draws; math.Abs(mean-0.3) > 0.01 {
+		t.Errorf("mean of Beta(3, 7) samples = %.3f, want about 0.3", mean)
+	}
+}
@@ -0,0 +1,55 @@
+package buildinfo
+
+import (
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+// Set at build time with -ldflags "-X url-shortener/buildinfo.Version=...
+// -X url-shortener/buildinfo.Commit=... -X url-shortener/buildinfo.BuildDate=..."
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = "" // RFC 3339
+)
+
+// StartedAt is when the process started
+var StartedAt = time.Now()
+
+// Instance identifies this replica in metrics: INSTANCE_ID when set (e.g.
+// the pod name), otherwise the host name
+var Instance = instanceID()
+
+func instanceID() string {
+	if id := os.Getenv("INSTANCE_ID"); id != "" {
+		return id
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+	return "unknown"
+}
+
+func init() {
+	if Commit != "" && BuildDate != "" {
+		return
+	}
+
+	// Fall back to the VCS details Go embeds when building from a checkout
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && Commit == "":
+				Commit = setting.Value
+			case setting.Key == "vcs.time" && BuildDate == "":
+				BuildDate = setting.Value
+			}
+		}
+	}
+}
+
+// Uptime returns how long the process has been running
+func Uptime() time.Duration {
+	return time.Since(StartedAt)
+}
@@ -0,0 +1,233 @@
+package cache
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"url-shortener/models"
+	"url-shortener/storage"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltCache is the bucket of the cache in a Bolt file
+var boltCache = []byte("cache")
+
+// errBoltMiss is the cause of misses of the Bolt cache
+var errBoltMiss = errors.New("cache miss")
+
+// NewBoltStore returns a Store keeping the cache in db, for running without
+// Redis (DB_DRIVER=embedded). Keys are those of Redis and expire after the
+// same TTLs; expired keys read as misses and are overwritten by the next
+// write.
+func NewBoltStore(db *bolt.DB) (Store, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltCache)
+		return err
+	})
+	return boltStore{db: db}, err
+}
+
+// boltStore is the Store over a Bolt file. Values are stored after their
+// expiry time in unix nanoseconds.
+type boltStore struct {
+	db *bolt.DB
+}
+
+func (s boltStore) GetRedirectEntry(shortCode string) (*RedirectEntry, error) {
+	var entry RedirectEntry
+	if err := s.getValue(RedirectKey+shortCode, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s boltStore) CacheRedirectEntry(shortCode string, entry *RedirectEntry) error {
+	return s.setValue(RedirectKey+shortCode, entry, DefaultCacheTTL)
+}
+
+func (s boltStore) GetURLMapping(shortCode string) (*models.URL, error) {
+	var cached cachedURL
+	if err := s.getValue(URLMappingKey+shortCode, &cached); err != nil {
+		return nil, err
+	}
+	return cached.toModel(shortCode), nil
+}
+
+func (s boltStore) CacheURLMapping(shortCode string, urlData *models.URL) error {
+	return s.setValue(URLMappingKey+shortCode, newCachedURL(urlData), DefaultCacheTTL)
+}
+
+func (s boltStore) GetShortCodeForOriginalURL(ownerID *uint, originalURL string) (string, error) {
+	value, err := s.get(originalURLKey(ownerID, originalURL))
+	return string(value), err
+}
+
+func (s boltStore) CacheOriginalURLMapping(ownerID *uint, originalURL string, shortCode string) error {
+	return s.set(originalURLKey(ownerID, originalURL), []byte(shortCode), DefaultCacheTTL)
+}
+
+func (s boltStore) GetURLStats(shortCode string) (*models.StatsResponse, error) {
+	var stats models.StatsResponse
+	if err := s.getValue(URLStatsKey+shortCode, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (s boltStore) CacheURLStats(shortCode string, stats *models.StatsResponse) error {
+	return s.setValue(URLStatsKey+shortCode, stats, StatsCacheTTL)
+}
+
+func (s boltStore) GetClickCount(shortCode string) (int64, error) {
+	return s.getCounter(URLClicksKey + shortCode)
+}
+
+func (s boltStore) ConsumeRemainingClick(shortCode string) (int64, error) {
+	var remaining int64
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltCache)
+		key := []byte(RemainingClicksKey + shortCode)
+		expiresAt, value, ok := readEntry(bucket.Get(key), time.Now())
+		if !ok {
+			return storage.Wrap(storage.ErrNotFound, errBoltMiss)
+		}
+		remaining = int64(binary.BigEndian.Uint64(value)) - 1
+		return bucket.Put(key, newEntry(expiresAt, counterValue(remaining)))
+	})
+	return remaining, boltCacheError(err)
+}
+
+func (s boltStore) SeedRemainingClicks(shortCode string, remaining int) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltCache)
+		key := []byte(RemainingClicksKey + shortCode)
+		now := time.Now()
+		// Unless a concurrent request seeded the counter first
+		if _, _, ok := readEntry(bucket.Get(key), now); ok {
+			return nil
+		}
+		return bucket.Put(key, newEntry(now.Add(DefaultCacheTTL), counterValue(int64(remaining))))
+	})
+	return boltCacheError(err)
+}
+
+func (s boltStore) GetRemainingClicks(shortCode string) (int64, error) {
+	return s.getCounter(RemainingClicksKey + shortCode)
+}
+
+func (s boltStore) IncrementVelocity(shortCode string, windowStart time.Time, window time.Duration) (int64, error) {
+	var count int64
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltCache)
+		key := []byte(VelocityKey + shortCode + ":" + windowStart.Format("20060102T150405"))
+		now := time.Now()
+		// A little past the window, like the Redis counter
+		expiresAt := now.Add(window + 5*time.Second)
+		if _, value, ok := readEntry(bucket.Get(key), now); ok {
+			count = int64(binary.BigEndian.Uint64(value))
+		}
+		count++
+		return bucket.Put(key, newEntry(expiresAt, counterValue(count)))
+	})
+	return count, boltCacheError(err)
+}
+
+func (s boltStore) InvalidateCache(shortCode string) {
+	s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltCache)
+		for _, prefix := range []string{RedirectKey, URLMappingKey, URLStatsKey, URLClicksKey} {
+			if err := bucket.Delete([]byte(prefix + shortCode)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// get returns the unexpired value of key
+func (s boltStore) get(key string) ([]byte, error) {
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		_, stored, ok := readEntry(tx.Bucket(boltCache).Get([]byte(key)), time.Now())
+		if !ok {
+			return storage.Wrap(storage.ErrNotFound, errBoltMiss)
+		}
+		// Values are only valid during the transaction
+		value = append([]byte(nil), stored...)
+		return nil
+	})
+	return value, boltCacheError(err)
+}
+
+// set stores value under key for ttl
+func (s boltStore) set(key string, value []byte, ttl time.Duration) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltCache).Put([]byte(key), newEntry(time.Now().Add(ttl), value))
+	})
+	return boltCacheError(err)
+}
+
+// getValue decodes the value of key into v with the value codec
+func (s boltStore) getValue(key string, v interface{}) error {
+	data, err := s.get(key)
+	if err != nil {
+		return err
+	}
+	return valueCodec.Unmarshal(data, v)
+}
+
+// setValue stores v under key for ttl, encoded with the value codec
+func (s boltStore) setValue(key string, v interface{}, ttl time.Duration) error {
+	data, err := valueCodec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.set(key, data, ttl)
+}
+
+// getCounter returns the counter stored under key
+func (s boltStore) getCounter(key string) (int64, error) {
+	value, err := s.get(key)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(value)), nil
+}
+
+// newEntry prefixes value with its expiry time
+func newEntry(expiresAt time.Time, value []byte) []byte {
+	entry := make([]byte, 8, 8+len(value))
+	binary.BigEndian.PutUint64(entry, uint64(expiresAt.UnixNano()))
+	return append(entry, value...)
+}
+
+// readEntry splits a stored entry, reporting false for a missing or
+// expired one
+func readEntry(entry []byte, now time.Time) (expiresAt time.Time, value []byte, ok bool) {
+	if len(entry) < 8 {
+		return time.Time{}, nil, false
+	}
+	expiresAt = time.Unix(0, int64(binary.BigEndian.Uint64(entry)))
+	if !now.Before(expiresAt) {
+		return time.Time{}, nil, false
+	}
+	return expiresAt, entry[8:], true
+}
+
+// counterValue encodes a counter
+func counterValue(count int64) []byte {
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, uint64(count))
+	return value
+}
+
+// boltCacheError classifies an error of the Bolt cache like redisError
+// does those of Redis
+func boltCacheError(err error) error {
+	if errors.Is(err, bolt.ErrDatabaseNotOpen) || errors.Is(err, bolt.ErrTimeout) {
+		return storage.Wrap(storage.ErrBackendUnavailable, err)
+	}
+	return err
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"url-shortener/models"
+	"url-shortener/storage"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func newTestBoltStore(t *testing.T) Store {
+	t.Helper()
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "cache.db"), 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	store, err := NewBoltStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestBoltStoreValues(t *testing.T) {
+	store := newTestBoltStore(t)
+
+	if _, err := store.GetRedirectEntry("abc"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("GetRedirectEntry() before caching = %v, want a miss", err)
+	}
+	entry := NewRedirectEntry(&models.URL{ID: 7, OriginalURL: "https://example.com/"})
+	if err := store.CacheRedirectEntry("abc", entry); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.GetRedirectEntry("abc"); err != nil || got.URLID != 7 || got.Destination != "https://example.com/" {
+		t.Errorf("GetRedirectEntry() = %+v, %v", got, err)
+	}
+
+	owner := uint(3)
+	if err := store.CacheOriginalURLMapping(&owner, "https://example.com/", "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if code, err := store.GetShortCodeForOriginalURL(&owner, "https://example.com/"); err != nil || code != "abc" {
+		t.Errorf("GetShortCodeForOriginalURL() = %q, %v", code, err)
+	}
+	if _, err := store.GetShortCodeForOriginalURL(nil, "https://example.com/"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetShortCodeForOriginalURL() of another owner = %v, want a miss", err)
+	}
+
+	store.InvalidateCache("abc")
+	if _, err := store.GetRedirectEntry("abc"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetRedirectEntry() after invalidating = %v, want a miss", err)
+	}
+}
+
+func TestBoltStoreExpiresValues(t *testing.T) {
+	bolted := newTestBoltStore(t).(boltStore)
+
+	if err := bolted.set("key", []byte("value"), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := bolted.get("key"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("get() after the TTL = %v, want a miss", err)
+	}
+}
+
+func TestBoltStoreRemainingClicks(t *testing.T) {
+	store := newTestBoltStore(t)
+
+	if _, err := store.ConsumeRemainingClick("limited"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("unseeded counter: err = %v, want storage.ErrNotFound", err)
+	}
+	if err := store.SeedRemainingClicks("limited", 3); err != nil {
+		t.Fatal(err)
+	}
+	// A second seed does not reset the counter
+	if err := store.SeedRemainingClicks("limited", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if remaining, err := store.ConsumeRemainingClick("limited"); err == nil && remaining >= 0 {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed.Load() != 3 {
+		t.Errorf("%d redirects allowed, want 3", allowed.Load())
+	}
+	if remaining, err := store.GetRemainingClicks("limited"); err != nil || remaining != -17 {
+		t.Errorf("GetRemainingClicks() = %d, %v, want -17", remaining, err)
+	}
+}
+
+func TestBoltStoreIncrementVelocity(t *testing.T) {
+	store := newTestBoltStore(t)
+	window := time.Minute
+	start := time.Now().Truncate(window)
+
+	for want := int64(1); want <= 3; want++ {
+		if got, err := store.IncrementVelocity("fast", start, window); err != nil || got != want {
+			t.Errorf("IncrementVelocity() = %d, %v, want %d", got, err, want)
+		}
+	}
+	if got, _ := store.IncrementVelocity("fast", start.Add(window), window); got != 1 {
+		t.Errorf("IncrementVelocity() in the next window = %d, want 1", got)
+	}
+}
@@ -0,0 +1,203 @@
+package cache
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Click increments counted in Redis but not yet written to the database,
+// shared by every instance. Fields are u:<url id> and v:<variant id>.
+const (
+	PendingClicksKey  = "clicks:pending"
+	ClickFlushLockKey = "clicks:flush-lock" // held by the instance flushing pending clicks
+)
+
+// Subtracts flushed increments, dropping fields that reach zero so the hash
+// only holds links with clicks still to write
+var acknowledgeClicks = redis.NewScript(`
+for i = 1, #ARGV, 2 do
+	if redis.call('HINCRBY', KEYS[1], ARGV[i], -tonumber(ARGV[i + 1])) <= 0 then
+		redis.call('HDEL', KEYS[1], ARGV[i])
+	end
+end
+return 0`)
+
+// Removes fields and returns their values, nil for missing fields
+var takeFields = redis.NewScript(`
+local taken = {}
+for i, field in ipairs(ARGV) do
+	taken[i] = redis.call('HGET', KEYS[1], field)
+	redis.call('HDEL', KEYS[1], field)
+end
+return taken`)
+
+// Releases the flush lock only if this instance still holds it
+var releaseLock = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+// RecordPendingClick increments a link's click counter and its pending
+// increments, and those of its variant when variantID is not 0, in one
+// round trip
+func RecordPendingClick(shortCode string, urlID, variantID uint) error {
+	if RedisClient == nil {
+		return ErrNotConnected
+	}
+
+	_, err := RedisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Incr(ctx, URLClicksKey+shortCode)
+		pipe.HIncrBy(ctx, PendingClicksKey, pendingURLField(urlID), 1)
+		if variantID != 0 {
+			pipe.HIncrBy(ctx, PendingClicksKey, pendingVariantField(variantID), 1)
+		}
+		return nil
+	})
+	return redisError(err)
+}
+
+// PendingClicks returns the pending increments per link and per variant
+func PendingClicks() (urls, variants map[uint]int64, err error) {
+	if RedisClient == nil {
+		return nil, nil, ErrNotConnected
+	}
+
+	fields, err := RedisClient.HGetAll(ctx, PendingClicksKey).Result()
+	if err != nil {
+		return nil, nil, redisError(err)
+	}
+	urls = make(map[uint]int64)
+	variants = make(map[uint]int64)
+	for field, value := range fields {
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || count <= 0 {
+			continue
+		}
+		kind, rawID, _ := strings.Cut(field, ":")
+		id, err := strconv.ParseUint(rawID, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch kind {
+		case "u":
+			urls[uint(id)] = count
+		case "v":
+			variants[uint(id)] = count
+		}
+	}
+	return urls, variants, nil
+}
+
+// PendingURLClicks returns the pending increments of the given links,
+// omitting links without any
+func PendingURLClicks(urlIDs []uint) (map[uint]int64, error) {
+	if RedisClient == nil {
+		return nil, ErrNotConnected
+	}
+	if len(urlIDs) == 0 {
+		return nil, nil
+	}
+
+	fields := make([]string, len(urlIDs))
+	for i, id := range urlIDs {
+		fields[i] = pendingURLField(id)
+	}
+	values, err := RedisClient.HMGet(ctx, PendingClicksKey, fields...).Result()
+	if err != nil {
+		return nil, redisError(err)
+	}
+
+	pending := make(map[uint]int64, len(values))
+	for i, value := range values {
+		if s, ok := value.(string); ok {
+			if count, err := strconv.ParseInt(s, 10, 64); err == nil && count > 0 {
+				pending[urlIDs[i]] = count
+			}
+		}
+	}
+	return pending, nil
+}
+
+// TakePendingClicks removes the pending increments of a link and its
+// variants, returning them, so they are never flushed
+func TakePendingClicks(urlID uint, variantIDs []uint) (url int64, variants map[uint]int64, err error) {
+	if RedisClient == nil {
+		return 0, nil, ErrNotConnected
+	}
+
+	fields := []interface{}{pendingURLField(urlID)}
+	for _, id := range variantIDs {
+		fields = append(fields, pendingVariantField(id))
+	}
+	values, err := takeFields.Run(ctx, RedisClient, []string{PendingClicksKey}, fields...).Slice()
+	if err != nil {
+		return 0, nil, redisError(err)
+	}
+
+	variants = make(map[uint]int64)
+	for i, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		count, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || count <= 0 {
+			continue
+		}
+		if i == 0 {
+			url = count
+		} else {
+			variants[variantIDs[i-1]] = count
+		}
+	}
+	return url, variants, nil
+}
+
+// AcknowledgePendingClicks subtracts increments written to the database
+func AcknowledgePendingClicks(urls, variants map[uint]int64) error {
+	if RedisClient == nil {
+		return ErrNotConnected
+	}
+
+	args := make([]interface{}, 0, 2*(len(urls)+len(variants)))
+	for id, count := range urls {
+		args = append(args, pendingURLField(id), count)
+	}
+	for id, count := range variants {
+		args = append(args, pendingVariantField(id), count)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	return redisError(acknowledgeClicks.Run(ctx, RedisClient, []string{PendingClicksKey}, args...).Err())
+}
+
+// LockClickFlush takes the lock allowing one instance at a time to flush
+// pending clicks, for at most ttl. It reports false when another instance
+// holds it.
+func LockClickFlush(owner string, ttl time.Duration) (bool, error) {
+	if RedisClient == nil {
+		return false, ErrNotConnected
+	}
+	return redisResult(RedisClient.SetNX(ctx, ClickFlushLockKey, owner, ttl).Result())
+}
+
+// UnlockClickFlush releases the lock taken by LockClickFlush
+func UnlockClickFlush(owner string) error {
+	if RedisClient == nil {
+		return ErrNotConnected
+	}
+	return redisError(releaseLock.Run(ctx, RedisClient, []string{ClickFlushLockKey}, owner).Err())
+}
+
+func pendingURLField(id uint) string {
+	return "u:" + strconv.FormatUint(uint64(id), 10)
+}
+
+func pendingVariantField(id uint) string {
+	return "v:" + strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Codec encodes values stored in the cache
+type Codec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec encodes cache values with encoding/json
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string { return "json" }
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// MsgpackCodec encodes cache values as msgpack, which is smaller and
+// allocates less than JSON on the redirect and stats paths (see
+// codec_test.go). Struct fields use their `codec` tag, falling back to `json`.
+type MsgpackCodec struct{}
+
+var (
+	msgpackHandle codec.MsgpackHandle
+
+	// Encoders and decoders are expensive to create, so they are reused
+	msgpackEncoders = sync.Pool{New: func() interface{} {
+		return codec.NewEncoderBytes(nil, &msgpackHandle)
+	}}
+	msgpackDecoders = sync.Pool{New: func() interface{} {
+		return codec.NewDecoderBytes(nil, &msgpackHandle)
+	}}
+)
+
+func (MsgpackCodec) Name() string { return "msgpack" }
+
+func (MsgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	encoder := msgpackEncoders.Get().(*codec.Encoder)
+	defer msgpackEncoders.Put(encoder)
+
+	data := make([]byte, 0, 256)
+	encoder.ResetBytes(&data)
+	err := encoder.Encode(v)
+	return data, err
+}
+
+func (MsgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	decoder := msgpackDecoders.Get().(*codec.Decoder)
+	defer msgpackDecoders.Put(decoder)
+
+	decoder.ResetBytes(data)
+	return decoder.Decode(v)
+}
+
+// valueCodec encodes URL mappings, redirect entries, stats and branded
+// domains, as set by CACHE_CODEC. Values written by a different codec fail
+// to decode and are treated as cache misses.
+var valueCodec Codec = MsgpackCodec{}
@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/models"
+)
+
+var benchmarkCodecs = []Codec{JSONCodec{}, MsgpackCodec{}}
+
+func benchmarkRedirectEntry() *RedirectEntry {
+	return &RedirectEntry{
+		URLID:       42,
+		Destination: "https://example.com/landing?utm_source=newsletter&utm_medium=email",
+		StatusCode:  301,
+		ExpiresAt:   time.Now().Add(24 * time.Hour).Unix(),
+		Flags:       RedirectNoIndex | RedirectNoEvents | RedirectNoCount,
+		UTM:         "utm_campaign=spring_sale",
+		Rules: []models.RoutingRule{{
+			Conditions: []models.RoutingCondition{{Field: models.RoutingFieldCountry, Op: models.RoutingOpIn, Values: []string{"US"}}},
+			Action:     models.RoutingAction{Type: models.RoutingActionRedirect, URL: "https://example.com/us"},
+		}},
+	}
+}
+
+func benchmarkStats() *models.StatsResponse {
+	return &models.StatsResponse{
+		OriginalURL: "https://example.com/landing?utm_source=newsletter&utm_medium=email",
+		ShortCode:   "aB3dE9",
+		ClickCount:  123456,
+		CreatedAt:   time.Now(),
+	}
+}
+
+func TestCodecsRoundTrip(t *testing.T) {
+	for _, codec := range benchmarkCodecs {
+		t.Run(codec.Name(), func(t *testing.T) {
+			entry := benchmarkRedirectEntry()
+			data, err := codec.Marshal(entry)
+			if err != nil {
+				t.Fatalf("marshal redirect entry: %v", err)
+			}
+			var decodedEntry RedirectEntry
+			if err := codec.Unmarshal(data, &decodedEntry); err != nil {
+				t.Fatalf("unmarshal redirect entry: %v", err)
+			}
+			if !reflect.DeepEqual(decodedEntry, *entry) {
+				t.Errorf("redirect entry = %+v, want %+v", decodedEntry, *entry)
+			}
+
+			stats := benchmarkStats()
+			data, err = codec.Marshal(stats)
+			if err != nil {
+				t.Fatalf("marshal stats: %v", err)
+			}
+			var decodedStats models.StatsResponse
+			if err := codec.Unmarshal(data, &decodedStats); err != nil {
+				t.Fatalf("unmarshal stats: %v", err)
+			}
+			if decodedStats.ShortCode != stats.ShortCode || decodedStats.ClickCount != stats.ClickCount ||
+				!decodedStats.CreatedAt.Equal(stats.CreatedAt) {
+				t.Errorf("stats = %+v, want %+v", decodedStats, *stats)
+			}
+		})
+	}
+}
+
+func TestPayloadCompressionRoundTrip(t *testing.T) {
+	data := []byte(strings.Repeat("https://bucket.s3.amazonaws.com/object?X-Amz-Signature=abc", 100))
+
+	payload, err := encodePayload(data)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if !strings.HasPrefix(payload, compressedPrefix) || len(payload) >= len(data) {
+		t.Errorf("expected a compressed payload, got %d bytes from %d", len(payload), len(data))
+	}
+
+	decoded, err := decodePayload(payload)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if string(decoded) != string(data) {
+		t.Error("decoded payload does not match input")
+	}
+}
+
+// Run with: make bench
+func BenchmarkRedirectEntryEncode(b *testing.B) {
+	entry := benchmarkRedirectEntry()
+	for _, codec := range benchmarkCodecs {
+		b.Run(codec.Name(), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := codec.Marshal(entry); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRedirectEntryDecode(b *testing.B) {
+	for _, codec := range benchmarkCodecs {
+		data, err := codec.Marshal(benchmarkRedirectEntry())
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(codec.Name(), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var entry RedirectEntry
+				if err := codec.Unmarshal(data, &entry); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkStatsEncode(b *testing.B) {
+	stats := benchmarkStats()
+	for _, codec := range benchmarkCodecs {
+		b.Run(codec.Name(), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := codec.Marshal(stats); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkStatsDecode(b *testing.B) {
+	for _, codec := range benchmarkCodecs {
+		data, err := codec.Marshal(benchmarkStats())
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(codec.Name(), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var stats models.StatsResponse
+				if err := codec.Unmarshal(data, &stats); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
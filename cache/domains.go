@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"time"
+
+	"url-shortener/models"
+)
+
+// ShortDomainsKey holds the encoded branded domains requests are routed by,
+// shared by every instance so each loads them from the database at most
+// once per ShortDomainsTTL
+const ShortDomainsKey = "domains:short"
+
+// How long the cached branded domains are kept; changes replace them sooner
+const ShortDomainsTTL = time.Hour
+
+// DomainsChannel tells the other instances the branded domains changed. The
+// message is the publishing instance.
+const DomainsChannel = "cache:invalidate:domains"
+
+// domainsHooks are called when another instance changes the branded domains
+var domainsHooks []func()
+
+// OnShortDomainsChanged registers hook to be called when another instance
+// replaces the cached branded domains. Register hooks before serving
+// requests.
+func OnShortDomainsChanged(hook func()) {
+	domainsHooks = append(domainsHooks, hook)
+}
+
+// GetShortDomains returns the cached branded domains
+func GetShortDomains() ([]models.Domain, error) {
+	if RedisClient == nil {
+		return nil, ErrNotConnected
+	}
+
+	payload, err := RedisClient.Get(ctx, ShortDomainsKey).Result()
+	if err != nil {
+		return nil, redisError(err)
+	}
+
+	data, err := decodePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored []models.Domain
+	if err := valueCodec.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// FillShortDomains caches the branded domains loaded after a miss, unless
+// ReplaceShortDomains cached newer ones in the meantime
+func FillShortDomains(stored []models.Domain) error {
+	if RedisClient == nil {
+		return nil
+	}
+
+	payload, err := encodeShortDomains(stored)
+	if err != nil {
+		return err
+	}
+	return redisError(RedisClient.SetNX(ctx, ShortDomainsKey, payload, ShortDomainsTTL).Err())
+}
+
+// ReplaceShortDomains caches the branded domains after they changed and
+// tells the other instances to drop their copies
+func ReplaceShortDomains(stored []models.Domain) error {
+	if RedisClient == nil {
+		return nil
+	}
+
+	payload, err := encodeShortDomains(stored)
+	if err == nil {
+		err = redisError(RedisClient.Set(ctx, ShortDomainsKey, payload, ShortDomainsTTL).Err())
+	}
+	if err != nil {
+		// Without the new copy, the previous one must not be reused
+		DropShortDomains()
+		return err
+	}
+	publishShortDomainsChanged()
+	return nil
+}
+
+// DropShortDomains removes the cached branded domains and tells the other
+// instances to drop their copies, so they are reloaded from the database
+func DropShortDomains() {
+	if RedisClient == nil {
+		return
+	}
+	RedisClient.Del(ctx, ShortDomainsKey)
+	publishShortDomainsChanged()
+}
+
+func publishShortDomainsChanged() {
+	RedisClient.Publish(ctx, DomainsChannel, invalidationSource)
+}
+
+func encodeShortDomains(stored []models.Domain) (string, error) {
+	data, err := valueCodec.Marshal(stored)
+	if err != nil {
+		return "", err
+	}
+	return encodePayload(data)
+}
+
+// shortDomainsChanged runs the hooks when another instance changed the
+// branded domains
+func shortDomainsChanged(source string) {
+	if source == invalidationSource {
+		return
+	}
+	for _, hook := range domainsHooks {
+		hook()
+	}
+}
@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"url-shortener/models"
+)
+
+func TestShortDomainsRoundTrip(t *testing.T) {
+	stored := []models.Domain{
+		{ID: 1, CreatedAt: time.Now(), Host: "go.acme.com"},
+		{ID: 2, CreatedAt: time.Now(), Host: "l.example.org"},
+	}
+	for _, codec := range benchmarkCodecs {
+		t.Run(codec.Name(), func(t *testing.T) {
+			valueCodec = codec
+			t.Cleanup(func() { valueCodec = MsgpackCodec{} })
+
+			payload, err := encodeShortDomains(stored)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			data, err := decodePayload(payload)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			var decoded []models.Domain
+			if err := codec.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if len(decoded) != len(stored) {
+				t.Fatalf("decoded %d domains, want %d", len(decoded), len(stored))
+			}
+			for i := range stored {
+				if decoded[i].ID != stored[i].ID || decoded[i].Host != stored[i].Host || !decoded[i].CreatedAt.Equal(stored[i].CreatedAt) {
+					t.Errorf("domain %d = %+v, want %+v", i, decoded[i], stored[i])
+				}
+			}
+		})
+	}
+}
+
+func TestShortDomainsChangedSkipsOwnMessages(t *testing.T) {
+	calls := 0
+	domainsHooks = []func(){func() { calls++ }}
+	t.Cleanup(func() { domainsHooks = nil })
+
+	shortDomainsChanged(invalidationSource)
+	if calls != 0 {
+		t.Errorf("hooks ran %d times for this instance's own change", calls)
+	}
+	shortDomainsChanged("another-instance")
+	if calls != 1 {
+		t.Errorf("hooks ran %d times for another instance's change, want 1", calls)
+	}
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"errors"
+
+	"url-shortener/chaos"
+	"url-shortener/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNotConnected is returned when running without Redis. It is a
+// storage.ErrBackendUnavailable; reads usually treat it like a miss.
+var ErrNotConnected = storage.Wrap(storage.ErrBackendUnavailable, errors.New("Redis is not connected"))
+
+// redisError classifies the error of a Redis command: missing keys are
+// storage.ErrNotFound, and failures reaching Redis
+// storage.ErrBackendUnavailable
+func redisError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, redis.Nil):
+		return storage.Wrap(storage.ErrNotFound, err)
+	case errors.Is(err, redis.ErrClosed) || errors.Is(err, chaos.ErrInjected) || storage.Unreachable(err):
+		return storage.Wrap(storage.ErrBackendUnavailable, err)
+	}
+	return err
+}
+
+// redisResult classifies the error of a Redis command returning a value
+func redisResult[T any](value T, err error) (T, error) {
+	return value, redisError(err)
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"url-shortener/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisError(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	cases := []struct {
+		err  error
+		kind error // nil when the error is kept as is
+	}{
+		{redis.Nil, storage.ErrNotFound},
+		{refused, storage.ErrBackendUnavailable},
+		{redis.ErrClosed, storage.ErrBackendUnavailable},
+		{errors.New("WRONGTYPE Operation against a key holding the wrong kind of value"), nil},
+	}
+	for _, tc := range cases {
+		err := redisError(tc.err)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("redisError(%v) = %v, lost the original error", tc.err, err)
+		}
+		if tc.kind != nil && !errors.Is(err, tc.kind) {
+			t.Errorf("redisError(%v) = %v, want a %v", tc.err, err, tc.kind)
+		}
+		if tc.kind == nil && err != tc.err {
+			t.Errorf("redisError(%v) = %v, want it unchanged", tc.err, err)
+		}
+	}
+	if redisError(nil) != nil {
+		t.Error("redisError(nil) != nil")
+	}
+}
+
+func TestReadsWithoutRedisAreUnavailable(t *testing.T) {
+	if RedisClient != nil {
+		t.Skip("Redis is connected")
+	}
+	if _, err := GetRedirectEntry("abc123"); !errors.Is(err, storage.ErrBackendUnavailable) || errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetRedirectEntry without Redis: err = %v, want ErrBackendUnavailable and not ErrNotFound", err)
+	}
+}
@@ -0,0 +1,29 @@
+package cache
+
+import "time"
+
+// LandingKey holds the short code a landing token was issued for, until the
+// destination reports the landing or LandingTokenTTL passes
+const LandingKey = "url:landing:" // url:landing:token
+
+// How long after a redirect its landing can be reported
+const LandingTokenTTL = 30 * time.Minute
+
+// StoreLandingToken records the landing token issued with a redirect of
+// shortCode, shared by every instance
+func StoreLandingToken(token, shortCode string) error {
+	if RedisClient == nil {
+		return ErrNotConnected
+	}
+	return redisError(RedisClient.Set(ctx, LandingKey+token, shortCode, LandingTokenTTL).Err())
+}
+
+// TakeLandingToken returns the short code a landing token was issued for
+// and forgets the token, so each redirect lands at most once. Unknown and
+// expired tokens are storage.ErrNotFound.
+func TakeLandingToken(token string) (string, error) {
+	if RedisClient == nil {
+		return "", ErrNotConnected
+	}
+	return redisResult(RedisClient.GetDel(ctx, LandingKey+token).Result())
+}
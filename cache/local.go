@@ -0,0 +1,230 @@
+package cache
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"url-shortener/config"
+	"url-shortener/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// InvalidationChannel carries the short codes whose cached values changed,
+// prefixed with the publishing instance, so every instance drops them from
+// its local cache
+const InvalidationChannel = "cache:invalidate"
+
+// localCache is a process-local LRU cache in front of Redis for the values
+// read on every redirect. Entries live at most ttl, which bounds how stale an
+// instance can be when an invalidation message is missed.
+type localCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	items    map[string]*list.Element
+	order    *list.List // most recently used first
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+type localItem struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+func newLocalCache(capacity int, ttl time.Duration) *localCache {
+	return &localCache{
+		capacity: capacity,
+		ttl:      ttl,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// local is sized by LOCAL_CACHE_SIZE and LOCAL_CACHE_TTL, set by
+// Configure, and nil when the local cache is disabled
+var (
+	local         = newLocalCache(config.Default().Cache.LocalSize, config.Default().Cache.LocalTTL)
+	invalidations *redis.PubSub
+)
+
+func (l *localCache) get(key string, now time.Time) (interface{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.items[key]
+	if !ok {
+		l.misses.Add(1)
+		return nil, false
+	}
+	item := element.Value.(*localItem)
+	if !now.Before(item.expiresAt) {
+		l.removeElement(element)
+		l.misses.Add(1)
+		return nil, false
+	}
+	l.order.MoveToFront(element)
+	l.hits.Add(1)
+	return item.value, true
+}
+
+func (l *localCache) set(key string, value interface{}, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.items[key]; ok {
+		item := element.Value.(*localItem)
+		item.value = value
+		item.expiresAt = now.Add(l.ttl)
+		l.order.MoveToFront(element)
+		return
+	}
+	l.items[key] = l.order.PushFront(&localItem{key: key, value: value, expiresAt: now.Add(l.ttl)})
+	for l.order.Len() > l.capacity {
+		l.removeElement(l.order.Back())
+		l.evictions.Add(1)
+	}
+}
+
+func (l *localCache) remove(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := l.items[key]; ok {
+			l.removeElement(element)
+		}
+	}
+}
+
+func (l *localCache) clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.items = make(map[string]*list.Element)
+	l.order.Init()
+}
+
+func (l *localCache) removeElement(element *list.Element) {
+	l.order.Remove(element)
+	delete(l.items, element.Value.(*localItem).key)
+}
+
+func (l *localCache) status() models.LocalCacheStatus {
+	l.mu.Lock()
+	size := l.order.Len()
+	l.mu.Unlock()
+
+	status := models.LocalCacheStatus{
+		Enabled:   true,
+		Size:      size,
+		Capacity:  l.capacity,
+		TTL:       l.ttl.String(),
+		Hits:      l.hits.Load(),
+		Misses:    l.misses.Load(),
+		Evictions: l.evictions.Load(),
+	}
+	if lookups := status.Hits + status.Misses; lookups > 0 {
+		status.HitRate = float64(status.Hits) / float64(lookups)
+	}
+	return status
+}
+
+// LocalCacheStats reports the size and hit rate of the local cache
+func LocalCacheStats() models.LocalCacheStatus {
+	if local == nil || RedisClient == nil {
+		return models.LocalCacheStatus{}
+	}
+	return local.status()
+}
+
+// getLocal returns a value cached locally under key
+func getLocal(key string) (interface{}, bool) {
+	if local == nil {
+		return nil, false
+	}
+	return local.get(key, time.Now())
+}
+
+// setLocal caches value locally under key
+func setLocal(key string, value interface{}) {
+	if local != nil {
+		local.set(key, value, time.Now())
+	}
+}
+
+// clearLocal drops every locally cached value
+func clearLocal() {
+	if local != nil {
+		local.clear()
+	}
+}
+
+// evictLocal drops the locally cached values of a short code
+func evictLocal(shortCode string) {
+	if local != nil {
+		local.remove(URLMappingKey+shortCode, RedirectKey+shortCode)
+	}
+}
+
+// invalidationHooks are called with every short code invalidated, on this
+// instance or on another one
+var invalidationHooks []func(shortCode string)
+
+// invalidationSource tells this instance's invalidation messages apart from
+// those of other instances
+var invalidationSource = newInvalidationSource()
+
+func newInvalidationSource() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// OnInvalidate registers hook to be called with every short code whose
+// cached values are invalidated, by this instance or, through Redis, by
+// another one. Register hooks before serving requests.
+func OnInvalidate(hook func(shortCode string)) {
+	invalidationHooks = append(invalidationHooks, hook)
+}
+
+// invalidated drops the local copies of a short code and runs the hooks
+func invalidated(shortCode string) {
+	evictLocal(shortCode)
+	for _, hook := range invalidationHooks {
+		hook(shortCode)
+	}
+}
+
+// publishInvalidation tells the other instances a short code changed
+func publishInvalidation(shortCode string) {
+	RedisClient.Publish(ctx, InvalidationChannel, invalidationSource+" "+shortCode)
+}
+
+// subscribeInvalidations handles the short codes other instances
+// invalidate, and changes to the branded domains, until the subscription is
+// closed
+func subscribeInvalidations() {
+	invalidations = RedisClient.Subscribe(ctx, InvalidationChannel, DomainsChannel)
+	go func(messages <-chan *redis.Message) {
+		for message := range messages {
+			if message.Channel == DomainsChannel {
+				shortDomainsChanged(message.Payload)
+				continue
+			}
+			source, shortCode, ok := strings.Cut(message.Payload, " ")
+			if ok && source != invalidationSource {
+				invalidated(shortCode)
+			}
+		}
+	}(invalidations.Channel())
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocalCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	l := newLocalCache(2, time.Minute)
+	now := time.Now()
+
+	l.set("a", 1, now)
+	l.set("b", 2, now)
+	if _, ok := l.get("a", now); !ok {
+		t.Fatal("a should be cached")
+	}
+	l.set("c", 3, now) // b is the least recently used
+
+	if _, ok := l.get("b", now); ok {
+		t.Error("b should have been evicted")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if value, ok := l.get(key, now); !ok || value.(int) != want {
+			t.Errorf("%s = %v, %v; want %d", key, value, ok, want)
+		}
+	}
+
+	status := l.status()
+	if status.Size != 2 || status.Evictions != 1 || status.Hits != 3 || status.Misses != 1 {
+		t.Errorf("status = %+v", status)
+	}
+	if status.HitRate != 0.75 {
+		t.Errorf("hit rate = %v, want 0.75", status.HitRate)
+	}
+}
+
+func TestLocalCacheExpiresEntries(t *testing.T) {
+	l := newLocalCache(10, time.Second)
+	now := time.Now()
+
+	l.set("a", 1, now)
+	if _, ok := l.get("a", now.Add(999*time.Millisecond)); !ok {
+		t.Error("a should still be cached")
+	}
+	if _, ok := l.get("a", now.Add(time.Second)); ok {
+		t.Error("a should have expired")
+	}
+	if size := l.status().Size; size != 0 {
+		t.Errorf("size = %d, expired entries should be dropped", size)
+	}
+}
+
+func TestLocalCacheRemove(t *testing.T) {
+	l := newLocalCache(10, time.Minute)
+	now := time.Now()
+
+	l.set(URLMappingKey+"abc", 1, now)
+	l.set(RedirectKey+"abc", 2, now)
+	l.set(RedirectKey+"xyz", 3, now)
+	l.remove(URLMappingKey+"abc", RedirectKey+"abc", RedirectKey+"missing")
+
+	if _, ok := l.get(RedirectKey+"abc", now); ok {
+		t.Error("abc should have been removed")
+	}
+	if _, ok := l.get(RedirectKey+"xyz", now); !ok {
+		t.Error("xyz should still be cached")
+	}
+}
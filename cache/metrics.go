@@ -0,0 +1,44 @@
+package cache
+
+import "time"
+
+// InstanceMetricsKey holds the metrics snapshot an instance last published
+const InstanceMetricsKey = "metrics:instance:" // metrics:instance:instanceID
+
+// PublishInstanceMetrics stores an instance's metrics snapshot. It expires
+// after ttl, so instances that stopped publishing drop out of the fleet.
+func PublishInstanceMetrics(instance string, snapshot []byte, ttl time.Duration) error {
+	if RedisClient == nil {
+		return ErrNotConnected
+	}
+	return redisError(RedisClient.Set(ctx, InstanceMetricsKey+instance, snapshot, ttl).Err())
+}
+
+// InstanceMetricsSnapshots returns the snapshots published by every instance
+func InstanceMetricsSnapshots() ([][]byte, error) {
+	if RedisClient == nil {
+		return nil, ErrNotConnected
+	}
+
+	var keys []string
+	iter := RedisClient.Scan(ctx, 0, InstanceMetricsKey+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil || len(keys) == 0 {
+		return nil, redisError(err)
+	}
+
+	values, err := RedisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, redisError(err)
+	}
+	snapshots := make([][]byte, 0, len(values))
+	for _, value := range values {
+		// Snapshots may expire between SCAN and MGET
+		if s, ok := value.(string); ok {
+			snapshots = append(snapshots, []byte(s))
+		}
+	}
+	return snapshots, nil
+}
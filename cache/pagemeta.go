@@ -0,0 +1,57 @@
+package cache
+
+import "time"
+
+// PageMetadataKey holds the encoded PageMetadata of a link's destination
+const PageMetadataKey = "url:page:" // url:page:shortCode
+
+// PageMetadata is what a link's destination page says about itself, as
+// shown on the link's preview page
+type PageMetadata struct {
+	Title       string `codec:"t,omitempty"`
+	Description string `codec:"d,omitempty"`
+	FetchedAt   int64  `codec:"f,omitempty"` // unix seconds, 0 when never fetched
+}
+
+// CachePageMetadata caches the destination page metadata of a short code
+// for ttl
+func CachePageMetadata(shortCode string, metadata *PageMetadata, ttl time.Duration) error {
+	if RedisClient == nil {
+		return nil
+	}
+
+	data, err := valueCodec.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	payload, err := encodePayload(data)
+	if err != nil {
+		return err
+	}
+
+	return redisError(RedisClient.Set(ctx, PageMetadataKey+shortCode, payload, ttl).Err())
+}
+
+// GetPageMetadata returns the cached destination page metadata of a short code
+func GetPageMetadata(shortCode string) (*PageMetadata, error) {
+	if RedisClient == nil {
+		return nil, ErrNotConnected
+	}
+
+	payload, err := RedisClient.Get(ctx, PageMetadataKey+shortCode).Result()
+	if err != nil {
+		return nil, redisError(err)
+	}
+
+	data, err := decodePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata PageMetadata
+	if err := valueCodec.Unmarshal(data, &metadata); err != nil {
+		return nil, err
+	}
+	return &metadata, nil
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"strings"
+	"time"
+
+	"url-shortener/config"
+	"url-shortener/encryption"
+	"url-shortener/models"
+)
+
+// Compressed payloads are marked with this prefix; plain JSON never starts with it
+const compressedPrefix = "z:"
+
+// Payloads at least this large are compressed, CACHE_COMPRESSION_THRESHOLD
+// set by Configure; 0 disables compression
+var compressionThreshold = config.Default().Cache.CompressionThreshold
+
+// cachedURL holds only the URL fields needed to redirect and to answer
+// duplicate shorten requests, keeping timestamps and soft-delete data out
+// of the cache
+type cachedURL struct {
+	ID          uint       `json:"id"`
+	OriginalURL string     `json:"original_url"`
+	ClickCount  int        `json:"click_count"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	Inert       bool       `json:"inert,omitempty"`
+}
+
+func newCachedURL(url *models.URL) cachedURL {
+	return cachedURL{
+		ID:          url.ID,
+		OriginalURL: url.OriginalURL,
+		ClickCount:  url.ClickCount,
+		ExpiresAt:   url.ExpiresAt,
+		Status:      url.Status,
+		Inert:       url.Inert,
+	}
+}
+
+func (c cachedURL) toModel(shortCode string) *models.URL {
+	url := &models.URL{
+		ID:          c.ID,
+		OriginalURL: c.OriginalURL,
+		ShortCode:   shortCode,
+		ClickCount:  c.ClickCount,
+		ExpiresAt:   c.ExpiresAt,
+		Status:      c.Status,
+		Inert:       c.Inert,
+	}
+	if url.Status == "" {
+		url.Status = models.StatusActive
+	}
+	return url
+}
+
+// encodePayload compresses large values and then encrypts them, since
+// destination URLs are encrypted in the cache just like in the database
+func encodePayload(data []byte) (string, error) {
+	payload := string(data)
+
+	if threshold := compressionThreshold; threshold > 0 && len(data) >= threshold {
+		var buf bytes.Buffer
+		buf.WriteString(compressedPrefix)
+		writer, err := flate.NewWriter(&buf, flate.BestSpeed)
+		if err != nil {
+			return "", err
+		}
+		if _, err := writer.Write(data); err != nil {
+			return "", err
+		}
+		if err := writer.Close(); err != nil {
+			return "", err
+		}
+		payload = buf.String()
+	}
+
+	return encryption.Encrypt(payload)
+}
+
+// decodePayload reverses encodePayload
+func decodePayload(payload string) ([]byte, error) {
+	data, err := encryption.Decrypt(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(data, compressedPrefix) {
+		return []byte(data), nil
+	}
+
+	reader := flate.NewReader(strings.NewReader(data[len(compressedPrefix):]))
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
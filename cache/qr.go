@@ -0,0 +1,26 @@
+package cache
+
+import "time"
+
+// QRCodeKey holds a rendered QR code image, keyed by the encoded short URL
+// and rendering options
+const QRCodeKey = "qr:" // qr:format:size:level:shortURL
+
+// QR codes only depend on the short URL, so they are kept for long
+const QRCodeTTL = 7 * 24 * time.Hour
+
+// GetQRCode returns a cached QR code image
+func GetQRCode(key string) ([]byte, error) {
+	if RedisClient == nil {
+		return nil, ErrNotConnected
+	}
+	return redisResult(RedisClient.Get(ctx, QRCodeKey+key).Bytes())
+}
+
+// CacheQRCode stores a rendered QR code image
+func CacheQRCode(key string, image []byte) error {
+	if RedisClient == nil {
+		return nil
+	}
+	return redisError(RedisClient.Set(ctx, QRCodeKey+key, image, QRCodeTTL).Err())
+}
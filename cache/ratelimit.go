@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimitKey counts a client's requests in a window
+const RateLimitKey = "ratelimit:" // ratelimit:scope:client:windowStart
+
+// IncrementRateLimit counts a request in the window starting at windowStart
+// and returns the requests counted so far in it and in the window before,
+// shared by every instance
+func IncrementRateLimit(client string, windowStart time.Time, window time.Duration) (int64, int64, error) {
+	if RedisClient == nil {
+		return 0, 0, ErrNotConnected
+	}
+
+	key := RateLimitKey + client + ":"
+	pipe := RedisClient.TxPipeline()
+	count := pipe.Incr(ctx, key+windowStart.Format("20060102T150405"))
+	// Kept for the next window, and a little past it so clock skew between
+	// instances is harmless
+	pipe.Expire(ctx, key+windowStart.Format("20060102T150405"), 2*window+time.Minute)
+	previous := pipe.Get(ctx, key+windowStart.Add(-window).Format("20060102T150405"))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return 0, 0, redisError(err)
+	}
+	previousCount, _ := previous.Int64()
+	return count.Val(), previousCount, nil
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is pinged every redisHealthInterval while in use, and taken out of
+// use after redisFailuresBeforeDown failed pings in a row. While out of use
+// it is pinged after a delay doubling from redisMinBackoff up to
+// redisMaxBackoff, with jitter so instances don't reconnect in lockstep.
+const (
+	redisHealthInterval     = 5 * time.Second
+	redisPingTimeout        = 2 * time.Second
+	redisFailuresBeforeDown = 2
+	redisMinBackoff         = time.Second
+	redisMaxBackoff         = 30 * time.Second
+)
+
+// superviseRedis keeps client in use while it answers pings, until it is
+// closed. When Redis stops answering, RedisClient is set to nil, so the
+// cache is skipped like when running without Redis, and set back once a
+// ping succeeds again. The local cache is cleared then, as invalidations
+// from other instances were missed meanwhile. up tells whether client is in
+// use already.
+func superviseRedis(client *redis.Client, up bool) {
+	failures, attempt := 0, 0
+	for {
+		if up {
+			time.Sleep(redisHealthInterval)
+		} else {
+			time.Sleep(reconnectDelay(attempt, rand.Float64()))
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, redisPingTimeout)
+		err := client.Ping(pingCtx).Err()
+		cancel()
+		if errors.Is(err, redis.ErrClosed) {
+			return
+		}
+
+		switch {
+		case err == nil && !up:
+			clearLocal()
+			useRedis(client)
+			slog.Info("Redis reconnected, cache enabled", "attempts", attempt+1)
+			up, failures, attempt = true, 0, 0
+		case err == nil:
+			failures = 0
+		case up:
+			if failures++; failures >= redisFailuresBeforeDown {
+				RedisClient = nil
+				slog.Warn("Redis is unreachable, continuing without cache and reconnecting in the background", "error", err)
+				up = false
+			}
+		default:
+			attempt++
+		}
+	}
+}
+
+// reconnectDelay returns how long to wait before reconnection attempt
+// attempt (from 0), jittered by up to a quarter with jitter in [0, 1)
+func reconnectDelay(attempt int, jitter float64) time.Duration {
+	delay := redisMinBackoff
+	for i := 0; i < attempt && delay < redisMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > redisMaxBackoff {
+		delay = redisMaxBackoff
+	}
+	return delay - time.Duration(jitter*float64(delay)/4)
+}
@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		jitter  float64
+		want    time.Duration
+	}{
+		{0, 0, time.Second},
+		{1, 0, 2 * time.Second},
+		{4, 0, 16 * time.Second},
+		{5, 0, 30 * time.Second},
+		{100, 0, 30 * time.Second},
+		{0, 0.5, 875 * time.Millisecond},
+		{100, 0.999, 30*time.Second - time.Duration(0.999*float64(30*time.Second)/4)},
+	}
+	for _, tt := range tests {
+		if got := reconnectDelay(tt.attempt, tt.jitter); got != tt.want {
+			t.Errorf("reconnectDelay(%d, %v) = %v, want %v", tt.attempt, tt.jitter, got, tt.want)
+		}
+	}
+}
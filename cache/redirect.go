@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"net/http"
+	"time"
+
+	"url-shortener/models"
+	"url-shortener/utils"
+)
+
+// RedirectKey holds the encoded RedirectEntry for a short code
+const RedirectKey = "url:redirect:" // url:redirect:shortCode
+
+// Redirect entry flags
+const (
+	RedirectInert    uint16 = 1 << iota // created by a shadow-banned creator, never redirects
+	RedirectPending                     // awaiting admin approval
+	RedirectRejected                    // rejected by an admin
+	RedirectPreview                     // has a custom Open Graph card for crawlers
+	RedirectNoIndex                     // search engines are asked not to index the link
+	RedirectVariants                    // split link, the destination is picked per visitor
+	RedirectBandit                      // split link optimized by Thompson sampling
+	RedirectNoEvents                    // clicks are counted without recording click events
+	RedirectNoCount                     // clicks are neither counted nor recorded
+	RedirectLimited                     // expires after max_clicks redirects
+	RedirectDisabled                    // disabled by an admin
+	RedirectRollout                     // soft launched, only Rollout percent of visitors are redirected
+	RedirectVelocity                    // redirects are capped to Velocity
+	RedirectLandings                    // redirects carry a landing token, see IssueLandingToken
+)
+
+// RedirectEntry is the compact record the redirect hot path reads from the
+// cache, instead of the full URL model
+type RedirectEntry struct {
+	URLID       uint   `codec:"i"`
+	Destination string `codec:"d"`
+	StatusCode  int    `codec:"s"`           // HTTP redirect status
+	ExpiresAt   int64  `codec:"e,omitempty"` // unix seconds, 0 when the link never expires
+	Flags       uint16 `codec:"f,omitempty"`
+	UTM         string `codec:"u,omitempty"` // UTM parameters added on redirect, URL encoded
+	// Routing rules of the link, checked before variants
+	Rules   []models.RoutingRule `codec:"r,omitempty"`
+	Version int                  `codec:"v,omitempty"` // configuration version, see models.LinkVersion
+	Rollout int                  `codec:"o,omitempty"` // percentage of visitors redirected, with RedirectRollout
+	// Redirects allowed per second or minute, with RedirectVelocity
+	Velocity *models.VelocityLimit `codec:"l,omitempty"`
+	Headers  map[string]string     `codec:"h,omitempty"` // extra headers of redirects
+}
+
+// NewRedirectEntry builds the redirect entry for a URL record
+func NewRedirectEntry(url *models.URL) *RedirectEntry {
+	entry := &RedirectEntry{
+		URLID:       url.ID,
+		Destination: url.OriginalURL,
+		StatusCode:  http.StatusMovedPermanently,
+		UTM:         url.UTMQuery(),
+		Rules:       url.RoutingRules,
+		Version:     url.Version,
+		Headers:     url.RedirectHeaders,
+	}
+	if url.RedirectType != 0 {
+		entry.StatusCode = url.RedirectType
+	}
+	if url.ExpiresAt != nil {
+		entry.ExpiresAt = url.ExpiresAt.Unix()
+	}
+	if url.Inert {
+		entry.Flags |= RedirectInert
+	}
+	if url.HasPreview() {
+		entry.Flags |= RedirectPreview
+	}
+	if url.NoIndex {
+		entry.Flags |= RedirectNoIndex
+	}
+	switch url.VariantMode {
+	case models.VariantModeWeighted:
+		entry.Flags |= RedirectVariants
+	case models.VariantModeBandit:
+		entry.Flags |= RedirectVariants | RedirectBandit
+	}
+	switch url.AnalyticsMode() {
+	case models.AnalyticsCount:
+		entry.Flags |= RedirectNoEvents
+	case models.AnalyticsNone:
+		entry.Flags |= RedirectNoEvents | RedirectNoCount
+	}
+	if url.MaxClicks != nil {
+		entry.Flags |= RedirectLimited
+	}
+	if url.RolloutPercent != nil {
+		entry.Flags |= RedirectRollout
+		entry.Rollout = *url.RolloutPercent
+	}
+	if url.VelocityLimit != nil {
+		entry.Flags |= RedirectVelocity
+		entry.Velocity = url.VelocityLimit
+	}
+	if url.TrackLandings {
+		entry.Flags |= RedirectLandings
+	}
+	// Browsers cache permanent redirects, which would pin visitors to a
+	// variant, or keep sending them through a rollout ramped back down, or
+	// past a velocity limit, or to a used up landing token
+	if entry.Has(RedirectVariants|RedirectRollout|RedirectVelocity|RedirectLandings) && entry.StatusCode == http.StatusMovedPermanently {
+		entry.StatusCode = http.StatusFound
+	}
+	switch url.Status {
+	case models.StatusPending:
+		entry.Flags |= RedirectPending
+	case models.StatusRejected:
+		entry.Flags |= RedirectRejected
+	case models.StatusDisabled:
+		entry.Flags |= RedirectDisabled
+	}
+	return entry
+}
+
+// Has reports whether flag is set on the entry
+func (e *RedirectEntry) Has(flag uint16) bool {
+	return e.Flags&flag != 0
+}
+
+// Target returns destination, the link's or one of its variants', with the
+// link's UTM parameters it does not set itself
+func (e *RedirectEntry) Target(destination string) string {
+	return utils.AddQueryDefaults(destination, e.UTM)
+}
+
+// Expired reports whether the link has expired at now
+func (e *RedirectEntry) Expired(now time.Time) bool {
+	return e.ExpiresAt != 0 && e.ExpiresAt <= now.Unix()
+}
+
+// Cache the redirect entry for a short code
+func CacheRedirectEntry(shortCode string, entry *RedirectEntry) error {
+	if RedisClient == nil {
+		return nil
+	}
+
+	data, err := valueCodec.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	payload, err := encodePayload(data)
+	if err != nil {
+		return err
+	}
+
+	key := RedirectKey + shortCode
+	if err := RedisClient.Set(ctx, key, payload, DefaultCacheTTL).Err(); err != nil {
+		return redisError(err)
+	}
+	cached := *entry
+	setLocal(key, &cached)
+	return nil
+}
+
+// Get the redirect entry for a short code from cache, checking the local
+// cache first
+func GetRedirectEntry(shortCode string) (*RedirectEntry, error) {
+	if RedisClient == nil {
+		return nil, ErrNotConnected
+	}
+
+	key := RedirectKey + shortCode
+	if cached, ok := getLocal(key); ok {
+		entry := *cached.(*RedirectEntry)
+		return &entry, nil
+	}
+
+	payload, err := RedisClient.Get(ctx, key).Result()
+	if err != nil {
+		return nil, redisError(err)
+	}
+
+	data, err := decodePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry RedirectEntry
+	if err := valueCodec.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	cached := entry
+	setLocal(key, &cached)
+	return &entry, nil
+}
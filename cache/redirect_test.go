@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"net/http"
+	"testing"
+
+	"url-shortener/models"
+)
+
+func TestNewRedirectEntryStatusCode(t *testing.T) {
+	soft := 10
+	cases := []struct {
+		name         string
+		redirectType int
+		variantMode  string
+		rollout      *int
+		want         int
+	}{
+		{"default", 0, "", nil, http.StatusMovedPermanently},
+		{"temporary", http.StatusFound, "", nil, http.StatusFound},
+		{"method preserving", http.StatusTemporaryRedirect, "", nil, http.StatusTemporaryRedirect},
+		{"split link default", 0, models.VariantModeWeighted, nil, http.StatusFound},
+		{"split link never permanent", http.StatusMovedPermanently, models.VariantModeBandit, nil, http.StatusFound},
+		{"split link method preserving", http.StatusTemporaryRedirect, models.VariantModeWeighted, nil, http.StatusTemporaryRedirect},
+		{"soft launch never permanent", http.StatusMovedPermanently, "", &soft, http.StatusFound},
+	}
+	for _, tc := range cases {
+		entry := NewRedirectEntry(&models.URL{OriginalURL: "https://example.com/", RedirectType: tc.redirectType, VariantMode: tc.variantMode, RolloutPercent: tc.rollout})
+		if entry.StatusCode != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.name, entry.StatusCode, tc.want)
+		}
+	}
+
+	// Browsers must come back through a velocity limit rather than skip it
+	limited := NewRedirectEntry(&models.URL{OriginalURL: "https://example.com/", VelocityLimit: &models.VelocityLimit{Clicks: 5}})
+	if !limited.Has(RedirectVelocity) || limited.StatusCode != http.StatusFound {
+		t.Errorf("velocity limited link: flags = %b, status = %d, want RedirectVelocity and %d", limited.Flags, limited.StatusCode, http.StatusFound)
+	}
+}
+
+func TestRedirectEntryTarget(t *testing.T) {
+	entry := NewRedirectEntry(&models.URL{OriginalURL: "https://example.com/", UTMSource: "newsletter", UTMCampaign: "spring sale"})
+	cases := map[string]string{
+		"https://example.com/":                          "https://example.com/?utm_campaign=spring+sale&utm_source=newsletter",
+		"https://example.com/p?id=1#top":                "https://example.com/p?id=1&utm_campaign=spring+sale&utm_source=newsletter#top",
+		"https://example.com/?utm_source=twitter&b=%2F": "https://example.com/?utm_source=twitter&b=%2F&utm_campaign=spring+sale",
+	}
+	for destination, want := range cases {
+		if got := entry.Target(destination); got != want {
+			t.Errorf("Target(%q) = %q, want %q", destination, got, want)
+		}
+	}
+
+	plain := NewRedirectEntry(&models.URL{OriginalURL: "https://example.com/a?b=c"})
+	if got := plain.Target(plain.Destination); got != plain.Destination {
+		t.Errorf("Target without UTM parameters = %q, want the destination unchanged", got)
+	}
+}
@@ -2,60 +2,127 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"strconv"
 	"time"
 
+	"url-shortener/chaos"
+	"url-shortener/config"
 	"url-shortener/models"
+	"url-shortener/utils"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// RedisClient is nil while Redis is unreachable, so the cache is skipped
+// instead of waiting for commands to time out
 var (
 	RedisClient *redis.Client
 	ctx         = context.Background()
 )
 
-// Initialize Redis connection
-func InitRedis() {
-	addr := getEnv("REDIS_ADDR", "localhost:6379")
-	password := getEnv("REDIS_PASSWORD", "")
-	dbStr := getEnv("REDIS_DB", "0")
+// The client InitRedis or Connect created, kept while Redis is unreachable,
+// and whether it follows invalidations yet
+var (
+	connected  *redis.Client
+	subscribed bool
+)
 
-	db, err := strconv.Atoi(dbStr)
+// InitRedis connects to Redis, running without the cache while it is
+// unreachable, at startup or later on, and bringing the cache back once it
+// answers again (see superviseRedis)
+func InitRedis(cfg config.Redis) {
+	client := newRedisClient(cfg)
+	err := client.Ping(ctx).Err()
+	adopt(client)
 	if err != nil {
-		log.Printf("Invalid REDIS_DB value, using default: %v", err)
-		db = 0
+		slog.Warn("Failed to connect to Redis, continuing without cache and reconnecting in the background", "error", err)
+		go superviseRedis(client, false)
+		return
+	}
+
+	useRedis(client)
+	go superviseRedis(client, true)
+	slog.Info("Redis connected successfully")
+}
+
+// Configure sets how values are encoded and cached locally. Call it before
+// connecting; the defaults of config.Default apply otherwise.
+func Configure(cfg config.Cache) {
+	switch cfg.Codec {
+	case "json":
+		valueCodec = JSONCodec{}
+	default:
+		valueCodec = MsgpackCodec{}
+	}
+	compressionThreshold = cfg.CompressionThreshold
+
+	local = nil
+	if cfg.LocalSize > 0 {
+		local = newLocalCache(cfg.LocalSize, cfg.LocalTTL)
+	}
+}
+
+// Connect creates the Redis client and pings it, leaving RedisClient nil
+// if Redis is unreachable. Unlike InitRedis it does not reconnect.
+func Connect(ctx context.Context, cfg config.Redis) error {
+	client := newRedisClient(cfg)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return err
 	}
+	adopt(client)
+	useRedis(client)
+	return nil
+}
 
-	RedisClient = redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password,
-		DB:       db,
+func newRedisClient(cfg config.Redis) *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
 	})
+}
 
-	// Test connection
-	_, err = RedisClient.Ping(ctx).Result()
-	if err != nil {
-		log.Printf("Failed to connect to Redis: %v", err)
-		log.Println("Continuing without cache...")
-		RedisClient = nil
-		return
+// adopt makes client the one Close closes. Fault injection is added after
+// the first ping, so it only affects commands issued while the server runs.
+func adopt(client *redis.Client) {
+	connected, subscribed = client, false
+	if chaos.Enabled() {
+		client.AddHook(chaos.RedisHook{})
+	}
+}
+
+// useRedis puts client in use, following the links other instances change
+// the first time; the subscription reconnects by itself once made
+func useRedis(client *redis.Client) {
+	RedisClient = client
+	if !subscribed {
+		subscribeInvalidations()
+		subscribed = true
 	}
+}
 
-	log.Println("Redis connected successfully")
+// Close closes the Redis client, if connected, which also stops reconnecting
+func Close() error {
+	if connected == nil {
+		return nil
+	}
+	if invalidations != nil {
+		invalidations.Close()
+	}
+	return connected.Close()
 }
 
-// Cache keys
+// Cache key prefixes, concatenated with the key to avoid formatting on hot paths
 const (
-	URLMappingKey   = "url:mapping:%s"  // url:mapping:shortCode
-	URLStatsKey     = "url:stats:%s"    // url:stats:shortCode
-	OriginalURLKey  = "url:original:%s" // url:original:hashedURL
-	DefaultCacheTTL = 24 * time.Hour    // 24 hours
-	StatsCacheTTL   = 5 * time.Minute   // 5 minutes for stats
+	URLMappingKey   = "url:mapping:"  // url:mapping:shortCode
+	URLStatsKey     = "url:stats:"    // url:stats:shortCode
+	URLClicksKey    = "url:clicks:"   // url:clicks:shortCode
+	OriginalURLKey  = "url:original:" // url:original:ownerID:hashedURL, 0 for ownerless links
+	SignatureKey    = "auth:sig:"     // auth:sig:signature (replay protection)
+	DefaultCacheTTL = 24 * time.Hour  // 24 hours
+	StatsCacheTTL   = 5 * time.Minute // 5 minutes for stats
 )
 
 // Cache URL mapping (shortCode -> URL data)
@@ -64,34 +131,56 @@ func CacheURLMapping(shortCode string, urlData *models.URL) error {
 		return nil // No-op if Redis is not available
 	}
 
-	key := fmt.Sprintf(URLMappingKey, shortCode)
-	data, err := json.Marshal(urlData)
+	key := URLMappingKey + shortCode
+	data, err := valueCodec.Marshal(newCachedURL(urlData))
+	if err != nil {
+		return err
+	}
+
+	payload, err := encodePayload(data)
 	if err != nil {
 		return err
 	}
 
-	return RedisClient.Set(ctx, key, data, DefaultCacheTTL).Err()
+	if err := RedisClient.Set(ctx, key, payload, DefaultCacheTTL).Err(); err != nil {
+		return redisError(err)
+	}
+	setLocal(key, newCachedURL(urlData).toModel(shortCode))
+	return nil
 }
 
-// Get URL mapping from cache
+// Get URL mapping from cache, checking the local cache first
 func GetURLMapping(shortCode string) (*models.URL, error) {
 	if RedisClient == nil {
-		return nil, redis.Nil // Simulate cache miss if Redis not available
+		return nil, ErrNotConnected
+	}
+
+	key := URLMappingKey + shortCode
+	if cached, ok := getLocal(key); ok {
+		urlData := *cached.(*models.URL)
+		return &urlData, nil
 	}
 
-	key := fmt.Sprintf(URLMappingKey, shortCode)
-	data, err := RedisClient.Get(ctx, key).Result()
+	payload, err := RedisClient.Get(ctx, key).Result()
+	if err != nil {
+		return nil, redisError(err)
+	}
+
+	data, err := decodePayload(payload)
 	if err != nil {
 		return nil, err
 	}
 
-	var urlData models.URL
-	err = json.Unmarshal([]byte(data), &urlData)
+	var cached cachedURL
+	err = valueCodec.Unmarshal(data, &cached)
 	if err != nil {
 		return nil, err
 	}
 
-	return &urlData, nil
+	urlData := cached.toModel(shortCode)
+	setLocal(key, urlData)
+	copied := *urlData
+	return &copied, nil
 }
 
 // Cache URL stats
@@ -100,29 +189,39 @@ func CacheURLStats(shortCode string, stats *models.StatsResponse) error {
 		return nil
 	}
 
-	key := fmt.Sprintf(URLStatsKey, shortCode)
-	data, err := json.Marshal(stats)
+	key := URLStatsKey + shortCode
+	data, err := valueCodec.Marshal(stats)
+	if err != nil {
+		return err
+	}
+
+	payload, err := encodePayload(data)
 	if err != nil {
 		return err
 	}
 
-	return RedisClient.Set(ctx, key, data, StatsCacheTTL).Err()
+	return redisError(RedisClient.Set(ctx, key, payload, StatsCacheTTL).Err())
 }
 
 // Get URL stats from cache
 func GetURLStats(shortCode string) (*models.StatsResponse, error) {
 	if RedisClient == nil {
-		return nil, redis.Nil
+		return nil, ErrNotConnected
+	}
+
+	key := URLStatsKey + shortCode
+	payload, err := RedisClient.Get(ctx, key).Result()
+	if err != nil {
+		return nil, redisError(err)
 	}
 
-	key := fmt.Sprintf(URLStatsKey, shortCode)
-	data, err := RedisClient.Get(ctx, key).Result()
+	data, err := decodePayload(payload)
 	if err != nil {
 		return nil, err
 	}
 
 	var stats models.StatsResponse
-	err = json.Unmarshal([]byte(data), &stats)
+	err = valueCodec.Unmarshal(data, &stats)
 	if err != nil {
 		return nil, err
 	}
@@ -130,24 +229,44 @@ func GetURLStats(shortCode string) (*models.StatsResponse, error) {
 	return &stats, nil
 }
 
-// Cache original URL mapping (to check if URL already exists)
-func CacheOriginalURLMapping(originalURL string, shortCode string) error {
+// Cache original URL mapping (to check if the owner already shortened the URL)
+func CacheOriginalURLMapping(ownerID *uint, originalURL string, shortCode string) error {
 	if RedisClient == nil {
 		return nil
 	}
 
-	key := fmt.Sprintf(OriginalURLKey, hashString(originalURL))
-	return RedisClient.Set(ctx, key, shortCode, DefaultCacheTTL).Err()
+	key := originalURLKey(ownerID, originalURL)
+	return redisError(RedisClient.Set(ctx, key, shortCode, DefaultCacheTTL).Err())
+}
+
+// Get short code for original URL among the owner's links
+func GetShortCodeForOriginalURL(ownerID *uint, originalURL string) (string, error) {
+	if RedisClient == nil {
+		return "", ErrNotConnected
+	}
+
+	key := originalURLKey(ownerID, originalURL)
+	return redisResult(RedisClient.Get(ctx, key).Result())
 }
 
-// Get short code for original URL
-func GetShortCodeForOriginalURL(originalURL string) (string, error) {
+// InvalidateOriginalURLMapping forgets the short code cached for an
+// owner's destination, after the link stops representing it
+func InvalidateOriginalURLMapping(ownerID *uint, originalURL string) {
 	if RedisClient == nil {
-		return "", redis.Nil
+		return
 	}
 
-	key := fmt.Sprintf(OriginalURLKey, hashString(originalURL))
-	return RedisClient.Get(ctx, key).Result()
+	RedisClient.Del(ctx, originalURLKey(ownerID, originalURL))
+}
+
+// originalURLKey is the key of an owner's mapping of a destination, shared
+// by every URL with the same canonical form (see utils.CanonicalURL)
+func originalURLKey(ownerID *uint, originalURL string) string {
+	owner := "0"
+	if ownerID != nil {
+		owner = strconv.FormatUint(uint64(*ownerID), 10)
+	}
+	return OriginalURLKey + owner + ":" + hashString(utils.CanonicalURL(originalURL))
 }
 
 // Increment click count in cache
@@ -156,35 +275,90 @@ func IncrementClickCount(shortCode string) error {
 		return nil
 	}
 
-	key := fmt.Sprintf("url:clicks:%s", shortCode)
-	return RedisClient.Incr(ctx, key).Err()
+	key := URLClicksKey + shortCode
+	return redisError(RedisClient.Incr(ctx, key).Err())
 }
 
 // Get click count from cache
 func GetClickCount(shortCode string) (int64, error) {
 	if RedisClient == nil {
-		return 0, redis.Nil
+		return 0, ErrNotConnected
+	}
+
+	key := URLClicksKey + shortCode
+	return redisResult(RedisClient.Get(ctx, key).Int64())
+}
+
+// Get cached click counts for several short codes, omitting codes without a counter
+func GetClickCounts(shortCodes []string) (map[string]int64, error) {
+	if RedisClient == nil {
+		return nil, ErrNotConnected
+	}
+	if len(shortCodes) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(shortCodes))
+	for i, shortCode := range shortCodes {
+		keys[i] = URLClicksKey + shortCode
+	}
+	values, err := RedisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, redisError(err)
+	}
+
+	counts := make(map[string]int64, len(values))
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		if count, err := strconv.ParseInt(value.(string), 10, 64); err == nil {
+			counts[shortCodes[i]] = count
+		}
+	}
+	return counts, nil
+}
+
+// Record a request signature, returning false if it was already seen (replay)
+func MarkSignatureUsed(signature string, ttl time.Duration) (bool, error) {
+	if RedisClient == nil {
+		return false, ErrNotConnected
 	}
 
-	key := fmt.Sprintf("url:clicks:%s", shortCode)
-	return RedisClient.Get(ctx, key).Int64()
+	key := SignatureKey + signature
+	return redisResult(RedisClient.SetNX(ctx, key, 1, ttl).Result())
 }
 
 // Invalidate cache for a short code
 func InvalidateCache(shortCode string) {
+	invalidated(shortCode)
 	if RedisClient == nil {
 		return
 	}
 
 	keys := []string{
-		fmt.Sprintf(URLMappingKey, shortCode),
-		fmt.Sprintf(URLStatsKey, shortCode),
-		fmt.Sprintf("url:clicks:%s", shortCode),
+		URLMappingKey + shortCode,
+		RedirectKey + shortCode,
+		URLStatsKey + shortCode,
+		URLClicksKey + shortCode,
+		PageMetadataKey + shortCode,
 	}
 
 	for _, key := range keys {
 		RedisClient.Del(ctx, key)
 	}
+
+	// Other instances drop their local copies when notified
+	publishInvalidation(shortCode)
+}
+
+// Invalidate cached stats and click count for a short code, keeping its mappings
+func InvalidateStats(shortCode string) {
+	if RedisClient == nil {
+		return
+	}
+
+	RedisClient.Del(ctx, URLStatsKey+shortCode, URLClicksKey+shortCode)
 }
 
 // Simple hash function for URL keys
@@ -193,7 +367,15 @@ func hashString(s string) string {
 	for _, c := range s {
 		hash = hash*31 + uint32(c)
 	}
-	return fmt.Sprintf("%x", hash)
+	return strconv.FormatUint(uint64(hash), 16)
+}
+
+// Ping checks the Redis connection
+func Ping(ctx context.Context) error {
+	if RedisClient == nil {
+		return ErrNotConnected
+	}
+	return redisError(RedisClient.Ping(ctx).Err())
 }
 
 // Health check for Redis
@@ -205,10 +387,3 @@ func IsRedisHealthy() bool {
 	_, err := RedisClient.Ping(ctx).Result()
 	return err == nil
 }
-
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
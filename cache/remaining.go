@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"github.com/redis/go-redis/v9"
+)
+
+// RemainingClicksKey counts down the redirects left to a link with max_clicks,
+// seeded from the database. It is not invalidated with the link's other keys,
+// as the database is written behind it.
+const RemainingClicksKey = "url:remaining:" // url:remaining:shortCode
+
+// Decrements the counter only when it exists, so that a counter that expired
+// is seeded again rather than counting down from zero
+var decrementExisting = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return false
+end
+return redis.call('DECR', KEYS[1])`)
+
+// ConsumeRemainingClick uses up one of a link's remaining clicks and returns
+// how many are left after it, negative when none were left to use. It
+// returns storage.ErrNotFound when the counter must be seeded first.
+func ConsumeRemainingClick(shortCode string) (int64, error) {
+	if RedisClient == nil {
+		return 0, ErrNotConnected
+	}
+	return redisResult(decrementExisting.Run(ctx, RedisClient, []string{RemainingClicksKey + shortCode}).Int64())
+}
+
+// SeedRemainingClicks caches the clicks a link has left, unless a concurrent
+// request seeded the counter first
+func SeedRemainingClicks(shortCode string, remaining int) error {
+	if RedisClient == nil {
+		return ErrNotConnected
+	}
+	return redisError(RedisClient.SetNX(ctx, RemainingClicksKey+shortCode, remaining, DefaultCacheTTL).Err())
+}
+
+// GetRemainingClicks returns the cached clicks a link has left
+func GetRemainingClicks(shortCode string) (int64, error) {
+	if RedisClient == nil {
+		return 0, ErrNotConnected
+	}
+	return redisResult(RedisClient.Get(ctx, RemainingClicksKey+shortCode).Int64())
+}
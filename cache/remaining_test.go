@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"url-shortener/config"
+	"url-shortener/storage"
+)
+
+// TestConsumeRemainingClickUnderConcurrency needs Redis (REDIS_ADDR, default
+// localhost:6379) and is skipped without it
+func TestConsumeRemainingClickUnderConcurrency(t *testing.T) {
+	cfg, err := config.FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	InitRedis(cfg.Redis)
+	if RedisClient == nil {
+		t.Skip("Redis is not available")
+	}
+	shortCode := "test-remaining"
+	RedisClient.Del(ctx, RemainingClicksKey+shortCode)
+	defer RedisClient.Del(ctx, RemainingClicksKey+shortCode)
+
+	if _, err := ConsumeRemainingClick(shortCode); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("unseeded counter: err = %v, want storage.ErrNotFound", err)
+	}
+	if err := SeedRemainingClicks(shortCode, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if remaining, err := ConsumeRemainingClick(shortCode); err == nil && remaining >= 0 {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed.Load() != 3 {
+		t.Errorf("%d redirects allowed, want 3", allowed.Load())
+	}
+}
@@ -0,0 +1,57 @@
+package cache
+
+import "time"
+
+// ResponseKey holds a cached HTTP response, keyed by a hash of the request's
+// host, path, query and negotiated headers
+const ResponseKey = "resp:" // resp:requestHash
+
+// CachedResponse is a response replayed to requests asking the same thing
+type CachedResponse struct {
+	Status   int               `codec:"s"`
+	Header   map[string]string `codec:"h,omitempty"`
+	Body     []byte            `codec:"b"`
+	StoredAt int64             `codec:"t"` // unix milliseconds
+}
+
+// CacheResponse stores a response for ttl
+func CacheResponse(key string, response *CachedResponse, ttl time.Duration) error {
+	if RedisClient == nil {
+		return nil
+	}
+
+	data, err := valueCodec.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	payload, err := encodePayload(data)
+	if err != nil {
+		return err
+	}
+
+	return redisError(RedisClient.Set(ctx, ResponseKey+key, payload, ttl).Err())
+}
+
+// GetCachedResponse returns a cached response
+func GetCachedResponse(key string) (*CachedResponse, error) {
+	if RedisClient == nil {
+		return nil, ErrNotConnected
+	}
+
+	payload, err := RedisClient.Get(ctx, ResponseKey+key).Result()
+	if err != nil {
+		return nil, redisError(err)
+	}
+
+	data, err := decodePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var response CachedResponse
+	if err := valueCodec.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"time"
+
+	"url-shortener/models"
+)
+
+// Store caches what the hot paths read about links: redirect entries,
+// links by short code and destination, stats and click counters. Misses
+// are storage.ErrNotFound, and ErrNotConnected is returned without Redis.
+// Everything else is still cached through the package functions.
+type Store interface {
+	GetRedirectEntry(shortCode string) (*RedirectEntry, error)
+	CacheRedirectEntry(shortCode string, entry *RedirectEntry) error
+	GetURLMapping(shortCode string) (*models.URL, error)
+	CacheURLMapping(shortCode string, urlData *models.URL) error
+	GetShortCodeForOriginalURL(ownerID *uint, originalURL string) (string, error)
+	CacheOriginalURLMapping(ownerID *uint, originalURL string, shortCode string) error
+	GetURLStats(shortCode string) (*models.StatsResponse, error)
+	CacheURLStats(shortCode string, stats *models.StatsResponse) error
+	GetClickCount(shortCode string) (int64, error)
+	// ConsumeRemainingClick uses up one of a link's remaining clicks, see
+	// the package function
+	ConsumeRemainingClick(shortCode string) (int64, error)
+	SeedRemainingClicks(shortCode string, remaining int) error
+	GetRemainingClicks(shortCode string) (int64, error)
+	// IncrementVelocity counts a redirect of a link with a velocity limit,
+	// see the package function
+	IncrementVelocity(shortCode string, windowStart time.Time, window time.Duration) (int64, error)
+	// InvalidateCache drops everything cached about a link but its
+	// remaining clicks
+	InvalidateCache(shortCode string)
+}
+
+// Links is the Store over Redis and the local cache
+var Links Store = redisStore{}
+
+// redisStore is the Store of the package functions
+type redisStore struct{}
+
+func (redisStore) GetRedirectEntry(shortCode string) (*RedirectEntry, error) {
+	return GetRedirectEntry(shortCode)
+}
+
+func (redisStore) CacheRedirectEntry(shortCode string, entry *RedirectEntry) error {
+	return CacheRedirectEntry(shortCode, entry)
+}
+
+func (redisStore) GetURLMapping(shortCode string) (*models.URL, error) {
+	return GetURLMapping(shortCode)
+}
+
+func (redisStore) CacheURLMapping(shortCode string, urlData *models.URL) error {
+	return CacheURLMapping(shortCode, urlData)
+}
+
+func (redisStore) GetShortCodeForOriginalURL(ownerID *uint, originalURL string) (string, error) {
+	return GetShortCodeForOriginalURL(ownerID, originalURL)
+}
+
+func (redisStore) CacheOriginalURLMapping(ownerID *uint, originalURL string, shortCode string) error {
+	return CacheOriginalURLMapping(ownerID, originalURL, shortCode)
+}
+
+func (redisStore) GetURLStats(shortCode string) (*models.StatsResponse, error) {
+	return GetURLStats(shortCode)
+}
+
+func (redisStore) CacheURLStats(shortCode string, stats *models.StatsResponse) error {
+	return CacheURLStats(shortCode, stats)
+}
+
+func (redisStore) GetClickCount(shortCode string) (int64, error) {
+	return GetClickCount(shortCode)
+}
+
+func (redisStore) ConsumeRemainingClick(shortCode string) (int64, error) {
+	return ConsumeRemainingClick(shortCode)
+}
+
+func (redisStore) SeedRemainingClicks(shortCode string, remaining int) error {
+	return SeedRemainingClicks(shortCode, remaining)
+}
+
+func (redisStore) GetRemainingClicks(shortCode string) (int64, error) {
+	return GetRemainingClicks(shortCode)
+}
+
+func (redisStore) IncrementVelocity(shortCode string, windowStart time.Time, window time.Duration) (int64, error) {
+	return IncrementVelocity(shortCode, windowStart, window)
+}
+
+func (redisStore) InvalidateCache(shortCode string) {
+	InvalidateCache(shortCode)
+}
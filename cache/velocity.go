@@ -0,0 +1,26 @@
+package cache
+
+import "time"
+
+// VelocityKey counts the redirects of a link with a velocity limit in a
+// window
+const VelocityKey = "url:velocity:" // url:velocity:shortCode:windowStart
+
+// IncrementVelocity counts a redirect of a link in the window starting at
+// windowStart and returns the redirects counted so far in it, shared by
+// every instance
+func IncrementVelocity(shortCode string, windowStart time.Time, window time.Duration) (int64, error) {
+	if RedisClient == nil {
+		return 0, ErrNotConnected
+	}
+
+	key := VelocityKey + shortCode + ":" + windowStart.Format("20060102T150405")
+	pipe := RedisClient.TxPipeline()
+	count := pipe.Incr(ctx, key)
+	// A little past the window, so clock skew between instances is harmless
+	pipe.Expire(ctx, key, window+5*time.Second)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, redisError(err)
+	}
+	return count.Val(), nil
+}
@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"url-shortener/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Daily HyperLogLogs of the visitors of each link, merged to count unique
+// visitors over any range of days in about 12KB per link and day
+const (
+	VisitorsKey    = "visitors:"     // visitors:<url id>:<YYYYMMDD>
+	VisitorSaltKey = "visitors:salt" // random salt shared by every instance
+)
+
+// Short codes each visitor followed lately, newest first, suggested when
+// they mistype one of them
+const (
+	RecentCodesKey  = "recent:" // recent:<visitor hash>, a sorted set scored by time
+	recentCodesKept = 20
+	recentCodesTTL  = 30 * 24 * time.Hour
+)
+
+// The salt is kept for good once read, so a visitor hashes the same way
+// every day and instance; it never leaves Redis and this process
+var (
+	visitorSaltMu sync.Mutex
+	visitorSalt   string
+)
+
+// RecordVisitor adds visitor, such as an IP address and user agent, to the
+// link's HyperLogLog for the day of at. Only a salted hash is stored.
+func RecordVisitor(urlID uint, at time.Time, visitor string) error {
+	if RedisClient == nil {
+		return ErrNotConnected
+	}
+
+	hash, err := VisitorHash(visitor)
+	if err != nil {
+		return err
+	}
+
+	key := visitorsKey(urlID, at)
+	_, err = RedisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.PFAdd(ctx, key, hash)
+		pipe.Expire(ctx, key, VisitorRetention())
+		return nil
+	})
+	return redisError(err)
+}
+
+// VisitorHash returns the salted hash identifying visitor in the unique
+// visitor counts, which click events also record so funnels can follow
+// visitors across links
+func VisitorHash(visitor string) (string, error) {
+	if RedisClient == nil {
+		return "", ErrNotConnected
+	}
+
+	salt, err := loadVisitorSalt()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(salt + "\x00" + visitor))
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// RecordRecentCode adds shortCode to the codes the visitor hashed as
+// visitorHash (see VisitorHash) followed lately, keeping the 20 latest for
+// 30 days
+func RecordRecentCode(visitorHash, shortCode string, at time.Time) error {
+	if RedisClient == nil {
+		return ErrNotConnected
+	}
+
+	key := RecentCodesKey + visitorHash
+	_, err := RedisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(at.Unix()), Member: shortCode})
+		pipe.ZRemRangeByRank(ctx, key, 0, -recentCodesKept-1)
+		pipe.Expire(ctx, key, recentCodesTTL)
+		return nil
+	})
+	return redisError(err)
+}
+
+// RecentCodes returns the short codes the visitor hashed as visitorHash
+// followed lately, newest first
+func RecentCodes(visitorHash string) ([]string, error) {
+	if RedisClient == nil {
+		return nil, ErrNotConnected
+	}
+	return redisResult(RedisClient.ZRevRange(ctx, RecentCodesKey+visitorHash, 0, -1).Result())
+}
+
+// CountVisitors estimates the unique visitors of a link over the UTC days
+// from from to to, both included, by merging their HyperLogLogs. The
+// standard error is 0.81%.
+func CountVisitors(urlID uint, from, to time.Time) (int64, error) {
+	if RedisClient == nil {
+		return 0, ErrNotConnected
+	}
+
+	var keys []string
+	for day := from.UTC().Truncate(24 * time.Hour); !day.After(to); day = day.AddDate(0, 0, 1) {
+		keys = append(keys, visitorsKey(urlID, day))
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	return redisResult(RedisClient.PFCount(ctx, keys...).Result())
+}
+
+// VisitorRetention reads UNIQUE_VISITOR_RETENTION, how long daily visitor
+// HyperLogLogs are kept (default 9480h, about 13 months)
+func VisitorRetention() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("UNIQUE_VISITOR_RETENTION")); err == nil && value > 0 {
+		return value
+	}
+	return 395 * 24 * time.Hour
+}
+
+func visitorsKey(urlID uint, day time.Time) string {
+	return VisitorsKey + strconv.FormatUint(uint64(urlID), 10) + ":" + day.UTC().Format("20060102")
+}
+
+// loadVisitorSalt reads the shared salt, creating it on first use
+func loadVisitorSalt() (string, error) {
+	visitorSaltMu.Lock()
+	defer visitorSaltMu.Unlock()
+	if visitorSalt != "" {
+		return visitorSalt, nil
+	}
+
+	salt, err := utils.GenerateToken(32)
+	if err != nil {
+		return "", err
+	}
+	if err := RedisClient.SetNX(ctx, VisitorSaltKey, salt, 0).Err(); err != nil {
+		return "", redisError(err)
+	}
+	// Another instance may have created it first
+	if visitorSalt, err = RedisClient.Get(ctx, VisitorSaltKey).Result(); err != nil {
+		return "", redisError(err)
+	}
+	return visitorSalt, nil
+}
@@ -0,0 +1,74 @@
+package captcha
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"url-shortener/outbound"
+)
+
+// Supported CAPTCHA providers and their verification endpoints
+var verifyURLs = map[string]string{
+	"turnstile": "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+	"hcaptcha":  "https://hcaptcha.com/siteverify",
+}
+
+// ErrInvalidToken is returned when the provider rejects the token
+var ErrInvalidToken = errors.New("captcha verification failed")
+
+var httpClient = outbound.NewClient(outbound.Options{Timeout: 5 * time.Second})
+
+// Enabled reports whether a CAPTCHA provider is configured via
+// CAPTCHA_PROVIDER and CAPTCHA_SECRET
+func Enabled() bool {
+	_, ok := verifyURLs[provider()]
+	return ok && os.Getenv("CAPTCHA_SECRET") != ""
+}
+
+// Verify checks a client token with the configured provider. It returns
+// ErrInvalidToken for rejected tokens and other errors when the provider
+// could not be reached.
+func Verify(token, remoteIP string) error {
+	verifyURL, ok := verifyURLs[provider()]
+	if !ok {
+		return fmt.Errorf("unsupported captcha provider %q", provider())
+	}
+
+	form := url.Values{
+		"secret":   {os.Getenv("CAPTCHA_SECRET")},
+		"response": {token},
+		"remoteip": {remoteIP},
+	}
+
+	resp, err := httpClient.PostForm(verifyURL, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("captcha provider returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+
+	if !result.Success {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+func provider() string {
+	return strings.ToLower(os.Getenv("CAPTCHA_PROVIDER"))
+}
@@ -0,0 +1,170 @@
+// Package certs serves HTTPS for the service's own host and its branded
+// short link domains with certificates obtained from an ACME CA such as
+// Let's Encrypt when ACME_ENABLED=true. Certificates are issued on the first
+// handshake for a host, or ahead of it by Provision, renewed before they
+// expire and stored encrypted in the database, so every instance serves the
+// same certificates and answers challenges started by another one.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/domains"
+	"url-shortener/encryption"
+	"url-shortener/outbound"
+	"url-shortener/storage"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Port TLS is served on unless TLS_PORT says otherwise
+const defaultTLSPort = 443
+
+// How long certificates are renewed before they expire
+const renewBefore = 30 * 24 * time.Hour
+
+var manager *autocert.Manager
+
+// Init reads the ACME settings: ACME_ENABLED, the contact ACME_EMAIL, the
+// CA's ACME_DIRECTORY_URL (Let's Encrypt by default) and ACME_HOSTS, a comma
+// separated list of hosts served besides the host of baseURL and the branded
+// domains. Certificates are only obtained when encryption at rest is
+// enabled, as they are stored with their private keys.
+func Init(baseURL string) {
+	enabled, _ := strconv.ParseBool(os.Getenv("ACME_ENABLED"))
+	if !enabled {
+		return
+	}
+	if !encryption.Enabled() {
+		slog.Error("ACME_ENABLED requires URL_ENCRYPTION_KEY to store certificate keys encrypted")
+		os.Exit(1)
+	}
+
+	directoryURL := os.Getenv("ACME_DIRECTORY_URL")
+	if directoryURL == "" {
+		directoryURL = autocert.DefaultACMEDirectory
+	}
+	manager = &autocert.Manager{
+		Prompt:      autocert.AcceptTOS,
+		Cache:       dbCache{},
+		HostPolicy:  hostPolicy(serviceHosts(baseURL, os.Getenv("ACME_HOSTS"))),
+		RenewBefore: renewBefore,
+		Email:       os.Getenv("ACME_EMAIL"),
+		Client: &acme.Client{
+			DirectoryURL: directoryURL,
+			HTTPClient:   outbound.NewClient(outbound.Options{Timeout: time.Minute}),
+		},
+	}
+	slog.Info("TLS certificates are obtained from an ACME directory", "directory_url", directoryURL)
+}
+
+// Enabled reports whether certificates are obtained through ACME
+func Enabled() bool {
+	return manager != nil
+}
+
+// Port returns the port HTTPS is served on, from TLS_PORT
+func Port() int {
+	if port, err := strconv.Atoi(os.Getenv("TLS_PORT")); err == nil && port > 0 && port <= 65535 {
+		return port
+	}
+	return defaultTLSPort
+}
+
+// TLSConfig returns the configuration of the HTTPS listener, picking the
+// certificate of each handshake's server name and answering TLS-ALPN-01
+// challenges
+func TLSConfig() *tls.Config {
+	return manager.TLSConfig()
+}
+
+// HTTPHandler answers HTTP-01 challenges and passes every other request to
+// fallback
+func HTTPHandler(fallback http.Handler) http.Handler {
+	return manager.HTTPHandler(fallback)
+}
+
+// Provision obtains a certificate for host unless a valid one is stored,
+// and keeps it renewed. It does nothing when ACME is disabled.
+func Provision(host string) error {
+	if manager == nil {
+		return nil
+	}
+	// Ask for the ECDSA certificate modern clients are served
+	_, err := manager.GetCertificate(&tls.ClientHelloInfo{
+		ServerName:       host,
+		CipherSuites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		SignatureSchemes: []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+		SupportedCurves:  []tls.CurveID{tls.CurveP256},
+	})
+	return err
+}
+
+// ProvisionAll provisions the certificate of every branded domain, logging
+// those that fail
+func ProvisionAll() {
+	for _, host := range domains.ShortDomainHosts() {
+		if err := Provision(host); err != nil {
+			slog.Error("Failed to obtain a TLS certificate", "host", host, "error", err)
+		}
+	}
+}
+
+// serviceHosts returns the host of baseURL and the comma separated extra
+// hosts, lowercased
+func serviceHosts(baseURL, extra string) map[string]bool {
+	hosts := make(map[string]bool)
+	if parsed, err := url.Parse(baseURL); err == nil && parsed.Hostname() != "" {
+		hosts[strings.ToLower(parsed.Hostname())] = true
+	}
+	for _, host := range strings.Split(extra, ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			hosts[host] = true
+		}
+	}
+	return hosts
+}
+
+// hostPolicy allows certificates for the service's own hosts and the
+// branded domains, so clients cannot have any name pointed at the service
+// request one
+func hostPolicy(hosts map[string]bool) autocert.HostPolicy {
+	return func(ctx context.Context, host string) error {
+		if hosts[host] || domains.ShortDomain(host) != nil {
+			return nil
+		}
+		return fmt.Errorf("%s is not a host of this service", host)
+	}
+}
+
+// dbCache stores the certificates, account key and challenge responses of
+// autocert in the database
+type dbCache struct{}
+
+func (dbCache) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := database.GetCertificateData(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, autocert.ErrCacheMiss
+	}
+	return data, err
+}
+
+func (dbCache) Put(ctx context.Context, key string, data []byte) error {
+	return database.PutCertificateData(ctx, key, data)
+}
+
+func (dbCache) Delete(ctx context.Context, key string) error {
+	return database.DeleteCertificateData(ctx, key)
+}
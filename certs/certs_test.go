@@ -0,0 +1,36 @@
+package certs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHostPolicy(t *testing.T) {
+	policy := hostPolicy(serviceHosts("https://Sho.rt:8443", " links.example.com, ,WWW.sho.rt"))
+	for host, allowed := range map[string]bool{
+		"sho.rt":            true,
+		"links.example.com": true,
+		"www.sho.rt":        true,
+		"evil.example.com":  false,
+		"":                  false,
+	} {
+		if err := policy(context.Background(), host); (err == nil) != allowed {
+			t.Errorf("policy(%q) = %v, want allowed %v", host, err, allowed)
+		}
+	}
+}
+
+func TestServiceHostsWithoutBaseURL(t *testing.T) {
+	if hosts := serviceHosts("", ""); len(hosts) != 0 {
+		t.Errorf("serviceHosts = %v, want none", hosts)
+	}
+}
+
+func TestPort(t *testing.T) {
+	for value, want := range map[string]int{"": 443, "8443": 8443, "0": 443, "70000": 443, "https": 443} {
+		t.Setenv("TLS_PORT", value)
+		if got := Port(); got != want {
+			t.Errorf("TLS_PORT=%q: Port() = %d, want %d", value, got, want)
+		}
+	}
+}
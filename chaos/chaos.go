@@ -0,0 +1,99 @@
+// Package chaos injects latency and errors into HTTP requests, database
+// queries and Redis commands, so resilience behaviors such as the degraded
+// health status and running without the cache can be exercised in
+// integration tests. Nothing is injected unless CHAOS_ENABLED=true; never
+// set it in production.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"url-shortener/models"
+)
+
+// ErrInjected is returned for injected failures
+var ErrInjected = errors.New("chaos: injected fault")
+
+// Current configuration; nil when nothing is injected
+var config atomic.Pointer[models.ChaosConfig]
+
+// Enabled reports whether fault injection was switched on with
+// CHAOS_ENABLED=true
+func Enabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("CHAOS_ENABLED"))
+	return enabled
+}
+
+// Init applies the initial configuration from CHAOS_LATENCY (a duration),
+// CHAOS_ERROR_RATE (0-1) and CHAOS_TARGETS (comma-separated http, db and
+// cache; default all) when fault injection is enabled
+func Init() {
+	if !Enabled() {
+		return
+	}
+
+	var initial models.ChaosConfig
+	if latency, err := time.ParseDuration(os.Getenv("CHAOS_LATENCY")); err == nil && latency > 0 {
+		initial.LatencyMs = int(latency.Milliseconds())
+	}
+	if rate, err := strconv.ParseFloat(os.Getenv("CHAOS_ERROR_RATE"), 64); err == nil && rate > 0 {
+		initial.ErrorRate = min(rate, 1)
+	}
+	if targets := os.Getenv("CHAOS_TARGETS"); targets != "" {
+		for _, target := range strings.Split(targets, ",") {
+			initial.Targets = append(initial.Targets, strings.TrimSpace(target))
+		}
+	}
+	Set(initial)
+}
+
+// Set replaces the configuration; a zero configuration injects nothing
+func Set(cfg models.ChaosConfig) {
+	if !Enabled() {
+		return
+	}
+	if cfg.LatencyMs == 0 && cfg.ErrorRate == 0 {
+		config.Store(nil)
+		return
+	}
+	config.Store(&cfg)
+}
+
+// Current returns the configuration in effect
+func Current() models.ChaosConfig {
+	if cfg := config.Load(); cfg != nil {
+		return *cfg
+	}
+	return models.ChaosConfig{}
+}
+
+// Inject delays an operation on target by the configured latency, or until
+// ctx is done, and then fails it at the configured error rate
+func Inject(ctx context.Context, target string) error {
+	cfg := config.Load()
+	if cfg == nil || (len(cfg.Targets) > 0 && !slices.Contains(cfg.Targets, target)) {
+		return nil
+	}
+
+	if cfg.LatencyMs > 0 {
+		timer := time.NewTimer(time.Duration(cfg.LatencyMs) * time.Millisecond)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+	if cfg.ErrorRate > 0 && rand.Float64() < cfg.ErrorRate {
+		return ErrInjected
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"url-shortener/models"
+)
+
+func TestSetRequiresEnabled(t *testing.T) {
+	t.Setenv("CHAOS_ENABLED", "")
+	Set(models.ChaosConfig{ErrorRate: 1})
+	if err := Inject(context.Background(), models.ChaosTargetDB); err != nil {
+		t.Fatalf("injected %v without CHAOS_ENABLED", err)
+	}
+}
+
+func TestInject(t *testing.T) {
+	t.Setenv("CHAOS_ENABLED", "true")
+	defer Set(models.ChaosConfig{})
+
+	Set(models.ChaosConfig{ErrorRate: 1, Targets: []string{models.ChaosTargetDB}})
+	if err := Inject(context.Background(), models.ChaosTargetDB); !errors.Is(err, ErrInjected) {
+		t.Errorf("db: expected ErrInjected, got %v", err)
+	}
+	if err := Inject(context.Background(), models.ChaosTargetHTTP); err != nil {
+		t.Errorf("http is not targeted, got %v", err)
+	}
+
+	// Latency is cut short by the caller's deadline
+	Set(models.ChaosConfig{LatencyMs: 10000})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := Inject(ctx, models.ChaosTargetCache); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("injection ignored the deadline, took %s", elapsed)
+	}
+
+	Set(models.ChaosConfig{})
+	if err := Inject(context.Background(), models.ChaosTargetDB); err != nil {
+		t.Errorf("zero configuration injected %v", err)
+	}
+}
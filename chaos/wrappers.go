@@ -0,0 +1,78 @@
+package chaos
+
+import (
+	"context"
+	"net"
+
+	"url-shortener/models"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// GormPlugin injects faults into database queries
+type GormPlugin struct{}
+
+// Name implements gorm.Plugin
+func (GormPlugin) Name() string {
+	return "chaos"
+}
+
+// Initialize implements gorm.Plugin
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	registrations := []error{
+		cb.Create().Before("gorm:create").Register("chaos:create", injectQuery),
+		cb.Query().Before("gorm:query").Register("chaos:query", injectQuery),
+		cb.Update().Before("gorm:update").Register("chaos:update", injectQuery),
+		cb.Delete().Before("gorm:delete").Register("chaos:delete", injectQuery),
+		cb.Row().Before("gorm:row").Register("chaos:row", injectQuery),
+		cb.Raw().Before("gorm:raw").Register("chaos:raw", injectQuery),
+	}
+	for _, err := range registrations {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func injectQuery(db *gorm.DB) {
+	if err := Inject(db.Statement.Context, models.ChaosTargetDB); err != nil {
+		db.AddError(err)
+	}
+}
+
+// RedisHook injects faults into Redis commands
+type RedisHook struct{}
+
+// DialHook implements redis.Hook
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+// ProcessHook implements redis.Hook
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := Inject(ctx, models.ChaosTargetCache); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+// ProcessPipelineHook implements redis.Hook
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := Inject(ctx, models.ChaosTargetCache); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		return next(ctx, cmds)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// checkTypeScript type-checks the TypeScript client with tsc, taken from
+// PATH or else fetched by npx
+func checkTypeScript(dir string) error {
+	args := []string{"-p", dir, "--noEmit"}
+	if _, err := exec.LookPath("tsc"); err == nil {
+		return runTool(exec.Command("tsc", args...))
+	}
+	if _, err := exec.LookPath("npx"); err != nil {
+		return fmt.Errorf("neither tsc nor npx is installed")
+	}
+	return runTool(exec.Command("npx", append([]string{"--yes", "-p", "typescript@5", "tsc"}, args...)...))
+}
+
+// checkPython compiles and imports the Python client, then type-checks it
+// when mypy is installed
+func checkPython(dir string) error {
+	python, err := pythonInterpreter()
+	if err != nil {
+		return err
+	}
+	if err := runTool(exec.Command(python, "-m", "compileall", "-q", filepath.Join(dir, "url_shortener"))); err != nil {
+		return err
+	}
+	importClient := exec.Command(python, "-c", "import url_shortener; url_shortener.Client('http://localhost')")
+	importClient.Env = append(os.Environ(), "PYTHONPATH="+dir)
+	if err := runTool(importClient); err != nil {
+		return err
+	}
+	if _, err := exec.LookPath("mypy"); err == nil {
+		return runTool(exec.Command("mypy", filepath.Join(dir, "url_shortener")))
+	}
+	return nil
+}
+
+// pythonInterpreter returns the Python 3 interpreter on PATH
+func pythonInterpreter() (string, error) {
+	for _, name := range []string{"python3", "python"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("python3 is not installed")
+}
+
+func runTool(cmd *exec.Cmd) error {
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(cmd.Path), err)
+	}
+	return nil
+}
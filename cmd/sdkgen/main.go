@@ -0,0 +1,121 @@
+// Command sdkgen generates typed TypeScript and Python clients from the
+// OpenAPI spec in docs/v1, and optionally checks them with each language's
+// toolchain:
+//
+//	go run ./cmd/sdkgen                 # write the clients to sdk/
+//	go run ./cmd/sdkgen -test           # and type-check them
+//	go run ./cmd/sdkgen -lang python    # only the Python client
+//
+// Operations are named after their operationId, set with @ID on each
+// handler; generation fails when one is missing so the clients never lose
+// an endpoint silently.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// generators maps each language to the function writing its client
+var generators = map[string]func(*api) (map[string]string, error){
+	"typescript": generateTypeScript,
+	"python":     generatePython,
+}
+
+// checks maps each language to the function testing its generated client
+var checks = map[string]func(dir string) error{
+	"typescript": checkTypeScript,
+	"python":     checkPython,
+}
+
+func main() {
+	specPath := flag.String("spec", "docs/v1/swagger.json", "OpenAPI (Swagger 2.0) spec to generate from")
+	outDir := flag.String("out", "sdk", "directory the clients are written to, one subdirectory per language")
+	langs := flag.String("lang", "typescript,python", "comma-separated languages to generate")
+	test := flag.Bool("test", false, "type-check and import the generated clients")
+	flag.Parse()
+
+	if err := run(*specPath, *outDir, strings.Split(*langs, ","), *test); err != nil {
+		fmt.Fprintln(os.Stderr, "sdkgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(specPath, outDir string, langs []string, test bool) error {
+	spec, err := loadSpec(specPath)
+	if err != nil {
+		return err
+	}
+	described, err := buildAPI(spec)
+	if err != nil {
+		return err
+	}
+
+	for _, lang := range langs {
+		lang = strings.TrimSpace(lang)
+		generate, ok := generators[lang]
+		if !ok {
+			return fmt.Errorf("unknown language %q", lang)
+		}
+		files, err := generate(described)
+		if err != nil {
+			return fmt.Errorf("%s: %w", lang, err)
+		}
+		if err := writeFiles(outDir, lang, files); err != nil {
+			return err
+		}
+		fmt.Printf("Generated the %s client in %s (%d operations)\n", lang, filepath.Join(outDir, lang), len(described.Operations))
+
+		if test {
+			if err := checks[lang](filepath.Join(outDir, lang)); err != nil {
+				return fmt.Errorf("%s client failed its checks: %w", lang, err)
+			}
+			fmt.Printf("Checked the %s client\n", lang)
+		}
+	}
+	return nil
+}
+
+// writeFiles replaces the language's directory under outDir with files, so
+// clients never keep code for removed operations
+func writeFiles(outDir, lang string, files map[string]string) error {
+	dir := filepath.Join(outDir, lang)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		target := filepath.Join(outDir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, []byte(files[path]), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generatedHeader marks a file as generated, in the comment syntax of its
+// language
+func generatedHeader(comment string) string {
+	return comment + " Code generated by cmd/sdkgen from the OpenAPI spec. DO NOT EDIT.\n"
+}
+
+// packageVersion turns the API version into a semantic version, e.g. 1.0
+// becomes 1.0.0
+func packageVersion(version string) string {
+	parts := strings.Split(version, ".")
+	for len(parts) < 3 {
+		parts = append(parts, "0")
+	}
+	return strings.Join(parts, ".")
+}
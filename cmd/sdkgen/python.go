@@ -0,0 +1,350 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Python keywords, and the names of the other arguments of a method, which
+// cannot name parameters
+var pythonReserved = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true, "async": true,
+	"await": true, "break": true, "class": true, "continue": true, "def": true, "del": true, "elif": true,
+	"else": true, "except": true, "finally": true, "for": true, "from": true, "global": true, "if": true,
+	"import": true, "in": true, "is": true, "lambda": true, "nonlocal": true, "not": true, "or": true,
+	"pass": true, "raise": true, "return": true, "try": true, "while": true, "with": true, "yield": true,
+	"self": true, "body": true, "content_type": true,
+}
+
+// generatePython returns the files of a Python client using only the
+// standard library, keyed by path
+func generatePython(spec *api) (map[string]string, error) {
+	var out strings.Builder
+	out.WriteString(generatedHeader("#"))
+	out.WriteString(pyImports)
+
+	// Aliases go first, as the typed dicts refer to them
+	for _, t := range spec.Types {
+		if !(t.Schema.Type == "object" && len(t.Schema.Properties) > 0) {
+			writePyComment(&out, t.Schema.Description)
+			fmt.Fprintf(&out, "%s = %s\n\n", t.Name, pyType(t.Schema, false))
+		}
+	}
+	for _, t := range spec.Types {
+		if t.Schema.Type == "object" && len(t.Schema.Properties) > 0 {
+			writePyComment(&out, t.Schema.Description)
+			fmt.Fprintf(&out, "%s = TypedDict(%q, {\n", t.Name, t.Name)
+			names := make([]string, 0, len(t.Schema.Properties))
+			for name := range t.Schema.Properties {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Fprintf(&out, "    %q: %s,\n", name, pyType(t.Schema.Properties[name], true))
+			}
+			out.WriteString("}, total=False)\n\n")
+		}
+	}
+
+	out.WriteString(pyRuntime)
+	for _, op := range spec.Operations {
+		writePyOperation(&out, op)
+	}
+
+	pyproject := fmt.Sprintf(pyProject, packageVersion(spec.Version), spec.Title)
+	return map[string]string{
+		"python/url_shortener/client.py":   out.String(),
+		"python/url_shortener/__init__.py": generatedHeader("#") + pyInit,
+		"python/url_shortener/py.typed":    "",
+		"python/pyproject.toml":            pyproject,
+		"python/README.md":                 pyReadme,
+	}, nil
+}
+
+func writePyOperation(out *strings.Builder, op operation) {
+	args := []string{"self"}
+	var pathArgs, queryArgs []string
+	for _, p := range op.PathParams {
+		name := pyParamName(p.Name)
+		args = append(args, name+": "+pyType(p.Schema, false))
+		pathArgs = append(pathArgs, fmt.Sprintf("%q: %s", p.Name, name))
+	}
+	switch {
+	case op.RawBody && op.BodyOpt:
+		args = append(args, "body: Optional[bytes] = None")
+	case op.RawBody:
+		args = append(args, "body: bytes")
+	case op.Body != nil && op.BodyOpt:
+		args = append(args, "body: Optional["+pyType(op.Body, false)+"] = None")
+	case op.Body != nil:
+		args = append(args, "body: "+pyType(op.Body, false))
+	}
+
+	var keywords []string
+	for _, p := range op.Query {
+		name := pyParamName(p.Name)
+		if p.Required {
+			keywords = append(keywords, name+": "+pyType(p.Schema, false))
+		} else {
+			keywords = append(keywords, name+": Optional["+pyType(p.Schema, false)+"] = None")
+		}
+		queryArgs = append(queryArgs, fmt.Sprintf("%q: %s", p.Name, name))
+	}
+	if op.RawBody {
+		keywords = append(keywords, "content_type: Optional[str] = None")
+	}
+	if len(keywords) > 0 {
+		args = append(args, "*")
+		args = append(args, keywords...)
+	}
+
+	call := []string{fmt.Sprintf("%q", op.Method), fmt.Sprintf("%q", op.Path)}
+	if len(pathArgs) > 0 {
+		call = append(call, "path={"+strings.Join(pathArgs, ", ")+"}")
+	}
+	if len(queryArgs) > 0 {
+		call = append(call, "query={"+strings.Join(queryArgs, ", ")+"}")
+	}
+	switch {
+	case op.RawBody:
+		call = append(call, "raw=body", "content_type=content_type")
+	case op.Body != nil:
+		call = append(call, "body=body")
+	}
+	call = append(call, fmt.Sprintf("kind=%q", op.Kind))
+
+	fmt.Fprintf(out, "    def %s(%s) -> %s:\n", snakeCase(op.ID), strings.Join(args, ", "), pyResult(op))
+	fmt.Fprintf(out, "        %q\n", strings.TrimSpace(op.Summary)+"\n\n"+op.Method+" "+op.Path)
+	fmt.Fprintf(out, "        return self._request(%s)\n\n", strings.Join(call, ", "))
+}
+
+// pyResult is the type an operation returns
+func pyResult(op operation) string {
+	switch op.Kind {
+	case responseNone:
+		return "None"
+	case responseText:
+		return "str"
+	case responseBinary:
+		return "bytes"
+	case responseAuto:
+		return "Union[" + pyType(op.Response, false) + ", str]"
+	default:
+		return pyType(op.Response, false)
+	}
+}
+
+// pyType returns the Python type of a schema. References are quoted where
+// they may name a typed dict defined further down.
+func pyType(s *schema, quoteRefs bool) string {
+	switch {
+	case s.empty():
+		return "Any"
+	case s.Ref != "":
+		if quoteRefs {
+			return fmt.Sprintf("%q", typeName(s.Ref))
+		}
+		return typeName(s.Ref)
+	case len(s.AllOf) == 1:
+		return pyType(s.AllOf[0], quoteRefs)
+	case len(s.AllOf) > 1:
+		return "Dict[str, Any]"
+	case len(s.Enum) > 0:
+		var literals []string
+		for _, value := range s.Enum {
+			literal, _ := json.Marshal(value)
+			literals = append(literals, pyLiteral(string(literal)))
+		}
+		return "Literal[" + strings.Join(literals, ", ") + "]"
+	}
+
+	switch s.Type {
+	case "string":
+		return "str"
+	case "integer":
+		return "int"
+	case "number":
+		return "float"
+	case "boolean":
+		return "bool"
+	case "file":
+		return "bytes"
+	case "array":
+		return "List[" + pyType(s.Items, quoteRefs) + "]"
+	case "object":
+		if values, ok := s.additional(); ok && values != nil {
+			return "Dict[str, " + pyType(values, quoteRefs) + "]"
+		}
+		return "Dict[str, Any]"
+	}
+	return "Any"
+}
+
+// pyLiteral turns a JSON literal into a Python one
+func pyLiteral(literal string) string {
+	switch literal {
+	case "true":
+		return "True"
+	case "false":
+		return "False"
+	case "null":
+		return "None"
+	}
+	return literal
+}
+
+// pyParamName turns a parameter name into an argument name
+func pyParamName(name string) string {
+	name = strings.NewReplacer("-", "_", ".", "_").Replace(snakeCase(name))
+	if pythonReserved[name] {
+		return name + "_"
+	}
+	return name
+}
+
+func writePyComment(out *strings.Builder, text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if line != "" {
+			fmt.Fprintf(out, "#%s\n", prefixed(" ", line))
+		}
+	}
+}
+
+const pyImports = `
+from __future__ import annotations
+
+import json
+import urllib.error
+import urllib.parse
+import urllib.request
+from typing import Any, Dict, List, Literal, Mapping, Optional, TypedDict, Union
+
+`
+
+const pyRuntime = `class ApiError(Exception):
+    """Error answered by the API, with the code of its error catalog (GET /errors)"""
+
+    def __init__(self, status: int, code: str, message: str, body: Any = None) -> None:
+        super().__init__(message)
+        self.status = status
+        self.code = code
+        self.message = message
+        self.body = body
+
+
+class Client:
+    """Client for the URL Shortener API.
+
+    token is an API key, admin token or session access token, sent as a
+    bearer token.
+    """
+
+    def __init__(self, base_url: str, token: Optional[str] = None, timeout: float = 30.0,
+                 headers: Optional[Mapping[str, str]] = None) -> None:
+        self.base_url = base_url.rstrip("/")
+        self.token = token
+        self.timeout = timeout
+        self.headers = dict(headers or {})
+
+    def _request(self, method: str, template: str, path: Optional[Mapping[str, Any]] = None,
+                 query: Optional[Mapping[str, Any]] = None, body: Any = None, raw: Optional[bytes] = None,
+                 content_type: Optional[str] = None, kind: str = "json") -> Any:
+        url = template
+        for name, value in (path or {}).items():
+            url = url.replace("{" + name + "}", urllib.parse.quote(str(value), safe=""))
+        params = []
+        for name, value in (query or {}).items():
+            if value is None:
+                continue
+            for item in value if isinstance(value, list) else [value]:
+                if isinstance(item, bool):
+                    item = "true" if item else "false"
+                params.append((name, str(item)))
+        if params:
+            url += "?" + urllib.parse.urlencode(params)
+
+        headers = {"Accept": "application/json", **self.headers}
+        if self.token:
+            headers["Authorization"] = "Bearer " + self.token
+        data = None
+        if raw is not None:
+            data = raw
+            if content_type:
+                headers["Content-Type"] = content_type
+        elif body is not None:
+            data = json.dumps(body).encode()
+            headers["Content-Type"] = "application/json"
+
+        request = urllib.request.Request(self.base_url + url, data=data, headers=headers, method=method)
+        try:
+            with urllib.request.urlopen(request, timeout=self.timeout) as response:
+                payload = response.read()
+                response_type = response.headers.get("Content-Type", "")
+        except urllib.error.HTTPError as err:
+            payload = err.read()
+            try:
+                detail = json.loads(payload)
+            except ValueError:
+                detail = {}
+            if not isinstance(detail, dict):
+                detail = {}
+            message = detail.get("error") or payload.decode(errors="replace") or str(err.reason)
+            raise ApiError(err.code, detail.get("code", ""), message, detail) from None
+
+        if kind == "none":
+            return None
+        if kind == "binary":
+            return payload
+        if kind == "text" or (kind == "auto" and "json" not in response_type):
+            return payload.decode()
+        return json.loads(payload) if payload else None
+
+`
+
+const pyInit = `
+from .client import ApiError, Client
+
+__all__ = ["ApiError", "Client"]
+`
+
+const pyProject = `[build-system]
+requires = ["setuptools>=61"]
+build-backend = "setuptools.build_meta"
+
+[project]
+name = "url-shortener-client"
+version = "%s"
+description = "Typed client for the %s, generated from its OpenAPI spec"
+readme = "README.md"
+license = {text = "MIT"}
+requires-python = ">=3.8"
+
+[tool.setuptools.package-data]
+url_shortener = ["py.typed"]
+`
+
+const pyReadme = `# url-shortener-client
+
+Typed Python client for the URL Shortener API, generated from its OpenAPI spec
+by ` + "`make sdk`" + `. Do not edit; regenerate instead. It needs only the standard
+library.
+
+` + "```python" + `
+from url_shortener import ApiError, Client
+
+client = Client("https://sho.rt", token=os.environ["SHORTENER_API_KEY"])
+link = client.shorten_url({"url": "https://example.com/spring"})
+try:
+    stats = client.get_url_stats(link["short_code"])
+except ApiError as err:
+    if err.code == "LINK_NOT_FOUND":
+        ...
+` + "```" + `
+
+Every operation of the API is a method named after its operation ID in
+snake_case. Path parameters come first, then the request body; query
+parameters are keyword arguments. Responses are the decoded JSON, typed with
+TypedDicts.
+`
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const specPath = "../../docs/v1/swagger.json"
+
+func loadTestAPI(t *testing.T) *api {
+	t.Helper()
+	spec, err := loadSpec(specPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	described, err := buildAPI(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return described
+}
+
+func TestEveryOperationIsGenerated(t *testing.T) {
+	spec, err := loadSpec(specPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	described := loadTestAPI(t)
+
+	operations := 0
+	for _, methods := range spec.Paths {
+		operations += len(methods)
+	}
+	if len(described.Operations) != operations {
+		t.Fatalf("got %d operations, the spec has %d", len(described.Operations), operations)
+	}
+
+	pythonNames := make(map[string]string)
+	for _, op := range described.Operations {
+		name := snakeCase(op.ID)
+		if other, taken := pythonNames[name]; taken {
+			t.Errorf("%s and %s are both %s in Python", other, op.ID, name)
+		}
+		pythonNames[name] = op.ID
+	}
+}
+
+func TestGenerationIsStable(t *testing.T) {
+	for lang, generate := range generators {
+		first, err := generate(loadTestAPI(t))
+		if err != nil {
+			t.Fatalf("%s: %v", lang, err)
+		}
+		second, err := generate(loadTestAPI(t))
+		if err != nil {
+			t.Fatalf("%s: %v", lang, err)
+		}
+		if !reflect.DeepEqual(first, second) {
+			t.Errorf("%s: generating twice gave different files", lang)
+		}
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"getURLStats":  "get_url_stats",
+		"listAPIKeys":  "list_api_keys",
+		"exportURLs":   "export_urls",
+		"getDBMetrics": "get_db_metrics",
+		"shortCode":    "short_code",
+		"login":        "login",
+	} {
+		if got := snakeCase(name); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestPythonClientCallsAPI(t *testing.T) {
+	python, err := pythonInterpreter()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/stats/promo 2024":
+			if r.Header.Get("Authorization") != "Bearer usk_test" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"short_code": "promo 2024", "click_count": 7, "max_age": r.URL.Query().Get("max_age")})
+		case "/links":
+			json.NewEncoder(w).Encode([]map[string]string{{"query": r.URL.RawQuery}})
+		case "/shorten":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]string{"short_code": "abc123", "original_url": body["url"]})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"LINK_NOT_FOUND","error":"Short URL not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	files, err := generatePython(loadTestAPI(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFiles(dir, "python", files); err != nil {
+		t.Fatal(err)
+	}
+
+	script := `
+import sys
+from url_shortener import ApiError, Client
+
+client = Client(sys.argv[1], token="usk_test")
+stats = client.get_url_stats("promo 2024", max_age=60)
+print(stats["short_code"], stats["click_count"], stats["max_age"])
+print(client.list_links(expired=True, limit=5)[0]["query"])
+print(client.shorten_url({"url": "https://example.com"})["original_url"])
+try:
+    client.list_link_versions("missing")
+except ApiError as err:
+    print(err.status, err.code, err.message)
+`
+	cmd := exec.Command(python, "-c", script, server.URL)
+	cmd.Env = append(os.Environ(), "PYTHONPATH="+filepath.Join(dir, "python"))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("python client failed: %v\n%s", err, out)
+	}
+
+	want := []string{
+		"promo 2024 7 60",
+		"limit=5&expired=true",
+		"https://example.com",
+		"404 LINK_NOT_FOUND Short URL not found",
+	}
+	if got := strings.Split(strings.TrimSpace(string(out)), "\n"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got output\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestTypeScriptClientCompiles(t *testing.T) {
+	if _, err := exec.LookPath("tsc"); err != nil {
+		t.Skip("tsc is not installed")
+	}
+	dir := t.TempDir()
+	files, err := generateTypeScript(loadTestAPI(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFiles(dir, "typescript", files); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkTypeScript(filepath.Join(dir, "typescript")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// swaggerSpec is the part of a Swagger 2.0 document clients are generated from
+type swaggerSpec struct {
+	Info struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Version     string `json:"version"`
+	} `json:"info"`
+	BasePath    string                              `json:"basePath"`
+	Paths       map[string]map[string]operationSpec `json:"paths"`
+	Definitions map[string]*schema                  `json:"definitions"`
+}
+
+type operationSpec struct {
+	OperationID string                  `json:"operationId"`
+	Summary     string                  `json:"summary"`
+	Consumes    []string                `json:"consumes"`
+	Produces    []string                `json:"produces"`
+	Parameters  []parameterSpec         `json:"parameters"`
+	Responses   map[string]responseSpec `json:"responses"`
+}
+
+type parameterSpec struct {
+	Name        string        `json:"name"`
+	In          string        `json:"in"`
+	Description string        `json:"description"`
+	Required    bool          `json:"required"`
+	Type        string        `json:"type"`
+	Items       *schema       `json:"items"`
+	Enum        []interface{} `json:"enum"`
+	Schema      *schema       `json:"schema"`
+}
+
+type responseSpec struct {
+	Schema *schema `json:"schema"`
+}
+
+// schema is a JSON schema of the spec. additionalProperties is either a
+// boolean or a schema.
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Description          string             `json:"description"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	Items                *schema            `json:"items"`
+	AllOf                []*schema          `json:"allOf"`
+	Enum                 []interface{}      `json:"enum"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+}
+
+// additional returns the schema of the values of a map, and whether the
+// schema is one
+func (s *schema) additional() (*schema, bool) {
+	raw := strings.TrimSpace(string(s.AdditionalProperties))
+	switch raw {
+	case "", "false":
+		return nil, false
+	case "true", "{}":
+		return nil, true
+	}
+	var values schema
+	if err := json.Unmarshal(s.AdditionalProperties, &values); err != nil {
+		return nil, true
+	}
+	return &values, true
+}
+
+func (s *schema) empty() bool {
+	return s == nil || (s.Ref == "" && s.Type == "" && len(s.AllOf) == 0 && len(s.Enum) == 0 && len(s.Properties) == 0)
+}
+
+// How an operation's response body is decoded
+const (
+	responseNone   = "none"   // no body
+	responseJSON   = "json"   // JSON of the response schema
+	responseText   = "text"   // plain text or HTML
+	responseBinary = "binary" // images and files
+	responseAuto   = "auto"   // JSON or text, following the Content-Type
+)
+
+// api is the spec reduced to what the language generators need, in a
+// stable order
+type api struct {
+	Title       string
+	Description string
+	Version     string
+	BasePath    string
+	Types       []namedType
+	Operations  []operation
+}
+
+type namedType struct {
+	Name   string
+	Schema *schema
+}
+
+type operation struct {
+	ID         string
+	Method     string
+	Path       string
+	Summary    string
+	PathParams []param // in the order of the path
+	Query      []param
+	Body       *schema // JSON request body
+	RawBody    bool    // the body is sent as is, e.g. a file or a form
+	BodyOpt    bool    // the body may be omitted
+	Response   *schema
+	Kind       string // decoding of the response body
+}
+
+type param struct {
+	Name        string
+	Description string
+	Required    bool
+	Schema      *schema
+}
+
+// loadSpec reads a Swagger 2.0 document from path
+func loadSpec(path string) (*swaggerSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var spec swaggerSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &spec, nil
+}
+
+// buildAPI checks that every operation can be generated and orders the
+// types and operations by name
+func buildAPI(spec *swaggerSpec) (*api, error) {
+	result := &api{
+		Title:       spec.Info.Title,
+		Description: spec.Info.Description,
+		Version:     spec.Info.Version,
+		BasePath:    strings.TrimSuffix(spec.BasePath, "/"),
+	}
+
+	names := make(map[string]string)
+	for key, definition := range spec.Definitions {
+		name := typeName(key)
+		if other, taken := names[name]; taken {
+			return nil, fmt.Errorf("definitions %s and %s would both be named %s", other, key, name)
+		}
+		names[name] = key
+		result.Types = append(result.Types, namedType{Name: name, Schema: definition})
+	}
+	sort.Slice(result.Types, func(i, j int) bool { return result.Types[i].Name < result.Types[j].Name })
+
+	seen := make(map[string]string)
+	for path, methods := range spec.Paths {
+		for method, described := range methods {
+			where := strings.ToUpper(method) + " " + path
+			if described.OperationID == "" {
+				return nil, fmt.Errorf("%s has no operationId; add an @ID to its handler", where)
+			}
+			if other, taken := seen[described.OperationID]; taken {
+				return nil, fmt.Errorf("%s and %s share operationId %s", other, where, described.OperationID)
+			}
+			seen[described.OperationID] = where
+
+			op, err := buildOperation(strings.ToUpper(method), path, described)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", where, err)
+			}
+			result.Operations = append(result.Operations, op)
+		}
+	}
+	sort.Slice(result.Operations, func(i, j int) bool { return result.Operations[i].ID < result.Operations[j].ID })
+	return result, nil
+}
+
+func buildOperation(method, path string, spec operationSpec) (operation, error) {
+	op := operation{ID: spec.OperationID, Method: method, Path: path, Summary: spec.Summary}
+
+	byName := make(map[string]param)
+	for _, p := range spec.Parameters {
+		parameter := param{Name: p.Name, Description: p.Description, Required: p.Required, Schema: &schema{Type: p.Type, Items: p.Items, Enum: p.Enum}}
+		switch p.In {
+		case "path":
+			byName[p.Name] = parameter
+		case "query":
+			op.Query = append(op.Query, parameter)
+		case "body":
+			op.Body = p.Schema
+			op.BodyOpt = !p.Required
+		case "formData":
+			op.RawBody = true
+			op.BodyOpt = true
+		case "header":
+			// Optional headers such as If-Match are left to the headers
+			// the clients are created with
+			if p.Required {
+				return op, fmt.Errorf("required parameters in header are not supported")
+			}
+		default:
+			return op, fmt.Errorf("parameters in %s are not supported", p.In)
+		}
+	}
+	for _, segment := range pathParams(path) {
+		parameter, ok := byName[segment]
+		if !ok {
+			return op, fmt.Errorf("path parameter %s is not described", segment)
+		}
+		parameter.Required = true
+		op.PathParams = append(op.PathParams, parameter)
+	}
+	sort.SliceStable(op.Query, func(i, j int) bool { return op.Query[i].Required && !op.Query[j].Required })
+
+	// Forms and other bodies that are not JSON are sent as given
+	if op.Body == nil && len(spec.Consumes) > 0 && !contains(spec.Consumes, "application/json") {
+		op.RawBody = true
+	}
+
+	var codes []string
+	for code := range spec.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	if len(codes) == 0 {
+		return op, fmt.Errorf("no successful response is described")
+	}
+	op.Response = spec.Responses[codes[0]].Schema
+	op.Kind = responseKind(spec.Produces, op.Response)
+	return op, nil
+}
+
+// responseKind decides how a response is decoded from the media types it
+// is produced as
+func responseKind(produces []string, response *schema) string {
+	isJSON, text := false, false
+	for _, mediaType := range produces {
+		switch {
+		case mediaType == "application/json":
+			isJSON = true
+		case strings.HasPrefix(mediaType, "text/"):
+			text = true
+		}
+	}
+	switch {
+	case len(produces) == 0 && response.empty():
+		return responseNone
+	case response != nil && response.Type == "file":
+		return responseBinary
+	case isJSON && text:
+		return responseAuto
+	case isJSON:
+		return responseJSON
+	case text:
+		return responseText
+	default:
+		return responseBinary
+	}
+}
+
+// pathParams returns the names of the parameters of a path template
+func pathParams(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, segment[1:len(segment)-1])
+		}
+	}
+	return names
+}
+
+// typeName names a definition without its Go package, e.g. models.URL is URL
+func typeName(ref string) string {
+	ref = strings.TrimPrefix(ref, "#/definitions/")
+	if i := strings.LastIndex(ref, "."); i >= 0 {
+		ref = ref[i+1:]
+	}
+	return ref
+}
+
+// snakeCase turns camelCase names into snake_case, keeping acronyms
+// together: getURLStats becomes get_url_stats
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var out strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			previous := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1]) && !pluralAcronym(runes, i)
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextLower) {
+				out.WriteByte('_')
+			}
+		}
+		out.WriteRune(unicode.ToLower(r))
+	}
+	return out.String()
+}
+
+// pluralAcronym reports whether the letter at i ends an acronym made plural,
+// like the L of URLs
+func pluralAcronym(runes []rune, i int) bool {
+	if runes[i+1] != 's' {
+		return false
+	}
+	return i+2 == len(runes) || unicode.IsUpper(runes[i+2])
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// firstLine returns the first line of a description, for doc comments
+func firstLine(text string) string {
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	tsIdentifier  = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+	tsUnsafeChars = regexp.MustCompile(`[^A-Za-z0-9_$]`)
+)
+
+// generateTypeScript returns the files of a dependency-free TypeScript
+// client using fetch, keyed by path
+func generateTypeScript(spec *api) (map[string]string, error) {
+	var out strings.Builder
+	out.WriteString(generatedHeader("//"))
+	out.WriteString("\n")
+
+	for _, t := range spec.Types {
+		writeTSDoc(&out, "", t.Schema.Description)
+		if t.Schema.Type == "object" && len(t.Schema.Properties) > 0 {
+			fmt.Fprintf(&out, "export interface %s %s\n\n", t.Name, tsObject(t.Schema, ""))
+		} else {
+			fmt.Fprintf(&out, "export type %s = %s;\n\n", t.Name, tsType(t.Schema, ""))
+		}
+	}
+
+	out.WriteString(tsRuntime)
+	for _, op := range spec.Operations {
+		writeTSOperation(&out, op)
+	}
+	out.WriteString("}\n")
+
+	packageJSON, err := json.MarshalIndent(map[string]interface{}{
+		"name":        "url-shortener-client",
+		"version":     packageVersion(spec.Version),
+		"description": "Typed client for the " + spec.Title + ", generated from its OpenAPI spec",
+		"license":     "MIT",
+		"type":        "module",
+		"main":        "dist/index.js",
+		"types":       "dist/index.d.ts",
+		"files":       []string{"dist"},
+		"scripts":     map[string]string{"build": "tsc", "check": "tsc --noEmit"},
+		"devDependencies": map[string]string{
+			"typescript": "^5.4.0",
+		},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"typescript/src/index.ts":  out.String(),
+		"typescript/package.json":  string(packageJSON) + "\n",
+		"typescript/tsconfig.json": tsConfig,
+		"typescript/README.md":     tsReadme,
+		"typescript/.gitignore":    "dist/\nnode_modules/\n",
+	}, nil
+}
+
+func writeTSOperation(out *strings.Builder, op operation) {
+	var args, pathArgs, queryFields []string
+	for _, p := range op.PathParams {
+		name := tsParamName(p.Name)
+		args = append(args, name+": "+tsType(p.Schema, ""))
+		pathArgs = append(pathArgs, tsKey(p.Name)+": "+name)
+	}
+	queryRequired := false
+	for _, p := range op.Query {
+		queryFields = append(queryFields, tsKey(p.Name)+optional(!p.Required)+": "+tsType(p.Schema, ""))
+		queryRequired = queryRequired || p.Required
+	}
+	// Optional arguments cannot come before required query parameters
+	bodyOpt, bodyUndefined := op.BodyOpt && !queryRequired, ""
+	if op.BodyOpt && queryRequired {
+		bodyUndefined = " | undefined"
+	}
+	switch {
+	case op.RawBody:
+		args = append(args, "body"+optional(bodyOpt)+": BodyInit"+bodyUndefined)
+	case op.Body != nil:
+		args = append(args, "body"+optional(bodyOpt)+": "+tsType(op.Body, "")+bodyUndefined)
+	}
+	if len(queryFields) > 0 {
+		query := "query: { " + strings.Join(queryFields, "; ") + " }"
+		if !queryRequired {
+			query += " = {}"
+		}
+		args = append(args, query)
+	}
+	if op.RawBody {
+		args = append(args, "contentType?: string")
+	}
+
+	var options []string
+	if len(pathArgs) > 0 {
+		options = append(options, "path: { "+strings.Join(pathArgs, ", ")+" }")
+	}
+	if len(queryFields) > 0 {
+		options = append(options, "query")
+	}
+	switch {
+	case op.RawBody:
+		options = append(options, "raw: body", "contentType")
+	case op.Body != nil:
+		options = append(options, "body")
+	}
+	options = append(options, `kind: "`+op.Kind+`"`)
+
+	writeTSDoc(out, "  ", op.Summary+"\n\n"+op.Method+" "+op.Path)
+	result := tsResult(op)
+	fmt.Fprintf(out, "  %s(%s): Promise<%s> {\n", op.ID, strings.Join(args, ", "), result)
+	fmt.Fprintf(out, "    return this.request<%s>(%q, %q, { %s });\n", result, op.Method, op.Path, strings.Join(options, ", "))
+	out.WriteString("  }\n\n")
+}
+
+// tsResult is the type an operation's promise resolves to
+func tsResult(op operation) string {
+	switch op.Kind {
+	case responseNone:
+		return "void"
+	case responseText:
+		return "string"
+	case responseBinary:
+		return "Blob"
+	case responseAuto:
+		return tsType(op.Response, "") + " | string"
+	default:
+		return tsType(op.Response, "")
+	}
+}
+
+// tsType returns the TypeScript type of a schema
+func tsType(s *schema, indent string) string {
+	switch {
+	case s.empty():
+		return "unknown"
+	case s.Ref != "":
+		return typeName(s.Ref)
+	case len(s.AllOf) > 0:
+		var parts []string
+		for _, part := range s.AllOf {
+			parts = append(parts, tsType(part, indent))
+		}
+		return strings.Join(parts, " & ")
+	case len(s.Enum) > 0:
+		var literals []string
+		for _, value := range s.Enum {
+			literal, _ := json.Marshal(value)
+			literals = append(literals, string(literal))
+		}
+		return strings.Join(literals, " | ")
+	}
+
+	switch s.Type {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "file":
+		return "Blob"
+	case "array":
+		item := tsType(s.Items, indent)
+		if strings.ContainsAny(item, "|&") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		if len(s.Properties) > 0 {
+			return tsObject(s, indent)
+		}
+		if values, ok := s.additional(); ok && values != nil {
+			return "Record<string, " + tsType(values, indent) + ">"
+		}
+		return "Record<string, unknown>"
+	}
+	return "unknown"
+}
+
+// tsObject returns the body of an interface for an object schema
+func tsObject(s *schema, indent string) string {
+	required := make(map[string]bool)
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out strings.Builder
+	out.WriteString("{\n")
+	for _, name := range names {
+		property := s.Properties[name]
+		writeTSDoc(&out, indent+"  ", property.Description)
+		fmt.Fprintf(&out, "%s  %s%s: %s;\n", indent, tsKey(name), optional(!required[name]), tsType(property, indent+"  "))
+	}
+	out.WriteString(indent + "}")
+	return out.String()
+}
+
+func writeTSDoc(out *strings.Builder, indent, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	text = strings.ReplaceAll(text, "*/", "*\\/")
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(out, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+	fmt.Fprintf(out, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(out, "%s *%s\n", indent, prefixed(" ", line))
+	}
+	fmt.Fprintf(out, "%s */\n", indent)
+}
+
+// tsKey quotes property names that are not identifiers
+func tsKey(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return name
+	}
+	return fmt.Sprintf("%q", name)
+}
+
+// tsParamName turns a parameter name into an argument name
+func tsParamName(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return name
+	}
+	return "p_" + tsUnsafeChars.ReplaceAllString(name, "_")
+}
+
+func optional(yes bool) string {
+	if yes {
+		return "?"
+	}
+	return ""
+}
+
+func prefixed(prefix, line string) string {
+	if line == "" {
+		return ""
+	}
+	return prefix + line
+}
+
+const tsRuntime = `/** Error answered by the API, with the code of its error catalog (GET /errors) */
+export class ApiError extends Error {
+  constructor(
+    readonly status: number,
+    readonly code: string,
+    message: string,
+    readonly body: unknown,
+  ) {
+    super(message);
+    this.name = "ApiError";
+  }
+}
+
+export interface ClientOptions {
+  /** API key, admin token or session access token, sent as a bearer token */
+  token?: string;
+  /** fetch implementation, defaulting to the global one */
+  fetch?: typeof fetch;
+  /** Headers added to every request */
+  headers?: Record<string, string>;
+}
+
+type QueryValue = string | number | boolean | undefined | null | Array<string | number | boolean>;
+
+interface RequestOptions {
+  path?: Record<string, string | number>;
+  query?: Record<string, QueryValue>;
+  body?: unknown;
+  raw?: BodyInit;
+  contentType?: string;
+  kind: "none" | "json" | "text" | "binary" | "auto";
+}
+
+export class Client {
+  private readonly baseUrl: string;
+  private readonly fetcher: typeof fetch;
+
+  constructor(baseUrl: string, private readonly options: ClientOptions = {}) {
+    this.baseUrl = baseUrl.replace(/\/+$/, "");
+    this.fetcher = options.fetch ?? ((input, init) => fetch(input, init));
+  }
+
+  private async request<T>(method: string, template: string, options: RequestOptions): Promise<T> {
+    let path = template.replace(/\{(\w+)\}/g, (_, name: string) => encodeURIComponent(String(options.path?.[name])));
+    const params = new URLSearchParams();
+    for (const [name, value] of Object.entries(options.query ?? {})) {
+      if (value === undefined || value === null) continue;
+      for (const item of Array.isArray(value) ? value : [value]) params.append(name, String(item));
+    }
+    const search = params.toString();
+    if (search) path += "?" + search;
+
+    const headers: Record<string, string> = { Accept: "application/json", ...this.options.headers };
+    if (this.options.token) headers.Authorization = "Bearer " + this.options.token;
+    let body: BodyInit | undefined;
+    if (options.raw !== undefined) {
+      body = options.raw;
+      if (options.contentType) headers["Content-Type"] = options.contentType;
+    } else if (options.body !== undefined) {
+      body = JSON.stringify(options.body);
+      headers["Content-Type"] = "application/json";
+    }
+
+    const response = await this.fetcher(this.baseUrl + path, { method, headers, body });
+    const contentType = response.headers.get("Content-Type") ?? "";
+    if (!response.ok) {
+      const text = await response.text();
+      let detail: { code?: string; error?: string } = {};
+      try {
+        detail = JSON.parse(text);
+      } catch {
+        // not a JSON error response
+      }
+      throw new ApiError(response.status, detail.code ?? "", detail.error ?? (text || response.statusText), detail);
+    }
+
+    switch (options.kind) {
+      case "none":
+        return undefined as T;
+      case "binary":
+        return (await response.blob()) as T;
+      case "text":
+        return (await response.text()) as T;
+      case "auto":
+        if (!contentType.includes("json")) return (await response.text()) as T;
+    }
+    const text = await response.text();
+    return (text ? JSON.parse(text) : undefined) as T;
+  }
+
+`
+
+const tsConfig = `{
+  "compilerOptions": {
+    "target": "ES2020",
+    "module": "ES2020",
+    "moduleResolution": "node",
+    "lib": ["ES2020", "DOM"],
+    "strict": true,
+    "declaration": true,
+    "outDir": "dist",
+    "rootDir": "src"
+  },
+  "include": ["src"]
+}
+`
+
+const tsReadme = `# url-shortener-client
+
+Typed TypeScript client for the URL Shortener API, generated from its OpenAPI
+spec by ` + "`make sdk`" + `. Do not edit; regenerate instead.
+
+` + "```ts" + `
+import { ApiError, Client } from "url-shortener-client";
+
+const client = new Client("https://sho.rt", { token: process.env.SHORTENER_API_KEY });
+const link = await client.shortenURL({ url: "https://example.com/spring" });
+try {
+  const stats = await client.getURLStats(link.short_code!);
+} catch (err) {
+  if (err instanceof ApiError && err.code === "LINK_NOT_FOUND") {
+    // ...
+  }
+}
+` + "```" + `
+
+Every operation of the API is a method named after its operation ID. Path
+parameters come first, then the request body, then an object of query
+parameters. Requests are sent with ` + "`fetch`" + `; pass your own in the options for
+older runtimes.
+`
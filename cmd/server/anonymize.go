@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+
+	"url-shortener/database"
+	"url-shortener/encryption"
+	"url-shortener/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// runAnonymize scrubs personal data from the configured database, which must
+// be a copy of production, so it can be used for staging performance tests.
+// The database name has to be confirmed since the changes cannot be undone.
+func runAnonymize(args []string) int {
+	flags := flag.NewFlagSet("anonymize", flag.ExitOnError)
+	confirm := flags.String("confirm", "", "Name of the database to anonymize, must match DB_NAME")
+	password := flags.String("password", "staging-password", "Password every user is reset to")
+	flags.Parse(args)
+
+	dbName := os.Getenv("DB_NAME")
+	if dbName == "" {
+		dbName = "urlshortener"
+	}
+	if *confirm != dbName {
+		fmt.Printf("This permanently scrubs personal data from database %q.\n", dbName)
+		fmt.Printf("Run it only against a copy, and confirm with: anonymize -confirm %s\n", dbName)
+		return 2
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+	if err != nil {
+		slog.Error("Failed to hash password", "error", err)
+		return 1
+	}
+
+	// A fresh salt per run, so hashed IP addresses can't be matched across copies
+	salt, err := utils.GenerateToken(16)
+	if err != nil {
+		slog.Error("Failed to generate salt", "error", err)
+		return 1
+	}
+
+	cfg := loadConfig()
+	encryption.Init()
+	if err := database.Connect(cfg.Database); err != nil {
+		slog.Error("Failed to connect to database", "error", err)
+		return 1
+	}
+
+	ctx := database.WithRoute(context.Background(), "anonymize")
+	result, err := database.Anonymize(ctx, string(passwordHash), salt)
+
+	tables := make([]string, 0, len(result))
+	for table := range result {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		fmt.Printf("%-20s %d rows scrubbed\n", table, result[table])
+	}
+
+	if err != nil {
+		slog.Error("Anonymization failed", "error", err)
+		return 1
+	}
+	fmt.Printf("Done. Users can log in as user-<id>@example.invalid with password %q\n", *password)
+	return 0
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener/artifacts"
+	"url-shortener/cache"
+	"url-shortener/captcha"
+	"url-shortener/chaos"
+	"url-shortener/config"
+	"url-shortener/database"
+	"url-shortener/encryption"
+	"url-shortener/expiry"
+	"url-shortener/geo"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/objectstore"
+	"url-shortener/policy"
+	"url-shortener/quota"
+	"url-shortener/retention"
+	"url-shortener/router"
+	"url-shortener/utils"
+
+	"gorm.io/gorm/schema"
+)
+
+// checkProblem is a failed or questionable check with a hint on fixing it
+type checkProblem struct {
+	fatal   bool
+	message string
+	hint    string
+}
+
+// runCheck validates configuration, connects to the database and Redis and
+// verifies the schema is migrated, printing a line per check. It returns a
+// non-zero exit code when anything would stop the server from working, so
+// it can gate deploys or run as an init container.
+func runCheck(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	timeout := flags.Duration("timeout", 10*time.Second, "Timeout for each connectivity check")
+	flags.Parse(args)
+
+	failed := false
+	report := func(name string, problems []checkProblem) {
+		if len(problems) == 0 {
+			fmt.Printf("[ok]   %s\n", name)
+			return
+		}
+		for _, problem := range problems {
+			label := "[warn]"
+			if problem.fatal {
+				label = "[FAIL]"
+				failed = true
+			}
+			fmt.Printf("%s %s: %s\n", label, name, problem.message)
+			if problem.hint != "" {
+				fmt.Printf("       %s\n", problem.hint)
+			}
+		}
+	}
+
+	cfg, configProblems := checkConfig()
+	report("configuration", configProblems)
+	if cfg == nil {
+		fmt.Println("Check failed, connectivity was not checked")
+		return 1
+	}
+
+	// Models need the encrypted serializer registered; an invalid key was
+	// reported above and would stop Init
+	if key := os.Getenv("URL_ENCRYPTION_KEY"); key == "" || encryption.ValidateKey(key) == nil {
+		encryption.Init()
+	} else {
+		schema.RegisterSerializer("encrypted", encryption.Serializer{})
+	}
+
+	databaseProblems := checkDatabase(cfg.Database)
+	report("database", databaseProblems)
+	if len(databaseProblems) == 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		report("migrations", checkMigrations(ctx))
+		cancel()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	report("redis", checkRedis(ctx, cfg.Redis))
+	cancel()
+
+	if failed {
+		fmt.Println("Check failed")
+		return 1
+	}
+	fmt.Println("All checks passed")
+	return 0
+}
+
+// checkConfig loads the configuration like the server does and checks the
+// settings of optional features, returning nil settings when the server
+// would refuse to start
+func checkConfig() (*config.Config, []checkProblem) {
+	var problems []checkProblem
+	cfg, err := config.Load()
+	var invalidConfig *config.ValidationError
+	if errors.As(err, &invalidConfig) {
+		for _, problem := range invalidConfig.Problems {
+			problems = append(problems, checkProblem{fatal: true, message: problem})
+		}
+		problems[len(problems)-1].hint = "the server refuses to start until these are fixed"
+	} else if err != nil {
+		problems = append(problems, checkProblem{fatal: true, message: err.Error(), hint: "fix or unset CONFIG_FILE"})
+	}
+
+	invalid := func(env, expected string) {
+		problems = append(problems, checkProblem{
+			fatal:   true,
+			message: fmt.Sprintf("%s=%q is not %s", env, os.Getenv(env), expected),
+			hint:    "fix or unset " + env,
+		})
+	}
+
+	for _, env := range []string{"TIMEOUT_REDIRECT", "TIMEOUT_DEFAULT", "TIMEOUT_EXPORT", "CLICK_EVENT_RETENTION", "API_KEY_STALE_AFTER", "LINK_ARCHIVE_AFTER", "CHAOS_LATENCY", "METRICS_PUBLISH_INTERVAL", "CLICK_FLUSH_INTERVAL", "ALIAS_RENAME_GRACE", "EXPIRED_LINK_CLEANUP_INTERVAL", "EXPIRED_LINK_RETENTION", "SERVER_READ_TIMEOUT", "SERVER_READ_HEADER_TIMEOUT", "SERVER_WRITE_TIMEOUT", "SERVER_IDLE_TIMEOUT", "SHUTDOWN_TIMEOUT", "UNIQUE_VISITOR_RETENTION", "ABUSE_SCORE_HALF_LIFE", "CONVERSION_ATTRIBUTION_WINDOW", "INTEGRITY_CHECK_INTERVAL"} {
+		if value := os.Getenv(env); value != "" {
+			if _, err := time.ParseDuration(value); err != nil {
+				invalid(env, "a duration such as 30s or 720h")
+			}
+		}
+	}
+	for _, env := range []string{"CLICK_WORKERS", "CLICK_QUEUE_SIZE", "CLICK_FLUSH_BATCH", "CLICK_EVENT_SHED_RATE", "SMTP_PORT", "OUTBOUND_RATE_LIMIT"} {
+		if value := os.Getenv(env); value != "" {
+			if n, err := strconv.Atoi(value); err != nil || n < 0 {
+				invalid(env, "a non-negative integer")
+			}
+		}
+	}
+	for _, env := range []string{"REQUIRE_APPROVAL", "REQUIRE_ADMIN_2FA", "API_KEY_AUTO_REVOKE_STALE", "ENABLE_PPROF", "OUTBOUND_ALLOW_PRIVATE_NETWORKS", "CHAOS_ENABLED", "ALLOW_ANONYMOUS_SHORTEN", "METRICS_AGGREGATION", "SHORTEN_ALLOW_PRIVATE_DESTINATIONS", "ACME_ENABLED", "ABUSE_SCORING", "INTEGRITY_CHECK_ON_STARTUP"} {
+		if value := os.Getenv(env); value != "" {
+			if _, err := strconv.ParseBool(value); err != nil {
+				invalid(env, "a boolean")
+			}
+		}
+	}
+	if value := os.Getenv("CHAOS_ERROR_RATE"); value != "" {
+		if n, err := strconv.ParseFloat(value, 64); err != nil || n < 0 || n > 1 {
+			invalid("CHAOS_ERROR_RATE", "a rate between 0 and 1")
+		}
+	}
+	if value := os.Getenv("MIRROR_PERCENT"); value != "" {
+		if n, err := strconv.ParseFloat(value, 64); err != nil || n < 0 || n > 100 {
+			invalid("MIRROR_PERCENT", "a percentage between 0 and 100")
+		}
+	}
+	if value := os.Getenv("TLS_PORT"); value != "" {
+		if n, err := strconv.Atoi(value); err != nil || n < 1 || n > 65535 {
+			invalid("TLS_PORT", "a port between 1 and 65535")
+		}
+	}
+	for _, env := range []string{"APPROVAL_WEBHOOK_URL", "API_KEY_ALERT_WEBHOOK_URL", "MIRROR_URL", "ACME_DIRECTORY_URL"} {
+		if value := os.Getenv(env); value != "" {
+			if parsed, err := url.Parse(value); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				invalid(env, "an http(s) URL")
+			}
+		}
+	}
+
+	if _, err := utils.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES")); err != nil {
+		invalid("TRUSTED_PROXIES", "a comma-separated list of IP addresses and CIDR ranges")
+	}
+
+	enums := map[string][]string{
+		"DB_DRIVER":                  database.Drivers,
+		"SWAGGER_ACCESS":             {router.SwaggerPublic, router.SwaggerAdmin, router.SwaggerDisabled},
+		"MIRROR_SHADOW":              {"database"},
+		"GEO_HEADERS":                geo.Providers(),
+		"ALIAS_RENAME_TARGET":        {models.AliasTargetShortURL, models.AliasTargetDestination},
+		"EXPIRED_LINK_CLEANUP":       {models.CleanupSoftDelete, models.CleanupPurge},
+		"SHORT_CODE_STRATEGY":        {models.ShortCodeStrategyRandom, models.ShortCodeStrategySequential},
+		"DEFAULT_DOMAIN_ENVIRONMENT": models.Environments,
+	}
+	for _, surface := range router.Surfaces {
+		prefix := strings.ToUpper(surface)
+		enums["REQUEST_LOG_"+prefix] = middleware.RequestLogVerbosities
+
+		if value := os.Getenv("CORS_" + prefix + "_MAX_AGE"); value != "" {
+			if d, err := time.ParseDuration(value); err != nil || d < 0 {
+				invalid("CORS_"+prefix+"_MAX_AGE", "a duration such as 10m")
+			}
+		}
+		if value := os.Getenv("CORS_" + prefix + "_CREDENTIALS"); value != "" {
+			if credentials, err := strconv.ParseBool(value); err != nil {
+				invalid("CORS_"+prefix+"_CREDENTIALS", "a boolean")
+			} else if origins := os.Getenv("CORS_" + prefix + "_ORIGINS"); credentials && (origins == "" || strings.Contains(origins, "*")) {
+				problems = append(problems, checkProblem{message: "CORS_" + prefix + "_CREDENTIALS is ignored as CORS_" + prefix + "_ORIGINS allows any origin", hint: "list the origins allowed to send credentials"})
+			}
+		}
+	}
+	for env, allowed := range enums {
+		if value := os.Getenv(env); value != "" && !contains(allowed, strings.ToLower(value)) {
+			invalid(env, "one of "+strings.Join(allowed, ", "))
+		}
+	}
+	for _, role := range policy.ApprovalRoles() {
+		if !contains(policy.CreatorRoles, role) {
+			invalid("APPROVAL_ROLES", "a comma-separated list of "+strings.Join(policy.CreatorRoles, ", "))
+			break
+		}
+	}
+
+	if _, err := expiry.PolicyFromEnv(); err != nil {
+		problems = append(problems, checkProblem{fatal: true, message: "LINK_DEFAULT_EXPIRY_DAYS, LINK_MAX_EXPIRY_DAYS or LINK_ANONYMOUS_EXPIRY_DAYS is invalid: " + err.Error(), hint: "use whole days, with the default and anonymous lifetimes no longer than the maximum"})
+	}
+	if _, err := retention.PolicyFromEnv(); err != nil {
+		problems = append(problems, checkProblem{fatal: true, message: "STATS_RETENTION_DAYS is invalid: " + err.Error(), hint: "list plans with their days of click history, like free=30,pro=730"})
+	}
+	if _, err := quota.PolicyFromEnv(); err != nil {
+		problems = append(problems, checkProblem{fatal: true, message: "LINK_QUOTAS is invalid: " + err.Error(), hint: "list plans with the links they may create per month, like free=100,pro=10000"})
+	}
+	if cfg != nil {
+		if conflicts := embeddedConflicts(cfg.Database); len(conflicts) > 0 {
+			problems = append(problems, checkProblem{fatal: true, message: strings.Join(conflicts, ", ") + " cannot be used with DB_DRIVER=embedded, which keeps links out of the SQL tables they read", hint: "unset them, or use DB_DRIVER=sqlite"})
+		}
+	}
+	if _, err := geo.PolicyFromEnv(); err != nil {
+		problems = append(problems, checkProblem{fatal: true, message: "CLICK_GEO_PRECISION or CLICK_GEO_ZONES is invalid: " + err.Error(), hint: "use none, country, region or city, and zones like EEA=country,CN=none"})
+	}
+
+	if _, err := objectstore.FromEnv("CLICK_EXPORT_"); err != nil && !errors.Is(err, objectstore.ErrNotConfigured) {
+		problems = append(problems, checkProblem{fatal: true, message: "Click export is misconfigured: " + err.Error(), hint: "set CLICK_EXPORT_ENDPOINT to an http(s) URL and provide access keys, or unset CLICK_EXPORT_BUCKET"})
+	}
+
+	if _, err := artifacts.FromEnv(); err != nil && !errors.Is(err, artifacts.ErrNotConfigured) {
+		problems = append(problems, checkProblem{fatal: true, message: "Artifact storage is misconfigured: " + err.Error(), hint: "fix the ARTIFACTS_ bucket settings, or set ARTIFACTS_SIGNING_SECRET with ARTIFACTS_DIR"})
+	}
+
+	if key := os.Getenv("URL_ENCRYPTION_KEY"); key != "" {
+		if err := encryption.ValidateKey(key); err != nil {
+			problems = append(problems, checkProblem{fatal: true, message: err.Error(), hint: "generate one with: openssl rand -base64 32"})
+		}
+	}
+	if acme, _ := strconv.ParseBool(os.Getenv("ACME_ENABLED")); acme && os.Getenv("URL_ENCRYPTION_KEY") == "" {
+		problems = append(problems, checkProblem{fatal: true, message: "ACME_ENABLED is set without URL_ENCRYPTION_KEY", hint: "set URL_ENCRYPTION_KEY, certificate keys are only stored encrypted"})
+	}
+	if os.Getenv("CAPTCHA_PROVIDER") != "" && !captcha.Enabled() {
+		problems = append(problems, checkProblem{
+			fatal:   true,
+			message: "CAPTCHA_PROVIDER is set but CAPTCHA is not usable",
+			hint:    "set CAPTCHA_PROVIDER to turnstile or hcaptcha and set CAPTCHA_SECRET",
+		})
+	}
+	if os.Getenv("SMTP_HOST") != "" && os.Getenv("SMTP_FROM") == "" {
+		problems = append(problems, checkProblem{fatal: true, message: "SMTP_HOST is set without SMTP_FROM", hint: "set SMTP_FROM to the sender address for replies"})
+	}
+	if os.Getenv("INBOUND_EMAIL_TOKEN") != "" && os.Getenv("INBOUND_EMAIL_ALLOWED_SENDERS") == "" {
+		problems = append(problems, checkProblem{message: "INBOUND_EMAIL_TOKEN is set but no senders are allowed", hint: "set INBOUND_EMAIL_ALLOWED_SENDERS or every email will be rejected"})
+	}
+	if chaos.Enabled() {
+		problems = append(problems, checkProblem{message: "CHAOS_ENABLED is set, faults may be injected into requests, queries and cache commands", hint: "unset CHAOS_ENABLED outside resilience tests"})
+	}
+	if token := os.Getenv("ADMIN_TOKEN"); token != "" && len(token) < 16 {
+		problems = append(problems, checkProblem{message: "ADMIN_TOKEN is shorter than 16 characters", hint: "use a long random token, e.g. openssl rand -hex 32"})
+	}
+
+	if err != nil {
+		return nil, problems
+	}
+	return cfg, problems
+}
+
+func checkDatabase(cfg config.Database) []checkProblem {
+	if err := database.Connect(cfg); err != nil {
+		return []checkProblem{{
+			fatal:   true,
+			message: err.Error(),
+			hint:    "check DATABASE_URL or DB_HOST, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME and DB_SSLMODE, and that the database accepts connections",
+		}}
+	}
+	return nil
+}
+
+func checkMigrations(ctx context.Context) []checkProblem {
+	pending, err := database.VerifySchema(ctx)
+	if err != nil {
+		return []checkProblem{{fatal: true, message: "failed to inspect schema: " + err.Error()}}
+	}
+
+	var problems []checkProblem
+	for _, difference := range pending {
+		problems = append(problems, checkProblem{fatal: true, message: difference})
+	}
+	if len(problems) > 0 {
+		problems[len(problems)-1].hint = "start the server once to apply migrations; it migrates on startup"
+	}
+	return problems
+}
+
+func checkRedis(ctx context.Context, cfg config.Redis) []checkProblem {
+	if err := cache.Connect(ctx, cfg); err != nil {
+		// The server runs without Redis, but an explicitly configured one should work
+		explicit := os.Getenv("REDIS_ADDR") != ""
+		return []checkProblem{{
+			fatal:   explicit,
+			message: err.Error(),
+			hint:    "check REDIS_ADDR, REDIS_PASSWORD and REDIS_DB; without Redis the server runs uncached",
+		}}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"url-shortener/cache"
+	"url-shortener/config"
+	"url-shortener/database"
+	"url-shortener/grpcapi"
+	"url-shortener/handlers"
+	"url-shortener/logging"
+	"url-shortener/middleware"
+	"url-shortener/notify"
+	"url-shortener/policy"
+	"url-shortener/utils"
+)
+
+// runCommand runs a subcommand and returns the process exit code
+func runCommand(name string, args []string) int {
+	switch name {
+	case "check":
+		return runCheck(args)
+	case "seed":
+		return runSeed(args)
+	case "anonymize":
+		return runAnonymize(args)
+	case "migrate":
+		return runMigrate(args)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+		fmt.Fprintln(os.Stderr, "Usage: server [command]")
+		fmt.Fprintln(os.Stderr, "Without a command the HTTP server starts. Commands:")
+		fmt.Fprintln(os.Stderr, "  check      Validate configuration, connectivity and migrations, exiting non-zero on failure")
+		fmt.Fprintln(os.Stderr, "  seed       Fill the database with demo users, links and click histories")
+		fmt.Fprintln(os.Stderr, "  anonymize  Scrub personal data from a copy of the production database")
+		fmt.Fprintln(os.Stderr, "  migrate    Migrate the database, or with -dry-run print the SQL and schema differences")
+		return 2
+	}
+}
+
+// loadConfig loads the configuration and hands each package its settings,
+// exiting with every invalid setting listed when it does not validate
+func loadConfig() *config.Config {
+	cfg, err := config.Load()
+	var invalid *config.ValidationError
+	if errors.As(err, &invalid) {
+		for _, problem := range invalid.Problems {
+			slog.Error("Invalid configuration", "problem", problem)
+		}
+		slog.Error("Fix the configuration above; `server check` reports every problem")
+		os.Exit(1)
+	}
+	if err != nil {
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+	if conflicts := embeddedConflicts(cfg.Database); len(conflicts) > 0 {
+		slog.Error("DB_DRIVER=embedded keeps links out of the SQL tables that these settings need; unset them or use the sqlite driver",
+			"settings", strings.Join(conflicts, ", "))
+		os.Exit(1)
+	}
+	// Logging was set up before CONFIG_FILE could set LOG_LEVEL or LOG_FORMAT
+	logging.Init()
+
+	cache.Configure(cfg.Cache)
+	middleware.ConfigureRateLimits(cfg.RateLimit)
+	middleware.ConfigureResponseCache(cfg.Cache.ResponseTTL)
+	handlers.ConfigureBaseURL(cfg.Server.BaseURL)
+	grpcapi.ConfigureBaseURL(cfg.Server.BaseURL)
+	notify.ConfigureBaseURL(cfg.Server.BaseURL)
+	utils.SetShortCodeLength(cfg.Links.ShortCodeLength)
+	return cfg
+}
+
+// embeddedConflicts lists the settings that are set but cannot work with
+// DB_DRIVER=embedded: approval, quotas, admin moderation and the expired
+// link cleanup read and update links in the SQL tables, while embedded mode
+// keeps them in bbolt
+func embeddedConflicts(cfg config.Database) []string {
+	if cfg.Driver != database.DriverEmbedded {
+		return nil
+	}
+	var conflicts []string
+	if policy.ApprovalRequired() {
+		conflicts = append(conflicts, "REQUIRE_APPROVAL")
+	}
+	if os.Getenv("LINK_QUOTAS") != "" {
+		conflicts = append(conflicts, "LINK_QUOTAS")
+	}
+	if os.Getenv("ADMIN_TOKEN") != "" {
+		conflicts = append(conflicts, "ADMIN_TOKEN")
+	}
+	if os.Getenv("EXPIRED_LINK_CLEANUP") != "" {
+		conflicts = append(conflicts, "EXPIRED_LINK_CLEANUP")
+	}
+	return conflicts
+}
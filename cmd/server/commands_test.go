@@ -0,0 +1,31 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"url-shortener/config"
+)
+
+func TestEmbeddedConflicts(t *testing.T) {
+	t.Setenv("REQUIRE_APPROVAL", "true")
+	t.Setenv("LINK_QUOTAS", "free=100")
+	t.Setenv("ADMIN_TOKEN", "0123456789abcdef")
+	t.Setenv("EXPIRED_LINK_CLEANUP", "purge")
+
+	if conflicts := embeddedConflicts(config.Database{Driver: "sqlite"}); conflicts != nil {
+		t.Errorf("sqlite conflicts = %v, want none", conflicts)
+	}
+	want := []string{"REQUIRE_APPROVAL", "LINK_QUOTAS", "ADMIN_TOKEN", "EXPIRED_LINK_CLEANUP"}
+	if conflicts := embeddedConflicts(config.Database{Driver: "embedded"}); !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("embedded conflicts = %v, want %v", conflicts, want)
+	}
+
+	t.Setenv("REQUIRE_APPROVAL", "false")
+	t.Setenv("LINK_QUOTAS", "")
+	t.Setenv("ADMIN_TOKEN", "")
+	t.Setenv("EXPIRED_LINK_CLEANUP", "")
+	if conflicts := embeddedConflicts(config.Database{Driver: "embedded"}); conflicts != nil {
+		t.Errorf("embedded conflicts = %v, want none when unset", conflicts)
+	}
+}
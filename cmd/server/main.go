@@ -1,17 +1,36 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+	_ "time/tzdata" // stats time zones, the runtime image has no zoneinfo
 
+	"url-shortener/artifacts"
+	"url-shortener/background"
 	"url-shortener/cache"
+	"url-shortener/certs"
+	"url-shortener/chaos"
 	"url-shortener/database"
-	"url-shortener/docs"
+	docs "url-shortener/docs/v1"
+	"url-shortener/domains"
+	"url-shortener/encryption"
+	"url-shortener/grpcapi"
 	"url-shortener/handlers"
+	"url-shortener/jobs"
+	"url-shortener/logging"
+	"url-shortener/mirror"
+	"url-shortener/notify"
+	"url-shortener/router"
 
-	"github.com/gin-gonic/gin"
-	swaggerfiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
+	"google.golang.org/grpc"
 )
 
 // @title URL Shortener API
@@ -30,57 +49,213 @@ import (
 // @BasePath /
 // @schemes http https
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
+// @description API key sent as "Bearer usk_...". Optional on public routes, where it applies the key's scopes and restrictions.
+
+// @securityDefinitions.apikey SessionAuth
+// @in header
+// @name Authorization
+// @description Dashboard session access token sent as "Bearer uss_...", obtained from /auth/login
+
+// @securityDefinitions.apikey AdminAuth
+// @in header
+// @name Authorization
+// @description API key with the admin scope, or the ADMIN_TOKEN, sent as "Bearer <token>"
+
 func main() {
+	// Structured logging, before anything logs
+	logging.Init()
+
+	// Subcommands run instead of the server
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
 	// Initialize Swagger docs
 	docs.SwaggerInfo.Title = "URL Shortener API"
 	docs.SwaggerInfo.Description = "A simple URL shortener service built with Go and Gin"
 	docs.SwaggerInfo.Version = "1.0"
-	docs.SwaggerInfo.Host = "localhost:8080"
-	docs.SwaggerInfo.BasePath = "/"
-	docs.SwaggerInfo.Schemes = []string{"http", "https"}
 
-	// Initialize database
-	database.InitDB()
+	// Load and validate the configuration, from CONFIG_FILE and the environment
+	cfg := loadConfig()
 
-	// Initialize Redis cache
-	cache.InitRedis()
+	// Send the docs' "Try it out" requests to this deployment
+	router.ConfigureSwagger(cfg.Server.BaseURL)
 
-	// Create Gin router
-	r := gin.Default()
+	// Initialize encryption at rest (before the database registers models)
+	encryption.Init()
 
-	// Add CORS middleware
-	r.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	// Initialize database
+	database.InitDB(cfg.Database)
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
+	// Initialize Redis cache, or cache in the Bolt file of a single binary
+	if cfg.Database.Driver == database.DriverEmbedded {
+		store, err := cache.NewBoltStore(database.Bolt)
+		if err != nil {
+			slog.Error("Failed to create the cache", "error", err)
+			os.Exit(1)
 		}
+		cache.Links = store
+		// Handlers invalidate through the package functions, which only
+		// reach Redis themselves
+		cache.OnInvalidate(store.InvalidateCache)
+	} else {
+		cache.InitRedis(cfg.Redis)
+	}
 
-		c.Next()
-	})
+	// Follow the branded domains other instances change
+	domains.FollowShortDomainChanges()
 
-	// Swagger documentation route
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
+	// TLS certificates from ACME, stored encrypted in the database
+	certs.Init(cfg.Server.BaseURL)
 
-	// API Routes
-	api := r.Group("/")
-	{
-		api.POST("/shorten", handlers.ShortenURL)
-		api.GET("/:shortCode", handlers.RedirectURL)
-		api.GET("/stats/:shortCode", handlers.GetURLStats)
-		api.GET("/health", handlers.HealthCheck)
+	// Storage for files generated in the background, such as exports
+	artifacts.Init()
+
+	// Start background jobs
+	jobs.StartStaleAPIKeyMonitor()
+	jobs.StartClickEventPartitionManager()
+	jobs.StartLinkArchiver()
+	jobs.StartClickCountReconciler()
+	jobs.StartIntegrityVerifier()
+	jobs.StartHookDeliveryRetrier()
+	jobs.StartWebhookExpiryNotifier()
+	jobs.StartClickGeoEnforcer()
+	jobs.StartLinkExpiryEnforcer()
+	jobs.StartStatsRetentionEnforcer()
+	jobs.StartMetricsPublisher()
+	jobs.StartClickEventExporter()
+	jobs.StartClickRollupBuilder()
+	jobs.StartExpiredLinkCleaner()
+	jobs.StartBulkOperationRunner()
+	jobs.StartArtifactCleaner()
+	jobs.StartLinkChangePruner()
+	jobs.StartLinkTable()
+	jobs.StartCertificateProvisioner()
+	handlers.StartClickRecorder()
+
+	// Mirror a sample of redirects to a shadow backend or resolver
+	handlers.RegisterMirrorShadows()
+	mirror.Start()
+
+	// Fault injection for resilience tests, configured once dependencies are up
+	chaos.Init()
+	if chaos.Enabled() {
+		slog.Warn("Fault injection is enabled (CHAOS_ENABLED); never use this in production")
 	}
 
+	// Create the router with the routes of every surface
+	r := router.New()
+
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	port := strconv.Itoa(cfg.Server.Port)
+	slog.Info("Server starting", "port", port)
+	if router.SwaggerAccess() != router.SwaggerDisabled {
+		slog.Info("Swagger docs available", "url", "http://localhost:"+port+"/swagger/"+router.LatestDocsVersion+"/index.html")
+	}
+
+	// ACME HTTP-01 challenges are answered on the plain listener
+	var handler http.Handler = r
+	if certs.Enabled() {
+		handler = certs.HTTPHandler(r)
+	}
+	server := newServer(":"+port, handler)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	// HTTPS for the service's host and branded domains
+	var tlsServer *http.Server
+	if certs.Enabled() {
+		tlsServer = newServer(":"+strconv.Itoa(certs.Port()), r)
+		tlsServer.TLSConfig = certs.TLSConfig()
+		slog.Info("HTTPS starting", "port", certs.Port())
+		go func() {
+			if err := tlsServer.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("HTTPS server failed", "error", err)
+				os.Exit(1)
+			}
+		}()
 	}
 
-	log.Printf("Server starting on port %s", port)
-	log.Printf("Swagger docs available at http://localhost:%s/swagger/index.html", port)
-	log.Fatal(r.Run(":" + port))
+	// The gRPC API shares the service layer with the REST handlers
+	var grpcServer *grpc.Server
+	if cfg.Server.GRPCPort != 0 {
+		listener, err := net.Listen("tcp", ":"+strconv.Itoa(cfg.Server.GRPCPort))
+		if err != nil {
+			slog.Error("Failed to listen for gRPC", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("gRPC API starting", "port", cfg.Server.GRPCPort)
+		grpcServer = grpcapi.NewServer()
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				slog.Error("gRPC server failed", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	// Stop gracefully so buffered clicks reach the database
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+
+	slog.Info("Shutting down...")
+	ctx, cancel := context.WithTimeout(context.Background(), serverTimeout("SHUTDOWN_TIMEOUT"))
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("Failed to finish in-flight requests", "error", err)
+	}
+	if tlsServer != nil {
+		if err := tlsServer.Shutdown(ctx); err != nil {
+			slog.Error("Failed to finish in-flight HTTPS requests", "error", err)
+		}
+	}
+	if grpcServer != nil {
+		stopGRPC(ctx, grpcServer)
+	}
+	handlers.StopClickRecorder()
+	if !notify.Drain(hookDrainTimeout) {
+		slog.Warn("Timed out recording hook deliveries, they are retried from the database")
+	}
+	if !background.Wait(backgroundDrainTimeout) {
+		slog.Warn("Timed out waiting for background writes such as last-used times")
+	}
+
+	// Connections are closed last, once nothing uses them
+	if err := cache.Close(); err != nil {
+		slog.Error("Failed to close Redis connection", "error", err)
+	}
+	if err := database.Close(); err != nil {
+		slog.Error("Failed to close database connections", "error", err)
+	}
+	slog.Info("Server stopped")
 }
+
+// stopGRPC lets in-flight gRPC calls finish, cancelling those still
+// running when ctx is done
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		slog.ErrorContext(ctx, "Failed to finish in-flight gRPC calls")
+		server.Stop()
+	}
+}
+
+// How long fired hook events may take to be recorded and sent on shutdown
+const hookDrainTimeout = 10 * time.Second
+
+// How long background writes started by requests may take on shutdown
+const backgroundDrainTimeout = 5 * time.Second
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/encryption"
+)
+
+// runMigrate migrates the database like the server does on startup, so
+// upgrades can migrate ahead of rolling out. With -dry-run it prints the
+// SQL that would be applied and how the schema would differ instead,
+// changing nothing, so the changes can be reviewed before a production
+// upgrade. A dry run exits with 3 when there are changes to apply.
+func runMigrate(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "Print the SQL and schema differences instead of migrating")
+	timeout := flags.Duration("timeout", 5*time.Minute, "Timeout for the migration")
+	flags.Parse(args)
+
+	cfg := loadConfig()
+	encryption.Init()
+	if err := database.Connect(cfg.Database); err != nil {
+		slog.Error("Failed to connect to database", "error", err)
+		return 1
+	}
+
+	if !*dryRun {
+		started := time.Now()
+		if err := database.Migrate(); err != nil {
+			slog.Error("Migration failed", "error", err)
+			return 1
+		}
+		fmt.Printf("Database migrated in %s\n", time.Since(started).Round(time.Millisecond))
+		return 0
+	}
+
+	ctx, cancel := context.WithTimeout(database.WithRoute(context.Background(), "migrate"), *timeout)
+	defer cancel()
+	plan, err := database.PlanMigration(ctx)
+	if plan != nil {
+		printMigrationPlan(plan)
+	}
+	if err != nil {
+		slog.Error("Dry run failed", "error", err)
+		return 1
+	}
+	if len(plan.Statements) > 0 || len(plan.Backfills) > 0 {
+		return 3
+	}
+	return 0
+}
+
+func printMigrationPlan(plan *database.MigrationPlan) {
+	if len(plan.Statements) == 0 && len(plan.Backfills) == 0 {
+		fmt.Println("The schema is up to date, nothing would be applied")
+		return
+	}
+
+	if len(plan.Differences) > 0 {
+		fmt.Println("-- Schema differences (+ added, - removed, ~ changed):")
+		for _, difference := range plan.Differences {
+			fmt.Println("--   " + difference)
+		}
+		fmt.Println()
+	}
+	if len(plan.Statements) > 0 {
+		fmt.Println("-- SQL that would be applied:")
+		for _, statement := range plan.Statements {
+			fmt.Println(statement + ";")
+		}
+		fmt.Println()
+	}
+	for _, backfill := range plan.Backfills {
+		fmt.Println("-- Then backfilled: " + backfill)
+	}
+}
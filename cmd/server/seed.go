@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/encryption"
+	"url-shortener/models"
+	"url-shortener/utils"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm/clause"
+)
+
+// Values demo data is drawn from
+var (
+	seedDomains   = []string{"example.com", "shop.example.com", "blog.example.org", "docs.example.net", "news.example.io", "events.example.co"}
+	seedPaths     = []string{"spring-sale", "product/4821", "blog/how-we-scaled", "docs/getting-started", "careers", "pricing", "webinar/2024-q3", "press/launch", "help/returns", "campaign/black-friday"}
+	seedSources   = []string{"twitter", "facebook", "linkedin", "newsletter", "email"}
+	seedReferrers = []string{"", "", "https://t.co/", "https://www.facebook.com/", "https://www.linkedin.com/", "https://www.google.com/", "https://news.ycombinator.com/"}
+	seedCountries = []string{"US", "US", "US", "GB", "DE", "FR", "IN", "BR", "CA", "JP", "AU", "VN"}
+	seedAgents    = []struct{ userAgent, device string }{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36", "desktop"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15", "desktop"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1", "mobile"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Mobile Safari/537.36", "mobile"},
+		{"Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1", "tablet"},
+	}
+)
+
+// Links and click events are written in batches of this size
+const seedBatchSize = 1000
+
+// runSeed fills the configured database with demo users, links and click
+// histories for load testing, demos and dashboard development. It migrates
+// the schema first and only adds rows, so it can be run repeatedly.
+func runSeed(args []string) int {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	links := flags.Int("links", 1000, "Number of links to create")
+	users := flags.Int("users", 10, "Number of dashboard users to create; the first is an admin")
+	clicks := flags.Int("clicks", 50, "Average clicks per link; counts are skewed so a few links get most clicks")
+	days := flags.Int("days", 90, "Spread link creation and clicks over this many past days")
+	password := flags.String("password", "demo-password", "Password for the demo users")
+	seed := flags.Int64("seed", 0, "Random seed for reproducible data (default: time based)")
+	flags.Parse(args)
+
+	if *links < 0 || *users < 0 || *clicks < 0 || *days < 1 {
+		fmt.Println("links, users and clicks must not be negative and days must be at least 1")
+		return 2
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(*seed))
+
+	cfg := loadConfig()
+	encryption.Init()
+	if err := database.Connect(cfg.Database); err != nil {
+		slog.Error("Failed to connect to database", "error", err)
+		return 1
+	}
+	if err := database.Migrate(); err != nil {
+		slog.Error("Failed to migrate database", "error", err)
+		return 1
+	}
+
+	ctx := database.WithRoute(context.Background(), "seed")
+	now := time.Now()
+	start := now.AddDate(0, 0, -*days)
+
+	// Clicks land in monthly partitions, which must exist for the whole history
+	for month := start; month.Before(now); month = month.AddDate(0, 1, 0) {
+		if err := database.EnsureClickEventPartitions(month); err != nil {
+			slog.Error("Failed to create click_events partitions", "error", err)
+			return 1
+		}
+	}
+
+	createdUsers, err := seedUsers(ctx, *users, *password)
+	if err != nil {
+		slog.Error("Failed to seed users", "error", err)
+		return 1
+	}
+
+	createdLinks, createdClicks, err := seedLinks(ctx, rng, *links, *clicks, start, now)
+	if err != nil {
+		slog.Error("Failed to seed links", "error", err)
+		return 1
+	}
+
+	fmt.Printf("Seeded %d users, %d links and %d clicks (seed %d)\n", createdUsers, createdLinks, createdClicks, *seed)
+	if createdUsers > 0 {
+		fmt.Printf("Log in as demo-user-1@example.com (admin) with password %q\n", *password)
+	}
+	return 0
+}
+
+func seedUsers(ctx context.Context, count int, password string) (int64, error) {
+	if count == 0 {
+		return 0, nil
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+
+	users := make([]models.User, count)
+	for i := range users {
+		users[i] = models.User{
+			Email:        fmt.Sprintf("demo-user-%d@example.com", i+1),
+			PasswordHash: string(passwordHash),
+			Role:         models.RoleUser,
+		}
+	}
+	users[0].Role = models.RoleAdmin
+
+	// Users from an earlier run are left as they are
+	result := database.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&users)
+	return result.RowsAffected, result.Error
+}
+
+func seedLinks(ctx context.Context, rng *rand.Rand, count, averageClicks int, start, now time.Time) (int64, int64, error) {
+	var linksCreated, clicksCreated int64
+	for offset := 0; offset < count; offset += seedBatchSize {
+		batch := make([]models.URL, min(seedBatchSize, count-offset))
+		for i := range batch {
+			batch[i] = seedLink(rng, averageClicks, start, now)
+		}
+
+		// Short codes already taken are skipped and keep a zero ID
+		result := database.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&batch)
+		if result.Error != nil {
+			return linksCreated, clicksCreated, result.Error
+		}
+		linksCreated += result.RowsAffected
+
+		var events []models.ClickEvent
+		for i := range batch {
+			if batch[i].ID != 0 {
+				events = append(events, seedClicks(rng, &batch[i], now)...)
+			}
+		}
+		copied, err := database.CopyClickEvents(ctx, events)
+		clicksCreated += copied
+		if err != nil {
+			return linksCreated, clicksCreated, err
+		}
+	}
+	return linksCreated, clicksCreated, nil
+}
+
+func seedLink(rng *rand.Rand, averageClicks int, start, now time.Time) models.URL {
+	createdAt := start.Add(time.Duration(rng.Int63n(int64(now.Sub(start)))))
+	source := seedSources[rng.Intn(len(seedSources))]
+	destination := fmt.Sprintf("https://%s/%s?utm_source=%s&utm_medium=social&ref=%d",
+		seedDomains[rng.Intn(len(seedDomains))], seedPaths[rng.Intn(len(seedPaths))], source, rng.Intn(100000))
+
+	link := models.URL{
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+		OriginalURL: destination,
+		ShortCode:   utils.GenerateShortCode(),
+		// Exponentially distributed, so most links get few clicks and some get many
+		ClickCount: int(math.Round(rng.ExpFloat64() * float64(averageClicks))),
+		Status:     models.StatusActive,
+		Tags:       []string{"demo", "channel:" + source},
+	}
+
+	switch roll := rng.Intn(100); {
+	case roll < 2:
+		link.Status = models.StatusPending
+		link.ClickCount = 0
+	case roll < 5:
+		expiresAt := createdAt.Add(time.Duration(rng.Int63n(int64(now.Sub(createdAt)) + 1)))
+		link.ExpiresAt = &expiresAt
+	case roll < 15:
+		expiresAt := now.AddDate(0, 0, 7+rng.Intn(60))
+		link.ExpiresAt = &expiresAt
+	}
+	return link
+}
+
+// seedClicks creates the click history matching a link's click count,
+// spread between its creation and expiry
+func seedClicks(rng *rand.Rand, link *models.URL, now time.Time) []models.ClickEvent {
+	end := now
+	if link.ExpiresAt != nil && link.ExpiresAt.Before(now) {
+		end = *link.ExpiresAt
+	}
+	span := int64(end.Sub(link.CreatedAt)) + 1
+
+	events := make([]models.ClickEvent, link.ClickCount)
+	for i := range events {
+		agent := seedAgents[rng.Intn(len(seedAgents))]
+		events[i] = models.ClickEvent{
+			ClickedAt:  link.CreatedAt.Add(time.Duration(rng.Int63n(span))),
+			URLID:      link.ID,
+			ShortCode:  link.ShortCode,
+			Referrer:   seedReferrers[rng.Intn(len(seedReferrers))],
+			UserAgent:  agent.userAgent,
+			Country:    seedCountries[rng.Intn(len(seedCountries))],
+			DeviceType: agent.device,
+		}
+	}
+	return events
+}
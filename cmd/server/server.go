@@ -0,0 +1,47 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// HTTP server timeouts unless configured otherwise. Reading and writing
+// whole requests is not limited by default: routes enforce their own
+// TIMEOUT_* deadlines, and imports and exports may take minutes.
+var defaultServerTimeouts = map[string]time.Duration{
+	"SERVER_READ_TIMEOUT":        0,
+	"SERVER_READ_HEADER_TIMEOUT": 10 * time.Second,
+	"SERVER_WRITE_TIMEOUT":       0,
+	"SERVER_IDLE_TIMEOUT":        2 * time.Minute,
+	"SHUTDOWN_TIMEOUT":           15 * time.Second,
+}
+
+// newServer returns the HTTP server listening on addr, with the timeouts
+// set by SERVER_READ_TIMEOUT, SERVER_READ_HEADER_TIMEOUT,
+// SERVER_WRITE_TIMEOUT and SERVER_IDLE_TIMEOUT
+func newServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       serverTimeout("SERVER_READ_TIMEOUT"),
+		ReadHeaderTimeout: serverTimeout("SERVER_READ_HEADER_TIMEOUT"),
+		WriteTimeout:      serverTimeout("SERVER_WRITE_TIMEOUT"),
+		IdleTimeout:       serverTimeout("SERVER_IDLE_TIMEOUT"),
+	}
+}
+
+// serverTimeout reads the timeout set by env, 0 meaning none
+func serverTimeout(env string) time.Duration {
+	value := os.Getenv(env)
+	if value == "" {
+		return defaultServerTimeouts[env]
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		slog.Warn("Invalid "+env+", using the default", "value", value, "default", defaultServerTimeouts[env])
+		return defaultServerTimeouts[env]
+	}
+	return timeout
+}
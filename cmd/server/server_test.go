@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewServerTimeouts(t *testing.T) {
+	t.Setenv("SERVER_READ_TIMEOUT", "30s")
+	t.Setenv("SERVER_IDLE_TIMEOUT", "soon")
+
+	server := newServer(":8080", nil)
+	if server.ReadTimeout != 30*time.Second {
+		t.Errorf("ReadTimeout = %s, want 30s", server.ReadTimeout)
+	}
+	if server.ReadHeaderTimeout != 10*time.Second {
+		t.Errorf("ReadHeaderTimeout = %s, want the 10s default", server.ReadHeaderTimeout)
+	}
+	if server.WriteTimeout != 0 {
+		t.Errorf("WriteTimeout = %s, want none", server.WriteTimeout)
+	}
+	if server.IdleTimeout != 2*time.Minute {
+		t.Errorf("IdleTimeout = %s, want the 2m default for an invalid value", server.IdleTimeout)
+	}
+}
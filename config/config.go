@@ -0,0 +1,414 @@
+// Package config loads the settings the server cannot start without: how
+// to reach the database and Redis, how long values are cached, short code
+// length, rate limits, and the address short links are served on. They are
+// read from environment variables, optionally preceded by a YAML or TOML
+// file named by CONFIG_FILE, and validated together so every mistake is
+// reported at startup. main loads them once and hands each package its part.
+//
+// Settings of optional features are still read from the environment by the
+// packages using them; values the file sets for them apply too.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Config holds the core settings
+type Config struct {
+	Server    Server
+	Database  Database
+	Redis     Redis
+	Cache     Cache
+	Links     Links
+	RateLimit RateLimits
+}
+
+// Server is where the API listens and short links are served
+type Server struct {
+	Port int // PORT
+	// GRPC_PORT the gRPC API listens on, 0 to not serve it
+	GRPCPort int
+	// BASE_URL short links are built on, such as https://sho.rt; empty to
+	// use the scheme and host of each request
+	BaseURL string
+}
+
+// Database is how to connect to PostgreSQL, or where the database file of
+// the file-based drivers is
+type Database struct {
+	Driver string // DB_DRIVER
+	Path   string // DB_PATH, the database file of the sqlite and embedded drivers
+	// DATABASE_URL, a connection URL or key=value string used instead of
+	// the DB_* connection settings below when set
+	URL                    string
+	Host                   string // DB_HOST
+	Port                   int    // DB_PORT
+	User                   string // DB_USER
+	Password               string // DB_PASSWORD
+	Name                   string // DB_NAME
+	SSLMode                string // DB_SSLMODE
+	SSLRootCert            string // DB_SSLROOTCERT
+	SSLCert                string // DB_SSLCERT
+	SSLKey                 string // DB_SSLKEY
+	ConnectTimeout         int    // DB_CONNECT_TIMEOUT, seconds, 0 for none
+	SearchPath             string // DB_SEARCH_PATH
+	ApplicationName        string // DB_APPLICATION_NAME
+	StatementCacheCapacity int    // DB_STATEMENT_CACHE_CAPACITY, 0 for the driver's default
+	// DB_PREFER_SIMPLE_PROTOCOL disables prepared statements, for poolers
+	// in transaction mode such as PgBouncer
+	PreferSimpleProtocol bool
+	SlowQueryThreshold   time.Duration // DB_SLOW_QUERY_THRESHOLD
+	CopyBatchSize        int           // DB_COPY_BATCH_SIZE
+}
+
+// Redis is how to connect to Redis
+type Redis struct {
+	Addr     string // REDIS_ADDR
+	Password string // REDIS_PASSWORD
+	DB       int    // REDIS_DB
+}
+
+// Cache is how values are cached
+type Cache struct {
+	Codec                string        // CACHE_CODEC, msgpack or json
+	CompressionThreshold int           // CACHE_COMPRESSION_THRESHOLD, bytes
+	LocalSize            int           // LOCAL_CACHE_SIZE, 0 disables the local cache
+	LocalTTL             time.Duration // LOCAL_CACHE_TTL
+	ResponseTTL          time.Duration // RESPONSE_CACHE_TTL, 0 disables the response cache
+}
+
+// Links is how links are created
+type Links struct {
+	ShortCodeLength int // SHORT_CODE_LENGTH of random and sequential codes
+}
+
+// RateLimits are the request limits of each rate limit scope
+type RateLimits struct {
+	Default  RateLimit // RATE_LIMIT_REQUESTS per RATE_LIMIT_WINDOW
+	Shorten  RateLimit // RATE_LIMIT_SHORTEN_REQUESTS per RATE_LIMIT_SHORTEN_WINDOW
+	Redirect RateLimit // RATE_LIMIT_REDIRECT_REQUESTS per RATE_LIMIT_REDIRECT_WINDOW
+	// RATE_LIMIT_IP_REQUESTS each IP address may make per window whoever
+	// makes them, 0 for no limit
+	PerIP int64
+}
+
+// RateLimit allows Requests per Window, 0 requests disabling the limit
+type RateLimit struct {
+	Requests int64
+	Window   time.Duration
+}
+
+// rateLimitScope is a limit with the prefix of its environment variables
+type rateLimitScope struct {
+	prefix string
+	limit  *RateLimit
+}
+
+func (l *RateLimits) scopes() []rateLimitScope {
+	return []rateLimitScope{
+		{"RATE_LIMIT_", &l.Default},
+		{"RATE_LIMIT_SHORTEN_", &l.Shorten},
+		{"RATE_LIMIT_REDIRECT_", &l.Redirect},
+	}
+}
+
+// Bounds of SHORT_CODE_LENGTH
+const (
+	MinShortCodeLength = 4
+	MaxShortCodeLength = 32
+)
+
+// Default returns the settings used for everything left unset
+func Default() *Config {
+	return &Config{
+		Server: Server{Port: 8080},
+		Database: Database{
+			Driver:             "postgres",
+			Path:               "url-shortener.db",
+			Host:               "localhost",
+			Port:               5432,
+			User:               "postgres",
+			Password:           "password",
+			Name:               "urlshortener",
+			SSLMode:            "disable",
+			SlowQueryThreshold: 200 * time.Millisecond,
+			CopyBatchSize:      10000,
+		},
+		Redis: Redis{Addr: "localhost:6379"},
+		Cache: Cache{
+			Codec:                "msgpack",
+			CompressionThreshold: 1024,
+			LocalSize:            10000,
+			LocalTTL:             5 * time.Second,
+			ResponseTTL:          5 * time.Second,
+		},
+		Links: Links{ShortCodeLength: 6},
+		RateLimit: RateLimits{
+			Default:  RateLimit{Requests: 600, Window: time.Minute},
+			Shorten:  RateLimit{Requests: 60, Window: time.Minute},
+			Redirect: RateLimit{Requests: 1200, Window: time.Minute}, // enough for a busy office behind one IP
+		},
+	}
+}
+
+// ValidationError lists every invalid setting
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Load reads the file named by CONFIG_FILE, if any, then the settings from
+// the environment, which wins over the file. It returns a *ValidationError
+// listing every invalid setting.
+func Load() (*Config, error) {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := applyFile(path); err != nil {
+			return nil, fmt.Errorf("failed to load CONFIG_FILE: %w", err)
+		}
+	}
+	return FromEnv()
+}
+
+// FromEnv reads the settings from the environment and validates them
+func FromEnv() (*Config, error) {
+	cfg := Default()
+	env := &envReader{}
+
+	cfg.Server.Port = env.int("PORT", cfg.Server.Port)
+	cfg.Server.GRPCPort = env.int("GRPC_PORT", cfg.Server.GRPCPort)
+	cfg.Server.BaseURL = env.string("BASE_URL", cfg.Server.BaseURL)
+
+	db := &cfg.Database
+	db.Driver = strings.ToLower(env.string("DB_DRIVER", db.Driver))
+	db.Path = env.string("DB_PATH", db.Path)
+	db.URL = env.string("DATABASE_URL", db.URL)
+	db.Host = env.string("DB_HOST", db.Host)
+	db.Port = env.int("DB_PORT", db.Port)
+	db.User = env.string("DB_USER", db.User)
+	db.Password = env.string("DB_PASSWORD", db.Password)
+	db.Name = env.string("DB_NAME", db.Name)
+	db.SSLMode = env.string("DB_SSLMODE", db.SSLMode)
+	db.SSLRootCert = env.string("DB_SSLROOTCERT", db.SSLRootCert)
+	db.SSLCert = env.string("DB_SSLCERT", db.SSLCert)
+	db.SSLKey = env.string("DB_SSLKEY", db.SSLKey)
+	db.ConnectTimeout = env.int("DB_CONNECT_TIMEOUT", db.ConnectTimeout)
+	db.SearchPath = env.string("DB_SEARCH_PATH", db.SearchPath)
+	db.ApplicationName = env.string("DB_APPLICATION_NAME", db.ApplicationName)
+	db.StatementCacheCapacity = env.int("DB_STATEMENT_CACHE_CAPACITY", db.StatementCacheCapacity)
+	db.PreferSimpleProtocol = env.bool("DB_PREFER_SIMPLE_PROTOCOL", db.PreferSimpleProtocol)
+	db.SlowQueryThreshold = env.duration("DB_SLOW_QUERY_THRESHOLD", db.SlowQueryThreshold)
+	db.CopyBatchSize = env.int("DB_COPY_BATCH_SIZE", db.CopyBatchSize)
+
+	cfg.Redis.Addr = env.string("REDIS_ADDR", cfg.Redis.Addr)
+	cfg.Redis.Password = env.string("REDIS_PASSWORD", cfg.Redis.Password)
+	cfg.Redis.DB = env.int("REDIS_DB", cfg.Redis.DB)
+
+	cache := &cfg.Cache
+	cache.Codec = strings.ToLower(env.string("CACHE_CODEC", cache.Codec))
+	cache.CompressionThreshold = env.int("CACHE_COMPRESSION_THRESHOLD", cache.CompressionThreshold)
+	cache.LocalSize = env.int("LOCAL_CACHE_SIZE", cache.LocalSize)
+	cache.LocalTTL = env.duration("LOCAL_CACHE_TTL", cache.LocalTTL)
+	cache.ResponseTTL = env.duration("RESPONSE_CACHE_TTL", cache.ResponseTTL)
+
+	cfg.Links.ShortCodeLength = env.int("SHORT_CODE_LENGTH", cfg.Links.ShortCodeLength)
+
+	limits := &cfg.RateLimit
+	for _, scope := range limits.scopes() {
+		scope.limit.Requests = int64(env.int(scope.prefix+"REQUESTS", int(scope.limit.Requests)))
+		scope.limit.Window = env.duration(scope.prefix+"WINDOW", scope.limit.Window)
+	}
+	limits.PerIP = int64(env.int("RATE_LIMIT_IP_REQUESTS", int(limits.PerIP)))
+
+	if err := cfg.Validate(); err != nil {
+		var invalid *ValidationError
+		errors.As(err, &invalid)
+		env.problems = append(env.problems, invalid.Problems...)
+	}
+	if len(env.problems) > 0 {
+		return nil, &ValidationError{Problems: env.problems}
+	}
+	return cfg, nil
+}
+
+// Validate checks the settings are usable, returning a *ValidationError
+// listing those that are not
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.Server.Port >= 1 && c.Server.Port <= 65535, "PORT must be between 1 and 65535")
+	check(c.Server.GRPCPort >= 0 && c.Server.GRPCPort <= 65535, "GRPC_PORT must be between 1 and 65535, or 0 to disable the gRPC API")
+	check(c.Server.GRPCPort == 0 || c.Server.GRPCPort != c.Server.Port, "GRPC_PORT must differ from PORT")
+	if c.Server.BaseURL != "" {
+		parsed, err := url.Parse(c.Server.BaseURL)
+		check(err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "",
+			"BASE_URL must be an http(s) URL such as https://sho.rt")
+	}
+
+	db := c.Database
+	if db.FileBased() {
+		check(db.Path != "", "DB_PATH is required with DB_DRIVER %s", db.Driver)
+	} else if db.URL == "" {
+		check(db.Host != "", "DB_HOST is required")
+		check(db.Port >= 1 && db.Port <= 65535, "DB_PORT must be between 1 and 65535")
+		check(db.User != "", "DB_USER is required")
+		check(db.Name != "", "DB_NAME is required")
+		check(db.ConnectTimeout >= 0, "DB_CONNECT_TIMEOUT must not be negative")
+		check(db.StatementCacheCapacity >= 0, "DB_STATEMENT_CACHE_CAPACITY must not be negative")
+	}
+	if _, err := pgconn.ParseConfig(db.DSN()); err != nil && !db.FileBased() {
+		check(false, "the database connection settings are invalid: %v", err)
+	}
+	check(db.SlowQueryThreshold > 0, "DB_SLOW_QUERY_THRESHOLD must be positive")
+	check(db.CopyBatchSize > 0, "DB_COPY_BATCH_SIZE must be positive")
+
+	if _, _, err := net.SplitHostPort(c.Redis.Addr); err != nil {
+		check(false, "REDIS_ADDR must be a host:port address")
+	}
+	check(c.Redis.DB >= 0, "REDIS_DB must not be negative")
+
+	check(c.Cache.Codec == "msgpack" || c.Cache.Codec == "json", "CACHE_CODEC must be msgpack or json")
+	check(c.Cache.CompressionThreshold >= 0, "CACHE_COMPRESSION_THRESHOLD must not be negative")
+	check(c.Cache.LocalSize >= 0, "LOCAL_CACHE_SIZE must not be negative")
+	check(c.Cache.LocalTTL > 0, "LOCAL_CACHE_TTL must be positive")
+	check(c.Cache.ResponseTTL >= 0, "RESPONSE_CACHE_TTL must not be negative")
+
+	check(c.Links.ShortCodeLength >= MinShortCodeLength && c.Links.ShortCodeLength <= MaxShortCodeLength,
+		"SHORT_CODE_LENGTH must be between %d and %d", MinShortCodeLength, MaxShortCodeLength)
+
+	for _, scope := range c.RateLimit.scopes() {
+		check(scope.limit.Requests >= 0, "%sREQUESTS must not be negative", scope.prefix)
+		check(scope.limit.Window >= time.Second, "%sWINDOW must be at least 1s", scope.prefix)
+	}
+	check(c.RateLimit.PerIP >= 0, "RATE_LIMIT_IP_REQUESTS must not be negative")
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// FileBased reports whether the driver keeps the database in the DB_PATH
+// file rather than connecting to PostgreSQL
+func (d Database) FileBased() bool {
+	return d.Driver == "sqlite" || d.Driver == "embedded"
+}
+
+// DSN returns the connection string of the database, the path of its file
+// for file-based drivers
+func (d Database) DSN() string {
+	if d.FileBased() {
+		return d.Path
+	}
+	if d.URL != "" {
+		return d.URL
+	}
+
+	params := []string{
+		"host=" + dsnValue(d.Host),
+		"port=" + strconv.Itoa(d.Port),
+		"user=" + dsnValue(d.User),
+		"password=" + dsnValue(d.Password),
+		"dbname=" + dsnValue(d.Name),
+		"sslmode=" + dsnValue(d.SSLMode),
+	}
+
+	// Optional TLS certificates and connection settings
+	optional := []struct{ key, value string }{
+		{"sslrootcert", d.SSLRootCert},
+		{"sslcert", d.SSLCert},
+		{"sslkey", d.SSLKey},
+		{"search_path", d.SearchPath},
+		{"application_name", d.ApplicationName},
+	}
+	if d.ConnectTimeout > 0 {
+		optional = append(optional, struct{ key, value string }{"connect_timeout", strconv.Itoa(d.ConnectTimeout)})
+	}
+	if d.StatementCacheCapacity > 0 {
+		optional = append(optional, struct{ key, value string }{"statement_cache_capacity", strconv.Itoa(d.StatementCacheCapacity)})
+	}
+	for _, option := range optional {
+		if option.value != "" {
+			params = append(params, option.key+"="+dsnValue(option.value))
+		}
+	}
+	return strings.Join(params, " ")
+}
+
+// dsnValue quotes a connection string value when it is empty or contains
+// spaces, quotes or backslashes
+func dsnValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " '\\") {
+		return value
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+	return "'" + escaped + "'"
+}
+
+// envReader reads environment variables, falling back to defaults when
+// they are unset and recording those that do not parse
+type envReader struct {
+	problems []string
+}
+
+func (r *envReader) string(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func (r *envReader) int(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("%s=%q is not an integer", key, value))
+		return fallback
+	}
+	return n
+}
+
+func (r *envReader) duration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("%s=%q is not a duration such as 30s or 720h", key, value))
+		return fallback
+	}
+	return d
+}
+
+func (r *envReader) bool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("%s=%q is not a boolean", key, value))
+		return fallback
+	}
+	return b
+}
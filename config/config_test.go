@@ -0,0 +1,148 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("PORT", "9090")
+	t.Setenv("GRPC_PORT", "9091")
+	t.Setenv("BASE_URL", "https://sho.rt")
+	t.Setenv("DATABASE_URL", "postgres://app@db:5432/links")
+	t.Setenv("LOCAL_CACHE_TTL", "2s")
+	t.Setenv("SHORT_CODE_LENGTH", "8")
+	t.Setenv("RATE_LIMIT_SHORTEN_REQUESTS", "10")
+	t.Setenv("RATE_LIMIT_SHORTEN_WINDOW", "30s")
+
+	cfg, err := FromEnv()
+	if err != nil {
+		t.Fatalf("FromEnv() error = %v", err)
+	}
+	if cfg.Server.Port != 9090 || cfg.Server.GRPCPort != 9091 || cfg.Server.BaseURL != "https://sho.rt" {
+		t.Errorf("server = %+v", cfg.Server)
+	}
+	if cfg.Database.DSN() != "postgres://app@db:5432/links" {
+		t.Errorf("DSN = %q", cfg.Database.DSN())
+	}
+	if cfg.Cache.LocalTTL != 2*time.Second || cfg.Links.ShortCodeLength != 8 {
+		t.Errorf("cache = %+v, links = %+v", cfg.Cache, cfg.Links)
+	}
+	if cfg.RateLimit.Shorten != (RateLimit{Requests: 10, Window: 30 * time.Second}) {
+		t.Errorf("shorten limit = %+v", cfg.RateLimit.Shorten)
+	}
+	if cfg.RateLimit.Default != Default().RateLimit.Default {
+		t.Errorf("default limit = %+v, want the default", cfg.RateLimit.Default)
+	}
+}
+
+func TestFromEnvListsEveryProblem(t *testing.T) {
+	t.Setenv("PORT", "eighty")
+	t.Setenv("GRPC_PORT", "-1")
+	t.Setenv("BASE_URL", "sho.rt")
+	t.Setenv("DATABASE_URL", "postgres://app@db:notaport/links")
+	t.Setenv("SHORT_CODE_LENGTH", "2")
+	t.Setenv("RATE_LIMIT_REDIRECT_WINDOW", "500ms")
+
+	_, err := FromEnv()
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("FromEnv() error = %v, want a *ValidationError", err)
+	}
+	want := []string{"PORT", "GRPC_PORT", "BASE_URL", "database connection", "SHORT_CODE_LENGTH", "RATE_LIMIT_REDIRECT_WINDOW"}
+	if len(invalid.Problems) != len(want) {
+		t.Fatalf("problems = %q, want one for each of %q", invalid.Problems, want)
+	}
+	for i, setting := range want {
+		if !strings.Contains(invalid.Problems[i], setting) {
+			t.Errorf("problem %d = %q, want it about %s", i, invalid.Problems[i], setting)
+		}
+	}
+}
+
+func TestFromEnvSQLite(t *testing.T) {
+	t.Setenv("DB_DRIVER", "SQLite")
+	t.Setenv("DB_PATH", "/data/links.db")
+	// The PostgreSQL settings are not needed
+	t.Setenv("DB_HOST", "")
+
+	cfg, err := FromEnv()
+	if err != nil {
+		t.Fatalf("FromEnv() error = %v", err)
+	}
+	if !cfg.Database.FileBased() || cfg.Database.DSN() != "/data/links.db" {
+		t.Errorf("database = %+v, DSN = %q, want the sqlite file", cfg.Database, cfg.Database.DSN())
+	}
+
+	cfg.Database.Path = ""
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "DB_PATH") {
+		t.Errorf("Validate() without DB_PATH error = %v", err)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	files := []struct{ name, content, host string }{
+		{"config.yaml", "db:\n  host: yaml-db\n  name: links\nrate_limit:\n  shorten:\n    requests: 5\ntrusted_proxies:\n  - 10.0.0.0/8\n  - 192.168.0.0/16\n", "yaml-db"},
+		{"config.toml", "trusted_proxies = [\"10.0.0.0/8\", \"192.168.0.0/16\"]\n\n[db]\nhost = \"toml-db\"\nname = \"links\"\n\n[rate_limit.shorten]\nrequests = 5\n", "toml-db"},
+	}
+	for _, file := range files {
+		t.Run(file.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), file.name)
+			if err := os.WriteFile(path, []byte(file.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("CONFIG_FILE", path)
+			// The environment wins over the file
+			t.Setenv("DB_NAME", "from-env")
+			for _, key := range []string{"DB_HOST", "RATE_LIMIT_SHORTEN_REQUESTS", "TRUSTED_PROXIES"} {
+				t.Setenv(key, "")
+				os.Unsetenv(key)
+			}
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.Database.Host != file.host {
+				t.Errorf("DB host = %q", cfg.Database.Host)
+			}
+			if cfg.Database.Name != "from-env" {
+				t.Errorf("DB name = %q, want the environment's", cfg.Database.Name)
+			}
+			if cfg.RateLimit.Shorten.Requests != 5 {
+				t.Errorf("shorten requests = %d", cfg.RateLimit.Shorten.Requests)
+			}
+			// Settings outside the core are read from the environment
+			if got := os.Getenv("TRUSTED_PROXIES"); got != "10.0.0.0/8,192.168.0.0/16" {
+				t.Errorf("TRUSTED_PROXIES = %q", got)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsUnknownFileType(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.ini")
+	if err := os.WriteFile(path, []byte("PORT=80\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+
+	if _, err := Load(); err == nil {
+		t.Error("Load() accepted an .ini file")
+	}
+}
+
+func TestDSNQuotesValues(t *testing.T) {
+	db := Default().Database
+	db.Password = `it's a secret`
+	db.ApplicationName = "url-shortener"
+
+	want := `host=localhost port=5432 user=postgres password='it\'s a secret' dbname=urlshortener sslmode=disable application_name=url-shortener`
+	if got := db.DSN(); got != want {
+		t.Errorf("DSN = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// applyFile sets the environment variables a YAML (.yaml, .yml) or TOML
+// (.toml) file configures, except those already set. Keys are environment
+// variable names, case-insensitive, and nested tables are joined to their
+// parent's name with an underscore, so
+//
+//	db:
+//	  host: postgres
+//	rate_limit:
+//	  shorten:
+//	    requests: 10
+//
+// sets DB_HOST and RATE_LIMIT_SHORTEN_REQUESTS. Lists are joined with commas.
+func applyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("%s is not a .yaml, .yml or .toml file", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	settings := make(map[string]string)
+	if err := flatten("", values, settings); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, set := os.LookupEnv(key); !set {
+			os.Setenv(key, settings[key])
+		}
+	}
+	return nil
+}
+
+// flatten adds the settings of a file table to settings, named after
+// prefix and their keys
+func flatten(prefix string, table map[string]interface{}, settings map[string]string) error {
+	for key, value := range table {
+		name := strings.ToUpper(key)
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if err := flatten(name, value, settings); err != nil {
+				return err
+			}
+		case []interface{}:
+			items := make([]string, len(value))
+			for i, item := range value {
+				if _, nested := item.(map[string]interface{}); nested {
+					return fmt.Errorf("%s: lists of tables are not supported", name)
+				}
+				items[i] = fmt.Sprint(item)
+			}
+			settings[name] = strings.Join(items, ",")
+		case nil:
+			settings[name] = ""
+		default:
+			settings[name] = fmt.Sprint(value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// decayedScore is the SQL of an abuse score decayed until the time bound
+// first, with the half-life in seconds bound second
+const decayedScore = "abuse_scores.score * power(0.5, greatest(extract(epoch from (?::timestamptz - abuse_scores.updated_at)), 0) / ?)"
+
+// AddAbuseScore decays the abuse score of creator until now with halfLife
+// and adds weight to it
+func AddAbuseScore(ctx context.Context, creator string, weight float64, halfLife time.Duration, now time.Time) error {
+	return storeError(DB.WithContext(ctx).Exec(`
+		INSERT INTO abuse_scores (creator, score, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (creator) DO UPDATE SET
+			score = `+decayedScore+` + EXCLUDED.score,
+			updated_at = EXCLUDED.updated_at`,
+		creator, weight, now, now, halfLife.Seconds()).Error)
+}
+
+// GetAbuseScore returns the abuse score of creator as last stored, returning
+// storage.ErrNotFound when it has none
+func GetAbuseScore(ctx context.Context, creator string) (*models.AbuseScore, error) {
+	var score models.AbuseScore
+	if err := DB.WithContext(ctx).Where("creator = ?", creator).First(&score).Error; err != nil {
+		return nil, storeError(err)
+	}
+	return &score, nil
+}
+
+// ListAbuseScores returns up to limit abuse scores, the highest once
+// decayed until now with halfLife first, and overridden ones before all
+func ListAbuseScores(ctx context.Context, halfLife time.Duration, now time.Time, limit int) ([]models.AbuseScore, error) {
+	var scores []models.AbuseScore
+	err := DB.WithContext(ctx).
+		Order("override IS NULL").
+		Order(clause.Expr{SQL: decayedScore + " DESC", Vars: []interface{}{now, halfLife.Seconds()}}).
+		Limit(limit).Find(&scores).Error
+	return scores, storeError(err)
+}
+
+// SetAbuseOverride sets the level of creator whatever its score, or clears
+// it when level is nil
+func SetAbuseOverride(ctx context.Context, creator string, level *string, reason string, now time.Time) (*models.AbuseScore, error) {
+	score := models.AbuseScore{Creator: creator, UpdatedAt: now, Override: level, Reason: reason}
+	err := DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "creator"}},
+		DoUpdates: clause.AssignmentColumns([]string{"override", "reason"}),
+	}).Create(&score).Error
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return GetAbuseScore(ctx, creator)
+}
+
+// DeleteAbuseScore forgets the abuse score and override of creator,
+// reporting whether it had any
+func DeleteAbuseScore(ctx context.Context, creator string) (bool, error) {
+	result := DB.WithContext(ctx).Where("creator = ?", creator).Delete(&models.AbuseScore{})
+	return result.RowsAffected > 0, storeError(result.Error)
+}
+
+// CreateAbuseReport stores report unless its reporter already reported the
+// link, reporting whether it was stored
+func CreateAbuseReport(ctx context.Context, report *models.AbuseReport) (bool, error) {
+	result := DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(report)
+	return result.RowsAffected > 0, storeError(result.Error)
+}
+
+// ListAbuseReports returns the latest limit abuse reports, only those
+// against creator when it is not empty
+func ListAbuseReports(ctx context.Context, creator string, limit int) ([]models.AbuseReport, error) {
+	query := DB.WithContext(ctx).Order("created_at DESC").Limit(limit)
+	if creator != "" {
+		query = query.Where("creator = ?", creator)
+	}
+	var reports []models.AbuseReport
+	err := query.Find(&reports).Error
+	return reports, storeError(err)
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/models"
+)
+
+// GlobalStats sums up the links of the whole service as of now, with the top
+// most clicked live links
+func GlobalStats(ctx context.Context, top int, now time.Time) (*models.GlobalStatsResponse, error) {
+	db := DB.WithContext(ctx)
+	stats := &models.GlobalStatsResponse{ByStatus: make(map[string]int64), TopLinks: []models.TopLink{}}
+
+	var byStatus []struct {
+		Status string
+		Count  int64
+		Clicks int64
+	}
+	err := db.Model(&models.URL{}).Select("status, count(*) AS count, coalesce(sum(click_count), 0) AS clicks").
+		Group("status").Scan(&byStatus).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range byStatus {
+		stats.ByStatus[row.Status] = row.Count
+		stats.TotalURLs += row.Count
+		stats.TotalClicks += row.Clicks
+	}
+
+	var archived struct {
+		Count  int64
+		Clicks int64
+	}
+	err = db.Model(&models.ArchivedURL{}).Select("count(*) AS count, coalesce(sum(click_count), 0) AS clicks").Scan(&archived).Error
+	if err != nil {
+		return nil, err
+	}
+	stats.ArchivedURLs = archived.Count
+	stats.TotalClicks += archived.Clicks
+
+	if err := db.Model(&models.URL{}).Where("created_at >= ?", now.Add(-24*time.Hour)).Count(&stats.CreatedLast24h).Error; err != nil {
+		return nil, err
+	}
+
+	var links []models.URL
+	err = db.Select("short_code", "original_url", "click_count", "status", "created_at").
+		Order("click_count DESC, id").Limit(top).Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		stats.TopLinks = append(stats.TopLinks, models.TopLink{
+			ShortCode:   link.ShortCode,
+			OriginalURL: link.OriginalURL,
+			ClickCount:  int64(link.ClickCount),
+			Status:      link.Status,
+			CreatedAt:   link.CreatedAt,
+		})
+	}
+	return stats, nil
+}
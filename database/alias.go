@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RenameURL changes the short code of a link, keeping the old code as a
+// renamed alias resolving to it until graceUntil. Renaming a link back to
+// one of its own renamed aliases takes the alias over again.
+func RenameURL(ctx context.Context, urlID uint, from, to string, graceUntil time.Time) error {
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.URL{}).Where("id = ?", urlID).Update("short_code", to).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("alias = ? AND url_id = ?", to, urlID).Delete(&models.RenamedAlias{}).Error; err != nil {
+			return err
+		}
+		alias := models.RenamedAlias{Alias: from, URLID: urlID, ExpiresAt: graceUntil}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&alias).Error
+	})
+}
+
+// LiveShortCodes returns those of codes used by active links that are
+// neither expired nor inert, in no particular order
+func LiveShortCodes(ctx context.Context, codes []string) ([]string, error) {
+	var live []string
+	err := DB.WithContext(ctx).Model(&models.URL{}).
+		Where("short_code IN ? AND status = ? AND NOT inert AND (expires_at IS NULL OR expires_at > ?)", codes, models.StatusActive, time.Now()).
+		Pluck("short_code", &live).Error
+	return live, err
+}
+
+// RenamedAliasOwner returns the ID of the link a short code was renamed away
+// from, expired or not, and false when no link was
+func RenamedAliasOwner(ctx context.Context, alias string) (uint, bool, error) {
+	var aliases []models.RenamedAlias
+	err := DB.WithContext(ctx).Where("alias = ?", alias).Limit(1).Find(&aliases).Error
+	if err != nil || len(aliases) == 0 {
+		return 0, false, err
+	}
+	return aliases[0].URLID, true, nil
+}
+
+// FindRenamedAlias returns a renamed alias still in its grace period with
+// the current short code of its link, live or archived. Aliases of deleted
+// links are not found.
+func FindRenamedAlias(ctx context.Context, alias string, now time.Time) (*models.RenamedAlias, string, error) {
+	var row struct {
+		models.RenamedAlias
+		ShortCode string
+	}
+	err := DB.WithContext(ctx).Raw(`
+		SELECT r.*, COALESCE(u.short_code, a.short_code) AS short_code
+		FROM renamed_aliases r
+		LEFT JOIN urls u ON u.id = r.url_id AND u.deleted_at IS NULL
+		LEFT JOIN archived_urls a ON a.id = r.url_id
+		WHERE r.alias = ? AND r.expires_at > ? AND (u.id IS NOT NULL OR a.id IS NOT NULL)`, alias, now).
+		Take(&row).Error
+	if err != nil {
+		return nil, "", err
+	}
+	return &row.RenamedAlias, row.ShortCode, nil
+}
+
+// RecordRenamedAliasHit counts a visit through a renamed alias
+func RecordRenamedAliasHit(ctx context.Context, alias string, at time.Time) error {
+	return DB.WithContext(ctx).Model(&models.RenamedAlias{}).Where("alias = ?", alias).
+		Updates(map[string]interface{}{"hits": gorm.Expr("hits + 1"), "last_hit_at": at}).Error
+}
+
+// RenamedAliases lists the aliases a link was renamed away from, newest first
+func RenamedAliases(ctx context.Context, urlID uint) ([]models.RenamedAlias, error) {
+	aliases := []models.RenamedAlias{}
+	err := DB.WithContext(ctx).Where("url_id = ?", urlID).Order("created_at desc").Find(&aliases).Error
+	return aliases, err
+}
+
+// RetireRenamedAlias ends the grace period of a link's renamed alias at now,
+// reporting false when the link has no such alias
+func RetireRenamedAlias(ctx context.Context, urlID uint, alias string, now time.Time) (bool, error) {
+	result := DB.WithContext(ctx).Model(&models.RenamedAlias{}).
+		Where("alias = ? AND url_id = ? AND expires_at > ?", alias, urlID, now).
+		Update("expires_at", now)
+	return result.RowsAffected > 0, result.Error
+}
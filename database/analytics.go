@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/models"
+)
+
+// Host of a referrer URL, without user info or port
+const referrerHost = `lower(substring(referrer from '^[a-zA-Z][a-zA-Z0-9+.-]*://(?:[^/?#@]*@)?([^/?#:]+)'))`
+
+// ClickCounts counts a link's click events in [from, to) per hour or day of
+// the time zone loc, keyed by the start of each bucket in UTC. Days follow
+// the zone's daylight saving time, so they may last 23 or 25 hours, and the
+// hour repeated when clocks go back is counted as two buckets. Buckets
+// without clicks are absent.
+func ClickCounts(ctx context.Context, urlID uint, interval string, loc *time.Location, from, to time.Time) (map[time.Time]int64, error) {
+	var rows []bucketRow
+	err := DB.WithContext(ctx).Table("click_events").
+		Select("date_trunc(?, clicked_at, ?) AS bucket, count(*) AS clicks", interval, loc.String()).
+		Where("url_id = ? AND clicked_at >= ? AND clicked_at < ?", urlID, from, to).
+		Group("bucket").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return bucketCounts(rows), nil
+}
+
+// bucketRow is the click count of one time bucket
+type bucketRow struct {
+	Bucket time.Time
+	Clicks int64
+}
+
+// bucketCounts keys the counts of time buckets by their start in UTC
+func bucketCounts(rows []bucketRow) map[time.Time]int64 {
+	counts := make(map[time.Time]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Bucket.UTC()] += row.Clicks
+	}
+	return counts
+}
+
+// TopReferrers ranks the hosts that referred a link's clicks in [from, to),
+// most clicks first. Clicks without a referrer count as models.DirectReferrer;
+// referrers that aren't URLs are reported as they are.
+func TopReferrers(ctx context.Context, urlID uint, from, to time.Time, limit int) ([]models.ReferrerCount, error) {
+	referrers := []models.ReferrerCount{}
+	err := DB.WithContext(ctx).Table("click_events").
+		Select("COALESCE(NULLIF("+referrerHost+", ''), NULLIF(referrer, ''), ?) AS referrer, count(*) AS clicks", models.DirectReferrer).
+		Where("url_id = ? AND clicked_at >= ? AND clicked_at < ?", urlID, from, to).
+		Group("1").Order("clicks DESC, referrer").Limit(limit).
+		Scan(&referrers).Error
+	return referrers, err
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/models"
+)
+
+// LinkAnnotations returns the annotations of a link between from and to,
+// oldest first
+func LinkAnnotations(ctx context.Context, urlID uint, from, to time.Time) ([]models.LinkAnnotation, error) {
+	annotations := []models.LinkAnnotation{}
+	err := DB.WithContext(ctx).Where("url_id = ? AND time >= ? AND time < ?", urlID, from, to).
+		Order("time, id").Find(&annotations).Error
+	return annotations, storeError(err)
+}
+
+// AllLinkAnnotations returns every annotation of a link, oldest first
+func AllLinkAnnotations(ctx context.Context, urlID uint) ([]models.LinkAnnotation, error) {
+	annotations := []models.LinkAnnotation{}
+	err := DB.WithContext(ctx).Where("url_id = ?", urlID).Order("time, id").Find(&annotations).Error
+	return annotations, storeError(err)
+}
+
+// CountLinkAnnotations counts the annotations of a link
+func CountLinkAnnotations(ctx context.Context, urlID uint) (int64, error) {
+	var count int64
+	err := DB.WithContext(ctx).Model(&models.LinkAnnotation{}).Where("url_id = ?", urlID).Count(&count).Error
+	return count, storeError(err)
+}
+
+// CreateLinkAnnotation stores a new annotation
+func CreateLinkAnnotation(ctx context.Context, annotation *models.LinkAnnotation) error {
+	return storeError(DB.WithContext(ctx).Create(annotation).Error)
+}
+
+// DeleteLinkAnnotation deletes the annotation id of a link, reporting
+// whether it existed
+func DeleteLinkAnnotation(ctx context.Context, urlID, id uint) (bool, error) {
+	result := DB.WithContext(ctx).Where("url_id = ? AND id = ?", urlID, id).Delete(&models.LinkAnnotation{})
+	return result.RowsAffected > 0, storeError(result.Error)
+}
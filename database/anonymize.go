@@ -0,0 +1,209 @@
+package database
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+)
+
+// AnonymizeResult counts the rows scrubbed per table
+type AnonymizeResult map[string]int64
+
+// Anonymize scrubs personal data from a copy of the production database so it
+// can be used for staging and performance testing:
+//   - user emails become user-<id>@example.invalid, passwords are reset to
+//     passwordHash and two-factor secrets and backup codes are removed
+//   - IP addresses in audit logs, shadow bans and link creators are replaced
+//     by salted hashes, keeping equal addresses equal within the copy
+//   - query strings, fragments and credentials are stripped from destination
+//     URLs, link versions, split link variants and click referrers
+//   - sessions, hook subscriptions, webhooks, deliveries and TLS
+//     certificates are deleted and API keys revoked, so production
+//     credentials, webhooks and private keys cannot be used from the copy
+//   - abuse scores and reports are deleted
+func Anonymize(ctx context.Context, passwordHash, salt string) (AnonymizeResult, error) {
+	db := DB.WithContext(ctx)
+	result := AnonymizeResult{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		steps := []struct {
+			table string
+			run   func() *gorm.DB
+		}{
+			{"users", func() *gorm.DB {
+				return tx.Exec("UPDATE users SET email = 'user-' || id || '@example.invalid', password_hash = ?, totp_secret = '', two_factor_enabled = false", passwordHash)
+			}},
+			{"backup_codes", func() *gorm.DB { return tx.Exec("DELETE FROM backup_codes") }},
+			{"sessions", func() *gorm.DB { return tx.Exec("DELETE FROM sessions") }},
+			{"hook_subscriptions", func() *gorm.DB { return tx.Exec("DELETE FROM hook_subscriptions") }},
+			{"hook_deliveries", func() *gorm.DB { return tx.Exec("DELETE FROM hook_deliveries") }},
+			{"webhooks", func() *gorm.DB { return tx.Exec("DELETE FROM webhooks") }},
+			{"tls_certificates", func() *gorm.DB { return tx.Exec("DELETE FROM tls_certificates") }},
+			{"api_keys", func() *gorm.DB {
+				return tx.Exec("UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?), signing_secret = ''", time.Now())
+			}},
+			{"audit_logs", func() *gorm.DB {
+				return tx.Exec("UPDATE audit_logs SET ip_address = 'anon-' || left(md5(ip_address || ?), 12) WHERE ip_address <> ''", salt)
+			}},
+			{"shadow_bans", func() *gorm.DB {
+				return tx.Exec("UPDATE shadow_bans SET ip_address = 'anon-' || left(md5(ip_address || ?), 12)", salt)
+			}},
+			{"urls.creator", func() *gorm.DB {
+				return tx.Exec("UPDATE urls SET creator = 'ip:anon-' || left(md5(creator || ?), 12) WHERE creator LIKE 'ip:%'", salt)
+			}},
+			{"archived_urls.creator", func() *gorm.DB {
+				return tx.Exec("UPDATE archived_urls SET creator = 'ip:anon-' || left(md5(creator || ?), 12) WHERE creator LIKE 'ip:%'", salt)
+			}},
+			{"abuse_scores", func() *gorm.DB { return tx.Exec("DELETE FROM abuse_scores") }},
+			{"abuse_reports", func() *gorm.DB { return tx.Exec("DELETE FROM abuse_reports") }},
+			{"click_events", func() *gorm.DB {
+				return tx.Exec("UPDATE click_events SET referrer = COALESCE(substring(referrer from '^[a-zA-Z][a-zA-Z0-9+.-]*://[^/?#@]+'), '') WHERE referrer <> ''")
+			}},
+			{"click_events.visitor_hash", func() *gorm.DB {
+				return tx.Exec("UPDATE click_events SET visitor_hash = '' WHERE visitor_hash <> ''")
+			}},
+			{"conversion_events.visitor_hash", func() *gorm.DB {
+				return tx.Exec("UPDATE conversion_events SET visitor_hash = '' WHERE visitor_hash <> ''")
+			}},
+		}
+
+		for _, step := range steps {
+			res := step.run()
+			if res.Error != nil {
+				return res.Error
+			}
+			result[step.table] = res.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// Destinations may be encrypted, so they are rewritten through the model
+	scrubbed, err := scrubDestinations(db)
+	result["urls"] = scrubbed
+	if err != nil {
+		return result, err
+	}
+	scrubbed, err = scrubArchivedDestinations(db)
+	result["archived_urls"] = scrubbed
+	if err != nil {
+		return result, err
+	}
+	scrubbed, err = scrubVersionDestinations(db)
+	result["link_versions"] = scrubbed
+	if err != nil {
+		return result, err
+	}
+	scrubbed, err = scrubVariantDestinations(db)
+	result["link_variants"] = scrubbed
+	return result, err
+}
+
+// scrubDestinations strips query strings, fragments and credentials from
+// every destination URL. Changed links stop deduplicating, since scrubbed
+// destinations can collide.
+func scrubDestinations(db *gorm.DB) (int64, error) {
+	var scrubbed int64
+	var batch []models.URL
+	err := db.Unscoped().Select("id", "original_url").FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			destination := scrubURL(batch[i].OriginalURL)
+			if destination == batch[i].OriginalURL {
+				continue
+			}
+
+			update := models.URL{OriginalURL: destination}
+			if err := db.Unscoped().Model(&models.URL{ID: batch[i].ID}).Select("original_url", "original_url_hash").Updates(&update).Error; err != nil {
+				return err
+			}
+			scrubbed++
+		}
+		return nil
+	}).Error
+	return scrubbed, err
+}
+
+// scrubArchivedDestinations does the same for archived links
+func scrubArchivedDestinations(db *gorm.DB) (int64, error) {
+	var scrubbed int64
+	var batch []models.ArchivedURL
+	err := db.Select("id", "original_url").FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			destination := scrubURL(batch[i].OriginalURL)
+			if destination == batch[i].OriginalURL {
+				continue
+			}
+
+			update := models.ArchivedURL{OriginalURL: destination}
+			if err := db.Model(&models.ArchivedURL{ID: batch[i].ID}).Select("original_url", "original_url_hash").Updates(&update).Error; err != nil {
+				return err
+			}
+			scrubbed++
+		}
+		return nil
+	}).Error
+	return scrubbed, err
+}
+
+// scrubVersionDestinations does the same for link versions
+func scrubVersionDestinations(db *gorm.DB) (int64, error) {
+	var scrubbed int64
+	var batch []models.LinkVersion
+	err := db.Select("id", "destination").FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			destination := scrubURL(batch[i].Destination)
+			if destination == batch[i].Destination {
+				continue
+			}
+
+			update := models.LinkVersion{Destination: destination}
+			if err := db.Model(&models.LinkVersion{ID: batch[i].ID}).Select("destination").Updates(&update).Error; err != nil {
+				return err
+			}
+			scrubbed++
+		}
+		return nil
+	}).Error
+	return scrubbed, err
+}
+
+// scrubVariantDestinations does the same for split link variants
+func scrubVariantDestinations(db *gorm.DB) (int64, error) {
+	var scrubbed int64
+	var batch []models.LinkVariant
+	err := db.Select("id", "destination").FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			destination := scrubURL(batch[i].Destination)
+			if destination == batch[i].Destination {
+				continue
+			}
+
+			update := models.LinkVariant{Destination: destination}
+			if err := db.Model(&models.LinkVariant{ID: batch[i].ID}).Select("destination").Updates(&update).Error; err != nil {
+				return err
+			}
+			scrubbed++
+		}
+		return nil
+	}).Error
+	return scrubbed, err
+}
+
+func scrubURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "https://example.invalid/"
+	}
+	parsed.User = nil
+	parsed.RawQuery = ""
+	parsed.ForceQuery = false
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	return parsed.String()
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"url-shortener/models"
+)
+
+// Columns shared by urls and archived_urls. Rows are moved with plain SQL,
+// so encrypted destinations are copied without decrypting them.
+var archivedColumns = strings.Join([]string{
+	"id", "created_at", "updated_at", "original_url", "original_url_hash", "short_code", "owner_id",
+	"click_count", "expires_at", "expiry_exempt", "locked", "status", "inert", "tags", "no_index", "variant_mode",
+	"analytics", "og_title", "og_description", "og_image", "page_title", "page_description", "page_fetched_at",
+	"max_clicks", "clicks_remaining", "stats_reset_at", "utm_source", "utm_medium", "utm_campaign", "redirect_type",
+	"domain_id", "routing_rules", "version", "external_id", "creator",
+}, ", ")
+
+// ArchiveIdleURLs moves up to limit links not updated since cutoff from urls
+// to archived_urls, returning their short codes. Click counting updates a
+// link, so updated_at is the last time it was clicked or edited. Soft-deleted
+// links stay where they are.
+func ArchiveIdleURLs(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	var shortCodes []string
+	err := DB.WithContext(ctx).Raw(`
+		WITH moved AS (
+			DELETE FROM urls WHERE id IN (
+				SELECT id FROM urls
+				WHERE deleted_at IS NULL AND updated_at < ?
+				ORDER BY updated_at
+				LIMIT ?
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING `+archivedColumns+`
+		)
+		INSERT INTO archived_urls (`+archivedColumns+`, archived_at)
+		SELECT `+archivedColumns+`, now() FROM moved
+		RETURNING short_code`, cutoff, limit).Scan(&shortCodes).Error
+	return shortCodes, err
+}
+
+// FindArchivedURL looks up an archived link without rehydrating it
+func FindArchivedURL(ctx context.Context, shortCode string) (*models.URL, error) {
+	var archived models.ArchivedURL
+	if err := DB.WithContext(ctx).Where("short_code = ?", shortCode).First(&archived).Error; err != nil {
+		return nil, err
+	}
+	return archived.ToURL(), nil
+}
+
+// RehydrateURL moves an archived link back into urls and returns it,
+// returning storage.ErrNotFound when shortCode was never archived. Its
+// destination hash is dropped when another live link of its owner
+// deduplicates the same destination by now. Concurrent calls for one code move it only once.
+func RehydrateURL(ctx context.Context, shortCode string) (*models.URL, error) {
+	db := DB.WithContext(ctx)
+	err := db.Exec(`
+		WITH moved AS (
+			DELETE FROM archived_urls WHERE short_code = ?
+			RETURNING `+archivedColumns+`
+		)
+		INSERT INTO urls (`+archivedColumns+`)
+		SELECT id, created_at, now(), original_url,
+			CASE WHEN EXISTS (
+				SELECT 1 FROM urls WHERE urls.original_url_hash = moved.original_url_hash
+					AND urls.owner_id IS NOT DISTINCT FROM moved.owner_id AND urls.deleted_at IS NULL
+			) THEN NULL ELSE original_url_hash END,
+			short_code, owner_id, click_count, expires_at, expiry_exempt, locked, status, inert, tags,
+			no_index, variant_mode, analytics, og_title, og_description, og_image,
+			page_title, page_description, page_fetched_at, max_clicks, clicks_remaining,
+			stats_reset_at, utm_source, utm_medium, utm_campaign, redirect_type, domain_id,
+			routing_rules, version, external_id, creator
+		FROM moved`, shortCode).Error
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	// Loaded even when nothing moved, as a concurrent call may have rehydrated it
+	var url models.URL
+	if err := db.Where("short_code = ?", shortCode).First(&url).Error; err != nil {
+		return nil, storeError(err)
+	}
+	return &url, nil
+}
@@ -0,0 +1,32 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/models"
+)
+
+// ExpiredArtifacts returns up to limit artifacts expired at now, oldest
+// first
+func ExpiredArtifacts(ctx context.Context, now time.Time, limit int) ([]models.Artifact, error) {
+	var expired []models.Artifact
+	err := DB.WithContext(ctx).Where("expires_at <= ?", now).Order("expires_at").Limit(limit).Find(&expired).Error
+	return expired, err
+}
+
+// FailStalledArtifacts marks the artifacts still pending since before as
+// failed, as the instance generating them stopped, returning how many
+func FailStalledArtifacts(ctx context.Context, before time.Time) (int64, error) {
+	result := DB.WithContext(ctx).Model(&models.Artifact{}).
+		Where("status = ? AND created_at < ?", models.ArtifactStatusPending, before).
+		Updates(map[string]interface{}{"status": models.ArtifactStatusFailed, "error": "generation was interrupted"})
+	return result.RowsAffected, result.Error
+}
+
+// RecentArtifacts returns the latest artifacts not expired yet, newest first
+func RecentArtifacts(ctx context.Context, limit int) ([]models.Artifact, error) {
+	artifacts := []models.Artifact{}
+	err := DB.WithContext(ctx).Where("expires_at > ?", time.Now()).Order("id DESC").Limit(limit).Find(&artifacts).Error
+	return artifacts, err
+}
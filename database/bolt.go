@@ -0,0 +1,343 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"strconv"
+	"time"
+
+	"url-shortener/encryption"
+	"url-shortener/models"
+	"url-shortener/storage"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bolt is the key-value file of DB_DRIVER=embedded, set by Connect. It
+// holds the links and, without Redis, the cache (see cache.NewBoltStore).
+var Bolt *bolt.DB
+
+// Buckets of the links in Bolt
+var (
+	boltLinks        = []byte("links")        // link ID -> link
+	boltShortCodes   = []byte("short_codes")  // link key -> link ID
+	boltDestinations = []byte("destinations") // owner ID (0 for none):destination hash -> link ID
+	boltVariants     = []byte("variants")     // variant ID -> variant
+)
+
+// errBoltMissing is returned for links missing from Bolt
+var errBoltMissing = errors.New("link not found")
+
+// openBolt opens the key-value file at path, waiting a moment for another
+// process to release it rather than hanging
+func openBolt(path string) (*bolt.DB, error) {
+	return bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+}
+
+// migrateBolt creates the buckets of the links
+func migrateBolt() error {
+	return Bolt.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{boltLinks, boltShortCodes, boltDestinations, boltVariants} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// boltStore is the Store over Bolt. Links stay where they are created:
+// they are never archived, and renames, edits and deletions through DB do
+// not reach them.
+type boltStore struct{}
+
+func (boltStore) CreateURL(ctx context.Context, url *models.URL, variants []models.LinkVariant) error {
+	err := Bolt.Update(func(tx *bolt.Tx) error {
+		shortCodes := tx.Bucket(boltShortCodes)
+		if shortCodes.Get([]byte(url.ShortCode)) != nil {
+			return storage.Wrap(storage.ErrConflict, errors.New("short code "+url.ShortCode+" is taken"))
+		}
+		destinations := tx.Bucket(boltDestinations)
+		if url.OriginalURLHash != nil && destinations.Get(destinationKey(url.OwnerID, *url.OriginalURLHash)) != nil {
+			return storage.Wrap(storage.ErrConflict, errors.New("the destination is already deduplicated"))
+		}
+
+		links := tx.Bucket(boltLinks)
+		id, err := links.NextSequence()
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		created := *url
+		created.ID = uint(id)
+		created.CreatedAt, created.UpdatedAt = now, now
+		applyURLDefaults(&created)
+		if err := putLink(tx, &created); err != nil {
+			return err
+		}
+		if err := shortCodes.Put([]byte(created.ShortCode), boltKey(id)); err != nil {
+			return err
+		}
+		if created.OriginalURLHash != nil {
+			if err := destinations.Put(destinationKey(created.OwnerID, *created.OriginalURLHash), boltKey(id)); err != nil {
+				return err
+			}
+		}
+
+		variantBucket := tx.Bucket(boltVariants)
+		stored := make([]models.LinkVariant, len(variants))
+		for i, variant := range variants {
+			variantID, err := variantBucket.NextSequence()
+			if err != nil {
+				return err
+			}
+			variant.ID, variant.URLID, variant.CreatedAt = uint(variantID), created.ID, now
+			if err := putVariant(tx, &variant); err != nil {
+				return err
+			}
+			stored[i] = variant
+		}
+
+		*url = created
+		copy(variants, stored)
+		return nil
+	})
+	return boltError(err)
+}
+
+func (boltStore) GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
+	var url *models.URL
+	err := Bolt.View(func(tx *bolt.Tx) error {
+		id := tx.Bucket(boltShortCodes).Get([]byte(shortCode))
+		if id == nil {
+			return errBoltMissing
+		}
+		var err error
+		url, err = getLink(tx, id)
+		return err
+	})
+	return url, boltError(err)
+}
+
+func (boltStore) GetArchived(ctx context.Context, shortCode string) (*models.URL, error) {
+	return nil, storage.Wrap(storage.ErrNotFound, errBoltMissing)
+}
+
+func (boltStore) Rehydrate(ctx context.Context, shortCode string) (*models.URL, error) {
+	return nil, storage.Wrap(storage.ErrNotFound, errBoltMissing)
+}
+
+func (boltStore) ShortCodeTaken(ctx context.Context, code string) (bool, error) {
+	var taken bool
+	err := Bolt.View(func(tx *bolt.Tx) error {
+		taken = tx.Bucket(boltShortCodes).Get([]byte(code)) != nil
+		return nil
+	})
+	return taken, boltError(err)
+}
+
+func (boltStore) FindRenamedAlias(ctx context.Context, alias string, now time.Time) (*models.RenamedAlias, string, error) {
+	return nil, "", storage.Wrap(storage.ErrNotFound, errBoltMissing)
+}
+
+func (boltStore) RecordRenamedAliasHit(ctx context.Context, alias string, at time.Time) error {
+	return nil
+}
+
+func (boltStore) GetByOriginalURL(ctx context.Context, ownerID *uint, hash string) (*models.URL, error) {
+	var url *models.URL
+	err := Bolt.View(func(tx *bolt.Tx) error {
+		id := tx.Bucket(boltDestinations).Get(destinationKey(ownerID, hash))
+		if id == nil {
+			return errBoltMissing
+		}
+		var err error
+		url, err = getLink(tx, id)
+		return err
+	})
+	return url, boltError(err)
+}
+
+func (boltStore) IncrementClicks(ctx context.Context, urls, variants map[uint]int64) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(urls))
+	now := time.Now()
+	err := Bolt.Update(func(tx *bolt.Tx) error {
+		for id, clicks := range urls {
+			url, err := getLink(tx, boltKey(uint64(id)))
+			if errors.Is(err, errBoltMissing) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			url.ClickCount += int(clicks)
+			url.UpdatedAt = now
+			if err := putLink(tx, url); err != nil {
+				return err
+			}
+			counts[id] = int64(url.ClickCount)
+		}
+		for id, clicks := range variants {
+			variant, err := getVariant(tx, boltKey(uint64(id)))
+			if errors.Is(err, errBoltMissing) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			variant.Clicks += clicks
+			if err := putVariant(tx, variant); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, boltError(err)
+	}
+	return counts, nil
+}
+
+func (boltStore) ConsumeClick(ctx context.Context, urlID uint) (int, error) {
+	var remaining int
+	err := Bolt.Update(func(tx *bolt.Tx) error {
+		url, err := getLink(tx, boltKey(uint64(urlID)))
+		if err != nil {
+			return err
+		}
+		if url.ClicksRemaining == nil || *url.ClicksRemaining <= 0 {
+			return ErrNoClicksLeft
+		}
+		// Not an edit, so updated_at is kept
+		remaining = *url.ClicksRemaining - 1
+		url.ClicksRemaining = &remaining
+		if remaining == 0 {
+			now := time.Now()
+			url.ExpiresAt = &now
+		}
+		return putLink(tx, url)
+	})
+	return remaining, boltError(err)
+}
+
+// applyURLDefaults sets the column defaults the SQL drivers apply to a new
+// link
+func applyURLDefaults(url *models.URL) {
+	if url.Status == "" {
+		url.Status = models.StatusActive
+	}
+	if url.Analytics == "" {
+		url.Analytics = models.AnalyticsFull
+	}
+	if url.Environment == "" {
+		url.Environment = models.EnvironmentProduction
+	}
+	if url.Version == 0 {
+		url.Version = 1
+	}
+	if url.Revision == 0 {
+		url.Revision = 1
+	}
+}
+
+// getLink loads the link stored under id, decrypting its destination
+func getLink(tx *bolt.Tx, id []byte) (*models.URL, error) {
+	data := tx.Bucket(boltLinks).Get(id)
+	if data == nil {
+		return nil, errBoltMissing
+	}
+	var url models.URL
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&url); err != nil {
+		return nil, err
+	}
+	destination, err := encryption.Decrypt(url.OriginalURL)
+	if err != nil {
+		return nil, err
+	}
+	url.OriginalURL = destination
+	return &url, nil
+}
+
+// putLink stores url under its ID, encrypting its destination like the
+// "encrypted" column serializer
+func putLink(tx *bolt.Tx, url *models.URL) error {
+	stored := *url
+	destination, err := encryption.Encrypt(url.OriginalURL)
+	if err != nil {
+		return err
+	}
+	stored.OriginalURL = destination
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(&stored); err != nil {
+		return err
+	}
+	return tx.Bucket(boltLinks).Put(boltKey(uint64(url.ID)), data.Bytes())
+}
+
+// getVariant loads the variant stored under id, decrypting its destination
+func getVariant(tx *bolt.Tx, id []byte) (*models.LinkVariant, error) {
+	data := tx.Bucket(boltVariants).Get(id)
+	if data == nil {
+		return nil, errBoltMissing
+	}
+	var variant models.LinkVariant
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&variant); err != nil {
+		return nil, err
+	}
+	destination, err := encryption.Decrypt(variant.Destination)
+	if err != nil {
+		return nil, err
+	}
+	variant.Destination = destination
+	return &variant, nil
+}
+
+// putVariant stores variant under its ID, encrypting its destination
+func putVariant(tx *bolt.Tx, variant *models.LinkVariant) error {
+	stored := *variant
+	destination, err := encryption.Encrypt(variant.Destination)
+	if err != nil {
+		return err
+	}
+	stored.Destination = destination
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(&stored); err != nil {
+		return err
+	}
+	return tx.Bucket(boltVariants).Put(boltKey(uint64(variant.ID)), data.Bytes())
+}
+
+// boltKey encodes an ID as a key sorting in ID order
+func boltKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// destinationKey is the key deduplicating a destination among the links
+// of ownerID, ownerless links sharing one scope
+func destinationKey(ownerID *uint, hash string) []byte {
+	owner := uint64(0)
+	if ownerID != nil {
+		owner = uint64(*ownerID)
+	}
+	return []byte(strconv.FormatUint(owner, 10) + ":" + hash)
+}
+
+// boltError classifies an error of Bolt like storeError does those of the
+// SQL drivers
+func boltError(err error) error {
+	var storageErr *storage.Error
+	switch {
+	case err == nil || errors.As(err, &storageErr):
+		return err
+	case errors.Is(err, errBoltMissing):
+		return storage.Wrap(storage.ErrNotFound, err)
+	case errors.Is(err, bolt.ErrDatabaseNotOpen) || errors.Is(err, bolt.ErrTimeout):
+		return storage.Wrap(storage.ErrBackendUnavailable, err)
+	}
+	return err
+}
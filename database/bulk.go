@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"url-shortener/encryption"
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+)
+
+// Matches the host of a link's destination as its first group. Bound as a
+// parameter, since GORM would take its question marks for placeholders.
+const destinationHostPattern = `^[^:/?#]+://(?:[^/?#@]*@)?([^/?#:]+)`
+
+// WhereDestinationDomain narrows query to the links whose destination host
+// is domain, a normalized domain name, or one of its subdomains. Encrypted
+// destinations never match, see DestinationDomainFilterable.
+func WhereDestinationDomain(query *gorm.DB, domain string) *gorm.DB {
+	return query.Where("'.' || lower(substring(original_url from ?)) LIKE ?", destinationHostPattern, "%."+domain)
+}
+
+// DestinationDomainFilterable reports whether links can be filtered by
+// destination domain, which needs destinations stored in plaintext
+func DestinationDomainFilterable() bool {
+	return !encryption.Enabled()
+}
+
+// bulkLinks narrows query to the live links matching the filter of op
+func bulkLinks(query *gorm.DB, op *models.BulkOperation) *gorm.DB {
+	query = query.Model(&models.URL{})
+	if op.Tag != "" {
+		tag, _ := json.Marshal([]string{op.Tag})
+		query = query.Where("tags @> ?::jsonb", string(tag))
+	}
+	if op.Domain != "" {
+		query = WhereDestinationDomain(query, op.Domain)
+	}
+	if op.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *op.CreatedBefore)
+	}
+	return query
+}
+
+// CountBulkLinks counts the links matching the filter of op
+func CountBulkLinks(ctx context.Context, op *models.BulkOperation) (int64, error) {
+	var count int64
+	err := bulkLinks(DB.WithContext(ctx), op).Count(&count).Error
+	return count, err
+}
+
+// BulkLinksAfter returns up to limit links matching the filter of op with
+// an ID above afterID, in ID order
+func BulkLinksAfter(ctx context.Context, op *models.BulkOperation, afterID uint, limit int) ([]models.URL, error) {
+	var links []models.URL
+	err := bulkLinks(DB.WithContext(ctx), op).
+		Select("id", "created_at", "short_code", "status", "expires_at", "locked", "original_url", "tags", "owner_id", "inert").
+		Where("id > ?", afterID).Order("id").Limit(limit).Find(&links).Error
+	return links, err
+}
+
+// ExpireLinks expires the unlocked links among ids at at, returning the
+// short codes of those it expired
+func ExpireLinks(ctx context.Context, ids []uint, at time.Time) ([]string, error) {
+	var shortCodes []string
+	err := DB.WithContext(ctx).Raw(`
+		UPDATE urls SET expires_at = ?, updated_at = ?
+		WHERE id IN ? AND NOT locked AND deleted_at IS NULL
+		RETURNING short_code`, at, at, ids).Scan(&shortCodes).Error
+	return shortCodes, err
+}
+
+// DisableLinks disables the active, unlocked links among ids, returning the
+// short codes of those it disabled. They no longer deduplicate their destination,
+// so shortening it again creates a working link.
+func DisableLinks(ctx context.Context, ids []uint) ([]string, error) {
+	var shortCodes []string
+	err := DB.WithContext(ctx).Raw(`
+		UPDATE urls SET status = ?, original_url_hash = NULL, updated_at = ?
+		WHERE id IN ? AND status = ? AND NOT locked AND deleted_at IS NULL
+		RETURNING short_code`, models.StatusDisabled, time.Now(), ids, models.StatusActive).Scan(&shortCodes).Error
+	return shortCodes, err
+}
+
+// TagLinks replaces the tags of the unlocked links among tags' keys,
+// returning the short codes of those it tagged
+func TagLinks(ctx context.Context, tags map[uint][]string) ([]string, error) {
+	rows := make([]string, 0, len(tags))
+	args := make([]interface{}, 0, 2*len(tags)+1)
+	args = append(args, time.Now())
+	for id, linkTags := range tags {
+		encoded, err := json.Marshal(linkTags)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, "(?::bigint, ?::jsonb)")
+		args = append(args, id, string(encoded))
+	}
+
+	var shortCodes []string
+	err := DB.WithContext(ctx).Raw(`
+		UPDATE urls SET tags = v.tags, updated_at = ?
+		FROM (VALUES `+strings.Join(rows, ", ")+`) AS v(id, tags)
+		WHERE urls.id = v.id AND NOT urls.locked AND urls.deleted_at IS NULL
+		RETURNING urls.short_code`, args...).Scan(&shortCodes).Error
+	return shortCodes, err
+}
+
+// ClaimBulkOperation leases the oldest unfinished bulk operation that no
+// instance holds a lease on, returning nil when there is none
+func ClaimBulkOperation(ctx context.Context, lease time.Duration) (*models.BulkOperation, error) {
+	var claimed []models.BulkOperation
+	now := time.Now()
+	// SQLite has no row locks, nor needs them with its single writer
+	lock := "FOR UPDATE SKIP LOCKED"
+	if usesSQLite() {
+		lock = ""
+	}
+	err := DB.WithContext(ctx).Raw(`
+		UPDATE bulk_operations SET status = ?, lease_until = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM bulk_operations
+			WHERE status IN ? AND (lease_until IS NULL OR lease_until < ?)
+			ORDER BY id
+			LIMIT 1
+			`+lock+`
+		)
+		RETURNING *`, models.BulkStatusRunning, now.Add(lease), now,
+		[]string{models.BulkStatusQueued, models.BulkStatusRunning}, now,
+	).Scan(&claimed).Error
+	if err != nil || len(claimed) == 0 {
+		return nil, err
+	}
+	return &claimed[0], nil
+}
+
+// SaveBulkProgress stores the progress of op, renewing its lease
+func SaveBulkProgress(ctx context.Context, op *models.BulkOperation, lease time.Duration) error {
+	leaseUntil := time.Now().Add(lease)
+	op.LeaseUntil = &leaseUntil
+	return DB.WithContext(ctx).Model(op).Select(
+		"status", "processed", "updated", "skipped", "error", "last_url_id", "lease_until", "finished_at",
+	).Updates(op).Error
+}
+
+// RecentBulkOperations returns the latest bulk operations, newest first
+func RecentBulkOperations(ctx context.Context, limit int) ([]models.BulkOperation, error) {
+	operations := []models.BulkOperation{}
+	err := DB.WithContext(ctx).Order("id DESC").Limit(limit).Find(&operations).Error
+	return operations, err
+}
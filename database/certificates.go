@@ -0,0 +1,31 @@
+package database
+
+import (
+	"context"
+
+	"url-shortener/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// GetCertificateData returns the ACME cache entry stored under key,
+// returning storage.ErrNotFound when there is none
+func GetCertificateData(ctx context.Context, key string) ([]byte, error) {
+	var entry models.TLSCertificate
+	if err := DB.WithContext(ctx).Where("key = ?", key).First(&entry).Error; err != nil {
+		return nil, storeError(err)
+	}
+	return []byte(entry.Data), nil
+}
+
+// PutCertificateData stores data as the ACME cache entry key, replacing the
+// previous one
+func PutCertificateData(ctx context.Context, key string, data []byte) error {
+	entry := models.TLSCertificate{Key: key, Data: string(data)}
+	return storeError(DB.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&entry).Error)
+}
+
+// DeleteCertificateData removes the ACME cache entry key, if any
+func DeleteCertificateData(ctx context.Context, key string) error {
+	return storeError(DB.WithContext(ctx).Where("key = ?", key).Delete(&models.TLSCertificate{}).Error)
+}
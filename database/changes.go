@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+)
+
+// Columns of urls whose changes are not recorded in the link change feed:
+// click counters and the metadata fetched from the destination page.
+// clicks_remaining is only recorded when it runs out or is topped up again.
+const linkChangeIgnoredColumns = `ARRAY['updated_at', 'click_count', 'clicks_remaining', 'stats_reset_at',
+	'page_title', 'page_description', 'page_fetched_at']`
+
+// ensureLinkChangeTrigger creates the trigger recording every change to a
+// link in link_changes, in the transaction making it. Restoring a soft
+// deleted link records it as created again.
+func ensureLinkChangeTrigger(db *gorm.DB) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION record_link_change() RETURNS trigger AS $$
+		DECLARE
+			change_event text;
+			link urls;
+			previous_code text := '';
+		BEGIN
+			IF TG_OP = 'INSERT' THEN
+				change_event := 'created';
+				link := NEW;
+			ELSIF TG_OP = 'DELETE' THEN
+				-- Soft deleted links were recorded as deleted already
+				IF OLD.deleted_at IS NOT NULL THEN
+					RETURN NULL;
+				END IF;
+				change_event := 'deleted';
+				link := OLD;
+			ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+				change_event := 'deleted';
+				link := NEW;
+			ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+				change_event := 'created';
+				link := NEW;
+			ELSIF NEW.deleted_at IS NULL AND (
+				to_jsonb(OLD) - ` + linkChangeIgnoredColumns + ` IS DISTINCT FROM to_jsonb(NEW) - ` + linkChangeIgnoredColumns + `
+				OR (OLD.clicks_remaining = 0) IS DISTINCT FROM (NEW.clicks_remaining = 0)
+			) THEN
+				change_event := 'updated';
+				link := NEW;
+				IF OLD.short_code <> NEW.short_code THEN
+					previous_code := OLD.short_code;
+				END IF;
+			ELSE
+				RETURN NULL;
+			END IF;
+
+			INSERT INTO link_changes (event, url_id, short_code, previous_short_code, recorded_at, horizon)
+			VALUES (change_event, link.id, link.short_code, previous_code, clock_timestamp(),
+				pg_snapshot_xmax(pg_current_snapshot())::text::bigint);
+			RETURN NULL;
+		END
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS urls_record_change ON urls`,
+		`CREATE TRIGGER urls_record_change AFTER INSERT OR UPDATE OR DELETE ON urls
+			FOR EACH ROW EXECUTE FUNCTION record_link_change()`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Changes are only read once every transaction running when they were
+// recorded has finished. Transactions commit out of ID order, and a change
+// committing after one with a higher ID was read would otherwise be skipped
+// by readers already past it. Those readable form a prefix of the feed.
+const committedLinkChanges = "horizon <= pg_snapshot_xmin(pg_current_snapshot())::text::bigint"
+
+// LinkChanges returns up to limit changes after the since cursor, in order
+func LinkChanges(ctx context.Context, since uint64, limit int) ([]models.LinkChange, error) {
+	var changes []models.LinkChange
+	err := DB.WithContext(ctx).
+		Where("id > ? AND "+committedLinkChanges, since).
+		Order("id").
+		Limit(limit).
+		Find(&changes).Error
+	return changes, err
+}
+
+// LinkChangeHead returns the cursor of the latest change that can be read,
+// 0 when there are none
+func LinkChangeHead(ctx context.Context) (uint64, error) {
+	var head uint64
+	err := DB.WithContext(ctx).Model(&models.LinkChange{}).
+		Where(committedLinkChanges).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&head).Error
+	return head, err
+}
+
+// LinkChangeCursorExpired reports whether changes after the since cursor
+// were pruned already
+func LinkChangeCursorExpired(ctx context.Context, since uint64) (bool, error) {
+	var oldest uint64
+	err := DB.WithContext(ctx).Model(&models.LinkChange{}).
+		Select("COALESCE(MIN(id), 0)").
+		Scan(&oldest).Error
+	return oldest > since+1, err
+}
+
+// PruneLinkChanges deletes the changes recorded before cutoff, returning how
+// many it deleted. The latest of them is kept, so cursors from before the
+// cutoff are told apart from those of changes rolled back.
+func PruneLinkChanges(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := DB.WithContext(ctx).
+		Where("id < (SELECT MAX(id) FROM link_changes WHERE recorded_at < ?)", cutoff).
+		Delete(&models.LinkChange{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+)
+
+// ExpiredURLs returns up to limit links that expired before cutoff, oldest
+// expiry first. Locked links are left alone. Soft-deleted links are included
+// when purging, so links soft-deleted earlier free their codes too.
+func ExpiredURLs(ctx context.Context, cutoff time.Time, limit int, purge bool) ([]models.URL, error) {
+	query := DB.WithContext(ctx)
+	if purge {
+		query = query.Unscoped()
+	}
+
+	var urls []models.URL
+	err := query.Where("expires_at < ? AND NOT locked", cutoff).
+		Order("expires_at").
+		Limit(limit).
+		Find(&urls).Error
+	return urls, err
+}
+
+// SoftDeleteURLs soft-deletes the links with the given IDs
+func SoftDeleteURLs(ctx context.Context, ids []uint) error {
+	return DB.WithContext(ctx).Where("id IN ?", ids).Delete(&models.URL{}).Error
+}
+
+// PurgeURLs deletes the links with the given IDs for good, along with their
+// variants, renamed aliases and stats history. Click events are kept for aggregate stats.
+func PurgeURLs(ctx context.Context, ids []uint) error {
+	return DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := purgeLinkDependents(tx, ids); err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&models.URL{}).Error
+	})
+}
+
+// PurgeExpiredArchivedURLs deletes up to limit archived links that expired
+// before cutoff for good, like PurgeURLs, returning their short codes
+func PurgeExpiredArchivedURLs(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	var shortCodes []string
+	err := DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var purged []models.ArchivedURL
+		err := tx.Raw(`
+			DELETE FROM archived_urls WHERE id IN (
+				SELECT id FROM archived_urls
+				WHERE expires_at < ? AND NOT locked
+				ORDER BY expires_at
+				LIMIT ?
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, short_code`, cutoff, limit).Scan(&purged).Error
+		if err != nil || len(purged) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(purged))
+		shortCodes = make([]string, len(purged))
+		for i, archived := range purged {
+			ids[i] = archived.ID
+			shortCodes[i] = archived.ShortCode
+		}
+		return purgeLinkDependents(tx, ids)
+	})
+	return shortCodes, err
+}
+
+// purgeLinkDependents deletes the rows that only make sense with their link
+func purgeLinkDependents(tx *gorm.DB, ids []uint) error {
+	if err := tx.Where("url_id IN ?", ids).Delete(&models.LinkVariant{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("url_id IN ?", ids).Delete(&models.RenamedAlias{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("url_id IN ?", ids).Delete(&models.LinkStatsReset{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("url_id IN ?", ids).Delete(&models.LinkAnnotation{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("url_id IN ?", ids).Delete(&models.LinkReference{}).Error
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// NextClickExportHour returns the first hour of click events not exported
+// yet: the hour after the last one exported or, before the first export, the
+// hour of the oldest click event. ok is false when there is nothing to export.
+func NextClickExportHour(ctx context.Context) (hour time.Time, ok bool, err error) {
+	var last *time.Time
+	if err := DB.WithContext(ctx).Model(&models.ClickExport{}).Select("max(hour)").Scan(&last).Error; err != nil {
+		return time.Time{}, false, err
+	}
+	if last != nil {
+		return last.UTC().Add(time.Hour), true, nil
+	}
+
+	var oldest *time.Time
+	if err := DB.WithContext(ctx).Table("click_events").Select("min(clicked_at)").Scan(&oldest).Error; err != nil {
+		return time.Time{}, false, err
+	}
+	if oldest == nil {
+		return time.Time{}, false, nil
+	}
+	return oldest.UTC().Truncate(time.Hour), true, nil
+}
+
+// ClickEventsBetween returns up to limit click events clicked in [from, to)
+// with an ID above afterID, in ID order, for paging through an hour
+func ClickEventsBetween(ctx context.Context, from, to time.Time, afterID uint, limit int) ([]models.ClickEvent, error) {
+	var events []models.ClickEvent
+	err := DB.WithContext(ctx).
+		Where("clicked_at >= ? AND clicked_at < ? AND id > ?", from, to, afterID).
+		Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// RecordClickExport marks an hour as exported, replacing an earlier record
+// of the same hour
+func RecordClickExport(ctx context.Context, export models.ClickExport) error {
+	return DB.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&export).Error
+}
+
+// ClickEventTotals counts the click events clicked in [from, to) and returns
+// the highest ID among them
+func ClickEventTotals(ctx context.Context, from, to time.Time) (count int64, lastID uint, err error) {
+	var totals struct {
+		Count  int64
+		LastID *uint
+	}
+	err = DB.WithContext(ctx).Table("click_events").Select("count(*) AS count, max(id) AS last_id").
+		Where("clicked_at >= ? AND clicked_at < ?", from, to).Scan(&totals).Error
+	if totals.LastID != nil {
+		lastID = *totals.LastID
+	}
+	return totals.Count, lastID, err
+}
+
+// ClickExportsSince returns the exports of hours from since on, oldest first
+func ClickExportsSince(ctx context.Context, since time.Time) ([]models.ClickExport, error) {
+	var exports []models.ClickExport
+	err := DB.WithContext(ctx).Where("hour >= ?", since).Order("hour").Find(&exports).Error
+	return exports, err
+}
+
+// FindClickExport returns the export of an hour
+func FindClickExport(ctx context.Context, hour time.Time) (*models.ClickExport, error) {
+	var export models.ClickExport
+	if err := DB.WithContext(ctx).Where("hour = ?", hour).First(&export).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
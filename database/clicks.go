@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ApplyClickCounts adds batched click increments to links and split link
+// variants in one transaction, returning the links' new click counts. Links
+// get a new updated_at, so the click count reconciler checks them and the
+// archiver sees they are in use.
+func ApplyClickCounts(ctx context.Context, urls, variants map[uint]int64) (map[uint]int64, error) {
+	if len(urls) == 0 && len(variants) == 0 {
+		return nil, nil
+	}
+
+	counts := make(map[uint]int64, len(urls))
+	err := DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(urls) > 0 {
+			values, args := incrementValues(urls)
+			args = append([]interface{}{time.Now()}, args...)
+			var rows []struct {
+				ID         uint
+				ClickCount int64
+			}
+			err := tx.Raw(`UPDATE urls SET click_count = urls.click_count + v.clicks, updated_at = ?
+				FROM (VALUES `+values+`) AS v(id, clicks) WHERE urls.id = v.id
+				RETURNING urls.id, urls.click_count`, args...).Scan(&rows).Error
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				counts[row.ID] = row.ClickCount
+			}
+		}
+		if len(variants) > 0 {
+			values, args := incrementValues(variants)
+			err := tx.Exec(`UPDATE link_variants SET clicks = link_variants.clicks + v.clicks
+				FROM (VALUES `+values+`) AS v(id, clicks) WHERE link_variants.id = v.id`, args...).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// incrementValues renders increments as a VALUES list with its arguments
+func incrementValues(increments map[uint]int64) (string, []interface{}) {
+	rows := make([]string, 0, len(increments))
+	args := make([]interface{}, 0, 2*len(increments))
+	for id, count := range increments {
+		rows = append(rows, "(?::bigint, ?::bigint)")
+		args = append(args, id, count)
+	}
+	return strings.Join(rows, ", "), args
+}
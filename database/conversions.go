@@ -0,0 +1,39 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// RecordConversion stores a conversion of a split link's variant by the
+// visitor with visitorHash, for attribution to the visitor's clicks
+func RecordConversion(ctx context.Context, variantID uint, visitorHash string, at time.Time) error {
+	return DB.WithContext(ctx).Exec(`INSERT INTO conversion_events (converted_at, url_id, variant_id, visitor_hash)
+		SELECT ?, url_id, id, ? FROM link_variants WHERE id = ?`, at, visitorHash, variantID).Error
+}
+
+// AttributedConversions counts the conversions of each variant of a split
+// link since since whose visitor clicked the link within window before
+// converting. Clicks are matched by the visitor hash click events record,
+// so conversions of visitors whose clicks were recorded without one are not
+// attributed.
+func AttributedConversions(ctx context.Context, urlID uint, since time.Time, window time.Duration) (map[uint]int64, error) {
+	var rows []struct {
+		VariantID   uint
+		Conversions int64
+	}
+	err := DB.WithContext(ctx).Raw(`SELECT c.variant_id, count(*) AS conversions FROM conversion_events c
+		WHERE c.url_id = ? AND c.converted_at >= ? AND EXISTS (
+			SELECT 1 FROM click_events e
+			WHERE e.url_id = c.url_id AND e.visitor_hash = c.visitor_hash
+				AND e.clicked_at <= c.converted_at AND e.clicked_at >= c.converted_at - make_interval(secs => ?))
+		GROUP BY c.variant_id`, urlID, since, window.Seconds()).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	conversions := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		conversions[row.VariantID] = row.Conversions
+	}
+	return conversions, nil
+}
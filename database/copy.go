@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"url-shortener/config"
+	"url-shortener/encryption"
+	"url-shortener/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Rows sent per COPY statement, DB_COPY_BATCH_SIZE set by Connect
+var copyBatchSize = config.Default().Database.CopyBatchSize
+
+// CopyError reports the input row (1-based) that made a bulk insert fail
+type CopyError struct {
+	Row int
+	Err error
+}
+
+func (e *CopyError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *CopyError) Unwrap() error {
+	return e.Err
+}
+
+// Postgres reports the failing line of a COPY in the error context
+var copyLinePattern = regexp.MustCompile(`COPY \S+, line (\d+)`)
+
+// CopyURLs bulk inserts urls with Postgres COPY, returning the number of rows
+// written. Rows are sent in batches, and each batch is committed on its own,
+// so rows from earlier batches are kept when a later batch fails. IDs are not
+// populated on the passed records.
+func CopyURLs(ctx context.Context, urls []models.URL) (int64, error) {
+	columns := []string{
+		"created_at", "updated_at", "original_url", "original_url_hash", "short_code",
+		"click_count", "expires_at", "locked", "status", "inert",
+	}
+
+	now := time.Now()
+	rows := make([][]interface{}, len(urls))
+	for i, url := range urls {
+		// COPY bypasses the GORM serializer, so encrypt here
+		originalURL, err := encryption.Encrypt(url.OriginalURL)
+		if err != nil {
+			return 0, &CopyError{Row: i + 1, Err: err}
+		}
+
+		createdAt := url.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = now
+		}
+		status := url.Status
+		if status == "" {
+			status = models.StatusActive
+		}
+
+		rows[i] = []interface{}{
+			createdAt, now, originalURL, url.OriginalURLHash, url.ShortCode,
+			url.ClickCount, url.ExpiresAt, url.Locked, status, url.Inert,
+		}
+	}
+
+	return copyRows(ctx, "urls", columns, rows)
+}
+
+// CopyClickEvents bulk inserts click events with Postgres COPY, batched like CopyURLs
+func CopyClickEvents(ctx context.Context, events []models.ClickEvent) (int64, error) {
+	columns := []string{"clicked_at", "url_id", "short_code", "referrer", "user_agent", "country", "region", "city", "device_type", "link_version", "visitor_hash"}
+
+	rows := make([][]interface{}, len(events))
+	for i, event := range events {
+		rows[i] = []interface{}{
+			event.ClickedAt, event.URLID, event.ShortCode, event.Referrer,
+			event.UserAgent, event.Country, event.Region, event.City, event.DeviceType, event.LinkVersion,
+			event.VisitorHash,
+		}
+	}
+
+	return copyRows(ctx, "click_events", columns, rows)
+}
+
+func copyRows(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error) {
+	batchSize := copyBatchSize
+
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return 0, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	var copied int64
+	for start := 0; start < len(rows); start += batchSize {
+		end := start + batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		count, err := copyBatch(ctx, conn, table, columns, rows[start:end])
+		if err != nil {
+			return copied, copyError(err, start)
+		}
+		copied += count
+	}
+	return copied, nil
+}
+
+func copyBatch(ctx context.Context, conn *sql.Conn, table string, columns []string, rows [][]interface{}) (int64, error) {
+	var count int64
+	err := conn.Raw(func(driverConn interface{}) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("COPY requires the pgx driver")
+		}
+
+		var err error
+		count, err = stdlibConn.Conn().CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows))
+		return err
+	})
+	return count, err
+}
+
+// copyError maps the line reported by Postgres back to the input row
+func copyError(err error, batchStart int) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if match := copyLinePattern.FindStringSubmatch(pgErr.Where); match != nil {
+			if line, convErr := strconv.Atoi(match[1]); convErr == nil {
+				return &CopyError{Row: batchStart + line, Err: err}
+			}
+		}
+	}
+	return fmt.Errorf("batch starting at row %d: %w", batchStart+1, err)
+}
@@ -1,11 +1,16 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"time"
 
+	"url-shortener/chaos"
+	"url-shortener/config"
 	"url-shortener/models"
+	"url-shortener/utils"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -13,38 +18,346 @@ import (
 
 var DB *gorm.DB
 
-func InitDB() {
+// DB_DRIVER of DB, set by Connect
+var dbDriver string
+
+// Prepared shares DB's connection pool but prepares and caches its
+// statements per connection. Use it for hot, fixed-shape queries such as the
+// redirect lookup and click count updates.
+var Prepared *gorm.DB
+
+// InitDB connects to the database and migrates it, exiting on failure
+func InitDB(cfg config.Database) {
+	if err := Connect(cfg); err != nil {
+		slog.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	if err := Migrate(); err != nil {
+		slog.Error("Failed to migrate database", "error", err)
+		os.Exit(1)
+	}
+
+	slog.Info("Database connected and migrated successfully")
+}
+
+// Connect opens the database connection pool and registers the query
+// instrumentation, without migrating
+func Connect(cfg config.Database) error {
 	var err error
 
-	// Database connection parameters
-	host := getEnv("DB_HOST", "localhost")
-	port := getEnv("DB_PORT", "5432")
-	user := getEnv("DB_USER", "postgres")
-	password := getEnv("DB_PASSWORD", "password")
-	dbname := getEnv("DB_NAME", "urlshortener")
+	// Fail before connecting when the driver is not built in
+	if Links, err = newStore(cfg.Driver); err != nil {
+		return err
+	}
+	copyBatchSize = cfg.CopyBatchSize
 
-	// Build connection string
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname)
+	dbDriver = cfg.Driver
 
-	// Connect to database
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// Connect to database. Poolers in transaction mode (e.g. PgBouncer)
+	// cannot keep prepared statements, so allow falling back to the simple
+	// query protocol.
+	dialector := postgres.New(postgres.Config{
+		DSN:                  cfg.DSN(),
+		PreferSimpleProtocol: cfg.PreferSimpleProtocol,
+	})
+	switch dbDriver {
+	case DriverSQLite:
+		dialector = sqliteDialector(cfg.DSN())
+	case DriverEmbedded:
+		if Bolt, err = openBolt(cfg.DSN()); err != nil {
+			return err
+		}
+		dialector = sqliteDialector(cfg.DSN() + ".sqlite")
+	}
+	DB, err = gorm.Open(dialector, &gorm.Config{Logger: slogLogger{}})
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		return err
+	}
+	if usesSQLite() {
+		sqlDB, err := DB.DB()
+		if err != nil {
+			return err
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	Prepared = DB
+	if !cfg.PreferSimpleProtocol {
+		Prepared = DB.Session(&gorm.Session{PrepareStmt: true})
+	}
+
+	// Record query durations and log slow queries
+	if err = DB.Use(&Instrumentation{SlowThreshold: cfg.SlowQueryThreshold}); err != nil {
+		return fmt.Errorf("failed to register query instrumentation: %w", err)
+	}
+
+	// Fault injection for resilience tests
+	if chaos.Enabled() {
+		if err = DB.Use(chaos.GormPlugin{}); err != nil {
+			return fmt.Errorf("failed to register fault injection: %w", err)
+		}
+	}
+	return nil
+}
+
+// Migrate brings the schema up to date: it auto-migrates the models, creates
+// the SMS code sequence, click_events partitions and link change trigger,
+// and runs backfills
+func Migrate() error {
+	migrationStart := time.Now()
+
+	// Existing links need their destination hashes backfilled once the column is added
+	needsHashBackfill := needsURLHashBackfill(DB)
+
+	migrate := migrateSchema
+	if usesSQLite() {
+		migrate = migrateSQLite
 	}
+	if err := migrate(DB); err != nil {
+		return err
+	}
+	if Bolt != nil {
+		if err := migrateBolt(); err != nil {
+			return fmt.Errorf("failed to create the buckets of links: %w", err)
+		}
+	}
+
+	if needsHashBackfill {
+		if err := backfillURLHashes(); err != nil {
+			return fmt.Errorf("failed to backfill URL hashes: %w", err)
+		}
+	}
+
+	migration = models.MigrationStatus{CompletedAt: time.Now(), DurationMs: time.Since(migrationStart).Milliseconds()}
+	return nil
+}
 
+// needsURLHashBackfill reports whether links exist without the destination
+// hash column, which Migrate backfills once it is added
+func needsURLHashBackfill(db *gorm.DB) bool {
+	return db.Migrator().HasTable(&models.URL{}) && !db.Migrator().HasColumn(&models.URL{}, "OriginalURLHash")
+}
+
+// migrateSchema makes the schema changes of Migrate through db
+func migrateSchema(db *gorm.DB) error {
 	// Auto-migrate tables
-	err = DB.AutoMigrate(&models.URL{})
+	err := db.AutoMigrate(migratedModels...)
 	if err != nil {
-		log.Fatal("Failed to migrate database:", err)
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	// SMS short codes are allocated sequentially
+	if err = db.Exec("CREATE SEQUENCE IF NOT EXISTS sms_code_seq").Error; err != nil {
+		return fmt.Errorf("failed to create SMS code sequence: %w", err)
+	}
+	// and so are random style codes with SHORT_CODE_STRATEGY=sequential
+	if err = db.Exec("CREATE SEQUENCE IF NOT EXISTS short_code_seq").Error; err != nil {
+		return fmt.Errorf("failed to create short code sequence: %w", err)
 	}
 
-	log.Println("Database connected and migrated successfully")
+	// Destinations are deduplicated per owner, ownerless links sharing one
+	// scope, replacing the index deduplicating them across owners
+	if err = db.Exec("DROP INDEX IF EXISTS idx_urls_original_url_hash").Error; err != nil {
+		return fmt.Errorf("failed to drop destination hash index: %w", err)
+	}
+	if err = db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_owner_original_url_hash ON urls (COALESCE(owner_id, 0), original_url_hash) WHERE deleted_at IS NULL").Error; err != nil {
+		return fmt.Errorf("failed to create destination hash index: %w", err)
+	}
+
+	// CMS identifiers are unique per owner among live links
+	if err = db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_owner_external_id ON urls (owner_id, external_id) WHERE deleted_at IS NULL").Error; err != nil {
+		return fmt.Errorf("failed to create external ID index: %w", err)
+	}
+
+	// click_events is partitioned by month and managed outside AutoMigrate
+	if err = ensureClickEventsTable(db); err != nil {
+		return fmt.Errorf("failed to create click_events table: %w", err)
+	}
+	if err = ensureClickEventPartitions(db, time.Now()); err != nil {
+		return fmt.Errorf("failed to create click_events partitions: %w", err)
+	}
+
+	// Link changes are recorded by a trigger so no write path can miss them
+	if err = ensureLinkChangeTrigger(db); err != nil {
+		return fmt.Errorf("failed to create link change trigger: %w", err)
+	}
+	return nil
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Models managed by AutoMigrate
+var migratedModels = []interface{}{
+	&models.URL{}, &models.AuditLog{}, &models.SafetyRule{}, &models.ShadowBan{}, &models.APIKey{},
+	&models.User{}, &models.Session{}, &models.BackupCode{}, &models.HookSubscription{}, &models.HookDelivery{}, &models.ArchivedURL{},
+	&models.VerifiedDomain{}, &models.LinkVariant{}, &models.ClickExport{}, &models.ClickRollup{}, &models.RenamedAlias{},
+	&models.LinkStatsReset{}, &models.BulkOperation{}, &models.Domain{}, &models.LinkVersion{},
+	&models.Webhook{}, &models.LinkChange{}, &models.TLSCertificate{}, &models.AbuseScore{}, &models.AbuseReport{},
+	&models.LinkAnnotation{}, &models.Funnel{}, &models.TagRule{}, &models.Artifact{}, &models.ConversionEvent{},
+	&models.Collection{}, &models.CollectionMember{}, &models.LinkReference{}, &models.Impersonation{},
+}
+
+// Result of the migration run by InitDB
+var migration models.MigrationStatus
+
+// MigrationStatus reports the startup migration and checks every migrated
+// table still exists
+func MigrationStatus(ctx context.Context) models.MigrationStatus {
+	status := migration
+	for _, table := range allTables() {
+		if !DB.WithContext(ctx).Migrator().HasTable(table.name) {
+			status.MissingTables = append(status.MissingTables, table.name)
+		}
+	}
+	return status
+}
+
+// VerifySchema compares the database to what Migrate creates without
+// changing anything, returning a description of each difference
+func VerifySchema(ctx context.Context) ([]string, error) {
+	db := DB.WithContext(ctx)
+	migrator := db.Migrator()
+
+	var problems []string
+	for _, table := range allTables() {
+		if !migrator.HasTable(table.name) {
+			problems = append(problems, "table "+table.name+" is missing")
+			continue
+		}
+		for _, column := range table.columns {
+			if !migrator.HasColumn(table.name, column) {
+				problems = append(problems, "column "+table.name+"."+column+" is missing")
+			}
+		}
+	}
+
+	// SQLite has no sequences, trigger or partitions to check
+	if usesSQLite() {
+		return problems, nil
+	}
+
+	for _, sequence := range []string{"sms_code_seq", "short_code_seq"} {
+		var exists bool
+		if err := db.Raw("SELECT EXISTS (SELECT 1 FROM pg_class WHERE relkind = 'S' AND relname = ?)", sequence).Scan(&exists).Error; err != nil {
+			return nil, err
+		}
+		if !exists {
+			problems = append(problems, "sequence "+sequence+" is missing")
+		}
+	}
+
+	var hasTrigger bool
+	if err := db.Raw("SELECT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'urls_record_change')").Scan(&hasTrigger).Error; err != nil {
+		return nil, err
+	}
+	if !hasTrigger {
+		problems = append(problems, "trigger urls_record_change is missing")
+	}
+
+	partition := monthStart(time.Now()).Format(clickEventPartitionLayout)
+	if !migrator.HasTable(partition) {
+		problems = append(problems, "click_events partition "+partition+" for the current month is missing")
+	}
+
+	return problems, nil
+}
+
+type tableColumns struct {
+	name    string
+	columns []string
+}
+
+// allTables lists the tables Migrate creates with their columns
+func allTables() []tableColumns {
+	var tables []tableColumns
+	for _, model := range append(migratedModels, &models.ClickEvent{}) {
+		stmt := &gorm.Statement{DB: DB}
+		if err := stmt.Parse(model); err != nil {
+			continue
+		}
+		table := tableColumns{name: stmt.Schema.Table}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" {
+				table.columns = append(table.columns, field.DBName)
+			}
+		}
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+// backfillURLHashes sets original_url_hash on the oldest visible link of
+// each owner for each destination, matching the link deduplication
+// previously returned
+func backfillURLHashes() error {
+	type ownedHash struct {
+		owner uint
+		hash  string
+	}
+	seen := make(map[ownedHash]bool)
+	var updated int
+
+	var batch []models.URL
+	err := DB.Where("inert = ?", false).Order("id").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, url := range batch {
+			key := ownedHash{hash: utils.HashURL(utils.CanonicalURL(url.OriginalURL))}
+			if url.OwnerID != nil {
+				key.owner = *url.OwnerID
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			if err := DB.Model(&models.URL{}).Where("id = ?", url.ID).Update("original_url_hash", key.hash).Error; err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Backfilled destination hashes", "links", updated)
+	return nil
+}
+
+// NextSMSCodeValue returns the next value for sequential SMS short codes
+func NextSMSCodeValue(ctx context.Context) (int64, error) {
+	if usesSQLite() {
+		return nextSQLiteSequenceValue(ctx, "sms_code_seq")
+	}
+	var value int64
+	err := DB.WithContext(ctx).Raw("SELECT nextval('sms_code_seq')").Scan(&value).Error
+	return value, err
+}
+
+// NextShortCodeValue returns the next value for sequential random style
+// short codes
+func NextShortCodeValue(ctx context.Context) (int64, error) {
+	if usesSQLite() {
+		return nextSQLiteSequenceValue(ctx, "short_code_seq")
+	}
+	var value int64
+	err := DB.WithContext(ctx).Raw("SELECT nextval('short_code_seq')").Scan(&value).Error
+	return value, err
+}
+
+// Close closes the connection pool once in-flight queries finish, and the
+// Bolt file
+func Close() error {
+	if Bolt != nil {
+		if err := Bolt.Close(); err != nil {
+			return err
+		}
+		Bolt = nil
+	}
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
 	}
-	return defaultValue
+	return sqlDB.Close()
 }
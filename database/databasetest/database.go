@@ -0,0 +1,40 @@
+// Package databasetest points the database package at an in-memory SQLite
+// database for tests of code querying database.DB, and creates the rows
+// those tests need
+package databasetest
+
+import (
+	"testing"
+	"time"
+
+	"url-shortener/config"
+	"url-shortener/database"
+	"url-shortener/encryption"
+	"url-shortener/notify"
+)
+
+// UseSQLite points the database package at an in-memory SQLite database
+// migrated for the test, for code querying database.DB rather than a
+// Store. Destinations are stored unencrypted, and the database is closed
+// when the test ends, once the hooks fired in the background are done
+// with it.
+func UseSQLite(t *testing.T) {
+	t.Helper()
+	t.Setenv("URL_ENCRYPTION_KEY", "")
+	encryption.Init()
+
+	cfg := config.Default().Database
+	cfg.Driver = database.DriverSQLite
+	cfg.Path = ":memory:"
+	if err := database.Connect(cfg); err != nil {
+		t.Fatalf("Connect() = %v", err)
+	}
+	t.Cleanup(func() {
+		notify.Drain(5 * time.Second)
+		database.Close()
+		database.DB, database.Prepared, database.Links = nil, nil, nil
+	})
+	if err := database.Migrate(); err != nil {
+		t.Fatalf("Migrate() = %v", err)
+	}
+}
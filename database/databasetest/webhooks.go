@@ -0,0 +1,36 @@
+package databasetest
+
+import (
+	"testing"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/notify"
+)
+
+// CreateWebhook registers a webhook of ownerID subscribed to events, on a
+// URL refusing connections, so deliveries are recorded and left to retry
+func CreateWebhook(t *testing.T, ownerID uint, events ...string) *models.Webhook {
+	t.Helper()
+	webhook := &models.Webhook{
+		OwnerID: ownerID, URL: "http://127.0.0.1:1/", Events: events,
+		Secret: "secret", ExpiryCheckedAt: time.Now(),
+	}
+	if err := database.DB.Create(webhook).Error; err != nil {
+		t.Fatalf("creating webhook: %v", err)
+	}
+	return webhook
+}
+
+// Deliveries waits for the hooks fired in the background and returns the
+// deliveries recorded for webhook, oldest first
+func Deliveries(t *testing.T, webhook *models.Webhook) []models.HookDelivery {
+	t.Helper()
+	notify.Drain(5 * time.Second)
+	var deliveries []models.HookDelivery
+	if err := database.DB.Where("webhook_id = ?", webhook.ID).Order("id").Find(&deliveries).Error; err != nil {
+		t.Fatalf("loading deliveries: %v", err)
+	}
+	return deliveries
+}
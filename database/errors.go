@@ -0,0 +1,64 @@
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+
+	"url-shortener/chaos"
+	"url-shortener/storage"
+
+	"github.com/glebarez/go-sqlite"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// storeError classifies a database error: missing rows are
+// storage.ErrNotFound, unique violations storage.ErrConflict, and failures
+// reaching the database storage.ErrBackendUnavailable
+func storeError(err error) error {
+	var pgErr *pgconn.PgError
+	var connectErr *pgconn.ConnectError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return storage.Wrap(storage.ErrNotFound, err)
+	case IsUniqueViolation(err):
+		return storage.Wrap(storage.ErrConflict, err)
+	// Connection exceptions (class 08) and the server shutting down (57P0x)
+	case errors.As(err, &pgErr) && (strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "57P0")):
+		return storage.Wrap(storage.ErrBackendUnavailable, err)
+	case errors.As(err, &connectErr) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, chaos.ErrInjected) || storage.Unreachable(err):
+		return storage.Wrap(storage.ErrBackendUnavailable, err)
+	}
+	return err
+}
+
+// IsUniqueViolation reports whether err is a unique constraint violation,
+// such as two requests claiming the same alias at once
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	var sqliteErr *sqlite.Error
+	switch {
+	case errors.As(err, &pgErr):
+		return pgErr.Code == "23505"
+	case errors.As(err, &sqliteErr):
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	return false
+}
+
+// IsShortCodeViolation reports whether err is a unique violation of the
+// short code of links, rather than of another unique column
+func IsShortCodeViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505" && pgErr.ConstraintName == "idx_urls_short_code"
+	}
+	// SQLite names the columns of the violated index instead
+	return IsUniqueViolation(err) && strings.Contains(err.Error(), "UNIQUE constraint failed: urls.short_code (")
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/expiry"
+)
+
+// EnforceLinkExpiry caps the expiry of links not exempted by an admin at the
+// policy's maximum lifetime, like expiry.Policy.Enforce, for live and archived
+// links. It returns the short codes of the live links changed so their cached
+// redirects can be dropped. updated_at is left alone so capping a link doesn't
+// keep it from being archived.
+func EnforceLinkExpiry(ctx context.Context, policy expiry.Policy, now time.Time) ([]string, error) {
+	if !policy.Enforced() {
+		return nil, nil
+	}
+	earliest := now.Add(expiry.GracePeriod)
+	const limit = `greatest(created_at + make_interval(days => ?), ?::timestamptz)`
+	const where = `NOT expiry_exempt AND (expires_at IS NULL OR expires_at > ` + limit + `)`
+
+	var shortCodes []string
+	err := DB.WithContext(ctx).Raw(`
+		UPDATE urls SET expires_at = `+limit+`
+		WHERE deleted_at IS NULL AND `+where+`
+		RETURNING short_code`, policy.MaxDays, earliest, policy.MaxDays, earliest).Scan(&shortCodes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = DB.WithContext(ctx).Exec(`UPDATE archived_urls SET expires_at = `+limit+` WHERE `+where,
+		policy.MaxDays, earliest, policy.MaxDays, earliest).Error
+	return shortCodes, err
+}
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+)
+
+// FindExternalLink returns the live link of ownerID with the CMS identifier
+// externalID, rehydrating it when it was archived. It returns
+// storage.ErrNotFound when the owner has no such link.
+func FindExternalLink(ctx context.Context, ownerID uint, externalID string) (*models.URL, error) {
+	db := DB.WithContext(ctx)
+	var url models.URL
+	err := db.Where("owner_id = ? AND external_id = ?", ownerID, externalID).First(&url).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		if err != nil {
+			return nil, storeError(err)
+		}
+		return &url, nil
+	}
+
+	var archived models.ArchivedURL
+	if err := db.Select("short_code").Where("owner_id = ? AND external_id = ?", ownerID, externalID).First(&archived).Error; err != nil {
+		return nil, storeError(err)
+	}
+	return RehydrateURL(ctx, archived.ShortCode)
+}
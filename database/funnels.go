@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FunnelVisitors counts the visitors who clicked each of the links in
+// order between from and to, each click at or after the visitor's first
+// click of the previous step. Visitors are told apart by the hash click
+// events record, so clicks recorded without one are not counted.
+func FunnelVisitors(ctx context.Context, urlIDs []uint, from, to time.Time) ([]int64, error) {
+	counts := make([]int64, len(urlIDs))
+	if len(urlIDs) == 0 {
+		return counts, nil
+	}
+
+	// Each step keeps the first click of every visitor who reached it
+	steps := make([]string, len(urlIDs))
+	selects := make([]string, len(urlIDs))
+	args := make([]interface{}, 0, 3*len(urlIDs))
+	for i, urlID := range urlIDs {
+		if i == 0 {
+			steps[i] = `s0 AS (SELECT visitor_hash, min(clicked_at) AS at FROM click_events
+				WHERE url_id = ? AND clicked_at >= ? AND clicked_at < ? AND visitor_hash <> ''
+				GROUP BY visitor_hash)`
+		} else {
+			steps[i] = fmt.Sprintf(`s%d AS (SELECT e.visitor_hash, min(e.clicked_at) AS at FROM click_events e
+				JOIN s%d p ON p.visitor_hash = e.visitor_hash AND e.clicked_at >= p.at
+				WHERE e.url_id = ? AND e.clicked_at >= ? AND e.clicked_at < ?
+				GROUP BY e.visitor_hash)`, i, i-1)
+		}
+		selects[i] = fmt.Sprintf("(SELECT count(*) FROM s%d) AS step%d", i, i)
+		args = append(args, urlID, from, to)
+	}
+
+	rows, err := DB.WithContext(ctx).Raw("WITH "+strings.Join(steps, ", ")+" SELECT "+strings.Join(selects, ", "), args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dest := make([]interface{}, len(counts))
+	for i := range counts {
+		dest[i] = &counts[i]
+	}
+	if rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+	}
+	return counts, rows.Err()
+}
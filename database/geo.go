@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+
+	"url-shortener/geo"
+)
+
+// CoarsenClickEvents drops stored click locations beyond what policy allows
+// for their country, so lowering the precision also applies to clicks
+// recorded before the change. It returns the number of rows changed.
+func CoarsenClickEvents(ctx context.Context, policy geo.Policy) (int64, error) {
+	db := DB.WithContext(ctx)
+	steps := []struct {
+		precision geo.Precision
+		set       map[string]interface{}
+		stored    string
+	}{
+		{geo.PrecisionCity, map[string]interface{}{"city": ""}, "city <> ''"},
+		{geo.PrecisionRegion, map[string]interface{}{"region": "", "city": ""}, "(region <> '' OR city <> '')"},
+		{geo.PrecisionCountry, map[string]interface{}{"country": "", "region": "", "city": ""}, "country <> ''"},
+	}
+
+	var changed int64
+	for _, step := range steps {
+		countries, defaultBelow := policy.CountriesBelow(step.precision)
+		query := db.Table("click_events").Where(step.stored)
+		switch {
+		case defaultBelow:
+			// Everything but the countries allowed this precision
+			var allowed []string
+			for country, precision := range policy.Countries {
+				if precision >= step.precision {
+					allowed = append(allowed, country)
+				}
+			}
+			if len(allowed) > 0 {
+				query = query.Where("country NOT IN ?", allowed)
+			}
+		case len(countries) > 0:
+			query = query.Where("country IN ?", countries)
+		default:
+			continue
+		}
+
+		result := query.Updates(step.set)
+		if result.Error != nil {
+			return changed, result.Error
+		}
+		changed += result.RowsAffected
+	}
+	return changed, nil
+}
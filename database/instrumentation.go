@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const instrumentationStartKey = "instrumentation:start"
+
+type routeContextKey struct{}
+
+// WithRoute attaches the HTTP route issuing queries to ctx, so slow queries
+// can be attributed to it. Use with DB.WithContext.
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeContextKey{}, route)
+}
+
+// QueryStats aggregates durations for one operation on one table
+type QueryStats struct {
+	Instance  string  `json:"instance,omitempty"` // reporting instance, empty when merged across the fleet
+	Operation string  `json:"operation"`
+	Table     string  `json:"table"`
+	Count     int64   `json:"count"`
+	SlowCount int64   `json:"slow_count"`
+	TotalMs   float64 `json:"total_ms"`
+	MaxMs     float64 `json:"max_ms"`
+	AvgMs     float64 `json:"avg_ms"`
+}
+
+var (
+	metricsMu    sync.Mutex
+	queryMetrics = make(map[string]*QueryStats)
+)
+
+// QueryMetrics returns a snapshot of per-query duration statistics, slowest total first
+func QueryMetrics() []QueryStats {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	snapshot := make([]QueryStats, 0, len(queryMetrics))
+	for _, stats := range queryMetrics {
+		entry := *stats
+		entry.AvgMs = entry.TotalMs / float64(entry.Count)
+		snapshot = append(snapshot, entry)
+	}
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].TotalMs > snapshot[j].TotalMs })
+	return snapshot
+}
+
+// MergeQueryStats adds up the query statistics of several instances,
+// slowest total first
+func MergeQueryStats(sets ...[]QueryStats) []QueryStats {
+	merged := make(map[string]*QueryStats)
+	for _, set := range sets {
+		for _, stats := range set {
+			key := stats.Operation + ":" + stats.Table
+			target, ok := merged[key]
+			if !ok {
+				target = &QueryStats{Operation: stats.Operation, Table: stats.Table}
+				merged[key] = target
+			}
+			target.Count += stats.Count
+			target.SlowCount += stats.SlowCount
+			target.TotalMs += stats.TotalMs
+			target.MaxMs = max(target.MaxMs, stats.MaxMs)
+		}
+	}
+
+	result := make([]QueryStats, 0, len(merged))
+	for _, stats := range merged {
+		if stats.Count > 0 {
+			stats.AvgMs = stats.TotalMs / float64(stats.Count)
+		}
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].TotalMs > result[j].TotalMs })
+	return result
+}
+
+// Instrumentation is a GORM plugin recording query durations and logging
+// queries slower than SlowThreshold together with the calling route
+type Instrumentation struct {
+	SlowThreshold time.Duration
+}
+
+// Name implements gorm.Plugin
+func (i *Instrumentation) Name() string {
+	return "instrumentation"
+}
+
+// Initialize implements gorm.Plugin
+func (i *Instrumentation) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	registrations := []error{
+		cb.Create().Before("gorm:create").Register("instrumentation:before_create", before),
+		cb.Create().After("gorm:create").Register("instrumentation:after_create", i.after("create")),
+		cb.Query().Before("gorm:query").Register("instrumentation:before_query", before),
+		cb.Query().After("gorm:query").Register("instrumentation:after_query", i.after("query")),
+		cb.Update().Before("gorm:update").Register("instrumentation:before_update", before),
+		cb.Update().After("gorm:update").Register("instrumentation:after_update", i.after("update")),
+		cb.Delete().Before("gorm:delete").Register("instrumentation:before_delete", before),
+		cb.Delete().After("gorm:delete").Register("instrumentation:after_delete", i.after("delete")),
+		cb.Row().Before("gorm:row").Register("instrumentation:before_row", before),
+		cb.Row().After("gorm:row").Register("instrumentation:after_row", i.after("row")),
+		cb.Raw().Before("gorm:raw").Register("instrumentation:before_raw", before),
+		cb.Raw().After("gorm:raw").Register("instrumentation:after_raw", i.after("raw")),
+	}
+
+	for _, err := range registrations {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func before(db *gorm.DB) {
+	db.InstanceSet(instrumentationStartKey, time.Now())
+}
+
+func (i *Instrumentation) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		i.record(db, operation)
+	}
+}
+
+func (i *Instrumentation) record(db *gorm.DB, operation string) {
+	value, ok := db.InstanceGet(instrumentationStartKey)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(value.(time.Time))
+	elapsedMs := float64(elapsed) / float64(time.Millisecond)
+	slow := i.SlowThreshold > 0 && elapsed > i.SlowThreshold
+
+	table := db.Statement.Table
+	key := operation + ":" + table
+
+	metricsMu.Lock()
+	stats, ok := queryMetrics[key]
+	if !ok {
+		stats = &QueryStats{Operation: operation, Table: table}
+		queryMetrics[key] = stats
+	}
+	stats.Count++
+	stats.TotalMs += elapsedMs
+	if elapsedMs > stats.MaxMs {
+		stats.MaxMs = elapsedMs
+	}
+	if slow {
+		stats.SlowCount++
+	}
+	metricsMu.Unlock()
+
+	if slow {
+		slog.WarnContext(db.Statement.Context, "Slow query",
+			"route", queryRoute(db.Statement.Context),
+			"sql", db.Statement.SQL.String(),
+			"rows", db.Statement.RowsAffected,
+			"elapsed_ms", elapsedMs,
+		)
+	}
+}
+
+// queryRoute returns the route attached by WithRoute, or - without one
+func queryRoute(ctx context.Context) string {
+	if route, _ := ctx.Value(routeContextKey{}).(string); route != "" {
+		return route
+	}
+	return "-"
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"url-shortener/models"
+)
+
+// duplicateShortCodes selects the short codes used by more than one link,
+// live, deleted or archived
+const duplicateShortCodes = `
+	SELECT short_code FROM (
+		SELECT short_code FROM urls
+		UNION ALL
+		SELECT short_code FROM archived_urls
+	) AS codes GROUP BY short_code HAVING count(*) > 1`
+
+// orphanedAliases selects the renamed aliases whose link is gone
+const orphanedAliases = `
+	SELECT alias FROM renamed_aliases a
+	WHERE NOT EXISTS (SELECT 1 FROM urls u WHERE u.id = a.url_id)
+	AND NOT EXISTS (SELECT 1 FROM archived_urls u WHERE u.id = a.url_id)`
+
+// expiredLinksBefore selects the live links, locked ones aside, that expired
+// before a time
+const expiredLinksBefore = `
+	SELECT short_code FROM urls
+	WHERE deleted_at IS NULL AND NOT locked AND expires_at < ?`
+
+// DuplicateShortCodes counts the short codes used by more than one link,
+// which the unique index on urls.short_code and archiving should prevent but
+// a restore without indexes or from separate dumps can bring back
+func DuplicateShortCodes(ctx context.Context) (models.IntegrityCheck, error) {
+	check := models.IntegrityCheck{Name: models.IntegrityDuplicateShortCodes}
+	err := DB.WithContext(ctx).Raw(`SELECT (SELECT count(*) FROM urls) + (SELECT count(*) FROM archived_urls)`).Scan(&check.Checked).Error
+	if err != nil {
+		return check, err
+	}
+	return check, findViolations(ctx, &check, duplicateShortCodes)
+}
+
+// OrphanedAliases counts the renamed aliases left behind by a link purged
+// without them
+func OrphanedAliases(ctx context.Context) (models.IntegrityCheck, error) {
+	check := models.IntegrityCheck{Name: models.IntegrityOrphanedAliases}
+	if err := DB.WithContext(ctx).Model(&models.RenamedAlias{}).Count(&check.Checked).Error; err != nil {
+		return check, err
+	}
+	return check, findViolations(ctx, &check, orphanedAliases)
+}
+
+// ExpiredLinks counts the live links, locked ones aside, that expired before
+// before and should have been cleaned up
+func ExpiredLinks(ctx context.Context, before time.Time) (models.IntegrityCheck, error) {
+	check := models.IntegrityCheck{Name: models.IntegrityExpiredLinks}
+	if err := DB.WithContext(ctx).Model(&models.URL{}).Where("expires_at IS NOT NULL").Count(&check.Checked).Error; err != nil {
+		return check, err
+	}
+	return check, findViolations(ctx, &check, expiredLinksBefore, before)
+}
+
+// SampleLinks returns up to n live links from a random point of the urls
+// table on
+func SampleLinks(ctx context.Context, n int) ([]models.URL, error) {
+	var maxID uint
+	if err := DB.WithContext(ctx).Model(&models.URL{}).Select("COALESCE(max(id), 0)").Scan(&maxID).Error; err != nil {
+		return nil, err
+	}
+	start := uint(0)
+	if maxID > 0 {
+		start = uint(rand.Int63n(int64(maxID)))
+	}
+
+	var links []models.URL
+	err := DB.WithContext(ctx).Where("id > ?", start).Order("id").Limit(n).Find(&links).Error
+	if err == nil && len(links) < n && start > 0 {
+		// Wrap around to the start of the table
+		var more []models.URL
+		err = DB.WithContext(ctx).Where("id <= ?", start).Order("id").Limit(n - len(links)).Find(&more).Error
+		links = append(links, more...)
+	}
+	return links, err
+}
+
+// findViolations counts the rows of query, which selects one offending value
+// per row, into check with a sample of them
+func findViolations(ctx context.Context, check *models.IntegrityCheck, query string, args ...interface{}) error {
+	db := DB.WithContext(ctx)
+	if err := db.Raw(`SELECT count(*) FROM (`+query+`) AS violations`, args...).Scan(&check.Violations).Error; err != nil {
+		return err
+	}
+	if check.Violations == 0 {
+		return nil
+	}
+	return db.Raw(query+` ORDER BY 1 LIMIT ?`, append(args, models.IntegritySamples)...).Scan(&check.Samples).Error
+}
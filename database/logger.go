@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slogLogger writes GORM's messages and failed queries to slog, with the
+// request ID and route of the query's context. Slow queries are logged by
+// the instrumentation instead.
+type slogLogger struct{}
+
+func (l slogLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+func (slogLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	slog.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (slogLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	slog.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (slogLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	slog.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// Trace logs queries failing for another reason than finding no rows or
+// the request giving up
+func (slogLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if err == nil || errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, context.Canceled) {
+		return
+	}
+	sql, rows := fc()
+	slog.ErrorContext(ctx, "Query failed",
+		"route", queryRoute(ctx),
+		"sql", sql,
+		"rows", rows,
+		"elapsed_ms", float64(time.Since(begin).Microseconds())/1000,
+		"error", err,
+	)
+}
@@ -0,0 +1,209 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// How long a dry run waits for the locks of the changes it tries, so it
+// never queues traffic behind it for long
+const migrationPlanLockTimeout = "5s"
+
+// MigrationPlan is what Migrate would change in the database
+type MigrationPlan struct {
+	// Statements changing the schema, in the order they would run.
+	// Statements changing nothing, such as CREATE ... IF NOT EXISTS of an
+	// existing object, are left out.
+	Statements []string
+	// Differences between the live schema and the migrated one, each
+	// starting with + (added), - (removed) or ~ (changed)
+	Differences []string
+	// Data migrations that would run after the schema changes
+	Backfills []string
+}
+
+// PlanMigration runs Migrate's schema changes in a transaction it rolls
+// back, recording the statements that changed the schema and comparing the
+// catalog before and after. Nothing is changed, but the locks the
+// statements take are held until the rollback, for at most the 5s each
+// waits for them. Backfills are listed, not run.
+func PlanMigration(ctx context.Context) (*MigrationPlan, error) {
+	recorder := &statementRecorder{}
+	tx := DB.WithContext(ctx).Session(&gorm.Session{Logger: recorder}).Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	defer tx.Rollback()
+
+	quiet := tx.Session(&gorm.Session{Logger: logger.Discard})
+	if err := quiet.Exec("SET LOCAL lock_timeout = '" + migrationPlanLockTimeout + "'").Error; err != nil {
+		return nil, err
+	}
+	before, err := schemaSnapshot(quiet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the schema: %w", err)
+	}
+
+	plan := &MigrationPlan{}
+	if needsURLHashBackfill(quiet) {
+		plan.Backfills = append(plan.Backfills, "destination hashes of existing links (urls.original_url_hash)")
+	}
+
+	recorder.db, recorder.last = quiet, before
+	migrateErr := migrateSchema(tx)
+	if recorder.err != nil {
+		return nil, fmt.Errorf("failed to read the schema: %w", recorder.err)
+	}
+	for _, step := range recorder.steps {
+		plan.Statements = append(plan.Statements, step.statement)
+	}
+	if migrateErr != nil {
+		// The statements up to the failing one are still worth reviewing
+		return plan, fmt.Errorf("migration would fail: %w", migrateErr)
+	}
+
+	after, err := schemaSnapshot(quiet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the schema: %w", err)
+	}
+	plan.Differences = diffSchemas(before, after)
+	return plan, nil
+}
+
+// statementRecorder is a GORM logger keeping the statements that changed
+// the schema, read through db after each one
+type statementRecorder struct {
+	db    *gorm.DB
+	last  map[string]string
+	steps []recordedStatement
+	err   error
+}
+
+type recordedStatement struct {
+	statement string
+	before    map[string]string // schema before the statement
+}
+
+func (r *statementRecorder) LogMode(logger.LogLevel) logger.Interface      { return r }
+func (r *statementRecorder) Info(context.Context, string, ...interface{})  {}
+func (r *statementRecorder) Warn(context.Context, string, ...interface{})  {}
+func (r *statementRecorder) Error(context.Context, string, ...interface{}) {}
+func (r *statementRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), err error) {
+	if r.db == nil || r.err != nil || err != nil {
+		return
+	}
+	statement, _ := fc()
+	if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(statement)), "SELECT") {
+		return
+	}
+
+	current, err := schemaSnapshot(r.db)
+	if err != nil {
+		r.err = err
+		return
+	}
+	switch {
+	case maps.Equal(current, r.last):
+		// Changed nothing
+	case len(r.steps) > 0 && maps.Equal(current, r.steps[len(r.steps)-1].before):
+		// Undid the previous statement, e.g. a trigger dropped and created
+		// again as it was
+		r.steps = r.steps[:len(r.steps)-1]
+	default:
+		r.steps = append(r.steps, recordedStatement{statement: strings.TrimSpace(statement), before: r.last})
+	}
+	r.last = current
+}
+
+// schemaSnapshotQuery describes every table, sequence, column, index,
+// constraint, trigger and function of the current schema. Partitions are
+// listed as tables only, as their columns and indexes follow their parent's.
+const schemaSnapshotQuery = `
+	WITH rels AS (
+		SELECT oid, relname, relkind, relispartition FROM pg_class
+		WHERE relnamespace = current_schema()::text::regnamespace
+	)
+	SELECT 'table ' || relname AS object, CASE WHEN relkind = 'p' THEN 'partitioned' ELSE '' END AS definition
+		FROM rels WHERE relkind IN ('r', 'p')
+	UNION ALL
+	SELECT 'sequence ' || relname, '' FROM rels WHERE relkind = 'S'
+	UNION ALL
+	SELECT 'column ' || r.relname || '.' || a.attname,
+		format_type(a.atttypid, a.atttypmod) ||
+		CASE WHEN a.attnotnull THEN ' NOT NULL' ELSE '' END ||
+		coalesce(' DEFAULT ' || pg_get_expr(d.adbin, d.adrelid), '')
+		FROM rels r JOIN pg_attribute a ON a.attrelid = r.oid
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE r.relkind IN ('r', 'p') AND NOT r.relispartition AND a.attnum > 0 AND NOT a.attisdropped
+	UNION ALL
+	SELECT 'index ' || i.relname, pg_get_indexdef(x.indexrelid)
+		FROM pg_index x JOIN rels i ON i.oid = x.indexrelid JOIN rels r ON r.oid = x.indrelid
+		WHERE NOT r.relispartition
+	UNION ALL
+	SELECT 'constraint ' || r.relname || '.' || k.conname, pg_get_constraintdef(k.oid)
+		FROM pg_constraint k JOIN rels r ON r.oid = k.conrelid
+		WHERE NOT r.relispartition
+	UNION ALL
+	SELECT 'trigger ' || t.tgname, pg_get_triggerdef(t.oid)
+		FROM pg_trigger t JOIN rels r ON r.oid = t.tgrelid
+		WHERE NOT t.tgisinternal AND NOT r.relispartition
+	UNION ALL
+	SELECT 'function ' || p.proname, md5(pg_get_functiondef(p.oid))
+		FROM pg_proc p WHERE p.pronamespace = current_schema()::text::regnamespace AND p.prokind = 'f'`
+
+// schemaSnapshot returns the definition of every object of the current
+// schema by its kind and name
+func schemaSnapshot(db *gorm.DB) (map[string]string, error) {
+	rows, err := db.Raw(schemaSnapshotQuery).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshot := make(map[string]string)
+	for rows.Next() {
+		var object, definition string
+		if err := rows.Scan(&object, &definition); err != nil {
+			return nil, err
+		}
+		snapshot[object] = definition
+	}
+	return snapshot, rows.Err()
+}
+
+// diffSchemas describes the objects added, removed and changed from before
+// to after, sorted by object
+func diffSchemas(before, after map[string]string) []string {
+	var differences []string
+	for object, definition := range after {
+		previous, existed := before[object]
+		switch {
+		case previous == definition && existed:
+		case strings.HasPrefix(object, "function "):
+			// Definitions of functions are compared by hash
+			if existed {
+				differences = append(differences, "~ "+object+": body changes")
+			} else {
+				differences = append(differences, "+ "+object)
+			}
+		case !existed:
+			differences = append(differences, strings.TrimSpace("+ "+object+" "+definition))
+		default:
+			differences = append(differences, "~ "+object+": "+previous+" -> "+definition)
+		}
+	}
+	for object := range before {
+		if _, exists := after[object]; !exists {
+			differences = append(differences, "- "+object)
+		}
+	}
+	sort.Slice(differences, func(i, j int) bool { return differences[i][2:] < differences[j][2:] })
+	return differences
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Monthly partitions of click_events are named click_events_pYYYY_MM
+const clickEventPartitionLayout = "click_events_p2006_01"
+
+// How many months of click_events partitions are created ahead of time
+const clickEventPartitionsAhead = 3
+
+// ensureClickEventsTable creates the partitioned click_events table.
+// AutoMigrate cannot declare partitioning, so the table is created by hand.
+func ensureClickEventsTable(db *gorm.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS click_events (
+			id bigserial NOT NULL,
+			clicked_at timestamptz NOT NULL,
+			url_id bigint NOT NULL,
+			short_code text NOT NULL,
+			referrer text,
+			user_agent text,
+			country text,
+			device_type text,
+			region text,
+			city text,
+			PRIMARY KEY (id, clicked_at)
+		) PARTITION BY RANGE (clicked_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_click_events_url_id_clicked_at ON click_events (url_id, clicked_at)`,
+		// Added after the table was first created
+		`ALTER TABLE click_events ADD COLUMN IF NOT EXISTS region text`,
+		`ALTER TABLE click_events ADD COLUMN IF NOT EXISTS city text`,
+		`ALTER TABLE click_events ADD COLUMN IF NOT EXISTS link_version integer NOT NULL DEFAULT 0`,
+		`ALTER TABLE click_events ADD COLUMN IF NOT EXISTS visitor_hash text NOT NULL DEFAULT ''`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnsureClickEventPartitions creates the partitions for the month containing
+// now and the following months, so inserts never miss a partition
+func EnsureClickEventPartitions(now time.Time) error {
+	return ensureClickEventPartitions(DB, now)
+}
+
+func ensureClickEventPartitions(db *gorm.DB, now time.Time) error {
+	month := monthStart(now)
+	for i := 0; i <= clickEventPartitionsAhead; i++ {
+		from := month.AddDate(0, i, 0)
+		to := from.AddDate(0, 1, 0)
+		statement := fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s PARTITION OF click_events FOR VALUES FROM ('%s') TO ('%s')",
+			from.Format(clickEventPartitionLayout), from.Format(time.RFC3339), to.Format(time.RFC3339),
+		)
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DropExpiredClickEventPartitions detaches and drops every partition whose
+// whole month is older than retention, returning the dropped partition names.
+// Dropping a partition is instant, unlike deleting its rows.
+func DropExpiredClickEventPartitions(now time.Time, retention time.Duration) ([]string, error) {
+	var partitions []string
+	err := DB.Raw(`SELECT child.relname FROM pg_inherits
+		JOIN pg_class parent ON parent.oid = pg_inherits.inhparent
+		JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+		WHERE parent.relname = 'click_events'`).Scan(&partitions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := now.Add(-retention)
+	var dropped []string
+	for _, partition := range partitions {
+		from, err := time.Parse(clickEventPartitionLayout, partition)
+		if err != nil {
+			// Not managed by us
+			continue
+		}
+		if from.AddDate(0, 1, 0).After(cutoff) {
+			continue
+		}
+
+		if err := DB.Exec(fmt.Sprintf("ALTER TABLE click_events DETACH PARTITION %s", partition)).Error; err != nil {
+			return dropped, err
+		}
+		if err := DB.Exec(fmt.Sprintf("DROP TABLE %s", partition)).Error; err != nil {
+			return dropped, err
+		}
+		slog.Info("Dropped click_events partition", "partition", partition)
+		dropped = append(dropped, partition)
+	}
+	return dropped, nil
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,17 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/models"
+)
+
+// LinksCreatedSince counts the links the user ownerID created since since,
+// deleted ones included
+func LinksCreatedSince(ctx context.Context, ownerID uint, since time.Time) (int64, error) {
+	var count int64
+	err := DB.WithContext(ctx).Unscoped().Model(&models.URL{}).
+		Where("owner_id = ? AND created_at >= ?", ownerID, since).Count(&count).Error
+	return count, err
+}
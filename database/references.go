@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/models"
+)
+
+// LinkReferences returns the external references of a link, oldest first
+func LinkReferences(ctx context.Context, urlID uint) ([]models.LinkReference, error) {
+	references := []models.LinkReference{}
+	err := DB.WithContext(ctx).Where("url_id = ?", urlID).Order("id").Find(&references).Error
+	return references, storeError(err)
+}
+
+// CountLinkReferences counts the external references of a link
+func CountLinkReferences(ctx context.Context, urlID uint) (int64, error) {
+	var count int64
+	err := DB.WithContext(ctx).Model(&models.LinkReference{}).Where("url_id = ?", urlID).Count(&count).Error
+	return count, storeError(err)
+}
+
+// CreateLinkReference stores a new external reference
+func CreateLinkReference(ctx context.Context, reference *models.LinkReference) error {
+	return storeError(DB.WithContext(ctx).Create(reference).Error)
+}
+
+// UpdateLinkReference saves the URL, kind and title of the external
+// reference of a link with the ID of reference, reporting whether it existed,
+// and loads the rest of it into reference
+func UpdateLinkReference(ctx context.Context, reference *models.LinkReference) (bool, error) {
+	db := DB.WithContext(ctx)
+	result := db.Model(&models.LinkReference{}).Where("url_id = ? AND id = ?", reference.URLID, reference.ID).
+		Updates(map[string]interface{}{"url": reference.URL, "kind": reference.Kind, "title": reference.Title, "updated_at": time.Now()})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, storeError(result.Error)
+	}
+	return true, storeError(db.First(reference, reference.ID).Error)
+}
+
+// DeleteLinkReference deletes the external reference id of a link,
+// reporting whether it existed
+func DeleteLinkReference(ctx context.Context, urlID, id uint) (bool, error) {
+	result := DB.WithContext(ctx).Where("url_id = ? AND id = ?", urlID, id).Delete(&models.LinkReference{})
+	return result.RowsAffected > 0, storeError(result.Error)
+}
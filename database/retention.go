@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"url-shortener/models"
+	"url-shortener/retention"
+
+	"gorm.io/gorm"
+)
+
+// linksOnPlan selects the IDs of the links, deleted and archived ones
+// included, whose owner is on a plan. Links without an owner, or whose owner
+// is gone, are on the free plan.
+const linksOnPlan = `
+	SELECT links.id FROM (
+		SELECT id, owner_id FROM urls
+		UNION ALL
+		SELECT id, owner_id FROM archived_urls
+	) AS links LEFT JOIN users ON users.id = links.owner_id
+	WHERE COALESCE(NULLIF(users.plan, ''), '` + retention.PlanFree + `') = ?`
+
+// UserPlan returns the plan of the user ownerID, the free plan for links
+// without an owner or whose owner is gone
+func UserPlan(ctx context.Context, ownerID *uint) (string, error) {
+	if ownerID == nil {
+		return retention.PlanFree, nil
+	}
+	var user models.User
+	err := DB.WithContext(ctx).Select("plan").First(&user, *ownerID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && user.Plan == "") {
+		return retention.PlanFree, nil
+	}
+	return user.Plan, err
+}
+
+// PurgeClickHistory deletes the click and conversion events and hourly
+// rollups from before cutoff of the links whose owner is on plan, returning
+// how many events and rollups were deleted. Click and conversion counts are
+// kept.
+func PurgeClickHistory(ctx context.Context, plan string, cutoff time.Time) (events, rollups int64, err error) {
+	db := DB.WithContext(ctx)
+	result := db.Exec(`DELETE FROM click_events WHERE clicked_at < ? AND url_id IN (`+linksOnPlan+`)`, cutoff, plan)
+	if result.Error != nil {
+		return 0, 0, result.Error
+	}
+	events = result.RowsAffected
+
+	result = db.Exec(`DELETE FROM conversion_events WHERE converted_at < ? AND url_id IN (`+linksOnPlan+`)`, cutoff, plan)
+	if result.Error != nil {
+		return events, 0, result.Error
+	}
+	events += result.RowsAffected
+
+	result = db.Exec(`DELETE FROM click_rollups WHERE hour < ? AND url_id IN (`+linksOnPlan+`)`, cutoff, plan)
+	return events, result.RowsAffected, result.Error
+}
@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"url-shortener/models"
+)
+
+// UTC start of the hour of a click event, as a timestamptz
+const clickHour = `date_trunc('hour', clicked_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'`
+
+// ClickRollupProgress returns the latest rolled up hour, zero when nothing
+// was rolled up yet, and the hour of the oldest click event after it. It
+// reports false when there is no such click event.
+func ClickRollupProgress(ctx context.Context) (latest, next time.Time, ok bool, err error) {
+	var rolled *time.Time
+	if err = DB.WithContext(ctx).Table("click_rollups").Select("max(hour)").Scan(&rolled).Error; err != nil {
+		return
+	}
+	query := DB.WithContext(ctx).Table("click_events").Select("min(clicked_at)")
+	if rolled != nil {
+		latest = rolled.UTC()
+		query = query.Where("clicked_at >= ?", latest.Add(time.Hour))
+	}
+
+	var oldest *time.Time
+	if err = query.Scan(&oldest).Error; err != nil || oldest == nil {
+		return
+	}
+	return latest, oldest.UTC().Truncate(time.Hour), true, nil
+}
+
+// RollUpClicks recounts the click events of the hours in [from, to) per link,
+// replacing the rollups of those hours, and returns how many were written.
+// Rollups of hours whose events were deleted are kept.
+func RollUpClicks(ctx context.Context, from, to time.Time) (int64, error) {
+	result := DB.WithContext(ctx).Exec(`
+		INSERT INTO click_rollups (url_id, hour, clicks)
+		SELECT url_id, `+clickHour+` AS hour, count(*)
+		FROM click_events
+		WHERE clicked_at >= ? AND clicked_at < ?
+		GROUP BY 1, 2
+		ON CONFLICT (url_id, hour) DO UPDATE SET clicks = EXCLUDED.clicks`, from, to)
+	return result.RowsAffected, result.Error
+}
+
+// taggedLinks selects the ID and click count of live and archived links
+// carrying a tag
+const taggedLinks = `
+	SELECT id, click_count FROM urls WHERE deleted_at IS NULL AND tags @> ?::jsonb
+	UNION ALL
+	SELECT id, click_count FROM archived_urls WHERE tags @> ?::jsonb`
+
+// TagTotals counts the links carrying a tag and adds up their clicks
+func TagTotals(ctx context.Context, tag string) (links, clicks int64, err error) {
+	filter := tagFilter(tag)
+	var totals struct {
+		Links  int64
+		Clicks int64
+	}
+	err = DB.WithContext(ctx).Raw(`SELECT count(*) AS links, COALESCE(sum(click_count), 0) AS clicks
+		FROM (`+taggedLinks+`) AS tagged`, filter, filter).Scan(&totals).Error
+	return totals.Links, totals.Clicks, err
+}
+
+// TagClickCounts adds up the click rollups of the links carrying a tag per
+// hour or day of the time zone loc for the hours in [from, to), keyed by the
+// start of each bucket in UTC, as ClickCounts does. Rollups cover UTC hours,
+// so in zones offset by a fraction of an hour each rollup counts toward the
+// bucket it starts in. Buckets without clicks are absent.
+func TagClickCounts(ctx context.Context, tag, interval string, loc *time.Location, from, to time.Time) (map[time.Time]int64, error) {
+	filter := tagFilter(tag)
+	var rows []bucketRow
+	err := DB.WithContext(ctx).Raw(`SELECT date_trunc(?, r.hour, ?) AS bucket, sum(r.clicks) AS clicks
+		FROM click_rollups r JOIN (`+taggedLinks+`) AS tagged ON tagged.id = r.url_id
+		WHERE r.hour >= ? AND r.hour < ?
+		GROUP BY bucket`, interval, loc.String(), filter, filter, from, to).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return bucketCounts(rows), nil
+}
+
+// LinkClickCounts adds up a link's click rollups per hour or day of the time
+// zone loc for the hours in [from, to), keyed by the start of each bucket in
+// UTC as TagClickCounts does
+func LinkClickCounts(ctx context.Context, urlID uint, interval string, loc *time.Location, from, to time.Time) (map[time.Time]int64, error) {
+	var rows []bucketRow
+	err := DB.WithContext(ctx).Raw(`SELECT date_trunc(?, hour, ?) AS bucket, sum(clicks) AS clicks
+		FROM click_rollups
+		WHERE url_id = ? AND hour >= ? AND hour < ?
+		GROUP BY bucket`, interval, loc.String(), urlID, from, to).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return bucketCounts(rows), nil
+}
+
+// LinkClickTotals adds up the click rollups of each of the links in
+// [from, to), keyed by link ID; links without clicks are absent
+func LinkClickTotals(ctx context.Context, urlIDs []uint, from, to time.Time) (map[uint]int64, error) {
+	totals := make(map[uint]int64, len(urlIDs))
+	if len(urlIDs) == 0 {
+		return totals, nil
+	}
+	var rows []struct {
+		URLID  uint
+		Clicks int64
+	}
+	err := DB.WithContext(ctx).Raw(`SELECT url_id, sum(clicks) AS clicks FROM click_rollups
+		WHERE url_id IN ? AND hour >= ? AND hour < ?
+		GROUP BY url_id`, urlIDs, from, to).Scan(&rows).Error
+	for _, row := range rows {
+		totals[row.URLID] = row.Clicks
+	}
+	return totals, err
+}
+
+// ClickHeatmap adds up a link's click rollups of the hours in [from, to) per
+// weekday, Monday first, and hour of the day in the time zone loc. Rollups
+// cover UTC hours, so in zones offset by a fraction of an hour each rollup
+// counts toward the hour it starts in.
+func ClickHeatmap(ctx context.Context, urlID uint, loc *time.Location, from, to time.Time) ([7][24]int64, error) {
+	var rows []struct {
+		Weekday int
+		Hour    int
+		Clicks  int64
+	}
+	var heatmap [7][24]int64
+	err := DB.WithContext(ctx).Raw(`SELECT extract(isodow FROM hour AT TIME ZONE ?)::int - 1 AS weekday,
+			extract(hour FROM hour AT TIME ZONE ?)::int AS hour, sum(clicks) AS clicks
+		FROM click_rollups
+		WHERE url_id = ? AND hour >= ? AND hour < ?
+		GROUP BY 1, 2`, loc.String(), loc.String(), urlID, from, to).Scan(&rows).Error
+	if err != nil {
+		return heatmap, err
+	}
+	for _, row := range rows {
+		heatmap[row.Weekday][row.Hour] += row.Clicks
+	}
+	return heatmap, nil
+}
+
+// ownedLinks selects the ID and destination of a user's live and archived
+// links
+const ownedLinks = `
+	SELECT id, original_url FROM urls WHERE deleted_at IS NULL AND owner_id = ?
+	UNION ALL
+	SELECT id, original_url FROM archived_urls WHERE owner_id = ?`
+
+// DestinationClicks adds up the click rollups of a user's links for the
+// hours in [from, to) per destination host, a leading www. dropped, most
+// clicks first. Destinations must be stored in plaintext, see
+// DestinationDomainFilterable.
+func DestinationClicks(ctx context.Context, ownerID uint, from, to time.Time) ([]models.DestinationCount, error) {
+	destinations := []models.DestinationCount{}
+	err := DB.WithContext(ctx).Raw(`SELECT regexp_replace(lower(substring(owned.original_url from ?)), '^www\.', '') AS domain,
+			count(DISTINCT owned.id) AS links, sum(r.clicks) AS clicks
+		FROM click_rollups r JOIN (`+ownedLinks+`) AS owned ON owned.id = r.url_id
+		WHERE r.hour >= ? AND r.hour < ?
+		GROUP BY 1 HAVING sum(r.clicks) > 0
+		ORDER BY clicks DESC, domain`, destinationHostPattern, ownerID, ownerID, from, to).Scan(&destinations).Error
+	return destinations, err
+}
+
+// tagFilter is the jsonb containment argument matching links with a tag
+func tagFilter(tag string) string {
+	filter, _ := json.Marshal([]string{tag})
+	return string(filter)
+}
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"url-shortener/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// sqliteDialector opens the SQLite database file path, ":memory:" for a
+// database that lives as long as the connection pool. SQLite has a single
+// writer, so the pool keeps one connection, which also keeps an in-memory
+// database alive.
+func sqliteDialector(path string) gorm.Dialector {
+	return sqlite.Open(path + "?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)")
+}
+
+// usesSQLite reports whether DB is a SQLite database
+func usesSQLite() bool {
+	return dbDriver == DriverSQLite || dbDriver == DriverEmbedded
+}
+
+// migrateSQLite makes the schema changes of Migrate on SQLite. The
+// sequences are rows of the sequences table, click_events is a plain table
+// and link changes are not recorded, as SQLite has no triggers calling
+// functions.
+func migrateSQLite(db *gorm.DB) error {
+	if err := db.AutoMigrate(migratedModels...); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS sequences (name text PRIMARY KEY, value integer NOT NULL)`,
+		`INSERT OR IGNORE INTO sequences (name, value) VALUES ('sms_code_seq', 0), ('short_code_seq', 0)`,
+		`DROP INDEX IF EXISTS idx_urls_original_url_hash`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_owner_original_url_hash ON urls (COALESCE(owner_id, 0), original_url_hash) WHERE deleted_at IS NULL`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_owner_external_id ON urls (owner_id, external_id) WHERE deleted_at IS NULL`,
+		`CREATE TABLE IF NOT EXISTS click_events (
+			id integer PRIMARY KEY AUTOINCREMENT,
+			clicked_at datetime NOT NULL,
+			url_id integer NOT NULL,
+			short_code text NOT NULL,
+			referrer text,
+			user_agent text,
+			country text,
+			device_type text,
+			region text,
+			city text,
+			link_version integer NOT NULL DEFAULT 0,
+			visitor_hash text NOT NULL DEFAULT ''
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_click_events_url_id_clicked_at ON click_events (url_id, clicked_at)`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+	return nil
+}
+
+// nextSQLiteSequenceValue returns the next value of the sequence name,
+// which is a row of the sequences table on SQLite
+func nextSQLiteSequenceValue(ctx context.Context, name string) (int64, error) {
+	var value int64
+	err := DB.WithContext(ctx).Raw("UPDATE sequences SET value = value + 1 WHERE name = ? RETURNING value", name).Scan(&value).Error
+	return value, err
+}
+
+// sqliteStore is the Store over a SQLite DB. It shares the queries of
+// postgresStore that SQLite understands.
+type sqliteStore struct {
+	postgresStore
+}
+
+func (sqliteStore) Rehydrate(ctx context.Context, shortCode string) (*models.URL, error) {
+	var url models.URL
+	err := DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var archived models.ArchivedURL
+		if err := tx.Where("short_code = ?", shortCode).First(&archived).Error; err != nil {
+			return err
+		}
+		url = *archived.ToURL()
+		url.UpdatedAt = time.Now()
+
+		// The destination hash is dropped when another live link of the
+		// owner deduplicates the destination by now
+		if url.OriginalURLHash != nil {
+			var duplicates int64
+			query := tx.Model(&models.URL{}).Where("original_url_hash = ?", *url.OriginalURLHash)
+			if url.OwnerID != nil {
+				query = query.Where("owner_id = ?", *url.OwnerID)
+			} else {
+				query = query.Where("owner_id IS NULL")
+			}
+			if err := query.Count(&duplicates).Error; err != nil {
+				return err
+			}
+			if duplicates > 0 {
+				url.OriginalURLHash = nil
+			}
+		}
+
+		if err := tx.Exec("DELETE FROM archived_urls WHERE short_code = ?", shortCode).Error; err != nil {
+			return err
+		}
+		return tx.Create(&url).Error
+	})
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return &url, nil
+}
+
+func (sqliteStore) IncrementClicks(ctx context.Context, urls, variants map[uint]int64) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(urls))
+	now := time.Now()
+	err := DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for id, clicks := range urls {
+			var count []int64
+			err := tx.Raw("UPDATE urls SET click_count = click_count + ?, updated_at = ? WHERE id = ? RETURNING click_count",
+				clicks, now, id).Scan(&count).Error
+			if err != nil {
+				return err
+			}
+			if len(count) > 0 {
+				counts[id] = count[0]
+			}
+		}
+		for id, clicks := range variants {
+			if err := tx.Exec("UPDATE link_variants SET clicks = clicks + ? WHERE id = ?", clicks, id).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return counts, nil
+}
+
+func (sqliteStore) ConsumeClick(ctx context.Context, urlID uint) (int, error) {
+	var remaining []int
+	err := DB.WithContext(ctx).Raw(`UPDATE urls
+		SET clicks_remaining = clicks_remaining - 1,
+			expires_at = CASE WHEN clicks_remaining = 1 THEN ? ELSE expires_at END
+		WHERE id = ? AND clicks_remaining > 0
+		RETURNING clicks_remaining`, time.Now(), urlID).Scan(&remaining).Error
+	if err != nil {
+		return 0, storeError(err)
+	}
+	if len(remaining) == 0 {
+		return 0, ErrNoClicksLeft
+	}
+	return remaining[0], nil
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ResetLinkStats zeroes the click counters of a link and its variants,
+// keeping what they counted in a history record along with the increments
+// not written to them yet, which the caller took from the pending clicks
+func ResetLinkStats(ctx context.Context, urlID uint, actor string, pendingClicks int64, pendingVariants map[uint]int64) (*models.LinkStatsReset, error) {
+	var reset *models.LinkStatsReset
+	err := DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locked so clicks flushed meanwhile are counted after the reset
+		var urlRecord models.URL
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "short_code", "click_count", "created_at", "stats_reset_at", "track_landings", "landings").
+			First(&urlRecord, urlID).Error
+		if err != nil {
+			return err
+		}
+		var variants []models.LinkVariant
+		if err := tx.Select("id", "name", "clicks", "conversions").Where("url_id = ?", urlID).Order("id").Find(&variants).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		reset = &models.LinkStatsReset{
+			CreatedAt:   now,
+			URLID:       urlID,
+			ShortCode:   urlRecord.ShortCode,
+			Actor:       actor,
+			PeriodStart: urlRecord.CreatedAt,
+			ClickCount:  int64(urlRecord.ClickCount) + pendingClicks,
+		}
+		if urlRecord.TrackLandings {
+			reset.Landings = &urlRecord.Landings
+		}
+		if urlRecord.StatsResetAt != nil {
+			reset.PeriodStart = *urlRecord.StatsResetAt
+		}
+		for _, variant := range variants {
+			reset.Variants = append(reset.Variants, models.VariantTotals{
+				ID:          variant.ID,
+				Name:        variant.Name,
+				Clicks:      variant.Clicks + pendingVariants[variant.ID],
+				Conversions: variant.Conversions,
+			})
+		}
+		if err := tx.Create(reset).Error; err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.URL{}).Where("id = ?", urlID).
+			Updates(map[string]interface{}{"click_count": 0, "stats_reset_at": now, "landings": 0, "landing_base_clicks": 0}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.LinkVariant{}).Where("url_id = ?", urlID).
+			Updates(map[string]interface{}{"clicks": 0, "conversions": 0}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reset, nil
+}
+
+// LinkStatsResets returns the stats history of a link, latest reset first
+func LinkStatsResets(ctx context.Context, urlID uint) ([]models.LinkStatsReset, error) {
+	resets := []models.LinkStatsReset{}
+	err := DB.WithContext(ctx).Where("url_id = ?", urlID).Order("created_at DESC").Find(&resets).Error
+	return resets, err
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"url-shortener/models"
+	"url-shortener/storage"
+
+	"gorm.io/gorm"
+)
+
+// Store holds the links on the hot paths: creating them, resolving short
+// codes, including archived links and renamed aliases, deduplicating
+// destinations and counting clicks. Everything else still goes through DB. Errors are classified as the errors of the storage
+// package, e.g. storage.ErrNotFound for missing links.
+type Store interface {
+	// CreateURL saves url and the variants of a split link, which get its
+	// ID, returning storage.ErrConflict when a unique column is taken
+	CreateURL(ctx context.Context, url *models.URL, variants []models.LinkVariant) error
+	// GetByShortCode returns the live link for shortCode, or storage.ErrNotFound
+	GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error)
+	// GetArchived returns an archived link without rehydrating it, or
+	// storage.ErrNotFound
+	GetArchived(ctx context.Context, shortCode string) (*models.URL, error)
+	// Rehydrate moves an archived link back among the live ones and returns
+	// it, or storage.ErrNotFound when shortCode was never archived
+	Rehydrate(ctx context.Context, shortCode string) (*models.URL, error)
+	// ShortCodeTaken reports whether any link, including soft-deleted and
+	// archived ones, uses the link key code or was renamed away from it
+	ShortCodeTaken(ctx context.Context, code string) (bool, error)
+	// FindRenamedAlias returns a renamed alias still in its grace period at
+	// now with the current short code of its link, or storage.ErrNotFound
+	FindRenamedAlias(ctx context.Context, alias string, now time.Time) (*models.RenamedAlias, string, error)
+	// RecordRenamedAliasHit counts a visit through a renamed alias
+	RecordRenamedAliasHit(ctx context.Context, alias string, at time.Time) error
+	// GetByOriginalURL returns the link of ownerID, nil for ownerless links,
+	// deduplicating the destination hashed as hash (see utils.HashURL), or
+	// storage.ErrNotFound
+	GetByOriginalURL(ctx context.Context, ownerID *uint, hash string) (*models.URL, error)
+	// IncrementClicks adds click counts to links and variants by ID,
+	// returning the links' new click counts
+	IncrementClicks(ctx context.Context, urls, variants map[uint]int64) (map[uint]int64, error)
+	// ConsumeClick uses up one of the remaining clicks of a link with
+	// max_clicks, expiring it with the last one, and returns how many are
+	// left. It returns ErrNoClicksLeft once none are.
+	ConsumeClick(ctx context.Context, urlID uint) (int, error)
+}
+
+// ErrNoClicksLeft is returned by Store.ConsumeClick for used up links. It
+// is a storage.ErrExpired.
+var ErrNoClicksLeft = storage.Wrap(storage.ErrExpired, errors.New("link has no clicks left"))
+
+// Database drivers accepted by DB_DRIVER
+const (
+	DriverPostgres = "postgres"
+	// DriverSQLite keeps the database in the DB_PATH file, for development
+	// and for testing without PostgreSQL
+	DriverSQLite = "sqlite"
+	// DriverEmbedded keeps the links in the bbolt file DB_PATH and the
+	// other tables in SQLite next to it, for a single binary without
+	// PostgreSQL or Redis
+	DriverEmbedded = "embedded"
+)
+
+// Drivers lists the DB_DRIVER values this build supports
+var Drivers = []string{DriverPostgres, DriverSQLite, DriverEmbedded}
+
+// Links is the Store for DB_DRIVER, set by Connect
+var Links Store
+
+// newStore returns the Store of driver over the open connection pool
+func newStore(driver string) (Store, error) {
+	switch driver {
+	case DriverPostgres:
+		return postgresStore{}, nil
+	case DriverSQLite:
+		return sqliteStore{}, nil
+	case DriverEmbedded:
+		return boltStore{}, nil
+	default:
+		return nil, fmt.Errorf("DB_DRIVER %q is not supported, use one of %s", driver, strings.Join(Drivers, ", "))
+	}
+}
+
+// postgresStore is the Store over DB and Prepared
+type postgresStore struct{}
+
+func (postgresStore) CreateURL(ctx context.Context, url *models.URL, variants []models.LinkVariant) error {
+	err := DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(url).Error; err != nil {
+			return err
+		}
+		if len(variants) == 0 {
+			return nil
+		}
+		for i := range variants {
+			variants[i].URLID = url.ID
+		}
+		return tx.Create(&variants).Error
+	})
+	return storeError(err)
+}
+
+func (postgresStore) GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
+	var url models.URL
+	if err := Prepared.WithContext(ctx).Where("short_code = ?", shortCode).First(&url).Error; err != nil {
+		return nil, storeError(err)
+	}
+	return &url, nil
+}
+
+func (postgresStore) GetArchived(ctx context.Context, shortCode string) (*models.URL, error) {
+	url, err := FindArchivedURL(ctx, shortCode)
+	return url, storeError(err)
+}
+
+func (postgresStore) Rehydrate(ctx context.Context, shortCode string) (*models.URL, error) {
+	return RehydrateURL(ctx, shortCode)
+}
+
+func (postgresStore) ShortCodeTaken(ctx context.Context, code string) (bool, error) {
+	var count int64
+	err := DB.WithContext(ctx).Unscoped().Model(&models.URL{}).Where("short_code = ?", code).Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, storeError(err)
+	}
+	err = DB.WithContext(ctx).Model(&models.ArchivedURL{}).Where("short_code = ?", code).Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, storeError(err)
+	}
+	_, renamed, err := RenamedAliasOwner(ctx, code)
+	return renamed, storeError(err)
+}
+
+func (postgresStore) FindRenamedAlias(ctx context.Context, alias string, now time.Time) (*models.RenamedAlias, string, error) {
+	renamed, shortCode, err := FindRenamedAlias(ctx, alias, now)
+	return renamed, shortCode, storeError(err)
+}
+
+func (postgresStore) RecordRenamedAliasHit(ctx context.Context, alias string, at time.Time) error {
+	return storeError(RecordRenamedAliasHit(ctx, alias, at))
+}
+
+func (postgresStore) GetByOriginalURL(ctx context.Context, ownerID *uint, hash string) (*models.URL, error) {
+	var url models.URL
+	query := DB.WithContext(ctx).Where("original_url_hash = ?", hash)
+	if ownerID != nil {
+		query = query.Where("owner_id = ?", *ownerID)
+	} else {
+		query = query.Where("owner_id IS NULL")
+	}
+	if err := query.First(&url).Error; err != nil {
+		return nil, storeError(err)
+	}
+	return &url, nil
+}
+
+func (postgresStore) IncrementClicks(ctx context.Context, urls, variants map[uint]int64) (map[uint]int64, error) {
+	counts, err := ApplyClickCounts(ctx, urls, variants)
+	return counts, storeError(err)
+}
+
+func (postgresStore) ConsumeClick(ctx context.Context, urlID uint) (int, error) {
+	// Not an edit, so updated_at is kept for the archiver
+	var remaining []int
+	err := DB.WithContext(ctx).Raw(`UPDATE urls
+		SET clicks_remaining = clicks_remaining - 1,
+			expires_at = CASE WHEN clicks_remaining = 1 THEN now() ELSE expires_at END
+		WHERE id = ? AND clicks_remaining > 0
+		RETURNING clicks_remaining`, urlID).Scan(&remaining).Error
+	if err != nil {
+		return 0, storeError(err)
+	}
+	if len(remaining) == 0 {
+		return 0, ErrNoClicksLeft
+	}
+	return remaining[0], nil
+}
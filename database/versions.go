@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecordLinkVersion snapshots the configuration of a link whose versioned
+// columns were just changed from those of previous within tx, under the
+// next version. Version 1 is only snapshotted once it is replaced, so links
+// that never change keep no snapshot.
+func RecordLinkVersion(tx *gorm.DB, previous, url *models.URL) error {
+	if previous.Version == 1 {
+		first := previous.Snapshot()
+		first.CreatedAt = previous.CreatedAt
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(first).Error; err != nil {
+			return err
+		}
+	}
+
+	// Row locked until the transaction ends, so concurrent changes take
+	// successive versions
+	var version int
+	err := tx.Raw("UPDATE urls SET version = version + 1 WHERE id = ? RETURNING version", url.ID).Scan(&version).Error
+	if err != nil {
+		return err
+	}
+	url.Version = version
+	return tx.Create(url.Snapshot()).Error
+}
+
+// LinkVersions returns the configuration versions of a link, newest first,
+// with the click events recorded under each
+func LinkVersions(ctx context.Context, url *models.URL) ([]models.LinkVersion, error) {
+	db := DB.WithContext(ctx)
+	var versions []models.LinkVersion
+	if err := db.Where("url_id = ?", url.ID).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		first := url.Snapshot()
+		first.CreatedAt = url.CreatedAt
+		versions = append(versions, *first)
+	}
+
+	var rows []struct {
+		LinkVersion int
+		Clicks      int64
+	}
+	err := db.Table("click_events").Select("link_version, count(*) AS clicks").
+		Where("url_id = ? AND link_version > 0", url.ID).Group("link_version").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	clicks := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		clicks[row.LinkVersion] = row.Clicks
+	}
+	for i := range versions {
+		versions[i].Clicks = clicks[versions[i].Version]
+	}
+	return versions, nil
+}
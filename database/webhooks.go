@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Expired links notified per webhook and claim, the rest wait for the next
+const webhookExpiryBatch = 500
+
+// WebhooksFor returns the webhooks of the owners subscribed to event
+func WebhooksFor(ctx context.Context, ownerIDs []uint, event string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if len(ownerIDs) == 0 {
+		return webhooks, nil
+	}
+	err := DB.WithContext(ctx).Where("owner_id IN ?", ownerIDs).Where(subscribedTo(event)).
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+// WebhookIDsFor returns the IDs of every webhook subscribed to event
+func WebhookIDsFor(ctx context.Context, event string) ([]uint, error) {
+	var ids []uint
+	err := DB.WithContext(ctx).Model(&models.Webhook{}).Where(subscribedTo(event)).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ClickThresholdLinks returns those of the links whose owner has a webhook
+// subscribed to link.clicks
+func ClickThresholdLinks(ctx context.Context, urlIDs []uint) ([]models.URL, error) {
+	var urls []models.URL
+	owners := DB.Model(&models.Webhook{}).Select("owner_id").
+		Where(subscribedTo(models.HookLinkClicks)).Where("click_threshold > 0")
+	err := DB.WithContext(ctx).
+		Select("id", "short_code", "original_url", "status", "created_at", "owner_id", "inert").
+		Where("id IN ? AND owner_id IN (?)", urlIDs, owners).
+		Find(&urls).Error
+	return urls, err
+}
+
+// ClaimExpiredLinks returns the owner's links that expired since the
+// webhook was last checked, up to now, and moves its checkpoint past them.
+// The webhook is locked meanwhile, so each expiry is claimed by one
+// instance; ok is false when another instance holds it.
+func ClaimExpiredLinks(ctx context.Context, webhookID uint, now time.Time) (webhook models.Webhook, urls []models.URL, ok bool, err error) {
+	err = DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked []models.Webhook
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("id = ?", webhookID).Limit(1).Find(&locked).Error
+		if err != nil || len(locked) == 0 {
+			return err
+		}
+		webhook, ok = locked[0], true
+
+		err = tx.Where("owner_id = ? AND NOT inert AND expires_at > ? AND expires_at <= ?", webhook.OwnerID, webhook.ExpiryCheckedAt, now).
+			Order("expires_at").Limit(webhookExpiryBatch).Find(&urls).Error
+		if err != nil {
+			return err
+		}
+		checkpoint := now
+		if len(urls) == webhookExpiryBatch {
+			checkpoint = *urls[len(urls)-1].ExpiresAt
+		}
+		return tx.Model(&models.Webhook{}).Where("id = ?", webhook.ID).Update("expiry_checked_at", checkpoint).Error
+	})
+	return webhook, urls, ok, err
+}
+
+// subscribedTo matches the webhooks subscribed to event: their events
+// contain it, with jsonb's @> or by listing the array on SQLite
+func subscribedTo(event string) clause.Expr {
+	if usesSQLite() {
+		return gorm.Expr("EXISTS (SELECT 1 FROM json_each(webhooks.events) WHERE json_each.value = ?)", event)
+	}
+	data, _ := json.Marshal([]string{event})
+	return gorm.Expr("events @> ?", string(data))
+}
@@ -1,260 +0,0 @@
-// Package docs Code generated by swaggo/swag. DO NOT EDIT
-package docs
-
-import "github.com/swaggo/swag"
-
-const docTemplate = `{
-    "schemes": {{ marshal .Schemes }},
-    "swagger": "2.0",
-    "info": {
-        "description": "{{escape .Description}}",
-        "title": "{{.Title}}",
-        "termsOfService": "http://swagger.io/terms/",
-        "contact": {
-            "name": "API Support",
-            "url": "http://www.swagger.io/support",
-            "email": "support@swagger.io"
-        },
-        "license": {
-            "name": "MIT",
-            "url": "https://opensource.org/licenses/MIT"
-        },
-        "version": "{{.Version}}"
-    },
-    "host": "{{.Host}}",
-    "basePath": "{{.BasePath}}",
-    "paths": {
-        "/health": {
-            "get": {
-                "description": "Check if the service is healthy and running",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "System"
-                ],
-                "summary": "Health check",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/shorten": {
-            "post": {
-                "description": "Create a short URL from a long URL with optional expiration",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "URL Shortener"
-                ],
-                "summary": "Create a short URL",
-                "parameters": [
-                    {
-                        "description": "URL to shorten",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.ShortenRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "URL already exists",
-                        "schema": {
-                            "$ref": "#/definitions/models.ShortenResponse"
-                        }
-                    },
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/models.ShortenResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/stats/{shortCode}": {
-            "get": {
-                "description": "Get statistics for a shortened URL including click count and creation date",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "URL Shortener"
-                ],
-                "summary": "Get URL statistics",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Short code",
-                        "name": "shortCode",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.StatsResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Short URL not found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/{shortCode}": {
-            "get": {
-                "description": "Redirect to the original URL using the short code and increment click count",
-                "tags": [
-                    "URL Shortener"
-                ],
-                "summary": "Redirect to original URL",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Short code",
-                        "name": "shortCode",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "301": {
-                        "description": "Redirects to original URL"
-                    },
-                    "404": {
-                        "description": "Short URL not found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "410": {
-                        "description": "Short URL has expired",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "models.ShortenRequest": {
-            "type": "object",
-            "required": [
-                "url"
-            ],
-            "properties": {
-                "expires_in": {
-                    "description": "in days, optional",
-                    "type": "integer"
-                },
-                "url": {
-                    "type": "string"
-                }
-            }
-        },
-        "models.ShortenResponse": {
-            "type": "object",
-            "properties": {
-                "expires_at": {
-                    "type": "string"
-                },
-                "original_url": {
-                    "type": "string"
-                },
-                "short_code": {
-                    "type": "string"
-                },
-                "short_url": {
-                    "type": "string"
-                }
-            }
-        },
-        "models.StatsResponse": {
-            "type": "object",
-            "properties": {
-                "click_count": {
-                    "type": "integer"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "expires_at": {
-                    "type": "string"
-                },
-                "original_url": {
-                    "type": "string"
-                },
-                "short_code": {
-                    "type": "string"
-                }
-            }
-        }
-    }
-}`
-
-// SwaggerInfo holds exported Swagger Info so clients can modify it
-var SwaggerInfo = &swag.Spec{
-	Version:          "1.0",
-	Host:             "localhost:8080",
-	BasePath:         "/",
-	Schemes:          []string{"http", "https"},
-	Title:            "URL Shortener API",
-	Description:      "A simple URL shortener service built with Go and Gin",
-	InfoInstanceName: "swagger",
-	SwaggerTemplate:  docTemplate,
-	LeftDelim:        "{{",
-	RightDelim:       "}}",
-}
-
-func init() {
-	swag.Register(SwaggerInfo.InstanceName(), SwaggerInfo)
-}
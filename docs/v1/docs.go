@@ -0,0 +1,13259 @@
+// Package v1 Code generated by swaggo/swag. DO NOT EDIT
+package v1
+
+import "github.com/swaggo/swag"
+
+const docTemplate = `{
+    "schemes": {{ marshal .Schemes }},
+    "swagger": "2.0",
+    "info": {
+        "description": "{{escape .Description}}",
+        "title": "{{.Title}}",
+        "termsOfService": "http://swagger.io/terms/",
+        "contact": {
+            "name": "API Support",
+            "url": "http://www.swagger.io/support",
+            "email": "support@swagger.io"
+        },
+        "license": {
+            "name": "MIT",
+            "url": "https://opensource.org/licenses/MIT"
+        },
+        "version": "{{.Version}}"
+    },
+    "host": "{{.Host}}",
+    "basePath": "{{.BasePath}}",
+    "paths": {
+        "/admin/abuse-reports": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List the latest reports of abusive links by visitors, optionally only those against one creator",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List abuse reports",
+                "operationId": "listAbuseReports",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Creator the reported links count against, user:\u003cid\u003e or ip:\u003caddress\u003e",
+                        "name": "creator",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Reports to return, 1 to 1000 (default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AbuseReport"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/abuse-scores": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List the abuse scores of creators of links, decayed until now, overridden ones first and then the highest. The level in force restricts the creator: elevated halves its rate limits, high quarters them and disables custom aliases, Open Graph overrides, variants and routing rules, and severe cuts them to a tenth and holds its new links for approval.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List abuse scores",
+                "operationId": "listAbuseScores",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Scores to return, 1 to 1000 (default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AbuseScore"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/abuse-scores/{creator}": {
+            "put": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Set the abuse level of a creator whatever its score, such as normal for a trusted creator hit by false reports or severe for a known abuser, or clear the override with a null level. The score keeps being counted and decaying meanwhile. Other instances apply the change within a minute; the action is audit-logged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Override the abuse level of a creator",
+                "operationId": "overrideAbuseScore",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Creator, user:\u003cid\u003e or ip:\u003caddress\u003e",
+                        "name": "creator",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Level to apply",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AbuseScoreOverrideRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AbuseScore"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid creator or request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Forget the abuse score of a creator and any override of its level, lifting its restrictions. Other instances apply the change within a minute; the action is audit-logged.",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Reset the abuse score of a creator",
+                "operationId": "resetAbuseScore",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Creator, user:\u003cid\u003e or ip:\u003caddress\u003e",
+                        "name": "creator",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Abuse score reset"
+                    },
+                    "400": {
+                        "description": "Invalid creator",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Creator has no abuse score",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List issued API keys (without secrets)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List API keys",
+                "operationId": "listAPIKeys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.APIKey"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Issue a new API key and HMAC signing secret. Both are only returned once. Scopes default to create and read_stats; expires_in is in days. Links created with a key assigned to a user (user_id) belong to that user, who can list, update and delete them under /links.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Issue an API key",
+                "operationId": "createAPIKey",
+                "parameters": [
+                    {
+                        "description": "API key details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKeyCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Revoke an API key so it can no longer authenticate bearer or signed requests",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Revoke an API key",
+                "operationId": "revokeAPIKey",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "API key revoked"
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/api-keys/{id}/rotate": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Issue a replacement key with the same scopes and restrictions. The old key keeps working until the grace period (API_KEY_ROTATION_GRACE, default 24h) ends.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Rotate an API key",
+                "operationId": "rotateAPIKey",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKeyCreatedResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/approvals": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List links in the pending state, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List links awaiting approval",
+                "operationId": "listPendingURLs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.URL"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/approvals/{shortCode}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Approve a pending link so that it starts redirecting",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Approve a pending link",
+                "operationId": "approveURL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Pending short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/approvals/{shortCode}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Reject a pending link so that it never redirects",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Reject a pending link",
+                "operationId": "rejectURL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Pending short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/artifacts": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List the latest 50 files generated with POST /admin/artifacts and not expired yet, newest first, with download URLs for those ready",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List generated files",
+                "operationId": "listArtifacts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ArtifactsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Artifact storage is not configured",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Generate a large file without holding the request open: links_export writes the links matching created_after, created_before, expired and domain as CSV (default) or JSON, like GET /admin/urls/export; qr_batch renders the QR codes of up to 1000 active links given as short_codes (host/code on branded domains) as PNG (default) or SVG images in a ZIP archive, sized size pixels with error correction ecc. The file is stored in ARTIFACTS_BUCKET or ARTIFACTS_DIR and kept for ARTIFACT_RETENTION (default 24h); poll GET /admin/artifacts/{id} until it is ready for its download URL.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Generate a file in the background",
+                "operationId": "createArtifact",
+                "parameters": [
+                    {
+                        "description": "File to generate",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ArtifactRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.Artifact"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Artifact storage is not configured",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/artifacts/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Report whether a file is still being generated, ready or failed. Ready files carry a download_url valid for ARTIFACT_URL_EXPIRY (default 15m); fetch the artifact again for a fresh one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Status of a generated file",
+                "operationId": "getArtifact",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Artifact ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Artifact"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Artifact not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Artifact storage is not configured",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Delete a file before it expires, e.g. once downloaded. Download URLs handed out already stop working.",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delete a generated file",
+                "operationId": "deleteArtifact",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Artifact ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Deleted"
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Artifact not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Artifact storage is not configured",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/chaos": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "The latency and error rate currently injected. Only available when the server runs with CHAOS_ENABLED=true.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Fault injection configuration",
+                "operationId": "getChaos",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ChaosConfig"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Replace the injected latency and error rate for HTTP requests (except /admin), database queries and Redis commands. A zero latency and error rate stop injecting. Only available when the server runs with CHAOS_ENABLED=true.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Configure fault injection",
+                "operationId": "updateChaos",
+                "parameters": [
+                    {
+                        "description": "Fault injection",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ChaosConfig"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ChaosConfig"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/click-reconciliation": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Last run of the hourly job comparing click counts in the database, click events and the cache, with discrepancy totals since this instance started",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Click count reconciliation report",
+                "operationId": "getClickReconciliation",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ClickReconciliationStatus"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/db-metrics": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Per-operation and per-table query counts and durations recorded by this instance, labelled with its instance ID. With scope fleet and METRICS_AGGREGATION on, the counts of every instance are added up and left unlabelled.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Database query metrics",
+                "operationId": "getDBMetrics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "instance (default) or fleet",
+                        "name": "scope",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.QueryStats"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/domains": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List destination domains added for ownership verification, with their verification records",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List destination domains",
+                "operationId": "listDomains",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DomainResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Add a destination domain and get the DNS TXT record and meta tag that prove its ownership. Links to the domain and its subdomains are marked verified once it is verified.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Add a destination domain",
+                "operationId": "createDomain",
+                "parameters": [
+                    {
+                        "description": "Domain to verify",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DomainRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.DomainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Domain already added",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/domains/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Change whether links to a verified domain skip approval holds",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Update a destination domain",
+                "operationId": "updateDomain",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Domain ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Domain options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DomainUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DomainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Domain not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Remove a domain; links to it are no longer marked verified",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Remove a destination domain",
+                "operationId": "deleteDomain",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Domain ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Domain removed"
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Domain not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/domains/{id}/verify": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Check the domain's DNS TXT record (dns) or the meta tag on its https home page (meta) for the verification token, and mark the domain verified when found",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Verify a destination domain",
+                "operationId": "verifyDomain",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Domain ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Verification method",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DomainVerifyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DomainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Domain not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Verification token not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "DNS lookup or home page fetch failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/expired-links/cleanup": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Soft-delete or purge the links expired for longer than EXPIRED_LINK_RETENTION, as set by EXPIRED_LINK_CLEANUP, without waiting for the next scheduled cleanup. Purging frees their short codes for reuse. A failure partway is reported in error, with the links cleaned up until then counted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Clean up expired links now",
+                "operationId": "cleanUpExpiredLinks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExpiredLinkCleanupReport"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/health": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Health check including migration status, background job heartbeats, the click queue, database pool usage and Go runtime details",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Detailed health check",
+                "operationId": "verboseHealthCheck",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Database unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/hooks": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Hooks"
+                ],
+                "summary": "List REST Hooks subscriptions",
+                "operationId": "listHookSubscriptions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.HookSubscription"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Register a target URL that receives a JSON POST for every occurrence of the event. Deliveries carry X-Hook-Delivery, X-Hook-Event, X-Hook-Attempt, X-Timestamp and X-Signature headers, where the signature is hex(HMAC-SHA256(secret, timestamp + \"\\n\" + body)) with the secret returned here once. Failed deliveries are retried with backoff. Responding 410 Gone to a delivery unsubscribes it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Hooks"
+                ],
+                "summary": "Subscribe to a link event",
+                "operationId": "subscribeHook",
+                "parameters": [
+                    {
+                        "description": "Subscription",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscribeHookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.HookSubscriptionCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/hooks/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List the most recent deliveries, newest first, with their attempts and last error. Filter by status to inspect the dead letters.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Hooks"
+                ],
+                "summary": "List hook deliveries",
+                "operationId": "listHookDeliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pending, delivered or dead",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only deliveries for this subscription",
+                        "name": "subscription_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum deliveries to return (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.HookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/hooks/deliveries/redrive": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Queue every dead delivery, or those of one subscription, again with a fresh retry schedule",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Hooks"
+                ],
+                "summary": "Redrive all dead hook deliveries",
+                "operationId": "redriveHookDeliveries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only redrive deliveries for this subscription",
+                        "name": "subscription_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.RedriveHookDeliveriesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid subscription_id",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/hooks/deliveries/{id}/redrive": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Queue a dead delivery again with a fresh retry schedule. It keeps its delivery ID so subscribers can discard it if it was processed after all.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Hooks"
+                ],
+                "summary": "Redrive a dead hook delivery",
+                "operationId": "redriveHookDelivery",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Delivery ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.RedriveHookDeliveriesResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No dead delivery with this ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/hooks/triggers": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List the link events that can be subscribed to",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Hooks"
+                ],
+                "summary": "List REST Hooks triggers",
+                "operationId": "listHookTriggers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.HookTrigger"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/hooks/triggers/{event}/sample": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Return sample payloads for an event, as used by Zapier's \"perform list\" when setting up a Zap",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Hooks"
+                ],
+                "summary": "Sample payloads for a trigger",
+                "operationId": "sampleHookTrigger",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Event name",
+                        "name": "event",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.HookLinkPayload"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown event",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/hooks/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "tags": [
+                    "Hooks"
+                ],
+                "summary": "Unsubscribe from a link event",
+                "operationId": "unsubscribeHook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Unsubscribed"
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Hook subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/impersonations": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List impersonations of users, newest first, or only those still usable with active=true",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List impersonations",
+                "operationId": "listImpersonations",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only impersonations neither ended nor expired",
+                        "name": "active",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Impersonation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/impersonations/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Revoke an impersonation's token before it expires",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "End an impersonation",
+                "operationId": "endImpersonation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Impersonation ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Impersonation"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Impersonation not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Impersonation already ended or expired",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/integrity": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Last run of the integrity verifier, which checks for duplicate short codes, expired links not cleaned up, orphaned aliases and cached redirects drifted from the database, with violation totals since this instance started",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Data integrity report",
+                "operationId": "getIntegrity",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.IntegrityStatus"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/integrity/verify": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Run the integrity verifier without waiting for INTEGRITY_CHECK_INTERVAL, such as after a migration or a restore. Violations are reported, never repaired; a check that fails reports its error and the others still run.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Verify data integrity now",
+                "operationId": "verifyIntegrity",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.IntegrityReport"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/links/bulk": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List the latest 50 bulk operations, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List bulk operations",
+                "operationId": "listBulkOperations",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkOperationsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Queue an action on every link matching all the filters given, such as every link to a compromised domain: expire makes the links answer 410 Gone now, disable makes them answer 410 Gone with LINK_DISABLED. At least one of tag, domain (the host or any of its subdomains) and created_before is required. The operation runs in the background, resuming after restarts; follow its progress with GET /admin/links/bulk/{id}. Locked links are skipped, and every link changed is audit-logged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Expire or disable links in bulk",
+                "operationId": "startBulkOperation",
+                "parameters": [
+                    {
+                        "description": "Action and filters",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkOperation"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, no filter, or a domain filter while destinations are encrypted",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/links/bulk/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Report how many of the links matched by a bulk operation were processed, updated and skipped, and whether it completed or failed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Progress of a bulk operation",
+                "operationId": "getBulkOperation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Bulk operation ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkOperation"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Bulk operation not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/links/export": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Serialize active links, selected by short code and/or tag, with their short codes, destinations, expiry, tags, variants and preview cards. The bundle is signed with LINK_BUNDLE_SECRET so another instance sharing the secret can import it, e.g. to promote links from staging to production. Clicks and history are not exported.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Export links as a signed bundle",
+                "operationId": "exportLinks",
+                "parameters": [
+                    {
+                        "description": "Links to export",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LinkExportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LinkBundle"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or too many links",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "LINK_BUNDLE_SECRET is not set",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/links/import": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Create the links of a bundle exported by an instance sharing LINK_BUNDLE_SECRET, keeping their short codes. Links whose short code is taken, reserved or invalid, or whose destination is blocked by brand safety rules, are skipped and reported. Expiry is capped by LINK_MAX_EXPIRY_DAYS, and links are held for approval when REQUIRE_APPROVAL is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Import a signed link bundle",
+                "operationId": "importLinks",
+                "parameters": [
+                    {
+                        "description": "Signed link bundle",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LinkBundle"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LinkImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid bundle",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Bundle signature is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "LINK_BUNDLE_SECRET is not set",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/mirror": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Mirroring configuration and counters of this instance: redirects sampled, dropped, sent to the shadow backend and compared with the shadow resolver, including mismatches",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Traffic mirroring status",
+                "operationId": "getMirrorStatus",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MirrorStatus"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/safety-rules": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List brand safety rules in evaluation order (highest priority first)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List safety rules",
+                "operationId": "listSafetyRules",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SafetyRule"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Create a regex, domain or keyword rule that allows, denies or requires review for matching URLs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Create a safety rule",
+                "operationId": "createSafetyRule",
+                "parameters": [
+                    {
+                        "description": "Safety rule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SafetyRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.SafetyRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/safety-rules/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Replace an existing safety rule",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Update a safety rule",
+                "operationId": "updateSafetyRule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Safety rule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SafetyRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SafetyRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Safety rule not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Delete a safety rule",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delete a safety rule",
+                "operationId": "deleteSafetyRule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Rule deleted"
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Safety rule not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/shadow-bans": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List creators whose new links are silently made inert",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List shadow bans",
+                "operationId": "listShadowBans",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ShadowBan"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Shadow-ban an IP address: its shorten calls still succeed but the links never redirect",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Shadow-ban a creator",
+                "operationId": "createShadowBan",
+                "parameters": [
+                    {
+                        "description": "Creator to shadow-ban",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ShadowBanRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ShadowBan"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/shadow-bans/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Lift a shadow ban. Links already created stay inert.",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Lift a shadow ban",
+                "operationId": "deleteShadowBan",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shadow ban ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Shadow ban lifted"
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Shadow ban not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/short-domains": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List the branded domains short links can be served on, besides the default one",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List short link domains",
+                "operationId": "listShortDomains",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Domain"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Add a branded domain to serve short links on. Point its DNS at this service first; links created with its name as domain resolve only on that host and have short codes of their own. With ACME_ENABLED=true its TLS certificate is obtained in the background.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Add a short link domain",
+                "operationId": "createShortDomain",
+                "parameters": [
+                    {
+                        "description": "Domain to serve",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ShortDomainRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Domain"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Domain already added",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/short-domains/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Remove a branded domain that no link uses anymore, including deleted and archived links",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Remove a short link domain",
+                "operationId": "deleteShortDomain",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Domain ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Domain removed"
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Domain not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Links still use the domain",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/stats": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Count the live links per status, the archived links, those created in the last 24 hours and the clicks of them all, and list the most clicked live links. Click counts cover the time since each link's last stats reset.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Service-wide link statistics",
+                "operationId": "getGlobalStats",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Most clicked links to list (default 10, max 100)",
+                        "name": "top",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.GlobalStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid top",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/tag-rules": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List the rules tagging new links by destination, in the order their tags are added",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List tag rules",
+                "operationId": "listTagRules",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TagRule"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Create a rule adding a tag to new links whose destination is on a domain (the host or any of its subdomains, or only its subdomains as *.host) or matches a regex. Existing links get it with POST /admin/tag-rules/backfill.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Create a tag rule",
+                "operationId": "createTagRule",
+                "parameters": [
+                    {
+                        "description": "Tag rule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TagRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/tag-rules/backfill": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Queue a bulk operation adding the tags of the enabled tag rules to every existing link whose destination matches them, as they are when each batch runs. Follow its progress with GET /admin/links/bulk/{id}. Locked links are skipped, links keep at most 20 tags, and every link tagged is audit-logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Apply the tag rules to existing links",
+                "operationId": "backfillTagRules",
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkOperation"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/tag-rules/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Replace an existing tag rule. Links it tagged keep their tags.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Update a tag rule",
+                "operationId": "updateTagRule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tag rule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TagRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Tag rule not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Delete a tag rule. Links it tagged keep their tags.",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delete a tag rule",
+                "operationId": "deleteTagRule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Rule deleted"
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Tag rule not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/urls": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List every link regardless of owner, newest first so the first page shows the links created most recently, with the same filters as GET /links",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List all links",
+                "operationId": "listURLs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Links per page (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Links to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only links created at or after this RFC 3339 time",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only links created before this RFC 3339 time",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only expired links (true) or links not expired (false)",
+                        "name": "expired",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only links to this destination host or its subdomains; unavailable while destinations are encrypted",
+                        "name": "domain",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "production",
+                            "staging"
+                        ],
+                        "type": "string",
+                        "description": "Only production or staging links",
+                        "name": "environment",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated list of fields to return for each link (e.g. short_code,original_url)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.URL"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/urls/export": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Stream every link, or those matching the same filters as GET /admin/urls, with its short code, destination, click count, status, creation and expiry times and tags, oldest first. Links of branded domains are exported as host/code. CSV files have a header row and separate tags with |; JSON files hold an array. Files can be imported again with POST /admin/urls/import, e.g. to restore a backup. To export many links without holding the request open, generate the file in the background with POST /admin/artifacts.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Export links as CSV or JSON",
+                "operationId": "exportURLs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv (default) or json",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only links created at or after this RFC 3339 time",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only links created before this RFC 3339 time",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only expired links (true) or links not expired (false)",
+                        "name": "expired",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only links to this destination host or its subdomains; unavailable while destinations are encrypted",
+                        "name": "domain",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "production",
+                            "staging"
+                        ],
+                        "type": "string",
+                        "description": "Only production or staging links",
+                        "name": "environment",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.URLFileRecord"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid format or filter",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/urls/import": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Create links from a file exported by GET /admin/urls/export or by another link shortener, sent as the request body or as the file field of a multipart form. CSV files need a header row with at least an original_url (or url, long_url, destination) column; short_code (or code, alias, slug, keyword), click_count (or clicks), created_at, expires_at and tags columns are optional. Short codes are kept where possible: links whose code is missing, invalid, reserved or taken get a generated one and are reported as renamed. Links whose destination fails this instance's checks or whose branded domain does not exist are skipped. Click counts and creation times are kept; expiry is capped by LINK_MAX_EXPIRY_DAYS, and links are held for approval when REQUIRE_APPROVAL is set. At most 10000 links and 20 MiB per file.",
+                "consumes": [
+                    "application/json",
+                    "text/csv",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Import links from CSV or JSON",
+                "operationId": "importURLs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv or json; defaults to the Content-Type or file extension",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "file",
+                        "description": "File to import, for multipart requests",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.URLImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown format or invalid file",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "File too large",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/urls/{shortCode}": {
+            "put": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Change the destination, short code, expiry, tags, noindex setting, UTM parameters, redirect type, routing rules, rollout or velocity limit of any link, including anonymous ones. Same rules as PUT /links/{shortCode}; the action is audit-logged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Update any link",
+                "operationId": "updateURL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the revision the edit is based on, e.g. \\",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.URL"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Revision of the updated link"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Short URL is locked",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Custom alias is already taken",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "If-Match does not match the current revision",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Delete any link, including anonymous ones, so it stops redirecting. Its short code is not reused. Locked links must be unlocked first; the action is audit-logged.",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delete any link",
+                "operationId": "deleteURL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Link deleted"
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Short URL is locked",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/urls/{shortCode}/disable": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Stop an active short URL from redirecting, such as one abused for phishing, without deleting it: it answers 410 Gone with LINK_DISABLED until enabled again, and no longer deduplicates its destination. Locked links must be unlocked first; the action is audit-logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Disable a short URL",
+                "operationId": "disableURL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Short URL is locked",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Short URL is not active",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/urls/{shortCode}/enable": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Let a short URL disabled by an admin, alone or in bulk, redirect again. The action is audit-logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Enable a disabled short URL",
+                "operationId": "enableURL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Short URL is not disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/urls/{shortCode}/expiry-exemption": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Exempt a short URL from LINK_MAX_EXPIRY_DAYS so it never expires, clearing its current expiry. The action is audit-logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Exempt a short URL from the maximum lifetime",
+                "operationId": "exemptURLExpiry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Hold a short URL to LINK_MAX_EXPIRY_DAYS again. Links already older than the maximum expire after a grace period of 7 days. The action is audit-logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Remove a short URL's lifetime exemption",
+                "operationId": "removeURLExpiryExemption",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/urls/{shortCode}/lock": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Lock a short URL so its destination cannot be edited and it cannot be deleted",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Lock a short URL",
+                "operationId": "lockURL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/urls/{shortCode}/stats/reset": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Zero the click counters of any link, including anonymous ones. Same rules as POST /links/{shortCode}/stats/reset; the action is audit-logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Reset the stats of any link",
+                "operationId": "resetURLStats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LinkStatsReset"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Short URL is locked",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Clicks are being written, try again",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/urls/{shortCode}/unlock": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Remove the lock from a short URL, allowing edits and deletion again. The action is audit-logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Unlock a short URL",
+                "operationId": "unlockURL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List dashboard user accounts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List users",
+                "operationId": "listUsers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Create a dashboard user account",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Create a user",
+                "operationId": "createUser",
+                "parameters": [
+                    {
+                        "description": "User details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email already registered",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/attribution-window": {
+            "put": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Set how many hours after a click the conversions of a user's split links are attributed to it, in variant stats, unless a link sets its own attribution_window_hours. 0 restores CONVERSION_ATTRIBUTION_WINDOW. Applies to past conversions too, as they are attributed when reported.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Change a user's attribution window",
+                "operationId": "setUserAttributionWindow",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New window",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SetAttributionWindowRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid window",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/impersonate": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Issue a token acting as a user for support debugging, for 30 minutes by default and at most 4 hours. Sent as Authorization: Bearer usi_..., it stands in for an API key of the user with the given scopes, read_stats only by default, and never reaches the admin API. Responses to requests made with it carry the X-Impersonation header and, when they are JSON objects, an impersonation field describing it. Starting and ending it and every request made with it are recorded in the audit log with the reason. The token is only returned once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Impersonate a user",
+                "operationId": "startImpersonation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Why, for how long and with which scopes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ImpersonationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImpersonationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/logout": {
+            "post": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Revoke all of a user's sessions, e.g. after an account compromise",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Force logout a user",
+                "operationId": "revokeUserSessions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/plan": {
+            "put": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "Move a user to the free plan or a plan of STATS_RETENTION_DAYS, which sets how long the click history of their links is kept. Moving to a plan keeping less history purges the older history at the next daily run.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Change a user's plan",
+                "operationId": "setUserPlan",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New plan",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SetUserPlanRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown plan",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/artifacts/{key}": {
+            "get": {
+                "description": "Download a file kept in ARTIFACTS_DIR through the signed URL GET /admin/artifacts/{id} hands out. Files kept in ARTIFACTS_BUCKET are downloaded from the bucket instead.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Download a generated file",
+                "operationId": "downloadArtifact",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Artifact key",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Expiry of the URL, in Unix seconds",
+                        "name": "expires",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name to save the file as",
+                        "name": "filename",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature of the URL",
+                        "name": "signature",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Generated file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "Invalid or expired signature",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Artifact not found or expired",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/2fa/backup-codes": {
+            "post": {
+                "security": [
+                    {
+                        "SessionAuth": []
+                    }
+                ],
+                "description": "Replace all backup codes after confirming a current TOTP code",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Regenerate backup codes",
+                "operationId": "regenerateBackupCodes",
+                "parameters": [
+                    {
+                        "description": "TOTP code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TwoFactorCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BackupCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid code",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired session",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/2fa/disable": {
+            "post": {
+                "security": [
+                    {
+                        "SessionAuth": []
+                    }
+                ],
+                "description": "Disable 2FA after confirming a TOTP or backup code",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Disable two-factor authentication",
+                "operationId": "disableTwoFactor",
+                "parameters": [
+                    {
+                        "description": "TOTP or backup code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TwoFactorCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Two-factor authentication disabled"
+                    },
+                    "400": {
+                        "description": "Invalid code",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired session",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Two-factor authentication is required for admins",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/2fa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "SessionAuth": []
+                    }
+                ],
+                "description": "Generate a TOTP secret for the current user. 2FA is enabled once a code is verified.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Start two-factor enrollment",
+                "operationId": "enrollTwoFactor",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TwoFactorEnrollResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired session",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Two-factor authentication already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/2fa/verify": {
+            "post": {
+                "security": [
+                    {
+                        "SessionAuth": []
+                    }
+                ],
+                "description": "Verify a TOTP code for the enrolled secret, enable 2FA and return backup codes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Complete two-factor enrollment",
+                "operationId": "verifyTwoFactor",
+                "parameters": [
+                    {
+                        "description": "TOTP code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TwoFactorCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BackupCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid code",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired session",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Start a dashboard session with email and password (plus otp_code when 2FA is enabled)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Log in",
+                "operationId": "login",
+                "parameters": [
+                    {
+                        "description": "Credentials",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.SessionTokensResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid email, password or two-factor code",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "security": [
+                    {
+                        "SessionAuth": []
+                    }
+                ],
+                "description": "Revoke the current session",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Log out",
+                "operationId": "logout",
+                "responses": {
+                    "204": {
+                        "description": "Logged out"
+                    },
+                    "401": {
+                        "description": "Invalid or expired session",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for new access and refresh tokens. The old tokens stop working.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Refresh a session",
+                "operationId": "refreshSession",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SessionTokensResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "SessionAuth": []
+                    }
+                ],
+                "description": "List the current user's active sessions across devices",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "List my sessions",
+                "operationId": "listSessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Session"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired session",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "SessionAuth": []
+                    }
+                ],
+                "description": "Sign out another device by revoking one of the current user's sessions",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Revoke one of my sessions",
+                "operationId": "revokeSession",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Session revoked"
+                    },
+                    "401": {
+                        "description": "Invalid or expired session",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/changes": {
+            "get": {
+                "security": [
+                    {
+                        "AdminAuth": []
+                    }
+                ],
+                "description": "List the links created, updated and deleted after the since cursor, in the order the changes were made, so edge caches, search indexes and other copies of the links stay in sync without rescanning them. Without since, no changes are returned and next_cursor is the current end of the feed: take it, scan the links, then follow the feed from it. Poll again with next_cursor; has_more is set when more changes are ready right away. Click counts and the fetched title and description of destination pages are not changes. Changes are kept for CHANGE_FEED_RETENTION, a cursor older than that answers 410 Gone and calls for a new scan.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Read the link change feed",
+                "operationId": "listLinkChanges",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Cursor of the last change read",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum changes to return (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LinkChangesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid cursor or limit",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "The cursor is older than the retained changes",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/collections": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the collections the caller owns or was granted a role on, with the caller's role in each.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "List your collections",
+                "operationId": "listCollections",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Collection"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Gather links owned by the caller, given by short code (host/code on branded domains), into a collection to share with other users or through a read-only share link, such as an agency's campaign links for a client. Up to 500 links.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Create a collection",
+                "operationId": "createCollection",
+                "parameters": [
+                    {
+                        "description": "Collection; name is required",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Collection"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, or a link is not one of yours",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Too many collections",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/collections/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the links of a collection the caller owns or has a role on, in order, with their all-time clicks and the clicks of the last 30 days from the hourly click rollups. Links since deleted, renamed or no longer owned by the collection's owner are left out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "View a collection",
+                "operationId": "getCollection",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Collection ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionView"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Collection not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Rename a collection or replace its links, as its owner or an editor. Links must be owned by the collection's owner. Omitted fields are kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Edit a collection",
+                "operationId": "updateCollection",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Collection ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Changes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Collection"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, or a link is not the owner's",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope, or the caller is a viewer",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Collection not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a collection the caller owns, revoking its members and share link. Its links are kept.",
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Delete a collection",
+                "operationId": "deleteCollection",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Collection ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Collection deleted"
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope, or the caller is not the owner",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Collection not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/collections/{id}/members": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the users granted a role on a collection the caller owns.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "List a collection's members",
+                "operationId": "listCollectionMembers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Collection ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CollectionMember"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope, or the caller is not the owner",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Collection not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Grant the user with an email a role on a collection the caller owns, or change the role they have: viewers see its links and their stats, editors also rename it and add or remove links.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Share a collection with a user",
+                "operationId": "grantCollectionRole",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Collection ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User and role",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionMemberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionMember"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, or no such user",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope, or the caller is not the owner",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Collection not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/collections/{id}/members/{userId}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove a user's role on a collection the caller owns.",
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Stop sharing a collection with a user",
+                "operationId": "revokeCollectionRole",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Collection ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Role removed"
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope, or the caller is not the owner",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Collection or member not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/collections/{id}/share": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a read-only share link to a collection the caller owns, showing anyone holding it the collection's links and stats without an API key, such as a client following their campaign. Replaces the previous share link. The token is only returned once.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Create a share link",
+                "operationId": "shareCollection",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Collection ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionShareResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope, or the caller is not the owner",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Collection not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revoke the read-only share link of a collection the caller owns.",
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Revoke the share link",
+                "operationId": "unshareCollection",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Collection ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Share link revoked"
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope, or the caller is not the owner",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Collection not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/debug/redirect/{shortCode}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Walk through how GET /{shortCode} would answer a visitor sending the same headers (User-Agent, Accept, Accept-Language and location headers) and info or preview parameters as this request, and return the rules checked in order: how the short code resolves, including old codes of renamed links, whether the link is available and not expired, loop detection, link preview crawlers, the rollout of a soft launched link, each routing rule of the link, the velocity limit, max_clicks and the variant a split link would serve. Nothing is redirected and no click is counted or used up. The variant is one draw from the link's current traffic shares, listed in variants. Only links owned by the caller can be simulated.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Simulate a redirect",
+                "operationId": "simulateRedirect",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code, followed by + to simulate the preview page",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Set to 1 to simulate asking for the plaintext summary",
+                        "name": "info",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Set to 1 to simulate asking for the preview page",
+                        "name": "preview",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RedirectTrace"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/embed/{shortCode}": {
+            "get": {
+                "description": "A small self-contained HTML page showing a link's total clicks and a sparkline of its clicks per UTC day, to embed in blogs and wikis with \u003ciframe src=\"https://sho.rt/embed/abc123\" width=\"320\" height=\"64\"\u003e. Drawn from the hourly click rollups behind the JSON stats, so the latest clicks show up within minutes; links whose analytics are not full have a flat sparkline. The page has no scripts, may be framed by any site and is cached for 5 minutes. Days before the click history kept on the plan of the link's owner, as set by STATS_RETENTION_DAYS, are left out.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Embeddable stats widget",
+                "operationId": "getStatsWidget",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Days covered by the sparkline, 1 to 90 (default 30)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "light",
+                            "dark"
+                        ],
+                        "type": "string",
+                        "description": "Colors of the widget",
+                        "name": "theme",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The widget",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid days or theme",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/errors": {
+            "get": {
+                "description": "List the stable error codes returned in the ` + "`" + `code` + "`" + ` field of error responses, with the status each is usually returned with",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "List error codes",
+                "operationId": "listErrorCodes",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ErrorCodeInfo"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/external/{external_id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a short link for a CMS item identified by external_id, or return the one already created for it, so CMS publish hooks can call the same endpoint every time. When the url differs from the link's destination in canonical form, the destination is updated as with PUT /links/{shortCode}. Other fields of the request only apply when the link is created, and a fresh link is always created rather than deduplicated, so if_exists and no_dedup are ignored. External IDs are unique among the links of the caller's user; deleted links free theirs and archived links are rehydrated. Requires both the create and update scopes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Create or refresh the short link of a CMS item",
+                "operationId": "upsertExternalLink",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Identifier of the item in the CMS, up to 128 printable characters",
+                        "name": "external_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Destination and options of a new link",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ShortenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Link already existed, its destination updated if it changed",
+                        "schema": {
+                            "$ref": "#/definitions/models.ShortenResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Link created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ShortenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or external ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user, lacks the create or update scope, or the link is locked",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The custom alias is taken",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/funnels": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Funnels"
+                ],
+                "summary": "List your funnels",
+                "operationId": "listFunnels",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Funnel"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Declare an ordered list of 2 to 10 different links owned by the caller, such as a teaser, a landing page and a signup link, whose report counts the unique visitors clicking each of them in order. Links on a branded domain are given as host/code.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Funnels"
+                ],
+                "summary": "Declare a funnel",
+                "operationId": "createFunnel",
+                "parameters": [
+                    {
+                        "description": "Funnel",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.FunnelRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Funnel"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, or a step is not one of your links",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Too many funnels",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/funnels/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "Funnels"
+                ],
+                "summary": "Delete one of your funnels",
+                "operationId": "deleteFunnel",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Funnel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Funnel deleted"
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Funnel not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/funnels/{id}/report": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Count the unique visitors who clicked the link of each step of a funnel owned by the caller between from and to, at or after their first click of the previous step, with the share of the previous step and of the first step that went on. Visitors are told apart by the same salted hash of IP address and user agent as unique visitors, which is only recorded with Redis and for links with full analytics. Covers the last 30 days by default, and at most 366 days. Steps whose link has since been deleted or renamed count no visitors.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Funnels"
+                ],
+                "summary": "Report on one of your funnels",
+                "operationId": "getFunnelReport",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Funnel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End, RFC 3339 (default now)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.FunnelReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid range",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Funnel not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Check if the service is healthy and running. Reports the build version, uptime and round-trip latency to the database and cache. The status is degraded when the cache is down or a background job is overdue, and unhealthy (503) when the database is down.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Health check",
+                "operationId": "healthCheck",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Database unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/inbound/email": {
+            "post": {
+                "description": "Inbound parse webhook for email providers (SendGrid Inbound Parse, Mailgun routes). The first URL in the subject or body is shortened and the short link is sent back to the sender by email. Senders must match INBOUND_EMAIL_ALLOWED_SENDERS.",
+                "consumes": [
+                    "application/x-www-form-urlencoded",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Shorten a URL sent by email",
+                "operationId": "inboundEmail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "INBOUND_EMAIL_TOKEN",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Gateway disabled, invalid token or sender not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/js/track.js": {
+            "get": {
+                "description": "JavaScript for destinations of links with track_landings to embed with \u003cscript src=\"https://sho.rt/js/track.js\" async\u003e\u003c/script\u003e. Once the page has loaded it reports the sl_land token added by the redirect to /px/land and removes it from the address bar without reloading; pages opened without a token report nothing.",
+                "produces": [
+                    "application/javascript"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Landing tracking script",
+                "operationId": "getLandingScript",
+                "responses": {
+                    "200": {
+                        "description": "The script",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/links": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the links created with API keys assigned to the caller's user, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "List your links",
+                "operationId": "listLinks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Links per page (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Links to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only links created at or after this RFC 3339 time",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only links created before this RFC 3339 time",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only expired links (true) or links not expired (false)",
+                        "name": "expired",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only links to this destination host or its subdomains; unavailable while destinations are encrypted",
+                        "name": "domain",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "production",
+                            "staging"
+                        ],
+                        "type": "string",
+                        "description": "Only production or staging links",
+                        "name": "environment",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated list of fields to return for each link (e.g. short_code,original_url)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.URL"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/links/{shortCode}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Change the destination, short code, expiry, tags, noindex setting, UTM parameters, redirect type, routing rules, rollout or velocity limit of a link owned by the caller. Raising rollout_percent ramps up a soft launch, and 100 launches the link fully. A velocity_limit with 0 clicks removes the limit. A new destination, and the URLs routing rules redirect to, pass the same checks as POST /shorten and may put the link back into review. A renamed link's old short code keeps resolving for ALIAS_RENAME_GRACE (see GET /links/{shortCode}/aliases). Locked links cannot be updated. Every edit raises the link's revision, returned in the ETag header and the revision field; sending it back in If-Match applies the edit only if nobody changed the link since, answering 412 otherwise.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Update one of your links",
+                "operationId": "updateLink",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the revision the edit is based on, e.g. \\",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.URL"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Revision of the updated link"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope, or the link is locked",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Custom alias is already taken",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "If-Match does not match the current revision",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a link owned by the caller so it stops redirecting. Its short code is not reused. Locked links cannot be deleted.",
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Delete one of your links",
+                "operationId": "deleteLink",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Link deleted"
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the delete scope, or the link is locked",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/links/{shortCode}/aliases": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the short codes a link owned by the caller was renamed away from, newest first. Each keeps resolving until expires_at; hits and last_hit_at show whether it is still used and safe to retire.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "List the old short codes of one of your links",
+                "operationId": "listRenamedAliases",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RenamedAlias"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/links/{shortCode}/aliases/{alias}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "End the grace period of a short code a link owned by the caller was renamed away from, so it stops resolving. The code is not given to another link.",
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Retire an old short code of one of your links",
+                "operationId": "retireRenamedAlias",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Old short code",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Alias retired"
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope, or the link is locked",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL or alias not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/links/{shortCode}/annotations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the events annotated on the timeline of a link owned by the caller, oldest first. GET /stats/{shortCode}/timeseries returns those of its range too.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "List the annotations of one of your links",
+                "operationId": "listAnnotations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LinkAnnotation"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Mark an event, such as a newsletter going out or a TV spot airing, at a time on the timeline of a link owned by the caller, now by default, so spikes in its click time series can be explained. Locked links can be annotated; a link carries at most 1000 annotations.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Annotate the timeline of one of your links",
+                "operationId": "createAnnotation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Event to annotate",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AnnotationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.LinkAnnotation"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The link carries 1000 annotations already",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/links/{shortCode}/annotations/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove an event from the timeline of a link owned by the caller",
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Delete an annotation of one of your links",
+                "operationId": "deleteAnnotation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Annotation ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Annotation deleted"
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL or annotation not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/links/{shortCode}/references": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the Jira tickets, documents, designs and other pages attached to a link owned by the caller, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "List the external references of one of your links",
+                "operationId": "listReferences",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LinkReference"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Attach a page explaining why a link owned by the caller exists, such as a Jira ticket, a Google Doc or a Figma design. Its kind is detected from the URL's host when omitted. Locked links can carry references; a link carries at most 50.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Attach an external reference to one of your links",
+                "operationId": "createReference",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Reference to attach",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReferenceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.LinkReference"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The link carries 50 references already",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/links/{shortCode}/references/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replace the URL, kind and title of a reference attached to a link owned by the caller. The kind is detected from the new URL's host when omitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Edit an external reference of one of your links",
+                "operationId": "updateReference",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Reference ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New reference",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReferenceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LinkReference"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL or reference not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove a reference attached to a link owned by the caller",
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Detach an external reference from one of your links",
+                "operationId": "deleteReference",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Reference ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Reference deleted"
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL or reference not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/links/{shortCode}/rules": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the routing rules of a link owned by the caller in the order they are checked. A rule's index in the list, from 0, is its priority and identifies it in PUT and DELETE /links/{shortCode}/rules/{index}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "List the routing rules of one of your links",
+                "operationId": "listRoutingRules",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RoutingRule"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replace every routing rule of a link owned by the caller, e.g. to reorder them, like routing_rules of PUT /links/{shortCode}. The URLs rules redirect to pass the same checks as POST /shorten and may put the link back into review. Locked links cannot be changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Replace the routing rules of one of your links",
+                "operationId": "replaceRoutingRules",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Rules in the order they are checked",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RoutingRule"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RoutingRule"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid rules",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope, or the link is locked",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Add a routing rule to a link owned by the caller, checked after the existing rules unless position says otherwise. Rules sending app users to a store typically match on os, e.g. ios to the App Store and android to Google Play, with the link's destination serving everyone else. Locked links cannot be changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Add a routing rule to one of your links",
+                "operationId": "addRoutingRule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Index the rule is inserted at, from 0; appended when omitted",
+                        "name": "position",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Rule to add",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RoutingRule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RoutingRule"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid rule or position",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope, or the link is locked",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/links/{shortCode}/rules/{index}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replace the routing rule at index of a link owned by the caller, keeping its priority. Locked links cannot be changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Change a routing rule of one of your links",
+                "operationId": "updateRoutingRule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Index of the rule, from 0",
+                        "name": "index",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "description": "New rule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RoutingRule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RoutingRule"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid rule",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope, or the link is locked",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL or routing rule not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove the routing rule at index of a link owned by the caller; later rules move up one place. Locked links cannot be changed.",
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Remove a routing rule from one of your links",
+                "operationId": "deleteRoutingRule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Index of the rule, from 0",
+                        "name": "index",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Routing rule removed"
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope, or the link is locked",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL or routing rule not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/links/{shortCode}/stats/reset": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Zero the click counters of a link owned by the caller and of its variants, for campaigns reusing a short code for a new push. The counters, including clicks not written yet, are kept as a stats period listed by GET /stats/{shortCode}/resets. Click events, and so time series, referrers and unique visitors, are kept; query them from the reset time. Locked links cannot be reset; the action is audit-logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Reset the stats of one of your links",
+                "operationId": "resetLinkStats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LinkStatsReset"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope, or the link is locked",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Clicks are being written, try again",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/links/{shortCode}/versions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the versions of the destination, routing rules, UTM parameters and redirect type of a link owned by the caller, newest first. Version 1 is the configuration the link was created with and each change records the next one; versions never change afterwards. clicks counts the click events recorded while each version was active, which carry it as link_version, including in click event exports. Clicks recorded before links were versioned have link_version 0 and are not counted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "List the configuration versions of one of your links",
+                "operationId": "listLinkVersions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LinkVersion"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/px/land": {
+            "post": {
+                "description": "Report that a visitor redirected by a link with track_landings loaded the destination, telling landings from bounces in its stats. /js/track.js on the destination calls this with the sl_land token the redirect added, sent as the request body or the t query parameter; GET with t is accepted too, for browsers without beacons. Each token counts once, within 30 minutes of its redirect; unknown and used tokens are ignored.",
+                "consumes": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Report a landing",
+                "operationId": "trackLanding",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Landing token, when not sent as the body",
+                        "name": "t",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Landing recorded or ignored"
+                    }
+                }
+            }
+        },
+        "/px/{shortCode}/{variant}": {
+            "get": {
+                "description": "Record a conversion for a variant of a split link and return a transparent 1x1 GIF. Embed it on the variant's destination where a visitor converts, e.g. after a purchase; pixel_url of each variant holds its address. Unknown links and variants are ignored. Conversions are attributed to the visitor's click when it falls within the link's attribution window.",
+                "produces": [
+                    "image/gif"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Conversion pixel",
+                "operationId": "trackConversion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Variant ID",
+                        "name": "variant",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transparent GIF"
+                    }
+                }
+            }
+        },
+        "/reports/duplicates": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List groups of the caller's links leading to the same destination once normalized the way deduplication compares them (lowercased scheme and host, no default port, tracking parameters or trailing slash, sorted query parameters), with each link's click count, so duplicates can be cleaned up before relying on deduplication. Groups with the most links come first, then those with the most clicks; links in a group are oldest first. Split link variants and routing rule destinations are not compared.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Links sharing a destination",
+                "operationId": "getDuplicatesReport",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Groups to return, 1 to 200 (default 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DuplicatesReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/routing/schema": {
+            "get": {
+                "description": "JSON schema of the routing_rules of a link, as accepted by POST /shorten and PUT /links/{shortCode}. Rules are checked in order; a rule matches when all of its conditions hold, and the first matching rule redirects the visitor to its url, sends them to the link's usual destination or answers as if the link did not exist.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Routing rules schema",
+                "operationId": "getRoutingSchema",
+                "responses": {
+                    "200": {
+                        "description": "JSON schema (draft 2020-12)",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/shared/collections/{token}": {
+            "get": {
+                "description": "View the links of a collection and their stats through its read-only share link, without an API key. Revoked and replaced share links answer 404.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "View a shared collection",
+                "operationId": "getSharedCollection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Share token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionView"
+                        }
+                    },
+                    "404": {
+                        "description": "Share link not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shorten": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a short URL from a long URL with optional expiration",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Create a short URL",
+                "operationId": "shortenURL",
+                "parameters": [
+                    {
+                        "description": "URL to shorten",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ShortenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "URL already exists",
+                        "schema": {
+                            "$ref": "#/definitions/models.ShortenResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ShortenResponse"
+                        },
+                        "headers": {
+                            "X-Quota-Warning": {
+                                "type": "string",
+                                "description": "Usage of the monthly link quota, once past 80%: plan, used, limit and resets"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or unknown domain",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid API key or request signature, or anonymous shortening is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "CAPTCHA verification failed or API key not permitted",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The caller already shortened the URL and if_exists is error, or the custom alias is taken",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, or the monthly link quota is used up",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "CAPTCHA verification unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shorten/channels": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create one short link per share channel (default twitter, facebook and email) for the same URL, tagged ` + "`" + `channel:\u003cname\u003e` + "`" + ` and with utm_source/utm_medium added to each destination, so share performance can be compared per channel",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Create per-channel share links",
+                "operationId": "shortenChannels",
+                "parameters": [
+                    {
+                        "description": "URL and channels",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ShortenChannelsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ShortenChannelsResponse"
+                        },
+                        "headers": {
+                            "X-Quota-Warning": {
+                                "type": "string",
+                                "description": "Usage of the monthly link quota, once past 80%: plan, used, limit and resets"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid API key or request signature, or anonymous shortening is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "CAPTCHA verification failed or API key not permitted",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, or the monthly link quota is used up",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/destinations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Add up the clicks of the caller's links, archived ones included, per destination domain, so teams can see which of their properties receive the most traffic. www. is dropped from the domain; other subdomains are counted apart. Counts come from the hourly click rollups, so the latest clicks show up within minutes. Covers the last 30 days by default, and at most 366 days. Unavailable while destinations are encrypted. Ranges starting before the click history kept on the caller's plan, as set by STATS_RETENTION_DAYS, start with it instead, as retention explains.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Clicks per destination domain",
+                "operationId": "getDestinationStats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End, RFC 3339 (default now)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Domains to return, 1 to 100 (default 20)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DestinationStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid range or limit, or destinations are encrypted",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/tags/{tag}": {
+            "get": {
+                "description": "Add up the clicks of every link carrying a tag, such as a campaign, including archived links. links and click_count cover all time; the time series counts clicks per hour or day of the time zone tz, UTC by default, from hourly click rollups, which are rebuilt every 5 minutes, so from is rounded down to a bucket boundary and the last bucket may lag. Covers the last 48 hours or 30 days by default, and at most 1000 buckets. Tags are matched as links carry them now. With compare=previous_period, the period of the same length right before is counted too, with the percentage change of the total and of each bucket from its counterpart. Ranges starting before the click history kept on the caller's plan, as set by STATS_RETENTION_DAYS, start with it instead, as retention explains.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Stats of a tag",
+                "operationId": "getTagStats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tag",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "hour or day (default day)",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone of the buckets, such as Europe/Berlin (default UTC)",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End, RFC 3339 (default now)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "previous_period"
+                        ],
+                        "type": "string",
+                        "description": "previous_period to add the series of the period of the same length right before, with percentage changes",
+                        "name": "compare",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid tag, interval, time zone, range or compare",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/{shortCode}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get statistics for a shortened URL including click count and creation date. Concurrent requests share one database lookup, and results up to max_age seconds old may be served. Responses are also shared between instances through the response cache, for up to RESPONSE_CACHE_TTL unless max_age is lower.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Get URL statistics",
+                "operationId": "getURLStats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated list of fields to return (e.g. click_count,original_url)",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Accept stats up to this many seconds old (default 1, max 300)",
+                        "name": "max_age",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.StatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown field requested or invalid max_age",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/{shortCode}/heatmap": {
+            "get": {
+                "description": "Count a link's clicks per weekday and hour of the day in the time zone tz, UTC by default, as a 7x24 matrix starting on Monday at midnight, with the busiest hour, to pick the best times to post the link. Counted from hourly click rollups, so the latest clicks show up within minutes; links whose analytics are not full have none. from is rounded down to the hour. Covers the last 12 weeks by default, and at most 366 days. Ranges starting before the click history kept on the plan of the link's owner, as set by STATS_RETENTION_DAYS, start with it instead, as retention explains.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Clicks by hour of the week",
+                "operationId": "getClickHeatmap",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone of the weekdays and hours, such as Europe/Berlin (default UTC)",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End, RFC 3339 (default now)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.HeatmapResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid time zone or range",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/{shortCode}/referrers": {
+            "get": {
+                "description": "Rank the sites that sent a link's clicks by referring host, most clicks first. Clicks without a referrer are grouped as \"(direct)\"; links whose analytics are not full have none. Covers the last 30 days by default. Ranges starting before the click history kept on the plan of the link's owner, as set by STATS_RETENTION_DAYS, start with it instead, as retention explains.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Top referrers",
+                "operationId": "getTopReferrers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End, RFC 3339 (default now)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Referrers returned (default 10, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReferrersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid range or limit",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/{shortCode}/resets": {
+            "get": {
+                "description": "List the counters a link had each time its stats were reset, latest first. The current period, since the last reset_at, is reported by GET /stats/{shortCode}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Stats periods of a link",
+                "operationId": "listStatsResets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.StatsResetsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/{shortCode}/timeseries": {
+            "get": {
+                "description": "Count a link's clicks per hour or day from its click events, including empty buckets, which stay empty for links whose analytics are not full. Buckets follow the time zone tz, UTC by default, including its daylight saving time changes; from is rounded down to a bucket boundary. Covers the last 48 hours or 30 days by default, and at most 1000 buckets. With compare=previous_period, the period of the same length right before is counted too, with the percentage change of the total and of each bucket from its counterpart. Events annotated on the link's timeline within the range are listed in annotations. Ranges starting before the click history kept on the plan of the link's owner, as set by STATS_RETENTION_DAYS, start with it instead, as retention explains.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Clicks over time",
+                "operationId": "getClickTimeseries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "hour or day (default day)",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone of the buckets, such as Europe/Berlin (default UTC)",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End, RFC 3339 (default now)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "previous_period"
+                        ],
+                        "type": "string",
+                        "description": "previous_period to add the series of the period of the same length right before, with percentage changes",
+                        "name": "compare",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TimeseriesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid interval, time zone, range or compare",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/{shortCode}/uniques": {
+            "get": {
+                "description": "Estimate how many different visitors clicked a link, told apart by IP address and user agent, by merging daily HyperLogLogs kept in Redis for UNIQUE_VISITOR_RETENTION. from is rounded down to a UTC day and the estimate has a 0.81% standard error. Covers the last 30 days by default, and at most 1000 days. Links whose analytics are not full have no unique visitors. Ranges starting before the click history kept on the plan of the link's owner, as set by STATS_RETENTION_DAYS, start with it instead, as retention explains.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Unique visitors",
+                "operationId": "getUniqueVisitors",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End, RFC 3339 (default now)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UniqueVisitorsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid range",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Redis is unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/{shortCode}/variants": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Report the clicks, conversions and conversion rate of each variant of a split link, the share of traffic each currently gets and the probability that each converts best. In bandit mode traffic follows that probability (Thompson sampling), updated every few seconds. attributed_conversions counts the conversions by visitors who clicked the link within the attribution window before, the link's attribution_window_hours, else its owner's, else CONVERSION_ATTRIBUTION_WINDOW; visitors are told apart as for unique visitors, so only conversions and clicks recorded with Redis can be attributed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Get split link variants",
+                "operationId": "getVariantStats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Branded domain serving the link, omitted for the default one",
+                        "name": "short_domain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.VariantStatsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid API key or request signature",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found or has no variants",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/status": {
+            "get": {
+                "description": "Rolling availability and latency per endpoint over the last hour and day, for generating a public status page. Computed from the answering instance's own request metrics since it started, or from every instance's when scope is fleet and METRICS_AGGREGATION is on. The overall status is degraded when a busy endpoint answered less than 99% of requests in the last hour without a server error.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Service status",
+                "operationId": "getStatus",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "instance (default) or fleet; fleet falls back to instance when aggregation is unavailable",
+                        "name": "scope",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.StatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/version": {
+            "get": {
+                "description": "Return the deployed version, git commit and build date, and which optional features are enabled",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Build and feature information",
+                "operationId": "getVersion",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.VersionResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "List your webhooks",
+                "operationId": "listWebhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Webhook"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Register a URL receiving a JSON POST for each subscribed event on the links owned by the caller: link.created, link.expired, link.clicks each time a link's click count reaches a multiple of click_threshold, link.disabled when an admin disables a link, or link.cleaned_up when the expired link cleanup deletes one; and on the caller's monthly link quota: quota.warning at 80% of it, quota.exhausted when it is used up. Deliveries carry X-Hook-Delivery, X-Hook-Event, X-Hook-Attempt, X-Timestamp and X-Signature headers, where the signature is hex(HMAC-SHA256(secret, timestamp + \"\\n\" + body)) with the secret returned here once. Failed deliveries are retried with backoff. Responding 410 Gone to a delivery deletes the webhook.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Register a webhook",
+                "operationId": "createWebhook",
+                "parameters": [
+                    {
+                        "description": "Webhook",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Too many webhooks",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the events on your links that webhooks can subscribe to",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "List webhook events",
+                "operationId": "listWebhookEvents",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.HookTrigger"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Get one of your webhooks",
+                "operationId": "getWebhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Change the URL, events and click threshold of a webhook owned by the caller. Its signing secret is kept. Subscribing to link.expired again only reports links expiring from now on.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Replace one of your webhooks",
+                "operationId": "updateWebhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a webhook owned by the caller. Deliveries still being retried are moved to the dead letters.",
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Delete one of your webhooks",
+                "operationId": "deleteWebhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Webhook deleted"
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the update scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the 50 most recent deliveries to a webhook owned by the caller, newest first, with their attempts and last error",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "List the deliveries of one of your webhooks",
+                "operationId": "listWebhookDeliveries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.HookDelivery"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is not assigned to a user or lacks the read_stats scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/{shortCode}": {
+            "get": {
+                "description": "Redirect to the original URL using the short code and increment click count. Browsers (Accept: text/html) get an HTML page for missing, expired and pending links instead of JSON, translated according to Accept-Language. With ?info=1, or an Accept header preferring text/plain, a plaintext summary of the destination, creation date and clicks is returned instead of redirecting, and no click is counted. With ?preview=1, or + appended to the short code (GET /abc123+), an HTML page shows the same along with the title and description of the destination page, so recipients can inspect the link before following it; no click is counted either. Links created with max_clicks expire after that many redirects, which neither the summary, the preview page nor link preview crawlers (answered with 204) use up. Soft launched links only redirect the rollout_percent of visitors whose address and user agent hash into it; the others get a holding page. Links with a velocity_limit redirect at most that many visitors per second or minute; the others get a waiting page retrying on its own (503 with Retry-After), 429 with Retry-After, or a 302 to the limit's overflow_url, and are not counted as clicks. Requests to a branded short link domain resolve the short code among that domain's links only.",
+                "produces": [
+                    "text/plain",
+                    "text/html"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Redirect to original URL",
+                "operationId": "redirectURL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code, followed by + for the preview page",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Set to 1 for a plaintext summary instead of a redirect",
+                        "name": "info",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Set to 1 for an HTML preview page instead of a redirect",
+                        "name": "preview",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Plaintext link summary, or HTML preview page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "204": {
+                        "description": "Link preview crawlers fetching a link with max_clicks"
+                    },
+                    "301": {
+                        "description": "Redirects to original URL, or from the old short code of a renamed link to its new short URL"
+                    },
+                    "302": {
+                        "description": "Split links redirect to one of their variants, and velocity limited links beyond their limit to their overflow_url"
+                    },
+                    "403": {
+                        "description": "Short URL is pending approval",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Short URL has expired or used up its max_clicks",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, or the link's velocity_limit with overflow reject",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Short URL is soft launched and not open to this visitor yet, or over its velocity_limit with overflow queue",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "508": {
+                        "description": "Short URL redirects in a loop",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/{shortCode}/qr": {
+            "get": {
+                "description": "Render a QR code encoding the short URL, as PNG (default) or SVG, for printing on posters and packaging. Images are cached, and scanning the code goes through the usual redirect, so clicks are counted.",
+                "produces": [
+                    "image/png",
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "QR code of a short link",
+                "operationId": "getQRCode",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "png or svg (default png)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Width and height in pixels, including the quiet zone (default 256, 128 to 1024)",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Error correction level: L, M, Q or H (default M); higher levels survive more damage or a logo over the center",
+                        "name": "ecc",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "QR code image",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid format, size or error correction level",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Short URL is pending approval",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Short URL has expired",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/{shortCode}/report": {
+            "post": {
+                "description": "Report a short link as abusive, such as phishing or spam. Each visitor's report of a link is counted once against the link's creator, raising its abuse score when ABUSE_SCORING is enabled, and kept for admins to review. The response does not tell whether the visitor had reported the link already.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URL Shortener"
+                ],
+                "summary": "Report an abusive short link",
+                "operationId": "reportLink",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "shortCode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Why the link is abusive",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.AbuseReportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Report received"
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "database.QueryStats": {
+            "type": "object",
+            "properties": {
+                "avg_ms": {
+                    "type": "number"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "instance": {
+                    "description": "reporting instance, empty when merged across the fleet",
+                    "type": "string"
+                },
+                "max_ms": {
+                    "type": "number"
+                },
+                "operation": {
+                    "type": "string"
+                },
+                "slow_count": {
+                    "type": "integer"
+                },
+                "table": {
+                    "type": "string"
+                },
+                "total_ms": {
+                    "type": "number"
+                }
+            }
+        },
+        "gorm.DeletedAt": {
+            "type": "object",
+            "properties": {
+                "time": {
+                    "type": "string"
+                },
+                "valid": {
+                    "description": "Valid is true if Time is not NULL",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIKey": {
+            "type": "object",
+            "properties": {
+                "allowed_domains": {
+                    "description": "destination domains this key may shorten",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "allowed_tags": {
+                    "description": "tags this key may put on links",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key_prefix": {
+                    "description": "first characters of the key, for identification",
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "rotated_from_id": {
+                    "description": "key this one replaced",
+                    "type": "integer"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "description": "user owning the links created with this key",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.APIKeyCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "allowed_domains": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "allowed_tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "signing_secret": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AbuseReport": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "creator": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "short_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AbuseReportRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "models.AbuseScore": {
+            "type": "object",
+            "properties": {
+                "creator": {
+                    "type": "string"
+                },
+                "level": {
+                    "description": "in force, from Override or Score",
+                    "type": "string",
+                    "enum": [
+                        "normal",
+                        "elevated",
+                        "high",
+                        "severe"
+                    ]
+                },
+                "override": {
+                    "description": "Level set by an admin, which applies whatever the score; nil to\nfollow the score",
+                    "type": "string",
+                    "enum": [
+                        "normal",
+                        "elevated",
+                        "high",
+                        "severe"
+                    ]
+                },
+                "reason": {
+                    "description": "why the override was set",
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AbuseScoreOverrideRequest": {
+            "type": "object",
+            "properties": {
+                "level": {
+                    "description": "Level applying whatever the score, null to follow the score again",
+                    "type": "string",
+                    "enum": [
+                        "normal",
+                        "elevated",
+                        "high",
+                        "severe"
+                    ]
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "models.AnnotationRequest": {
+            "type": "object",
+            "required": [
+                "text"
+            ],
+            "properties": {
+                "text": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Newsletter sent"
+                },
+                "time": {
+                    "description": "when the event happened, RFC 3339; now when omitted",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Artifact": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "text/csv"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "download_url": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "links-20240501-120000.csv"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "description": "links in the file",
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "request": {
+                    "$ref": "#/definitions/models.ArtifactRequest"
+                },
+                "size": {
+                    "description": "bytes",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ArtifactRequest": {
+            "type": "object",
+            "required": [
+                "kind",
+                "short_codes"
+            ],
+            "properties": {
+                "created_after": {
+                    "type": "string"
+                },
+                "created_before": {
+                    "type": "string"
+                },
+                "domain": {
+                    "type": "string",
+                    "maxLength": 253
+                },
+                "ecc": {
+                    "description": "QR error correction level, M by default",
+                    "type": "string",
+                    "enum": [
+                        "L",
+                        "M",
+                        "Q",
+                        "H"
+                    ],
+                    "example": "M"
+                },
+                "expired": {
+                    "type": "boolean"
+                },
+                "format": {
+                    "description": "csv (default) or json for exports, png (default) or svg for QR codes",
+                    "type": "string",
+                    "enum": [
+                        "csv",
+                        "json",
+                        "png",
+                        "svg"
+                    ],
+                    "example": "png"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "links_export",
+                        "qr_batch"
+                    ],
+                    "example": "qr_batch"
+                },
+                "short_codes": {
+                    "type": "array",
+                    "maxItems": 1000,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "size": {
+                    "description": "QR code width in pixels, 256 by default",
+                    "type": "integer",
+                    "maximum": 1024,
+                    "minimum": 128,
+                    "example": 512
+                }
+            }
+        },
+        "models.ArtifactsResponse": {
+            "type": "object",
+            "properties": {
+                "artifacts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Artifact"
+                    }
+                }
+            }
+        },
+        "models.BackupCodesResponse": {
+            "type": "object",
+            "properties": {
+                "backup_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.BulkLinkRequest": {
+            "type": "object",
+            "required": [
+                "action"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "expire",
+                        "disable"
+                    ],
+                    "example": "disable"
+                },
+                "created_before": {
+                    "description": "links created before this time",
+                    "type": "string"
+                },
+                "domain": {
+                    "description": "links to this host or its subdomains",
+                    "type": "string",
+                    "maxLength": 253,
+                    "example": "evil.com"
+                },
+                "tag": {
+                    "description": "links with this tag",
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "models.BulkOperation": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_before": {
+                    "type": "string"
+                },
+                "domain": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "matched": {
+                    "description": "links matching the filter when the operation was queued",
+                    "type": "integer"
+                },
+                "processed": {
+                    "description": "links checked so far",
+                    "type": "integer"
+                },
+                "skipped": {
+                    "description": "locked links, left alone",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tag": {
+                    "type": "string"
+                },
+                "updated": {
+                    "description": "links the action changed",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.BulkOperationsResponse": {
+            "type": "object",
+            "properties": {
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BulkOperation"
+                    }
+                }
+            }
+        },
+        "models.BundleLink": {
+            "type": "object",
+            "properties": {
+                "analytics": {
+                    "type": "string",
+                    "enum": [
+                        "full",
+                        "count",
+                        "none"
+                    ]
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "max_clicks": {
+                    "description": "clicks the link had left when exported",
+                    "type": "integer"
+                },
+                "noindex": {
+                    "type": "boolean"
+                },
+                "og_description": {
+                    "type": "string"
+                },
+                "og_image": {
+                    "type": "string"
+                },
+                "og_title": {
+                    "type": "string"
+                },
+                "redirect_type": {
+                    "type": "integer"
+                },
+                "routing_rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RoutingRule"
+                    }
+                },
+                "short_code": {
+                    "type": "string",
+                    "example": "promo2024"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/spring"
+                },
+                "utm_campaign": {
+                    "type": "string"
+                },
+                "utm_medium": {
+                    "type": "string"
+                },
+                "utm_source": {
+                    "type": "string"
+                },
+                "variant_mode": {
+                    "type": "string"
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.VariantRequest"
+                    }
+                }
+            }
+        },
+        "models.ChannelLink": {
+            "type": "object",
+            "properties": {
+                "analytics": {
+                    "description": "full, count or none",
+                    "type": "string"
+                },
+                "attribution_window_hours": {
+                    "description": "Attribution window of the split link's conversions, when set",
+                    "type": "integer"
+                },
+                "channel": {
+                    "type": "string"
+                },
+                "domain": {
+                    "description": "branded domain serving the link",
+                    "type": "string"
+                },
+                "environment": {
+                    "description": "production or staging",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "external_id": {
+                    "type": "string"
+                },
+                "max_clicks": {
+                    "type": "integer"
+                },
+                "original_url": {
+                    "type": "string"
+                },
+                "qr_url": {
+                    "description": "PNG QR code of short_url, see GET /{shortCode}/qr",
+                    "type": "string"
+                },
+                "redirect_headers": {
+                    "description": "Extra headers of the link's redirects",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "redirect_type": {
+                    "description": "HTTP status of the link's redirects",
+                    "type": "integer"
+                },
+                "rollout_percent": {
+                    "description": "Percentage of visitors redirected, for soft launched links",
+                    "type": "integer"
+                },
+                "short_code": {
+                    "type": "string"
+                },
+                "short_url": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "track_landings": {
+                    "type": "boolean"
+                },
+                "variants": {
+                    "description": "Variants of a split link, with their conversion pixels",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.VariantStats"
+                    }
+                },
+                "velocity_limit": {
+                    "$ref": "#/definitions/models.VelocityLimit"
+                },
+                "warnings": {
+                    "description": "Problems with the new link that did not stop its creation",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.ChaosConfig": {
+            "type": "object",
+            "properties": {
+                "error_rate": {
+                    "description": "share of affected operations that fail",
+                    "type": "number",
+                    "maximum": 1,
+                    "minimum": 0
+                },
+                "latency_ms": {
+                    "description": "added to every affected operation",
+                    "type": "integer",
+                    "maximum": 60000,
+                    "minimum": 0
+                },
+                "targets": {
+                    "description": "all targets when empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.ClickQueueStatus": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer"
+                },
+                "depth": {
+                    "type": "integer"
+                },
+                "dropped": {
+                    "type": "integer"
+                },
+                "shed": {
+                    "description": "Clicks counted without their click event during spikes over\nCLICK_EVENT_SHED_RATE",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ClickReconciliationReport": {
+            "type": "object",
+            "properties": {
+                "cache_mismatched": {
+                    "description": "The cached counter differed from the database and was invalidated",
+                    "type": "integer"
+                },
+                "database_behind": {
+                    "description": "click_count was below the click events or cached counter and was raised",
+                    "type": "integer"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "events_missing": {
+                    "description": "click_count exceeds the link's recorded click events; expected for\nclicks older than CLICK_EVENT_RETENTION, so it is reported but not repaired",
+                    "type": "integer"
+                },
+                "links_checked": {
+                    "type": "integer"
+                },
+                "since": {
+                    "description": "links updated since then were checked",
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ClickReconciliationStatus": {
+            "type": "object",
+            "properties": {
+                "last_run": {
+                    "$ref": "#/definitions/models.ClickReconciliationReport"
+                },
+                "runs": {
+                    "type": "integer"
+                },
+                "total_cache_mismatched": {
+                    "type": "integer"
+                },
+                "total_database_behind": {
+                    "type": "integer"
+                },
+                "total_events_missing": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Collection": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "links": {
+                    "description": "Short codes of the owner's links, in the order shown",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "spring",
+                        "spring-fb"
+                    ]
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Acme spring campaign"
+                },
+                "owner_id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "description": "The caller's role, in listings",
+                    "type": "string",
+                    "example": "owner"
+                },
+                "shared_at": {
+                    "description": "when the current share link was created",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CollectionLink": {
+            "type": "object",
+            "properties": {
+                "click_count": {
+                    "type": "integer",
+                    "example": 4200
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "original_url": {
+                    "type": "string",
+                    "example": "https://acme.com/spring"
+                },
+                "recent_clicks": {
+                    "type": "integer",
+                    "example": 610
+                },
+                "short_code": {
+                    "type": "string",
+                    "example": "spring"
+                },
+                "short_url": {
+                    "type": "string",
+                    "example": "https://sho.rt/spring"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "active"
+                }
+            }
+        },
+        "models.CollectionMember": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "viewer"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CollectionMemberRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "role"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "client@acme.com"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "viewer",
+                        "editor"
+                    ],
+                    "example": "viewer"
+                }
+            }
+        },
+        "models.CollectionRequest": {
+            "type": "object",
+            "required": [
+                "links"
+            ],
+            "properties": {
+                "links": {
+                    "description": "Short codes of the owner's links, host/code on branded domains;\nreplaces the links when editing",
+                    "type": "array",
+                    "maxItems": 500,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "spring",
+                        "spring-fb"
+                    ]
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1,
+                    "example": "Acme spring campaign"
+                }
+            }
+        },
+        "models.CollectionShareResponse": {
+            "type": "object",
+            "properties": {
+                "share_url": {
+                    "type": "string",
+                    "example": "https://sho.rt/shared/collections/3f9a..."
+                },
+                "shared_at": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "3f9a..."
+                }
+            }
+        },
+        "models.CollectionView": {
+            "type": "object",
+            "properties": {
+                "click_count": {
+                    "type": "integer",
+                    "example": 5400
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CollectionLink"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Acme spring campaign"
+                },
+                "recent_clicks": {
+                    "type": "integer",
+                    "example": 820
+                },
+                "role": {
+                    "description": "the caller's, absent through a share link",
+                    "type": "string",
+                    "example": "viewer"
+                }
+            }
+        },
+        "models.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "allowed_domains": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "allowed_tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "expires_in": {
+                    "description": "in days, optional",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "description": "optional, links created with the key belong to this user",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 8
+                },
+                "plan": {
+                    "description": "a plan of STATS_RETENTION_DAYS or LINK_QUOTAS, free by default",
+                    "type": "string",
+                    "example": "pro"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "admin"
+                    ]
+                }
+            }
+        },
+        "models.DBPoolStatus": {
+            "type": "object",
+            "properties": {
+                "idle": {
+                    "type": "integer"
+                },
+                "in_use": {
+                    "type": "integer"
+                },
+                "open": {
+                    "type": "integer"
+                },
+                "wait_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DependencyHealth": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "latency_ms": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.DestinationCount": {
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "type": "integer",
+                    "example": 3100
+                },
+                "domain": {
+                    "type": "string",
+                    "example": "example.com"
+                },
+                "links": {
+                    "description": "links to the domain clicked in the range",
+                    "type": "integer",
+                    "example": 14
+                }
+            }
+        },
+        "models.DestinationStatsResponse": {
+            "type": "object",
+            "properties": {
+                "destinations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DestinationCount"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "retention": {
+                    "description": "Set when from was moved forward to the click history kept on the\ncaller's plan",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RetentionNotice"
+                        }
+                    ]
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 5200
+                }
+            }
+        },
+        "models.Domain": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "environment": {
+                    "description": "Environment of the links served on the domain, see\nEnvironmentProduction",
+                    "type": "string",
+                    "enum": [
+                        "production",
+                        "staging"
+                    ],
+                    "example": "production"
+                },
+                "host": {
+                    "type": "string",
+                    "example": "go.acme.com"
+                },
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DomainRequest": {
+            "type": "object",
+            "required": [
+                "domain"
+            ],
+            "properties": {
+                "domain": {
+                    "type": "string",
+                    "example": "example.com"
+                },
+                "skip_approval": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.DomainResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "dns_record_name": {
+                    "type": "string",
+                    "example": "_url-shortener-verification.example.com"
+                },
+                "dns_record_value": {
+                    "type": "string"
+                },
+                "domain": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "meta_tag": {
+                    "type": "string"
+                },
+                "method": {
+                    "description": "dns or meta, once verified",
+                    "type": "string"
+                },
+                "skip_approval": {
+                    "description": "Links to the domain skip the approval required of their creator's\nrole once it is verified, not safety or abuse reviews",
+                    "type": "boolean"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "verified_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DomainUpdateRequest": {
+            "type": "object",
+            "required": [
+                "skip_approval"
+            ],
+            "properties": {
+                "skip_approval": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.DomainVerifyRequest": {
+            "type": "object",
+            "required": [
+                "method"
+            ],
+            "properties": {
+                "method": {
+                    "type": "string",
+                    "enum": [
+                        "dns",
+                        "meta"
+                    ]
+                }
+            }
+        },
+        "models.DuplicateGroup": {
+            "type": "object",
+            "properties": {
+                "destination": {
+                    "description": "The canonical form: lowercased scheme and host, no default port,\ntracking parameters or trailing slash, sorted query parameters",
+                    "type": "string",
+                    "example": "https://example.com/pricing"
+                },
+                "links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DuplicateLink"
+                    }
+                },
+                "total_clicks": {
+                    "type": "integer",
+                    "example": 310
+                }
+            }
+        },
+        "models.DuplicateLink": {
+            "type": "object",
+            "properties": {
+                "click_count": {
+                    "type": "integer",
+                    "example": 300
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "domain": {
+                    "description": "branded domain serving the link",
+                    "type": "string",
+                    "example": "go.acme.com"
+                },
+                "original_url": {
+                    "type": "string",
+                    "example": "https://Example.com/pricing/?utm_source=x"
+                },
+                "short_code": {
+                    "type": "string",
+                    "example": "abc123"
+                }
+            }
+        },
+        "models.DuplicatesReport": {
+            "type": "object",
+            "properties": {
+                "duplicate_links": {
+                    "description": "Links that are not the oldest of their group, including those past\nthe limit; what strict deduplication would have avoided",
+                    "type": "integer",
+                    "example": 48
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DuplicateGroup"
+                    }
+                },
+                "links_scanned": {
+                    "type": "integer",
+                    "example": 1200
+                },
+                "total_groups": {
+                    "description": "Groups of links sharing a destination, including those past the limit",
+                    "type": "integer",
+                    "example": 35
+                }
+            }
+        },
+        "models.EndpointStatus": {
+            "type": "object",
+            "properties": {
+                "method": {
+                    "type": "string",
+                    "example": "GET"
+                },
+                "route": {
+                    "type": "string",
+                    "example": "/:shortCode"
+                },
+                "windows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StatusWindow"
+                    }
+                }
+            }
+        },
+        "models.ErrorCode": {
+            "type": "string",
+            "enum": [
+                "INVALID_REQUEST",
+                "URL_INVALID",
+                "URL_BLOCKED",
+                "URL_UNSAFE",
+                "URL_EXISTS",
+                "ALIAS_TAKEN",
+                "LINK_NOT_FOUND",
+                "LINK_EXPIRED",
+                "LINK_PENDING",
+                "LINK_DISABLED",
+                "LINK_LOOP",
+                "LINK_NOT_LAUNCHED",
+                "LINK_BUSY",
+                "CAPTCHA_FAILED",
+                "UNAUTHORIZED",
+                "TWO_FACTOR_REQUIRED",
+                "TWO_FACTOR_INVALID",
+                "FORBIDDEN",
+                "SCOPE_MISSING",
+                "NOT_FOUND",
+                "CONFLICT",
+                "CURSOR_EXPIRED",
+                "REVISION_MISMATCH",
+                "DOMAIN_VERIFICATION_FAILED",
+                "RATE_LIMITED",
+                "ABUSE_RESTRICTED",
+                "QUOTA_EXCEEDED",
+                "TIMEOUT",
+                "SERVICE_UNAVAILABLE",
+                "INTERNAL_ERROR"
+            ],
+            "x-enum-varnames": [
+                "ErrCodeInvalidRequest",
+                "ErrCodeURLInvalid",
+                "ErrCodeURLBlocked",
+                "ErrCodeURLUnsafe",
+                "ErrCodeURLExists",
+                "ErrCodeAliasTaken",
+                "ErrCodeLinkNotFound",
+                "ErrCodeLinkExpired",
+                "ErrCodeLinkPending",
+                "ErrCodeLinkDisabled",
+                "ErrCodeLinkLoop",
+                "ErrCodeLinkNotLaunched",
+                "ErrCodeLinkBusy",
+                "ErrCodeCaptchaFailed",
+                "ErrCodeUnauthorized",
+                "ErrCodeTwoFactorRequired",
+                "ErrCodeTwoFactorInvalid",
+                "ErrCodeForbidden",
+                "ErrCodeScopeMissing",
+                "ErrCodeNotFound",
+                "ErrCodeConflict",
+                "ErrCodeCursorExpired",
+                "ErrCodeRevisionMismatch",
+                "ErrCodeDomainUnverified",
+                "ErrCodeRateLimited",
+                "ErrCodeAbuseRestricted",
+                "ErrCodeQuotaExceeded",
+                "ErrCodeTimeout",
+                "ErrCodeUnavailable",
+                "ErrCodeInternal"
+            ]
+        },
+        "models.ErrorCodeInfo": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/models.ErrorCode"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ErrorResponse": {
+            "type": "object",
+            "required": [
+                "code",
+                "error"
+            ],
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ErrorCode"
+                        }
+                    ],
+                    "example": "LINK_NOT_FOUND"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Short URL not found"
+                },
+                "short_code": {
+                    "type": "string"
+                },
+                "two_factor_required": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.ExpiredLinkCleanupReport": {
+            "type": "object",
+            "properties": {
+                "archived_links": {
+                    "description": "archived links purged",
+                    "type": "integer"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expired_before": {
+                    "description": "links expired before then were cleaned up",
+                    "type": "string"
+                },
+                "links": {
+                    "description": "live links soft-deleted or purged",
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "soft"
+                },
+                "started_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Funnel": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Spring launch"
+                },
+                "steps": {
+                    "description": "short codes, in order",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "teaser",
+                        "launch",
+                        "signup"
+                    ]
+                }
+            }
+        },
+        "models.FunnelReport": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Spring launch"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FunnelStep"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FunnelRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "steps"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Spring launch"
+                },
+                "steps": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "minItems": 2,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "teaser",
+                        "launch",
+                        "signup"
+                    ]
+                }
+            }
+        },
+        "models.FunnelStep": {
+            "type": "object",
+            "properties": {
+                "conversion": {
+                    "type": "number",
+                    "example": 40
+                },
+                "short_code": {
+                    "type": "string",
+                    "example": "launch"
+                },
+                "step_rate": {
+                    "description": "Percentage of the visitors of the previous step, or of the first step\nfor conversion, rounded to a tenth; null when that step had none",
+                    "type": "number",
+                    "example": 40
+                },
+                "visitors": {
+                    "type": "integer",
+                    "example": 240
+                }
+            }
+        },
+        "models.GlobalStatsResponse": {
+            "type": "object",
+            "properties": {
+                "archived_urls": {
+                    "description": "idle links moved to the archive",
+                    "type": "integer",
+                    "example": 310
+                },
+                "by_status": {
+                    "description": "live links per status",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "created_last_24h": {
+                    "description": "live links created in the last 24 hours",
+                    "type": "integer",
+                    "example": 42
+                },
+                "top_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TopLink"
+                    }
+                },
+                "total_clicks": {
+                    "description": "clicks of live and archived links",
+                    "type": "integer",
+                    "example": 98321
+                },
+                "total_urls": {
+                    "description": "live links, not deleted or archived",
+                    "type": "integer",
+                    "example": 1250
+                }
+            }
+        },
+        "models.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "cache": {
+                    "$ref": "#/definitions/models.DependencyHealth"
+                },
+                "click_queue": {
+                    "$ref": "#/definitions/models.ClickQueueStatus"
+                },
+                "commit": {
+                    "type": "string"
+                },
+                "database": {
+                    "$ref": "#/definitions/models.DependencyHealth"
+                },
+                "db_pool": {
+                    "$ref": "#/definitions/models.DBPoolStatus"
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "url-shortener-7d9f8-abcde"
+                },
+                "integrity": {
+                    "$ref": "#/definitions/models.IntegrityStatus"
+                },
+                "jobs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.JobHeartbeat"
+                    }
+                },
+                "link_table": {
+                    "$ref": "#/definitions/models.LinkTableStatus"
+                },
+                "local_cache": {
+                    "$ref": "#/definitions/models.LocalCacheStatus"
+                },
+                "migrations": {
+                    "description": "Admin-only details",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MigrationStatus"
+                        }
+                    ]
+                },
+                "runtime": {
+                    "$ref": "#/definitions/models.RuntimeStatus"
+                },
+                "service": {
+                    "type": "string",
+                    "example": "url-shortener"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "healthy"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "uptime": {
+                    "type": "string",
+                    "example": "72h3m0s"
+                },
+                "uptime_seconds": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.4.0"
+                }
+            }
+        },
+        "models.HeatmapPeak": {
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "type": "integer",
+                    "example": 31
+                },
+                "hour": {
+                    "type": "integer",
+                    "example": 18
+                },
+                "weekday": {
+                    "type": "string",
+                    "example": "tuesday"
+                }
+            }
+        },
+        "models.HeatmapResponse": {
+            "type": "object",
+            "properties": {
+                "analytics": {
+                    "description": "full, count or none",
+                    "type": "string",
+                    "example": "full"
+                },
+                "clicks": {
+                    "description": "7 weekdays of 24 hours",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "peak": {
+                    "description": "the hour with the most clicks, absent without clicks",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.HeatmapPeak"
+                        }
+                    ]
+                },
+                "retention": {
+                    "description": "Set when from was moved forward to the click history kept on the\nplan of the link's owner",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RetentionNotice"
+                        }
+                    ]
+                },
+                "short_code": {
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 420
+                },
+                "tz": {
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                }
+            }
+        },
+        "models.HookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "delivery_id": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status_code": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "description": "0 for webhook deliveries",
+                    "type": "integer"
+                },
+                "target_url": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.HookLinkPayload": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "original_url": {
+                    "type": "string"
+                },
+                "short_code": {
+                    "type": "string"
+                },
+                "short_url": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.HookSubscription": {
+            "type": "object",
+            "properties": {
+                "api_key_id": {
+                    "description": "key that subscribed, if any",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_delivery_id": {
+                    "description": "Checkpoint: the latest event the target acknowledged and when it\noccurred. Events before it were delivered or are dead letters.",
+                    "type": "string"
+                },
+                "last_event_at": {
+                    "type": "string"
+                },
+                "target_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.HookSubscriptionCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "api_key_id": {
+                    "description": "key that subscribed, if any",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_delivery_id": {
+                    "description": "Checkpoint: the latest event the target acknowledged and when it\noccurred. Events before it were delivered or are dead letters.",
+                    "type": "string"
+                },
+                "last_event_at": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "target_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.HookTrigger": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Impersonation": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "the admin credential that started it, admin or api_key:\u003cid\u003e",
+                    "type": "string",
+                    "example": "admin"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "ended_at": {
+                    "description": "when an admin ended it early",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Ticket #4821: links missing from dashboard"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read_stats"
+                    ]
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ImpersonationRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "minutes": {
+                    "description": "How long the token is valid, 1 to 240 minutes (default 30)",
+                    "type": "integer",
+                    "maximum": 240,
+                    "minimum": 1,
+                    "example": 30
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "Ticket #4821: links missing from dashboard"
+                },
+                "scopes": {
+                    "description": "What the token may do as the user (default read_stats only)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read_stats"
+                    ]
+                }
+            }
+        },
+        "models.ImpersonationResponse": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "the admin credential that started it, admin or api_key:\u003cid\u003e",
+                    "type": "string",
+                    "example": "admin"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "ended_at": {
+                    "description": "when an admin ended it early",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Ticket #4821: links missing from dashboard"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read_stats"
+                    ]
+                },
+                "token": {
+                    "type": "string",
+                    "example": "usi_3f9a..."
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.IntegrityCheck": {
+            "type": "object",
+            "properties": {
+                "checked": {
+                    "description": "rows or links examined",
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "orphaned_aliases"
+                },
+                "samples": {
+                    "description": "Up to IntegritySamples of the offending short codes or aliases",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "spring"
+                    ]
+                },
+                "skipped": {
+                    "description": "why the check did not run",
+                    "type": "string"
+                },
+                "violations": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.IntegrityReport": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.IntegrityCheck"
+                    }
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "trigger": {
+                    "type": "string",
+                    "example": "startup"
+                },
+                "violations": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.IntegrityStatus": {
+            "type": "object",
+            "properties": {
+                "last_run": {
+                    "$ref": "#/definitions/models.IntegrityReport"
+                },
+                "runs": {
+                    "type": "integer"
+                },
+                "runs_with_violations": {
+                    "type": "integer"
+                },
+                "total_violations": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.JobHeartbeat": {
+            "type": "object",
+            "properties": {
+                "interval": {
+                    "type": "string",
+                    "example": "1h0m0s"
+                },
+                "last_run": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "stale_api_keys"
+                },
+                "stale": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.LandingStats": {
+            "type": "object",
+            "properties": {
+                "bounces": {
+                    "type": "integer",
+                    "example": 180
+                },
+                "landing_rate": {
+                    "description": "Share of tracked clicks that landed, 0 to 1",
+                    "type": "number",
+                    "example": 0.85
+                },
+                "landings": {
+                    "description": "reported by /js/track.js on the destination",
+                    "type": "integer",
+                    "example": 1020
+                },
+                "tracked_clicks": {
+                    "type": "integer",
+                    "example": 1200
+                }
+            }
+        },
+        "models.LinkAnnotation": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "description": "the owner as user:\u003cid\u003e",
+                    "type": "string",
+                    "example": "user:7"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Newsletter sent"
+                },
+                "time": {
+                    "description": "when the event happened",
+                    "type": "string"
+                }
+            }
+        },
+        "models.LinkBundle": {
+            "type": "object",
+            "properties": {
+                "exported_at": {
+                    "type": "string"
+                },
+                "links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BundleLink"
+                    }
+                },
+                "signature": {
+                    "description": "Hex HMAC-SHA256 of the bundle encoded as JSON with an empty signature",
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "staging.sho.rt"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.LinkChangeEvent": {
+            "type": "object",
+            "properties": {
+                "changed_at": {
+                    "type": "string"
+                },
+                "cursor": {
+                    "description": "Opaque cursor of the change; pass the last one seen as since",
+                    "type": "string",
+                    "example": "1042"
+                },
+                "event": {
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "updated",
+                        "deleted"
+                    ],
+                    "example": "updated"
+                },
+                "link": {
+                    "description": "The link as it is now, when it still exists; later changes may\nalready be reflected",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.URL"
+                        }
+                    ]
+                },
+                "previous_short_code": {
+                    "description": "Short code the link had before this change renamed it",
+                    "type": "string"
+                },
+                "short_code": {
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "url_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.LinkChangesResponse": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LinkChangeEvent"
+                    }
+                },
+                "has_more": {
+                    "description": "More changes are ready to be read right away",
+                    "type": "boolean"
+                },
+                "next_cursor": {
+                    "description": "Cursor to pass as since for the next page; unchanged when there were\nno new changes",
+                    "type": "string",
+                    "example": "1042"
+                }
+            }
+        },
+        "models.LinkExportRequest": {
+            "type": "object",
+            "properties": {
+                "short_codes": {
+                    "type": "array",
+                    "maxItems": 1000,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tag": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "spring-sale"
+                }
+            }
+        },
+        "models.LinkImportResponse": {
+            "type": "object",
+            "properties": {
+                "imported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "skipped": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LinkImportSkip"
+                    }
+                }
+            }
+        },
+        "models.LinkImportSkip": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "short code is taken"
+                },
+                "short_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LinkReference": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "description": "the owner as user:\u003cid\u003e",
+                    "type": "string",
+                    "example": "user:7"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "jira"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Spring campaign launch"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://acme.atlassian.net/browse/MKT-142"
+                }
+            }
+        },
+        "models.LinkStatsReset": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "admin, or the owner as user:\u003cid\u003e",
+                    "type": "string",
+                    "example": "user:7"
+                },
+                "click_count": {
+                    "type": "integer",
+                    "example": 4200
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "landings": {
+                    "description": "for links tracking landings",
+                    "type": "integer",
+                    "example": 3600
+                },
+                "period_start": {
+                    "description": "creation or the previous reset",
+                    "type": "string"
+                },
+                "reset_at": {
+                    "type": "string"
+                },
+                "short_code": {
+                    "type": "string",
+                    "example": "promo2024"
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.VariantTotals"
+                    }
+                }
+            }
+        },
+        "models.LinkTableStatus": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "hit_rate": {
+                    "type": "number",
+                    "example": 0.99
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "last_rebuild": {
+                    "type": "string"
+                },
+                "last_refresh": {
+                    "type": "string"
+                },
+                "links": {
+                    "type": "integer"
+                },
+                "loaded": {
+                    "type": "boolean"
+                },
+                "misses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.LinkVersion": {
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "description": "Click events recorded while the version was active",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "description": "when the version became active",
+                    "type": "string"
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "redirect_type": {
+                    "type": "integer"
+                },
+                "routing_rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RoutingRule"
+                    }
+                },
+                "utm_campaign": {
+                    "type": "string"
+                },
+                "utm_medium": {
+                    "type": "string"
+                },
+                "utm_source": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.LocalCacheStatus": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "evictions": {
+                    "type": "integer"
+                },
+                "hit_rate": {
+                    "type": "number",
+                    "example": 0.93
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "ttl": {
+                    "type": "string",
+                    "example": "5s"
+                }
+            }
+        },
+        "models.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "otp_code": {
+                    "description": "TOTP or backup code, required when 2FA is enabled",
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MigrationStatus": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "missing_tables": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.MirrorStatus": {
+            "type": "object",
+            "properties": {
+                "compared": {
+                    "description": "redirects replayed against the shadow resolver",
+                    "type": "integer"
+                },
+                "dropped": {
+                    "description": "sampled redirects dropped because the queue was full",
+                    "type": "integer"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "description": "redirects MIRROR_URL did not accept",
+                    "type": "integer"
+                },
+                "mirrored": {
+                    "description": "redirects sampled and queued",
+                    "type": "integer"
+                },
+                "mismatch": {
+                    "description": "replays whose status or destination differed",
+                    "type": "integer"
+                },
+                "percent": {
+                    "type": "number"
+                },
+                "sent": {
+                    "description": "redirects delivered to MIRROR_URL",
+                    "type": "integer"
+                },
+                "shadow": {
+                    "description": "MIRROR_SHADOW resolver",
+                    "type": "string"
+                },
+                "target": {
+                    "description": "host of MIRROR_URL",
+                    "type": "string"
+                }
+            }
+        },
+        "models.PreviousPeriod": {
+            "type": "object",
+            "properties": {
+                "change": {
+                    "description": "Percentage change of the total from this period to the requested\none, rounded to a tenth; null when this period had no clicks",
+                    "type": "number",
+                    "example": 16.7
+                },
+                "from": {
+                    "type": "string"
+                },
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TimeseriesPoint"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 36
+                }
+            }
+        },
+        "models.RedirectTrace": {
+            "type": "object",
+            "properties": {
+                "error_code": {
+                    "description": "Error answered instead of a redirect",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ErrorCode"
+                        }
+                    ],
+                    "example": "LINK_EXPIRED"
+                },
+                "location": {
+                    "description": "Where the visitor would be redirected",
+                    "type": "string",
+                    "example": "https://example.com/b?utm_source=newsletter"
+                },
+                "rule": {
+                    "description": "Rule that decided the response",
+                    "type": "string",
+                    "example": "variants"
+                },
+                "short_code": {
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 302
+                },
+                "steps": {
+                    "description": "Rules checked, in order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TraceStep"
+                    }
+                },
+                "variant": {
+                    "description": "Variant picked for this visit, for split links",
+                    "type": "string",
+                    "example": "B"
+                },
+                "variants": {
+                    "description": "Every variant with its current share of traffic, for split links",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.VariantStats"
+                    }
+                }
+            }
+        },
+        "models.RedriveHookDeliveriesResponse": {
+            "type": "object",
+            "properties": {
+                "redriven": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ReferenceRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "kind": {
+                    "description": "Detected from the URL's host when omitted",
+                    "type": "string",
+                    "enum": [
+                        "jira",
+                        "google_doc",
+                        "figma",
+                        "github",
+                        "notion",
+                        "link"
+                    ],
+                    "example": "jira"
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Spring campaign launch"
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://acme.atlassian.net/browse/MKT-142"
+                }
+            }
+        },
+        "models.ReferrerCount": {
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "referrer": {
+                    "type": "string",
+                    "example": "news.ycombinator.com"
+                }
+            }
+        },
+        "models.ReferrersResponse": {
+            "type": "object",
+            "properties": {
+                "analytics": {
+                    "description": "full, count or none",
+                    "type": "string",
+                    "example": "full"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "referrers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ReferrerCount"
+                    }
+                },
+                "retention": {
+                    "description": "Set when from was moved forward to the click history kept on the\nplan of the link's owner",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RetentionNotice"
+                        }
+                    ]
+                },
+                "short_code": {
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RefreshRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RenamedAlias": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "last_hit_at": {
+                    "type": "string"
+                },
+                "renamed_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RetentionNotice": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "description": "days of click history kept on the plan",
+                    "type": "integer",
+                    "example": 30
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Click history is kept for 30 days on the free plan, from was moved to 2024-03-01T00:00:00Z"
+                },
+                "plan": {
+                    "type": "string",
+                    "example": "free"
+                },
+                "requested_from": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RoutingAction": {
+            "type": "object",
+            "properties": {
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "redirect",
+                        "destination",
+                        "deny"
+                    ],
+                    "example": "redirect"
+                },
+                "url": {
+                    "description": "for redirect only",
+                    "type": "string",
+                    "example": "https://example.com/us"
+                }
+            }
+        },
+        "models.RoutingCondition": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "enum": [
+                        "country",
+                        "device",
+                        "os",
+                        "language",
+                        "weekday",
+                        "hour",
+                        "time"
+                    ],
+                    "example": "country"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "in",
+                        "not_in",
+                        "before",
+                        "after"
+                    ],
+                    "example": "in"
+                },
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "US",
+                        "CA"
+                    ]
+                }
+            }
+        },
+        "models.RoutingRule": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/models.RoutingAction"
+                },
+                "conditions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RoutingCondition"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "example": "mobile-us"
+                }
+            }
+        },
+        "models.RuntimeStatus": {
+            "type": "object",
+            "properties": {
+                "go_version": {
+                    "type": "string"
+                },
+                "goroutines": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SafetyRule": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "allow, deny or review",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "pattern": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "regex, domain or keyword",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SafetyRuleRequest": {
+            "type": "object",
+            "required": [
+                "action",
+                "pattern",
+                "type"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "allow",
+                        "deny",
+                        "review"
+                    ]
+                },
+                "description": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "description": "defaults to true",
+                    "type": "boolean"
+                },
+                "pattern": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "regex",
+                        "domain",
+                        "keyword"
+                    ]
+                }
+            }
+        },
+        "models.Session": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "refresh_expires_at": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SessionTokensResponse": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "refresh_expires_at": {
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SetAttributionWindowRequest": {
+            "type": "object",
+            "required": [
+                "hours"
+            ],
+            "properties": {
+                "hours": {
+                    "description": "0 for CONVERSION_ATTRIBUTION_WINDOW",
+                    "type": "integer",
+                    "maximum": 2160,
+                    "minimum": 0,
+                    "example": 72
+                }
+            }
+        },
+        "models.SetUserPlanRequest": {
+            "type": "object",
+            "required": [
+                "plan"
+            ],
+            "properties": {
+                "plan": {
+                    "description": "free or a plan of STATS_RETENTION_DAYS or LINK_QUOTAS",
+                    "type": "string",
+                    "example": "pro"
+                }
+            }
+        },
+        "models.ShadowBan": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ShadowBanRequest": {
+            "type": "object",
+            "required": [
+                "ip_address"
+            ],
+            "properties": {
+                "ip_address": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ShortDomainRequest": {
+            "type": "object",
+            "required": [
+                "host"
+            ],
+            "properties": {
+                "environment": {
+                    "description": "production when omitted",
+                    "type": "string",
+                    "enum": [
+                        "production",
+                        "staging"
+                    ],
+                    "example": "staging"
+                },
+                "host": {
+                    "type": "string",
+                    "example": "go.acme.com"
+                }
+            }
+        },
+        "models.ShortenChannelsRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "add_utm": {
+                    "description": "Append utm_source=\u003cchannel\u003e and utm_medium to each destination (default true)",
+                    "type": "boolean"
+                },
+                "analytics": {
+                    "description": "See ShortenRequest.Analytics",
+                    "type": "string",
+                    "enum": [
+                        "full",
+                        "count",
+                        "none"
+                    ]
+                },
+                "captcha_token": {
+                    "type": "string"
+                },
+                "channels": {
+                    "description": "default twitter, facebook, email",
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "expires_in": {
+                    "description": "in days, optional",
+                    "type": "integer"
+                },
+                "noindex": {
+                    "description": "see ShortenRequest.NoIndex",
+                    "type": "boolean"
+                },
+                "tags": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ShortenChannelsResponse": {
+            "type": "object",
+            "properties": {
+                "links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ChannelLink"
+                    }
+                },
+                "original_url": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "description": "Problems that did not stop the links' creation",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.ShortenRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "analytics": {
+                    "description": "Clicks kept for privacy-sensitive links: true or full (default) counts\nclicks and records click events, false or count only counts them,\nnone keeps nothing",
+                    "type": "string",
+                    "enum": [
+                        "full",
+                        "count",
+                        "none"
+                    ]
+                },
+                "attribution_window_hours": {
+                    "description": "Attribute conversions of a split link to clicks by the same visitor\nwithin this many hours before them, instead of the owner's window",
+                    "type": "integer",
+                    "maximum": 2160,
+                    "minimum": 1,
+                    "example": 72
+                },
+                "captcha_token": {
+                    "description": "Token from the configured CAPTCHA widget, required for anonymous requests when CAPTCHA is enabled",
+                    "type": "string"
+                },
+                "code_style": {
+                    "description": "random (default), sms or words",
+                    "type": "string",
+                    "enum": [
+                        "random",
+                        "sms",
+                        "words"
+                    ]
+                },
+                "custom_alias": {
+                    "description": "Branded short code such as promo2024 instead of a generated one:\n3-64 letters, digits, hyphens or underscores, not a reserved route name",
+                    "type": "string",
+                    "example": "promo2024"
+                },
+                "domain": {
+                    "description": "Branded domain to serve the link on instead of the default one, see\nGET /admin/short-domains",
+                    "type": "string",
+                    "maxLength": 253,
+                    "example": "go.acme.com"
+                },
+                "environment": {
+                    "description": "Environment of the link, which must be that of its domain; the\ndomain's when omitted",
+                    "type": "string",
+                    "enum": [
+                        "production",
+                        "staging"
+                    ],
+                    "example": "staging"
+                },
+                "expires_in": {
+                    "description": "in days, optional",
+                    "type": "integer"
+                },
+                "if_exists": {
+                    "description": "return (default), error or new",
+                    "type": "string",
+                    "enum": [
+                        "return",
+                        "error",
+                        "new"
+                    ]
+                },
+                "max_clicks": {
+                    "description": "Expire the link after this many redirects, 1 for a one-time link",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 1
+                },
+                "no_dedup": {
+                    "description": "Always create a fresh code, like if_exists=new, instead of returning\nthe link of the same destination",
+                    "type": "boolean"
+                },
+                "noindex": {
+                    "description": "Send X-Robots-Tag: noindex with redirects so search engines don't index the link",
+                    "type": "boolean"
+                },
+                "og_description": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "og_image": {
+                    "type": "string"
+                },
+                "og_title": {
+                    "description": "Optional Open Graph card for social previews of the short link",
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "redirect_headers": {
+                    "description": "Extra headers sent with the link's redirects, such as Referrer-Policy:\nno-referrer or a Cache-Control for a CDN; up to 10 of Referrer-Policy,\nCache-Control, CDN-Cache-Control, Surrogate-Control, Surrogate-Key,\nCache-Tag, Vary, Expires or X- headers such as X-Robots-Tag",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "Referrer-Policy": "no-referrer"
+                    }
+                },
+                "redirect_type": {
+                    "description": "Redirect with 301 (default, cached by browsers), 302 or 307 (not\ncached, so later destination changes and every click are seen)",
+                    "type": "integer",
+                    "enum": [
+                        301,
+                        302,
+                        307
+                    ],
+                    "example": 302
+                },
+                "rollout_percent": {
+                    "description": "Soft launch the link: only this percentage of visitors is redirected\nand the others see a holding page, see UpdateLinkRequest to ramp up",
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 0,
+                    "example": 10
+                },
+                "routing_rules": {
+                    "description": "Send visitors matching a rule's conditions elsewhere, or turn them\naway; the first matching rule decides, see GET /routing/schema",
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "$ref": "#/definitions/models.RoutingRule"
+                    }
+                },
+                "tags": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "track_landings": {
+                    "description": "Count the visitors who load the destination, reported by /js/track.js\nembedded there; redirects carry a landing token for it",
+                    "type": "boolean"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "utm_campaign": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "spring_sale"
+                },
+                "utm_medium": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "email"
+                },
+                "utm_source": {
+                    "description": "UTM parameters added to the destination, and to those of variants,\nwhen a redirect happens; parameters already in a destination win",
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "newsletter"
+                },
+                "variant_mode": {
+                    "description": "weighted (default) or bandit",
+                    "type": "string",
+                    "enum": [
+                        "weighted",
+                        "bandit"
+                    ]
+                },
+                "variants": {
+                    "description": "Split traffic between these destinations instead of url, which is only\nused when they cannot be loaded",
+                    "type": "array",
+                    "maxItems": 10,
+                    "minItems": 2,
+                    "items": {
+                        "$ref": "#/definitions/models.VariantRequest"
+                    }
+                },
+                "velocity_limit": {
+                    "description": "Redirect at most this many visitors per second or minute, queueing,\nrejecting or redirecting elsewhere the others",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.VelocityLimit"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.ShortenResponse": {
+            "type": "object",
+            "properties": {
+                "analytics": {
+                    "description": "full, count or none",
+                    "type": "string"
+                },
+                "attribution_window_hours": {
+                    "description": "Attribution window of the split link's conversions, when set",
+                    "type": "integer"
+                },
+                "domain": {
+                    "description": "branded domain serving the link",
+                    "type": "string"
+                },
+                "environment": {
+                    "description": "production or staging",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "external_id": {
+                    "type": "string"
+                },
+                "max_clicks": {
+                    "type": "integer"
+                },
+                "original_url": {
+                    "type": "string"
+                },
+                "qr_url": {
+                    "description": "PNG QR code of short_url, see GET /{shortCode}/qr",
+                    "type": "string"
+                },
+                "redirect_headers": {
+                    "description": "Extra headers of the link's redirects",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "redirect_type": {
+                    "description": "HTTP status of the link's redirects",
+                    "type": "integer"
+                },
+                "rollout_percent": {
+                    "description": "Percentage of visitors redirected, for soft launched links",
+                    "type": "integer"
+                },
+                "short_code": {
+                    "type": "string"
+                },
+                "short_url": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "track_landings": {
+                    "type": "boolean"
+                },
+                "variants": {
+                    "description": "Variants of a split link, with their conversion pixels",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.VariantStats"
+                    }
+                },
+                "velocity_limit": {
+                    "$ref": "#/definitions/models.VelocityLimit"
+                },
+                "warnings": {
+                    "description": "Problems with the new link that did not stop its creation",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.StatsResetsResponse": {
+            "type": "object",
+            "properties": {
+                "resets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LinkStatsReset"
+                    }
+                },
+                "short_code": {
+                    "type": "string",
+                    "example": "promo2024"
+                }
+            }
+        },
+        "models.StatsResponse": {
+            "type": "object",
+            "properties": {
+                "analytics": {
+                    "description": "Clicks kept for the link: full, count (click_count only) or none\n(click_count stays 0)",
+                    "type": "string"
+                },
+                "click_count": {
+                    "type": "integer"
+                },
+                "clicks_remaining": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "landings": {
+                    "description": "Clicks that loaded the destination, for links with track_landings",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.LandingStats"
+                        }
+                    ]
+                },
+                "max_clicks": {
+                    "description": "Redirects the link allows and how many are left, for links with\nmax_clicks; the link expires once none are left",
+                    "type": "integer"
+                },
+                "original_url": {
+                    "type": "string"
+                },
+                "short_code": {
+                    "type": "string"
+                },
+                "stats_reset_at": {
+                    "description": "Set once the stats were reset, click_count covers the time since",
+                    "type": "string"
+                },
+                "verified": {
+                    "description": "The destination is on a domain whose ownership has been verified",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.StatusResponse": {
+            "type": "object",
+            "properties": {
+                "endpoints": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.EndpointStatus"
+                    }
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "url-shortener-7d9f8-abcde"
+                },
+                "instances": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scope": {
+                    "type": "string",
+                    "example": "instance"
+                },
+                "since": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "operational"
+                }
+            }
+        },
+        "models.StatusWindow": {
+            "type": "object",
+            "properties": {
+                "availability": {
+                    "type": "number",
+                    "example": 99.95
+                },
+                "errors": {
+                    "type": "integer"
+                },
+                "latency_p50_ms": {
+                    "type": "number",
+                    "example": 10
+                },
+                "latency_p95_ms": {
+                    "type": "number",
+                    "example": 50
+                },
+                "latency_p99_ms": {
+                    "type": "number",
+                    "example": 250
+                },
+                "requests": {
+                    "type": "integer"
+                },
+                "window": {
+                    "type": "string",
+                    "example": "24h"
+                }
+            }
+        },
+        "models.SubscribeHookRequest": {
+            "type": "object",
+            "required": [
+                "event",
+                "target_url"
+            ],
+            "properties": {
+                "event": {
+                    "type": "string",
+                    "enum": [
+                        "link.created",
+                        "link.approved",
+                        "link.rejected"
+                    ]
+                },
+                "target_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TagRule": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "pattern": {
+                    "type": "string",
+                    "example": "*.shopify.com"
+                },
+                "tag": {
+                    "type": "string",
+                    "example": "store"
+                },
+                "type": {
+                    "description": "domain or regex",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TagRuleRequest": {
+            "type": "object",
+            "required": [
+                "pattern",
+                "tag",
+                "type"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "description": "defaults to true",
+                    "type": "boolean"
+                },
+                "pattern": {
+                    "type": "string",
+                    "example": "*.shopify.com"
+                },
+                "tag": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "store"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "domain",
+                        "regex"
+                    ]
+                }
+            }
+        },
+        "models.TagStatsResponse": {
+            "type": "object",
+            "properties": {
+                "click_count": {
+                    "type": "integer",
+                    "example": 3400
+                },
+                "from": {
+                    "type": "string"
+                },
+                "interval": {
+                    "type": "string",
+                    "example": "day"
+                },
+                "links": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TimeseriesPoint"
+                    }
+                },
+                "previous": {
+                    "description": "with compare=previous_period",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PreviousPeriod"
+                        }
+                    ]
+                },
+                "retention": {
+                    "description": "Set when from was moved forward to the click history kept on the\ncaller's plan",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RetentionNotice"
+                        }
+                    ]
+                },
+                "tag": {
+                    "type": "string",
+                    "example": "spring-sale"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 420
+                },
+                "tz": {
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                }
+            }
+        },
+        "models.TimeseriesPoint": {
+            "type": "object",
+            "properties": {
+                "change": {
+                    "description": "Percentage change from the bucket at the same position of the\nprevious period, with compare=previous_period; absent when that bucket\nhad no clicks",
+                    "type": "number",
+                    "example": 16.7
+                },
+                "clicks": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TimeseriesResponse": {
+            "type": "object",
+            "properties": {
+                "analytics": {
+                    "description": "full, count or none",
+                    "type": "string",
+                    "example": "full"
+                },
+                "annotations": {
+                    "description": "Events annotated on the link's timeline between From and To, oldest\nfirst",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LinkAnnotation"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "interval": {
+                    "type": "string",
+                    "example": "day"
+                },
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TimeseriesPoint"
+                    }
+                },
+                "previous": {
+                    "description": "with compare=previous_period",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PreviousPeriod"
+                        }
+                    ]
+                },
+                "retention": {
+                    "description": "Set when from was moved forward to the click history kept on the\nplan of the link's owner",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RetentionNotice"
+                        }
+                    ]
+                },
+                "short_code": {
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "tz": {
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                }
+            }
+        },
+        "models.TopLink": {
+            "type": "object",
+            "properties": {
+                "click_count": {
+                    "type": "integer",
+                    "example": 5120
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "original_url": {
+                    "type": "string"
+                },
+                "short_code": {
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "active"
+                }
+            }
+        },
+        "models.TraceStep": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "Expires at 2025-01-01T00:00:00Z"
+                },
+                "fired": {
+                    "description": "Whether the rule changed the response",
+                    "type": "boolean"
+                },
+                "rule": {
+                    "type": "string",
+                    "example": "expiry"
+                }
+            }
+        },
+        "models.TwoFactorCodeRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TwoFactorEnrollResponse": {
+            "type": "object",
+            "properties": {
+                "provisioning_uri": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.URL": {
+            "type": "object",
+            "properties": {
+                "analytics": {
+                    "description": "full, count or none, see ShortenRequest.Analytics",
+                    "type": "string"
+                },
+                "attribution_window_hours": {
+                    "description": "Hours after a click that a conversion of a split link is attributed to\nit; 0 follows the owner's window, see ConversionAttributionWindow",
+                    "type": "integer"
+                },
+                "click_count": {
+                    "type": "integer"
+                },
+                "clicks_remaining": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "domain_id": {
+                    "description": "branded domain serving the link, nil for the default one",
+                    "type": "integer"
+                },
+                "environment": {
+                    "description": "Environment of the domain serving the link, see EnvironmentProduction",
+                    "type": "string",
+                    "example": "production"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "expiry_exempt": {
+                    "description": "exempt from the maximum link lifetime by an admin",
+                    "type": "boolean"
+                },
+                "external_id": {
+                    "description": "Identifier of the link in the owner's CMS, unique among the owner's\nlinks, see PUT /external/{external_id}",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "inert": {
+                    "description": "created by a shadow-banned creator, never redirects",
+                    "type": "boolean"
+                },
+                "landings": {
+                    "description": "Landings reported since landing tracking was turned on or the stats\nreset, and click_count at that time",
+                    "type": "integer"
+                },
+                "locked": {
+                    "description": "locked links cannot be edited or deleted",
+                    "type": "boolean"
+                },
+                "max_clicks": {
+                    "description": "Redirects a link allows before expiring, and how many are left; nil\nfor links without a limit",
+                    "type": "integer"
+                },
+                "noindex": {
+                    "description": "asks search engines not to index the link",
+                    "type": "boolean"
+                },
+                "og_description": {
+                    "type": "string"
+                },
+                "og_image": {
+                    "type": "string"
+                },
+                "og_title": {
+                    "description": "Open Graph card shown when the short link is shared on social networks",
+                    "type": "string"
+                },
+                "original_url": {
+                    "description": "encrypted when URL_ENCRYPTION_KEY is set",
+                    "type": "string"
+                },
+                "owner_id": {
+                    "description": "user whose API key created the link",
+                    "type": "integer"
+                },
+                "page_description": {
+                    "type": "string"
+                },
+                "page_fetched_at": {
+                    "type": "string"
+                },
+                "page_title": {
+                    "description": "Title and description of the destination page, shown on the link's\npreview page and fetched when it is first viewed",
+                    "type": "string"
+                },
+                "redirect_headers": {
+                    "description": "Extra response headers of the link's redirects, such as\nReferrer-Policy; nil for none, see ShortenRequest.RedirectHeaders",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "redirect_type": {
+                    "description": "HTTP status of redirects: 301, 302 or 307; 0 for the default, 301\n(302 for split links)",
+                    "type": "integer"
+                },
+                "revision": {
+                    "description": "Revision of the link, raised by every edit; sent as the ETag of\nedits, and matched against If-Match to refuse edits of a stale copy",
+                    "type": "integer"
+                },
+                "rollout_percent": {
+                    "description": "Percentage of visitors a soft launched link redirects, 0 to 100;\nthe others see a holding page. nil once fully launched.",
+                    "type": "integer"
+                },
+                "routing_rules": {
+                    "description": "Rules sending matching visitors elsewhere, checked in order; see\nRoutingRule",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RoutingRule"
+                    }
+                },
+                "short_code": {
+                    "description": "host/code on branded domains, see LinkKey",
+                    "type": "string"
+                },
+                "stats_reset_at": {
+                    "description": "When the click counters were last reset; click_count and variant\ncounters cover the time since",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "track_landings": {
+                    "description": "Redirects carry a landing token the destination reports back through\n/js/track.js, telling clicks that loaded the destination from bounces",
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "utm_campaign": {
+                    "type": "string"
+                },
+                "utm_medium": {
+                    "type": "string"
+                },
+                "utm_source": {
+                    "description": "UTM parameters added to the destination on redirect, unless it has\nits own",
+                    "type": "string"
+                },
+                "variant_mode": {
+                    "description": "weighted or bandit for split links with variants",
+                    "type": "string"
+                },
+                "velocity_limit": {
+                    "description": "Most redirects per second or minute, and what visitors beyond it get;\nnil for links without a limit",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.VelocityLimit"
+                        }
+                    ]
+                },
+                "version": {
+                    "description": "Version of the configuration above, see LinkVersion",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.URLFileRecord": {
+            "type": "object",
+            "properties": {
+                "click_count": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "original_url": {
+                    "type": "string",
+                    "example": "https://example.com/spring"
+                },
+                "short_code": {
+                    "description": "host/code on branded domains",
+                    "type": "string",
+                    "example": "promo2024"
+                },
+                "status": {
+                    "description": "exported, ignored by imports",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "separated by | in CSV files",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.URLImportRename": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "description": "short code in the file",
+                    "type": "string",
+                    "example": "promo"
+                },
+                "reason": {
+                    "description": "why the code could not be kept",
+                    "type": "string",
+                    "example": "short code is taken"
+                },
+                "row": {
+                    "description": "1 for the first link of the file",
+                    "type": "integer"
+                },
+                "short_code": {
+                    "description": "short code of the imported link",
+                    "type": "string",
+                    "example": "aB3dE9"
+                }
+            }
+        },
+        "models.URLImportResponse": {
+            "type": "object",
+            "properties": {
+                "imported": {
+                    "type": "integer"
+                },
+                "renamed": {
+                    "description": "Imported links whose short code could not be kept, with the code they got",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.URLImportRename"
+                    }
+                },
+                "skipped": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.URLImportSkip"
+                    }
+                }
+            }
+        },
+        "models.URLImportSkip": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "URL is blocked by safety policy"
+                },
+                "row": {
+                    "type": "integer"
+                },
+                "short_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UniqueVisitorsResponse": {
+            "type": "object",
+            "properties": {
+                "analytics": {
+                    "description": "full, count or none",
+                    "type": "string",
+                    "example": "full"
+                },
+                "approximate": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "from": {
+                    "type": "string"
+                },
+                "retention": {
+                    "description": "Set when from was moved forward to the click history kept on the\nplan of the link's owner",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RetentionNotice"
+                        }
+                    ]
+                },
+                "short_code": {
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "unique_visitors": {
+                    "type": "integer",
+                    "example": 3120
+                }
+            }
+        },
+        "models.UpdateLinkRequest": {
+            "type": "object",
+            "properties": {
+                "attribution_window_hours": {
+                    "description": "Attribution window of a split link's conversions in hours, 0 to follow\nthe owner's window again",
+                    "type": "integer",
+                    "maximum": 2160,
+                    "minimum": 0
+                },
+                "custom_alias": {
+                    "description": "Renames the link; the old short code keeps resolving for ALIAS_RENAME_GRACE",
+                    "type": "string",
+                    "example": "promo2025"
+                },
+                "expires_in": {
+                    "description": "in days from now, 0 removes the expiry",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "noindex": {
+                    "type": "boolean"
+                },
+                "redirect_headers": {
+                    "description": "Replaces the redirect headers, an empty object removes them",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "redirect_type": {
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        301,
+                        302,
+                        307
+                    ]
+                },
+                "rollout_percent": {
+                    "description": "Ramps a soft launched link, or soft launches a live one; visitors\nalready let through stay so as it grows, and 100 launches it fully",
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 0,
+                    "example": 50
+                },
+                "routing_rules": {
+                    "description": "Replaces the routing rules, an empty list removes them",
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "$ref": "#/definitions/models.RoutingRule"
+                    }
+                },
+                "tags": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "track_landings": {
+                    "description": "Turning landing tracking on starts counting landings from zero",
+                    "type": "boolean"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/new"
+                },
+                "utm_campaign": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "utm_medium": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "utm_source": {
+                    "description": "See ShortenRequest; an empty UTM parameter removes it and redirect\ntype 0 restores the default",
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "velocity_limit": {
+                    "description": "Replaces the velocity limit, clicks 0 removes it",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.VelocityLimit"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
+                "attribution_window_hours": {
+                    "description": "Hours after a click that conversions of the user's split links are\nattributed to it, unless a link sets its own; 0 for\nCONVERSION_ATTRIBUTION_WINDOW",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "plan": {
+                    "description": "Plan sets how long the click history of the user's links is kept\nand how many links they may create per month",
+                    "type": "string",
+                    "example": "free"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "two_factor_enabled": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.VariantRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "B"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "weight": {
+                    "description": "Share of traffic in weighted mode, relative to the other variants (default 1)",
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 1
+                }
+            }
+        },
+        "models.VariantStats": {
+            "type": "object",
+            "properties": {
+                "allocation": {
+                    "description": "Share of new visitors currently sent to the variant",
+                    "type": "number"
+                },
+                "attributed_conversions": {
+                    "description": "Conversions by visitors who clicked the link within the attribution\nwindow before, see VariantStatsResponse.AttributionWindowHours",
+                    "type": "integer"
+                },
+                "clicks": {
+                    "type": "integer"
+                },
+                "conversion_rate": {
+                    "type": "number"
+                },
+                "conversions": {
+                    "type": "integer"
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "pixel_url": {
+                    "description": "Load this from the variant's destination to record a conversion",
+                    "type": "string"
+                },
+                "probability_best": {
+                    "description": "Probability that the variant converts best, given the data so far",
+                    "type": "number"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.VariantStatsResponse": {
+            "type": "object",
+            "properties": {
+                "attribution_window_hours": {
+                    "description": "Hours after a click that conversions were attributed to it",
+                    "type": "integer",
+                    "example": 168
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "short_code": {
+                    "type": "string"
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.VariantStats"
+                    }
+                }
+            }
+        },
+        "models.VariantTotals": {
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "type": "integer"
+                },
+                "conversions": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.VelocityLimit": {
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "description": "Redirects allowed per window; 0 removes the limit in an update",
+                    "type": "integer",
+                    "maximum": 1000000,
+                    "minimum": 0,
+                    "example": 50
+                },
+                "overflow": {
+                    "description": "queue (default) shows a waiting page retrying on its own, reject\nanswers 429 Too Many Requests, redirect sends the visitor to\noverflow_url",
+                    "type": "string",
+                    "enum": [
+                        "queue",
+                        "reject",
+                        "redirect"
+                    ],
+                    "example": "queue"
+                },
+                "overflow_url": {
+                    "description": "for redirect only",
+                    "type": "string",
+                    "example": "https://status.example.com/busy"
+                },
+                "per": {
+                    "description": "second (default) or minute",
+                    "type": "string",
+                    "enum": [
+                        "second",
+                        "minute"
+                    ],
+                    "example": "second"
+                }
+            }
+        },
+        "models.VersionResponse": {
+            "type": "object",
+            "properties": {
+                "build_date": {
+                    "type": "string",
+                    "example": "2024-01-15T10:30:00Z"
+                },
+                "commit": {
+                    "type": "string"
+                },
+                "features": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "boolean"
+                    }
+                },
+                "go_version": {
+                    "type": "string",
+                    "example": "go1.23.0"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "v1.4.0"
+                }
+            }
+        },
+        "models.Webhook": {
+            "type": "object",
+            "properties": {
+                "click_threshold": {
+                    "description": "link.clicks fires each time a link's click_count reaches a multiple of\nthis, 0 when not subscribed",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebhookCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "click_threshold": {
+                    "description": "link.clicks fires each time a link's click_count reaches a multiple of\nthis, 0 when not subscribed",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebhookRequest": {
+            "type": "object",
+            "required": [
+                "events",
+                "url"
+            ],
+            "properties": {
+                "click_threshold": {
+                    "description": "Required with link.clicks, e.g. 100 to be told every 100 clicks",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 100
+                },
+                "events": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/links"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "AdminAuth": {
+            "description": "API key with the admin scope, or the ADMIN_TOKEN, sent as \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
+        "ApiKeyAuth": {
+            "description": "API key sent as \"Bearer usk_...\". Optional on public routes, where it applies the key's scopes and restrictions.",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
+        "SessionAuth": {
+            "description": "Dashboard session access token sent as \"Bearer uss_...\", obtained from /auth/login",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
+    }
+}`
+
+// SwaggerInfo holds exported Swagger Info so clients can modify it
+var SwaggerInfo = &swag.Spec{
+	Version:          "1.0",
+	Host:             "localhost:8080",
+	BasePath:         "/",
+	Schemes:          []string{"http", "https"},
+	Title:            "URL Shortener API",
+	Description:      "A simple URL shortener service built with Go and Gin",
+	InfoInstanceName: "swagger",
+	SwaggerTemplate:  docTemplate,
+	LeftDelim:        "{{",
+	RightDelim:       "}}",
+}
+
+func init() {
+	swag.Register(SwaggerInfo.InstanceName(), SwaggerInfo)
+}
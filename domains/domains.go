@@ -0,0 +1,91 @@
+// Package domains tracks destination domains whose ownership has been
+// verified, and checks the DNS records and meta tags that prove it. It also
+// knows the branded domains short links are served on.
+package domains
+
+import (
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/utils"
+)
+
+// How long loaded domains are reused before being reloaded from the database
+const domainsCacheTTL = time.Minute
+
+var (
+	mu       sync.RWMutex
+	verified []models.VerifiedDomain
+	loadedAt time.Time
+)
+
+// A lowercase host name with at least two labels
+var domainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+// Normalize validates a domain name, lowercasing it and dropping a
+// trailing dot
+func Normalize(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if !domainPattern.MatchString(domain) {
+		return "", errors.New("domain must be a host name such as example.com, without scheme or path")
+	}
+	return domain, nil
+}
+
+// Lookup returns the verified domain covering rawURL's host, or nil
+func Lookup(rawURL string) *models.VerifiedDomain {
+	for _, domain := range currentDomains() {
+		if utils.URLMatchesDomain(rawURL, domain.Domain) {
+			domain := domain
+			return &domain
+		}
+	}
+	return nil
+}
+
+// Verified reports whether rawURL points to a verified domain
+func Verified(rawURL string) bool {
+	return Lookup(rawURL) != nil
+}
+
+// SkipsApproval reports whether links to rawURL skip the approval required
+// of their creator's role. Review safety rules and abuse holds still apply.
+func SkipsApproval(rawURL string) bool {
+	domain := Lookup(rawURL)
+	return domain != nil && domain.SkipApproval
+}
+
+// Invalidate drops the cached domains so the next lookup reloads them
+func Invalidate() {
+	mu.Lock()
+	loadedAt = time.Time{}
+	mu.Unlock()
+}
+
+func currentDomains() []models.VerifiedDomain {
+	mu.RLock()
+	if time.Since(loadedAt) < domainsCacheTTL || database.DB == nil {
+		defer mu.RUnlock()
+		return verified
+	}
+	mu.RUnlock()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	var stored []models.VerifiedDomain
+	if err := database.DB.Where("verified_at IS NOT NULL").Order("length(domain) desc").Find(&stored).Error; err != nil {
+		slog.Error("Failed to load verified domains, using previous set", "error", err)
+		return verified
+	}
+
+	verified = stored
+	loadedAt = time.Now()
+	return verified
+}
@@ -0,0 +1,124 @@
+package domains
+
+import (
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/models"
+)
+
+var (
+	shortMu       sync.RWMutex
+	shortByHost   map[string]models.Domain
+	shortLoadedAt time.Time
+)
+
+// ShortDomain returns the branded domain serving short links on host, which
+// may carry a port, or nil when host is not one
+func ShortDomain(host string) *models.Domain {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	domain, ok := currentShortDomains()[host]
+	if !ok {
+		return nil
+	}
+	return &domain
+}
+
+// ShortDomainHosts returns the hosts of every branded domain
+func ShortDomainHosts() []string {
+	byHost := currentShortDomains()
+	hosts := make([]string, 0, len(byHost))
+	for host := range byHost {
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// Environment returns the environment of the links served on domain, nil
+// for the default one, whose environment is DEFAULT_DOMAIN_ENVIRONMENT
+func Environment(domain *models.Domain) string {
+	environment := os.Getenv("DEFAULT_DOMAIN_ENVIRONMENT")
+	if domain != nil {
+		environment = domain.Environment
+	}
+	if environment = strings.ToLower(environment); environment == "" {
+		return models.EnvironmentProduction
+	}
+	return environment
+}
+
+// InvalidateShortDomains reloads the branded domains after they changed,
+// replacing the copies cached in Redis and on every instance
+func InvalidateShortDomains() {
+	dropShortDomains()
+	if database.DB == nil {
+		return
+	}
+
+	var stored []models.Domain
+	if err := database.DB.Find(&stored).Error; err != nil {
+		slog.Error("Failed to reload short link domains", "error", err)
+		cache.DropShortDomains()
+		return
+	}
+	if err := cache.ReplaceShortDomains(stored); err != nil {
+		slog.Error("Failed to cache short link domains", "error", err)
+	}
+}
+
+// FollowShortDomainChanges drops this instance's branded domains whenever
+// another instance changes them. Call it before serving requests.
+func FollowShortDomainChanges() {
+	cache.OnShortDomainsChanged(dropShortDomains)
+}
+
+// dropShortDomains makes the next lookup reload the branded domains
+func dropShortDomains() {
+	shortMu.Lock()
+	shortLoadedAt = time.Time{}
+	shortMu.Unlock()
+}
+
+// currentShortDomains returns the branded domains by host, reloaded from
+// Redis once a minute, or from the database when Redis does not have them,
+// so redirects on any host never query the database
+func currentShortDomains() map[string]models.Domain {
+	shortMu.RLock()
+	if time.Since(shortLoadedAt) < domainsCacheTTL || database.DB == nil {
+		defer shortMu.RUnlock()
+		return shortByHost
+	}
+	shortMu.RUnlock()
+
+	shortMu.Lock()
+	defer shortMu.Unlock()
+	if time.Since(shortLoadedAt) < domainsCacheTTL {
+		return shortByHost
+	}
+
+	stored, err := cache.GetShortDomains()
+	if err != nil {
+		if err = database.DB.Find(&stored).Error; err != nil {
+			slog.Error("Failed to load short link domains, using previous set", "error", err)
+			return shortByHost
+		}
+		cache.FillShortDomains(stored)
+	}
+
+	byHost := make(map[string]models.Domain, len(stored))
+	for _, domain := range stored {
+		byHost[domain.Host] = domain
+	}
+	shortByHost = byHost
+	shortLoadedAt = time.Now()
+	return shortByHost
+}
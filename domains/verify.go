@@ -0,0 +1,113 @@
+package domains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"url-shortener/models"
+	"url-shortener/outbound"
+)
+
+// Name of the TXT record and meta tag carrying the verification token
+const verificationName = "url-shortener-verification"
+
+// ErrNotVerified is returned when the token was not found
+var ErrNotVerified = errors.New("verification token not found")
+
+var (
+	httpClient = outbound.NewClient(outbound.Options{Timeout: 10 * time.Second, MaxResponseBytes: 512 << 10})
+	resolver   = net.DefaultResolver
+
+	metaTagPattern = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	namePattern    = regexp.MustCompile(`(?i)\bname\s*=\s*["']?` + verificationName + `["'\s/>]`)
+	contentPattern = regexp.MustCompile(`(?i)\bcontent\s*=\s*["']([^"']*)["']`)
+)
+
+// DNSRecordName is the TXT record proving ownership of domain
+func DNSRecordName(domain string) string {
+	return "_" + verificationName + "." + domain
+}
+
+// DNSRecordValue is the TXT record value for token
+func DNSRecordValue(token string) string {
+	return verificationName + "=" + token
+}
+
+// MetaTag is the tag to add to the domain's home page for token
+func MetaTag(token string) string {
+	return fmt.Sprintf(`<meta name="%s" content="%s">`, verificationName, token)
+}
+
+// Check proves ownership of domain with method, returning ErrNotVerified
+// when the token is missing and other errors when the check could not run
+func Check(ctx context.Context, domain, token, method string) error {
+	switch method {
+	case models.DomainVerifyDNS:
+		return checkDNS(ctx, domain, token)
+	case models.DomainVerifyMeta:
+		return checkMetaTag(ctx, domain, token)
+	}
+	return fmt.Errorf("unknown verification method %q", method)
+}
+
+func checkDNS(ctx context.Context, domain, token string) error {
+	records, err := resolver.LookupTXT(ctx, DNSRecordName(domain))
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return ErrNotVerified
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if strings.TrimSpace(record) == DNSRecordValue(token) {
+			return nil
+		}
+	}
+	return ErrNotVerified
+}
+
+func checkMetaTag(ctx context.Context, domain, token string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+domain+"/", nil)
+	if err != nil {
+		return err
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("home page responded with status %d", response.StatusCode)
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if hasMetaTag(string(body), token) {
+		return nil
+	}
+	return ErrNotVerified
+}
+
+// hasMetaTag reports whether page carries the verification meta tag for token
+func hasMetaTag(page, token string) bool {
+	for _, tag := range metaTagPattern.FindAllString(page, -1) {
+		if !namePattern.MatchString(tag) {
+			continue
+		}
+		if content := contentPattern.FindStringSubmatch(tag); content != nil && strings.TrimSpace(content[1]) == token {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package domains
+
+import "testing"
+
+func TestHasMetaTag(t *testing.T) {
+	cases := map[string]bool{
+		`<head><meta name="url-shortener-verification" content="abc123"></head>`: true,
+		`<META content='abc123' NAME='url-shortener-verification' />`:            true,
+		"<meta\n  name=url-shortener-verification\n  content=\"abc123\">":        true,
+		`<meta name="url-shortener-verification" content="other">`:               false,
+		`<meta name="url-shortener-verification-old" content="abc123">`:          false,
+		`<meta name="description" content="abc123">`:                             false,
+		`<p>url-shortener-verification abc123</p>`:                               false,
+	}
+	for page, want := range cases {
+		if got := hasMetaTag(page, "abc123"); got != want {
+			t.Errorf("hasMetaTag(%q) = %v, want %v", page, got, want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	valid := map[string]string{
+		"Example.com":       "example.com",
+		" shop.example.io.": "shop.example.io",
+	}
+	for input, want := range valid {
+		if got, err := Normalize(input); err != nil || got != want {
+			t.Errorf("Normalize(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"", "localhost", "https://example.com", "example.com/path", "-bad.example.com"} {
+		if _, err := Normalize(input); err == nil {
+			t.Errorf("Normalize(%q) succeeded, want an error", input)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// Encrypted values are stored as prefix + base64(nonce + AES-GCM ciphertext)
+const encryptedPrefix = "enc:v1:"
+
+var aead cipher.AEAD
+
+// Init loads the AES-256 key from URL_ENCRYPTION_KEY (base64 encoded, 32 bytes)
+// and registers the "encrypted" GORM serializer. Without a key values are
+// stored in plaintext.
+func Init() {
+	schema.RegisterSerializer("encrypted", Serializer{})
+
+	encodedKey := os.Getenv("URL_ENCRYPTION_KEY")
+	if encodedKey == "" {
+		return
+	}
+
+	key, err := decodeKey(encodedKey)
+	if err != nil {
+		slog.Error("Invalid URL_ENCRYPTION_KEY", "error", err)
+		os.Exit(1)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		slog.Error("Failed to initialize encryption", "error", err)
+		os.Exit(1)
+	}
+	aead, err = cipher.NewGCM(block)
+	if err != nil {
+		slog.Error("Failed to initialize encryption", "error", err)
+		os.Exit(1)
+	}
+
+	slog.Info("Encryption at rest enabled for destination URLs")
+}
+
+// ValidateKey checks a URL_ENCRYPTION_KEY value without enabling encryption
+func ValidateKey(encodedKey string) error {
+	_, err := decodeKey(encodedKey)
+	return err
+}
+
+func decodeKey(encodedKey string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("URL_ENCRYPTION_KEY must be a base64 encoded 32 byte key")
+	}
+	return key, nil
+}
+
+// Enabled reports whether an encryption key is configured
+func Enabled() bool {
+	return aead != nil
+}
+
+// Encrypt encrypts plaintext, returning it unchanged when encryption is disabled
+func Encrypt(plaintext string) (string, error) {
+	if aead == nil {
+		return plaintext, nil
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt. Values without the encrypted prefix (stored
+// before encryption was enabled) are returned unchanged.
+func Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+	if aead == nil {
+		return "", errors.New("encrypted value found but URL_ENCRYPTION_KEY is not set")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Serializer encrypts string fields tagged `gorm:"serializer:encrypted"`
+type Serializer struct{}
+
+// Scan implements the GORM serializer interface
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("unsupported encrypted value type %T", dbValue)
+	}
+
+	plaintext, err := Decrypt(stored)
+	if err != nil {
+		return err
+	}
+	return field.Set(ctx, dst, plaintext)
+}
+
+// Value implements the GORM serializer interface
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encrypted serializer only supports strings, got %T", fieldValue)
+	}
+	return Encrypt(plaintext)
+}
@@ -0,0 +1,125 @@
+// Package expiry applies the link lifetime policy: a default expiry for
+// links created without one, and a maximum lifetime no link may exceed
+// unless an admin exempts it. Links created without an API key can be held
+// to a shorter lifetime of their own.
+package expiry
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy holds the link lifetimes in days; zero means none
+type Policy struct {
+	DefaultDays int
+	MaxDays     int
+	// Lifetime of links created without an API key, both their default
+	// and their maximum
+	AnonymousDays int
+}
+
+// ParsePolicy parses the default, maximum and anonymous lifetimes in days.
+// Any may be empty. The default and anonymous lifetimes may not exceed the
+// maximum.
+func ParsePolicy(defaultDays, maxDays, anonymousDays string) (Policy, error) {
+	var policy Policy
+	var err error
+	if policy.DefaultDays, err = parseDays(defaultDays); err != nil {
+		return Policy{}, fmt.Errorf("default lifetime: %w", err)
+	}
+	if policy.MaxDays, err = parseDays(maxDays); err != nil {
+		return Policy{}, fmt.Errorf("maximum lifetime: %w", err)
+	}
+	if policy.AnonymousDays, err = parseDays(anonymousDays); err != nil {
+		return Policy{}, fmt.Errorf("anonymous lifetime: %w", err)
+	}
+	if policy.MaxDays > 0 && policy.DefaultDays > policy.MaxDays {
+		return Policy{}, fmt.Errorf("default lifetime of %d days exceeds the maximum of %d days", policy.DefaultDays, policy.MaxDays)
+	}
+	if policy.MaxDays > 0 && policy.AnonymousDays > policy.MaxDays {
+		return Policy{}, fmt.Errorf("anonymous lifetime of %d days exceeds the maximum of %d days", policy.AnonymousDays, policy.MaxDays)
+	}
+	return policy, nil
+}
+
+func parseDays(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		return 0, fmt.Errorf("%q is not a non-negative number of days", value)
+	}
+	return days, nil
+}
+
+// PolicyFromEnv reads LINK_DEFAULT_EXPIRY_DAYS, LINK_MAX_EXPIRY_DAYS and
+// LINK_ANONYMOUS_EXPIRY_DAYS
+func PolicyFromEnv() (Policy, error) {
+	return ParsePolicy(os.Getenv("LINK_DEFAULT_EXPIRY_DAYS"), os.Getenv("LINK_MAX_EXPIRY_DAYS"), os.Getenv("LINK_ANONYMOUS_EXPIRY_DAYS"))
+}
+
+// Anonymous returns the policy for links created without an API key:
+// the anonymous lifetime is their default and maximum when set
+func (p Policy) Anonymous() Policy {
+	if p.AnonymousDays == 0 {
+		return p
+	}
+	return Policy{DefaultDays: p.AnonymousDays, MaxDays: p.AnonymousDays, AnonymousDays: p.AnonymousDays}
+}
+
+// Enforced reports whether links are held to a maximum lifetime
+func (p Policy) Enforced() bool {
+	return p.MaxDays > 0
+}
+
+// Check rejects a requested lifetime longer than the maximum
+func (p Policy) Check(expiresInDays int) error {
+	if p.Enforced() && expiresInDays > p.MaxDays {
+		return fmt.Errorf("expires_in may not exceed the maximum link lifetime of %d days", p.MaxDays)
+	}
+	return nil
+}
+
+// ExpiresAt returns when a link created at now expires, given the lifetime
+// requested in days (0 when none was), or nil when it never expires. Links
+// without a requested lifetime get the default, or else the maximum.
+func (p Policy) ExpiresAt(expiresInDays int, now time.Time) *time.Time {
+	days := expiresInDays
+	if days <= 0 {
+		days = p.DefaultDays
+	}
+	if days <= 0 || (p.Enforced() && days > p.MaxDays) {
+		days = p.MaxDays
+	}
+	if days <= 0 {
+		return nil
+	}
+	expiresAt := now.AddDate(0, 0, days)
+	return &expiresAt
+}
+
+// Links found past the maximum lifetime when it is enforced on existing
+// links, or when their exemption is removed, get this long before expiring
+const GracePeriod = 7 * 24 * time.Hour
+
+// Enforce returns the expiry of an existing link created at createdAt under
+// the maximum lifetime, or expiresAt unchanged when it is already within it.
+// Links past the maximum expire after GracePeriod rather than at once.
+func (p Policy) Enforce(createdAt time.Time, expiresAt *time.Time, now time.Time) *time.Time {
+	if !p.Enforced() {
+		return expiresAt
+	}
+	limit := createdAt.AddDate(0, 0, p.MaxDays)
+	if earliest := now.Add(GracePeriod); limit.Before(earliest) {
+		limit = earliest
+	}
+	if expiresAt != nil && !expiresAt.After(limit) {
+		return expiresAt
+	}
+	return &limit
+}
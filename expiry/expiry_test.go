@@ -0,0 +1,76 @@
+package expiry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	if _, err := ParsePolicy("400", "365", ""); err == nil {
+		t.Error("default above the maximum should be rejected")
+	}
+	if _, err := ParsePolicy("-1", "", ""); err == nil {
+		t.Error("negative lifetime should be rejected")
+	}
+	if _, err := ParsePolicy("", "365", "400"); err == nil {
+		t.Error("anonymous lifetime above the maximum should be rejected")
+	}
+	policy, err := ParsePolicy("", " 365 ", "30")
+	if err != nil || policy != (Policy{MaxDays: 365, AnonymousDays: 30}) {
+		t.Errorf("ParsePolicy() = %+v, %v", policy, err)
+	}
+}
+
+func TestExpiresAt(t *testing.T) {
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	days := func(n int) *time.Time {
+		expiresAt := now.AddDate(0, 0, n)
+		return &expiresAt
+	}
+	tests := []struct {
+		name      string
+		policy    Policy
+		expiresIn int
+		want      *time.Time
+	}{
+		{name: "no policy", policy: Policy{}, expiresIn: 0, want: nil},
+		{name: "requested", policy: Policy{}, expiresIn: 30, want: days(30)},
+		{name: "default", policy: Policy{DefaultDays: 90, MaxDays: 365}, expiresIn: 0, want: days(90)},
+		{name: "maximum without default", policy: Policy{MaxDays: 365}, expiresIn: 0, want: days(365)},
+		{name: "requested within maximum", policy: Policy{DefaultDays: 90, MaxDays: 365}, expiresIn: 7, want: days(7)},
+		{name: "capped", policy: Policy{MaxDays: 365}, expiresIn: 1000, want: days(365)},
+		{name: "anonymous default", policy: Policy{AnonymousDays: 30}.Anonymous(), expiresIn: 0, want: days(30)},
+		{name: "anonymous capped", policy: Policy{DefaultDays: 90, AnonymousDays: 30}.Anonymous(), expiresIn: 90, want: days(30)},
+		{name: "anonymous unset", policy: Policy{DefaultDays: 90}.Anonymous(), expiresIn: 0, want: days(90)},
+	}
+	for _, tt := range tests {
+		got := tt.policy.ExpiresAt(tt.expiresIn, now)
+		if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+			t.Errorf("%s: ExpiresAt() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if err := (Policy{MaxDays: 365}).Check(366); err == nil {
+		t.Error("Check should reject a lifetime above the maximum")
+	}
+}
+
+func TestEnforce(t *testing.T) {
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	policy := Policy{MaxDays: 365}
+	soon := now.AddDate(0, 0, 30)
+
+	if got := policy.Enforce(now, &soon, now); got != &soon {
+		t.Errorf("expiry within the maximum changed to %v", got)
+	}
+	if got := policy.Enforce(now, nil, now); got == nil || !got.Equal(now.AddDate(0, 0, 365)) {
+		t.Errorf("link without expiry got %v, want the maximum lifetime", got)
+	}
+	old := now.AddDate(-2, 0, 0)
+	if got := policy.Enforce(old, nil, now); got == nil || !got.Equal(now.Add(GracePeriod)) {
+		t.Errorf("link past the maximum got %v, want the grace period", got)
+	}
+	if got := (Policy{}).Enforce(old, nil, now); got != nil {
+		t.Errorf("no maximum should leave the expiry alone, got %v", got)
+	}
+}
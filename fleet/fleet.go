@@ -0,0 +1,116 @@
+// Package fleet aggregates the in-memory metrics of every replica through
+// Redis, so request and query statistics can reflect the whole deployment
+// rather than the instance answering. Each instance publishes a snapshot of
+// its counts periodically; readers merge the snapshots still fresh.
+package fleet
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"url-shortener/buildinfo"
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/middleware"
+)
+
+// Snapshots are published this often unless METRICS_PUBLISH_INTERVAL is set
+const defaultPublishInterval = 15 * time.Second
+
+// Snapshots missing this many publications are ignored
+const missedPublications = 3
+
+// Snapshot is the metrics an instance publishes
+type Snapshot struct {
+	Instance    string                        `json:"instance"`
+	PublishedAt time.Time                     `json:"published_at"`
+	Since       time.Time                     `json:"since"`
+	Endpoints   []middleware.EndpointSnapshot `json:"endpoints"`
+	Queries     []database.QueryStats         `json:"queries"`
+}
+
+// Enabled reports whether METRICS_AGGREGATION is on and Redis is available
+func Enabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("METRICS_AGGREGATION"))
+	return enabled && cache.RedisClient != nil
+}
+
+// PublishInterval returns METRICS_PUBLISH_INTERVAL, at least one second
+func PublishInterval() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("METRICS_PUBLISH_INTERVAL")); err == nil && value >= time.Second {
+		return value
+	}
+	return defaultPublishInterval
+}
+
+// Local returns this instance's current snapshot
+func Local(now time.Time) Snapshot {
+	return Snapshot{
+		Instance:    buildinfo.Instance,
+		PublishedAt: now.UTC(),
+		Since:       middleware.MetricsSince(),
+		Endpoints:   middleware.EndpointSnapshots(now),
+		Queries:     database.QueryMetrics(),
+	}
+}
+
+// Publish stores this instance's current snapshot in Redis
+func Publish(now time.Time) error {
+	body, err := json.Marshal(Local(now))
+	if err != nil {
+		return err
+	}
+	return cache.PublishInstanceMetrics(buildinfo.Instance, body, missedPublications*PublishInterval())
+}
+
+// Collect returns the snapshots of every instance, with this instance's
+// current counts in place of the one it last published, sorted by instance
+func Collect(now time.Time) ([]Snapshot, error) {
+	published, err := cache.InstanceMetricsSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []Snapshot{Local(now)}
+	for _, body := range published {
+		var snapshot Snapshot
+		if err := json.Unmarshal(body, &snapshot); err != nil || snapshot.Instance == buildinfo.Instance {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Instance < snapshots[j].Instance })
+	return snapshots, nil
+}
+
+// Merge adds up the counts of several snapshots
+func Merge(snapshots []Snapshot) Snapshot {
+	var merged Snapshot
+	endpoints := make([][]middleware.EndpointSnapshot, 0, len(snapshots))
+	queries := make([][]database.QueryStats, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		if merged.Since.IsZero() || snapshot.Since.Before(merged.Since) {
+			merged.Since = snapshot.Since
+		}
+		if snapshot.PublishedAt.After(merged.PublishedAt) {
+			merged.PublishedAt = snapshot.PublishedAt
+		}
+		endpoints = append(endpoints, snapshot.Endpoints)
+		queries = append(queries, snapshot.Queries)
+	}
+	merged.Endpoints = middleware.MergeEndpointSnapshots(endpoints...)
+	merged.Queries = database.MergeQueryStats(queries...)
+	return merged
+}
+
+// Instances lists the instances of snapshots
+func Instances(snapshots []Snapshot) []string {
+	instances := make([]string, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		instances = append(instances, snapshot.Instance)
+	}
+	return instances
+}
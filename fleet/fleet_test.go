@@ -0,0 +1,61 @@
+package fleet
+
+import (
+	"testing"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+)
+
+func TestMerge(t *testing.T) {
+	started := time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC)
+	redirects := func(requests, errors int64, latency []int64) []middleware.EndpointSnapshot {
+		return []middleware.EndpointSnapshot{{
+			Method:  "GET",
+			Route:   "/:shortCode",
+			Windows: []middleware.WindowSnapshot{{Window: "1h", Requests: requests, Errors: errors, Latency: latency}},
+		}}
+	}
+	latency := func(fast, slow int64) []int64 {
+		counts := make([]int64, 12)
+		counts[0], counts[3] = fast, slow
+		return counts
+	}
+
+	merged := Merge([]Snapshot{
+		{
+			Instance:  "a",
+			Since:     started.Add(time.Hour),
+			Endpoints: redirects(90, 0, latency(90, 0)),
+			Queries:   []database.QueryStats{{Operation: "query", Table: "urls", Count: 3, TotalMs: 30, MaxMs: 20}},
+		},
+		{
+			Instance:  "b",
+			Since:     started,
+			Endpoints: redirects(10, 5, latency(0, 10)),
+			Queries:   []database.QueryStats{{Operation: "query", Table: "urls", Count: 1, TotalMs: 50, MaxMs: 50}},
+		},
+	})
+
+	if !merged.Since.Equal(started) {
+		t.Errorf("Since = %v, want the earliest instance start", merged.Since)
+	}
+	if len(merged.Endpoints) != 1 {
+		t.Fatalf("got %d endpoints, want 1", len(merged.Endpoints))
+	}
+	status := middleware.SummarizeEndpoints(merged.Endpoints)[0].Windows[0]
+	if status.Requests != 100 || status.Errors != 5 || status.Availability != 95 {
+		t.Errorf("merged window = %+v, want 100 requests, 5 errors, 95%% available", status)
+	}
+	if status.LatencyP50Ms != 5 || status.LatencyP95Ms != 50 {
+		t.Errorf("latency p50 = %v, p95 = %v, want 5 and 50", status.LatencyP50Ms, status.LatencyP95Ms)
+	}
+
+	if len(merged.Queries) != 1 {
+		t.Fatalf("got %d query stats, want 1", len(merged.Queries))
+	}
+	if q := merged.Queries[0]; q.Count != 4 || q.TotalMs != 80 || q.MaxMs != 50 || q.AvgMs != 20 {
+		t.Errorf("merged queries = %+v", q)
+	}
+}
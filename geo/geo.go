@@ -0,0 +1,51 @@
+// Package geo locates visitors from the headers a CDN or load balancer adds
+// and coarsens locations to the precision allowed for the visitor's country,
+// so analytics never store more than regional privacy rules permit.
+package geo
+
+import (
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Location is where a visitor is, as precise as known or allowed. Region is
+// the provider's subdivision code, e.g. "CA" for California.
+type Location struct {
+	Country string
+	Region  string
+	City    string
+}
+
+// Location headers per provider, set by GEO_HEADERS. They are only trusted
+// when the service runs behind that provider, as clients can set them too.
+var providerHeaders = map[string]struct{ country, region, city string }{
+	"cloudflare": {"CF-IPCountry", "CF-Region-Code", "CF-IPCity"},
+	"cloudfront": {"CloudFront-Viewer-Country", "CloudFront-Viewer-Country-Region", "CloudFront-Viewer-City"},
+	"appengine":  {"X-AppEngine-Country", "X-AppEngine-Region", "X-AppEngine-City"},
+}
+
+// Providers lists the supported GEO_HEADERS values
+func Providers() []string {
+	return []string{"cloudflare", "cloudfront", "appengine"}
+}
+
+// FromRequest reads the visitor's location from the headers of the
+// GEO_HEADERS provider, returning an empty location when none is configured
+func FromRequest(r *http.Request) Location {
+	headers, ok := providerHeaders[strings.ToLower(os.Getenv("GEO_HEADERS"))]
+	if !ok {
+		return Location{}
+	}
+
+	country := strings.ToUpper(strings.TrimSpace(r.Header.Get(headers.country)))
+	// Providers report unknown countries and Tor exits with placeholder codes
+	if len(country) != 2 || country == "XX" || country == "ZZ" || country == "T1" {
+		return Location{}
+	}
+	return Location{
+		Country: country,
+		Region:  strings.TrimSpace(r.Header.Get(headers.region)),
+		City:    strings.TrimSpace(r.Header.Get(headers.city)),
+	}
+}
@@ -0,0 +1,98 @@
+package geo
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("city", "EU=country, DE=region,CN=none")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]Precision{
+		"US": PrecisionCity,
+		"FR": PrecisionCountry,
+		"DE": PrecisionRegion,
+		"CN": PrecisionNone,
+		"NO": PrecisionCity,
+	}
+	for country, want := range cases {
+		if got := policy.PrecisionFor(country); got != want {
+			t.Errorf("PrecisionFor(%s) = %s, want %s", country, got, want)
+		}
+	}
+}
+
+func TestParsePolicyDefaultsToCountry(t *testing.T) {
+	policy, err := ParsePolicy("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Default != PrecisionCountry {
+		t.Errorf("Default = %s, want country", policy.Default)
+	}
+}
+
+func TestParsePolicyRejectsInvalid(t *testing.T) {
+	for _, zones := range []string{"EU", "EU=street", "USA=city"} {
+		if _, err := ParsePolicy("", zones); err == nil {
+			t.Errorf("ParsePolicy(%q) succeeded, want an error", zones)
+		}
+	}
+	if _, err := ParsePolicy("street", ""); err == nil {
+		t.Error("ParsePolicy accepted an unknown default precision")
+	}
+}
+
+func TestCoarsen(t *testing.T) {
+	policy, _ := ParsePolicy("city", "GB=region,FR=country,CN=none")
+
+	cases := []struct {
+		in, want Location
+	}{
+		{Location{"US", "CA", "San Francisco"}, Location{"US", "CA", "San Francisco"}},
+		{Location{"GB", "ENG", "London"}, Location{"GB", "ENG", ""}},
+		{Location{"FR", "IDF", "Paris"}, Location{Country: "FR"}},
+		{Location{"CN", "BJ", "Beijing"}, Location{}},
+	}
+	for _, tc := range cases {
+		if got := policy.Coarsen(tc.in); got != tc.want {
+			t.Errorf("Coarsen(%+v) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestCountriesBelow(t *testing.T) {
+	policy, _ := ParsePolicy("country", "US=city,GB=region,CN=none")
+
+	countries, defaultBelow := policy.CountriesBelow(PrecisionRegion)
+	if !defaultBelow {
+		t.Error("default country precision should be below region")
+	}
+	if len(countries) != 1 || countries[0] != "CN" {
+		t.Errorf("countries below region = %v, want [CN]", countries)
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/abc", nil)
+	r.Header.Set("CF-IPCountry", "de")
+	r.Header.Set("CF-Region-Code", "BE")
+	r.Header.Set("CF-IPCity", "Berlin")
+
+	if got := FromRequest(r); got != (Location{}) {
+		t.Errorf("FromRequest without GEO_HEADERS = %+v, want empty", got)
+	}
+
+	t.Setenv("GEO_HEADERS", "cloudflare")
+	if got, want := FromRequest(r), (Location{"DE", "BE", "Berlin"}); got != want {
+		t.Errorf("FromRequest = %+v, want %+v", got, want)
+	}
+
+	r.Header.Set("CF-IPCountry", "XX")
+	if got := FromRequest(r); got != (Location{}) {
+		t.Errorf("FromRequest with unknown country = %+v, want empty", got)
+	}
+}
@@ -0,0 +1,144 @@
+package geo
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Precision is how much of a location may be stored
+type Precision int
+
+// Precisions from least to most precise
+const (
+	PrecisionNone Precision = iota
+	PrecisionCountry
+	PrecisionRegion
+	PrecisionCity
+)
+
+var precisionNames = map[string]Precision{
+	"none":    PrecisionNone,
+	"country": PrecisionCountry,
+	"region":  PrecisionRegion,
+	"city":    PrecisionCity,
+}
+
+func (p Precision) String() string {
+	for name, precision := range precisionNames {
+		if precision == p {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// ParsePrecision parses none, country, region or city
+func ParsePrecision(value string) (Precision, error) {
+	precision, ok := precisionNames[strings.ToLower(strings.TrimSpace(value))]
+	if !ok {
+		return 0, fmt.Errorf("unknown precision %q, expected none, country, region or city", value)
+	}
+	return precision, nil
+}
+
+// Zone aliases usable in CLICK_GEO_ZONES
+var zoneAliases = map[string][]string{
+	"EU": {"AT", "BE", "BG", "HR", "CY", "CZ", "DK", "EE", "FI", "FR", "DE", "GR", "HU", "IE", "IT",
+		"LV", "LT", "LU", "MT", "NL", "PL", "PT", "RO", "SK", "SI", "ES", "SE"},
+	"EEA": {"AT", "BE", "BG", "HR", "CY", "CZ", "DK", "EE", "FI", "FR", "DE", "GR", "HU", "IE", "IT",
+		"LV", "LT", "LU", "MT", "NL", "PL", "PT", "RO", "SK", "SI", "ES", "SE", "IS", "LI", "NO"},
+}
+
+// Policy is the location precision allowed for visitors from each country
+type Policy struct {
+	Default   Precision
+	Countries map[string]Precision
+}
+
+// ParsePolicy builds a policy from a default precision and zones such as
+// "EEA=country,GB=region,CN=none", where a zone is a country code or the
+// EU or EEA alias. Country codes override aliases regardless of order.
+func ParsePolicy(defaultPrecision, zones string) (Policy, error) {
+	policy := Policy{Default: PrecisionCountry, Countries: make(map[string]Precision)}
+	if defaultPrecision != "" {
+		precision, err := ParsePrecision(defaultPrecision)
+		if err != nil {
+			return policy, err
+		}
+		policy.Default = precision
+	}
+
+	countries := make(map[string]Precision)
+	for _, entry := range strings.Split(zones, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		zone, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return policy, fmt.Errorf("invalid zone %q, expected <zone>=<precision>", entry)
+		}
+		precision, err := ParsePrecision(value)
+		if err != nil {
+			return policy, err
+		}
+
+		zone = strings.ToUpper(strings.TrimSpace(zone))
+		if members, ok := zoneAliases[zone]; ok {
+			for _, country := range members {
+				if _, set := policy.Countries[country]; !set {
+					policy.Countries[country] = precision
+				}
+			}
+			continue
+		}
+		if len(zone) != 2 {
+			return policy, fmt.Errorf("invalid zone %q, expected a country code, EU or EEA", zone)
+		}
+		countries[zone] = precision
+	}
+	for country, precision := range countries {
+		policy.Countries[country] = precision
+	}
+	return policy, nil
+}
+
+// PolicyFromEnv reads CLICK_GEO_PRECISION (default country) and
+// CLICK_GEO_ZONES
+func PolicyFromEnv() (Policy, error) {
+	return ParsePolicy(os.Getenv("CLICK_GEO_PRECISION"), os.Getenv("CLICK_GEO_ZONES"))
+}
+
+// PrecisionFor returns the precision allowed for visitors from country
+func (p Policy) PrecisionFor(country string) Precision {
+	if precision, ok := p.Countries[country]; ok {
+		return precision
+	}
+	return p.Default
+}
+
+// Coarsen drops the parts of location beyond the allowed precision
+func (p Policy) Coarsen(location Location) Location {
+	switch p.PrecisionFor(location.Country) {
+	case PrecisionNone:
+		return Location{}
+	case PrecisionCountry:
+		return Location{Country: location.Country}
+	case PrecisionRegion:
+		return Location{Country: location.Country, Region: location.Region}
+	}
+	return location
+}
+
+// CountriesBelow groups the countries whose precision is below precision,
+// sorted, and reports whether the default precision is below it too
+func (p Policy) CountriesBelow(precision Precision) (countries []string, defaultBelow bool) {
+	for country, allowed := range p.Countries {
+		if allowed < precision {
+			countries = append(countries, country)
+		}
+	}
+	sort.Strings(countries)
+	return countries, p.Default < precision
+}
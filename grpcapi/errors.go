@@ -0,0 +1,54 @@
+package grpcapi
+
+import (
+	"net/http"
+
+	"url-shortener/middleware"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain identifies the service in ErrorInfo details
+const errorDomain = "url-shortener"
+
+// statusFor converts an error of the service layer to a gRPC status error,
+// keeping the REST error code as the reason of an ErrorInfo detail
+func statusFor(err error) error {
+	apiErr := middleware.APIErrorFor(err)
+	st := status.New(codeFor(apiErr.Status), apiErr.Message)
+	info := &errdetails.ErrorInfo{Reason: string(apiErr.Code), Domain: errorDomain}
+	if apiErr.ShortCode != "" {
+		info.Metadata = map[string]string{"short_code": apiErr.ShortCode}
+	}
+	if detailed, detailErr := st.WithDetails(info); detailErr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// codeFor maps the HTTP status of an API error to the closest gRPC code
+func codeFor(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusGone, http.StatusLoopDetected:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	return codes.Internal
+}
@@ -0,0 +1,114 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"url-shortener/logging"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/policy"
+	"url-shortener/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type callerKey struct{}
+
+// currentCaller returns the caller authenticate attached to the call
+func currentCaller(ctx context.Context) service.Caller {
+	caller, _ := ctx.Value(callerKey{}).(service.Caller)
+	return caller
+}
+
+// recoverPanics turns a panicking handler into an Internal error, like
+// gin's recovery middleware does for REST requests
+func recoverPanics(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			slog.ErrorContext(ctx, "gRPC handler panicked", "method", info.FullMethod, "panic", recovered, "stack", string(debug.Stack()))
+			err = statusFor(models.ErrInternal)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// logCalls identifies each call by the x-request-id it came with, or a new
+// random one, and logs a line per call like middleware.RequestLog: failed
+// calls as warnings, and internal errors as errors
+func logCalls(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	id := middleware.NewRequestID(firstMetadata(ctx, strings.ToLower(middleware.RequestIDHeader)))
+	ctx = logging.WithRequestID(ctx, id)
+	grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(middleware.RequestIDHeader), id))
+
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK:
+	case codes.Internal, codes.Unknown:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	attrs := []slog.Attr{
+		slog.String("surface", "grpc"),
+		slog.String("method", info.FullMethod),
+		slog.String("code", code.String()),
+		slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+		slog.String("client_ip", clientIP(ctx)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+	slog.LogAttrs(ctx, level, "request", attrs...)
+	return resp, err
+}
+
+// authenticate attaches the caller to the call, authenticated by an
+// "authorization: Bearer usk_..." metadata entry like REST requests. Calls
+// without a key continue anonymously; invalid keys are rejected.
+func authenticate(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ip := clientIP(ctx)
+	caller := service.Caller{Policy: policy.Load(ip), ClientIP: ip}
+
+	if authorization := firstMetadata(ctx, "authorization"); strings.HasPrefix(authorization, "Bearer "+middleware.APIKeyPrefix) {
+		caller.APIKey = middleware.AuthenticateAPIKey(ctx, strings.TrimPrefix(authorization, "Bearer "))
+		if caller.APIKey == nil {
+			return nil, statusFor(models.NewAPIError(http.StatusUnauthorized, models.ErrCodeUnauthorized, "Invalid API key"))
+		}
+	}
+
+	return handler(context.WithValue(ctx, callerKey{}, caller), req)
+}
+
+// firstMetadata returns the first value of an incoming metadata key
+func firstMetadata(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// clientIP returns the address of the peer making the call
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return host
+	}
+	return p.Addr.String()
+}
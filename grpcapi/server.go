@@ -0,0 +1,196 @@
+// Package grpcapi serves the gRPC API defined in
+// proto/shortener/v1/shortener.proto. Its handlers translate messages to and
+// from the models of the REST API and call the same service functions, so
+// both APIs apply the same checks; failures carry the REST error code as the
+// reason of a google.rpc.ErrorInfo detail.
+package grpcapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/grpcapi/shortenerv1"
+	"url-shortener/models"
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// baseURL is BASE_URL, set by ConfigureBaseURL
+var baseURL string
+
+// ConfigureBaseURL sets BASE_URL, the address short links are built on
+// unless they are on a branded domain. Without it, responses only carry the
+// short URL of links on branded domains.
+func ConfigureBaseURL(url string) {
+	baseURL = url
+}
+
+// NewServer returns a gRPC server serving the Shortener service
+func NewServer() *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(recoverPanics, logCalls, authenticate))
+	shortenerv1.RegisterShortenerServer(server, shortener{})
+	return server
+}
+
+type shortener struct {
+	shortenerv1.UnimplementedShortenerServer
+}
+
+// Shorten creates a short link, like POST /shorten
+func (shortener) Shorten(ctx context.Context, in *shortenerv1.ShortenRequest) (*shortenerv1.ShortenResponse, error) {
+	caller := currentCaller(ctx)
+	if caller.APIKey == nil && !caller.Policy.AnonymousShorten {
+		return nil, statusFor(models.NewAPIError(http.StatusUnauthorized, models.ErrCodeUnauthorized, "An API key is required to shorten URLs"))
+	}
+	if err := requireScope(caller, models.ScopeCreate); err != nil {
+		return nil, err
+	}
+
+	request := models.ShortenRequest{
+		URL:            in.GetUrl(),
+		ExpiresIn:      int(in.GetExpiresInDays()),
+		CustomAlias:    in.GetCustomAlias(),
+		Tags:           in.GetTags(),
+		CodeStyle:      in.GetCodeStyle(),
+		IfExists:       in.GetIfExists(),
+		NoDedup:        in.GetNoDedup(),
+		Domain:         strings.ToLower(in.GetDomain()),
+		MaxClicks:      optionalInt(in.MaxClicks),
+		RedirectType:   int(in.GetRedirectType()),
+		RolloutPercent: optionalInt(in.RolloutPercent),
+	}
+	if err := binding.Validator.ValidateStruct(&request); err != nil {
+		return nil, statusFor(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+	}
+
+	urlRecord, created, err := service.Default().Shorten(ctx, caller, request)
+	if err != nil {
+		return nil, statusFor(err)
+	}
+
+	host, shortCode := models.SplitLinkKey(urlRecord.ShortCode)
+	return &shortenerv1.ShortenResponse{
+		ShortUrl:     shortURL(urlRecord.ShortCode),
+		ShortCode:    shortCode,
+		Domain:       host,
+		OriginalUrl:  urlRecord.OriginalURL,
+		ExpiresAt:    timestamp(urlRecord.ExpiresAt),
+		Status:       urlRecord.Status,
+		Created:      created,
+		RedirectType: int32(cache.NewRedirectEntry(urlRecord).StatusCode),
+	}, nil
+}
+
+// Resolve returns where a short link leads, like GET /{shortCode}?info=1
+func (shortener) Resolve(ctx context.Context, in *shortenerv1.ResolveRequest) (*shortenerv1.ResolveResponse, error) {
+	if in.GetShortCode() == "" {
+		return nil, statusFor(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "short_code is required"))
+	}
+
+	entry, err := service.Default().Resolve(ctx, linkKey(in.GetDomain(), in.GetShortCode()))
+	if err != nil {
+		return nil, statusFor(err)
+	}
+
+	response := &shortenerv1.ResolveResponse{
+		Destination: entry.Target(entry.Destination),
+		StatusCode:  int32(entry.StatusCode),
+	}
+	if entry.ExpiresAt != 0 {
+		response.ExpiresAt = timestamppb.New(time.Unix(entry.ExpiresAt, 0))
+	}
+	return response, nil
+}
+
+// GetStats returns the stats of a short link, like GET /stats/{shortCode}
+func (shortener) GetStats(ctx context.Context, in *shortenerv1.GetStatsRequest) (*shortenerv1.Stats, error) {
+	if err := requireScope(currentCaller(ctx), models.ScopeReadStats); err != nil {
+		return nil, err
+	}
+	if in.GetShortCode() == "" {
+		return nil, statusFor(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "short_code is required"))
+	}
+
+	maxAge := service.DefaultStatsMaxAge
+	if in.GetMaxAgeSeconds() != 0 {
+		maxAge = time.Duration(in.GetMaxAgeSeconds()) * time.Second
+	}
+	if maxAge < 0 || maxAge > service.MaxStatsMaxAge {
+		return nil, statusFor(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "max_age_seconds must be between 0 and 300"))
+	}
+
+	stats, err := service.Default().Stats(ctx, linkKey(in.GetDomain(), in.GetShortCode()), maxAge)
+	if err != nil {
+		return nil, statusFor(service.LinkLookupError(err))
+	}
+
+	_, shortCode := models.SplitLinkKey(stats.ShortCode)
+	return &shortenerv1.Stats{
+		OriginalUrl:     stats.OriginalURL,
+		ShortCode:       shortCode,
+		ClickCount:      int64(stats.ClickCount),
+		CreatedAt:       timestamppb.New(stats.CreatedAt),
+		ExpiresAt:       timestamp(stats.ExpiresAt),
+		Verified:        stats.Verified,
+		Analytics:       stats.Analytics,
+		MaxClicks:       optionalInt32(stats.MaxClicks),
+		ClicksRemaining: optionalInt32(stats.ClicksRemaining),
+	}, nil
+}
+
+// requireScope rejects callers authenticated with an API key lacking scope,
+// like middleware.RequireScope
+func requireScope(caller service.Caller, scope string) error {
+	if caller.APIKey != nil && !caller.APIKey.HasScope(scope) {
+		return statusFor(models.NewAPIError(http.StatusForbidden, models.ErrCodeScopeMissing, "API key lacks the "+scope+" scope"))
+	}
+	return nil
+}
+
+// linkKey returns the key of the link shortCode on the branded domain, or
+// on the default one when domain is empty
+func linkKey(domain, shortCode string) string {
+	return models.LinkKey(strings.ToLower(domain), shortCode)
+}
+
+// shortURL returns the public URL of a link, empty when it is on the
+// default domain and BASE_URL is not set
+func shortURL(key string) string {
+	host, code := models.SplitLinkKey(key)
+	switch {
+	case host != "":
+		return "https://" + host + "/" + code
+	case baseURL != "":
+		return strings.TrimRight(baseURL, "/") + "/" + code
+	}
+	return ""
+}
+
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func optionalInt(value *int32) *int {
+	if value == nil {
+		return nil
+	}
+	converted := int(*value)
+	return &converted
+}
+
+func optionalInt32(value *int) *int32 {
+	if value == nil {
+		return nil
+	}
+	converted := int32(*value)
+	return &converted
+}
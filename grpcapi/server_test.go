@@ -0,0 +1,123 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"url-shortener/grpcapi/shortenerv1"
+	"url-shortener/models"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient serves the API in memory and returns a client calling it
+func newTestClient(t *testing.T) shortenerv1.ShortenerClient {
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer()
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return shortenerv1.NewShortenerClient(conn)
+}
+
+// assertError checks the code of a failed call and the REST error code it
+// carries as the reason of its ErrorInfo
+func assertError(t *testing.T, err error, wantCode codes.Code, wantReason models.ErrorCode) {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != wantCode {
+		t.Fatalf("code = %v (%s), want %v", st.Code(), st.Message(), wantCode)
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			if info.Reason != string(wantReason) {
+				t.Errorf("reason = %q, want %q", info.Reason, wantReason)
+			}
+			return
+		}
+	}
+	t.Errorf("no ErrorInfo detail in %v", st.Details())
+}
+
+func TestShortenRejectsAnonymousCallersWhenDisabled(t *testing.T) {
+	t.Setenv("ALLOW_ANONYMOUS_SHORTEN", "false")
+	client := newTestClient(t)
+
+	_, err := client.Shorten(context.Background(), &shortenerv1.ShortenRequest{Url: "https://example.com"})
+	assertError(t, err, codes.Unauthenticated, models.ErrCodeUnauthorized)
+}
+
+func TestShortenValidatesLikeREST(t *testing.T) {
+	client := newTestClient(t)
+
+	tests := []struct {
+		name    string
+		request *shortenerv1.ShortenRequest
+		reason  models.ErrorCode
+	}{
+		{"missing url", &shortenerv1.ShortenRequest{}, models.ErrCodeInvalidRequest},
+		{"unknown code style", &shortenerv1.ShortenRequest{Url: "https://example.com", CodeStyle: "emoji"}, models.ErrCodeInvalidRequest},
+		{"relative url", &shortenerv1.ShortenRequest{Url: "/not/absolute"}, models.ErrCodeURLInvalid},
+		{"reserved alias", &shortenerv1.ShortenRequest{Url: "https://example.com", CustomAlias: "stats"}, models.ErrCodeInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.Shorten(context.Background(), tt.request)
+			assertError(t, err, codes.InvalidArgument, tt.reason)
+		})
+	}
+}
+
+func TestGetStatsRejectsLongMaxAge(t *testing.T) {
+	client := newTestClient(t)
+
+	_, err := client.GetStats(context.Background(), &shortenerv1.GetStatsRequest{ShortCode: "abc123", MaxAgeSeconds: 301})
+	assertError(t, err, codes.InvalidArgument, models.ErrCodeInvalidRequest)
+}
+
+func TestCallsEchoRequestID(t *testing.T) {
+	client := newTestClient(t)
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "trace-42")
+	client.Resolve(ctx, &shortenerv1.ResolveRequest{}, grpc.Header(&header))
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "trace-42" {
+		t.Errorf("x-request-id = %q, want the client's", got)
+	}
+}
+
+func TestCodeFor(t *testing.T) {
+	tests := []struct {
+		err  *models.APIError
+		want codes.Code
+	}{
+		{models.ErrURLInvalid, codes.InvalidArgument},
+		{models.ErrLinkNotFound, codes.NotFound},
+		{models.ErrLinkPending, codes.PermissionDenied},
+		{models.ErrLinkExpired, codes.FailedPrecondition},
+		{models.ErrLinkLoop, codes.FailedPrecondition},
+		{models.ErrAliasTaken, codes.AlreadyExists},
+		{models.ErrLinkNotLaunched, codes.Unavailable},
+		{models.ErrTimeout, codes.DeadlineExceeded},
+		{models.ErrInternal, codes.Internal},
+	}
+	for _, tt := range tests {
+		if got := codeFor(tt.err.Status); got != tt.want {
+			t.Errorf("codeFor(%d) for %s = %v, want %v", tt.err.Status, tt.err.Code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"url-shortener/abuse"
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Abuse scores and reports listed by default, and at most
+const (
+	defaultAbuseListLimit = 100
+	maxAbuseListLimit     = 1000
+)
+
+// ReportLink godoc
+// @Summary Report an abusive short link
+// @ID reportLink
+// @Description Report a short link as abusive, such as phishing or spam. Each visitor's report of a link is counted once against the link's creator, raising its abuse score when ABUSE_SCORING is enabled, and kept for admins to review. The response does not tell whether the visitor had reported the link already.
+// @Tags URL Shortener
+// @Accept json
+// @Param shortCode path string true "Short code"
+// @Param request body models.AbuseReportRequest false "Why the link is abusive"
+// @Success 204 "Report received"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /{shortCode}/report [post]
+func ReportLink(c *gin.Context) {
+	var request models.AbuseReportRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	shortCode := hostLinkKey(c, c.Param("shortCode"))
+	var urlRecord models.URL
+	if err := database.DB.WithContext(ctx).Where("short_code = ?", shortCode).First(&urlRecord).Error; err != nil || urlRecord.Inert {
+		c.Error(models.ErrLinkNotFound)
+		return
+	}
+
+	creator := abuse.LinkCreator(&urlRecord)
+	report := models.AbuseReport{
+		ShortCode:    urlRecord.ShortCode,
+		Creator:      creator,
+		Reason:       request.Reason,
+		ReporterHash: utils.HashToken(urlRecord.ShortCode + "|" + c.ClientIP()),
+	}
+	created, err := database.CreateAbuseReport(ctx, &report)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to store the report"))
+		return
+	}
+	if created {
+		abuse.Record(ctx, creator, abuse.SignalReport)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListAbuseReports godoc
+// @Summary List abuse reports
+// @ID listAbuseReports
+// @Description List the latest reports of abusive links by visitors, optionally only those against one creator
+// @Tags Admin
+// @Produce json
+// @Param creator query string false "Creator the reported links count against, user:<id> or ip:<address>"
+// @Param limit query int false "Reports to return, 1 to 1000 (default 100)"
+// @Success 200 {array} models.AbuseReport
+// @Failure 400 {object} models.ErrorResponse "Invalid limit"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/abuse-reports [get]
+func ListAbuseReports(c *gin.Context) {
+	limit, ok := abuseListLimit(c)
+	if !ok {
+		return
+	}
+	reports, err := database.ListAbuseReports(c.Request.Context(), c.Query("creator"), limit)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list abuse reports"))
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// ListAbuseScores godoc
+// @Summary List abuse scores
+// @ID listAbuseScores
+// @Description List the abuse scores of creators of links, decayed until now, overridden ones first and then the highest. The level in force restricts the creator: elevated halves its rate limits, high quarters them and disables custom aliases, Open Graph overrides, variants and routing rules, and severe cuts them to a tenth and holds its new links for approval.
+// @Tags Admin
+// @Produce json
+// @Param limit query int false "Scores to return, 1 to 1000 (default 100)"
+// @Success 200 {array} models.AbuseScore
+// @Failure 400 {object} models.ErrorResponse "Invalid limit"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/abuse-scores [get]
+func ListAbuseScores(c *gin.Context) {
+	limit, ok := abuseListLimit(c)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	halfLife := abuse.HalfLife()
+	scores, err := database.ListAbuseScores(c.Request.Context(), halfLife, now, limit)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list abuse scores"))
+		return
+	}
+	for i := range scores {
+		scores[i] = abuse.Current(&scores[i], halfLife, now)
+	}
+	c.JSON(http.StatusOK, scores)
+}
+
+// OverrideAbuseScore godoc
+// @Summary Override the abuse level of a creator
+// @ID overrideAbuseScore
+// @Description Set the abuse level of a creator whatever its score, such as normal for a trusted creator hit by false reports or severe for a known abuser, or clear the override with a null level. The score keeps being counted and decaying meanwhile. Other instances apply the change within a minute; the action is audit-logged.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param creator path string true "Creator, user:<id> or ip:<address>"
+// @Param request body models.AbuseScoreOverrideRequest true "Level to apply"
+// @Success 200 {object} models.AbuseScore
+// @Failure 400 {object} models.ErrorResponse "Invalid creator or request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/abuse-scores/{creator} [put]
+func OverrideAbuseScore(c *gin.Context) {
+	creator, ok := abuseCreatorParam(c)
+	if !ok {
+		return
+	}
+	var request models.AbuseScoreOverrideRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	now := time.Now()
+	score, err := database.SetAbuseOverride(c.Request.Context(), creator, request.Level, request.Reason, now)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to override the abuse level"))
+		return
+	}
+	abuse.Forget(creator)
+
+	level := "none"
+	if request.Level != nil {
+		level = *request.Level
+	}
+	recordAudit(c, models.AuditActionAbuseOverride, "", fmt.Sprintf("abuse level of %s overridden to %s: %s", creator, level, request.Reason))
+	c.JSON(http.StatusOK, abuse.Current(score, abuse.HalfLife(), now))
+}
+
+// ResetAbuseScore godoc
+// @Summary Reset the abuse score of a creator
+// @ID resetAbuseScore
+// @Description Forget the abuse score of a creator and any override of its level, lifting its restrictions. Other instances apply the change within a minute; the action is audit-logged.
+// @Tags Admin
+// @Param creator path string true "Creator, user:<id> or ip:<address>"
+// @Success 204 "Abuse score reset"
+// @Failure 400 {object} models.ErrorResponse "Invalid creator"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Creator has no abuse score"
+// @Security AdminAuth
+// @Router /admin/abuse-scores/{creator} [delete]
+func ResetAbuseScore(c *gin.Context) {
+	creator, ok := abuseCreatorParam(c)
+	if !ok {
+		return
+	}
+	deleted, err := database.DeleteAbuseScore(c.Request.Context(), creator)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to reset the abuse score"))
+		return
+	}
+	if !deleted {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Creator has no abuse score"))
+		return
+	}
+	abuse.Forget(creator)
+
+	recordAudit(c, models.AuditActionAbuseReset, "", "abuse score of "+creator+" reset")
+	c.Status(http.StatusNoContent)
+}
+
+// abuseCreatorParam returns the creator path parameter, writing the error
+// response and returning false when it is not a creator
+func abuseCreatorParam(c *gin.Context) (string, bool) {
+	creator := c.Param("creator")
+	if !abuse.ValidCreator(creator) {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "creator must be user:<id> or ip:<address>"))
+		return "", false
+	}
+	return creator, true
+}
+
+// abuseListLimit returns the limit query parameter of abuse listings,
+// writing the error response and returning false when it is invalid
+func abuseListLimit(c *gin.Context) (int, bool) {
+	limit, err := queryInt(c, "limit", defaultAbuseListLimit)
+	if err != nil || limit < 1 || limit > maxAbuseListLimit {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, fmt.Sprintf("limit must be between 1 and %d", maxAbuseListLimit)))
+		return 0, false
+	}
+	return limit, true
+}
+
+// recordFlaggedLink counts a link rejected or disabled by an admin against
+// its creator
+func recordFlaggedLink(c *gin.Context, urlRecord *models.URL) {
+	abuse.Record(c.Request.Context(), abuse.LinkCreator(urlRecord), abuse.SignalFlagged)
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"url-shortener/buildinfo"
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/fleet"
+	"url-shortener/jobs"
+	"url-shortener/models"
+	"url-shortener/notify"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Bounds of the most clicked links listed by GET /admin/stats
+const (
+	defaultTopLinks = 10
+	maxTopLinks     = 100
+)
+
+// LockURL godoc
+// @Summary Lock a short URL
+// @ID lockURL
+// @Description Lock a short URL so its destination cannot be edited and it cannot be deleted
+// @Tags Admin
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Security AdminAuth
+// @Router /admin/urls/{shortCode}/lock [post]
+func LockURL(c *gin.Context) {
+	setURLLocked(c, true)
+}
+
+// UnlockURL godoc
+// @Summary Unlock a short URL
+// @ID unlockURL
+// @Description Remove the lock from a short URL, allowing edits and deletion again. The action is audit-logged.
+// @Tags Admin
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Security AdminAuth
+// @Router /admin/urls/{shortCode}/unlock [post]
+func UnlockURL(c *gin.Context) {
+	setURLLocked(c, false)
+}
+
+func setURLLocked(c *gin.Context, locked bool) {
+	shortCode := pathLinkKey(c)
+
+	var urlRecord models.URL
+	if err := database.DB.Where("short_code = ?", shortCode).First(&urlRecord).Error; err != nil {
+		c.Error(models.ErrLinkNotFound)
+		return
+	}
+
+	if err := database.DB.Model(&urlRecord).Update("locked", locked).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update lock"))
+		return
+	}
+
+	action := models.AuditActionUnlock
+	if locked {
+		action = models.AuditActionLock
+	}
+	recordAudit(c, action, shortCode, "")
+
+	// Cached mapping still carries the previous lock state
+	cache.InvalidateCache(shortCode)
+
+	c.JSON(http.StatusOK, gin.H{"short_code": shortCode, "locked": locked})
+}
+
+// DisableURL godoc
+// @Summary Disable a short URL
+// @ID disableURL
+// @Description Stop an active short URL from redirecting, such as one abused for phishing, without deleting it: it answers 410 Gone with LINK_DISABLED until enabled again, and no longer deduplicates its destination. Locked links must be unlocked first; the action is audit-logged.
+// @Tags Admin
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 403 {object} models.ErrorResponse "Short URL is locked"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 409 {object} models.ErrorResponse "Short URL is not active"
+// @Security AdminAuth
+// @Router /admin/urls/{shortCode}/disable [post]
+func DisableURL(c *gin.Context) {
+	urlRecord, ok := unlockedLink(c, database.DB.Where("short_code = ?", pathLinkKey(c)))
+	if !ok {
+		return
+	}
+	if urlRecord.Status != models.StatusActive {
+		c.Error(models.NewAPIError(http.StatusConflict, models.ErrCodeConflict, "Only active short URLs can be disabled, this one is "+urlRecord.Status))
+		return
+	}
+
+	disabled, err := database.DisableLinks(c.Request.Context(), []uint{urlRecord.ID})
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to disable short URL"))
+		return
+	}
+	if len(disabled) == 0 {
+		// Locked, reviewed or disabled since it was loaded
+		c.Error(models.NewAPIError(http.StatusConflict, models.ErrCodeConflict, "Short URL changed meanwhile, try again"))
+		return
+	}
+	recordAudit(c, models.AuditActionDisable, urlRecord.ShortCode, "")
+	recordFlaggedLink(c, urlRecord)
+	cache.InvalidateCache(urlRecord.ShortCode)
+	cache.InvalidateOriginalURLMapping(urlRecord.OwnerID, urlRecord.OriginalURL)
+	urlRecord.Status = models.StatusDisabled
+	notify.FireLinkWebhooks(c.Request.Context(), models.HookLinkDisabled, []models.URL{*urlRecord})
+
+	c.JSON(http.StatusOK, gin.H{"short_code": urlRecord.ShortCode, "status": models.StatusDisabled})
+}
+
+// EnableURL godoc
+// @Summary Enable a disabled short URL
+// @ID enableURL
+// @Description Let a short URL disabled by an admin, alone or in bulk, redirect again. The action is audit-logged.
+// @Tags Admin
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 409 {object} models.ErrorResponse "Short URL is not disabled"
+// @Security AdminAuth
+// @Router /admin/urls/{shortCode}/enable [post]
+func EnableURL(c *gin.Context) {
+	shortCode := pathLinkKey(c)
+	result := database.DB.WithContext(c.Request.Context()).Model(&models.URL{}).
+		Where("short_code = ? AND status = ?", shortCode, models.StatusDisabled).
+		Update("status", models.StatusActive)
+	if result.Error != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to enable short URL"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		database.DB.WithContext(c.Request.Context()).Model(&models.URL{}).Where("short_code = ?", shortCode).Count(&count)
+		if count == 0 {
+			c.Error(models.ErrLinkNotFound)
+			return
+		}
+		c.Error(models.NewAPIError(http.StatusConflict, models.ErrCodeConflict, "Short URL is not disabled"))
+		return
+	}
+	recordAudit(c, models.AuditActionEnable, shortCode, "")
+	cache.InvalidateCache(shortCode)
+
+	c.JSON(http.StatusOK, gin.H{"short_code": shortCode, "status": models.StatusActive})
+}
+
+// GetGlobalStats godoc
+// @Summary Service-wide link statistics
+// @ID getGlobalStats
+// @Description Count the live links per status, the archived links, those created in the last 24 hours and the clicks of them all, and list the most clicked live links. Click counts cover the time since each link's last stats reset.
+// @Tags Admin
+// @Produce json
+// @Param top query int false "Most clicked links to list (default 10, max 100)"
+// @Success 200 {object} models.GlobalStatsResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid top"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/stats [get]
+func GetGlobalStats(c *gin.Context) {
+	top, err := queryInt(c, "top", defaultTopLinks)
+	if err != nil || top < 1 || top > maxTopLinks {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "top must be between 1 and 100"))
+		return
+	}
+	stats, err := database.GlobalStats(c.Request.Context(), top, time.Now())
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to compute stats"))
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetDBMetrics godoc
+// @Summary Database query metrics
+// @ID getDBMetrics
+// @Description Per-operation and per-table query counts and durations recorded by this instance, labelled with its instance ID. With scope fleet and METRICS_AGGREGATION on, the counts of every instance are added up and left unlabelled.
+// @Tags Admin
+// @Produce json
+// @Param scope query string false "instance (default) or fleet"
+// @Success 200 {array} database.QueryStats
+// @Failure 400 {object} models.ErrorResponse "Invalid scope"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/db-metrics [get]
+func GetDBMetrics(c *gin.Context) {
+	scope, snapshots, ok := metricsScope(c, time.Now())
+	if !ok {
+		return
+	}
+	if scope == models.MetricsScopeFleet {
+		c.JSON(http.StatusOK, fleet.Merge(snapshots).Queries)
+		return
+	}
+
+	stats := database.QueryMetrics()
+	for i := range stats {
+		stats[i].Instance = buildinfo.Instance
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetClickReconciliation godoc
+// @Summary Click count reconciliation report
+// @ID getClickReconciliation
+// @Description Last run of the hourly job comparing click counts in the database, click events and the cache, with discrepancy totals since this instance started
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} models.ClickReconciliationStatus
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/click-reconciliation [get]
+func GetClickReconciliation(c *gin.Context) {
+	c.JSON(http.StatusOK, jobs.ClickReconciliation())
+}
+
+// GetIntegrity godoc
+// @Summary Data integrity report
+// @ID getIntegrity
+// @Description Last run of the integrity verifier, which checks for duplicate short codes, expired links not cleaned up, orphaned aliases and cached redirects drifted from the database, with violation totals since this instance started
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} models.IntegrityStatus
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/integrity [get]
+func GetIntegrity(c *gin.Context) {
+	c.JSON(http.StatusOK, jobs.Integrity())
+}
+
+// VerifyIntegrity godoc
+// @Summary Verify data integrity now
+// @ID verifyIntegrity
+// @Description Run the integrity verifier without waiting for INTEGRITY_CHECK_INTERVAL, such as after a migration or a restore. Violations are reported, never repaired; a check that fails reports its error and the others still run.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} models.IntegrityReport
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/integrity/verify [post]
+func VerifyIntegrity(c *gin.Context) {
+	c.JSON(http.StatusOK, jobs.VerifyIntegrity(c.Request.Context(), models.IntegrityTriggerAdmin))
+}
+
+// recordAudit stores an audit log entry for an administrative action
+func recordAudit(c *gin.Context, action, shortCode, details string) {
+	recordAuditAs(c, "admin", action, shortCode, details)
+}
+
+// recordAuditAs stores an audit log entry for an action taken by actor,
+// admin or a link owner as user:<id>
+func recordAuditAs(c *gin.Context, actor, action, shortCode, details string) {
+	entry := models.AuditLog{
+		Action:    action,
+		ShortCode: shortCode,
+		Actor:     actor,
+		IPAddress: c.ClientIP(),
+		Details:   details,
+	}
+
+	if err := database.DB.WithContext(c.Request.Context()).Create(&entry).Error; err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to record audit log", "action", action, "short_code", shortCode, "error", err)
+	}
+}
@@ -0,0 +1,109 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/database/databasetest"
+	"url-shortener/handlers"
+	"url-shortener/handlers/handlertest"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDisableURLFiresWebhooks(t *testing.T) {
+	databasetest.UseSQLite(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Errors())
+	router.POST("/admin/urls/:shortCode/disable", handlers.DisableURL)
+
+	owner, other := uint(1), uint(2)
+	links := []models.URL{
+		{OriginalURL: "https://example.com/owned", ShortCode: "owned", OwnerID: &owner},
+		{OriginalURL: "https://example.com/inert", ShortCode: "inert", OwnerID: &owner, Inert: true},
+	}
+	if err := database.DB.Create(&links).Error; err != nil {
+		t.Fatalf("creating links: %v", err)
+	}
+	webhook := databasetest.CreateWebhook(t, owner, models.HookLinkDisabled)
+	unsubscribed := databasetest.CreateWebhook(t, owner, models.HookLinkCreated)
+	stranger := databasetest.CreateWebhook(t, other, models.HookLinkDisabled)
+
+	for _, shortCode := range []string{"owned", "inert"} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/admin/urls/"+shortCode+"/disable", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("disable %s = %d: %s", shortCode, recorder.Code, recorder.Body)
+		}
+	}
+
+	// Inert links are not reported
+	deliveries := databasetest.Deliveries(t, webhook)
+	if len(deliveries) != 1 || deliveries[0].Event != models.HookLinkDisabled {
+		t.Fatalf("deliveries = %+v, want one link.disabled", deliveries)
+	}
+	var payload models.HookLinkPayload
+	if err := json.Unmarshal(deliveries[0].Payload, &payload); err != nil || payload.ShortCode != "owned" || payload.Status != models.StatusDisabled {
+		t.Errorf("link.disabled payload = %+v, %v, want owned now disabled", payload, err)
+	}
+	for name, hook := range map[string]*models.Webhook{"unsubscribed": unsubscribed, "another owner's": stranger} {
+		if deliveries := databasetest.Deliveries(t, hook); len(deliveries) != 0 {
+			t.Errorf("%s webhook got %+v, want nothing", name, deliveries)
+		}
+	}
+}
+
+func TestIntegrityReport(t *testing.T) {
+	databasetest.UseSQLite(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Errors())
+	router.GET("/admin/integrity", handlers.GetIntegrity)
+	router.POST("/admin/integrity/verify", handlers.VerifyIntegrity)
+
+	expires := time.Now().Add(time.Hour)
+	if err := database.DB.Create(&models.RenamedAlias{Alias: "orphan", URLID: 404, ExpiresAt: expires}).Error; err != nil {
+		t.Fatalf("creating alias: %v", err)
+	}
+	var before models.IntegrityStatus
+	json.Unmarshal(handlertest.Serve(router, 0, http.MethodGet, "/admin/integrity", "").Body.Bytes(), &before)
+
+	recorder := handlertest.Serve(router, 0, http.MethodPost, "/admin/integrity/verify", "")
+	var report models.IntegrityReport
+	if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &report) != nil {
+		t.Fatalf("POST /admin/integrity/verify = %d: %s", recorder.Code, recorder.Body)
+	}
+	if report.Trigger != models.IntegrityTriggerAdmin || report.Violations != 1 {
+		t.Errorf("report = %+v, want one violation found on request", report)
+	}
+	checks := make(map[string]models.IntegrityCheck, len(report.Checks))
+	for _, check := range report.Checks {
+		checks[check.Name] = check
+	}
+	for _, name := range []string{models.IntegrityDuplicateShortCodes, models.IntegrityExpiredLinks, models.IntegrityOrphanedAliases, models.IntegrityCacheDrift} {
+		if _, ok := checks[name]; !ok {
+			t.Errorf("report has no %s check: %+v", name, report.Checks)
+		}
+	}
+	if orphans := checks[models.IntegrityOrphanedAliases]; orphans.Violations != 1 || len(orphans.Samples) != 1 || orphans.Samples[0] != "orphan" {
+		t.Errorf("orphaned aliases = %+v, want orphan", orphans)
+	}
+
+	// The status reports the run and adds it to the totals
+	var status models.IntegrityStatus
+	recorder = handlertest.Serve(router, 0, http.MethodGet, "/admin/integrity", "")
+	if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &status) != nil {
+		t.Fatalf("GET /admin/integrity = %d: %s", recorder.Code, recorder.Body)
+	}
+	if status.LastRun == nil || !status.LastRun.StartedAt.Equal(report.StartedAt) || status.Runs != before.Runs+1 ||
+		status.RunsWithViolations != before.RunsWithViolations+1 || status.TotalViolations != before.TotalViolations+1 {
+		t.Errorf("status = %+v after %+v, want the run counted", status, before)
+	}
+}
@@ -0,0 +1,623 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Click analytics cover this much time unless from is given
+var defaultAnalyticsWindow = map[string]time.Duration{
+	models.IntervalHour: 48 * time.Hour,
+	models.IntervalDay:  30 * 24 * time.Hour,
+}
+
+// Upper bound on the buckets of a time series
+const maxTimeseriesPoints = 1000
+
+// Longest tag a link may carry, as validated when links are created
+const maxTagLength = 64
+
+// Click heatmaps cover this much time unless from is given, and at most
+const (
+	defaultHeatmapWindow = 12 * 7 * 24 * time.Hour
+	maxHeatmapWindow     = 366 * 24 * time.Hour
+)
+
+// Referrers returned unless limit says otherwise, and the most allowed
+const (
+	defaultReferrerLimit = 10
+	maxReferrerLimit     = 100
+)
+
+// Destination domains returned unless limit says otherwise, and the most
+// allowed
+const (
+	defaultDestinationLimit = 20
+	maxDestinationLimit     = 100
+)
+
+// GetClickTimeseries godoc
+// @Summary Clicks over time
+// @ID getClickTimeseries
+// @Description Count a link's clicks per hour or day from its click events, including empty buckets, which stay empty for links whose analytics are not full. Buckets follow the time zone tz, UTC by default, including its daylight saving time changes; from is rounded down to a bucket boundary. Covers the last 48 hours or 30 days by default, and at most 1000 buckets. With compare=previous_period, the period of the same length right before is counted too, with the percentage change of the total and of each bucket from its counterpart. Events annotated on the link's timeline within the range are listed in annotations. Ranges starting before the click history kept on the plan of the link's owner, as set by STATS_RETENTION_DAYS, start with it instead, as retention explains.
+// @Tags URL Shortener
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param interval query string false "hour or day (default day)"
+// @Param tz query string false "IANA time zone of the buckets, such as Europe/Berlin (default UTC)"
+// @Param from query string false "Start, RFC 3339"
+// @Param to query string false "End, RFC 3339 (default now)"
+// @Param compare query string false "previous_period to add the series of the period of the same length right before, with percentage changes" Enums(previous_period)
+// @Success 200 {object} models.TimeseriesResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid interval, time zone, range or compare"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the read_stats scope"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Router /stats/{shortCode}/timeseries [get]
+func GetClickTimeseries(c *gin.Context) {
+	interval := c.DefaultQuery("interval", models.IntervalDay)
+	if _, ok := defaultAnalyticsWindow[interval]; !ok {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "interval must be hour or day"))
+		return
+	}
+	loc, ok := parseTimeZone(c)
+	if !ok {
+		return
+	}
+	compare, ok := parseCompare(c)
+	if !ok {
+		return
+	}
+	from, to, ok := parseAnalyticsRange(c, defaultAnalyticsWindow[interval], time.Now())
+	if !ok {
+		return
+	}
+	from = truncateToInterval(from, interval, loc)
+	if bucketCount(from, to, interval) > maxTimeseriesPoints {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Range covers more than 1000 buckets, use a shorter range or a longer interval"))
+		return
+	}
+
+	urlRecord, err := findStatsLink(c.Request.Context(), pathLinkKey(c))
+	if err != nil {
+		c.Error(models.ErrLinkNotFound)
+		return
+	}
+	from, notice, ok := clampStatsRange(c, urlRecord.OwnerID, from, to)
+	if !ok {
+		return
+	}
+	from = truncateToInterval(from, interval, loc)
+
+	// Both periods are counted at once when comparing
+	countFrom := from
+	if compare {
+		countFrom = previousPeriodStart(from, to, interval, loc)
+	}
+
+	// Links opting out of analytics have no click events to count
+	var counts map[time.Time]int64
+	if models.CapturesEvents(urlRecord.AnalyticsMode()) {
+		if counts, err = database.ClickCounts(c.Request.Context(), urlRecord.ID, interval, loc, countFrom, to); err != nil {
+			c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to count clicks"))
+			return
+		}
+	}
+
+	response := models.TimeseriesResponse{
+		ShortCode: urlRecord.ShortCode,
+		Analytics: urlRecord.AnalyticsMode(),
+		Interval:  interval,
+		TimeZone:  loc.String(),
+		From:      from,
+		To:        to.In(loc),
+		Retention: notice,
+	}
+	response.Points = timeseriesPoints(counts, from, to, interval)
+	for _, point := range response.Points {
+		response.Total += point.Clicks
+	}
+	if compare {
+		response.Previous = comparePeriods(response.Points, response.Total, counts, countFrom, from, interval)
+	}
+	if response.Annotations, err = database.LinkAnnotations(c.Request.Context(), urlRecord.ID, from, to); err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list annotations"))
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetClickHeatmap godoc
+// @Summary Clicks by hour of the week
+// @ID getClickHeatmap
+// @Description Count a link's clicks per weekday and hour of the day in the time zone tz, UTC by default, as a 7x24 matrix starting on Monday at midnight, with the busiest hour, to pick the best times to post the link. Counted from hourly click rollups, so the latest clicks show up within minutes; links whose analytics are not full have none. from is rounded down to the hour. Covers the last 12 weeks by default, and at most 366 days. Ranges starting before the click history kept on the plan of the link's owner, as set by STATS_RETENTION_DAYS, start with it instead, as retention explains.
+// @Tags URL Shortener
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param tz query string false "IANA time zone of the weekdays and hours, such as Europe/Berlin (default UTC)"
+// @Param from query string false "Start, RFC 3339"
+// @Param to query string false "End, RFC 3339 (default now)"
+// @Success 200 {object} models.HeatmapResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid time zone or range"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the read_stats scope"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Router /stats/{shortCode}/heatmap [get]
+func GetClickHeatmap(c *gin.Context) {
+	loc, ok := parseTimeZone(c)
+	if !ok {
+		return
+	}
+	from, to, ok := parseAnalyticsRange(c, defaultHeatmapWindow, time.Now())
+	if !ok {
+		return
+	}
+	// Rollups count whole UTC hours
+	from = from.Truncate(time.Hour)
+	if to.Sub(from) > maxHeatmapWindow {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Range covers more than 366 days, use a shorter range"))
+		return
+	}
+
+	urlRecord, err := findStatsLink(c.Request.Context(), pathLinkKey(c))
+	if err != nil {
+		c.Error(models.ErrLinkNotFound)
+		return
+	}
+	from, notice, ok := clampStatsRange(c, urlRecord.OwnerID, from, to)
+	if !ok {
+		return
+	}
+	from = from.Truncate(time.Hour)
+
+	// Links opting out of analytics have no click events to roll up
+	var heatmap [7][24]int64
+	if models.CapturesEvents(urlRecord.AnalyticsMode()) {
+		if heatmap, err = database.ClickHeatmap(c.Request.Context(), urlRecord.ID, loc, from, to); err != nil {
+			c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to count clicks"))
+			return
+		}
+	}
+
+	response := models.HeatmapResponse{
+		ShortCode: urlRecord.ShortCode,
+		Analytics: urlRecord.AnalyticsMode(),
+		TimeZone:  loc.String(),
+		From:      from.In(loc),
+		To:        to.In(loc),
+		Retention: notice,
+	}
+	response.Clicks, response.Total, response.Peak = heatmapCells(heatmap)
+	c.JSON(http.StatusOK, response)
+}
+
+// heatmapCells returns the rows of heatmap, the clicks they add up to and
+// the earliest hour with the most clicks, nil when there are none
+func heatmapCells(heatmap [7][24]int64) ([][]int64, int64, *models.HeatmapPeak) {
+	rows := make([][]int64, len(heatmap))
+	var total int64
+	var peak *models.HeatmapPeak
+	for weekday := range heatmap {
+		rows[weekday] = heatmap[weekday][:]
+		for hour, clicks := range heatmap[weekday] {
+			total += clicks
+			if clicks > 0 && (peak == nil || clicks > peak.Clicks) {
+				// time.Weekday counts from Sunday, the heatmap from Monday
+				name := strings.ToLower(time.Weekday((weekday + 1) % 7).String())
+				peak = &models.HeatmapPeak{Weekday: name, Hour: hour, Clicks: clicks}
+			}
+		}
+	}
+	return rows, total, peak
+}
+
+// GetTopReferrers godoc
+// @Summary Top referrers
+// @ID getTopReferrers
+// @Description Rank the sites that sent a link's clicks by referring host, most clicks first. Clicks without a referrer are grouped as "(direct)"; links whose analytics are not full have none. Covers the last 30 days by default. Ranges starting before the click history kept on the plan of the link's owner, as set by STATS_RETENTION_DAYS, start with it instead, as retention explains.
+// @Tags URL Shortener
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param from query string false "Start, RFC 3339"
+// @Param to query string false "End, RFC 3339 (default now)"
+// @Param limit query int false "Referrers returned (default 10, max 100)"
+// @Success 200 {object} models.ReferrersResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid range or limit"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the read_stats scope"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Router /stats/{shortCode}/referrers [get]
+func GetTopReferrers(c *gin.Context) {
+	from, to, ok := parseAnalyticsRange(c, defaultAnalyticsWindow[models.IntervalDay], time.Now())
+	if !ok {
+		return
+	}
+	limit, err := queryInt(c, "limit", defaultReferrerLimit)
+	if err != nil || limit < 1 || limit > maxReferrerLimit {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "limit must be between 1 and 100"))
+		return
+	}
+
+	urlRecord, err := findStatsLink(c.Request.Context(), pathLinkKey(c))
+	if err != nil {
+		c.Error(models.ErrLinkNotFound)
+		return
+	}
+	from, notice, ok := clampStatsRange(c, urlRecord.OwnerID, from, to)
+	if !ok {
+		return
+	}
+
+	response := models.ReferrersResponse{
+		ShortCode: urlRecord.ShortCode,
+		Analytics: urlRecord.AnalyticsMode(),
+		From:      from,
+		To:        to,
+		Referrers: []models.ReferrerCount{},
+		Retention: notice,
+	}
+	// Links opting out of analytics have no click events to rank
+	if models.CapturesEvents(response.Analytics) {
+		if response.Referrers, err = database.TopReferrers(c.Request.Context(), urlRecord.ID, from, to, limit); err != nil {
+			c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to rank referrers"))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDestinationStats godoc
+// @Summary Clicks per destination domain
+// @ID getDestinationStats
+// @Description Add up the clicks of the caller's links, archived ones included, per destination domain, so teams can see which of their properties receive the most traffic. www. is dropped from the domain; other subdomains are counted apart. Counts come from the hourly click rollups, so the latest clicks show up within minutes. Covers the last 30 days by default, and at most 366 days. Unavailable while destinations are encrypted. Ranges starting before the click history kept on the caller's plan, as set by STATS_RETENTION_DAYS, start with it instead, as retention explains.
+// @Tags URL Shortener
+// @Produce json
+// @Param from query string false "Start, RFC 3339"
+// @Param to query string false "End, RFC 3339 (default now)"
+// @Param limit query int false "Domains to return, 1 to 100 (default 20)"
+// @Success 200 {object} models.DestinationStatsResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid range or limit, or destinations are encrypted"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /stats/destinations [get]
+func GetDestinationStats(c *gin.Context) {
+	from, to, ok := parseAnalyticsRange(c, defaultAnalyticsWindow[models.IntervalDay], time.Now())
+	if !ok {
+		return
+	}
+	if to.Sub(from) > maxHeatmapWindow {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Range covers more than 366 days"))
+		return
+	}
+	limit, err := queryInt(c, "limit", defaultDestinationLimit)
+	if err != nil || limit < 1 || limit > maxDestinationLimit {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "limit must be between 1 and 100"))
+		return
+	}
+	if !database.DestinationDomainFilterable() {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "destination stats are unavailable while destinations are encrypted (URL_ENCRYPTION_KEY)"))
+		return
+	}
+
+	from, notice, ok := clampStatsRange(c, middleware.CurrentOwnerID(c), from, to)
+	if !ok {
+		return
+	}
+
+	destinations, err := database.DestinationClicks(c.Request.Context(), *middleware.CurrentOwnerID(c), from, to)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to count clicks"))
+		return
+	}
+	response := models.DestinationStatsResponse{From: from, To: to, Retention: notice}
+	for _, destination := range destinations {
+		response.Total += destination.Clicks
+	}
+	response.Destinations = destinations[:min(limit, len(destinations))]
+	c.JSON(http.StatusOK, response)
+}
+
+// GetUniqueVisitors godoc
+// @Summary Unique visitors
+// @ID getUniqueVisitors
+// @Description Estimate how many different visitors clicked a link, told apart by IP address and user agent, by merging daily HyperLogLogs kept in Redis for UNIQUE_VISITOR_RETENTION. from is rounded down to a UTC day and the estimate has a 0.81% standard error. Covers the last 30 days by default, and at most 1000 days. Links whose analytics are not full have no unique visitors. Ranges starting before the click history kept on the plan of the link's owner, as set by STATS_RETENTION_DAYS, start with it instead, as retention explains.
+// @Tags URL Shortener
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param from query string false "Start, RFC 3339"
+// @Param to query string false "End, RFC 3339 (default now)"
+// @Success 200 {object} models.UniqueVisitorsResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid range"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the read_stats scope"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Failure 503 {object} models.ErrorResponse "Redis is unavailable"
+// @Router /stats/{shortCode}/uniques [get]
+func GetUniqueVisitors(c *gin.Context) {
+	from, to, ok := parseAnalyticsRange(c, defaultAnalyticsWindow[models.IntervalDay], time.Now())
+	if !ok {
+		return
+	}
+	from = truncateToInterval(from, models.IntervalDay, time.UTC)
+	if bucketCount(from, to, models.IntervalDay) > maxTimeseriesPoints {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Range covers more than 1000 days, use a shorter range"))
+		return
+	}
+
+	urlRecord, err := findStatsLink(c.Request.Context(), pathLinkKey(c))
+	if err != nil {
+		c.Error(models.ErrLinkNotFound)
+		return
+	}
+	from, notice, ok := clampStatsRange(c, urlRecord.OwnerID, from, to)
+	if !ok {
+		return
+	}
+	from = truncateToInterval(from, models.IntervalDay, time.UTC)
+
+	response := models.UniqueVisitorsResponse{
+		ShortCode:   urlRecord.ShortCode,
+		Analytics:   urlRecord.AnalyticsMode(),
+		From:        from,
+		To:          to,
+		Approximate: true,
+		Retention:   notice,
+	}
+	// Links opting out of analytics never record visitors
+	if models.CapturesEvents(response.Analytics) {
+		if response.UniqueVisitors, err = cache.CountVisitors(urlRecord.ID, from, to); err != nil {
+			c.Error(models.NewAPIError(http.StatusServiceUnavailable, models.ErrCodeUnavailable, "Unique visitors are unavailable without Redis"))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTagStats godoc
+// @Summary Stats of a tag
+// @ID getTagStats
+// @Description Add up the clicks of every link carrying a tag, such as a campaign, including archived links. links and click_count cover all time; the time series counts clicks per hour or day of the time zone tz, UTC by default, from hourly click rollups, which are rebuilt every 5 minutes, so from is rounded down to a bucket boundary and the last bucket may lag. Covers the last 48 hours or 30 days by default, and at most 1000 buckets. Tags are matched as links carry them now. With compare=previous_period, the period of the same length right before is counted too, with the percentage change of the total and of each bucket from its counterpart. Ranges starting before the click history kept on the caller's plan, as set by STATS_RETENTION_DAYS, start with it instead, as retention explains.
+// @Tags URL Shortener
+// @Produce json
+// @Param tag path string true "Tag"
+// @Param interval query string false "hour or day (default day)"
+// @Param tz query string false "IANA time zone of the buckets, such as Europe/Berlin (default UTC)"
+// @Param from query string false "Start, RFC 3339"
+// @Param to query string false "End, RFC 3339 (default now)"
+// @Param compare query string false "previous_period to add the series of the period of the same length right before, with percentage changes" Enums(previous_period)
+// @Success 200 {object} models.TagStatsResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid tag, interval, time zone, range or compare"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the read_stats scope"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Router /stats/tags/{tag} [get]
+func GetTagStats(c *gin.Context) {
+	tag := c.Param("tag")
+	if len(tag) > maxTagLength {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "tag must be at most 64 characters long"))
+		return
+	}
+	interval := c.DefaultQuery("interval", models.IntervalDay)
+	if _, ok := defaultAnalyticsWindow[interval]; !ok {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "interval must be hour or day"))
+		return
+	}
+	loc, ok := parseTimeZone(c)
+	if !ok {
+		return
+	}
+	compare, ok := parseCompare(c)
+	if !ok {
+		return
+	}
+	from, to, ok := parseAnalyticsRange(c, defaultAnalyticsWindow[interval], time.Now())
+	if !ok {
+		return
+	}
+	from = truncateToInterval(from, interval, loc)
+	if bucketCount(from, to, interval) > maxTimeseriesPoints {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Range covers more than 1000 buckets, use a shorter range or a longer interval"))
+		return
+	}
+	from, notice, ok := clampStatsRange(c, middleware.CurrentOwnerID(c), from, to)
+	if !ok {
+		return
+	}
+	from = truncateToInterval(from, interval, loc)
+	countFrom := from
+	if compare {
+		countFrom = previousPeriodStart(from, to, interval, loc)
+	}
+
+	ctx := c.Request.Context()
+	response := models.TagStatsResponse{Tag: tag, Interval: interval, TimeZone: loc.String(), From: from, To: to.In(loc), Retention: notice}
+	var err error
+	if response.Links, response.ClickCount, err = database.TagTotals(ctx, tag); err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to count tagged links"))
+		return
+	}
+	counts, err := database.TagClickCounts(ctx, tag, interval, loc, countFrom, to)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to count clicks"))
+		return
+	}
+
+	response.Points = timeseriesPoints(counts, from, to, interval)
+	for _, point := range response.Points {
+		response.Total += point.Clicks
+	}
+	if compare {
+		response.Previous = comparePeriods(response.Points, response.Total, counts, countFrom, from, interval)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// findStatsLink loads a link for reporting, including archived links, which
+// report their stats without being rehydrated
+func findStatsLink(ctx context.Context, shortCode string) (*models.URL, error) {
+	var urlRecord models.URL
+	err := database.DB.WithContext(ctx).Where("short_code = ?", shortCode).First(&urlRecord).Error
+	if err == nil {
+		return &urlRecord, nil
+	}
+	archived, archiveErr := database.FindArchivedURL(ctx, shortCode)
+	if archiveErr != nil {
+		return nil, err
+	}
+	return archived, nil
+}
+
+// parseAnalyticsRange reads the optional from and to query parameters,
+// defaulting to the window ending now, and writes the error response when
+// they are invalid
+func parseAnalyticsRange(c *gin.Context, window time.Duration, now time.Time) (time.Time, time.Time, bool) {
+	to := now.UTC()
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "to must be an RFC 3339 time"))
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed.UTC()
+	}
+
+	from := to.Add(-window)
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "from must be an RFC 3339 time"))
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed.UTC()
+	}
+
+	if !from.Before(to) {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "from must be before to"))
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// parseTimeZone reads the optional tz query parameter, an IANA time zone
+// name defaulting to UTC, and writes the error response when it is unknown
+func parseTimeZone(c *gin.Context) (*time.Location, bool) {
+	name := c.Query("tz")
+	if name == "" {
+		return time.UTC, true
+	}
+	// "Local" would be the server's zone, which callers can't know
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "tz must be an IANA time zone, such as Europe/Berlin"))
+		return nil, false
+	}
+	return loc, true
+}
+
+// parseCompare reads the optional compare query parameter, reporting
+// whether the previous period is asked for, and writes the error response
+// when it is not previous_period
+func parseCompare(c *gin.Context) (bool, bool) {
+	switch c.Query("compare") {
+	case "":
+		return false, true
+	case "previous_period":
+		return true, true
+	}
+	c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "compare must be previous_period"))
+	return false, false
+}
+
+// previousPeriodStart returns the start of the period as long as the one
+// from from until to that ends at from, rounded down to a bucket boundary
+func previousPeriodStart(from, to time.Time, interval string, loc *time.Location) time.Time {
+	return truncateToInterval(from.Add(-to.Sub(from)), interval, loc)
+}
+
+// comparePeriods lists the buckets of the previous period from from until
+// to, and sets the change of each of points from the bucket at the same
+// position in it
+func comparePeriods(points []models.TimeseriesPoint, total int64, counts map[time.Time]int64, from, to time.Time, interval string) *models.PreviousPeriod {
+	previous := &models.PreviousPeriod{From: from, To: to, Points: timeseriesPoints(counts, from, to, interval)}
+	for i, point := range previous.Points {
+		previous.Total += point.Clicks
+		if i < len(points) {
+			points[i].Change = percentChange(point.Clicks, points[i].Clicks)
+		}
+	}
+	previous.Change = percentChange(previous.Total, total)
+	return previous
+}
+
+// percentChange returns how many percent current is above or below
+// previous, rounded to a tenth, or nil when previous is zero
+func percentChange(previous, current int64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := math.Round(float64(current-previous)/float64(previous)*1000) / 10
+	return &change
+}
+
+// truncateToInterval rounds t down to the start of its hour or day in loc,
+// returned in loc. Hours are cut at the minute, not rebuilt from the wall
+// clock, so the hour repeated when clocks go back stays two hours.
+func truncateToInterval(t time.Time, interval string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	if interval == models.IntervalDay {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+	return t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+}
+
+// nextBucket returns the start of the bucket after the one starting at t.
+// Days end at the next midnight of t's zone, so they last 23 or 25 hours
+// when daylight saving time starts or ends.
+func nextBucket(t time.Time, interval string) time.Time {
+	if interval == models.IntervalDay {
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	}
+	return t.Add(time.Hour)
+}
+
+// bucketCount returns about how many buckets starting at from begin before
+// to, give or take one around daylight saving time changes
+func bucketCount(from, to time.Time, interval string) int {
+	step := time.Hour
+	if interval == models.IntervalDay {
+		step = 24 * time.Hour
+	}
+	return int((to.Sub(from) + step - 1) / step)
+}
+
+// timeseriesPoints lists every bucket from from until to with its clicks,
+// counts being keyed by the start of each bucket in UTC
+func timeseriesPoints(counts map[time.Time]int64, from, to time.Time, interval string) []models.TimeseriesPoint {
+	points := make([]models.TimeseriesPoint, 0, bucketCount(from, to, interval))
+	for bucket := from; bucket.Before(to); bucket = nextBucket(bucket, interval) {
+		points = append(points, models.TimeseriesPoint{Time: bucket, Clicks: counts[bucket.UTC()]})
+	}
+	return points
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url-shortener/models"
+	"url-shortener/retention"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseAnalyticsRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		query    string
+		from, to time.Time
+		ok       bool
+	}{
+		{query: "", from: now.Add(-48 * time.Hour), to: now, ok: true},
+		{query: "to=2024-01-10T00:00:00Z", from: time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), to: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), ok: true},
+		{query: "from=2024-01-15T12:30:00%2B02:00", ok: false}, // equal to now once in UTC
+		{query: "from=2024-01-01T00:00:00Z", from: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), to: now, ok: true},
+		{query: "from=yesterday", ok: false},
+	}
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/stats/abc123/timeseries?"+tc.query, nil)
+
+		from, to, ok := parseAnalyticsRange(c, 48*time.Hour, now)
+		if ok != tc.ok {
+			t.Errorf("%q: ok = %t, want %t", tc.query, ok, tc.ok)
+			continue
+		}
+		if ok && (!from.Equal(tc.from) || !to.Equal(tc.to)) {
+			t.Errorf("%q: range = %v - %v, want %v - %v", tc.query, from, to, tc.from, tc.to)
+		}
+	}
+}
+
+func TestTimeseriesPoints(t *testing.T) {
+	from := truncateToInterval(time.Date(2024, 1, 13, 18, 45, 0, 0, time.UTC), models.IntervalDay, time.UTC)
+	to := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	counts := map[time.Time]int64{time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC): 7}
+
+	points := timeseriesPoints(counts, from, to, models.IntervalDay)
+	want := []models.TimeseriesPoint{
+		{Time: time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC), Clicks: 0},
+		{Time: time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC), Clicks: 7},
+		{Time: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), Clicks: 0},
+	}
+	if len(points) != len(want) {
+		t.Fatalf("got %d points, want %d", len(points), len(want))
+	}
+	for i := range want {
+		if !points[i].Time.Equal(want[i].Time) || points[i].Clicks != want[i].Clicks {
+			t.Errorf("point %d = %+v, want %+v", i, points[i], want[i])
+		}
+	}
+	if n := bucketCount(from, to, models.IntervalDay); n != 3 {
+		t.Errorf("bucketCount = %d, want 3", n)
+	}
+}
+
+func TestTimeseriesPointsAcrossDaylightSavingTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Clocks went forward at 02:00 on March 31 and back at 03:00 on October 27
+	from := truncateToInterval(time.Date(2024, 3, 30, 12, 0, 0, 0, time.UTC), models.IntervalDay, berlin)
+	points := timeseriesPoints(nil, from, time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC), models.IntervalDay)
+	want := []time.Time{
+		time.Date(2024, 3, 29, 23, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 30, 23, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 22, 0, 0, 0, time.UTC),
+	}
+	if len(points) != len(want) {
+		t.Fatalf("got %d days, want %d", len(points), len(want))
+	}
+	for i := range want {
+		if !points[i].Time.Equal(want[i]) {
+			t.Errorf("day %d starts at %v, want %v", i, points[i].Time.UTC(), want[i])
+		}
+	}
+
+	from = truncateToInterval(time.Date(2024, 10, 27, 0, 30, 0, 0, time.UTC), models.IntervalHour, berlin)
+	counts := map[time.Time]int64{time.Date(2024, 10, 27, 0, 0, 0, 0, time.UTC): 3, time.Date(2024, 10, 27, 1, 0, 0, 0, time.UTC): 5}
+	points = timeseriesPoints(counts, from, time.Date(2024, 10, 27, 2, 0, 0, 0, time.UTC), models.IntervalHour)
+	if len(points) != 2 || points[0].Clicks != 3 || points[1].Clicks != 5 {
+		t.Fatalf("repeated hour points = %+v, want 3 then 5 clicks", points)
+	}
+	if points[0].Time.Format(time.RFC3339) != "2024-10-27T02:00:00+02:00" || points[1].Time.Format(time.RFC3339) != "2024-10-27T02:00:00+01:00" {
+		t.Errorf("repeated hour points start at %v and %v", points[0].Time, points[1].Time)
+	}
+}
+
+func TestParseTimeZone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := map[string]string{"": "UTC", "tz=Asia/Kolkata": "Asia/Kolkata", "tz=Local": "", "tz=Mars/Olympus": ""}
+	for query, want := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/stats/abc123/timeseries?"+query, nil)
+
+		loc, ok := parseTimeZone(c)
+		if ok != (want != "") {
+			t.Errorf("%q: ok = %t", query, ok)
+			continue
+		}
+		if ok && loc.String() != want {
+			t.Errorf("%q: zone = %s, want %s", query, loc, want)
+		}
+	}
+}
+
+func TestHeatmapCells(t *testing.T) {
+	rows, total, peak := heatmapCells([7][24]int64{})
+	if len(rows) != 7 || len(rows[6]) != 24 || total != 0 || peak != nil {
+		t.Fatalf("empty heatmap = %d rows, total %d, peak %+v", len(rows), total, peak)
+	}
+
+	var heatmap [7][24]int64
+	heatmap[1][18] = 31 // Tuesday 18:00
+	heatmap[6][9] = 31  // Sunday 09:00, tied but later in the week
+	heatmap[0][0] = 4
+	rows, total, peak = heatmapCells(heatmap)
+	if total != 66 || rows[1][18] != 31 || rows[0][0] != 4 {
+		t.Errorf("total = %d, rows[1][18] = %d, rows[0][0] = %d", total, rows[1][18], rows[0][0])
+	}
+	want := models.HeatmapPeak{Weekday: "tuesday", Hour: 18, Clicks: 31}
+	if peak == nil || *peak != want {
+		t.Errorf("peak = %+v, want %+v", peak, want)
+	}
+}
+
+func TestComparePeriods(t *testing.T) {
+	loc := time.UTC
+	from := time.Date(2024, 1, 13, 0, 0, 0, 0, loc)
+	to := time.Date(2024, 1, 15, 10, 30, 0, 0, loc)
+	previousFrom := previousPeriodStart(from, to, models.IntervalDay, loc)
+	if want := time.Date(2024, 1, 10, 0, 0, 0, 0, loc); !previousFrom.Equal(want) {
+		t.Fatalf("previous period starts %v, want %v", previousFrom, want)
+	}
+
+	counts := map[time.Time]int64{
+		time.Date(2024, 1, 10, 0, 0, 0, 0, loc): 6,
+		time.Date(2024, 1, 12, 0, 0, 0, 0, loc): 4,
+		time.Date(2024, 1, 13, 0, 0, 0, 0, loc): 9,
+		time.Date(2024, 1, 14, 0, 0, 0, 0, loc): 3,
+	}
+	points := timeseriesPoints(counts, from, to, models.IntervalDay)
+	previous := comparePeriods(points, 12, counts, previousFrom, from, models.IntervalDay)
+
+	if previous.Total != 10 || len(previous.Points) != 3 {
+		t.Fatalf("previous period = %d clicks in %d points, want 10 in 3", previous.Total, len(previous.Points))
+	}
+	if previous.Change == nil || *previous.Change != 20 {
+		t.Errorf("total change = %v, want 20", previous.Change)
+	}
+	// 6 -> 9 is +50%, nothing -> 3 has no change, 4 -> 0 is -100%
+	if points[0].Change == nil || *points[0].Change != 50 {
+		t.Errorf("first bucket change = %v, want 50", points[0].Change)
+	}
+	if points[1].Change != nil {
+		t.Errorf("second bucket change = %v, want none", *points[1].Change)
+	}
+	if points[2].Change == nil || *points[2].Change != -100 {
+		t.Errorf("third bucket change = %v, want -100", points[2].Change)
+	}
+}
+
+func TestPercentChange(t *testing.T) {
+	if change := percentChange(0, 5); change != nil {
+		t.Errorf("change from zero = %v, want nil", *change)
+	}
+	if change := percentChange(3, 4); change == nil || *change != 33.3 {
+		t.Errorf("change from 3 to 4 = %v, want 33.3", change)
+	}
+}
+
+func TestClampToRetention(t *testing.T) {
+	policy := retention.Policy{Days: map[string]int{"free": 30}}
+	now := time.Date(2024, 3, 31, 10, 0, 0, 0, time.UTC)
+	cutoff := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	from := now.AddDate(0, 0, -7)
+	if got, notice := clampToRetention(policy, "free", from, now, now); !got.Equal(from) || notice != nil {
+		t.Errorf("range within the history = %v, %+v, want it unchanged", got, notice)
+	}
+	if got, notice := clampToRetention(policy, "pro", now.AddDate(-1, 0, 0), now, now); !got.Equal(now.AddDate(-1, 0, 0)) || notice != nil {
+		t.Errorf("unlimited plan = %v, %+v, want it unchanged", got, notice)
+	}
+
+	from = now.AddDate(0, -6, 0)
+	got, notice := clampToRetention(policy, "free", from, now, now)
+	if !got.Equal(cutoff) || notice == nil {
+		t.Fatalf("range before the history = %v, %+v, want %v with a notice", got, notice, cutoff)
+	}
+	if notice.Plan != "free" || notice.Days != 30 || !notice.RequestedFrom.Equal(from) {
+		t.Errorf("notice = %+v", notice)
+	}
+
+	// A range entirely before the history is left empty
+	to := cutoff.AddDate(0, 0, -2)
+	if got, _ := clampToRetention(policy, "free", from, to, now); !got.Equal(to) {
+		t.Errorf("range ending before the history = %v, want %v", got, to)
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Most annotations a link may carry
+const maxLinkAnnotations = 1000
+
+// ListAnnotations godoc
+// @Summary List the annotations of one of your links
+// @ID listAnnotations
+// @Description List the events annotated on the timeline of a link owned by the caller, oldest first. GET /stats/{shortCode}/timeseries returns those of its range too.
+// @Tags Links
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {array} models.LinkAnnotation
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode}/annotations [get]
+func ListAnnotations(c *gin.Context) {
+	urlRecord, ok := annotatedLink(c)
+	if !ok {
+		return
+	}
+	annotations, err := database.AllLinkAnnotations(c.Request.Context(), urlRecord.ID)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list annotations"))
+		return
+	}
+	c.JSON(http.StatusOK, annotations)
+}
+
+// CreateAnnotation godoc
+// @Summary Annotate the timeline of one of your links
+// @ID createAnnotation
+// @Description Mark an event, such as a newsletter going out or a TV spot airing, at a time on the timeline of a link owned by the caller, now by default, so spikes in its click time series can be explained. Locked links can be annotated; a link carries at most 1000 annotations.
+// @Tags Links
+// @Accept json
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param request body models.AnnotationRequest true "Event to annotate"
+// @Success 201 {object} models.LinkAnnotation
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 409 {object} models.ErrorResponse "The link carries 1000 annotations already"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode}/annotations [post]
+func CreateAnnotation(c *gin.Context) {
+	var request models.AnnotationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	urlRecord, ok := annotatedLink(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	count, err := database.CountLinkAnnotations(ctx, urlRecord.ID)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to count annotations"))
+		return
+	}
+	if count >= maxLinkAnnotations {
+		c.Error(models.NewAPIError(http.StatusConflict, models.ErrCodeConflict, "The link carries 1000 annotations already, delete some first"))
+		return
+	}
+
+	annotation := models.LinkAnnotation{
+		URLID:  urlRecord.ID,
+		Time:   time.Now().UTC(),
+		Text:   request.Text,
+		Author: "user:" + strconv.FormatUint(uint64(*middleware.CurrentOwnerID(c)), 10),
+	}
+	if request.Time != nil {
+		annotation.Time = request.Time.UTC()
+	}
+	if err := database.CreateLinkAnnotation(ctx, &annotation); err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create annotation"))
+		return
+	}
+	c.JSON(http.StatusCreated, annotation)
+}
+
+// DeleteAnnotation godoc
+// @Summary Delete an annotation of one of your links
+// @ID deleteAnnotation
+// @Description Remove an event from the timeline of a link owned by the caller
+// @Tags Links
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param id path int true "Annotation ID"
+// @Success 204 "Annotation deleted"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope"
+// @Failure 404 {object} models.ErrorResponse "Short URL or annotation not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode}/annotations/{id} [delete]
+func DeleteAnnotation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Annotation not found"))
+		return
+	}
+	urlRecord, ok := annotatedLink(c)
+	if !ok {
+		return
+	}
+	deleted, err := database.DeleteLinkAnnotation(c.Request.Context(), urlRecord.ID, uint(id))
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete annotation"))
+		return
+	}
+	if !deleted {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Annotation not found"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// annotatedLink loads the link of the caller named by the path, locked or
+// not, as annotations leave the link itself unchanged
+func annotatedLink(c *gin.Context) (*models.URL, bool) {
+	var urlRecord models.URL
+	err := database.DB.WithContext(c.Request.Context()).
+		Where("short_code = ? AND owner_id = ?", pathLinkKey(c), *middleware.CurrentOwnerID(c)).First(&urlRecord).Error
+	if err != nil {
+		c.Error(models.ErrLinkNotFound)
+		return nil, false
+	}
+	return &urlRecord, true
+}
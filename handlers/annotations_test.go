@@ -0,0 +1,162 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/database/databasetest"
+	"url-shortener/handlers"
+	"url-shortener/handlers/handlertest"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// annotationsRouter serves the annotation routes and the click time series
+// over a SQLite database where user 1 owns the link launch. The link only
+// counts clicks, so the time series skips counting click events, which
+// needs PostgreSQL.
+func annotationsRouter(t *testing.T) (*gin.Engine, models.URL) {
+	t.Helper()
+	databasetest.UseSQLite(t)
+	gin.SetMode(gin.TestMode)
+
+	owner := uint(1)
+	link := models.URL{OriginalURL: "https://example.com/launch", ShortCode: "launch", OwnerID: &owner, Analytics: models.AnalyticsCount}
+	if err := database.DB.Create(&link).Error; err != nil {
+		t.Fatalf("creating link: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(middleware.Errors(), handlertest.AsUser())
+	router.GET("/links/:shortCode/annotations", handlers.ListAnnotations)
+	router.POST("/links/:shortCode/annotations", handlers.CreateAnnotation)
+	router.DELETE("/links/:shortCode/annotations/:id", handlers.DeleteAnnotation)
+	router.GET("/stats/:shortCode/timeseries", handlers.GetClickTimeseries)
+	return router, link
+}
+
+func TestAnnotations(t *testing.T) {
+	router, _ := annotationsRouter(t)
+	sent := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	annotate := func(body string) models.LinkAnnotation {
+		t.Helper()
+		recorder := handlertest.Serve(router, 1, http.MethodPost, "/links/launch/annotations", body)
+		var annotation models.LinkAnnotation
+		if recorder.Code != http.StatusCreated || json.Unmarshal(recorder.Body.Bytes(), &annotation) != nil {
+			t.Fatalf("POST annotations %s = %d: %s", body, recorder.Code, recorder.Body)
+		}
+		return annotation
+	}
+	list := func() []models.LinkAnnotation {
+		t.Helper()
+		var annotations []models.LinkAnnotation
+		recorder := handlertest.Serve(router, 1, http.MethodGet, "/links/launch/annotations", "")
+		if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &annotations) != nil {
+			t.Fatalf("GET annotations = %d: %s", recorder.Code, recorder.Body)
+		}
+		return annotations
+	}
+
+	now := annotate(`{"text":"TV spot"}`)
+	if now.Author != "user:1" || time.Since(now.Time) > time.Minute {
+		t.Errorf("annotation without a time = %+v, want now by user:1", now)
+	}
+	earlier := annotate(`{"text":"Newsletter sent","time":"` + sent.Format(time.RFC3339) + `"}`)
+
+	// Oldest first
+	if annotations := list(); len(annotations) != 2 || annotations[0].ID != earlier.ID || annotations[1].ID != now.ID {
+		t.Errorf("annotations = %+v, want the newsletter then the TV spot", annotations)
+	}
+
+	path := "/links/launch/annotations/" + strconv.FormatUint(uint64(earlier.ID), 10)
+	if recorder := handlertest.Serve(router, 1, http.MethodDelete, path, ""); recorder.Code != http.StatusNoContent {
+		t.Fatalf("DELETE annotation = %d: %s", recorder.Code, recorder.Body)
+	}
+	if recorder := handlertest.Serve(router, 1, http.MethodDelete, path, ""); recorder.Code != http.StatusNotFound {
+		t.Errorf("DELETE annotation again = %d, want 404", recorder.Code)
+	}
+	if annotations := list(); len(annotations) != 1 || annotations[0].ID != now.ID {
+		t.Errorf("annotations after deleting = %+v, want the TV spot", annotations)
+	}
+
+	// Links of other users cannot be annotated or listed
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		recorder := handlertest.Serve(router, 2, method, "/links/launch/annotations", `{"text":"Mine"}`)
+		if recorder.Code != http.StatusNotFound {
+			t.Errorf("%s annotations by another user = %d, want 404", method, recorder.Code)
+		}
+	}
+	if recorder := handlertest.Serve(router, 1, http.MethodPost, "/links/launch/annotations", `{"text":""}`); recorder.Code != http.StatusBadRequest {
+		t.Errorf("POST an annotation without text = %d, want 400", recorder.Code)
+	}
+}
+
+func TestAnnotationsCap(t *testing.T) {
+	router, link := annotationsRouter(t)
+
+	annotations := make([]models.LinkAnnotation, 999)
+	for i := range annotations {
+		annotations[i] = models.LinkAnnotation{URLID: link.ID, Time: time.Now(), Text: "event " + strconv.Itoa(i), Author: "user:1"}
+	}
+	if err := database.DB.CreateInBatches(&annotations, 100).Error; err != nil {
+		t.Fatalf("creating annotations: %v", err)
+	}
+
+	if recorder := handlertest.Serve(router, 1, http.MethodPost, "/links/launch/annotations", `{"text":"1000th"}`); recorder.Code != http.StatusCreated {
+		t.Fatalf("POST the 1000th annotation = %d: %s", recorder.Code, recorder.Body)
+	}
+	if recorder := handlertest.Serve(router, 1, http.MethodPost, "/links/launch/annotations", `{"text":"1001st"}`); recorder.Code != http.StatusConflict {
+		t.Errorf("POST the 1001st annotation = %d, want 409", recorder.Code)
+	}
+
+	// Deleting one makes room again
+	path := "/links/launch/annotations/" + strconv.FormatUint(uint64(annotations[0].ID), 10)
+	if recorder := handlertest.Serve(router, 1, http.MethodDelete, path, ""); recorder.Code != http.StatusNoContent {
+		t.Fatalf("DELETE annotation = %d: %s", recorder.Code, recorder.Body)
+	}
+	if recorder := handlertest.Serve(router, 1, http.MethodPost, "/links/launch/annotations", `{"text":"1000th again"}`); recorder.Code != http.StatusCreated {
+		t.Errorf("POST after deleting one = %d, want 201", recorder.Code)
+	}
+}
+
+func TestTimeseriesListsAnnotationsOfItsRange(t *testing.T) {
+	router, link := annotationsRouter(t)
+
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+	annotations := []models.LinkAnnotation{
+		{URLID: link.ID, Time: from.Add(-time.Hour), Text: "before", Author: "user:1"},
+		{URLID: link.ID, Time: from.AddDate(0, 0, 3), Text: "later", Author: "user:1"},
+		{URLID: link.ID, Time: from, Text: "first", Author: "user:1"},
+		{URLID: link.ID, Time: to, Text: "after", Author: "user:1"},
+	}
+	if err := database.DB.Create(&annotations).Error; err != nil {
+		t.Fatalf("creating annotations: %v", err)
+	}
+
+	query := url.Values{"interval": {"day"}, "from": {from.Format(time.RFC3339)}, "to": {to.Format(time.RFC3339)}}
+	recorder := handlertest.Serve(router, 1, http.MethodGet, "/stats/launch/timeseries?"+query.Encode(), "")
+	var response models.TimeseriesResponse
+	if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &response) != nil {
+		t.Fatalf("GET timeseries = %d: %s", recorder.Code, recorder.Body)
+	}
+	if len(response.Points) != 7 {
+		t.Errorf("timeseries has %d points, want 7 days", len(response.Points))
+	}
+	// Those in [from, to), oldest first
+	var texts []string
+	for _, annotation := range response.Annotations {
+		texts = append(texts, annotation.Text)
+	}
+	if len(texts) != 2 || texts[0] != "first" || texts[1] != "later" {
+		t.Errorf("timeseries annotations = %q, want first and later", texts)
+	}
+}
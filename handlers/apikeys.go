@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// API keys are "usk_" followed by 48 hex characters
+const (
+	apiKeyPrefix       = middleware.APIKeyPrefix
+	apiKeyBytes        = 24
+	signingSecretBytes = 32
+)
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @ID listAPIKeys
+// @Description List issued API keys (without secrets)
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} models.APIKey
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/api-keys [get]
+func ListAPIKeys(c *gin.Context) {
+	var keys []models.APIKey
+	if err := database.DB.Order("created_at desc").Find(&keys).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list API keys"))
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// CreateAPIKey godoc
+// @Summary Issue an API key
+// @ID createAPIKey
+// @Description Issue a new API key and HMAC signing secret. Both are only returned once. Scopes default to create and read_stats; expires_in is in days. Links created with a key assigned to a user (user_id) belong to that user, who can list, update and delete them under /links.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body models.CreateAPIKeyRequest true "API key details"
+// @Success 201 {object} models.APIKeyCreatedResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/api-keys [post]
+func CreateAPIKey(c *gin.Context) {
+	var request models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	scopes := request.Scopes
+	if len(scopes) == 0 {
+		scopes = models.DefaultScopes
+	}
+
+	if request.UserID != nil {
+		if err := database.DB.First(&models.User{}, *request.UserID).Error; err != nil {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "User not found"))
+			return
+		}
+	}
+
+	apiKey := models.APIKey{
+		Name:           request.Name,
+		Scopes:         scopes,
+		AllowedDomains: request.AllowedDomains,
+		AllowedTags:    request.AllowedTags,
+		UserID:         request.UserID,
+	}
+	if request.ExpiresIn > 0 {
+		expiresAt := time.Now().AddDate(0, 0, request.ExpiresIn)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	response, err := issueAPIKey(&apiKey)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create API key"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// RotateAPIKey godoc
+// @Summary Rotate an API key
+// @ID rotateAPIKey
+// @Description Issue a replacement key with the same scopes and restrictions. The old key keeps working until the grace period (API_KEY_ROTATION_GRACE, default 24h) ends.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 201 {object} models.APIKeyCreatedResponse
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "API key not found"
+// @Security AdminAuth
+// @Router /admin/api-keys/{id}/rotate [post]
+func RotateAPIKey(c *gin.Context) {
+	var oldKey models.APIKey
+	if err := database.DB.Where("id = ? AND revoked_at IS NULL", c.Param("id")).First(&oldKey).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "API key not found"))
+		return
+	}
+
+	newKey := models.APIKey{
+		Name:           oldKey.Name,
+		Scopes:         oldKey.Scopes,
+		AllowedDomains: oldKey.AllowedDomains,
+		AllowedTags:    oldKey.AllowedTags,
+		UserID:         oldKey.UserID,
+		ExpiresAt:      oldKey.ExpiresAt,
+		RotatedFromID:  &oldKey.ID,
+	}
+	response, err := issueAPIKey(&newKey)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to rotate API key"))
+		return
+	}
+
+	// Grace-period the old key instead of revoking it immediately
+	graceEnds := time.Now().Add(durationFromEnv("API_KEY_ROTATION_GRACE", 24*time.Hour))
+	if oldKey.ExpiresAt == nil || oldKey.ExpiresAt.After(graceEnds) {
+		if err := database.DB.Model(&oldKey).Update("expires_at", graceEnds).Error; err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to set grace period on rotated API key", "api_key_id", oldKey.ID, "error", err)
+		}
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// issueAPIKey generates the key and signing secret for apiKey and stores it
+func issueAPIKey(apiKey *models.APIKey) (*models.APIKeyCreatedResponse, error) {
+	token, err := utils.GenerateToken(apiKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := utils.GenerateToken(signingSecretBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key := apiKeyPrefix + token
+	apiKey.KeyHash = utils.HashToken(key)
+	apiKey.KeyPrefix = key[:len(apiKeyPrefix)+6]
+	apiKey.SigningSecret = secret
+
+	if err := database.DB.Create(apiKey).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.APIKeyCreatedResponse{
+		ID:             apiKey.ID,
+		Name:           apiKey.Name,
+		Key:            key,
+		SigningSecret:  secret,
+		Scopes:         apiKey.Scopes,
+		AllowedDomains: apiKey.AllowedDomains,
+		AllowedTags:    apiKey.AllowedTags,
+		UserID:         apiKey.UserID,
+		ExpiresAt:      apiKey.ExpiresAt,
+		CreatedAt:      apiKey.CreatedAt,
+	}, nil
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @ID revokeAPIKey
+// @Description Revoke an API key so it can no longer authenticate bearer or signed requests
+// @Tags Admin
+// @Param id path int true "API key ID"
+// @Success 204 "API key revoked"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "API key not found"
+// @Security AdminAuth
+// @Router /admin/api-keys/{id} [delete]
+func RevokeAPIKey(c *gin.Context) {
+	result := database.DB.Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", c.Param("id")).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to revoke API key"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "API key not found"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,54 @@
+package handlers_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"url-shortener/database"
+	"url-shortener/database/databasetest"
+	"url-shortener/handlers"
+	"url-shortener/handlers/handlertest"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAPIKeyAllowedTags(t *testing.T) {
+	t.Setenv("SHORTEN_ALLOW_PRIVATE_DESTINATIONS", "true")
+	databasetest.UseSQLite(t)
+	gin.SetMode(gin.TestMode)
+
+	ownerID := uint(3)
+	router := gin.New()
+	router.Use(middleware.Errors(), func(c *gin.Context) {
+		middleware.APIKeyContextKey.Set(c, &models.APIKey{UserID: &ownerID, AllowedTags: []string{"campaign", "ci"}})
+	})
+	router.POST("/shorten", handlers.New(database.Links, handlertest.NewCache()).ShortenURL)
+	router.PUT("/links/:shortCode", handlers.UpdateLink)
+
+	recorder := handlertest.Serve(router, 0, http.MethodPost, "/shorten", `{"url":"https://example.org/a","custom_alias":"tagged","tags":["campaign"]}`)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("shorten with an allowed tag = %d: %s", recorder.Code, recorder.Body)
+	}
+	recorder = handlertest.Serve(router, 0, http.MethodPost, "/shorten", `{"url":"https://example.org/b","tags":["campaign","finance"]}`)
+	if recorder.Code != http.StatusForbidden || !strings.Contains(recorder.Body.String(), "finance") {
+		t.Errorf("shorten with a tag not allowed = %d: %s, want 403", recorder.Code, recorder.Body)
+	}
+	if recorder := handlertest.Serve(router, 0, http.MethodPost, "/shorten", `{"url":"https://example.org/c"}`); recorder.Code != http.StatusCreated {
+		t.Errorf("shorten without tags = %d: %s", recorder.Code, recorder.Body)
+	}
+
+	if recorder := handlertest.Serve(router, 0, http.MethodPut, "/links/tagged", `{"tags":["finance"]}`); recorder.Code != http.StatusForbidden {
+		t.Errorf("update to a tag not allowed = %d: %s, want 403", recorder.Code, recorder.Body)
+	}
+	if recorder := handlertest.Serve(router, 0, http.MethodPut, "/links/tagged", `{"tags":["ci"]}`); recorder.Code != http.StatusOK {
+		t.Errorf("update to an allowed tag = %d: %s", recorder.Code, recorder.Body)
+	}
+	var link models.URL
+	database.DB.Where("short_code = ?", "tagged").First(&link)
+	if len(link.Tags) != 1 || link.Tags[0] != "ci" {
+		t.Errorf("tags = %v, want [ci]", link.Tags)
+	}
+}
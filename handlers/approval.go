@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListPendingURLs godoc
+// @Summary List links awaiting approval
+// @ID listPendingURLs
+// @Description List links in the pending state, oldest first
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} models.URL
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/approvals [get]
+func ListPendingURLs(c *gin.Context) {
+	var pending []models.URL
+	if err := database.DB.Where("status = ?", models.StatusPending).Order("created_at asc").Find(&pending).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list pending URLs"))
+		return
+	}
+
+	c.JSON(http.StatusOK, pending)
+}
+
+// ApproveURL godoc
+// @Summary Approve a pending link
+// @ID approveURL
+// @Description Approve a pending link so that it starts redirecting
+// @Tags Admin
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Pending short URL not found"
+// @Security AdminAuth
+// @Router /admin/approvals/{shortCode}/approve [post]
+func ApproveURL(c *gin.Context) {
+	reviewPendingURL(c, models.StatusActive, models.AuditActionApprove)
+}
+
+// RejectURL godoc
+// @Summary Reject a pending link
+// @ID rejectURL
+// @Description Reject a pending link so that it never redirects
+// @Tags Admin
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Pending short URL not found"
+// @Security AdminAuth
+// @Router /admin/approvals/{shortCode}/reject [post]
+func RejectURL(c *gin.Context) {
+	reviewPendingURL(c, models.StatusRejected, models.AuditActionReject)
+}
+
+func reviewPendingURL(c *gin.Context, status, action string) {
+	shortCode := pathLinkKey(c)
+
+	// A rejected link no longer deduplicates its destination, so shortening
+	// it again creates a new link rather than returning the rejected one
+	updates := map[string]interface{}{"status": status}
+	if status == models.StatusRejected {
+		updates["original_url_hash"] = nil
+	}
+	result := database.DB.Model(&models.URL{}).
+		Where("short_code = ? AND status = ?", shortCode, models.StatusPending).
+		Updates(updates)
+	if result.Error != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update status"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Pending short URL not found"))
+		return
+	}
+
+	recordAudit(c, action, shortCode, "")
+	cache.InvalidateCache(shortCode)
+
+	var urlRecord models.URL
+	if err := database.DB.Where("short_code = ?", shortCode).First(&urlRecord).Error; err == nil {
+		event := models.HookLinkApproved
+		if status == models.StatusRejected {
+			event = models.HookLinkRejected
+			cache.InvalidateOriginalURLMapping(urlRecord.OwnerID, urlRecord.OriginalURL)
+			recordFlaggedLink(c, &urlRecord)
+		}
+		fireLinkHook(c, event, &urlRecord)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"short_code": shortCode, "status": status})
+}
@@ -0,0 +1,74 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/database/databasetest"
+	"url-shortener/handlers"
+	"url-shortener/handlers/handlertest"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRejectURLFreesDestination(t *testing.T) {
+	t.Setenv("SHORTEN_ALLOW_PRIVATE_DESTINATIONS", "true")
+	t.Setenv("REQUIRE_APPROVAL", "true")
+	databasetest.UseSQLite(t)
+	gin.SetMode(gin.TestMode)
+
+	// Admin handlers invalidate the shared cache, which this one stands in for
+	linkCache := handlertest.NewCache()
+	cache.OnInvalidate(linkCache.InvalidateCache)
+	handler := handlers.New(database.Links, linkCache)
+	router := gin.New()
+	router.Use(middleware.Errors())
+	router.POST("/shorten", handler.ShortenURL)
+	router.POST("/admin/approvals/:shortCode/reject", handlers.RejectURL)
+
+	shorten := func() (int, models.URL) {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/shorten", strings.NewReader(`{"url":"https://example.com/held"}`))
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(recorder, request)
+		var link models.URL
+		json.Unmarshal(recorder.Body.Bytes(), &link)
+		return recorder.Code, link
+	}
+
+	code, held := shorten()
+	if code != http.StatusCreated || held.Status != models.StatusPending {
+		t.Fatalf("POST /shorten = %d, %+v, want a pending link", code, held)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/admin/approvals/"+held.ShortCode+"/reject", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("reject = %d: %s", recorder.Code, recorder.Body)
+	}
+	var rejected models.URL
+	database.DB.Where("short_code = ?", held.ShortCode).First(&rejected)
+	if rejected.Status != models.StatusRejected || rejected.OriginalURLHash != nil {
+		t.Errorf("rejected link = %s with hash %v, want rejected without a hash", rejected.Status, rejected.OriginalURLHash)
+	}
+
+	code, again := shorten()
+	if code != http.StatusCreated || again.ShortCode == held.ShortCode {
+		t.Errorf("POST /shorten again = %d, %s, want a new link rather than the rejected %s", code, again.ShortCode, held.ShortCode)
+	}
+
+	// Rejecting it twice finds no pending link
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/admin/approvals/"+held.ShortCode+"/reject", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("second reject = %d, want 404", recorder.Code)
+	}
+}
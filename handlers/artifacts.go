@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"url-shortener/artifacts"
+	"url-shortener/background"
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/qrcode"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How many artifacts GET /admin/artifacts lists
+const artifactsListed = 50
+
+// CreateArtifact godoc
+// @Summary Generate a file in the background
+// @ID createArtifact
+// @Description Generate a large file without holding the request open: links_export writes the links matching created_after, created_before, expired and domain as CSV (default) or JSON, like GET /admin/urls/export; qr_batch renders the QR codes of up to 1000 active links given as short_codes (host/code on branded domains) as PNG (default) or SVG images in a ZIP archive, sized size pixels with error correction ecc. The file is stored in ARTIFACTS_BUCKET or ARTIFACTS_DIR and kept for ARTIFACT_RETENTION (default 24h); poll GET /admin/artifacts/{id} until it is ready for its download URL.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body models.ArtifactRequest true "File to generate"
+// @Success 202 {object} models.Artifact
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 503 {object} models.ErrorResponse "Artifact storage is not configured"
+// @Security AdminAuth
+// @Router /admin/artifacts [post]
+func CreateArtifact(c *gin.Context) {
+	var request models.ArtifactRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	store, ok := artifactStore(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	var filename, contentType string
+	switch request.Kind {
+	case models.ArtifactLinksExport:
+		if request.Format == "" {
+			request.Format = urlFileCSV
+		}
+		if request.Format != urlFileCSV && request.Format != urlFileJSON {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "format must be csv or json for links exports"))
+			return
+		}
+		if request.Domain != "" {
+			if request.Domain, ok = parseDomainFilter(c, request.Domain); !ok {
+				return
+			}
+		}
+		filename, contentType = urlExportFilename(request.Format, now), urlFileContentType(request.Format)
+	case models.ArtifactQRBatch:
+		if request.Format == "" {
+			request.Format = "png"
+		}
+		if request.Format != "png" && request.Format != "svg" {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "format must be png or svg for QR batches"))
+			return
+		}
+		if len(request.ShortCodes) == 0 {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "short_codes is required for QR batches"))
+			return
+		}
+		if request.Size == 0 {
+			request.Size = defaultQRSize
+		}
+		if request.ECC == "" {
+			request.ECC = "M"
+		}
+		filename, contentType = "qr-codes-"+now.UTC().Format("20060102-150405")+".zip", "application/zip"
+	}
+
+	token, err := utils.GenerateToken(16)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to queue artifact"))
+		return
+	}
+	artifact := models.Artifact{
+		ExpiresAt:   now.Add(artifacts.Retention()),
+		Kind:        request.Kind,
+		Request:     request,
+		Status:      models.ArtifactStatusPending,
+		Filename:    filename,
+		ContentType: contentType,
+		Key:         request.Kind + "/" + now.UTC().Format("2006-01-02") + "/" + token + "/" + filename,
+		BaseURL:     shortURLBase(c, ""),
+	}
+	if err := database.DB.WithContext(c.Request.Context()).Create(&artifact).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to queue artifact"))
+		return
+	}
+
+	generated := artifact
+	ctx := context.WithoutCancel(c.Request.Context())
+	background.Go(func() { generateArtifact(ctx, store, &generated) })
+	c.JSON(http.StatusAccepted, artifact)
+}
+
+// GetArtifact godoc
+// @Summary Status of a generated file
+// @ID getArtifact
+// @Description Report whether a file is still being generated, ready or failed. Ready files carry a download_url valid for ARTIFACT_URL_EXPIRY (default 15m); fetch the artifact again for a fresh one.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Artifact ID"
+// @Success 200 {object} models.Artifact
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Artifact not found"
+// @Failure 503 {object} models.ErrorResponse "Artifact storage is not configured"
+// @Security AdminAuth
+// @Router /admin/artifacts/{id} [get]
+func GetArtifact(c *gin.Context) {
+	store, ok := artifactStore(c)
+	if !ok {
+		return
+	}
+	var artifact models.Artifact
+	if err := database.DB.WithContext(c.Request.Context()).First(&artifact, c.Param("id")).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Artifact not found"))
+		return
+	}
+	signArtifact(c, store, &artifact)
+	c.JSON(http.StatusOK, artifact)
+}
+
+// ListArtifacts godoc
+// @Summary List generated files
+// @ID listArtifacts
+// @Description List the latest 50 files generated with POST /admin/artifacts and not expired yet, newest first, with download URLs for those ready
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} models.ArtifactsResponse
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 503 {object} models.ErrorResponse "Artifact storage is not configured"
+// @Security AdminAuth
+// @Router /admin/artifacts [get]
+func ListArtifacts(c *gin.Context) {
+	store, ok := artifactStore(c)
+	if !ok {
+		return
+	}
+	list, err := database.RecentArtifacts(c.Request.Context(), artifactsListed)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list artifacts"))
+		return
+	}
+	for i := range list {
+		signArtifact(c, store, &list[i])
+	}
+	c.JSON(http.StatusOK, models.ArtifactsResponse{Artifacts: list})
+}
+
+// DeleteArtifact godoc
+// @Summary Delete a generated file
+// @ID deleteArtifact
+// @Description Delete a file before it expires, e.g. once downloaded. Download URLs handed out already stop working.
+// @Tags Admin
+// @Param id path int true "Artifact ID"
+// @Success 204 "Deleted"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Artifact not found"
+// @Failure 503 {object} models.ErrorResponse "Artifact storage is not configured"
+// @Security AdminAuth
+// @Router /admin/artifacts/{id} [delete]
+func DeleteArtifact(c *gin.Context) {
+	store, ok := artifactStore(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	var artifact models.Artifact
+	if err := database.DB.WithContext(ctx).First(&artifact, c.Param("id")).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Artifact not found"))
+		return
+	}
+	if err := store.Delete(ctx, artifact.Key); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete artifact", "artifact_id", artifact.ID, "error", err)
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete artifact"))
+		return
+	}
+	if err := database.DB.WithContext(ctx).Delete(&artifact).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete artifact"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DownloadArtifact godoc
+// @Summary Download a generated file
+// @ID downloadArtifact
+// @Description Download a file kept in ARTIFACTS_DIR through the signed URL GET /admin/artifacts/{id} hands out. Files kept in ARTIFACTS_BUCKET are downloaded from the bucket instead.
+// @Tags Admin
+// @Produce octet-stream
+// @Param key path string true "Artifact key"
+// @Param expires query int true "Expiry of the URL, in Unix seconds"
+// @Param filename query string true "Name to save the file as"
+// @Param signature query string true "Signature of the URL"
+// @Success 200 {file} binary "Generated file"
+// @Failure 403 {object} models.ErrorResponse "Invalid or expired signature"
+// @Failure 404 {object} models.ErrorResponse "Artifact not found or expired"
+// @Router /artifacts/{key} [get]
+func DownloadArtifact(c *gin.Context) {
+	store, ok := artifacts.Current().(*artifacts.DiskStore)
+	if !ok {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Artifact not found"))
+		return
+	}
+	path, filename, err := store.Open(strings.TrimPrefix(c.Param("key"), "/"), c.Request.URL.Query())
+	switch {
+	case errors.Is(err, artifacts.ErrURLExpired):
+		c.Error(models.NewAPIError(http.StatusForbidden, models.ErrCodeForbidden, "Download URL has expired, ask for a new one"))
+		return
+	case err != nil:
+		c.Error(models.NewAPIError(http.StatusForbidden, models.ErrCodeForbidden, "Invalid download URL"))
+		return
+	}
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Artifact not found"))
+		return
+	}
+	c.Header("Cache-Control", "private, no-store")
+	c.FileAttachment(path, filename)
+}
+
+// artifactStore returns the configured artifact store, writing the error
+// response when there is none
+func artifactStore(c *gin.Context) (artifacts.Store, bool) {
+	store := artifacts.Current()
+	if store == nil {
+		c.Error(models.NewAPIError(http.StatusServiceUnavailable, models.ErrCodeUnavailable, "Artifact storage is not configured; set ARTIFACTS_BUCKET or ARTIFACTS_DIR"))
+		return nil, false
+	}
+	return store, true
+}
+
+// signArtifact sets the download URL of a ready artifact, absolute even
+// when the store serves it through the API
+func signArtifact(c *gin.Context, store artifacts.Store, artifact *models.Artifact) {
+	if artifact.Status != models.ArtifactStatusReady {
+		return
+	}
+	downloadURL, err := store.DownloadURL(artifact.Key, artifact.Filename, artifacts.URLExpiry())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to sign the download URL of artifact", "artifact_id", artifact.ID, "error", err)
+		return
+	}
+	if strings.HasPrefix(downloadURL, "/") {
+		downloadURL = requestScheme(c) + "://" + requestHost(c) + downloadURL
+	}
+	artifact.DownloadURL = downloadURL
+}
+
+// generateArtifact writes the file of a pending artifact to store and
+// records whether it is ready or failed
+func generateArtifact(ctx context.Context, store artifacts.Store, artifact *models.Artifact) {
+	ctx, cancel := context.WithTimeout(ctx, artifacts.GenerationTimeout)
+	defer cancel()
+	ctx = database.WithRoute(ctx, "artifact_generator")
+
+	var body []byte
+	var items int
+	var err error
+	if artifact.Kind == models.ArtifactQRBatch {
+		body, items, err = qrBatchFile(ctx, artifact)
+	} else {
+		body, items, err = linksExportFile(ctx, artifact.Request)
+	}
+	if err == nil {
+		err = store.Put(ctx, artifact.Key, body, artifact.ContentType)
+	}
+
+	updates := map[string]interface{}{"status": models.ArtifactStatusReady, "size": len(body), "items": items}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate artifact", "artifact_id", artifact.ID, "kind", artifact.Kind, "error", err)
+		updates = map[string]interface{}{"status": models.ArtifactStatusFailed, "error": err.Error()}
+	}
+	if err := database.DB.WithContext(ctx).Model(artifact).Updates(updates).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to save artifact", "artifact_id", artifact.ID, "error", err)
+	}
+}
+
+// linksExportFile writes the links matching the filters of request as a
+// file like GET /admin/urls/export, returning how many it holds
+func linksExportFile(ctx context.Context, request models.ArtifactRequest) ([]byte, int, error) {
+	filter := linkFilter{
+		createdAfter:  request.CreatedAfter,
+		createdBefore: request.CreatedBefore,
+		expired:       request.Expired,
+		domain:        request.Domain,
+	}
+	var file bytes.Buffer
+	written, err := writeURLFile(filterLinks(database.DB.WithContext(ctx), filter), newURLFileWriter(&file, request.Format), func() {})
+	return file.Bytes(), written, err
+}
+
+// qrBatchFile renders the QR codes of the active links of an artifact into
+// a ZIP archive, one image per link named after its key, returning how
+// many it holds
+func qrBatchFile(ctx context.Context, artifact *models.Artifact) ([]byte, int, error) {
+	request := artifact.Request
+	level, err := qrcode.ParseLevel(request.ECC)
+	if err != nil {
+		return nil, 0, err
+	}
+	var links []models.URL
+	err = database.DB.WithContext(ctx).
+		Where("short_code IN ? AND status = ? AND (expires_at IS NULL OR expires_at > ?)", request.ShortCodes, models.StatusActive, time.Now()).
+		Order("short_code").Find(&links).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(links) == 0 {
+		return nil, 0, errors.New("none of the short codes is an active link")
+	}
+
+	var file bytes.Buffer
+	archive := zip.NewWriter(&file)
+	for _, link := range links {
+		host, code := models.SplitLinkKey(link.ShortCode)
+		shortURL := strings.TrimRight(artifact.BaseURL, "/") + "/" + code
+		if host != "" {
+			shortURL = "https://" + host + "/" + code
+		}
+		qr, err := qrcode.Encode([]byte(shortURL), level)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", link.ShortCode, err)
+		}
+		image := qr.SVG(request.Size)
+		if request.Format == "png" {
+			if image, err = qr.PNG(request.Size); err != nil {
+				return nil, 0, fmt.Errorf("%s: %w", link.ShortCode, err)
+			}
+		}
+		entry, err := archive.Create(strings.ReplaceAll(link.ShortCode, "/", "_") + "." + request.Format)
+		if err != nil {
+			return nil, 0, err
+		}
+		if _, err := entry.Write(image); err != nil {
+			return nil, 0, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, 0, err
+	}
+	return file.Bytes(), len(links), nil
+}
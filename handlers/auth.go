@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Session tokens are a prefix followed by 64 hex characters
+const sessionTokenBytes = 32
+
+// Login godoc
+// @Summary Log in
+// @ID login
+// @Description Start a dashboard session with email and password (plus otp_code when 2FA is enabled)
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body models.LoginRequest true "Credentials"
+// @Success 201 {object} models.SessionTokensResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid email, password or two-factor code"
+// @Router /auth/login [post]
+func Login(c *gin.Context) {
+	var request models.LoginRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Where("email = ?", request.Email).First(&user).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusUnauthorized, models.ErrCodeUnauthorized, "Invalid email or password"))
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(request.Password)) != nil {
+		c.Error(models.NewAPIError(http.StatusUnauthorized, models.ErrCodeUnauthorized, "Invalid email or password"))
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		if request.OTPCode == "" {
+			c.Error(&models.APIError{Status: http.StatusUnauthorized, Code: models.ErrCodeTwoFactorRequired, Message: "Two-factor code required", TwoFactorRequired: true})
+			return
+		}
+		if !checkSecondFactor(c.Request.Context(), &user, request.OTPCode) {
+			c.Error(&models.APIError{Status: http.StatusUnauthorized, Code: models.ErrCodeTwoFactorInvalid, Message: "Invalid two-factor code", TwoFactorRequired: true})
+			return
+		}
+	}
+
+	session := models.Session{
+		UserID:    user.ID,
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+	response, err := issueSessionTokens(&session)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create session"))
+		return
+	}
+	if err := database.DB.Create(&session).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create session"))
+		return
+	}
+	response.SessionID = session.ID
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// RefreshSession godoc
+// @Summary Refresh a session
+// @ID refreshSession
+// @Description Exchange a refresh token for new access and refresh tokens. The old tokens stop working.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshRequest true "Refresh token"
+// @Success 200 {object} models.SessionTokensResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid or expired refresh token"
+// @Router /auth/refresh [post]
+func RefreshSession(c *gin.Context) {
+	var request models.RefreshRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	var session models.Session
+	err := database.DB.
+		Where("refresh_token_hash = ? AND revoked_at IS NULL AND refresh_expires_at > ?", utils.HashToken(request.RefreshToken), time.Now()).
+		First(&session).Error
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusUnauthorized, models.ErrCodeUnauthorized, "Invalid or expired refresh token"))
+		return
+	}
+
+	refreshTokenHash := session.RefreshTokenHash
+	response, err := issueSessionTokens(&session)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to refresh session"))
+		return
+	}
+	// Rotate only the tokens just read, so a refresh racing a revocation or
+	// another refresh of the same token does not bring the session back
+	result := database.DB.Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, refreshTokenHash).
+		Updates(map[string]interface{}{
+			"token_hash":         session.TokenHash,
+			"refresh_token_hash": session.RefreshTokenHash,
+			"expires_at":         session.ExpiresAt,
+			"refresh_expires_at": session.RefreshExpiresAt,
+			"last_seen_at":       session.LastSeenAt,
+		})
+	if result.Error != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to refresh session"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.NewAPIError(http.StatusUnauthorized, models.ErrCodeUnauthorized, "Invalid or expired refresh token"))
+		return
+	}
+	response.SessionID = session.ID
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout godoc
+// @Summary Log out
+// @ID logout
+// @Description Revoke the current session
+// @Tags Auth
+// @Success 204 "Logged out"
+// @Failure 401 {object} models.ErrorResponse "Invalid or expired session"
+// @Security SessionAuth
+// @Router /auth/logout [post]
+func Logout(c *gin.Context) {
+	session := middleware.CurrentSession(c)
+	if err := database.DB.Model(session).Update("revoked_at", time.Now()).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to revoke session"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListSessions godoc
+// @Summary List my sessions
+// @ID listSessions
+// @Description List the current user's active sessions across devices
+// @Tags Auth
+// @Produce json
+// @Success 200 {array} models.Session
+// @Failure 401 {object} models.ErrorResponse "Invalid or expired session"
+// @Security SessionAuth
+// @Router /auth/sessions [get]
+func ListSessions(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var sessions []models.Session
+	err := database.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND refresh_expires_at > ?", user.ID, time.Now()).
+		Order("last_seen_at desc").
+		Find(&sessions).Error
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list sessions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession godoc
+// @Summary Revoke one of my sessions
+// @ID revokeSession
+// @Description Sign out another device by revoking one of the current user's sessions
+// @Tags Auth
+// @Param id path int true "Session ID"
+// @Success 204 "Session revoked"
+// @Failure 401 {object} models.ErrorResponse "Invalid or expired session"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Security SessionAuth
+// @Router /auth/sessions/{id} [delete]
+func RevokeSession(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	result := database.DB.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", c.Param("id"), user.ID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to revoke session"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Session not found"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// issueSessionTokens generates fresh access and refresh tokens for session,
+// storing their hashes and expirations on it. Neither outlives
+// SESSION_MAX_LIFETIME from the login, however often it is refreshed.
+func issueSessionTokens(session *models.Session) (*models.SessionTokensResponse, error) {
+	accessToken, err := utils.GenerateToken(sessionTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := utils.GenerateToken(sessionTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken = middleware.SessionTokenPrefix + accessToken
+	now := time.Now()
+
+	loggedInAt := session.CreatedAt
+	if loggedInAt.IsZero() {
+		loggedInAt = now
+	}
+	endsAt := loggedInAt.Add(durationFromEnv("SESSION_MAX_LIFETIME", 90*24*time.Hour))
+
+	session.TokenHash = utils.HashToken(accessToken)
+	session.RefreshTokenHash = utils.HashToken(refreshToken)
+	session.ExpiresAt = earliest(now.Add(durationFromEnv("SESSION_TTL", 12*time.Hour)), endsAt)
+	session.RefreshExpiresAt = earliest(now.Add(durationFromEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour)), endsAt)
+	session.LastSeenAt = now
+
+	return &models.SessionTokensResponse{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresAt:        session.ExpiresAt,
+		RefreshExpiresAt: session.RefreshExpiresAt,
+	}, nil
+}
+
+// earliest returns the earlier of a and b
+func earliest(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+// durationFromEnv parses a positive duration such as "12h" from key
+func durationFromEnv(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
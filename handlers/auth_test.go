@@ -0,0 +1,165 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/background"
+	"url-shortener/database"
+	"url-shortener/database/databasetest"
+	"url-shortener/handlers"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// sessionsRouter serves logging in and refreshing, and the routes accepting
+// dashboard sessions, against an in-memory SQLite database
+func sessionsRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	databasetest.UseSQLite(t)
+	// Sessions are marked seen in the background, before the database closes
+	t.Cleanup(func() { background.Wait(5 * time.Second) })
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(middleware.Errors())
+	router.POST("/auth/login", handlers.Login)
+	router.POST("/auth/refresh", handlers.RefreshSession)
+	router.POST("/auth/logout", middleware.SessionAuth(), handlers.Logout)
+	router.GET("/links", middleware.SessionKeyAuth(), middleware.APIKeyAuth(), middleware.RequireKeyOwner(), handlers.ListLinks)
+	router.GET("/admin/users", middleware.SessionKeyAuth(), middleware.APIKeyAuth(), middleware.AdminAuth(), handlers.ListUsers)
+	return router
+}
+
+// createAccount creates a user who logs in with password "correct horse"
+func createAccount(t *testing.T, email, role string) models.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := models.User{Email: email, PasswordHash: string(hash), Role: role}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	return user
+}
+
+// sendJSON sends body to path with an optional bearer token
+func sendJSON(router http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// logIn starts a session for email and returns its tokens
+func logIn(t *testing.T, router http.Handler, email string) models.SessionTokensResponse {
+	t.Helper()
+	recorder := sendJSON(router, http.MethodPost, "/auth/login", "", `{"email":"`+email+`","password":"correct horse"}`)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("login = %d: %s", recorder.Code, recorder.Body)
+	}
+	var tokens models.SessionTokensResponse
+	json.Unmarshal(recorder.Body.Bytes(), &tokens)
+	return tokens
+}
+
+func TestRefreshSessionRotatesTokens(t *testing.T) {
+	router := sessionsRouter(t)
+	createAccount(t, "user@example.com", models.RoleUser)
+	tokens := logIn(t, router, "user@example.com")
+
+	recorder := sendJSON(router, http.MethodPost, "/auth/refresh", "", `{"refresh_token":"`+tokens.RefreshToken+`"}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("refresh = %d: %s", recorder.Code, recorder.Body)
+	}
+	var refreshed models.SessionTokensResponse
+	json.Unmarshal(recorder.Body.Bytes(), &refreshed)
+	if refreshed.SessionID != tokens.SessionID || refreshed.RefreshToken == tokens.RefreshToken {
+		t.Errorf("refresh = %+v, want new tokens for session %d", refreshed, tokens.SessionID)
+	}
+
+	if recorder := sendJSON(router, http.MethodPost, "/auth/refresh", "", `{"refresh_token":"`+tokens.RefreshToken+`"}`); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("refresh with the used refresh token = %d, want 401", recorder.Code)
+	}
+	if recorder := sendJSON(router, http.MethodGet, "/links", tokens.AccessToken, ""); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("old access token = %d, want 401", recorder.Code)
+	}
+	if recorder := sendJSON(router, http.MethodGet, "/links", refreshed.AccessToken, ""); recorder.Code != http.StatusOK {
+		t.Errorf("new access token = %d: %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestRefreshSessionRefusedOnceRevoked(t *testing.T) {
+	router := sessionsRouter(t)
+	createAccount(t, "user@example.com", models.RoleUser)
+	tokens := logIn(t, router, "user@example.com")
+
+	if recorder := sendJSON(router, http.MethodPost, "/auth/logout", tokens.AccessToken, ""); recorder.Code != http.StatusNoContent {
+		t.Fatalf("logout = %d: %s", recorder.Code, recorder.Body)
+	}
+	if recorder := sendJSON(router, http.MethodPost, "/auth/refresh", "", `{"refresh_token":"`+tokens.RefreshToken+`"}`); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("refresh of a revoked session = %d, want 401", recorder.Code)
+	}
+	var session models.Session
+	database.DB.First(&session, tokens.SessionID)
+	if session.RevokedAt == nil {
+		t.Error("refresh brought the revoked session back")
+	}
+}
+
+func TestSessionMaxLifetime(t *testing.T) {
+	t.Setenv("SESSION_MAX_LIFETIME", "1h")
+	router := sessionsRouter(t)
+	createAccount(t, "user@example.com", models.RoleUser)
+	tokens := logIn(t, router, "user@example.com")
+	if limit := time.Now().Add(time.Hour); tokens.RefreshExpiresAt.After(limit) {
+		t.Errorf("refresh token expires at %s, after the session's end at %s", tokens.RefreshExpiresAt, limit)
+	}
+
+	// Refreshing near the end does not extend the session
+	loggedInAt := time.Now().Add(-50 * time.Minute)
+	database.DB.Model(&models.Session{}).Where("id = ?", tokens.SessionID).Update("created_at", loggedInAt)
+	recorder := sendJSON(router, http.MethodPost, "/auth/refresh", "", `{"refresh_token":"`+tokens.RefreshToken+`"}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("refresh = %d: %s", recorder.Code, recorder.Body)
+	}
+	var refreshed models.SessionTokensResponse
+	json.Unmarshal(recorder.Body.Bytes(), &refreshed)
+	if end := loggedInAt.Add(time.Hour); refreshed.ExpiresAt.After(end) || refreshed.RefreshExpiresAt.After(end) {
+		t.Errorf("refreshed tokens expire at %s and %s, after the session's end at %s", refreshed.ExpiresAt, refreshed.RefreshExpiresAt, end)
+	}
+}
+
+func TestSessionsOnManagementRoutes(t *testing.T) {
+	router := sessionsRouter(t)
+	createAccount(t, "user@example.com", models.RoleUser)
+	createAccount(t, "admin@example.com", models.RoleAdmin)
+	user := logIn(t, router, "user@example.com")
+	admin := logIn(t, router, "admin@example.com")
+
+	if recorder := sendJSON(router, http.MethodGet, "/links", user.AccessToken, ""); recorder.Code != http.StatusOK {
+		t.Errorf("user session on /links = %d: %s", recorder.Code, recorder.Body)
+	}
+	if recorder := sendJSON(router, http.MethodGet, "/admin/users", user.AccessToken, ""); recorder.Code != http.StatusForbidden {
+		t.Errorf("user session on /admin = %d, want 403", recorder.Code)
+	}
+	if recorder := sendJSON(router, http.MethodGet, "/admin/users", admin.AccessToken, ""); recorder.Code != http.StatusOK {
+		t.Errorf("admin session on /admin = %d: %s", recorder.Code, recorder.Body)
+	}
+	if recorder := sendJSON(router, http.MethodGet, "/links", "uss_unknown", ""); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("unknown session on /links = %d, want 401", recorder.Code)
+	}
+}
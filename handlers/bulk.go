@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/domains"
+	"url-shortener/jobs"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How many bulk operations GET /admin/links/bulk lists
+const bulkOperationsListed = 50
+
+// StartBulkOperation godoc
+// @Summary Expire or disable links in bulk
+// @ID startBulkOperation
+// @Description Queue an action on every link matching all the filters given, such as every link to a compromised domain: expire makes the links answer 410 Gone now, disable makes them answer 410 Gone with LINK_DISABLED. At least one of tag, domain (the host or any of its subdomains) and created_before is required. The operation runs in the background, resuming after restarts; follow its progress with GET /admin/links/bulk/{id}. Locked links are skipped, and every link changed is audit-logged.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body models.BulkLinkRequest true "Action and filters"
+// @Success 202 {object} models.BulkOperation
+// @Failure 400 {object} models.ErrorResponse "Invalid request, no filter, or a domain filter while destinations are encrypted"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/links/bulk [post]
+func StartBulkOperation(c *gin.Context) {
+	var request models.BulkLinkRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	if request.Tag == "" && request.Domain == "" && request.CreatedBefore == nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "tag, domain or created_before is required"))
+		return
+	}
+
+	op := models.BulkOperation{
+		Action:        request.Action,
+		Tag:           request.Tag,
+		CreatedBefore: request.CreatedBefore,
+		Status:        models.BulkStatusQueued,
+	}
+	if request.Domain != "" {
+		domain, ok := parseDomainFilter(c, request.Domain)
+		if !ok {
+			return
+		}
+		op.Domain = domain
+	}
+
+	matched, err := database.CountBulkLinks(c.Request.Context(), &op)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to count matching links"))
+		return
+	}
+	op.Matched = matched
+	if err := database.DB.WithContext(c.Request.Context()).Create(&op).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to queue bulk operation"))
+		return
+	}
+	jobs.WakeBulkOperationRunner()
+
+	action := models.AuditActionBulkExpire
+	if op.Action == models.BulkActionDisable {
+		action = models.AuditActionBulkDisable
+	}
+	recordAudit(c, action, "", fmt.Sprintf("bulk operation %d on %d links matching %s", op.ID, op.Matched, bulkFilterDescription(&op)))
+	c.JSON(http.StatusAccepted, op)
+}
+
+// GetBulkOperation godoc
+// @Summary Progress of a bulk operation
+// @ID getBulkOperation
+// @Description Report how many of the links matched by a bulk operation were processed, updated and skipped, and whether it completed or failed
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Bulk operation ID"
+// @Success 200 {object} models.BulkOperation
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Bulk operation not found"
+// @Security AdminAuth
+// @Router /admin/links/bulk/{id} [get]
+func GetBulkOperation(c *gin.Context) {
+	var op models.BulkOperation
+	if err := database.DB.WithContext(c.Request.Context()).First(&op, c.Param("id")).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Bulk operation not found"))
+		return
+	}
+	c.JSON(http.StatusOK, op)
+}
+
+// ListBulkOperations godoc
+// @Summary List bulk operations
+// @ID listBulkOperations
+// @Description List the latest 50 bulk operations, newest first
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} models.BulkOperationsResponse
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/links/bulk [get]
+func ListBulkOperations(c *gin.Context) {
+	operations, err := database.RecentBulkOperations(c.Request.Context(), bulkOperationsListed)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list bulk operations"))
+		return
+	}
+	c.JSON(http.StatusOK, models.BulkOperationsResponse{Operations: operations})
+}
+
+// parseDomainFilter normalizes a destination domain to filter links by,
+// writing the error response when it is invalid or cannot be filtered on
+func parseDomainFilter(c *gin.Context, raw string) (string, bool) {
+	domain, err := domains.Normalize(raw)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return "", false
+	}
+	if !database.DestinationDomainFilterable() {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "domain filters are unavailable while destinations are encrypted (URL_ENCRYPTION_KEY)"))
+		return "", false
+	}
+	return domain, true
+}
+
+// bulkFilterDescription describes the filter of a bulk operation for the audit log
+func bulkFilterDescription(op *models.BulkOperation) string {
+	var parts []string
+	if op.Tag != "" {
+		parts = append(parts, "tag "+op.Tag)
+	}
+	if op.Domain != "" {
+		parts = append(parts, "domain "+op.Domain)
+	}
+	if op.CreatedBefore != nil {
+		parts = append(parts, "created before "+op.CreatedBefore.UTC().Format(time.RFC3339))
+	}
+	return strings.Join(parts, ", ")
+}
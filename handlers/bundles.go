@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/routing"
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Upper bound for the links in one bundle
+const maxBundleLinks = 1000
+
+// ExportLinks godoc
+// @Summary Export links as a signed bundle
+// @ID exportLinks
+// @Description Serialize active links, selected by short code and/or tag, with their short codes, destinations, expiry, tags, variants and preview cards. The bundle is signed with LINK_BUNDLE_SECRET so another instance sharing the secret can import it, e.g. to promote links from staging to production. Clicks and history are not exported.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body models.LinkExportRequest true "Links to export"
+// @Success 200 {object} models.LinkBundle
+// @Failure 400 {object} models.ErrorResponse "Invalid request or too many links"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 503 {object} models.ErrorResponse "LINK_BUNDLE_SECRET is not set"
+// @Security AdminAuth
+// @Router /admin/links/export [post]
+func ExportLinks(c *gin.Context) {
+	var request models.LinkExportRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	if len(request.ShortCodes) == 0 && request.Tag == "" {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "short_codes or tag is required"))
+		return
+	}
+	secret, ok := bundleSecret(c)
+	if !ok {
+		return
+	}
+
+	query := database.DB.WithContext(c.Request.Context()).
+		Where("status = ? AND NOT inert", models.StatusActive).
+		Order("created_at asc").Limit(maxBundleLinks + 1)
+	if len(request.ShortCodes) > 0 {
+		query = query.Where("short_code IN ?", request.ShortCodes)
+	}
+	if request.Tag != "" {
+		tag, _ := json.Marshal([]string{request.Tag})
+		query = query.Where("tags @> ?::jsonb", string(tag))
+	}
+	var urls []models.URL
+	if err := query.Find(&urls).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to load links"))
+		return
+	}
+	if len(urls) > maxBundleLinks {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Too many links, export at most 1000 at a time"))
+		return
+	}
+
+	variants, err := bundleVariants(c, urls)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to load link variants"))
+		return
+	}
+
+	bundle := models.LinkBundle{
+		Version:    models.LinkBundleVersion,
+		Source:     c.Request.Host,
+		ExportedAt: time.Now().UTC(),
+		Links:      make([]models.BundleLink, 0, len(urls)),
+	}
+	for _, urlRecord := range urls {
+		bundle.Links = append(bundle.Links, models.BundleLink{
+			ShortCode:     urlRecord.ShortCode,
+			URL:           urlRecord.OriginalURL,
+			ExpiresAt:     urlRecord.ExpiresAt,
+			Tags:          urlRecord.Tags,
+			NoIndex:       urlRecord.NoIndex,
+			VariantMode:   urlRecord.VariantMode,
+			Analytics:     models.AnalyticsMode(urlRecord.AnalyticsMode()),
+			MaxClicks:     urlRecord.ClicksRemaining,
+			UTMSource:     urlRecord.UTMSource,
+			UTMMedium:     urlRecord.UTMMedium,
+			UTMCampaign:   urlRecord.UTMCampaign,
+			RedirectType:  urlRecord.RedirectType,
+			Variants:      variants[urlRecord.ID],
+			RoutingRules:  urlRecord.RoutingRules,
+			OGTitle:       urlRecord.OGTitle,
+			OGDescription: urlRecord.OGDescription,
+			OGImage:       urlRecord.OGImage,
+		})
+	}
+	bundle.Signature = signBundle(secret, bundle)
+
+	c.JSON(http.StatusOK, bundle)
+}
+
+// bundleVariants loads the variants of the split links among urls
+func bundleVariants(c *gin.Context, urls []models.URL) (map[uint][]models.VariantRequest, error) {
+	var ids []uint
+	for _, urlRecord := range urls {
+		if urlRecord.VariantMode != "" {
+			ids = append(ids, urlRecord.ID)
+		}
+	}
+	byURL := make(map[uint][]models.VariantRequest)
+	if len(ids) == 0 {
+		return byURL, nil
+	}
+
+	var variants []models.LinkVariant
+	if err := database.DB.WithContext(c.Request.Context()).Where("url_id IN ?", ids).Order("id asc").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	for _, variant := range variants {
+		byURL[variant.URLID] = append(byURL[variant.URLID], models.VariantRequest{
+			Name:   variant.Name,
+			URL:    variant.Destination,
+			Weight: variant.Weight,
+		})
+	}
+	return byURL, nil
+}
+
+// ImportLinks godoc
+// @Summary Import a signed link bundle
+// @ID importLinks
+// @Description Create the links of a bundle exported by an instance sharing LINK_BUNDLE_SECRET, keeping their short codes. Links whose short code is taken, reserved or invalid, or whose destination is blocked by brand safety rules, are skipped and reported. Expiry is capped by LINK_MAX_EXPIRY_DAYS, and links are held for approval when REQUIRE_APPROVAL is set.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body models.LinkBundle true "Signed link bundle"
+// @Success 200 {object} models.LinkImportResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid bundle"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 403 {object} models.ErrorResponse "Bundle signature is invalid"
+// @Failure 503 {object} models.ErrorResponse "LINK_BUNDLE_SECRET is not set"
+// @Security AdminAuth
+// @Router /admin/links/import [post]
+func ImportLinks(c *gin.Context) {
+	var bundle models.LinkBundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	if bundle.Version != models.LinkBundleVersion {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Unsupported bundle version"))
+		return
+	}
+	if len(bundle.Links) > maxBundleLinks {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Too many links, import at most 1000 at a time"))
+		return
+	}
+	secret, ok := bundleSecret(c)
+	if !ok {
+		return
+	}
+	if !hmac.Equal([]byte(signBundle(secret, bundle)), []byte(bundle.Signature)) {
+		c.Error(models.NewAPIError(http.StatusForbidden, models.ErrCodeForbidden, "Bundle signature is invalid"))
+		return
+	}
+
+	response := models.LinkImportResponse{Imported: []string{}}
+	now := time.Now()
+	for _, link := range bundle.Links {
+		if err := importLink(c, link, now); err != nil {
+			response.Skipped = append(response.Skipped, models.LinkImportSkip{ShortCode: link.ShortCode, Reason: err.Error()})
+			continue
+		}
+		response.Imported = append(response.Imported, link.ShortCode)
+	}
+
+	slog.InfoContext(c.Request.Context(), "Imported links from a bundle", "source", bundle.Source, "imported", len(response.Imported), "skipped", len(response.Skipped))
+	c.JSON(http.StatusOK, response)
+}
+
+// importLink creates one bundled link, returning why it was skipped
+func importLink(c *gin.Context, link models.BundleLink, now time.Time) error {
+	// Links of a branded domain keep it, which must exist on this instance
+	host, shortCode := models.SplitLinkKey(link.ShortCode)
+	if err := service.ValidateAlias(shortCode); err != nil {
+		return err
+	}
+	if link.VariantMode != "" && link.VariantMode != models.VariantModeWeighted && link.VariantMode != models.VariantModeBandit {
+		return errors.New("unknown variant mode")
+	}
+	if err := routing.Validate(link.RoutingRules); err != nil {
+		return err
+	}
+
+	// The destinations must pass this instance's checks too
+	destinations := []string{link.URL}
+	for _, variant := range link.Variants {
+		destinations = append(destinations, variant.URL)
+	}
+	destinations = append(destinations, routing.RedirectURLs(link.RoutingRules)...)
+	safetyAction, err := checkImportedDestinations(c, destinations)
+	if err != nil {
+		return err
+	}
+
+	request := models.ShortenRequest{
+		URL:           link.URL,
+		CustomAlias:   shortCode,
+		Domain:        host,
+		IfExists:      models.IfExistsNew,
+		Tags:          link.Tags,
+		NoIndex:       link.NoIndex,
+		Variants:      link.Variants,
+		VariantMode:   link.VariantMode,
+		Analytics:     link.Analytics,
+		MaxClicks:     link.MaxClicks,
+		UTMSource:     link.UTMSource,
+		UTMMedium:     link.UTMMedium,
+		UTMCampaign:   link.UTMCampaign,
+		RedirectType:  link.RedirectType,
+		RoutingRules:  link.RoutingRules,
+		OGTitle:       link.OGTitle,
+		OGDescription: link.OGDescription,
+		OGImage:       link.OGImage,
+	}
+	expiresAt := service.LinkExpiry.Enforce(now, link.ExpiresAt, now)
+	if _, err := createURLRecordUntil(c, request, expiresAt, safetyAction, false); err != nil {
+		if errors.Is(err, service.ErrAliasTaken) {
+			return errors.New("short code is taken")
+		}
+		if errors.Is(err, service.ErrUnknownDomain) {
+			return errors.New("unknown short link domain")
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to import link", "short_code", link.ShortCode, "error", err)
+		return errors.New("failed to save link")
+	}
+	return nil
+}
+
+// checkImportedDestinations applies this instance's checks to the
+// destinations of an imported link, returning the strictest safety action or
+// why the link is skipped
+func checkImportedDestinations(c *gin.Context, destinations []string) (string, error) {
+	safetyAction := ""
+	for _, destination := range destinations {
+		if !service.IsValidURL(destination) {
+			return "", errors.New("invalid URL format")
+		}
+		if apiErr := service.ScreenDestination(c.Request.Context(), destination); apiErr != nil {
+			return "", errors.New(apiErr.Message)
+		}
+		switch action, _ := middleware.CurrentPolicy(c).EvaluateSafety(destination); action {
+		case models.SafetyActionDeny:
+			return "", errors.New("URL is blocked by safety policy")
+		case models.SafetyActionReview:
+			safetyAction = models.SafetyActionReview
+		}
+	}
+	return safetyAction, nil
+}
+
+// bundleSecret returns LINK_BUNDLE_SECRET, writing the error response and
+// returning false when it is not set
+func bundleSecret(c *gin.Context) (string, bool) {
+	secret := os.Getenv("LINK_BUNDLE_SECRET")
+	if secret == "" {
+		c.Error(models.NewAPIError(http.StatusServiceUnavailable, models.ErrCodeUnavailable, "Link bundles are disabled; set LINK_BUNDLE_SECRET"))
+		return "", false
+	}
+	return secret, true
+}
+
+// signBundle returns hex(HMAC-SHA256(secret, bundle as JSON)) computed with
+// an empty signature
+func signBundle(secret string, bundle models.LinkBundle) string {
+	bundle.Signature = ""
+	body, _ := json.Marshal(bundle)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
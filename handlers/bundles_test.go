@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"url-shortener/models"
+)
+
+func TestSignBundleSurvivesTransfer(t *testing.T) {
+	expiresAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.FixedZone("ICT", 7*60*60))
+	bundle := models.LinkBundle{
+		Version:    models.LinkBundleVersion,
+		Source:     "staging.sho.rt",
+		ExportedAt: time.Now().UTC(),
+		Links: []models.BundleLink{
+			{ShortCode: "promo2024", URL: "https://example.com/spring", ExpiresAt: &expiresAt, Tags: []string{"spring"}},
+			{ShortCode: "split1", URL: "https://example.com/a", VariantMode: models.VariantModeBandit, Variants: []models.VariantRequest{
+				{Name: "A", URL: "https://example.com/a", Weight: 1},
+				{Name: "B", URL: "https://example.com/b", Weight: 3},
+			}},
+		},
+	}
+	bundle.Signature = signBundle("secret", bundle)
+
+	body, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received models.LinkBundle
+	if err := json.Unmarshal(body, &received); err != nil {
+		t.Fatal(err)
+	}
+	if got := signBundle("secret", received); got != received.Signature {
+		t.Errorf("signature changed in transfer: %s, want %s", got, received.Signature)
+	}
+
+	received.Links[0].URL = "https://attacker.example/"
+	if got := signBundle("secret", received); got == received.Signature {
+		t.Error("tampered bundle kept its signature")
+	}
+	if got := signBundle("other", bundle); got == bundle.Signature {
+		t.Error("signature does not depend on the secret")
+	}
+}
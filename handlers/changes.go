@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"url-shortener/database"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListLinkChanges godoc
+// @Summary Read the link change feed
+// @ID listLinkChanges
+// @Description List the links created, updated and deleted after the since cursor, in the order the changes were made, so edge caches, search indexes and other copies of the links stay in sync without rescanning them. Without since, no changes are returned and next_cursor is the current end of the feed: take it, scan the links, then follow the feed from it. Poll again with next_cursor; has_more is set when more changes are ready right away. Click counts and the fetched title and description of destination pages are not changes. Changes are kept for CHANGE_FEED_RETENTION, a cursor older than that answers 410 Gone and calls for a new scan.
+// @Tags Admin
+// @Produce json
+// @Param since query string false "Cursor of the last change read"
+// @Param limit query int false "Maximum changes to return (default 100, max 1000)"
+// @Success 200 {object} models.LinkChangesResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid cursor or limit"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 410 {object} models.ErrorResponse "The cursor is older than the retained changes"
+// @Security AdminAuth
+// @Router /changes [get]
+func ListLinkChanges(c *gin.Context) {
+	var since uint64
+	var err error
+	hasCursor := c.Query("since") != ""
+	if hasCursor {
+		if since, err = strconv.ParseUint(c.Query("since"), 10, 64); err != nil {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid since cursor"))
+			return
+		}
+	}
+
+	limit := 100
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > 1000 {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "limit must be between 1 and 1000"))
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	if !hasCursor {
+		head, err := database.LinkChangeHead(ctx)
+		if err != nil {
+			c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to read the change feed"))
+			return
+		}
+		c.JSON(http.StatusOK, models.LinkChangesResponse{Changes: []models.LinkChangeEvent{}, NextCursor: formatCursor(head)})
+		return
+	}
+
+	expired, err := database.LinkChangeCursorExpired(ctx, since)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to read the change feed"))
+		return
+	}
+	if expired {
+		c.Error(models.NewAPIError(http.StatusGone, models.ErrCodeCursorExpired, "Changes after this cursor are no longer retained; rescan the links"))
+		return
+	}
+
+	// One more change than asked for tells whether there are more
+	changes, err := database.LinkChanges(ctx, since, limit+1)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to read the change feed"))
+		return
+	}
+	response := models.LinkChangesResponse{Changes: []models.LinkChangeEvent{}, NextCursor: formatCursor(since)}
+	if len(changes) > limit {
+		changes, response.HasMore = changes[:limit], true
+	}
+	if len(changes) == 0 {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	// The current state of the links still there
+	var ids []uint
+	for _, change := range changes {
+		if change.Event != models.ChangeDeleted {
+			ids = append(ids, change.URLID)
+		}
+	}
+	links := make(map[uint]*models.URL, len(ids))
+	if len(ids) > 0 {
+		var urls []models.URL
+		if err := database.DB.WithContext(ctx).Where("id IN ?", ids).Find(&urls).Error; err != nil {
+			c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to read the change feed"))
+			return
+		}
+		for i := range urls {
+			links[urls[i].ID] = &urls[i]
+		}
+	}
+
+	for _, change := range changes {
+		event := models.LinkChangeEvent{
+			Cursor:            formatCursor(change.ID),
+			Event:             change.Event,
+			URLID:             change.URLID,
+			ShortCode:         change.ShortCode,
+			PreviousShortCode: change.PreviousShortCode,
+			ChangedAt:         change.RecordedAt,
+		}
+		if change.Event != models.ChangeDeleted {
+			event.Link = links[change.URLID]
+		}
+		response.Changes = append(response.Changes, event)
+	}
+	response.NextCursor = formatCursor(changes[len(changes)-1].ID)
+	c.JSON(http.StatusOK, response)
+}
+
+func formatCursor(id uint64) string {
+	return strconv.FormatUint(id, 10)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/service"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShortenChannels godoc
+// @Summary Create per-channel share links
+// @ID shortenChannels
+// @Description Create one short link per share channel (default twitter, facebook and email) for the same URL, tagged `channel:<name>` and with utm_source/utm_medium added to each destination, so share performance can be compared per channel
+// @Tags URL Shortener
+// @Accept json
+// @Produce json
+// @Param request body models.ShortenChannelsRequest true "URL and channels"
+// @Success 201 {object} models.ShortenChannelsResponse
+// @Header 201 {string} X-Quota-Warning "Usage of the monthly link quota, once past 80%: plan, used, limit and resets"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key or request signature, or anonymous shortening is disabled"
+// @Failure 403 {object} models.ErrorResponse "CAPTCHA verification failed or API key not permitted"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded, or the monthly link quota is used up"
+// @Security ApiKeyAuth
+// @Router /shorten/channels [post]
+func ShortenChannels(c *gin.Context) {
+	var request models.ShortenChannelsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	safetyAction, ok := checkShortenAllowed(c, request.URL, request.CaptchaToken)
+	if !ok || !checkExpiryAllowed(c, request.ExpiresIn) {
+		return
+	}
+	if apiErr := service.CheckTags(requestCaller(c), request.Tags); apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+	shadowBanned := middleware.CurrentPolicy(c).ShadowBanned()
+
+	channels := request.Channels
+	if len(channels) == 0 {
+		channels = models.DefaultShareChannels
+	}
+	addUTM := request.AddUTM == nil || *request.AddUTM
+
+	response := models.ShortenChannelsResponse{OriginalURL: request.URL}
+	seen := make(map[string]bool)
+	for _, channel := range channels {
+		channel = strings.ToLower(channel)
+		if seen[channel] {
+			continue
+		}
+		seen[channel] = true
+
+		destination := request.URL
+		if addUTM {
+			destination = withUTM(request.URL, channel)
+		}
+
+		tags := append(append([]string{}, request.Tags...), "channel:"+channel)
+		urlRecord, err := createURLRecord(c, models.ShortenRequest{
+			URL:       destination,
+			ExpiresIn: request.ExpiresIn,
+			IfExists:  models.IfExistsNew,
+			Tags:      tags,
+			NoIndex:   request.NoIndex,
+			Analytics: request.Analytics,
+		}, safetyAction, shadowBanned)
+		if errors.Is(err, service.ErrQuotaExhausted) {
+			c.Error(service.QuotaExhaustedError())
+			return
+		}
+		if err != nil {
+			c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create short URL"))
+			return
+		}
+
+		response.Links = append(response.Links, models.ChannelLink{
+			Channel:         channel,
+			ShortenResponse: buildShortenResponse(c, urlRecord),
+		})
+	}
+
+	response.Warnings = quotaWarnings(c)
+	c.JSON(http.StatusCreated, response)
+}
+
+// withUTM adds utm_source and utm_medium for a share channel, keeping any
+// UTM parameters the URL already has
+func withUTM(rawURL, channel string) string {
+	medium := "social"
+	if channel == "email" {
+		medium = "email"
+	}
+	return utils.AddQueryDefaults(rawURL, url.Values{"utm_source": {channel}, "utm_medium": {medium}}.Encode())
+}
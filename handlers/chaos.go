@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"url-shortener/chaos"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetChaos godoc
+// @Summary Fault injection configuration
+// @ID getChaos
+// @Description The latency and error rate currently injected. Only available when the server runs with CHAOS_ENABLED=true.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} models.ChaosConfig
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/chaos [get]
+func GetChaos(c *gin.Context) {
+	c.JSON(http.StatusOK, chaos.Current())
+}
+
+// UpdateChaos godoc
+// @Summary Configure fault injection
+// @ID updateChaos
+// @Description Replace the injected latency and error rate for HTTP requests (except /admin), database queries and Redis commands. A zero latency and error rate stop injecting. Only available when the server runs with CHAOS_ENABLED=true.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body models.ChaosConfig true "Fault injection"
+// @Success 200 {object} models.ChaosConfig
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/chaos [put]
+func UpdateChaos(c *gin.Context) {
+	var request models.ChaosConfig
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	chaos.Set(request)
+	c.JSON(http.StatusOK, chaos.Current())
+}
@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"url-shortener/buildinfo"
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/geo"
+	"url-shortener/logging"
+	"url-shortener/models"
+	"url-shortener/notify"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clickRecord is a redirect waiting to be counted
+type clickRecord struct {
+	shortCode string
+	urlID     uint
+	variantID uint // variant of a split link, 0 for other links
+	version   int  // configuration version of the link
+	countOnly bool // the link only counts clicks, no click event is recorded
+	clickedAt time.Time
+	referrer  string
+	userAgent string
+	clientIP  string // only hashed into the unique visitor counts
+	// Already coarsened, so precise locations never leave the request
+	location geo.Location
+	// Logged with failures, to find the redirect that queued the click
+	requestID string
+}
+
+// Referrers and user agents are cut to this many bytes in click events
+const maxClickHeaderLength = 1024
+
+// Redirects queue clicks for a fixed pool of workers rather than spawning a
+// goroutine per request
+var (
+	clickQueue    = make(chan clickRecord, clickQueueSize())
+	droppedClicks atomic.Int64
+	clickWorkers  sync.WaitGroup
+)
+
+// Workers accumulate clicks, which the flusher writes to the database in
+// batches. Increments go to Redis when available, so they survive a crash
+// and any instance can flush them; without Redis they are kept in memory.
+// Click events are always buffered in memory.
+var (
+	pendingMu       sync.Mutex
+	pendingURLs     = make(map[uint]int64)
+	pendingVariants = make(map[uint]int64)
+	pendingEvents   []models.ClickEvent
+
+	// Held while writing clicks, so stats resets never take pending clicks
+	// that are being written
+	clickWriteMu sync.Mutex
+
+	clickFlushNow  = make(chan struct{}, 1)
+	clickFlushStop = make(chan struct{})
+	clickFlushDone = make(chan struct{})
+)
+
+// clickGeoPolicy limits how precisely click locations are kept
+var clickGeoPolicy = loadClickGeoPolicy()
+
+// Route reported for click count queries in the database instrumentation
+const clickRoute = "/:shortCode"
+
+// Clicks are flushed this often, or as soon as this many are buffered,
+// unless CLICK_FLUSH_INTERVAL or CLICK_FLUSH_BATCH say otherwise
+const (
+	defaultClickFlushInterval = 5 * time.Second
+	defaultClickFlushBatch    = 1000
+)
+
+// How long the final flush may take on shutdown
+const clickFlushShutdownTimeout = 10 * time.Second
+
+// StartClickRecorder starts CLICK_WORKERS (default 4) workers counting
+// queued redirect clicks, and the flusher writing them to the database every
+// CLICK_FLUSH_INTERVAL or CLICK_FLUSH_BATCH clicks
+func StartClickRecorder() {
+	workers := 4
+	if value, err := strconv.Atoi(os.Getenv("CLICK_WORKERS")); err == nil && value > 0 {
+		workers = value
+	}
+
+	batch := clickFlushBatch()
+	for i := 0; i < workers; i++ {
+		clickWorkers.Add(1)
+		go func() {
+			defer clickWorkers.Done()
+			for click := range clickQueue {
+				if recordClick(click) >= batch {
+					select {
+					case clickFlushNow <- struct{}{}:
+					default:
+					}
+				}
+			}
+		}()
+	}
+
+	go runClickFlusher(clickFlushInterval())
+}
+
+// StopClickRecorder counts the clicks still queued and flushes everything
+// pending to the database. Call it once no more redirects are served.
+func StopClickRecorder() {
+	close(clickQueue)
+	clickWorkers.Wait()
+	close(clickFlushStop)
+	<-clickFlushDone
+}
+
+// enqueueClick queues a click on a link without blocking the redirect.
+// When the queue is full the click is dropped and counted instead. Links
+// that opted out of analytics are not counted, and links only counting
+// clicks never have their visitor's referrer, user agent, address or location
+// read, nor do clicks whose event is shed during a spike.
+func enqueueClick(c *gin.Context, shortCode string, entry *cache.RedirectEntry, variantID uint) {
+	if entry.Has(cache.RedirectNoCount) {
+		return
+	}
+	click := clickRecord{
+		shortCode: shortCode,
+		urlID:     entry.URLID,
+		variantID: variantID,
+		version:   entry.Version,
+		countOnly: entry.Has(cache.RedirectNoEvents),
+		clickedAt: time.Now(),
+		requestID: logging.RequestID(c.Request.Context()),
+	}
+	if !click.countOnly && shedClickEvent(click.clickedAt) {
+		click.countOnly = true
+	}
+	if !click.countOnly {
+		click.referrer = truncateHeader(c.Request.Referer())
+		click.userAgent = truncateHeader(c.Request.UserAgent())
+		click.clientIP = c.ClientIP()
+		click.location = clickGeoPolicy.Coarsen(geo.FromRequest(c.Request))
+	}
+	select {
+	case clickQueue <- click:
+	default:
+		if droppedClicks.Add(1)%1000 == 1 {
+			slog.WarnContext(c.Request.Context(), "Click queue full, dropping clicks", "short_code", shortCode, "dropped", droppedClicks.Load())
+		}
+	}
+}
+
+// recordClick counts a click in the cache and buffers it for the database,
+// returning how many click events are buffered
+func recordClick(click clickRecord) int {
+	redisErr := cache.RecordPendingClick(click.shortCode, click.urlID, click.variantID)
+	if redisErr != nil && cache.RedisClient != nil {
+		// Debug only, as every click fails the same way while Redis is down
+		slog.DebugContext(logging.WithRequestID(context.Background(), click.requestID), "Failed to count click in Redis, keeping it in memory",
+			"short_code", click.shortCode, "error", redisErr)
+	}
+	// Invalidate stats cache since click count changed
+	cache.InvalidateStats(click.shortCode)
+	// Approximate unique visitors are kept in Redis only
+	var visitorHash string
+	if click.clientIP != "" {
+		visitor := click.clientIP + " " + click.userAgent
+		cache.RecordVisitor(click.urlID, click.clickedAt, visitor)
+		visitorHash, _ = cache.VisitorHash(visitor)
+		recordRecentCode(visitorHash, click)
+	}
+
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	if redisErr != nil {
+		pendingURLs[click.urlID]++
+		if click.variantID != 0 {
+			pendingVariants[click.variantID]++
+		}
+	}
+	if click.countOnly {
+		return len(pendingEvents)
+	}
+
+	event := models.ClickEvent{
+		ClickedAt:   click.clickedAt,
+		URLID:       click.urlID,
+		ShortCode:   click.shortCode,
+		Referrer:    click.referrer,
+		UserAgent:   click.userAgent,
+		Country:     click.location.Country,
+		Region:      click.location.Region,
+		City:        click.location.City,
+		DeviceType:  deviceType(click.userAgent),
+		LinkVersion: click.version,
+		VisitorHash: visitorHash,
+	}
+	pendingEvents = append(pendingEvents, event)
+	return len(pendingEvents)
+}
+
+// runClickFlusher flushes pending clicks every interval, when the batch
+// fills up, and a last time when StopClickRecorder is called
+func runClickFlusher(interval time.Duration) {
+	defer close(clickFlushDone)
+	queryCtx := database.WithRoute(context.Background(), clickRoute)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-clickFlushNow:
+		case <-clickFlushStop:
+			ctx, cancel := context.WithTimeout(queryCtx, clickFlushShutdownTimeout)
+			flushClicks(ctx)
+			cancel()
+			return
+		}
+		flushClicks(queryCtx)
+	}
+}
+
+// flushClicks writes the click counts and events accumulated since the
+// last flush. Counts that fail to be written are kept for the next flush;
+// click events are dropped, as the reconciler repairs counts from the other
+// tiers but cannot recreate events.
+func flushClicks(ctx context.Context) {
+	clickWriteMu.Lock()
+	defer clickWriteMu.Unlock()
+
+	pendingMu.Lock()
+	urls, variants, events := pendingURLs, pendingVariants, pendingEvents
+	pendingURLs, pendingVariants, pendingEvents = make(map[uint]int64), make(map[uint]int64), nil
+	pendingMu.Unlock()
+
+	counts, err := database.Links.IncrementClicks(ctx, urls, variants)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write click counts, retrying with the next flush", "error", err)
+		pendingMu.Lock()
+		for id, count := range urls {
+			pendingURLs[id] += count
+		}
+		for id, count := range variants {
+			pendingVariants[id] += count
+		}
+		pendingMu.Unlock()
+	} else {
+		notify.FireClickThresholds(ctx, counts, urls)
+	}
+
+	if len(events) > 0 {
+		if _, err := database.CopyClickEvents(ctx, events); err != nil {
+			slog.ErrorContext(ctx, "Failed to write click events", "events", len(events), "error", err)
+		}
+	}
+
+	flushRedisClicks(ctx)
+}
+
+// flushRedisClicks writes the increments pending in Redis. One instance
+// flushes at a time; increments are acknowledged once written, so a crash
+// in between writes them again.
+func flushRedisClicks(ctx context.Context) {
+	locked, err := cache.LockClickFlush(buildinfo.Instance, clickFlushShutdownTimeout)
+	if err != nil || !locked {
+		return
+	}
+	defer cache.UnlockClickFlush(buildinfo.Instance)
+
+	urls, variants, err := cache.PendingClicks()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read pending clicks", "error", err)
+		return
+	}
+	counts, err := database.Links.IncrementClicks(ctx, urls, variants)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write click counts, retrying with the next flush", "error", err)
+		return
+	}
+	notify.FireClickThresholds(ctx, counts, urls)
+	if err := cache.AcknowledgePendingClicks(urls, variants); err != nil {
+		slog.ErrorContext(ctx, "Failed to acknowledge written clicks, they may be counted twice", "error", err)
+	}
+}
+
+func clickFlushInterval() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("CLICK_FLUSH_INTERVAL")); err == nil && value > 0 {
+		return value
+	}
+	return defaultClickFlushInterval
+}
+
+func clickFlushBatch() int {
+	if value, err := strconv.Atoi(os.Getenv("CLICK_FLUSH_BATCH")); err == nil && value > 0 {
+		return value
+	}
+	return defaultClickFlushBatch
+}
+
+// deviceType classifies a user agent as bot, tablet, mobile or desktop, or
+// returns an empty string when there is no user agent
+func deviceType(userAgent string) string {
+	userAgent = strings.ToLower(userAgent)
+	switch {
+	case userAgent == "":
+		return ""
+	case strings.Contains(userAgent, "bot") || strings.Contains(userAgent, "crawler") || strings.Contains(userAgent, "spider"):
+		return "bot"
+	case strings.Contains(userAgent, "ipad") || strings.Contains(userAgent, "tablet") ||
+		(strings.Contains(userAgent, "android") && !strings.Contains(userAgent, "mobile")):
+		return "tablet"
+	case strings.Contains(userAgent, "mobi") || strings.Contains(userAgent, "iphone"):
+		return "mobile"
+	default:
+		return "desktop"
+	}
+}
+
+// truncateHeader cuts a header value stored with a click to its limit
+func truncateHeader(value string) string {
+	if len(value) > maxClickHeaderLength {
+		return strings.ToValidUTF8(value[:maxClickHeaderLength], "")
+	}
+	return value
+}
+
+func clickQueueSize() int {
+	if value, err := strconv.Atoi(os.Getenv("CLICK_QUEUE_SIZE")); err == nil && value > 0 {
+		return value
+	}
+	return 10000
+}
+
+// loadClickGeoPolicy reads the click location precision, falling back to
+// country precision when it is invalid
+func loadClickGeoPolicy() geo.Policy {
+	policy, err := geo.PolicyFromEnv()
+	if err != nil {
+		slog.Warn("Invalid click location precision, keeping countries only", "error", err)
+		policy, _ = geo.ParsePolicy("country", "")
+	}
+	return policy
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeviceType(t *testing.T) {
+	cases := map[string]string{
+		"": "",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36":                             "desktop",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1": "mobile",
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Mobile Safari/537.36":                       "mobile",
+		"Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1":          "tablet",
+		"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36":                              "tablet",
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)":                                                                "bot",
+	}
+	for userAgent, want := range cases {
+		if got := deviceType(userAgent); got != want {
+			t.Errorf("deviceType(%q) = %q, want %q", userAgent, got, want)
+		}
+	}
+}
+
+func TestTruncateHeader(t *testing.T) {
+	long := strings.Repeat("é", maxClickHeaderLength)
+	got := truncateHeader(long)
+	if len(got) > maxClickHeaderLength || !strings.HasPrefix(long, got) {
+		t.Errorf("truncateHeader returned %d bytes, not a prefix within the limit", len(got))
+	}
+	if truncateHeader("https://t.co/") != "https://t.co/" {
+		t.Error("short values must be kept as they are")
+	}
+}
+
+func TestRecordClickWithoutRedisKeepsIncrementsInMemory(t *testing.T) {
+	pendingURLs, pendingVariants, pendingEvents = make(map[uint]int64), make(map[uint]int64), nil
+	t.Cleanup(func() {
+		pendingURLs, pendingVariants, pendingEvents = make(map[uint]int64), make(map[uint]int64), nil
+	})
+
+	recordClick(clickRecord{shortCode: "abc123", urlID: 7, userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) Mobile"})
+	buffered := recordClick(clickRecord{shortCode: "abc123", urlID: 7, variantID: 3, version: 2})
+
+	if buffered != 2 {
+		t.Errorf("recordClick reported %d buffered events, want 2", buffered)
+	}
+	if pendingURLs[7] != 2 || pendingVariants[3] != 1 {
+		t.Errorf("pending increments = %v and %v, want 2 clicks for link 7 and 1 for variant 3", pendingURLs, pendingVariants)
+	}
+	if pendingEvents[0].ShortCode != "abc123" || pendingEvents[0].DeviceType != "mobile" {
+		t.Errorf("buffered event = %+v", pendingEvents[0])
+	}
+	if pendingEvents[1].LinkVersion != 2 {
+		t.Errorf("buffered event has link version %d, want 2", pendingEvents[1].LinkVersion)
+	}
+}
+
+func TestRecordClickCountOnlySkipsEvent(t *testing.T) {
+	pendingURLs, pendingVariants, pendingEvents = make(map[uint]int64), make(map[uint]int64), nil
+	t.Cleanup(func() {
+		pendingURLs, pendingVariants, pendingEvents = make(map[uint]int64), make(map[uint]int64), nil
+	})
+
+	buffered := recordClick(clickRecord{shortCode: "abc123", urlID: 7, countOnly: true})
+
+	if buffered != 0 || len(pendingEvents) != 0 {
+		t.Errorf("count-only click buffered %d events, want none", buffered)
+	}
+	if pendingURLs[7] != 1 {
+		t.Errorf("pending increments = %v, want 1 click for link 7", pendingURLs)
+	}
+}
+
+func TestShedClickEvent(t *testing.T) {
+	rate, shed := clickEventShedRate, shedEvents.Load()
+	clickEventShedRate = 3
+	t.Cleanup(func() { clickEventShedRate = rate })
+
+	second := time.Unix(1700000000, 0)
+	var captured int
+	for i := 0; i < 5; i++ {
+		if !shedClickEvent(second.Add(time.Duration(i) * time.Millisecond)) {
+			captured++
+		}
+	}
+	if captured != 3 || shedEvents.Load()-shed != 2 {
+		t.Errorf("captured %d and shed %d events in a second, want 3 and 2", captured, shedEvents.Load()-shed)
+	}
+	if shedClickEvent(second.Add(time.Second)) {
+		t.Error("events were still shed in the next second")
+	}
+
+	clickEventShedRate = 0
+	if shedClickEvent(second.Add(time.Second)) {
+		t.Error("events were shed with shedding off")
+	}
+}
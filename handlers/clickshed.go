@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Click events captured per second per instance unless CLICK_EVENT_SHED_RATE
+// says otherwise
+const defaultClickEventShedRate = 5000
+
+// clickEventShedRate caps the click events captured per second, 0 for no
+// cap
+var clickEventShedRate = loadClickEventShedRate()
+
+// Click events captured in the current second, and shed beyond the rate
+var (
+	eventSecond   atomic.Int64
+	eventsCounted atomic.Int64
+	shedEvents    atomic.Int64
+)
+
+// shedClickEvent reports whether the click at now should only be counted,
+// without capturing its event, because more than clickEventShedRate events
+// were captured this second already. Shedding keeps a viral spike from
+// filling the click queue with events whose referrer, location and visitor
+// work would crowd out the counts, and from growing the batches written to
+// the database; the counts stay exact.
+func shedClickEvent(now time.Time) bool {
+	if clickEventShedRate <= 0 {
+		return false
+	}
+	second := now.Unix()
+	if current := eventSecond.Load(); current != second && eventSecond.CompareAndSwap(current, second) {
+		eventsCounted.Store(0)
+	}
+	if eventsCounted.Add(1) <= clickEventShedRate {
+		return false
+	}
+	if shedEvents.Add(1)%1000 == 1 {
+		slog.Warn("Click spike, shedding click events", "rate", clickEventShedRate, "shed", shedEvents.Load())
+	}
+	return true
+}
+
+func loadClickEventShedRate() int64 {
+	value := os.Getenv("CLICK_EVENT_SHED_RATE")
+	if value == "" {
+		return defaultClickEventShedRate
+	}
+	rate, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || rate < 0 {
+		slog.Warn("Invalid CLICK_EVENT_SHED_RATE, using the default", "value", value, "default", defaultClickEventShedRate)
+		return defaultClickEventShedRate
+	}
+	return rate
+}
@@ -0,0 +1,576 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Collections a user may own
+const maxCollectionsPerUser = 100
+
+// Random bytes in a collection's share token
+const collectionTokenBytes = 24
+
+// Days of recent clicks counted in collection views
+const collectionRecentDays = 30
+
+// collectionRoleRank orders roles by what they allow
+var collectionRoleRank = map[string]int{
+	models.CollectionRoleViewer: 1,
+	models.CollectionRoleEditor: 2,
+	models.CollectionRoleOwner:  3,
+}
+
+var errCollectionNotFound = models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Collection not found")
+
+// ListCollections godoc
+// @Summary List your collections
+// @ID listCollections
+// @Description List the collections the caller owns or was granted a role on, with the caller's role in each.
+// @Tags Collections
+// @Produce json
+// @Success 200 {array} models.Collection
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /collections [get]
+func ListCollections(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := *middleware.CurrentOwnerID(c)
+
+	var members []models.CollectionMember
+	if err := database.DB.WithContext(ctx).Where("user_id = ?", userID).Find(&members).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list collections"))
+		return
+	}
+	roles := make(map[uint]string, len(members))
+	sharedIDs := make([]uint, len(members))
+	for i, member := range members {
+		roles[member.CollectionID] = member.Role
+		sharedIDs[i] = member.CollectionID
+	}
+
+	collections := []models.Collection{}
+	err := database.DB.WithContext(ctx).Where("owner_id = ? OR id IN ?", userID, append(sharedIDs, 0)).Order("id").Find(&collections).Error
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list collections"))
+		return
+	}
+	for i := range collections {
+		collections[i].Role = models.CollectionRoleOwner
+		if collections[i].OwnerID != userID {
+			collections[i].Role = roles[collections[i].ID]
+		}
+	}
+	c.JSON(http.StatusOK, collections)
+}
+
+// CreateCollection godoc
+// @Summary Create a collection
+// @ID createCollection
+// @Description Gather links owned by the caller, given by short code (host/code on branded domains), into a collection to share with other users or through a read-only share link, such as an agency's campaign links for a client. Up to 500 links.
+// @Tags Collections
+// @Accept json
+// @Produce json
+// @Param request body models.CollectionRequest true "Collection; name is required"
+// @Success 201 {object} models.Collection
+// @Failure 400 {object} models.ErrorResponse "Invalid request, or a link is not one of yours"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope"
+// @Failure 409 {object} models.ErrorResponse "Too many collections"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /collections [post]
+func CreateCollection(c *gin.Context) {
+	var request models.CollectionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	if request.Name == nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "name is required"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	ownerID := *middleware.CurrentOwnerID(c)
+	links := []string{}
+	if request.Links != nil {
+		links = *request.Links
+	}
+	if !checkCollectionLinks(c, ownerID, links) {
+		return
+	}
+
+	var count int64
+	if err := database.DB.WithContext(ctx).Model(&models.Collection{}).Where("owner_id = ?", ownerID).Count(&count).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create collection"))
+		return
+	}
+	if count >= maxCollectionsPerUser {
+		c.Error(models.NewAPIError(http.StatusConflict, models.ErrCodeConflict, "You already have the maximum number of collections"))
+		return
+	}
+
+	collection := models.Collection{OwnerID: ownerID, Name: *request.Name, Links: links}
+	if err := database.DB.WithContext(ctx).Create(&collection).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create collection"))
+		return
+	}
+	collection.Role = models.CollectionRoleOwner
+	c.JSON(http.StatusCreated, collection)
+}
+
+// GetCollection godoc
+// @Summary View a collection
+// @ID getCollection
+// @Description List the links of a collection the caller owns or has a role on, in order, with their all-time clicks and the clicks of the last 30 days from the hourly click rollups. Links since deleted, renamed or no longer owned by the collection's owner are left out.
+// @Tags Collections
+// @Produce json
+// @Param id path int true "Collection ID"
+// @Success 200 {object} models.CollectionView
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope"
+// @Failure 404 {object} models.ErrorResponse "Collection not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /collections/{id} [get]
+func GetCollection(c *gin.Context) {
+	collection, role, ok := collectionAccess(c, models.CollectionRoleViewer)
+	if !ok {
+		return
+	}
+	view, err := buildCollectionView(c, collection)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to load collection"))
+		return
+	}
+	view.Role = role
+	c.JSON(http.StatusOK, view)
+}
+
+// UpdateCollection godoc
+// @Summary Edit a collection
+// @ID updateCollection
+// @Description Rename a collection or replace its links, as its owner or an editor. Links must be owned by the collection's owner. Omitted fields are kept.
+// @Tags Collections
+// @Accept json
+// @Produce json
+// @Param id path int true "Collection ID"
+// @Param request body models.CollectionRequest true "Changes"
+// @Success 200 {object} models.Collection
+// @Failure 400 {object} models.ErrorResponse "Invalid request, or a link is not the owner's"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope, or the caller is a viewer"
+// @Failure 404 {object} models.ErrorResponse "Collection not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /collections/{id} [put]
+func UpdateCollection(c *gin.Context) {
+	var request models.CollectionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	collection, role, ok := collectionAccess(c, models.CollectionRoleEditor)
+	if !ok {
+		return
+	}
+
+	columns := []string{}
+	if request.Name != nil {
+		collection.Name = *request.Name
+		columns = append(columns, "name")
+	}
+	if request.Links != nil {
+		if !checkCollectionLinks(c, collection.OwnerID, *request.Links) {
+			return
+		}
+		collection.Links = *request.Links
+		columns = append(columns, "links")
+	}
+	if len(columns) > 0 {
+		if err := database.DB.WithContext(c.Request.Context()).Model(collection).Select(columns).Updates(collection).Error; err != nil {
+			c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update collection"))
+			return
+		}
+	}
+	collection.Role = role
+	c.JSON(http.StatusOK, collection)
+}
+
+// DeleteCollection godoc
+// @Summary Delete a collection
+// @ID deleteCollection
+// @Description Delete a collection the caller owns, revoking its members and share link. Its links are kept.
+// @Tags Collections
+// @Param id path int true "Collection ID"
+// @Success 204 "Collection deleted"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope, or the caller is not the owner"
+// @Failure 404 {object} models.ErrorResponse "Collection not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /collections/{id} [delete]
+func DeleteCollection(c *gin.Context) {
+	collection, _, ok := collectionAccess(c, models.CollectionRoleOwner)
+	if !ok {
+		return
+	}
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("collection_id = ?", collection.ID).Delete(&models.CollectionMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(collection).Error
+	})
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete collection"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListCollectionMembers godoc
+// @Summary List a collection's members
+// @ID listCollectionMembers
+// @Description List the users granted a role on a collection the caller owns.
+// @Tags Collections
+// @Produce json
+// @Param id path int true "Collection ID"
+// @Success 200 {array} models.CollectionMember
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope, or the caller is not the owner"
+// @Failure 404 {object} models.ErrorResponse "Collection not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /collections/{id}/members [get]
+func ListCollectionMembers(c *gin.Context) {
+	collection, _, ok := collectionAccess(c, models.CollectionRoleOwner)
+	if !ok {
+		return
+	}
+	members := []models.CollectionMember{}
+	err := database.DB.WithContext(c.Request.Context()).Table("collection_members").
+		Select("collection_members.*, users.email").
+		Joins("JOIN users ON users.id = collection_members.user_id").
+		Where("collection_members.collection_id = ?", collection.ID).Order("collection_members.id").
+		Scan(&members).Error
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list members"))
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// GrantCollectionRole godoc
+// @Summary Share a collection with a user
+// @ID grantCollectionRole
+// @Description Grant the user with an email a role on a collection the caller owns, or change the role they have: viewers see its links and their stats, editors also rename it and add or remove links.
+// @Tags Collections
+// @Accept json
+// @Produce json
+// @Param id path int true "Collection ID"
+// @Param request body models.CollectionMemberRequest true "User and role"
+// @Success 200 {object} models.CollectionMember
+// @Failure 400 {object} models.ErrorResponse "Invalid request, or no such user"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope, or the caller is not the owner"
+// @Failure 404 {object} models.ErrorResponse "Collection not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /collections/{id}/members [put]
+func GrantCollectionRole(c *gin.Context) {
+	var request models.CollectionMemberRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	collection, _, ok := collectionAccess(c, models.CollectionRoleOwner)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	var user models.User
+	if err := database.DB.WithContext(ctx).Select("id", "email").Where("email = ?", request.Email).First(&user).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "No user has the email "+request.Email))
+		return
+	}
+	if user.ID == collection.OwnerID {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "The owner already has every role"))
+		return
+	}
+
+	member := models.CollectionMember{CollectionID: collection.ID, UserID: user.ID, Role: request.Role}
+	err := database.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "collection_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(&member).Error
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to share collection"))
+		return
+	}
+	member.Email = user.Email
+	c.JSON(http.StatusOK, member)
+}
+
+// RevokeCollectionRole godoc
+// @Summary Stop sharing a collection with a user
+// @ID revokeCollectionRole
+// @Description Remove a user's role on a collection the caller owns.
+// @Tags Collections
+// @Param id path int true "Collection ID"
+// @Param userId path int true "User ID"
+// @Success 204 "Role removed"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope, or the caller is not the owner"
+// @Failure 404 {object} models.ErrorResponse "Collection or member not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /collections/{id}/members/{userId} [delete]
+func RevokeCollectionRole(c *gin.Context) {
+	collection, _, ok := collectionAccess(c, models.CollectionRoleOwner)
+	if !ok {
+		return
+	}
+	result := database.DB.WithContext(c.Request.Context()).
+		Where("collection_id = ? AND user_id = ?", collection.ID, c.Param("userId")).Delete(&models.CollectionMember{})
+	if result.Error != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to remove member"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Member not found"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ShareCollection godoc
+// @Summary Create a share link
+// @ID shareCollection
+// @Description Create a read-only share link to a collection the caller owns, showing anyone holding it the collection's links and stats without an API key, such as a client following their campaign. Replaces the previous share link. The token is only returned once.
+// @Tags Collections
+// @Produce json
+// @Param id path int true "Collection ID"
+// @Success 201 {object} models.CollectionShareResponse
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope, or the caller is not the owner"
+// @Failure 404 {object} models.ErrorResponse "Collection not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /collections/{id}/share [post]
+func ShareCollection(c *gin.Context) {
+	collection, _, ok := collectionAccess(c, models.CollectionRoleOwner)
+	if !ok {
+		return
+	}
+	token, err := utils.GenerateToken(collectionTokenBytes)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to share collection"))
+		return
+	}
+
+	hash := utils.HashToken(token)
+	now := time.Now()
+	err = database.DB.WithContext(c.Request.Context()).Model(collection).
+		Updates(map[string]interface{}{"share_token_hash": hash, "shared_at": now}).Error
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to share collection"))
+		return
+	}
+	c.JSON(http.StatusCreated, models.CollectionShareResponse{
+		ShareURL: shortURLBase(c, "") + "/shared/collections/" + token,
+		Token:    token,
+		SharedAt: now,
+	})
+}
+
+// UnshareCollection godoc
+// @Summary Revoke the share link
+// @ID unshareCollection
+// @Description Revoke the read-only share link of a collection the caller owns.
+// @Tags Collections
+// @Param id path int true "Collection ID"
+// @Success 204 "Share link revoked"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope, or the caller is not the owner"
+// @Failure 404 {object} models.ErrorResponse "Collection not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /collections/{id}/share [delete]
+func UnshareCollection(c *gin.Context) {
+	collection, _, ok := collectionAccess(c, models.CollectionRoleOwner)
+	if !ok {
+		return
+	}
+	err := database.DB.WithContext(c.Request.Context()).Model(collection).
+		Updates(map[string]interface{}{"share_token_hash": nil, "shared_at": nil}).Error
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to revoke share link"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetSharedCollection godoc
+// @Summary View a shared collection
+// @ID getSharedCollection
+// @Description View the links of a collection and their stats through its read-only share link, without an API key. Revoked and replaced share links answer 404.
+// @Tags Collections
+// @Produce json
+// @Param token path string true "Share token"
+// @Success 200 {object} models.CollectionView
+// @Failure 404 {object} models.ErrorResponse "Share link not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Router /shared/collections/{token} [get]
+func GetSharedCollection(c *gin.Context) {
+	var collection models.Collection
+	err := database.DB.WithContext(c.Request.Context()).
+		Where("share_token_hash = ?", utils.HashToken(c.Param("token"))).First(&collection).Error
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Share link not found"))
+		return
+	}
+	view, err := buildCollectionView(c, &collection)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to load collection"))
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=60")
+	c.JSON(http.StatusOK, view)
+}
+
+// collectionAccess loads the collection in the path with the caller's role
+// on it, writing the error response when they have none, or one allowing
+// less than need. Collections the caller has no role on are reported as not
+// found.
+func collectionAccess(c *gin.Context, need string) (*models.Collection, string, bool) {
+	ctx := c.Request.Context()
+	userID := *middleware.CurrentOwnerID(c)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(errCollectionNotFound)
+		return nil, "", false
+	}
+
+	var collection models.Collection
+	if err := database.DB.WithContext(ctx).First(&collection, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(errCollectionNotFound)
+		} else {
+			c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to load collection"))
+		}
+		return nil, "", false
+	}
+
+	role := models.CollectionRoleOwner
+	if collection.OwnerID != userID {
+		var member models.CollectionMember
+		err := database.DB.WithContext(ctx).Where("collection_id = ? AND user_id = ?", collection.ID, userID).First(&member).Error
+		if err != nil {
+			c.Error(errCollectionNotFound)
+			return nil, "", false
+		}
+		role = member.Role
+	}
+	if collectionRoleRank[role] < collectionRoleRank[need] {
+		c.Error(models.NewAPIError(http.StatusForbidden, models.ErrCodeForbidden, "This needs the "+need+" role on the collection, you are "+role))
+		return nil, "", false
+	}
+	return &collection, role, true
+}
+
+// checkCollectionLinks refuses duplicate links and links not owned by
+// ownerID, writing the error response
+func checkCollectionLinks(c *gin.Context, ownerID uint, shortCodes []string) bool {
+	seen := make(map[string]bool, len(shortCodes))
+	for _, shortCode := range shortCodes {
+		if seen[shortCode] {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Link "+shortCode+" is listed twice"))
+			return false
+		}
+		seen[shortCode] = true
+	}
+	if len(shortCodes) == 0 {
+		return true
+	}
+
+	links, err := funnelLinks(c, ownerID, shortCodes)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to look up links"))
+		return false
+	}
+	for _, shortCode := range shortCodes {
+		if _, ok := links[shortCode]; !ok {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Link "+shortCode+" is not owned by the collection's owner"))
+			return false
+		}
+	}
+	return true
+}
+
+// buildCollectionView loads the links of collection still owned by its
+// owner, in order, with their clicks
+func buildCollectionView(c *gin.Context, collection *models.Collection) (*models.CollectionView, error) {
+	ctx := c.Request.Context()
+	to := time.Now().UTC()
+	view := &models.CollectionView{
+		ID:    collection.ID,
+		Name:  collection.Name,
+		From:  to.Truncate(time.Hour).AddDate(0, 0, -collectionRecentDays),
+		Links: []models.CollectionLink{},
+	}
+	if len(collection.Links) == 0 {
+		return view, nil
+	}
+
+	var urls []models.URL
+	err := database.DB.WithContext(ctx).Where("short_code IN ? AND owner_id = ?", collection.Links, collection.OwnerID).Find(&urls).Error
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]*models.URL, len(urls))
+	urlIDs := make([]uint, len(urls))
+	for i := range urls {
+		byCode[urls[i].ShortCode] = &urls[i]
+		urlIDs[i] = urls[i].ID
+	}
+	recent, err := database.LinkClickTotals(ctx, urlIDs, view.From, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, shortCode := range collection.Links {
+		urlRecord, ok := byCode[shortCode]
+		if !ok {
+			continue
+		}
+		link := models.CollectionLink{
+			ShortCode:    urlRecord.ShortCode,
+			ShortURL:     buildShortURL(c, urlRecord.ShortCode),
+			OriginalURL:  urlRecord.OriginalURL,
+			Status:       urlRecord.Status,
+			CreatedAt:    urlRecord.CreatedAt,
+			ExpiresAt:    urlRecord.ExpiresAt,
+			ClickCount:   int64(urlRecord.ClickCount),
+			RecentClicks: recent[urlRecord.ID],
+		}
+		view.ClickCount += link.ClickCount
+		view.RecentClicks += link.RecentClicks
+		view.Links = append(view.Links, link)
+	}
+	return view, nil
+}
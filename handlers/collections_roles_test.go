@@ -0,0 +1,175 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"url-shortener/database"
+	"url-shortener/database/databasetest"
+	"url-shortener/handlers"
+	"url-shortener/handlers/handlertest"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// collectionsRouter serves the collection routes over a SQLite database
+// with users 1 to 4, user 1 owning the links spring and summer
+func collectionsRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	databasetest.UseSQLite(t)
+	gin.SetMode(gin.TestMode)
+
+	for i := 1; i <= 4; i++ {
+		user := models.User{Email: "user" + strconv.Itoa(i) + "@example.com", PasswordHash: "x"}
+		if err := database.DB.Create(&user).Error; err != nil {
+			t.Fatalf("creating user: %v", err)
+		}
+	}
+	owner := uint(1)
+	links := []models.URL{
+		{OriginalURL: "https://example.com/spring", ShortCode: "spring", OwnerID: &owner},
+		{OriginalURL: "https://example.com/summer", ShortCode: "summer", OwnerID: &owner},
+	}
+	if err := database.DB.Create(&links).Error; err != nil {
+		t.Fatalf("creating links: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(middleware.Errors(), handlertest.AsUser())
+	router.POST("/collections", handlers.CreateCollection)
+	router.GET("/collections/:id", handlers.GetCollection)
+	router.PUT("/collections/:id", handlers.UpdateCollection)
+	router.DELETE("/collections/:id", handlers.DeleteCollection)
+	router.GET("/collections/:id/members", handlers.ListCollectionMembers)
+	router.PUT("/collections/:id/members", handlers.GrantCollectionRole)
+	router.POST("/collections/:id/share", handlers.ShareCollection)
+	router.DELETE("/collections/:id/share", handlers.UnshareCollection)
+	router.GET("/shared/collections/:token", handlers.GetSharedCollection)
+	return router
+}
+
+// createCollection creates a collection of user 1 with links and returns
+// its path
+func createCollection(t *testing.T, router *gin.Engine, links string) string {
+	t.Helper()
+	recorder := handlertest.Serve(router, 1, http.MethodPost, "/collections", `{"name":"Campaign","links":`+links+`}`)
+	var collection models.Collection
+	if recorder.Code != http.StatusCreated || json.Unmarshal(recorder.Body.Bytes(), &collection) != nil {
+		t.Fatalf("POST /collections = %d: %s", recorder.Code, recorder.Body)
+	}
+	return "/collections/" + strconv.FormatUint(uint64(collection.ID), 10)
+}
+
+func TestCollectionRoles(t *testing.T) {
+	router := collectionsRouter(t)
+	path := createCollection(t, router, `["spring"]`)
+	for _, grant := range []string{`{"email":"user2@example.com","role":"editor"}`, `{"email":"user3@example.com","role":"viewer"}`} {
+		if recorder := handlertest.Serve(router, 1, http.MethodPut, path+"/members", grant); recorder.Code != http.StatusOK {
+			t.Fatalf("PUT members %s = %d: %s", grant, recorder.Code, recorder.Body)
+		}
+	}
+
+	const owner, editor, viewer, stranger = 1, 2, 3, 4
+	for _, tc := range []struct {
+		userID       uint
+		method, path string
+		body         string
+		want         int
+	}{
+		// Each role is allowed what the ones below it are
+		{viewer, http.MethodGet, path, "", http.StatusOK},
+		{editor, http.MethodGet, path, "", http.StatusOK},
+		{owner, http.MethodGet, path, "", http.StatusOK},
+		{viewer, http.MethodPut, path, `{"name":"Renamed"}`, http.StatusForbidden},
+		{editor, http.MethodPut, path, `{"links":["spring","summer"]}`, http.StatusOK},
+		{viewer, http.MethodPost, path + "/share", "", http.StatusForbidden},
+		{editor, http.MethodPost, path + "/share", "", http.StatusForbidden},
+		{editor, http.MethodGet, path + "/members", "", http.StatusForbidden},
+		{owner, http.MethodGet, path + "/members", "", http.StatusOK},
+		{editor, http.MethodDelete, path, "", http.StatusForbidden},
+		// Collections without a role on them are not found, not forbidden
+		{stranger, http.MethodGet, path, "", http.StatusNotFound},
+		{stranger, http.MethodPut, path, `{"name":"Mine"}`, http.StatusNotFound},
+		{stranger, http.MethodDelete, path, "", http.StatusNotFound},
+		{owner, http.MethodGet, "/collections/999", "", http.StatusNotFound},
+	} {
+		recorder := handlertest.Serve(router, tc.userID, tc.method, tc.path, tc.body)
+		if recorder.Code != tc.want {
+			t.Errorf("user %d: %s %s = %d, want %d: %s", tc.userID, tc.method, tc.path, recorder.Code, tc.want, recorder.Body)
+		}
+	}
+
+	// The viewer's edit was refused, the editor's applied
+	var view models.CollectionView
+	json.Unmarshal(handlertest.Serve(router, viewer, http.MethodGet, path, "").Body.Bytes(), &view)
+	if view.Name != "Campaign" || len(view.Links) != 2 || view.Role != models.CollectionRoleViewer {
+		t.Errorf("collection seen by the viewer = %+v, want Campaign with 2 links", view)
+	}
+}
+
+func TestCollectionShareLinks(t *testing.T) {
+	router := collectionsRouter(t)
+	path := createCollection(t, router, `["spring"]`)
+
+	share := func() string {
+		t.Helper()
+		recorder := handlertest.Serve(router, 1, http.MethodPost, path+"/share", "")
+		var response models.CollectionShareResponse
+		if recorder.Code != http.StatusCreated || json.Unmarshal(recorder.Body.Bytes(), &response) != nil || response.Token == "" {
+			t.Fatalf("POST share = %d: %s", recorder.Code, recorder.Body)
+		}
+		return response.Token
+	}
+	shared := func(token string) int {
+		return handlertest.Serve(router, 0, http.MethodGet, "/shared/collections/"+token, "").Code
+	}
+
+	first := share()
+	if code := shared(first); code != http.StatusOK {
+		t.Errorf("shared collection = %d, want 200 without an API key", code)
+	}
+	// Sharing again replaces the share link
+	second := share()
+	if code := shared(first); code != http.StatusNotFound {
+		t.Errorf("replaced share link = %d, want 404", code)
+	}
+	if code := shared(second); code != http.StatusOK {
+		t.Errorf("new share link = %d, want 200", code)
+	}
+
+	if recorder := handlertest.Serve(router, 1, http.MethodDelete, path+"/share", ""); recorder.Code != http.StatusNoContent {
+		t.Fatalf("DELETE share = %d: %s", recorder.Code, recorder.Body)
+	}
+	if code := shared(second); code != http.StatusNotFound {
+		t.Errorf("revoked share link = %d, want 404", code)
+	}
+}
+
+func TestCollectionLeavesOutLinksNoLongerOwned(t *testing.T) {
+	router := collectionsRouter(t)
+	path := createCollection(t, router, `["spring","summer"]`)
+
+	// summer changed hands after it was added
+	if err := database.DB.Model(&models.URL{}).Where("short_code = ?", "summer").Update("owner_id", 2).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := handlertest.Serve(router, 1, http.MethodGet, path, "")
+	var view models.CollectionView
+	if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &view) != nil {
+		t.Fatalf("GET collection = %d: %s", recorder.Code, recorder.Body)
+	}
+	if len(view.Links) != 1 || view.Links[0].ShortCode != "spring" {
+		t.Errorf("collection links = %+v, want only spring", view.Links)
+	}
+
+	// Nor can it be added again
+	recorder = handlertest.Serve(router, 1, http.MethodPut, path, `{"links":["spring","summer"]}`)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("PUT with a link of another user = %d, want 400", recorder.Code)
+	}
+}
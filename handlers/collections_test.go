@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCheckCollectionLinksRefusesDuplicates(t *testing.T) {
+	for _, tc := range []struct {
+		links []string
+		ok    bool
+	}{
+		{nil, true},
+		{[]string{}, true},
+		{[]string{"spring", "spring"}, false},
+		{[]string{"spring", "go.acme.com/spring", "spring"}, false},
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("POST", "/collections", nil)
+
+		if ok := checkCollectionLinks(c, 1, tc.links); ok != tc.ok {
+			t.Errorf("%q: ok = %t, want %t", tc.links, ok, tc.ok)
+		}
+		if !tc.ok && len(c.Errors) != 1 {
+			t.Errorf("%q: %d errors, want 1", tc.links, len(c.Errors))
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	docs "url-shortener/docs/v1"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Contract tests run handlers through a router and validate each response
+// against the operation in the generated OpenAPI spec: the status code must
+// be documented and the body must match its schema. Objects with declared
+// properties must not carry undeclared ones, so a handler returning fields
+// its annotations don't describe fails here. Regenerate the spec with
+// `make swagger-gen` after changing annotations.
+
+const contractAdminToken = "contract-admin-token"
+
+type contractCase struct {
+	name   string
+	method string
+	path   string // request path
+	route  string // spec path, e.g. /stats/{shortCode}
+	body   string
+	header map[string]string
+	status int
+}
+
+func TestContract(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ADMIN_TOKEN", contractAdminToken)
+
+	spec := loadContractSpec(t)
+	router := contractRouter()
+
+	service.ShareStats("contract1", &models.StatsResponse{
+		OriginalURL: "https://example.com/contract",
+		ShortCode:   "contract1",
+		ClickCount:  3,
+		CreatedAt:   time.Now().UTC(),
+	})
+
+	admin := map[string]string{"Authorization": "Bearer " + contractAdminToken}
+	cases := []contractCase{
+		{name: "version", method: http.MethodGet, path: "/version", route: "/version", status: http.StatusOK},
+		{name: "status", method: http.MethodGet, path: "/status", route: "/status", status: http.StatusOK},
+		{name: "renamed aliases require an API key", method: http.MethodGet, path: "/links/abc123/aliases", route: "/links/{shortCode}/aliases", status: http.StatusUnauthorized},
+		{name: "retiring an alias requires an API key", method: http.MethodDelete, path: "/links/abc123/aliases/promo2024", route: "/links/{shortCode}/aliases/{alias}", status: http.StatusUnauthorized},
+		{name: "link versions require an API key", method: http.MethodGet, path: "/links/abc123/versions", route: "/links/{shortCode}/versions", status: http.StatusUnauthorized},
+		{name: "annotating a link requires an API key", method: http.MethodPost, path: "/links/abc123/annotations", route: "/links/{shortCode}/annotations", body: `{"text":"Newsletter sent"}`, status: http.StatusUnauthorized},
+		{name: "stats reset requires an API key", method: http.MethodPost, path: "/links/abc123/stats/reset", route: "/links/{shortCode}/stats/reset", status: http.StatusUnauthorized},
+		{name: "redirect dry run requires an API key", method: http.MethodGet, path: "/debug/redirect/abc123", route: "/debug/redirect/{shortCode}", status: http.StatusUnauthorized},
+		{name: "webhooks require an API key", method: http.MethodGet, path: "/webhooks", route: "/webhooks", status: http.StatusUnauthorized},
+		{name: "registering a webhook requires an API key", method: http.MethodPost, path: "/webhooks", route: "/webhooks", body: `{"url":"https://example.com/hook","events":["link.created"]}`, status: http.StatusUnauthorized},
+		{name: "funnel reports require an API key", method: http.MethodGet, path: "/funnels/3/report", route: "/funnels/{id}/report", status: http.StatusUnauthorized},
+		{name: "destination stats require an API key", method: http.MethodGet, path: "/stats/destinations", route: "/stats/destinations", status: http.StatusUnauthorized},
+		{name: "duplicates report requires an API key", method: http.MethodGet, path: "/reports/duplicates", route: "/reports/duplicates", status: http.StatusUnauthorized},
+		{name: "links require an API key", method: http.MethodGet, path: "/links", route: "/links", status: http.StatusUnauthorized},
+		{name: "error catalog", method: http.MethodGet, path: "/errors", route: "/errors", status: http.StatusOK},
+		{name: "routing rules schema", method: http.MethodGet, path: "/routing/schema", route: "/routing/schema", status: http.StatusOK},
+		{name: "shorten rejects invalid body", method: http.MethodPost, path: "/shorten", route: "/shorten", body: `{"url":"not a url"}`, status: http.StatusBadRequest},
+		{name: "shorten rejects unknown code style", method: http.MethodPost, path: "/shorten", route: "/shorten", body: `{"url":"https://example.com","code_style":"emoji"}`, status: http.StatusBadRequest},
+		{name: "shorten rejects a reserved alias", method: http.MethodPost, path: "/shorten", route: "/shorten", body: `{"url":"https://example.com","custom_alias":"stats"}`, status: http.StatusBadRequest},
+		{name: "shorten rejects a single variant", method: http.MethodPost, path: "/shorten", route: "/shorten", body: `{"url":"https://example.com","variants":[{"url":"https://example.com/b"}]}`, status: http.StatusBadRequest},
+		{name: "channels rejects invalid body", method: http.MethodPost, path: "/shorten/channels", route: "/shorten/channels", body: `{}`, status: http.StatusBadRequest},
+		{name: "stats served from recent results", method: http.MethodGet, path: "/stats/contract1?max_age=300", route: "/stats/{shortCode}", status: http.StatusOK},
+		{name: "stats with field selection", method: http.MethodGet, path: "/stats/contract1?max_age=300&fields=click_count,short_code", route: "/stats/{shortCode}", status: http.StatusOK},
+		{name: "stats rejects unknown field", method: http.MethodGet, path: "/stats/contract1?max_age=300&fields=bogus", route: "/stats/{shortCode}", status: http.StatusBadRequest},
+		{name: "timeseries rejects unknown interval", method: http.MethodGet, path: "/stats/contract1/timeseries?interval=week", route: "/stats/{shortCode}/timeseries", status: http.StatusBadRequest},
+		{name: "timeseries rejects too many buckets", method: http.MethodGet, path: "/stats/contract1/timeseries?interval=hour&from=2020-01-01T00:00:00Z", route: "/stats/{shortCode}/timeseries", status: http.StatusBadRequest},
+		{name: "qr code rejects unknown format", method: http.MethodGet, path: "/contract1/qr?format=gif", route: "/{shortCode}/qr", status: http.StatusBadRequest},
+		{name: "qr code rejects oversized image", method: http.MethodGet, path: "/contract1/qr?size=4096", route: "/{shortCode}/qr", status: http.StatusBadRequest},
+		{name: "qr code rejects unknown error correction level", method: http.MethodGet, path: "/contract1/qr?ecc=X", route: "/{shortCode}/qr", status: http.StatusBadRequest},
+		{name: "tag stats reject unknown interval", method: http.MethodGet, path: "/stats/tags/spring-sale?interval=week", route: "/stats/tags/{tag}", status: http.StatusBadRequest},
+		{name: "tag stats reject too many buckets", method: http.MethodGet, path: "/stats/tags/spring-sale?interval=hour&from=2020-01-01T00:00:00Z", route: "/stats/tags/{tag}", status: http.StatusBadRequest},
+		{name: "referrers reject reversed range", method: http.MethodGet, path: "/stats/contract1/referrers?from=2024-02-01T00:00:00Z&to=2024-01-01T00:00:00Z", route: "/stats/{shortCode}/referrers", status: http.StatusBadRequest},
+		{name: "uniques reject too many days", method: http.MethodGet, path: "/stats/contract1/uniques?from=2000-01-01T00:00:00Z", route: "/stats/{shortCode}/uniques", status: http.StatusBadRequest},
+		{name: "stats rejects invalid max_age", method: http.MethodGet, path: "/stats/contract1?max_age=-1", route: "/stats/{shortCode}", status: http.StatusBadRequest},
+		{name: "hook triggers require admin", method: http.MethodGet, path: "/admin/hooks/triggers", route: "/admin/hooks/triggers", header: map[string]string{"Authorization": "Bearer wrong"}, status: http.StatusUnauthorized},
+		{name: "hook triggers", method: http.MethodGet, path: "/admin/hooks/triggers", route: "/admin/hooks/triggers", header: admin, status: http.StatusOK},
+		{name: "hook sample for unknown event", method: http.MethodGet, path: "/admin/hooks/triggers/link.unknown/sample", route: "/admin/hooks/triggers/{event}/sample", header: admin, status: http.StatusNotFound},
+		{name: "chaos configuration", method: http.MethodGet, path: "/admin/chaos", route: "/admin/chaos", header: admin, status: http.StatusOK},
+		{name: "chaos rejects invalid target", method: http.MethodPut, path: "/admin/chaos", route: "/admin/chaos", body: `{"error_rate":0.5,"targets":["disk"]}`, header: admin, status: http.StatusBadRequest},
+		{name: "mirror status", method: http.MethodGet, path: "/admin/mirror", route: "/admin/mirror", header: admin, status: http.StatusOK},
+		{name: "expired link cleanup requires admin", method: http.MethodPost, path: "/admin/expired-links/cleanup", route: "/admin/expired-links/cleanup", header: map[string]string{"Authorization": "Bearer wrong"}, status: http.StatusUnauthorized},
+		{name: "integrity verification requires admin", method: http.MethodPost, path: "/admin/integrity/verify", route: "/admin/integrity/verify", header: map[string]string{"Authorization": "Bearer wrong"}, status: http.StatusUnauthorized},
+		{name: "click reconciliation", method: http.MethodGet, path: "/admin/click-reconciliation", route: "/admin/click-reconciliation", header: admin, status: http.StatusOK},
+		{name: "change feed requires admin", method: http.MethodGet, path: "/changes", route: "/changes", header: map[string]string{"Authorization": "Bearer wrong"}, status: http.StatusUnauthorized},
+		{name: "change feed rejects invalid cursor", method: http.MethodGet, path: "/changes?since=abc", route: "/changes", header: admin, status: http.StatusBadRequest},
+		{name: "change feed rejects invalid limit", method: http.MethodGet, path: "/changes?since=1&limit=5000", route: "/changes", header: admin, status: http.StatusBadRequest},
+		{name: "hook deliveries reject unknown status", method: http.MethodGet, path: "/admin/hooks/deliveries?status=lost", route: "/admin/hooks/deliveries", header: admin, status: http.StatusBadRequest},
+		{name: "hook redrive rejects invalid subscription", method: http.MethodPost, path: "/admin/hooks/deliveries/redrive?subscription_id=x", route: "/admin/hooks/deliveries/redrive", header: admin, status: http.StatusBadRequest},
+		{name: "hook redrive of unknown delivery", method: http.MethodPost, path: "/admin/hooks/deliveries/x/redrive", route: "/admin/hooks/deliveries/{id}/redrive", header: admin, status: http.StatusNotFound},
+		{name: "hook subscribe rejects private target", method: http.MethodPost, path: "/admin/hooks", route: "/admin/hooks", body: `{"event":"link.created","target_url":"http://169.254.169.254/latest"}`, header: admin, status: http.StatusBadRequest},
+		{name: "domain rejects invalid name", method: http.MethodPost, path: "/admin/domains", route: "/admin/domains", body: `{"domain":"https://example.com/path"}`, header: admin, status: http.StatusBadRequest},
+		{name: "domain verify rejects unknown method", method: http.MethodPost, path: "/admin/domains/1/verify", route: "/admin/domains/{id}/verify", body: `{"method":"email"}`, header: admin, status: http.StatusBadRequest},
+		{name: "short link domain rejects invalid host", method: http.MethodPost, path: "/admin/short-domains", route: "/admin/short-domains", body: `{"host":"https://go.acme.com"}`, header: admin, status: http.StatusBadRequest},
+		{name: "domain update requires options", method: http.MethodPut, path: "/admin/domains/1", route: "/admin/domains/{id}", body: `{}`, header: admin, status: http.StatusBadRequest},
+		{name: "link export requires a selection", method: http.MethodPost, path: "/admin/links/export", route: "/admin/links/export", body: `{}`, header: admin, status: http.StatusBadRequest},
+		{name: "bulk operation requires a filter", method: http.MethodPost, path: "/admin/links/bulk", route: "/admin/links/bulk", body: `{"action":"disable"}`, header: admin, status: http.StatusBadRequest},
+		{name: "bulk operation rejects invalid domain", method: http.MethodPost, path: "/admin/links/bulk", route: "/admin/links/bulk", body: `{"action":"expire","domain":"https://evil.com/"}`, header: admin, status: http.StatusBadRequest},
+		{name: "artifact rejects unknown kind", method: http.MethodPost, path: "/admin/artifacts", route: "/admin/artifacts", body: `{"kind":"report"}`, header: admin, status: http.StatusBadRequest},
+		{name: "artifacts require storage", method: http.MethodGet, path: "/admin/artifacts", route: "/admin/artifacts", header: admin, status: http.StatusServiceUnavailable},
+		{name: "link import rejects unknown version", method: http.MethodPost, path: "/admin/links/import", route: "/admin/links/import", body: `{"version":2,"links":[]}`, header: admin, status: http.StatusBadRequest},
+		{name: "url export rejects unknown format", method: http.MethodGet, path: "/admin/urls/export?format=xml", route: "/admin/urls/export", header: admin, status: http.StatusBadRequest},
+		{name: "url export rejects invalid filter", method: http.MethodGet, path: "/admin/urls/export?expired=maybe", route: "/admin/urls/export", header: admin, status: http.StatusBadRequest},
+		{name: "url import rejects unknown format", method: http.MethodPost, path: "/admin/urls/import?format=xml", route: "/admin/urls/import", body: `[]`, header: admin, status: http.StatusBadRequest},
+		{name: "url import requires a destination column", method: http.MethodPost, path: "/admin/urls/import?format=csv", route: "/admin/urls/import", body: "code,clicks\npromo,3\n", header: admin, status: http.StatusBadRequest},
+		{name: "url list rejects invalid created_after", method: http.MethodGet, path: "/admin/urls?created_after=yesterday", route: "/admin/urls", header: admin, status: http.StatusBadRequest},
+		{name: "url list rejects invalid domain filter", method: http.MethodGet, path: "/admin/urls?domain=https://evil.com", route: "/admin/urls", header: admin, status: http.StatusBadRequest},
+		{name: "global stats rejects invalid top", method: http.MethodGet, path: "/admin/stats?top=0", route: "/admin/stats", header: admin, status: http.StatusBadRequest},
+		{name: "url list rejects invalid expired filter", method: http.MethodGet, path: "/admin/urls?expired=maybe", route: "/admin/urls", header: admin, status: http.StatusBadRequest},
+		{name: "url update rejects reserved alias", method: http.MethodPut, path: "/admin/urls/abc123", route: "/admin/urls/{shortCode}", body: `{"custom_alias":"admin"}`, header: admin, status: http.StatusBadRequest},
+		{name: "url update rejects invalid alias characters", method: http.MethodPut, path: "/admin/urls/abc123", route: "/admin/urls/{shortCode}", body: `{"custom_alias":"promo 2025"}`, header: admin, status: http.StatusBadRequest},
+		{name: "url update rejects negative expiry", method: http.MethodPut, path: "/admin/urls/abc123", route: "/admin/urls/{shortCode}", body: `{"expires_in":-1}`, header: admin, status: http.StatusBadRequest},
+		{name: "hook subscribe rejects invalid body", method: http.MethodPost, path: "/admin/hooks", route: "/admin/hooks", body: `{"event":"link.created"}`, header: admin, status: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.body != "" {
+				request.Header.Set("Content-Type", "application/json")
+			}
+			for key, value := range tc.header {
+				request.Header.Set(key, value)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tc.status {
+				t.Fatalf("status = %d, want %d (body %s)", recorder.Code, tc.status, recorder.Body.String())
+			}
+			spec.checkResponse(t, tc.method, tc.route, recorder)
+		})
+	}
+}
+
+// TestContractSpecReferences checks every $ref in the spec resolves, so a
+// renamed or removed model is caught even without a test case for its route
+func TestContractSpecReferences(t *testing.T) {
+	spec := loadContractSpec(t)
+
+	var walk func(path string, node interface{})
+	walk = func(path string, node interface{}) {
+		switch value := node.(type) {
+		case map[string]interface{}:
+			if ref, ok := value["$ref"].(string); ok {
+				if _, err := spec.resolve(ref); err != nil {
+					t.Errorf("%s: %v", path, err)
+				}
+			}
+			for key, child := range value {
+				walk(path+"/"+key, child)
+			}
+		case []interface{}:
+			for i, child := range value {
+				walk(path+"/"+strconv.Itoa(i), child)
+			}
+		}
+	}
+	walk("#", spec.doc)
+}
+
+// contractRouter mounts the routes under test with the same middleware as
+// cmd/server, minus timeouts
+func contractRouter() *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.RequestMetrics())
+	router.Use(middleware.Errors())
+	router.GET("/errors", ListErrorCodes)
+	router.GET("/routing/schema", GetRoutingSchema)
+	router.GET("/status", GetStatus)
+	router.GET("/version", GetVersion)
+	router.POST("/shorten", middleware.APIKeyAuth(), middleware.RequireScope(models.ScopeCreate), ShortenURL)
+	router.POST("/shorten/channels", middleware.APIKeyAuth(), middleware.RequireScope(models.ScopeCreate), ShortenChannels)
+	router.GET("/links", middleware.APIKeyAuth(), middleware.RequireKeyOwner(), ListLinks)
+	router.GET("/debug/redirect/:shortCode", middleware.APIKeyAuth(), middleware.RequireKeyOwner(), SimulateRedirect)
+	router.GET("/links/:shortCode/aliases", middleware.APIKeyAuth(), middleware.RequireKeyOwner(), ListRenamedAliases)
+	router.GET("/links/:shortCode/versions", middleware.APIKeyAuth(), middleware.RequireKeyOwner(), ListLinkVersions)
+	router.GET("/webhooks", middleware.APIKeyAuth(), middleware.RequireKeyOwner(), ListWebhooks)
+	router.POST("/webhooks", middleware.APIKeyAuth(), middleware.RequireKeyOwner(), CreateWebhook)
+	router.DELETE("/links/:shortCode/aliases/:alias", middleware.APIKeyAuth(), middleware.RequireKeyOwner(), RetireRenamedAlias)
+	router.POST("/links/:shortCode/stats/reset", middleware.APIKeyAuth(), middleware.RequireKeyOwner(), ResetLinkStats)
+	router.POST("/links/:shortCode/annotations", middleware.APIKeyAuth(), middleware.RequireKeyOwner(), CreateAnnotation)
+	router.GET("/:shortCode/qr", GetQRCode)
+	router.GET("/stats/:shortCode", middleware.APIKeyAuth(), middleware.RequireScope(models.ScopeReadStats), GetURLStats)
+	router.GET("/funnels/:id/report", middleware.APIKeyAuth(), middleware.RequireKeyOwner(), GetFunnelReport)
+	router.GET("/stats/destinations", middleware.APIKeyAuth(), middleware.RequireKeyOwner(), GetDestinationStats)
+	router.GET("/reports/duplicates", middleware.APIKeyAuth(), middleware.RequireKeyOwner(), GetDuplicatesReport)
+	router.GET("/stats/tags/:tag", middleware.APIKeyAuth(), middleware.RequireScope(models.ScopeReadStats), GetTagStats)
+	router.GET("/stats/:shortCode/timeseries", middleware.APIKeyAuth(), middleware.RequireScope(models.ScopeReadStats), GetClickTimeseries)
+	router.GET("/stats/:shortCode/referrers", middleware.APIKeyAuth(), middleware.RequireScope(models.ScopeReadStats), GetTopReferrers)
+	router.GET("/stats/:shortCode/uniques", middleware.APIKeyAuth(), middleware.RequireScope(models.ScopeReadStats), GetUniqueVisitors)
+
+	router.GET("/changes", middleware.APIKeyAuth(), middleware.AdminAuth(), ListLinkChanges)
+	admin := router.Group("/admin", middleware.APIKeyAuth(), middleware.AdminAuth())
+	admin.GET("/hooks/triggers", ListHookTriggers)
+	admin.GET("/click-reconciliation", GetClickReconciliation)
+	admin.GET("/integrity", GetIntegrity)
+	admin.POST("/integrity/verify", VerifyIntegrity)
+	admin.POST("/expired-links/cleanup", CleanUpExpiredLinks)
+	admin.GET("/mirror", GetMirrorStatus)
+	admin.GET("/chaos", GetChaos)
+	admin.PUT("/chaos", UpdateChaos)
+	admin.GET("/hooks/triggers/:event/sample", SampleHookTrigger)
+	admin.POST("/hooks", SubscribeHook)
+	admin.GET("/hooks/deliveries", ListHookDeliveries)
+	admin.POST("/hooks/deliveries/redrive", RedriveHookDeliveries)
+	admin.POST("/hooks/deliveries/:id/redrive", RedriveHookDelivery)
+	admin.POST("/domains", CreateDomain)
+	admin.POST("/links/export", ExportLinks)
+	admin.POST("/links/import", ImportLinks)
+	admin.POST("/links/bulk", StartBulkOperation)
+	admin.GET("/artifacts", ListArtifacts)
+	admin.POST("/artifacts", CreateArtifact)
+	admin.GET("/urls", ListURLs)
+	admin.GET("/urls/export", ExportURLs)
+	admin.POST("/urls/import", ImportURLs)
+	admin.GET("/stats", GetGlobalStats)
+	admin.PUT("/urls/:shortCode", UpdateURL)
+	admin.PUT("/domains/:id", UpdateDomain)
+	admin.POST("/domains/:id/verify", VerifyDomain)
+	admin.POST("/short-domains", CreateShortDomain)
+	return router
+}
+
+type contractSpec struct {
+	doc map[string]interface{}
+}
+
+func loadContractSpec(t *testing.T) *contractSpec {
+	t.Helper()
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &doc); err != nil {
+		t.Fatalf("failed to parse OpenAPI spec: %v", err)
+	}
+	return &contractSpec{doc: doc}
+}
+
+// checkResponse validates a recorded response against the spec operation
+func (s *contractSpec) checkResponse(t *testing.T, method, route string, recorder *httptest.ResponseRecorder) {
+	t.Helper()
+
+	paths, _ := s.doc["paths"].(map[string]interface{})
+	item, ok := paths[route].(map[string]interface{})
+	if !ok {
+		t.Fatalf("route %s is not in the spec", route)
+	}
+	operation, ok := item[strings.ToLower(method)].(map[string]interface{})
+	if !ok {
+		t.Fatalf("%s %s is not in the spec", method, route)
+	}
+
+	responses, _ := operation["responses"].(map[string]interface{})
+	response, ok := responses[strconv.Itoa(recorder.Code)].(map[string]interface{})
+	if !ok {
+		if response, ok = responses["default"].(map[string]interface{}); !ok {
+			t.Fatalf("%s %s: status %d is not documented", method, route, recorder.Code)
+		}
+	}
+
+	schema, ok := response["schema"]
+	if !ok {
+		return
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%s %s: response is not JSON: %v", method, route, err)
+	}
+	if err := s.validate("body", schema, body); err != nil {
+		t.Errorf("%s %s %d: %v\nbody: %s", method, route, recorder.Code, err, recorder.Body.String())
+	}
+}
+
+func (s *contractSpec) resolve(ref string) (map[string]interface{}, error) {
+	name := strings.TrimPrefix(ref, "#/definitions/")
+	definitions, _ := s.doc["definitions"].(map[string]interface{})
+	definition, ok := definitions[name].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unresolved reference %s", ref)
+	}
+	return definition, nil
+}
+
+// validate checks value against the subset of JSON Schema swag generates
+func (s *contractSpec) validate(path string, rawSchema, value interface{}) error {
+	schema, _ := rawSchema.(map[string]interface{})
+	if ref, ok := schema["$ref"].(string); ok {
+		definition, err := s.resolve(ref)
+		if err != nil {
+			return err
+		}
+		return s.validate(path, definition, value)
+	}
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, part := range allOf {
+			if err := s.validate(path, part, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %T", path, value)
+		}
+		return s.validateObject(path, schema, object)
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T", path, value)
+		}
+		for i, element := range array {
+			if err := s.validate(path+"["+strconv.Itoa(i)+"]", schema["items"], element); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok && value != nil {
+			return fmt.Errorf("%s: expected string, got %T", path, value)
+		}
+	case "integer":
+		if number, ok := value.(float64); !ok || number != float64(int64(number)) {
+			if value != nil {
+				return fmt.Errorf("%s: expected integer, got %v", path, value)
+			}
+		}
+	case "number":
+		if _, ok := value.(float64); !ok && value != nil {
+			return fmt.Errorf("%s: expected number, got %T", path, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok && value != nil {
+			return fmt.Errorf("%s: expected boolean, got %T", path, value)
+		}
+	}
+	return nil
+}
+
+func (s *contractSpec) validateObject(path string, schema, object map[string]interface{}) error {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if _, ok := object[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+	}
+
+	properties, hasProperties := schema["properties"].(map[string]interface{})
+	additional, hasAdditional := schema["additionalProperties"]
+	for name, propertyValue := range object {
+		if property, ok := properties[name]; ok {
+			if err := s.validate(path+"."+name, property, propertyValue); err != nil {
+				return err
+			}
+			continue
+		}
+		switch {
+		case hasAdditional:
+			if err := s.validate(path+"."+name, additional, propertyValue); err != nil {
+				return err
+			}
+		case hasProperties:
+			return fmt.Errorf("%s: undocumented property %q", path, name)
+		}
+	}
+	return nil
+}
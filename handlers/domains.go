@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/domains"
+	"url-shortener/models"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Random bytes in a domain verification token
+const domainTokenBytes = 16
+
+// ListDomains godoc
+// @Summary List destination domains
+// @ID listDomains
+// @Description List destination domains added for ownership verification, with their verification records
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} models.DomainResponse
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/domains [get]
+func ListDomains(c *gin.Context) {
+	var stored []models.VerifiedDomain
+	if err := database.DB.Order("domain asc").Find(&stored).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list domains"))
+		return
+	}
+
+	response := make([]models.DomainResponse, len(stored))
+	for i := range stored {
+		response[i] = buildDomainResponse(stored[i])
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateDomain godoc
+// @Summary Add a destination domain
+// @ID createDomain
+// @Description Add a destination domain and get the DNS TXT record and meta tag that prove its ownership. Links to the domain and its subdomains are marked verified once it is verified.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body models.DomainRequest true "Domain to verify"
+// @Success 201 {object} models.DomainResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 409 {object} models.ErrorResponse "Domain already added"
+// @Security AdminAuth
+// @Router /admin/domains [post]
+func CreateDomain(c *gin.Context) {
+	var request models.DomainRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	name, err := domains.Normalize(request.Domain)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.VerifiedDomain{}).Where("domain = ?", name).Count(&count).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to add domain"))
+		return
+	}
+	if count > 0 {
+		c.Error(models.NewAPIError(http.StatusConflict, models.ErrCodeConflict, "Domain has already been added"))
+		return
+	}
+
+	token, err := utils.GenerateToken(domainTokenBytes)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to add domain"))
+		return
+	}
+	domain := models.VerifiedDomain{Domain: name, Token: token, SkipApproval: request.SkipApproval}
+	if err := database.DB.Create(&domain).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to add domain"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, buildDomainResponse(domain))
+}
+
+// VerifyDomain godoc
+// @Summary Verify a destination domain
+// @ID verifyDomain
+// @Description Check the domain's DNS TXT record (dns) or the meta tag on its https home page (meta) for the verification token, and mark the domain verified when found
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Domain ID"
+// @Param request body models.DomainVerifyRequest true "Verification method"
+// @Success 200 {object} models.DomainResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Domain not found"
+// @Failure 422 {object} models.ErrorResponse "Verification token not found"
+// @Failure 502 {object} models.ErrorResponse "DNS lookup or home page fetch failed"
+// @Security AdminAuth
+// @Router /admin/domains/{id}/verify [post]
+func VerifyDomain(c *gin.Context) {
+	var request models.DomainVerifyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	var domain models.VerifiedDomain
+	if err := database.DB.First(&domain, c.Param("id")).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Domain not found"))
+		return
+	}
+
+	if err := domains.Check(c.Request.Context(), domain.Domain, domain.Token, request.Method); err != nil {
+		if errors.Is(err, domains.ErrNotVerified) {
+			c.Error(models.NewAPIError(http.StatusUnprocessableEntity, models.ErrCodeDomainUnverified, "Verification token not found for "+domain.Domain))
+			return
+		}
+		slog.WarnContext(c.Request.Context(), "Failed to verify domain", "domain", domain.Domain, "error", err)
+		c.Error(models.NewAPIError(http.StatusBadGateway, models.ErrCodeDomainUnverified, "Could not check the domain: "+err.Error()))
+		return
+	}
+
+	now := time.Now()
+	domain.VerifiedAt = &now
+	domain.Method = request.Method
+	if err := database.DB.Save(&domain).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update domain"))
+		return
+	}
+	domains.Invalidate()
+
+	c.JSON(http.StatusOK, buildDomainResponse(domain))
+}
+
+// UpdateDomain godoc
+// @Summary Update a destination domain
+// @ID updateDomain
+// @Description Change whether links to a verified domain skip approval holds
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Domain ID"
+// @Param request body models.DomainUpdateRequest true "Domain options"
+// @Success 200 {object} models.DomainResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Domain not found"
+// @Security AdminAuth
+// @Router /admin/domains/{id} [put]
+func UpdateDomain(c *gin.Context) {
+	var request models.DomainUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	var domain models.VerifiedDomain
+	if err := database.DB.First(&domain, c.Param("id")).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Domain not found"))
+		return
+	}
+
+	domain.SkipApproval = *request.SkipApproval
+	if err := database.DB.Save(&domain).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update domain"))
+		return
+	}
+	domains.Invalidate()
+
+	c.JSON(http.StatusOK, buildDomainResponse(domain))
+}
+
+// DeleteDomain godoc
+// @Summary Remove a destination domain
+// @ID deleteDomain
+// @Description Remove a domain; links to it are no longer marked verified
+// @Tags Admin
+// @Param id path int true "Domain ID"
+// @Success 204 "Domain removed"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Domain not found"
+// @Security AdminAuth
+// @Router /admin/domains/{id} [delete]
+func DeleteDomain(c *gin.Context) {
+	result := database.DB.Delete(&models.VerifiedDomain{}, c.Param("id"))
+	if result.Error != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to remove domain"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Domain not found"))
+		return
+	}
+	domains.Invalidate()
+
+	c.Status(http.StatusNoContent)
+}
+
+func buildDomainResponse(domain models.VerifiedDomain) models.DomainResponse {
+	return models.DomainResponse{
+		VerifiedDomain: domain,
+		DNSRecordName:  domains.DNSRecordName(domain.Domain),
+		DNSRecordValue: domains.DNSRecordValue(domain.Token),
+		MetaTag:        domains.MetaTag(domain.Token),
+	}
+}
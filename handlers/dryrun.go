@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/routing"
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SimulateRedirect godoc
+// @Summary Simulate a redirect
+// @ID simulateRedirect
+// @Description Walk through how GET /{shortCode} would answer a visitor sending the same headers (User-Agent, Accept, Accept-Language and location headers) and info or preview parameters as this request, and return the rules checked in order: how the short code resolves, including old codes of renamed links, whether the link is available and not expired, loop detection, link preview crawlers, the rollout of a soft launched link, each routing rule of the link, the velocity limit, max_clicks and the variant a split link would serve. Nothing is redirected and no click is counted or used up. The variant is one draw from the link's current traffic shares, listed in variants. Only links owned by the caller can be simulated.
+// @Tags Links
+// @Produce json
+// @Param shortCode path string true "Short code, followed by + to simulate the preview page"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param info query int false "Set to 1 to simulate asking for the plaintext summary"
+// @Param preview query int false "Set to 1 to simulate asking for the preview page"
+// @Success 200 {object} models.RedirectTrace
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /debug/redirect/{shortCode} [get]
+func SimulateRedirect(c *gin.Context) {
+	ctx := c.Request.Context()
+	ownerID := *middleware.CurrentOwnerID(c)
+	shortCode, preview := strings.CutSuffix(pathLinkKey(c), "+")
+	trace := &models.RedirectTrace{ShortCode: shortCode}
+
+	urlRecord, source, err := findTracedLink(ctx, shortCode)
+	if err != nil {
+		// Renamed links keep their old short code for a grace period
+		alias, currentCode, err := database.FindRenamedAlias(ctx, shortCode, time.Now())
+		if err != nil {
+			c.Error(models.ErrLinkNotFound)
+			return
+		}
+		if urlRecord, source, err = findTracedLink(ctx, currentCode); err != nil || !tracedLinkOwned(urlRecord, ownerID) {
+			c.Error(models.ErrLinkNotFound)
+			return
+		}
+		addTraceStep(trace, models.TraceRuleLookup, false, "No link has this short code")
+		addTraceStep(trace, models.TraceRuleRenamedAlias, true, fmt.Sprintf("Old short code of %s until %s", currentCode, alias.ExpiresAt.UTC().Format(time.RFC3339)))
+		entry, _ := loadTracedEntry(currentCode, urlRecord)
+		traceRenamedAlias(c, trace, currentCode, entry)
+		c.JSON(http.StatusOK, trace)
+		return
+	}
+	if !tracedLinkOwned(urlRecord, ownerID) {
+		c.Error(models.ErrLinkNotFound)
+		return
+	}
+
+	entry, cached := loadTracedEntry(shortCode, urlRecord)
+	if cached {
+		source = "Found in the cache"
+	}
+	addTraceStep(trace, models.TraceRuleLookup, false, source)
+	traceRedirect(c, trace, shortCode, urlRecord, entry, preview)
+	c.JSON(http.StatusOK, trace)
+}
+
+// findTracedLink looks up a live or archived link without rehydrating it,
+// returning where it was found
+func findTracedLink(ctx context.Context, shortCode string) (*models.URL, string, error) {
+	if urlRecord, err := database.Links.GetByShortCode(ctx, shortCode); err == nil {
+		return urlRecord, "Found in the database", nil
+	}
+	urlRecord, err := database.FindArchivedURL(ctx, shortCode)
+	if err != nil {
+		return nil, "", err
+	}
+	return urlRecord, "Found in the archive, restored on the first visit", nil
+}
+
+func tracedLinkOwned(urlRecord *models.URL, ownerID uint) bool {
+	return urlRecord.OwnerID != nil && *urlRecord.OwnerID == ownerID
+}
+
+// loadTracedEntry returns the cached redirect entry of a link, which is what
+// redirects read, or one built from urlRecord when nothing is cached,
+// reporting whether it was cached
+func loadTracedEntry(shortCode string, urlRecord *models.URL) (*cache.RedirectEntry, bool) {
+	if entry, err := cache.GetRedirectEntry(shortCode); err == nil {
+		return entry, true
+	}
+	return cache.NewRedirectEntry(urlRecord), false
+}
+
+// traceRedirect follows the rules of RedirectURL for an available short code
+func traceRedirect(c *gin.Context, trace *models.RedirectTrace, shortCode string, urlRecord *models.URL, entry *cache.RedirectEntry, preview bool) {
+	if !traceAvailability(trace, entry, time.Now()) {
+		return
+	}
+
+	if wantsLinkInfo(c) {
+		endTrace(trace, models.TraceRuleInfo, http.StatusOK, "Plaintext summary requested with ?info=1 or Accept: text/plain")
+		return
+	}
+	addTraceStep(trace, models.TraceRuleInfo, false, "Plaintext summary not requested")
+
+	if preview || wantsLinkPreview(c) {
+		endTrace(trace, models.TraceRulePreview, http.StatusOK, "Preview page requested with + or ?preview=1")
+		return
+	}
+	addTraceStep(trace, models.TraceRulePreview, false, "Preview page not requested")
+
+	if defaultHandler().redirectLoops(c, shortCode, entry) {
+		failTrace(trace, models.TraceRuleLoop, models.ErrLinkLoop, "The destination leads back to this link through short links of the service")
+		return
+	}
+	addTraceStep(trace, models.TraceRuleLoop, false, "The destination leaves the service")
+
+	crawler := isPreviewCrawler(c.GetHeader("User-Agent"))
+	switch {
+	case !entry.Has(cache.RedirectPreview):
+		addTraceStep(trace, models.TraceRulePreviewCard, false, "No custom preview card")
+	case crawler:
+		endTrace(trace, models.TraceRulePreviewCard, http.StatusOK, "User-Agent is a link preview crawler, served the custom preview card")
+		return
+	default:
+		addTraceStep(trace, models.TraceRulePreviewCard, false, "User-Agent is not a link preview crawler")
+	}
+
+	if !traceRollout(c, trace, entry) {
+		return
+	}
+
+	rule, ok := traceRouting(c, trace, entry)
+	if !ok {
+		return
+	}
+
+	traceVelocity(trace, entry)
+
+	if !traceMaxClicks(trace, shortCode, urlRecord, entry, crawler) {
+		return
+	}
+
+	destination := entry.Destination
+	switch {
+	case rule != nil && rule.Action.Type == models.RoutingActionRedirect:
+		destination = rule.Action.URL
+		addTraceStep(trace, models.TraceRuleVariants, false, "The routing rule decides the destination")
+	case entry.Has(cache.RedirectVariants):
+		destination = traceVariants(c, trace, urlRecord, entry)
+	default:
+		addTraceStep(trace, models.TraceRuleVariants, false, "Not a split link")
+	}
+
+	trace.Location = entry.Target(destination)
+	detail := "Redirected to the destination"
+	if entry.UTM != "" {
+		detail += " with the link's UTM parameters it does not set itself"
+	}
+	if entry.Has(cache.RedirectNoIndex) {
+		detail += ", with X-Robots-Tag: noindex"
+	}
+	if entry.Has(cache.RedirectLandings) {
+		detail += ", adding a landing token as " + landingParam
+	}
+	if len(entry.Headers) > 0 {
+		names := make([]string, 0, len(entry.Headers))
+		for name := range entry.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		detail += ", with the link's " + strings.Join(names, ", ") + " headers"
+	}
+	endTrace(trace, models.TraceRuleRedirect, entry.StatusCode, detail)
+}
+
+// traceRenamedAlias follows the rules of followRenamedAlias for the current
+// short code of a renamed link
+func traceRenamedAlias(c *gin.Context, trace *models.RedirectTrace, currentCode string, entry *cache.RedirectEntry) {
+	shortURL := buildShortURL(c, currentCode)
+	if aliasRenameTarget == models.AliasTargetDestination {
+		if !traceAvailability(trace, entry, time.Now()) {
+			return
+		}
+		if !entry.Has(cache.RedirectVariants|cache.RedirectRollout|cache.RedirectVelocity) && len(entry.Rules) == 0 && !defaultHandler().redirectLoops(c, currentCode, entry) {
+			trace.Location = entry.Target(entry.Destination)
+			endTrace(trace, models.TraceRuleRedirect, entry.StatusCode, "Redirected straight to the destination, as ALIAS_RENAME_TARGET is destination")
+			return
+		}
+		addTraceStep(trace, models.TraceRuleVariants, false, "Split links, links with routing rules, a rollout or a velocity limit and links leading back into the service are left to their short URL")
+	}
+
+	trace.Location = shortURL
+	if c.Request.URL.RawQuery != "" {
+		trace.Location += "?" + c.Request.URL.RawQuery
+	}
+	endTrace(trace, models.TraceRuleRedirect, http.StatusMovedPermanently, "Redirected to the new short URL")
+}
+
+// traceAvailability checks whether the link may be followed at all,
+// reporting false once the trace has ended
+func traceAvailability(trace *models.RedirectTrace, entry *cache.RedirectEntry, now time.Time) bool {
+	apiErr := service.UnavailableLinkError(entry, now)
+	if apiErr != nil && apiErr.Code != models.ErrCodeLinkExpired {
+		failTrace(trace, models.TraceRuleAvailability, apiErr, unavailableDetail(entry))
+		return false
+	}
+	addTraceStep(trace, models.TraceRuleAvailability, false, "Active")
+
+	expiry := "Never expires"
+	if entry.ExpiresAt != 0 {
+		expiry = "Expires at " + time.Unix(entry.ExpiresAt, 0).UTC().Format(time.RFC3339)
+	}
+	if apiErr != nil {
+		failTrace(trace, models.TraceRuleExpiry, apiErr, strings.Replace(expiry, "Expires", "Expired", 1))
+		return false
+	}
+	addTraceStep(trace, models.TraceRuleExpiry, false, expiry)
+	return true
+}
+
+// unavailableDetail explains why service.UnavailableLinkError refuses a link
+// that has not expired
+func unavailableDetail(entry *cache.RedirectEntry) string {
+	switch {
+	case entry.Has(cache.RedirectInert):
+		return "Created by a shadow-banned user, answered as not found"
+	case entry.Has(cache.RedirectPending):
+		return "Pending approval"
+	case entry.Has(cache.RedirectRejected):
+		return "Rejected by an admin, answered as not found"
+	case entry.Has(cache.RedirectDisabled):
+		return "Disabled by an admin"
+	}
+	return "Unavailable"
+}
+
+// traceVelocity describes the link's velocity limit, which the simulation
+// neither counts against nor checks
+func traceVelocity(trace *models.RedirectTrace, entry *cache.RedirectEntry) {
+	if !entry.Has(cache.RedirectVelocity) {
+		addTraceStep(trace, models.TraceRuleVelocity, false, "No velocity limit")
+		return
+	}
+	limit := entry.Velocity
+	overflow := "a waiting page"
+	switch limit.Overflow {
+	case models.VelocityOverflowReject:
+		overflow = "429 Too Many Requests"
+	case models.VelocityOverflowRedirect:
+		overflow = "a redirect to " + limit.OverflowURL
+	}
+	addTraceStep(trace, models.TraceRuleVelocity, false,
+		fmt.Sprintf("Redirects up to %d visitors per %s, the others get %s; simulations are not counted", limit.Clicks, limit.Per, overflow))
+}
+
+// traceMaxClicks checks the clicks a link with max_clicks has left without
+// using one up, reporting false once the trace has ended
+func traceMaxClicks(trace *models.RedirectTrace, shortCode string, urlRecord *models.URL, entry *cache.RedirectEntry, crawler bool) bool {
+	if !entry.Has(cache.RedirectLimited) {
+		addTraceStep(trace, models.TraceRuleMaxClicks, false, "No max_clicks")
+		return true
+	}
+	if crawler {
+		endTrace(trace, models.TraceRuleMaxClicks, http.StatusNoContent, "User-Agent is a link preview crawler, answered 204 so it does not use up a click")
+		return false
+	}
+
+	remaining, err := cache.GetRemainingClicks(shortCode)
+	if err != nil && urlRecord.ClicksRemaining != nil {
+		remaining = int64(*urlRecord.ClicksRemaining)
+	}
+	if remaining <= 0 {
+		failTrace(trace, models.TraceRuleMaxClicks, models.ErrLinkExpired, "No clicks left")
+		return false
+	}
+	addTraceStep(trace, models.TraceRuleMaxClicks, false, fmt.Sprintf("%d clicks left, a visit would use one up", remaining))
+	return true
+}
+
+// traceRollout checks whether a soft launched link lets the visitor
+// through, reporting false once the trace has ended
+func traceRollout(c *gin.Context, trace *models.RedirectTrace, entry *cache.RedirectEntry) bool {
+	if !entry.Has(cache.RedirectRollout) {
+		addTraceStep(trace, models.TraceRuleRollout, false, "Fully launched")
+		return true
+	}
+	bucket := rolloutBucket(entry.URLID, c.ClientIP()+" "+c.GetHeader("User-Agent"))
+	if bucket >= entry.Rollout {
+		failTrace(trace, models.TraceRuleRollout, models.ErrLinkNotLaunched,
+			fmt.Sprintf("Soft launched to %d%% of visitors; this visitor is in bucket %d and sees the holding page", entry.Rollout, bucket))
+		return false
+	}
+	addTraceStep(trace, models.TraceRuleRollout, false,
+		fmt.Sprintf("Soft launched to %d%% of visitors; this visitor is in bucket %d and is let through", entry.Rollout, bucket))
+	return true
+}
+
+// traceRouting checks the link's routing rules in order, adding a step for
+// each rule checked, and returns the rule deciding the visit. It reports
+// false once the trace has ended.
+func traceRouting(c *gin.Context, trace *models.RedirectTrace, entry *cache.RedirectEntry) (*models.RoutingRule, bool) {
+	if len(entry.Rules) == 0 {
+		addTraceStep(trace, models.TraceRuleRouting, false, "No routing rules")
+		return nil, true
+	}
+
+	rule, steps := routing.Evaluate(entry.Rules, requestVisit(c))
+	for _, step := range steps {
+		name := fmt.Sprintf("Rule %d", step.Index+1)
+		if step.Rule.Name != "" {
+			name += fmt.Sprintf(" (%s)", step.Rule.Name)
+		}
+		if !step.Matched {
+			addTraceStep(trace, models.TraceRuleRouting, false, name+": "+step.Detail)
+			continue
+		}
+
+		detail := fmt.Sprintf("%s: %s, ", name, step.Detail)
+		switch step.Rule.Action.Type {
+		case models.RoutingActionDeny:
+			failTrace(trace, models.TraceRuleRouting, models.ErrLinkNotFound, detail+"answered as not found")
+			return nil, false
+		case models.RoutingActionRedirect:
+			addTraceStep(trace, models.TraceRuleRouting, true, detail+"redirected to "+step.Rule.Action.URL)
+		default:
+			addTraceStep(trace, models.TraceRuleRouting, true, detail+"the link is followed as usual")
+		}
+	}
+	if rule == nil {
+		addTraceStep(trace, models.TraceRuleRouting, false, "No routing rule matches, the link is followed as usual")
+	}
+	return rule, true
+}
+
+// traceVariants picks the variant of a split link a visit would be served
+// and reports every variant's share of traffic
+func traceVariants(c *gin.Context, trace *models.RedirectTrace, urlRecord *models.URL, entry *cache.RedirectEntry) string {
+	ctx := c.Request.Context()
+	trace.Variants = buildVariantStats(c, urlRecord, loadVariants(ctx, entry.URLID), nil)
+
+	variant := pickVariant(ctx, entry)
+	if variant == nil {
+		addTraceStep(trace, models.TraceRuleVariants, false, "Variants could not be loaded, the link's own destination is served")
+		return entry.Destination
+	}
+	trace.Variant = variant.Name
+
+	mode := "Weighted by variant weights"
+	if entry.Has(cache.RedirectBandit) {
+		mode = "Thompson sampling on conversions"
+	}
+	addTraceStep(trace, models.TraceRuleVariants, true, fmt.Sprintf("%s, picked %s", mode, variant.Name))
+	return variant.Destination
+}
+
+func addTraceStep(trace *models.RedirectTrace, rule string, fired bool, detail string) {
+	trace.Steps = append(trace.Steps, models.TraceStep{Rule: rule, Fired: fired, Detail: detail})
+}
+
+// endTrace records the rule deciding the response
+func endTrace(trace *models.RedirectTrace, rule string, status int, detail string) {
+	addTraceStep(trace, rule, true, detail)
+	trace.Rule = rule
+	trace.Status = status
+}
+
+// failTrace records the rule answering with an error
+func failTrace(trace *models.RedirectTrace, rule string, apiErr *models.APIError, detail string) {
+	endTrace(trace, rule, apiErr.Status, detail)
+	trace.ErrorCode = apiErr.Code
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Page size bounds of the duplicates report, in groups
+const (
+	defaultDuplicateGroups = 50
+	maxDuplicateGroups     = 200
+)
+
+// GetDuplicatesReport godoc
+// @Summary Links sharing a destination
+// @ID getDuplicatesReport
+// @Description List groups of the caller's links leading to the same destination once normalized the way deduplication compares them (lowercased scheme and host, no default port, tracking parameters or trailing slash, sorted query parameters), with each link's click count, so duplicates can be cleaned up before relying on deduplication. Groups with the most links come first, then those with the most clicks; links in a group are oldest first. Split link variants and routing rule destinations are not compared.
+// @Tags Links
+// @Produce json
+// @Param limit query int false "Groups to return, 1 to 200 (default 50)"
+// @Success 200 {object} models.DuplicatesReport
+// @Failure 400 {object} models.ErrorResponse "Invalid limit"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /reports/duplicates [get]
+func GetDuplicatesReport(c *gin.Context) {
+	limit, err := queryInt(c, "limit", defaultDuplicateGroups)
+	if err != nil || limit < 1 || limit > maxDuplicateGroups {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "limit must be between 1 and 200"))
+		return
+	}
+
+	// Destinations are compared once decrypted, so links are read in
+	// batches rather than grouped by the database
+	var links, batch []models.URL
+	err = database.DB.WithContext(c.Request.Context()).
+		Select("id", "short_code", "original_url", "click_count", "created_at").
+		Where("owner_id = ?", *middleware.CurrentOwnerID(c)).
+		FindInBatches(&batch, 1000, func(*gorm.DB, int) error {
+			links = append(links, batch...)
+			return nil
+		}).Error
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list links"))
+		return
+	}
+
+	report := duplicatesReport(links)
+	report.Groups = report.Groups[:min(limit, len(report.Groups))]
+	c.JSON(http.StatusOK, report)
+}
+
+// duplicatesReport groups links by the canonical form of their destination,
+// keeping the groups of more than one link
+func duplicatesReport(links []models.URL) models.DuplicatesReport {
+	byDestination := make(map[string]*models.DuplicateGroup)
+	for _, link := range links {
+		destination := utils.CanonicalURL(link.OriginalURL)
+		group, ok := byDestination[destination]
+		if !ok {
+			group = &models.DuplicateGroup{Destination: destination}
+			byDestination[destination] = group
+		}
+		host, shortCode := models.SplitLinkKey(link.ShortCode)
+		group.Links = append(group.Links, models.DuplicateLink{
+			ShortCode:   shortCode,
+			Domain:      host,
+			OriginalURL: link.OriginalURL,
+			ClickCount:  link.ClickCount,
+			CreatedAt:   link.CreatedAt,
+		})
+		group.TotalClicks += int64(link.ClickCount)
+	}
+
+	report := models.DuplicatesReport{LinksScanned: len(links), Groups: []models.DuplicateGroup{}}
+	for _, group := range byDestination {
+		if len(group.Links) < 2 {
+			continue
+		}
+		sort.SliceStable(group.Links, func(i, j int) bool { return group.Links[i].CreatedAt.Before(group.Links[j].CreatedAt) })
+		report.Groups = append(report.Groups, *group)
+		report.DuplicateLinks += len(group.Links) - 1
+	}
+	report.TotalGroups = len(report.Groups)
+
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if len(a.Links) != len(b.Links) {
+			return len(a.Links) > len(b.Links)
+		}
+		if a.TotalClicks != b.TotalClicks {
+			return a.TotalClicks > b.TotalClicks
+		}
+		return a.Destination < b.Destination
+	})
+	return report
+}
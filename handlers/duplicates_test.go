@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"url-shortener/models"
+)
+
+func TestDuplicatesReport(t *testing.T) {
+	now := time.Now()
+	links := []models.URL{
+		{ShortCode: "new", OriginalURL: "https://example.com/pricing?utm_source=x", ClickCount: 5, CreatedAt: now},
+		{ShortCode: "old", OriginalURL: "HTTPS://Example.com:443/pricing/", ClickCount: 300, CreatedAt: now.Add(-time.Hour)},
+		{ShortCode: "go.acme.com/p", OriginalURL: "https://example.com/pricing", ClickCount: 5, CreatedAt: now.Add(-time.Minute)},
+		{ShortCode: "a", OriginalURL: "https://example.com/docs", ClickCount: 1, CreatedAt: now},
+		{ShortCode: "b", OriginalURL: "https://example.com/docs#intro", ClickCount: 1, CreatedAt: now},
+		{ShortCode: "alone", OriginalURL: "https://example.org/", ClickCount: 9, CreatedAt: now},
+	}
+
+	report := duplicatesReport(links)
+	if report.LinksScanned != 6 || report.TotalGroups != 1 || report.DuplicateLinks != 2 {
+		t.Fatalf("report = %+v, want 6 links scanned and 1 group of 3 links", report)
+	}
+	group := report.Groups[0]
+	if group.Destination != "https://example.com/pricing" || group.TotalClicks != 310 {
+		t.Errorf("group = %s with %d clicks", group.Destination, group.TotalClicks)
+	}
+	var order []string
+	for _, link := range group.Links {
+		order = append(order, link.Domain+"/"+link.ShortCode)
+	}
+	if len(order) != 3 || order[0] != "/old" || order[1] != "go.acme.com/p" || order[2] != "/new" {
+		t.Errorf("links = %v, want oldest first", order)
+	}
+}
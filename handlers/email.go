@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"os"
+	"regexp"
+	"strings"
+
+	"url-shortener/background"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/notify"
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// First http(s) URL in a message
+var emailURLPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// InboundEmail godoc
+// @Summary Shorten a URL sent by email
+// @ID inboundEmail
+// @Description Inbound parse webhook for email providers (SendGrid Inbound Parse, Mailgun routes). The first URL in the subject or body is shortened and the short link is sent back to the sender by email. Senders must match INBOUND_EMAIL_ALLOWED_SENDERS.
+// @Tags URL Shortener
+// @Accept x-www-form-urlencoded
+// @Accept mpfd
+// @Produce json
+// @Param token query string true "INBOUND_EMAIL_TOKEN"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} models.ErrorResponse "Gateway disabled, invalid token or sender not allowed"
+// @Router /inbound/email [post]
+func InboundEmail(c *gin.Context) {
+	token := os.Getenv("INBOUND_EMAIL_TOKEN")
+	if token == "" {
+		c.Error(models.NewAPIError(http.StatusForbidden, models.ErrCodeForbidden, "Email gateway is disabled"))
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(token)) != 1 {
+		c.Error(models.NewAPIError(http.StatusForbidden, models.ErrCodeForbidden, "Invalid token"))
+		return
+	}
+
+	// SendGrid posts "from"; Mailgun posts "sender" and "from"
+	from := c.PostForm("sender")
+	if from == "" {
+		from = c.PostForm("from")
+	}
+	address, err := mail.ParseAddress(from)
+	if err != nil || !emailSenderAllowed(address.Address) {
+		// Never reply to unknown senders, to avoid backscatter
+		c.Error(models.NewAPIError(http.StatusForbidden, models.ErrCodeForbidden, "Sender not allowed"))
+		return
+	}
+
+	// Reject mail the provider could not authenticate, when it tells us
+	if spf := c.PostForm("SPF"); spf != "" && !strings.EqualFold(spf, "pass") {
+		c.Error(models.NewAPIError(http.StatusForbidden, models.ErrCodeForbidden, "Sender not allowed"))
+		return
+	}
+
+	subject := c.PostForm("subject")
+	body := c.PostForm("stripped-text")
+	if body == "" {
+		body = c.PostForm("body-plain")
+	}
+	if body == "" {
+		body = c.PostForm("text")
+	}
+
+	rawURL := emailURLPattern.FindString(subject + "\n" + body)
+	if rawURL == "" || !service.IsValidURL(rawURL) {
+		replyToEmail(c.Request.Context(), address.Address, subject, "No URL was found in your message. Send a message containing the link to shorten.")
+		c.JSON(http.StatusOK, gin.H{"status": "no_url"})
+		return
+	}
+
+	safetyAction, _ := middleware.CurrentPolicy(c).EvaluateSafety(rawURL)
+	if safetyAction == models.SafetyActionDeny {
+		replyToEmail(c.Request.Context(), address.Address, subject, "This URL is blocked by the safety policy and was not shortened:\n\n"+rawURL)
+		c.JSON(http.StatusOK, gin.H{"status": "blocked"})
+		return
+	}
+
+	request := models.ShortenRequest{URL: rawURL}
+	urlRecord := service.Default().FindExistingURL(c.Request.Context(), requestCaller(c).OwnerID(), rawURL)
+	if urlRecord == nil {
+		if urlRecord, err = createURLRecord(c, request, safetyAction, false); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to shorten URL from email", "from", address.Address, "error", err)
+			c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create short URL"))
+			return
+		}
+	}
+
+	shortURL := buildShortURL(c, urlRecord.ShortCode)
+	reply := "Your short link:\n\n" + shortURL + "\n\nDestination: " + rawURL
+	if urlRecord.Status == models.StatusPending {
+		reply += "\n\nThe link is awaiting approval and will redirect once approved."
+	}
+	replyToEmail(c.Request.Context(), address.Address, subject, reply)
+
+	c.JSON(http.StatusOK, gin.H{"short_url": shortURL, "status": urlRecord.Status})
+}
+
+// emailSenderAllowed checks INBOUND_EMAIL_ALLOWED_SENDERS, a comma separated
+// list of addresses and @domain entries
+func emailSenderAllowed(address string) bool {
+	address = strings.ToLower(address)
+	for _, entry := range strings.Split(os.Getenv("INBOUND_EMAIL_ALLOWED_SENDERS"), ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == address || (strings.HasPrefix(entry, "@") && strings.HasSuffix(address, entry)) {
+			return true
+		}
+	}
+	return false
+}
+
+func replyToEmail(ctx context.Context, to, subject, body string) {
+	if !notify.EmailEnabled() {
+		slog.WarnContext(ctx, "SMTP is not configured, not replying", "to", to)
+		return
+	}
+	if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = "Re: " + subject
+	}
+	ctx = context.WithoutCancel(ctx)
+	background.Go(func() {
+		if err := notify.SendEmail(to, subject, body); err != nil {
+			slog.ErrorContext(ctx, "Failed to reply to email", "to", to, "error", err)
+		}
+	})
+}
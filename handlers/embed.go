@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Days the stats widget covers by default and at most
+const (
+	defaultWidgetDays = 30
+	maxWidgetDays     = 90
+)
+
+// How long browsers and shared caches may keep a stats widget
+const widgetMaxAge = 5 * time.Minute
+
+// Size of the widget's sparkline, in pixels
+const (
+	sparklineWidth  = 160
+	sparklineHeight = 40
+)
+
+// widgetTemplate is self-contained, with no scripts and no resources to
+// fetch, so it can be framed anywhere
+var widgetTemplate = template.Must(template.New("stats-widget").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>{{.ShortURL}} clicks</title>
+<style>
+html, body { margin: 0; background: transparent; }
+.widget { display: inline-flex; align-items: center; gap: 12px; box-sizing: border-box; padding: 8px 12px; border-radius: 8px; font-family: system-ui, sans-serif; }
+.total { font-size: 1.5rem; font-weight: 600; line-height: 1.2; }
+.label { font-size: 0.75rem; }
+{{if .Dark}}.widget { background: #1e1e1e; color: #eee; border: 1px solid #333; }
+.label { color: #aaa; }
+polyline { stroke: #8ab4f8; }
+{{else}}.widget { background: #fff; color: #222; border: 1px solid #ddd; }
+.label { color: #666; }
+polyline { stroke: #1a73e8; }
+{{end}}</style>
+</head>
+<body>
+<div class="widget">
+<div><div class="total">{{.Total}}</div><div class="label">clicks on {{.ShortURL}}</div></div>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" role="img" aria-label="{{.Recent}} clicks in the last {{.Days}} days">
+<polyline fill="none" stroke-width="2" stroke-linejoin="round" stroke-linecap="round" points="{{.Points}}"/>
+</svg>
+</div>
+</body>
+</html>
+`))
+
+// GetStatsWidget godoc
+// @Summary Embeddable stats widget
+// @ID getStatsWidget
+// @Description A small self-contained HTML page showing a link's total clicks and a sparkline of its clicks per UTC day, to embed in blogs and wikis with <iframe src="https://sho.rt/embed/abc123" width="320" height="64">. Drawn from the hourly click rollups behind the JSON stats, so the latest clicks show up within minutes; links whose analytics are not full have a flat sparkline. The page has no scripts, may be framed by any site and is cached for 5 minutes. Days before the click history kept on the plan of the link's owner, as set by STATS_RETENTION_DAYS, are left out.
+// @Tags URL Shortener
+// @Produce html
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param days query int false "Days covered by the sparkline, 1 to 90 (default 30)"
+// @Param theme query string false "Colors of the widget" Enums(light, dark)
+// @Success 200 {string} string "The widget"
+// @Failure 400 {object} models.ErrorResponse "Invalid days or theme"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Router /embed/{shortCode} [get]
+func GetStatsWidget(c *gin.Context) {
+	days, err := queryInt(c, "days", defaultWidgetDays)
+	if err != nil || days < 1 || days > maxWidgetDays {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "days must be between 1 and 90"))
+		return
+	}
+	theme := c.DefaultQuery("theme", "light")
+	if theme != "light" && theme != "dark" {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "theme must be light or dark"))
+		return
+	}
+
+	urlRecord, err := findStatsLink(c.Request.Context(), pathLinkKey(c))
+	if err != nil {
+		respondLinkError(c, models.ErrLinkNotFound)
+		return
+	}
+
+	// The last bucket is today, so far
+	to := time.Now().UTC()
+	from := truncateToInterval(to, models.IntervalDay, time.UTC).AddDate(0, 0, 1-days)
+	from, _, ok := clampStatsRange(c, urlRecord.OwnerID, from, to)
+	if !ok {
+		return
+	}
+	from = truncateToInterval(from, models.IntervalDay, time.UTC)
+
+	// Links opting out of analytics have no click events to roll up
+	var counts map[time.Time]int64
+	if models.CapturesEvents(urlRecord.AnalyticsMode()) {
+		if counts, err = database.LinkClickCounts(c.Request.Context(), urlRecord.ID, models.IntervalDay, time.UTC, from, to); err != nil {
+			c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to count clicks"))
+			return
+		}
+	}
+	points := timeseriesPoints(counts, from, to, models.IntervalDay)
+	clicks := make([]int64, len(points))
+	var recent int64
+	for i, point := range points {
+		clicks[i] = point.Clicks
+		recent += point.Clicks
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(widgetMaxAge/time.Second)))
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors *")
+	c.Status(http.StatusOK)
+	widgetTemplate.Execute(c.Writer, gin.H{
+		"ShortURL": smsShortURL(c, urlRecord.ShortCode),
+		"Total":    groupThousands(int64(urlRecord.ClickCount)),
+		"Recent":   recent,
+		"Days":     len(points),
+		"Dark":     theme == "dark",
+		"Width":    sparklineWidth,
+		"Height":   sparklineHeight,
+		"Points":   sparklinePoints(clicks, sparklineWidth, sparklineHeight),
+	})
+}
+
+// sparklinePoints scales clicks to the points of a polyline filling width
+// by height, oldest first, with the busiest bucket at the top. A stroke's
+// width is kept free at the top and bottom.
+func sparklinePoints(clicks []int64, width, height int) string {
+	if len(clicks) == 0 {
+		clicks = []int64{0}
+	}
+	// A single bucket is drawn as a flat line
+	if len(clicks) == 1 {
+		clicks = []int64{clicks[0], clicks[0]}
+	}
+	var peak int64
+	for _, count := range clicks {
+		peak = max(peak, count)
+	}
+
+	const margin = 2.0
+	span := float64(height) - 2*margin
+	points := make([]string, len(clicks))
+	for i, count := range clicks {
+		x := float64(width) * float64(i) / float64(len(clicks)-1)
+		y := float64(height) - margin
+		if peak > 0 {
+			y -= span * float64(count) / float64(peak)
+		}
+		points[i] = strconv.FormatFloat(x, 'f', 1, 64) + "," + strconv.FormatFloat(y, 'f', 1, 64)
+	}
+	return strings.Join(points, " ")
+}
+
+// groupThousands formats n with commas between groups of three digits
+func groupThousands(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	var grouped strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+	return sign + grouped.String()
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import "testing"
+
+func TestSparklinePoints(t *testing.T) {
+	cases := []struct {
+		clicks []int64
+		want   string
+	}{
+		{clicks: []int64{0, 5, 10}, want: "0.0,38.0 80.0,20.0 160.0,2.0"},
+		{clicks: []int64{0, 0}, want: "0.0,38.0 160.0,38.0"},
+		{clicks: []int64{3}, want: "0.0,2.0 160.0,2.0"},
+		{clicks: nil, want: "0.0,38.0 160.0,38.0"},
+	}
+	for _, tc := range cases {
+		if got := sparklinePoints(tc.clicks, 160, 40); got != tc.want {
+			t.Errorf("sparklinePoints(%v) = %q, want %q", tc.clicks, got, tc.want)
+		}
+	}
+}
+
+func TestGroupThousands(t *testing.T) {
+	cases := map[int64]string{0: "0", 999: "999", 1000: "1,000", 1234567: "1,234,567", -45000: "-45,000"}
+	for n, want := range cases {
+		if got := groupThousands(n); got != want {
+			t.Errorf("groupThousands(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListErrorCodes godoc
+// @Summary List error codes
+// @ID listErrorCodes
+// @Description List the stable error codes returned in the `code` field of error responses, with the status each is usually returned with
+// @Tags System
+// @Produce json
+// @Success 200 {array} models.ErrorCodeInfo
+// @Router /errors [get]
+func ListErrorCodes(c *gin.Context) {
+	c.JSON(http.StatusOK, models.ErrorCatalog)
+}
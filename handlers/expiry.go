@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/jobs"
+	"url-shortener/models"
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkExpiryAllowed rejects lifetimes longer than the maximum, writing the
+// error response and returning false
+func checkExpiryAllowed(c *gin.Context, expiresIn int) bool {
+	if apiErr := service.CheckExpiry(requestCaller(c), expiresIn); apiErr != nil {
+		c.Error(apiErr)
+		return false
+	}
+	return true
+}
+
+// ExemptURLExpiry godoc
+// @Summary Exempt a short URL from the maximum lifetime
+// @ID exemptURLExpiry
+// @Description Exempt a short URL from LINK_MAX_EXPIRY_DAYS so it never expires, clearing its current expiry. The action is audit-logged.
+// @Tags Admin
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Security AdminAuth
+// @Router /admin/urls/{shortCode}/expiry-exemption [post]
+func ExemptURLExpiry(c *gin.Context) {
+	setURLExpiryExempt(c, true)
+}
+
+// RemoveURLExpiryExemption godoc
+// @Summary Remove a short URL's lifetime exemption
+// @ID removeURLExpiryExemption
+// @Description Hold a short URL to LINK_MAX_EXPIRY_DAYS again. Links already older than the maximum expire after a grace period of 7 days. The action is audit-logged.
+// @Tags Admin
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Security AdminAuth
+// @Router /admin/urls/{shortCode}/expiry-exemption [delete]
+func RemoveURLExpiryExemption(c *gin.Context) {
+	setURLExpiryExempt(c, false)
+}
+
+func setURLExpiryExempt(c *gin.Context, exempt bool) {
+	shortCode := pathLinkKey(c)
+
+	var urlRecord models.URL
+	if err := database.DB.Where("short_code = ?", shortCode).First(&urlRecord).Error; err != nil {
+		c.Error(models.ErrLinkNotFound)
+		return
+	}
+
+	expiresAt := service.LinkExpiry.Enforce(urlRecord.CreatedAt, urlRecord.ExpiresAt, time.Now())
+	if exempt {
+		expiresAt = nil
+	}
+	updates := map[string]interface{}{"expiry_exempt": exempt, "expires_at": expiresAt}
+	if err := database.DB.Model(&urlRecord).Updates(updates).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update expiry exemption"))
+		return
+	}
+
+	action := models.AuditActionEnforce
+	if exempt {
+		action = models.AuditActionExempt
+	}
+	recordAudit(c, action, shortCode, "")
+
+	// Cached redirects still carry the previous expiry
+	cache.InvalidateCache(shortCode)
+
+	c.JSON(http.StatusOK, gin.H{"short_code": shortCode, "expiry_exempt": exempt, "expires_at": expiresAt})
+}
+
+// CleanUpExpiredLinks godoc
+// @Summary Clean up expired links now
+// @ID cleanUpExpiredLinks
+// @Description Soft-delete or purge the links expired for longer than EXPIRED_LINK_RETENTION, as set by EXPIRED_LINK_CLEANUP, without waiting for the next scheduled cleanup. Purging frees their short codes for reuse. A failure partway is reported in error, with the links cleaned up until then counted.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} models.ExpiredLinkCleanupReport
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/expired-links/cleanup [post]
+func CleanUpExpiredLinks(c *gin.Context) {
+	c.JSON(http.StatusOK, jobs.CleanUpExpiredLinks(c.Request.Context()))
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"unicode"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/service"
+	"url-shortener/storage"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Longest CMS identifier accepted by PUT /external/{external_id}
+const maxExternalIDLength = 128
+
+// UpsertExternalLink godoc
+// @Summary Create or refresh the short link of a CMS item
+// @ID upsertExternalLink
+// @Description Create a short link for a CMS item identified by external_id, or return the one already created for it, so CMS publish hooks can call the same endpoint every time. When the url differs from the link's destination in canonical form, the destination is updated as with PUT /links/{shortCode}. Other fields of the request only apply when the link is created, and a fresh link is always created rather than deduplicated, so if_exists and no_dedup are ignored. External IDs are unique among the links of the caller's user; deleted links free theirs and archived links are rehydrated. Requires both the create and update scopes.
+// @Tags Links
+// @Accept json
+// @Produce json
+// @Param external_id path string true "Identifier of the item in the CMS, up to 128 printable characters"
+// @Param request body models.ShortenRequest true "Destination and options of a new link"
+// @Success 201 {object} models.ShortenResponse "Link created"
+// @Success 200 {object} models.ShortenResponse "Link already existed, its destination updated if it changed"
+// @Failure 400 {object} models.ErrorResponse "Invalid request or external ID"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user, lacks the create or update scope, or the link is locked"
+// @Failure 409 {object} models.ErrorResponse "The custom alias is taken"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /external/{external_id} [put]
+func UpsertExternalLink(c *gin.Context) {
+	externalID := c.Param("external_id")
+	if err := validateExternalID(externalID); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	var request models.ShortenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	ownerID := *middleware.CurrentOwnerID(c)
+	urlRecord, err := database.FindExternalLink(ctx, ownerID, externalID)
+	if errors.Is(err, storage.ErrNotFound) {
+		request.ExternalID = externalID
+		request.NoDedup = true
+		request.IfExists = ""
+		created, _, createErr := service.Default().Shorten(ctx, requestCaller(c), request)
+		if createErr == nil {
+			c.JSON(http.StatusCreated, buildShortenResponse(c, created))
+			return
+		}
+
+		// A concurrent request for the same item may have created it first
+		if urlRecord, err = database.FindExternalLink(ctx, ownerID, externalID); errors.Is(err, storage.ErrNotFound) {
+			c.Error(createErr)
+			return
+		}
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if utils.CanonicalURL(request.URL) != utils.CanonicalURL(urlRecord.OriginalURL) {
+		if urlRecord.Locked {
+			c.Error(models.NewAPIError(http.StatusForbidden, models.ErrCodeForbidden, "Short URL is locked"))
+			return
+		}
+		if !updateLink(c, urlRecord, models.UpdateLinkRequest{URL: &request.URL}) {
+			return
+		}
+	}
+	c.JSON(http.StatusOK, buildShortenResponse(c, urlRecord))
+}
+
+// validateExternalID checks a CMS identifier is 1 to maxExternalIDLength
+// printable characters without spaces
+func validateExternalID(externalID string) error {
+	if externalID == "" || len(externalID) > maxExternalIDLength {
+		return fmt.Errorf("external_id must be 1 to %d characters", maxExternalIDLength)
+	}
+	for _, r := range externalID {
+		if !unicode.IsPrint(r) || unicode.IsSpace(r) {
+			return errors.New("external_id must not contain spaces or control characters")
+		}
+	}
+	return nil
+}
+
+// externalID returns the CMS identifier of urlRecord, if any
+func externalID(urlRecord *models.URL) string {
+	if urlRecord.ExternalID == nil {
+		return ""
+	}
+	return *urlRecord.ExternalID
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateExternalID(t *testing.T) {
+	for externalID, valid := range map[string]bool{
+		"post-42":                true,
+		"wp:post/42":             true,
+		"článek-7":               true,
+		strings.Repeat("a", 128): true,
+		"":                       false,
+		strings.Repeat("a", 129): false,
+		"two words":              false,
+		"tab\tseparated":         false,
+		"line\nbreak":            false,
+	} {
+		if err := validateExternalID(externalID); (err == nil) != valid {
+			t.Errorf("validateExternalID(%q) = %v, want valid %v", externalID, err, valid)
+		}
+	}
+}
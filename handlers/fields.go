@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseFields reads the optional `fields` query parameter (comma separated)
+// used for sparse fieldsets. An empty result means all fields are returned.
+func parseFields(c *gin.Context) []string {
+	raw := c.Query("fields")
+	if raw == "" {
+		return nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// selectFields converts a response value into a map containing only the
+// requested JSON fields. Unknown field names are reported as an error.
+func selectFields(value interface{}, fields []string) (map[string]interface{}, error) {
+	if err := checkFields(reflect.TypeOf(value), fields); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var all map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		// Fields dropped by omitempty are returned explicitly as null
+		selected[field] = all[field]
+	}
+
+	return selected, nil
+}
+
+// checkFields reports the first of fields that the struct type t does not
+// declare as a JSON field
+func checkFields(t reflect.Type, fields []string) error {
+	known := jsonFieldNames(t)
+	for _, field := range fields {
+		if !known[field] {
+			return fmt.Errorf("unknown field: %s", field)
+		}
+	}
+	return nil
+}
+
+// jsonFieldNames returns the JSON names declared by a struct type
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	names := make(map[string]bool)
+	if t.Kind() != reflect.Struct {
+		return names
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// respondWithFields writes value, a struct or a slice of them, as JSON,
+// honoring the `fields` query parameter for the struct or each element
+func respondWithFields(c *gin.Context, status int, value interface{}) {
+	fields := parseFields(c)
+	if len(fields) == 0 {
+		c.JSON(status, value)
+		return
+	}
+
+	list := reflect.ValueOf(value)
+	if list.Kind() != reflect.Slice {
+		selected, err := selectFields(value, fields)
+		if err != nil {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+			return
+		}
+		c.JSON(status, selected)
+		return
+	}
+
+	// Unknown fields are reported for empty lists too
+	if err := checkFields(list.Type().Elem(), fields); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	selected := make([]map[string]interface{}, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		item, err := selectFields(list.Index(i).Interface(), fields)
+		if err != nil {
+			c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to select fields"))
+			return
+		}
+		selected = append(selected, item)
+	}
+	c.JSON(status, selected)
+}
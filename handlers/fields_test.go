@@ -0,0 +1,64 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"url-shortener/database"
+	"url-shortener/database/databasetest"
+	"url-shortener/handlers"
+	"url-shortener/handlers/handlertest"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListLinksFields(t *testing.T) {
+	databasetest.UseSQLite(t)
+	gin.SetMode(gin.TestMode)
+
+	ownerID := uint(7)
+	for _, link := range []models.URL{
+		{ShortCode: "mine", OriginalURL: "https://example.org/mine", OwnerID: &ownerID},
+		{ShortCode: "theirs", OriginalURL: "https://example.org/theirs"},
+	} {
+		if err := database.DB.Create(&link).Error; err != nil {
+			t.Fatalf("creating link: %v", err)
+		}
+	}
+	router := gin.New()
+	router.Use(middleware.Errors(), handlertest.AsUser())
+	router.GET("/links", handlers.ListLinks)
+	router.GET("/admin/urls", handlers.ListURLs)
+
+	for path, want := range map[string]int{"/links": 1, "/admin/urls": 2} {
+		recorder := handlertest.Serve(router, ownerID, http.MethodGet, path+"?fields=short_code,original_url", "")
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("%s = %d: %s", path, recorder.Code, recorder.Body)
+		}
+		var links []map[string]interface{}
+		json.Unmarshal(recorder.Body.Bytes(), &links)
+		if len(links) != want {
+			t.Fatalf("%s listed %d links, want %d: %s", path, len(links), want, recorder.Body)
+		}
+		for _, link := range links {
+			if len(link) != 2 || link["short_code"] == nil || link["original_url"] == nil {
+				t.Errorf("%s link = %v, want only short_code and original_url", path, link)
+			}
+		}
+
+		recorder = handlertest.Serve(router, ownerID, http.MethodGet, path+"?fields=short_code,nonsense", "")
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("%s with an unknown field = %d, want 400", path, recorder.Code)
+		}
+	}
+
+	recorder := handlertest.Serve(router, ownerID, http.MethodGet, "/links", "")
+	var links []map[string]interface{}
+	json.Unmarshal(recorder.Body.Bytes(), &links)
+	if len(links) != 1 || links[0]["id"] == nil || links[0]["created_at"] == nil {
+		t.Errorf("/links without fields = %s, want whole links", recorder.Body)
+	}
+}
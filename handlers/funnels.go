@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Funnels a user may declare
+const maxFunnelsPerUser = 20
+
+// ListFunnels godoc
+// @Summary List your funnels
+// @ID listFunnels
+// @Tags Funnels
+// @Produce json
+// @Success 200 {array} models.Funnel
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /funnels [get]
+func ListFunnels(c *gin.Context) {
+	funnels := []models.Funnel{}
+	err := database.DB.WithContext(c.Request.Context()).
+		Where("owner_id = ?", *middleware.CurrentOwnerID(c)).Order("id").Find(&funnels).Error
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list funnels"))
+		return
+	}
+	c.JSON(http.StatusOK, funnels)
+}
+
+// CreateFunnel godoc
+// @Summary Declare a funnel
+// @ID createFunnel
+// @Description Declare an ordered list of 2 to 10 different links owned by the caller, such as a teaser, a landing page and a signup link, whose report counts the unique visitors clicking each of them in order. Links on a branded domain are given as host/code.
+// @Tags Funnels
+// @Accept json
+// @Produce json
+// @Param request body models.FunnelRequest true "Funnel"
+// @Success 201 {object} models.Funnel
+// @Failure 400 {object} models.ErrorResponse "Invalid request, or a step is not one of your links"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope"
+// @Failure 409 {object} models.ErrorResponse "Too many funnels"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /funnels [post]
+func CreateFunnel(c *gin.Context) {
+	var request models.FunnelRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	steps := request.Steps
+	seen := make(map[string]bool, len(steps))
+	for _, step := range steps {
+		if seen[step] {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "steps must be different links"))
+			return
+		}
+		seen[step] = true
+	}
+
+	ctx := c.Request.Context()
+	ownerID := *middleware.CurrentOwnerID(c)
+	links, err := funnelLinks(c, ownerID, steps)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create funnel"))
+		return
+	}
+	for _, step := range steps {
+		if _, ok := links[step]; !ok {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Step "+step+" is not one of your links"))
+			return
+		}
+	}
+
+	var count int64
+	if err := database.DB.WithContext(ctx).Model(&models.Funnel{}).Where("owner_id = ?", ownerID).Count(&count).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create funnel"))
+		return
+	}
+	if count >= maxFunnelsPerUser {
+		c.Error(models.NewAPIError(http.StatusConflict, models.ErrCodeConflict, "You already have the maximum number of funnels"))
+		return
+	}
+
+	funnel := models.Funnel{OwnerID: ownerID, Name: request.Name, Steps: steps}
+	if err := database.DB.WithContext(ctx).Create(&funnel).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create funnel"))
+		return
+	}
+	c.JSON(http.StatusCreated, funnel)
+}
+
+// DeleteFunnel godoc
+// @Summary Delete one of your funnels
+// @ID deleteFunnel
+// @Tags Funnels
+// @Param id path int true "Funnel ID"
+// @Success 204 "Funnel deleted"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope"
+// @Failure 404 {object} models.ErrorResponse "Funnel not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /funnels/{id} [delete]
+func DeleteFunnel(c *gin.Context) {
+	result := database.DB.WithContext(c.Request.Context()).
+		Where("id = ? AND owner_id = ?", c.Param("id"), *middleware.CurrentOwnerID(c)).Delete(&models.Funnel{})
+	if result.Error != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete funnel"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Funnel not found"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetFunnelReport godoc
+// @Summary Report on one of your funnels
+// @ID getFunnelReport
+// @Description Count the unique visitors who clicked the link of each step of a funnel owned by the caller between from and to, at or after their first click of the previous step, with the share of the previous step and of the first step that went on. Visitors are told apart by the same salted hash of IP address and user agent as unique visitors, which is only recorded with Redis and for links with full analytics. Covers the last 30 days by default, and at most 366 days. Steps whose link has since been deleted or renamed count no visitors.
+// @Tags Funnels
+// @Produce json
+// @Param id path int true "Funnel ID"
+// @Param from query string false "Start, RFC 3339"
+// @Param to query string false "End, RFC 3339 (default now)"
+// @Success 200 {object} models.FunnelReport
+// @Failure 400 {object} models.ErrorResponse "Invalid range"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope"
+// @Failure 404 {object} models.ErrorResponse "Funnel not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /funnels/{id}/report [get]
+func GetFunnelReport(c *gin.Context) {
+	from, to, ok := parseAnalyticsRange(c, defaultAnalyticsWindow[models.IntervalDay], time.Now())
+	if !ok {
+		return
+	}
+	if to.Sub(from) > maxHeatmapWindow {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Range covers more than 366 days"))
+		return
+	}
+
+	var funnel models.Funnel
+	ownerID := *middleware.CurrentOwnerID(c)
+	err := database.DB.WithContext(c.Request.Context()).
+		Where("id = ? AND owner_id = ?", c.Param("id"), ownerID).First(&funnel).Error
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Funnel not found"))
+		return
+	}
+	links, err := funnelLinks(c, ownerID, funnel.Steps)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to report on funnel"))
+		return
+	}
+
+	// Visitors cannot go past a step whose link is gone
+	urlIDs := make([]uint, 0, len(funnel.Steps))
+	for _, step := range funnel.Steps {
+		urlID, ok := links[step]
+		if !ok {
+			break
+		}
+		urlIDs = append(urlIDs, urlID)
+	}
+	visitors, err := database.FunnelVisitors(c.Request.Context(), urlIDs, from, to)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to report on funnel"))
+		return
+	}
+
+	report := models.FunnelReport{ID: funnel.ID, Name: funnel.Name, From: from, To: to, Steps: make([]models.FunnelStep, len(funnel.Steps))}
+	for i, step := range funnel.Steps {
+		report.Steps[i].ShortCode = step
+		if i < len(visitors) {
+			report.Steps[i].Visitors = visitors[i]
+		}
+		previous := report.Steps[max(i-1, 0)].Visitors
+		report.Steps[i].StepRate = funnelRate(report.Steps[i].Visitors, previous)
+		report.Steps[i].Conversion = funnelRate(report.Steps[i].Visitors, report.Steps[0].Visitors)
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// funnelLinks returns the IDs of the owner's live links among short codes
+func funnelLinks(c *gin.Context, ownerID uint, shortCodes []string) (map[string]uint, error) {
+	var urls []models.URL
+	err := database.DB.WithContext(c.Request.Context()).Select("id", "short_code").
+		Where("short_code IN ? AND owner_id = ?", shortCodes, ownerID).Find(&urls).Error
+	if err != nil {
+		return nil, err
+	}
+	links := make(map[string]uint, len(urls))
+	for _, urlRecord := range urls {
+		links[urlRecord.ShortCode] = urlRecord.ID
+	}
+	return links, nil
+}
+
+// funnelRate is visitors as a percentage of base, rounded to a tenth, or
+// nil when base is 0
+func funnelRate(visitors, base int64) *float64 {
+	if base == 0 {
+		return nil
+	}
+	rate := math.Round(float64(visitors)/float64(base)*1000) / 10
+	return &rate
+}
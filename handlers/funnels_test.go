@@ -0,0 +1,102 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/database/databasetest"
+	"url-shortener/handlers"
+	"url-shortener/handlers/handlertest"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFunnelReport(t *testing.T) {
+	databasetest.UseSQLite(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Errors(), handlertest.AsUser())
+	router.POST("/funnels", handlers.CreateFunnel)
+	router.GET("/funnels/:id/report", handlers.GetFunnelReport)
+
+	owner := uint(1)
+	links := []models.URL{
+		{OriginalURL: "https://example.com/teaser", ShortCode: "teaser", OwnerID: &owner},
+		{OriginalURL: "https://example.com/landing", ShortCode: "landing", OwnerID: &owner},
+		{OriginalURL: "https://example.com/signup", ShortCode: "signup", OwnerID: &owner},
+	}
+	if err := database.DB.Create(&links).Error; err != nil {
+		t.Fatalf("creating links: %v", err)
+	}
+	recorder := handlertest.Serve(router, owner, http.MethodPost, "/funnels", `{"name":"Launch","steps":["teaser","landing","signup"]}`)
+	var funnel models.Funnel
+	if recorder.Code != http.StatusCreated || json.Unmarshal(recorder.Body.Bytes(), &funnel) != nil {
+		t.Fatalf("POST /funnels = %d: %s", recorder.Code, recorder.Body)
+	}
+
+	from := time.Now().UTC().Truncate(time.Second).Add(-2 * time.Hour)
+	to := from.Add(time.Hour)
+	at := func(minutes int) time.Time { return from.Add(time.Duration(minutes) * time.Minute) }
+	teaser, landing, signup := links[0], links[1], links[2]
+	var clicks []models.ClickEvent
+	click := func(visitor string, link models.URL, clickedAt time.Time) {
+		clicks = append(clicks, models.ClickEvent{URLID: link.ID, ShortCode: link.ShortCode, ClickedAt: clickedAt, VisitorHash: visitor})
+	}
+	// Goes through every step in order
+	click("complete", teaser, at(1))
+	click("complete", landing, at(2))
+	click("complete", signup, at(3))
+	// Clicked the landing page before the teaser, so stops at the teaser
+	click("backwards", landing, at(1))
+	click("backwards", teaser, at(2))
+	// Clicks the teaser twice, counting once
+	click("twice", teaser, at(1))
+	click("twice", teaser, at(5))
+	click("twice", landing, at(6))
+	// Clicked the teaser before the window, then the landing page in it
+	click("early", teaser, from.Add(-time.Minute))
+	click("early", landing, at(1))
+	// Clicks the landing page after the window
+	click("late", teaser, at(59))
+	click("late", landing, to.Add(time.Minute))
+	// Clicks without a visitor hash cannot be followed
+	click("", teaser, at(1))
+	if err := database.DB.Create(&clicks).Error; err != nil {
+		t.Fatalf("creating clicks: %v", err)
+	}
+
+	query := url.Values{"from": {from.Format(time.RFC3339)}, "to": {to.Format(time.RFC3339)}}
+	recorder = handlertest.Serve(router, owner, http.MethodGet, "/funnels/"+strconv.FormatUint(uint64(funnel.ID), 10)+"/report?"+query.Encode(), "")
+	var report models.FunnelReport
+	if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &report) != nil {
+		t.Fatalf("GET report = %d: %s", recorder.Code, recorder.Body)
+	}
+	want := []int64{4, 2, 1}
+	if len(report.Steps) != len(want) {
+		t.Fatalf("report steps = %+v, want %d", report.Steps, len(want))
+	}
+	for i, step := range report.Steps {
+		if step.Visitors != want[i] {
+			t.Errorf("step %d (%s): %d visitors, want %d", i, step.ShortCode, step.Visitors, want[i])
+		}
+	}
+	if rate := report.Steps[1].StepRate; rate == nil || *rate != 50 {
+		t.Errorf("landing step rate = %v, want 50", rate)
+	}
+	if conversion := report.Steps[2].Conversion; conversion == nil || *conversion != 25 {
+		t.Errorf("signup conversion = %v, want 25", conversion)
+	}
+
+	// Another user's funnel is not found
+	recorder = handlertest.Serve(router, 2, http.MethodGet, "/funnels/"+strconv.FormatUint(uint64(funnel.ID), 10)+"/report", "")
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("GET report by another user = %d, want 404", recorder.Code)
+	}
+}
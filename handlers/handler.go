@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/service"
+)
+
+// Handler serves the hot paths of links, shortening, redirecting and
+// reading stats, over the stores it is given, so they can be exercised
+// against in-memory stores (see the handlertest package). The package
+// functions of the same names serve them over the server's connections.
+// Other routes, and the rarer features of these ones such as split links'
+// variants and page previews, still use the database and Redis directly.
+type Handler struct {
+	stores service.Stores
+}
+
+// New returns a Handler over links and cache
+func New(links database.Store, cache cache.Store) *Handler {
+	return &Handler{stores: service.Stores{Links: links, Cache: cache}}
+}
+
+// defaultHandler is the Handler over the server's connections, looked up
+// on each request as they are made after the routes are registered
+func defaultHandler() *Handler {
+	return &Handler{stores: service.Default()}
+}
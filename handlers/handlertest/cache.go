@@ -0,0 +1,171 @@
+package handlertest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/models"
+	"url-shortener/storage"
+)
+
+// Cache is an in-memory cache.Store whose values never expire. Values are
+// copied in and out, as they would be encoded for Redis.
+type Cache struct {
+	mu        sync.Mutex
+	entries   map[string]cache.RedirectEntry
+	urls      map[string]models.URL
+	originals map[string]string
+	stats     map[string]models.StatsResponse
+	clicks    map[string]int64
+	remaining map[string]int64
+	velocity  map[string]int64
+}
+
+var _ cache.Store = (*Cache)(nil)
+
+// NewCache returns an empty Cache
+func NewCache() *Cache {
+	return &Cache{
+		entries:   make(map[string]cache.RedirectEntry),
+		urls:      make(map[string]models.URL),
+		originals: make(map[string]string),
+		stats:     make(map[string]models.StatsResponse),
+		clicks:    make(map[string]int64),
+		remaining: make(map[string]int64),
+		velocity:  make(map[string]int64),
+	}
+}
+
+// SetClickCount caches the click count of a link, as counting a redirect does
+func (c *Cache) SetClickCount(shortCode string, clicks int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clicks[shortCode] = clicks
+}
+
+// Cached reports whether a redirect entry is cached for shortCode
+func (c *Cache) Cached(shortCode string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.entries[shortCode]
+	return ok
+}
+
+// get returns the value of key in values, or storage.ErrNotFound
+func get[V any](c *Cache, values map[string]V, key string) (V, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := values[key]
+	if !ok {
+		return value, storage.ErrNotFound
+	}
+	return value, nil
+}
+
+// set stores value under key in values
+func set[V any](c *Cache, values map[string]V, key string, value V) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	values[key] = value
+	return nil
+}
+
+func (c *Cache) GetRedirectEntry(shortCode string) (*cache.RedirectEntry, error) {
+	entry, err := get(c, c.entries, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (c *Cache) CacheRedirectEntry(shortCode string, entry *cache.RedirectEntry) error {
+	return set(c, c.entries, shortCode, *entry)
+}
+
+func (c *Cache) GetURLMapping(shortCode string) (*models.URL, error) {
+	url, err := get(c, c.urls, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	return &url, nil
+}
+
+func (c *Cache) CacheURLMapping(shortCode string, urlData *models.URL) error {
+	return set(c, c.urls, shortCode, *urlData)
+}
+
+func (c *Cache) GetShortCodeForOriginalURL(ownerID *uint, originalURL string) (string, error) {
+	return get(c, c.originals, originalKey(ownerID, originalURL))
+}
+
+func (c *Cache) CacheOriginalURLMapping(ownerID *uint, originalURL string, shortCode string) error {
+	return set(c, c.originals, originalKey(ownerID, originalURL), shortCode)
+}
+
+// originalKey scopes a destination's mapping to its owner
+func originalKey(ownerID *uint, originalURL string) string {
+	if ownerID == nil {
+		return "0:" + originalURL
+	}
+	return fmt.Sprintf("%d:%s", *ownerID, originalURL)
+}
+
+func (c *Cache) GetURLStats(shortCode string) (*models.StatsResponse, error) {
+	stats, err := get(c, c.stats, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (c *Cache) CacheURLStats(shortCode string, stats *models.StatsResponse) error {
+	return set(c, c.stats, shortCode, *stats)
+}
+
+func (c *Cache) GetClickCount(shortCode string) (int64, error) {
+	return get(c, c.clicks, shortCode)
+}
+
+func (c *Cache) ConsumeRemainingClick(shortCode string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	remaining, ok := c.remaining[shortCode]
+	if !ok {
+		return 0, storage.ErrNotFound
+	}
+	remaining--
+	c.remaining[shortCode] = remaining
+	return remaining, nil
+}
+
+func (c *Cache) SeedRemainingClicks(shortCode string, remaining int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, seeded := c.remaining[shortCode]; !seeded {
+		c.remaining[shortCode] = int64(remaining)
+	}
+	return nil
+}
+
+func (c *Cache) GetRemainingClicks(shortCode string) (int64, error) {
+	return get(c, c.remaining, shortCode)
+}
+
+func (c *Cache) IncrementVelocity(shortCode string, windowStart time.Time, window time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := shortCode + " " + windowStart.Format(time.RFC3339)
+	c.velocity[key]++
+	return c.velocity[key], nil
+}
+
+func (c *Cache) InvalidateCache(shortCode string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, shortCode)
+	delete(c.urls, shortCode)
+	delete(c.stats, shortCode)
+	delete(c.clicks, shortCode)
+}
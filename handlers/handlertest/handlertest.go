@@ -0,0 +1,91 @@
+// Package handlertest serves the hot paths of the handlers, shortening,
+// redirecting and reading stats, over in-memory stores, so they can be
+// tested without PostgreSQL or Redis:
+//
+//	env := handlertest.New(t)
+//	resp := env.Do(t, http.MethodPost, "/shorten", `{"url":"https://example.com"}`)
+//
+// Only the routes themselves are served, without authentication, rate
+// limits or the other middleware of the router. Tests of everything else
+// run against containers with the testkit package.
+package handlertest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"url-shortener/handlers"
+	"url-shortener/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Env serves a Handler over its own in-memory stores
+type Env struct {
+	Server *httptest.Server
+	Store  *Store
+	Cache  *Cache
+
+	client *http.Client
+}
+
+// New starts a server for POST /shorten, GET /stats/{shortCode} and
+// GET /{shortCode} over empty stores, closed when the test ends.
+// Destinations are not screened against private networks, so shortening
+// does not resolve their hosts.
+func New(t *testing.T) *Env {
+	t.Helper()
+	t.Setenv("SHORTEN_ALLOW_PRIVATE_DESTINATIONS", "true")
+	gin.SetMode(gin.TestMode)
+
+	env := &Env{
+		Store: NewStore(),
+		Cache: NewCache(),
+		client: &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+	handler := handlers.New(env.Store, env.Cache)
+
+	router := gin.New()
+	router.Use(middleware.Errors())
+	router.POST("/shorten", handler.ShortenURL)
+	router.GET("/stats/:shortCode", handler.GetURLStats)
+	router.GET("/:shortCode", handler.RedirectURL)
+
+	env.Server = httptest.NewServer(router)
+	t.Cleanup(env.Server.Close)
+	return env
+}
+
+// Do sends a request to path with body as JSON, unless empty, without
+// following redirects, and returns the response with its body read
+func (e *Env) Do(t *testing.T, method, path, body string) (*http.Response, string) {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, e.Server.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	read, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(read)
+}
@@ -0,0 +1,282 @@
+package handlertest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/models"
+	"url-shortener/policy"
+	"url-shortener/service"
+)
+
+func decode(t *testing.T, body string, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(body), v); err != nil {
+		t.Fatalf("Failed to decode %s: %v", body, err)
+	}
+}
+
+func TestShorten(t *testing.T) {
+	env := New(t)
+
+	resp, body := env.Do(t, http.MethodPost, "/shorten", `{"url":"https://example.com/docs"}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST /shorten = %d: %s", resp.StatusCode, body)
+	}
+	var created models.ShortenResponse
+	decode(t, body, &created)
+	if created.ShortURL != env.Server.URL+"/"+created.ShortCode {
+		t.Errorf("short_url = %q", created.ShortURL)
+	}
+	if url, ok := env.Store.URL(created.ShortCode); !ok || url.OriginalURL != "https://example.com/docs" {
+		t.Errorf("stored link = %+v", url)
+	}
+
+	// The same destination, written differently, gets the same link back
+	resp, body = env.Do(t, http.MethodPost, "/shorten", `{"url":"HTTPS://Example.com/docs"}`)
+	var existing models.ShortenResponse
+	decode(t, body, &existing)
+	if resp.StatusCode != http.StatusOK || existing.ShortCode != created.ShortCode {
+		t.Errorf("shortening again = %d %s, want 200 %s", resp.StatusCode, existing.ShortCode, created.ShortCode)
+	}
+
+	resp, body = env.Do(t, http.MethodPost, "/shorten", `{"url":"not a url"}`)
+	if resp.StatusCode != http.StatusBadRequest || !strings.Contains(body, string(models.ErrCodeURLInvalid)) {
+		t.Errorf("invalid URL = %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	env := New(t)
+	env.Store.AddURL(&models.URL{ShortCode: "docs", OriginalURL: "https://example.com/docs", Status: models.StatusActive})
+
+	resp, body := env.Do(t, http.MethodGet, "/docs", "")
+	if resp.StatusCode != http.StatusMovedPermanently || resp.Header.Get("Location") != "https://example.com/docs" {
+		t.Fatalf("GET /docs = %d to %q: %s", resp.StatusCode, resp.Header.Get("Location"), body)
+	}
+	if !env.Cache.Cached("docs") {
+		t.Error("redirect entry not cached")
+	}
+
+	resp, _ = env.Do(t, http.MethodGet, "/missing", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /missing = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestRedirectArchivedAndRenamed(t *testing.T) {
+	env := New(t)
+	env.Store.AddURL(&models.URL{ShortCode: "idle", OriginalURL: "https://example.com/idle", Status: models.StatusActive})
+	env.Store.Archive("idle")
+	env.Store.AddURL(&models.URL{ShortCode: "new", OriginalURL: "https://example.com/new", Status: models.StatusActive})
+	env.Store.Rename("old", "new", time.Now().Add(time.Hour))
+
+	resp, _ := env.Do(t, http.MethodGet, "/idle", "")
+	if resp.StatusCode != http.StatusMovedPermanently {
+		t.Errorf("GET /idle = %d, want the archived link to redirect", resp.StatusCode)
+	}
+	if _, ok := env.Store.URL("idle"); !ok {
+		t.Error("archived link not rehydrated")
+	}
+
+	resp, _ = env.Do(t, http.MethodGet, "/old", "")
+	if resp.StatusCode != http.StatusMovedPermanently || resp.Header.Get("Location") != env.Server.URL+"/new" {
+		t.Errorf("GET /old = %d to %q, want the new short URL", resp.StatusCode, resp.Header.Get("Location"))
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	env := New(t)
+	past := time.Now().Add(-time.Minute)
+	env.Store.AddURL(&models.URL{ShortCode: "gone", OriginalURL: "https://example.com/", Status: models.StatusActive, ExpiresAt: &past})
+
+	resp, _ := env.Do(t, http.MethodGet, "/gone", "")
+	if resp.StatusCode != http.StatusGone {
+		t.Errorf("GET /gone = %d, want 410", resp.StatusCode)
+	}
+
+	resp, body := env.Do(t, http.MethodPost, "/shorten", `{"url":"https://example.com/once","max_clicks":1}`)
+	var created models.ShortenResponse
+	decode(t, body, &created)
+	if resp, _ = env.Do(t, http.MethodGet, "/"+created.ShortCode, ""); resp.StatusCode != http.StatusMovedPermanently {
+		t.Fatalf("first visit = %d, want 301", resp.StatusCode)
+	}
+	if resp, _ = env.Do(t, http.MethodGet, "/"+created.ShortCode, ""); resp.StatusCode != http.StatusGone {
+		t.Errorf("second visit = %d, want 410", resp.StatusCode)
+	}
+}
+
+func TestStats(t *testing.T) {
+	env := New(t)
+	env.Store.AddURL(&models.URL{ShortCode: "popular", OriginalURL: "https://example.com/", Status: models.StatusActive, ClickCount: 7})
+	env.Store.AddURL(&models.URL{ShortCode: "counted", OriginalURL: "https://example.com/counted", Status: models.StatusActive, ClickCount: 1})
+	env.Cache.SetClickCount("counted", 3)
+
+	for shortCode, want := range map[string]int{"popular": 7, "counted": 3} {
+		resp, body := env.Do(t, http.MethodGet, "/stats/"+shortCode, "")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET /stats/%s = %d: %s", shortCode, resp.StatusCode, body)
+		}
+		var stats models.StatsResponse
+		decode(t, body, &stats)
+		if stats.ClickCount != want {
+			t.Errorf("click_count of %s = %d, want %d", shortCode, stats.ClickCount, want)
+		}
+	}
+
+	resp, body := env.Do(t, http.MethodGet, "/stats/popular?fields=click_count", "")
+	if resp.StatusCode != http.StatusOK || strings.Contains(body, "original_url") {
+		t.Errorf("fields not applied: %d %s", resp.StatusCode, body)
+	}
+	if resp, _ = env.Do(t, http.MethodGet, "/stats/missing", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /stats/missing = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestVelocityLimit(t *testing.T) {
+	env := New(t)
+	// The clicks below must fall in one window
+	if time.Now().Second() >= 58 {
+		time.Sleep(3 * time.Second)
+	}
+
+	resp, body := env.Do(t, http.MethodPost, "/shorten", `{"url":"https://example.com/fragile","velocity_limit":{"clicks":2,"per":"minute","overflow":"reject"}}`)
+	var rejecting models.ShortenResponse
+	decode(t, body, &rejecting)
+	if resp.StatusCode != http.StatusCreated || rejecting.VelocityLimit == nil {
+		t.Fatalf("POST /shorten = %d: %s", resp.StatusCode, body)
+	}
+	for i := 0; i < 2; i++ {
+		if resp, _ = env.Do(t, http.MethodGet, "/"+rejecting.ShortCode, ""); resp.StatusCode != http.StatusFound {
+			t.Fatalf("visit %d = %d, want 302", i+1, resp.StatusCode)
+		}
+	}
+	resp, body = env.Do(t, http.MethodGet, "/"+rejecting.ShortCode, "")
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Errorf("visit over the limit = %d with Retry-After %q: %s", resp.StatusCode, resp.Header.Get("Retry-After"), body)
+	}
+
+	_, body = env.Do(t, http.MethodPost, "/shorten", `{"url":"https://example.com/launch","velocity_limit":{"clicks":1,"per":"minute","overflow":"redirect","overflow_url":"https://example.com/busy"}}`)
+	var redirecting models.ShortenResponse
+	decode(t, body, &redirecting)
+	env.Do(t, http.MethodGet, "/"+redirecting.ShortCode, "")
+	resp, _ = env.Do(t, http.MethodGet, "/"+redirecting.ShortCode, "")
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "https://example.com/busy" {
+		t.Errorf("visit over the limit = %d to %q, want 302 to the overflow URL", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	resp, _ = env.Do(t, http.MethodPost, "/shorten", `{"url":"https://example.com/x","velocity_limit":{"clicks":1,"overflow":"redirect"}}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("overflow redirect without overflow_url = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestTrackLandings(t *testing.T) {
+	env := New(t)
+
+	resp, body := env.Do(t, http.MethodPost, "/shorten", `{"url":"https://example.com/welcome?ref=mail","track_landings":true}`)
+	var created models.ShortenResponse
+	decode(t, body, &created)
+	if resp.StatusCode != http.StatusCreated || !created.TrackLandings {
+		t.Fatalf("POST /shorten = %d: %s", resp.StatusCode, body)
+	}
+
+	// Every visit gets its own token, so browsers must not cache the redirect
+	first, _ := env.Do(t, http.MethodGet, "/"+created.ShortCode, "")
+	second, _ := env.Do(t, http.MethodGet, "/"+created.ShortCode, "")
+	location := first.Header.Get("Location")
+	if first.StatusCode != http.StatusFound || !strings.HasPrefix(location, "https://example.com/welcome?ref=mail&sl_land=") {
+		t.Fatalf("visit = %d to %q, want 302 with a landing token", first.StatusCode, location)
+	}
+	if location == second.Header.Get("Location") {
+		t.Errorf("two visits got the same landing token %q", location)
+	}
+
+	resp, _ = env.Do(t, http.MethodPost, "/shorten", `{"url":"https://example.com/private","track_landings":true,"analytics":"none"}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("track_landings with analytics none = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestRedirectHeaders(t *testing.T) {
+	env := New(t)
+
+	resp, body := env.Do(t, http.MethodPost, "/shorten", `{"url":"https://example.com/private","redirect_headers":{"referrer-policy":"no-referrer","Cache-Control":"private, max-age=60"}}`)
+	var created models.ShortenResponse
+	decode(t, body, &created)
+	if resp.StatusCode != http.StatusCreated || created.RedirectHeaders["Referrer-Policy"] != "no-referrer" {
+		t.Fatalf("POST /shorten = %d: %s", resp.StatusCode, body)
+	}
+
+	resp, _ = env.Do(t, http.MethodGet, "/"+created.ShortCode, "")
+	if resp.Header.Get("Referrer-Policy") != "no-referrer" || resp.Header.Get("Cache-Control") != "private, max-age=60" {
+		t.Errorf("redirect headers = %v", resp.Header)
+	}
+
+	resp, _ = env.Do(t, http.MethodPost, "/shorten", `{"url":"https://example.com/x","redirect_headers":{"Set-Cookie":"session=1"}}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("redirect_headers setting a cookie = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestShortenDeduplicatesPerOwner(t *testing.T) {
+	env := New(t)
+	stores := service.Stores{Links: env.Store, Cache: env.Cache}
+	caller := func(userID uint) service.Caller {
+		return service.Caller{APIKey: &models.APIKey{UserID: &userID}, Policy: policy.Load("")}
+	}
+	request := models.ShortenRequest{URL: "https://example.com/shared"}
+
+	first, created, err := stores.Shorten(context.Background(), caller(1), request)
+	if err != nil || !created {
+		t.Fatalf("Shorten() by user 1 = %v, %v", created, err)
+	}
+	again, created, err := stores.Shorten(context.Background(), caller(1), request)
+	if err != nil || created || again.ShortCode != first.ShortCode {
+		t.Errorf("Shorten() again by user 1 = %+v, %v, %v, want %s back", again, created, err, first.ShortCode)
+	}
+
+	// Another owner gets a link of their own, never the first one
+	other, created, err := stores.Shorten(context.Background(), caller(2), request)
+	if err != nil || !created || other.ShortCode == first.ShortCode || *other.OwnerID != 2 {
+		t.Fatalf("Shorten() by user 2 = %+v, %v, %v, want a new link", other, created, err)
+	}
+	again, _, _ = stores.Shorten(context.Background(), caller(2), request)
+	if again.ShortCode != other.ShortCode {
+		t.Errorf("Shorten() again by user 2 = %s, want %s", again.ShortCode, other.ShortCode)
+	}
+
+	// if_exists=error only conflicts with the caller's own link
+	request.IfExists = models.IfExistsError
+	_, _, err = stores.Shorten(context.Background(), caller(1), request)
+	var apiErr *models.APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusConflict || apiErr.ShortCode != first.ShortCode {
+		t.Errorf("if_exists=error by user 1 = %v, want 409 with %s", err, first.ShortCode)
+	}
+	third, created, err := stores.Shorten(context.Background(), caller(3), request)
+	if err != nil || !created || third.ShortCode == first.ShortCode || third.ShortCode == other.ShortCode {
+		t.Errorf("if_exists=error by user 3 = %+v, %v, %v, want a new link", third, created, err)
+	}
+}
+
+func TestShortenIfExistsErrorAnonymous(t *testing.T) {
+	env := New(t)
+	request := `{"url":"https://example.com/anonymous","if_exists":"error"}`
+
+	// Anonymous callers own no links, so they never conflict with others'
+	codes := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		resp, body := env.Do(t, http.MethodPost, "/shorten", request)
+		var created models.ShortenResponse
+		decode(t, body, &created)
+		if resp.StatusCode != http.StatusCreated || codes[created.ShortCode] {
+			t.Fatalf("POST /shorten %d = %d: %s, want a new link", i+1, resp.StatusCode, body)
+		}
+		codes[created.ShortCode] = true
+	}
+}
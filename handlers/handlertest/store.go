@@ -0,0 +1,250 @@
+package handlertest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/storage"
+)
+
+// Store is an in-memory database.Store. It keeps what the hot paths need of
+// links, archived links and renamed aliases, but none of their constraints
+// beyond unique short codes and deduplicated destinations.
+type Store struct {
+	mu            sync.Mutex
+	nextID        uint
+	nextVariantID uint
+	links         map[string]*models.URL
+	archived      map[string]*models.URL
+	aliases       map[string]*models.RenamedAlias
+	variants      map[uint][]models.LinkVariant
+}
+
+var _ database.Store = (*Store)(nil)
+
+// NewStore returns an empty Store
+func NewStore() *Store {
+	return &Store{
+		links:    make(map[string]*models.URL),
+		archived: make(map[string]*models.URL),
+		aliases:  make(map[string]*models.RenamedAlias),
+		variants: make(map[uint][]models.LinkVariant),
+	}
+}
+
+// AddURL stores url as is, e.g. already expired, giving it an ID and a
+// creation time when it has none
+func (s *Store) AddURL(url *models.URL) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(url)
+}
+
+// Archive moves a link to the archive, as the archiver does with idle links
+func (s *Store) Archive(shortCode string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if url, ok := s.links[shortCode]; ok {
+		delete(s.links, shortCode)
+		s.archived[shortCode] = url
+	}
+}
+
+// Rename records that the link now at shortCode was renamed away from
+// alias, which keeps resolving until expiresAt
+func (s *Store) Rename(alias, shortCode string, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if url, ok := s.links[shortCode]; ok {
+		s.aliases[alias] = &models.RenamedAlias{Alias: alias, URLID: url.ID, CreatedAt: time.Now(), ExpiresAt: expiresAt}
+	}
+}
+
+// URL returns a copy of the live link at shortCode, if any
+func (s *Store) URL(shortCode string) (*models.URL, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	url, ok := s.links[shortCode]
+	if !ok {
+		return nil, false
+	}
+	copied := *url
+	return &copied, true
+}
+
+func (s *Store) add(url *models.URL) {
+	if url.ID == 0 {
+		s.nextID++
+		url.ID = s.nextID
+	} else if url.ID > s.nextID {
+		s.nextID = url.ID
+	}
+	if url.CreatedAt.IsZero() {
+		url.CreatedAt = time.Now()
+		url.UpdatedAt = url.CreatedAt
+	}
+	copied := *url
+	s.links[url.ShortCode] = &copied
+}
+
+// findByID returns the live link with id
+func (s *Store) findByID(id uint) *models.URL {
+	for _, url := range s.links {
+		if url.ID == id {
+			return url
+		}
+	}
+	return nil
+}
+
+func (s *Store) CreateURL(ctx context.Context, url *models.URL, variants []models.LinkVariant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, taken := s.links[url.ShortCode]; taken {
+		return storage.Wrap(storage.ErrConflict, fmt.Errorf("short code %s is taken", url.ShortCode))
+	}
+	if url.OriginalURLHash != nil {
+		for _, existing := range s.links {
+			if existing.OriginalURLHash != nil && *existing.OriginalURLHash == *url.OriginalURLHash && sameOwner(existing.OwnerID, url.OwnerID) {
+				return storage.Wrap(storage.ErrConflict, fmt.Errorf("%s is already deduplicated", url.OriginalURL))
+			}
+		}
+	}
+	s.add(url)
+	for i := range variants {
+		s.nextVariantID++
+		variants[i].ID = s.nextVariantID
+		variants[i].URLID = url.ID
+	}
+	s.variants[url.ID] = append([]models.LinkVariant(nil), variants...)
+	return nil
+}
+
+func (s *Store) GetByShortCode(ctx context.Context, shortCode string) (*models.URL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	url, ok := s.links[shortCode]
+	if !ok || url.DeletedAt.Valid {
+		return nil, storage.ErrNotFound
+	}
+	copied := *url
+	return &copied, nil
+}
+
+func (s *Store) GetArchived(ctx context.Context, shortCode string) (*models.URL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	url, ok := s.archived[shortCode]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	copied := *url
+	return &copied, nil
+}
+
+func (s *Store) Rehydrate(ctx context.Context, shortCode string) (*models.URL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	url, ok := s.archived[shortCode]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	delete(s.archived, shortCode)
+	s.links[shortCode] = url
+	copied := *url
+	return &copied, nil
+}
+
+func (s *Store) ShortCodeTaken(ctx context.Context, code string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, live := s.links[code]
+	_, archived := s.archived[code]
+	_, renamed := s.aliases[code]
+	return live || archived || renamed, nil
+}
+
+func (s *Store) FindRenamedAlias(ctx context.Context, alias string, now time.Time) (*models.RenamedAlias, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	renamed, ok := s.aliases[alias]
+	if !ok || !renamed.ExpiresAt.After(now) {
+		return nil, "", storage.ErrNotFound
+	}
+	url := s.findByID(renamed.URLID)
+	if url == nil || url.DeletedAt.Valid {
+		return nil, "", storage.ErrNotFound
+	}
+	copied := *renamed
+	return &copied, url.ShortCode, nil
+}
+
+func (s *Store) RecordRenamedAliasHit(ctx context.Context, alias string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if renamed, ok := s.aliases[alias]; ok {
+		renamed.Hits++
+		renamed.LastHitAt = &at
+	}
+	return nil
+}
+
+func (s *Store) GetByOriginalURL(ctx context.Context, ownerID *uint, hash string) (*models.URL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, url := range s.links {
+		if url.OriginalURLHash != nil && *url.OriginalURLHash == hash && sameOwner(url.OwnerID, ownerID) && !url.DeletedAt.Valid {
+			copied := *url
+			return &copied, nil
+		}
+	}
+	return nil, storage.ErrNotFound
+}
+
+func (s *Store) IncrementClicks(ctx context.Context, urls, variants map[uint]int64) (map[uint]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[uint]int64, len(urls))
+	for id, clicks := range urls {
+		if url := s.findByID(id); url != nil {
+			url.ClickCount += int(clicks)
+			counts[id] = int64(url.ClickCount)
+		}
+	}
+	for _, linkVariants := range s.variants {
+		for i := range linkVariants {
+			linkVariants[i].Clicks += variants[linkVariants[i].ID]
+		}
+	}
+	return counts, nil
+}
+
+func (s *Store) ConsumeClick(ctx context.Context, urlID uint) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	url := s.findByID(urlID)
+	if url == nil || url.ClicksRemaining == nil || *url.ClicksRemaining <= 0 {
+		return 0, database.ErrNoClicksLeft
+	}
+	remaining := *url.ClicksRemaining - 1
+	url.ClicksRemaining = &remaining
+	if remaining == 0 {
+		now := time.Now()
+		url.ExpiresAt = &now
+	}
+	return remaining, nil
+}
+
+// sameOwner reports whether two links belong to the same owner, ownerless
+// links sharing one
+func sameOwner(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,47 @@
+package handlertest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userHeader carries the ID of the user a test request is sent as
+const userHeader = "X-Test-User"
+
+// AsUser is middleware standing in for API key authentication: requests
+// act as the user whose ID Serve sent, through an API key assigned to them
+func AsUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if id, err := strconv.ParseUint(c.GetHeader(userHeader), 10, 64); err == nil {
+			userID := uint(id)
+			middleware.APIKeyContextKey.Set(c, &models.APIKey{UserID: &userID})
+		}
+	}
+}
+
+// Serve sends a request to router as userID, or anonymously when 0, with
+// body as JSON unless empty, and returns the recorded response
+func Serve(router http.Handler, userID uint, method, path, body string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	request := httptest.NewRequest(method, path, reader)
+	if body != "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if userID != 0 {
+		request.Header.Set(userHeader, strconv.FormatUint(uint64(userID), 10))
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
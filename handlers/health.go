@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"time"
+
+	"url-shortener/buildinfo"
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/jobs"
+	"url-shortener/linktable"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Upper bound for each dependency round trip in the health check
+const healthPingTimeout = 2 * time.Second
+
+// HealthCheck godoc
+// @Summary Health check
+// @ID healthCheck
+// @Description Check if the service is healthy and running. Reports the build version, uptime and round-trip latency to the database and cache. The status is degraded when the cache is down or a background job is overdue, and unhealthy (503) when the database is down.
+// @Tags System
+// @Produce json
+// @Success 200 {object} models.HealthResponse
+// @Failure 503 {object} models.HealthResponse "Database unavailable"
+// @Router /health [get]
+func HealthCheck(c *gin.Context) {
+	respondWithHealth(c, buildHealth(c.Request.Context(), false))
+}
+
+// VerboseHealthCheck godoc
+// @Summary Detailed health check
+// @ID verboseHealthCheck
+// @Description Health check including migration status, background job heartbeats, the click queue, database pool usage and Go runtime details
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} models.HealthResponse
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 503 {object} models.HealthResponse "Database unavailable"
+// @Security AdminAuth
+// @Router /admin/health [get]
+func VerboseHealthCheck(c *gin.Context) {
+	respondWithHealth(c, buildHealth(c.Request.Context(), true))
+}
+
+func respondWithHealth(c *gin.Context, health *models.HealthResponse) {
+	if health.Status == models.HealthUnhealthy {
+		c.JSON(http.StatusServiceUnavailable, health)
+		return
+	}
+	c.JSON(http.StatusOK, health)
+}
+
+func buildHealth(ctx context.Context, verbose bool) *models.HealthResponse {
+	uptime := buildinfo.Uptime()
+	health := &models.HealthResponse{
+		Status:        models.HealthHealthy,
+		Timestamp:     time.Now().UTC(),
+		Service:       "url-shortener",
+		Version:       buildinfo.Version,
+		Commit:        buildinfo.Commit,
+		Instance:      buildinfo.Instance,
+		Uptime:        uptime.Round(time.Second).String(),
+		UptimeSeconds: int64(uptime.Seconds()),
+		Database:      checkDependency(ctx, pingDatabase),
+		Cache:         checkDependency(ctx, cache.Ping),
+	}
+
+	heartbeats := jobs.Heartbeats()
+
+	// Redis is optional, so we don't fail if it's down
+	if !health.Cache.Healthy {
+		health.Status = models.HealthDegraded
+	}
+	for _, job := range heartbeats {
+		if job.Stale {
+			health.Status = models.HealthDegraded
+		}
+	}
+	if !health.Database.Healthy {
+		health.Status = models.HealthUnhealthy
+	}
+
+	if !verbose {
+		// Connection errors can reveal internal addresses
+		health.Database.Error = ""
+		health.Cache.Error = ""
+		return health
+	}
+
+	health.Jobs = heartbeats
+	health.ClickQueue = &models.ClickQueueStatus{
+		Depth:    len(clickQueue),
+		Capacity: cap(clickQueue),
+		Dropped:  droppedClicks.Load(),
+		Shed:     shedEvents.Load(),
+	}
+	localCache := cache.LocalCacheStats()
+	health.LocalCache = &localCache
+	if linktable.Enabled() {
+		linkTable := linktable.Status()
+		health.LinkTable = &linkTable
+	}
+	if integrity := jobs.Integrity(); integrity.Runs > 0 {
+		health.Integrity = &integrity
+	}
+	health.Runtime = &models.RuntimeStatus{
+		GoVersion:  runtime.Version(),
+		Goroutines: runtime.NumGoroutine(),
+	}
+	if sqlDB, err := database.DB.DB(); err == nil {
+		stats := sqlDB.Stats()
+		health.DBPool = &models.DBPoolStatus{
+			Open:      stats.OpenConnections,
+			InUse:     stats.InUse,
+			Idle:      stats.Idle,
+			WaitCount: stats.WaitCount,
+		}
+	}
+	if health.Database.Healthy {
+		migrations := database.MigrationStatus(ctx)
+		health.Migrations = &migrations
+	}
+	return health
+}
+
+// checkDependency times a ping, bounded by healthPingTimeout
+func checkDependency(ctx context.Context, ping func(context.Context) error) models.DependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, healthPingTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ping(ctx)
+	result := models.DependencyHealth{
+		Healthy:   err == nil,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func pingDatabase(ctx context.Context) error {
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/notify"
+	"url-shortener/outbound"
+	"url-shortener/service"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListHookTriggers godoc
+// @Summary List REST Hooks triggers
+// @ID listHookTriggers
+// @Description List the link events that can be subscribed to
+// @Tags Hooks
+// @Produce json
+// @Success 200 {array} models.HookTrigger
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/hooks/triggers [get]
+func ListHookTriggers(c *gin.Context) {
+	c.JSON(http.StatusOK, models.HookTriggers)
+}
+
+// SampleHookTrigger godoc
+// @Summary Sample payloads for a trigger
+// @ID sampleHookTrigger
+// @Description Return sample payloads for an event, as used by Zapier's "perform list" when setting up a Zap
+// @Tags Hooks
+// @Produce json
+// @Param event path string true "Event name"
+// @Success 200 {array} models.HookLinkPayload
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Unknown event"
+// @Security AdminAuth
+// @Router /admin/hooks/triggers/{event}/sample [get]
+func SampleHookTrigger(c *gin.Context) {
+	event := c.Param("event")
+	if !isHookEvent(event) {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Unknown event"))
+		return
+	}
+
+	// Prefer real recent links so Zap field mapping matches live data
+	query := database.DB.Order("created_at desc").Limit(3).Where("inert = ?", false)
+	switch event {
+	case models.HookLinkApproved:
+		query = query.Where("status = ?", models.StatusActive)
+	case models.HookLinkRejected:
+		query = query.Where("status = ?", models.StatusRejected)
+	}
+
+	var urls []models.URL
+	if err := query.Find(&urls).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to load samples"))
+		return
+	}
+
+	samples := make([]models.HookLinkPayload, 0, len(urls))
+	for i := range urls {
+		samples = append(samples, linkHookPayload(c, event, &urls[i]))
+	}
+	if len(samples) == 0 {
+		samples = append(samples, models.HookLinkPayload{
+			ID:          1,
+			Event:       event,
+			ShortCode:   "abc123",
+			ShortURL:    buildShortURL(c, "abc123"),
+			OriginalURL: "https://example.com/very/long/url",
+			Status:      models.StatusActive,
+			CreatedAt:   time.Now().UTC(),
+		})
+	}
+
+	c.JSON(http.StatusOK, samples)
+}
+
+// ListHookSubscriptions godoc
+// @Summary List REST Hooks subscriptions
+// @ID listHookSubscriptions
+// @Tags Hooks
+// @Produce json
+// @Success 200 {array} models.HookSubscription
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/hooks [get]
+func ListHookSubscriptions(c *gin.Context) {
+	var subscriptions []models.HookSubscription
+	if err := database.DB.Order("id").Find(&subscriptions).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list hook subscriptions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// SubscribeHook godoc
+// @Summary Subscribe to a link event
+// @ID subscribeHook
+// @Description Register a target URL that receives a JSON POST for every occurrence of the event. Deliveries carry X-Hook-Delivery, X-Hook-Event, X-Hook-Attempt, X-Timestamp and X-Signature headers, where the signature is hex(HMAC-SHA256(secret, timestamp + "\n" + body)) with the secret returned here once. Failed deliveries are retried with backoff. Responding 410 Gone to a delivery unsubscribes it.
+// @Tags Hooks
+// @Accept json
+// @Produce json
+// @Param request body models.SubscribeHookRequest true "Subscription"
+// @Success 201 {object} models.HookSubscriptionCreatedResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/hooks [post]
+func SubscribeHook(c *gin.Context) {
+	var request models.SubscribeHookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	if err := outbound.CheckURL(request.TargetURL); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid target_url: "+err.Error()))
+		return
+	}
+
+	secret, err := utils.GenerateToken(signingSecretBytes)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create hook subscription"))
+		return
+	}
+
+	subscription := models.HookSubscription{
+		Event:     request.Event,
+		TargetURL: request.TargetURL,
+		Secret:    secret,
+	}
+	if apiKey := middleware.CurrentAPIKey(c); apiKey != nil {
+		subscription.APIKeyID = &apiKey.ID
+	}
+
+	if err := database.DB.Create(&subscription).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create hook subscription"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.HookSubscriptionCreatedResponse{HookSubscription: subscription, Secret: secret})
+}
+
+// UnsubscribeHook godoc
+// @Summary Unsubscribe from a link event
+// @ID unsubscribeHook
+// @Tags Hooks
+// @Param id path int true "Subscription ID"
+// @Success 204 "Unsubscribed"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Hook subscription not found"
+// @Security AdminAuth
+// @Router /admin/hooks/{id} [delete]
+func UnsubscribeHook(c *gin.Context) {
+	result := database.DB.Delete(&models.HookSubscription{}, c.Param("id"))
+	if result.Error != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete hook subscription"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Hook subscription not found"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListHookDeliveries godoc
+// @Summary List hook deliveries
+// @ID listHookDeliveries
+// @Description List the most recent deliveries, newest first, with their attempts and last error. Filter by status to inspect the dead letters.
+// @Tags Hooks
+// @Produce json
+// @Param status query string false "pending, delivered or dead"
+// @Param subscription_id query int false "Only deliveries for this subscription"
+// @Param limit query int false "Maximum deliveries to return (default 50, max 500)"
+// @Success 200 {array} models.HookDelivery
+// @Failure 400 {object} models.ErrorResponse "Invalid filter"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/hooks/deliveries [get]
+func ListHookDeliveries(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead:
+	default:
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "status must be pending, delivered or dead"))
+		return
+	}
+
+	subscriptionID, err := optionalID(c.Query("subscription_id"))
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid subscription_id"))
+		return
+	}
+
+	limit := 50
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > 500 {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "limit must be between 1 and 500"))
+			return
+		}
+	}
+
+	query := database.DB.Order("id desc").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if subscriptionID != 0 {
+		query = query.Where("subscription_id = ?", subscriptionID)
+	}
+
+	deliveries := []models.HookDelivery{}
+	if err := query.Find(&deliveries).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list hook deliveries"))
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// RedriveHookDelivery godoc
+// @Summary Redrive a dead hook delivery
+// @ID redriveHookDelivery
+// @Description Queue a dead delivery again with a fresh retry schedule. It keeps its delivery ID so subscribers can discard it if it was processed after all.
+// @Tags Hooks
+// @Produce json
+// @Param id path int true "Delivery ID"
+// @Success 202 {object} models.RedriveHookDeliveriesResponse
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "No dead delivery with this ID"
+// @Security AdminAuth
+// @Router /admin/hooks/deliveries/{id}/redrive [post]
+func RedriveHookDelivery(c *gin.Context) {
+	id, err := optionalID(c.Param("id"))
+	if err != nil || id == 0 {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Dead hook delivery not found"))
+		return
+	}
+
+	redriven, err := notify.RedriveHookDeliveries(c.Request.Context(), id, 0)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to redrive hook delivery"))
+		return
+	}
+	if redriven == 0 {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Dead hook delivery not found"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.RedriveHookDeliveriesResponse{Redriven: redriven})
+}
+
+// RedriveHookDeliveries godoc
+// @Summary Redrive all dead hook deliveries
+// @ID redriveHookDeliveries
+// @Description Queue every dead delivery, or those of one subscription, again with a fresh retry schedule
+// @Tags Hooks
+// @Produce json
+// @Param subscription_id query int false "Only redrive deliveries for this subscription"
+// @Success 202 {object} models.RedriveHookDeliveriesResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid subscription_id"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/hooks/deliveries/redrive [post]
+func RedriveHookDeliveries(c *gin.Context) {
+	subscriptionID, err := optionalID(c.Query("subscription_id"))
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid subscription_id"))
+		return
+	}
+
+	redriven, err := notify.RedriveHookDeliveries(c.Request.Context(), 0, subscriptionID)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to redrive hook deliveries"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.RedriveHookDeliveriesResponse{Redriven: redriven})
+}
+
+// optionalID parses an ID from a path or query value, returning 0 when empty
+func optionalID(value string) (uint, error) {
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	return uint(id), err
+}
+
+// fireLinkHook notifies REST Hooks subscribers about a link event, and the
+// webhooks of the link's owner subscribed to it
+func fireLinkHook(c *gin.Context, event string, urlRecord *models.URL) {
+	service.FireLinkHook(c.Request.Context(), requestCaller(c), event, urlRecord)
+}
+
+func linkHookPayload(c *gin.Context, event string, urlRecord *models.URL) models.HookLinkPayload {
+	payload := notify.LinkPayload(event, urlRecord)
+	payload.ShortURL = buildShortURL(c, urlRecord.ShortCode)
+	return payload
+}
+
+func isHookEvent(event string) bool {
+	for _, trigger := range models.HookTriggers {
+		if trigger.Event == event {
+			return true
+		}
+	}
+	return false
+}
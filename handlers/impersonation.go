@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How long an impersonation lasts unless the admin asks otherwise
+const defaultImpersonationMinutes = 30
+
+// Random bytes in an impersonation token
+const impersonationTokenBytes = 32
+
+// StartImpersonation godoc
+// @Summary Impersonate a user
+// @ID startImpersonation
+// @Description Issue a token acting as a user for support debugging, for 30 minutes by default and at most 4 hours. Sent as Authorization: Bearer usi_..., it stands in for an API key of the user with the given scopes, read_stats only by default, and never reaches the admin API. Responses to requests made with it carry the X-Impersonation header and, when they are JSON objects, an impersonation field describing it. Starting and ending it and every request made with it are recorded in the audit log with the reason. The token is only returned once.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body models.ImpersonationRequest true "Why, for how long and with which scopes"
+// @Success 201 {object} models.ImpersonationResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "User not found"
+// @Security AdminAuth
+// @Router /admin/users/{id}/impersonate [post]
+func StartImpersonation(c *gin.Context) {
+	var request models.ImpersonationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	if strings.TrimSpace(request.Reason) == "" {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "reason is required"))
+		return
+	}
+	if request.Minutes == 0 {
+		request.Minutes = defaultImpersonationMinutes
+	}
+	if len(request.Scopes) == 0 {
+		request.Scopes = []string{models.ScopeReadStats}
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", c.Param("id")).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "User not found"))
+		return
+	}
+
+	token, err := utils.GenerateToken(impersonationTokenBytes)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to start impersonation"))
+		return
+	}
+	token = middleware.ImpersonationTokenPrefix + token
+
+	impersonation := models.Impersonation{
+		UserID:    user.ID,
+		Actor:     adminActor(c),
+		Reason:    request.Reason,
+		Scopes:    request.Scopes,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(time.Duration(request.Minutes) * time.Minute),
+	}
+	if err := database.DB.Create(&impersonation).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to start impersonation"))
+		return
+	}
+	recordAuditAs(c, impersonation.Actor, models.AuditActionImpersonateStart, "",
+		fmt.Sprintf("impersonation %d of user %d for %d minutes with scopes %s: %s",
+			impersonation.ID, user.ID, request.Minutes, strings.Join(request.Scopes, ","), request.Reason))
+
+	c.JSON(http.StatusCreated, models.ImpersonationResponse{Impersonation: impersonation, Token: token})
+}
+
+// ListImpersonations godoc
+// @Summary List impersonations
+// @ID listImpersonations
+// @Description List impersonations of users, newest first, or only those still usable with active=true
+// @Tags Admin
+// @Produce json
+// @Param active query bool false "Only impersonations neither ended nor expired"
+// @Success 200 {array} models.Impersonation
+// @Failure 400 {object} models.ErrorResponse "Invalid filter"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/impersonations [get]
+func ListImpersonations(c *gin.Context) {
+	query := database.DB.Order("id DESC").Limit(500)
+	if value := c.Query("active"); value != "" {
+		active, err := strconv.ParseBool(value)
+		if err != nil {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "active must be true or false"))
+			return
+		}
+		if active {
+			query = query.Where("ended_at IS NULL AND expires_at > ?", time.Now())
+		} else {
+			query = query.Where("ended_at IS NOT NULL OR expires_at <= ?", time.Now())
+		}
+	}
+
+	impersonations := []models.Impersonation{}
+	if err := query.Find(&impersonations).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list impersonations"))
+		return
+	}
+	c.JSON(http.StatusOK, impersonations)
+}
+
+// EndImpersonation godoc
+// @Summary End an impersonation
+// @ID endImpersonation
+// @Description Revoke an impersonation's token before it expires
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Impersonation ID"
+// @Success 200 {object} models.Impersonation
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Impersonation not found"
+// @Failure 409 {object} models.ErrorResponse "Impersonation already ended or expired"
+// @Security AdminAuth
+// @Router /admin/impersonations/{id} [delete]
+func EndImpersonation(c *gin.Context) {
+	var impersonation models.Impersonation
+	if err := database.DB.First(&impersonation, "id = ?", c.Param("id")).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Impersonation not found"))
+		return
+	}
+	now := time.Now()
+	if !impersonation.Active(now) {
+		c.Error(models.NewAPIError(http.StatusConflict, models.ErrCodeConflict, "Impersonation already ended or expired"))
+		return
+	}
+
+	result := database.DB.Model(&impersonation).Where("ended_at IS NULL").Update("ended_at", now)
+	if result.Error != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to end impersonation"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.NewAPIError(http.StatusConflict, models.ErrCodeConflict, "Impersonation already ended or expired"))
+		return
+	}
+	impersonation.EndedAt = &now
+	recordAuditAs(c, adminActor(c), models.AuditActionImpersonateEnd, "",
+		fmt.Sprintf("impersonation %d of user %d", impersonation.ID, impersonation.UserID))
+
+	c.JSON(http.StatusOK, impersonation)
+}
+
+// adminActor names the admin credential of the request in the audit log
+func adminActor(c *gin.Context) string {
+	if user := middleware.CurrentUser(c); user != nil {
+		return "user:" + strconv.FormatUint(uint64(user.ID), 10)
+	}
+	if apiKey := middleware.CurrentAPIKey(c); apiKey != nil {
+		return "api_key:" + strconv.FormatUint(uint64(apiKey.ID), 10)
+	}
+	return "admin"
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/models"
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// wantsLinkInfo reports whether the visitor asked for a plaintext summary of
+// the link instead of being redirected: ?info=1, or an Accept header
+// preferring text/plain over HTML
+func wantsLinkInfo(c *gin.Context) bool {
+	if c.Query("info") == "1" {
+		return true
+	}
+	accept := c.GetHeader("Accept")
+	return strings.Contains(accept, "text/plain") && !strings.Contains(accept, "text/html")
+}
+
+// serveLinkInfo answers with the link's destination, creation date and
+// clicks as plaintext. It is not counted as a click.
+func (h *Handler) serveLinkInfo(c *gin.Context, shortCode string, entry *cache.RedirectEntry) {
+	stats, err := h.stores.Stats(c.Request.Context(), shortCode, service.DefaultStatsMaxAge)
+	if err != nil {
+		c.Error(models.ErrLinkNotFound)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Vary", "Accept")
+	c.String(http.StatusOK, formatLinkInfo(buildShortURL(c, shortCode), entry, stats))
+}
+
+func formatLinkInfo(shortURL string, entry *cache.RedirectEntry, stats *models.StatsResponse) string {
+	var out strings.Builder
+	w := tabwriter.NewWriter(&out, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "Short link:\t%s\n", shortURL)
+	fmt.Fprintf(w, "Destination:\t%s\n", entry.Target(entry.Destination))
+	if entry.Has(cache.RedirectVariants) {
+		fmt.Fprintf(w, "Split link:\tvisitors are sent to one of several variants\n")
+	}
+	fmt.Fprintf(w, "Created:\t%s\n", stats.CreatedAt.UTC().Format(time.RFC3339))
+	if stats.ExpiresAt != nil {
+		fmt.Fprintf(w, "Expires:\t%s\n", stats.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "Clicks:\t%d\n", stats.ClickCount)
+	w.Flush()
+	return out.String()
+}
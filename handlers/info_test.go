@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWantsLinkInfo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		query, accept string
+		want          bool
+	}{
+		{query: "", accept: "*/*", want: false},
+		{query: "?info=1", accept: "*/*", want: true},
+		{query: "", accept: "text/plain", want: true},
+		{query: "", accept: "text/html,application/xhtml+xml,text/plain;q=0.8", want: false},
+		{query: "?info=0", accept: "", want: false},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/abc"+tt.query, nil)
+		c.Request.Header.Set("Accept", tt.accept)
+		if got := wantsLinkInfo(c); got != tt.want {
+			t.Errorf("wantsLinkInfo(%q, Accept %q) = %v, want %v", tt.query, tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestFormatLinkInfo(t *testing.T) {
+	created := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	entry := &cache.RedirectEntry{Destination: "https://example.com/page"}
+	stats := &models.StatsResponse{ClickCount: 42, CreatedAt: created}
+
+	got := formatLinkInfo("https://sho.rt/abc", entry, stats)
+	want := "Short link:  https://sho.rt/abc\n" +
+		"Destination: https://example.com/page\n" +
+		"Created:     2024-01-15T10:30:00Z\n" +
+		"Clicks:      42\n"
+	if got != want {
+		t.Errorf("formatLinkInfo() =\n%s\nwant\n%s", got, want)
+	}
+	if strings.Contains(got, "Expires") {
+		t.Error("links without an expiry should not list one")
+	}
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// landingParam carries the landing token to the destination, where
+// /js/track.js reports it and removes it from the address bar
+const landingParam = "sl_land"
+
+// Landing tokens issued while Redis is unavailable, per instance, so
+// landings are only matched when reported to the instance that redirected
+var (
+	localLandingsMu sync.Mutex
+	localLandings   = make(map[string]localLanding)
+)
+
+type localLanding struct {
+	shortCode string
+	expires   time.Time
+}
+
+// Local landing tokens kept at most; expired ones, then any, make room beyond
+const maxLocalLandings = 100000
+
+// landingTarget adds a landing token for a redirect of shortCode to target,
+// leaving it unchanged when no token could be issued; the click then counts
+// as a bounce
+func landingTarget(c *gin.Context, shortCode, target string) string {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return target
+	}
+	encoded := hex.EncodeToString(token)
+	if err := cache.StoreLandingToken(encoded, shortCode); err != nil {
+		if cache.RedisClient != nil {
+			slog.DebugContext(c.Request.Context(), "Failed to store landing token, keeping it in memory", "short_code", shortCode, "error", err)
+		}
+		storeLocalLanding(encoded, shortCode, time.Now())
+	}
+	return utils.AddQueryDefaults(target, url.Values{landingParam: {encoded}}.Encode())
+}
+
+func storeLocalLanding(token, shortCode string, now time.Time) {
+	localLandingsMu.Lock()
+	defer localLandingsMu.Unlock()
+	if len(localLandings) >= maxLocalLandings {
+		for key, landing := range localLandings {
+			if landing.expires.Before(now) {
+				delete(localLandings, key)
+			}
+		}
+		// Still full of live tokens: make room at random
+		for key := range localLandings {
+			if len(localLandings) < maxLocalLandings {
+				break
+			}
+			delete(localLandings, key)
+		}
+	}
+	localLandings[token] = localLanding{shortCode: shortCode, expires: now.Add(cache.LandingTokenTTL)}
+}
+
+func takeLocalLanding(token string, now time.Time) (string, bool) {
+	localLandingsMu.Lock()
+	defer localLandingsMu.Unlock()
+	landing, ok := localLandings[token]
+	delete(localLandings, token)
+	return landing.shortCode, ok && now.Before(landing.expires)
+}
+
+// takeLanding returns the short code a landing token was issued for, once.
+// Tokens unknown to Redis may have been issued during an outage.
+func takeLanding(token string) (string, bool) {
+	if shortCode, err := cache.TakeLandingToken(token); err == nil {
+		return shortCode, true
+	}
+	return takeLocalLanding(token, time.Now())
+}
+
+// TrackLanding godoc
+// @Summary Report a landing
+// @ID trackLanding
+// @Description Report that a visitor redirected by a link with track_landings loaded the destination, telling landings from bounces in its stats. /js/track.js on the destination calls this with the sl_land token the redirect added, sent as the request body or the t query parameter; GET with t is accepted too, for browsers without beacons. Each token counts once, within 30 minutes of its redirect; unknown and used tokens are ignored.
+// @Tags URL Shortener
+// @Accept plain
+// @Param t query string false "Landing token, when not sent as the body"
+// @Success 204 "Landing recorded or ignored"
+// @Router /px/land [post]
+func TrackLanding(c *gin.Context) {
+	token := c.Query("t")
+	if token == "" && c.Request.Method == http.MethodPost {
+		body, _ := io.ReadAll(io.LimitReader(c.Request.Body, 64))
+		token = strings.TrimSpace(string(body))
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Access-Control-Allow-Origin", "*")
+	if token == "" {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	if shortCode, ok := takeLanding(token); ok {
+		err := database.DB.WithContext(c.Request.Context()).Model(&models.URL{}).
+			Where("short_code = ? AND track_landings", shortCode).
+			Update("landings", gorm.Expr("landings + ?", 1)).Error
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to record landing", "short_code", shortCode, "error", err)
+		}
+		cache.InvalidateStats(shortCode)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// landingScript reports the landing token of the page's address once the
+// page has loaded, preferring a beacon that survives the visitor leaving,
+// and drops the token from the address bar without reloading, so it is
+// neither bookmarked nor shared. The endpoint is found relative to the
+// script's own address.
+const landingScript = `(function () {
+  var params = new URLSearchParams(location.search);
+  var token = params.get("` + landingParam + `");
+  if (!token) return;
+  var script = document.currentScript;
+  var endpoint = new URL("/px/land", script && script.src ? script.src : location.href).href;
+
+  params.delete("` + landingParam + `");
+  var search = params.toString();
+  if (history.replaceState) {
+    history.replaceState(history.state, "", location.pathname + (search ? "?" + search : "") + location.hash);
+  }
+
+  function report() {
+    if (!(navigator.sendBeacon && navigator.sendBeacon(endpoint, token))) {
+      new Image().src = endpoint + "?t=" + encodeURIComponent(token);
+    }
+  }
+  if (document.readyState === "complete") {
+    report();
+  } else {
+    addEventListener("load", report);
+  }
+})();
+`
+
+// LandingScript godoc
+// @Summary Landing tracking script
+// @ID getLandingScript
+// @Description JavaScript for destinations of links with track_landings to embed with <script src="https://sho.rt/js/track.js" async></script>. Once the page has loaded it reports the sl_land token added by the redirect to /px/land and removes it from the address bar without reloading; pages opened without a token report nothing.
+// @Tags URL Shortener
+// @Produce application/javascript
+// @Success 200 {string} string "The script"
+// @Router /js/track.js [get]
+func LandingScript(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, "application/javascript; charset=utf-8", []byte(landingScript))
+}
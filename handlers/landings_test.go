@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLandingTokensWithoutRedis(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/abc123", nil)
+
+	target := landingTarget(c, "abc123", "https://example.com/landing?a=1")
+	parsed, err := url.Parse(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := parsed.Query().Get(landingParam)
+	if len(token) != 32 || parsed.Query().Get("a") != "1" {
+		t.Fatalf("target = %q, want the destination with a landing token", target)
+	}
+
+	if shortCode, ok := takeLanding(token); !ok || shortCode != "abc123" {
+		t.Errorf("takeLanding = %q, %v, want abc123", shortCode, ok)
+	}
+	if _, ok := takeLanding(token); ok {
+		t.Error("a landing token was taken twice")
+	}
+	if _, ok := takeLanding("unknown"); ok {
+		t.Error("an unknown landing token was taken")
+	}
+}
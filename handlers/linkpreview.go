@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"url-shortener/background"
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/i18n"
+	"url-shortener/models"
+	"url-shortener/pagemeta"
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/singleflight"
+)
+
+// Destination pages are fetched again once their metadata is this old, and
+// after a failed fetch once this much time has passed
+const (
+	pageMetadataMaxAge     = 7 * 24 * time.Hour
+	pageMetadataRetryAfter = time.Hour
+	pageMetadataTimeout    = 10 * time.Second
+)
+
+// Deduplicates fetches of a destination page viewed by many at once
+var pageMetadataGroup singleflight.Group
+
+var linkPreviewTemplate = template.Must(template.New("link-preview").Parse(`<!DOCTYPE html>
+<html lang="{{.Locale}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Heading}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 36rem; margin: 10vh auto; padding: 0 1rem; color: #222; }
+h1 { font-size: 1.5rem; }
+blockquote { margin: 1rem 0; padding-left: 1rem; border-left: 3px solid #ddd; }
+dt { color: #666; font-size: 0.875rem; margin-top: 0.75rem; }
+dd { margin: 0; word-break: break-all; }
+.continue { display: inline-block; margin-top: 2rem; padding: 0.6rem 1.2rem; background: #2563eb; color: #fff; text-decoration: none; border-radius: 0.375rem; }
+footer { margin-top: 3rem; color: #888; font-size: 0.875rem; }
+</style>
+</head>
+<body>
+<h1>{{.Heading}}</h1>
+{{if or .PageTitle .PageDescription}}<blockquote>
+{{if .PageTitle}}<strong>{{.PageTitle}}</strong>{{end}}
+{{if .PageDescription}}<p>{{.PageDescription}}</p>{{end}}
+</blockquote>{{end}}
+<dl>
+<dt>{{.Labels.Destination}}</dt>
+<dd>{{.Destination}}</dd>
+{{if .Split}}<dd><small>{{.Labels.Split}}</small></dd>{{end}}
+<dt>{{.Labels.Created}}</dt>
+<dd>{{.Created}}</dd>
+{{if .Expires}}<dt>{{.Labels.Expires}}</dt>
+<dd>{{.Expires}}</dd>{{end}}
+<dt>{{.Labels.Clicks}}</dt>
+<dd>{{.Clicks}}</dd>
+</dl>
+{{if .Followable}}<a class="continue" href="{{.Destination}}" rel="noopener noreferrer">{{.Labels.Continue}}</a>{{end}}
+<footer>{{.Footer}}</footer>
+</body>
+</html>
+`))
+
+// wantsLinkPreview reports whether the visitor asked to inspect the link
+// before following it, with ?preview=1. The other way, appending + to the
+// short code, is recognized by RedirectURL.
+func wantsLinkPreview(c *gin.Context) bool {
+	return c.Query("preview") == "1"
+}
+
+// IsLinkPreview reports whether a request to a short link asks for its
+// preview page, which is the same for every visitor speaking the language
+func IsLinkPreview(c *gin.Context) bool {
+	plus := strings.HasSuffix(c.Param("shortCode"), "+")
+	return (plus || wantsLinkPreview(c)) && !wantsLinkInfo(c)
+}
+
+// serveLinkPreview shows browsers where the link leads, with the title and
+// description of the destination page, when the link was created and how
+// often it was clicked. It is not counted as a click.
+func serveLinkPreview(c *gin.Context, shortCode string, entry *cache.RedirectEntry) {
+	stats, err := service.Default().Stats(c.Request.Context(), shortCode, service.DefaultStatsMaxAge)
+	if err != nil {
+		c.Error(models.ErrLinkNotFound)
+		return
+	}
+	metadata := loadPageMetadata(c.Request.Context(), shortCode, entry.Destination)
+
+	locale := i18n.Negotiate(c.GetHeader("Accept-Language"))
+	page := gin.H{
+		"Locale":  locale,
+		"Heading": i18n.T(locale, "preview.title"),
+		"Footer":  i18n.T(locale, "footer"),
+		"Labels": gin.H{
+			"Destination": i18n.T(locale, "preview.destination"),
+			"Created":     i18n.T(locale, "preview.created"),
+			"Expires":     i18n.T(locale, "preview.expires"),
+			"Clicks":      i18n.T(locale, "preview.clicks"),
+			"Split":       i18n.T(locale, "preview.split"),
+			"Continue":    i18n.T(locale, "preview.continue"),
+		},
+		"PageTitle":       metadata.Title,
+		"PageDescription": metadata.Description,
+		"Destination":     entry.Target(entry.Destination),
+		"Followable":      isWebURL(entry.Destination),
+		"Split":           entry.Has(cache.RedirectVariants),
+		"Created":         stats.CreatedAt.UTC().Format(time.RFC3339),
+		"Clicks":          stats.ClickCount,
+	}
+	if stats.ExpiresAt != nil {
+		page["Expires"] = stats.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Content-Language", locale)
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	linkPreviewTemplate.Execute(c.Writer, page)
+}
+
+// loadPageMetadata returns what the destination page says about itself,
+// from the cache or the link's row. Missing or stale metadata is fetched in
+// the background, so the first preview of a link may show none.
+func loadPageMetadata(ctx context.Context, shortCode, destination string) *cache.PageMetadata {
+	metadata, err := cache.GetPageMetadata(shortCode)
+	if err != nil {
+		metadata = &cache.PageMetadata{}
+		var urlRecord models.URL
+		err := database.DB.WithContext(ctx).Select("page_title", "page_description", "page_fetched_at").
+			Where("short_code = ?", shortCode).First(&urlRecord).Error
+		if err == nil {
+			metadata.Title, metadata.Description = urlRecord.PageTitle, urlRecord.PageDescription
+			if urlRecord.PageFetchedAt != nil {
+				metadata.FetchedAt = urlRecord.PageFetchedAt.Unix()
+			}
+		}
+		cache.CachePageMetadata(shortCode, metadata, cache.DefaultCacheTTL)
+	}
+
+	if isWebURL(destination) && time.Since(time.Unix(metadata.FetchedAt, 0)) > pageMetadataMaxAge {
+		previous := *metadata
+		ctx := context.WithoutCancel(ctx)
+		background.Go(func() { refreshPageMetadata(ctx, shortCode, destination, previous) })
+	}
+	return metadata
+}
+
+// refreshPageMetadata fetches the destination page and stores its metadata
+// on the link. Failures keep the previous metadata and are cached for a
+// while so that previews don't keep fetching a page that is down.
+func refreshPageMetadata(ctx context.Context, shortCode, destination string, previous cache.PageMetadata) {
+	pageMetadataGroup.Do(shortCode, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(database.WithRoute(ctx, "link_preview"), pageMetadataTimeout)
+		defer cancel()
+
+		fetched, err := pagemeta.Fetch(ctx, destination)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to fetch the page of a link for its preview", "short_code", shortCode, "error", err)
+			previous.FetchedAt = time.Now().Add(pageMetadataRetryAfter - pageMetadataMaxAge).Unix()
+			cache.CachePageMetadata(shortCode, &previous, pageMetadataRetryAfter)
+			return nil, err
+		}
+
+		// Not an edit, so updated_at is kept for the archiver
+		now := time.Now()
+		err = database.DB.WithContext(ctx).Model(&models.URL{}).Where("short_code = ?", shortCode).UpdateColumns(map[string]interface{}{
+			"page_title":       fetched.Title,
+			"page_description": fetched.Description,
+			"page_fetched_at":  now,
+		}).Error
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to store the page metadata of a link", "short_code", shortCode, "error", err)
+		}
+		metadata := &cache.PageMetadata{Title: fetched.Title, Description: fetched.Description, FetchedAt: now.Unix()}
+		cache.CachePageMetadata(shortCode, metadata, cache.DefaultCacheTTL)
+		return nil, nil
+	})
+}
+
+// isWebURL reports whether rawURL is an http(s) URL, the only kind fetched
+// or linked to from preview pages
+func isWebURL(rawURL string) bool {
+	rawURL = strings.ToLower(rawURL)
+	return strings.HasPrefix(rawURL, "http://") || strings.HasPrefix(rawURL, "https://")
+}
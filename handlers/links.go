@@ -0,0 +1,591 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"url-shortener/background"
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/domains"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Page size bounds for listing owned links
+const (
+	defaultLinkPageSize = 50
+	maxLinkPageSize     = 200
+)
+
+// ListLinks godoc
+// @Summary List your links
+// @ID listLinks
+// @Description List the links created with API keys assigned to the caller's user, newest first
+// @Tags Links
+// @Produce json
+// @Param limit query int false "Links per page (default 50, max 200)"
+// @Param offset query int false "Links to skip"
+// @Param created_after query string false "Only links created at or after this RFC 3339 time"
+// @Param created_before query string false "Only links created before this RFC 3339 time"
+// @Param expired query bool false "Only expired links (true) or links not expired (false)"
+// @Param domain query string false "Only links to this destination host or its subdomains; unavailable while destinations are encrypted"
+// @Param environment query string false "Only production or staging links" Enums(production, staging)
+// @Param fields query string false "Comma-separated list of fields to return for each link (e.g. short_code,original_url)"
+// @Success 200 {array} models.URL
+// @Failure 400 {object} models.ErrorResponse "Invalid filter"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links [get]
+func ListLinks(c *gin.Context) {
+	filter, ok := parseLinkFilter(c)
+	if !ok {
+		return
+	}
+	listLinks(c, filter, database.DB.Where("owner_id = ?", *middleware.CurrentOwnerID(c)))
+}
+
+// UpdateLink godoc
+// @Summary Update one of your links
+// @ID updateLink
+// @Description Change the destination, short code, expiry, tags, noindex setting, UTM parameters, redirect type, routing rules, rollout or velocity limit of a link owned by the caller. Raising rollout_percent ramps up a soft launch, and 100 launches the link fully. A velocity_limit with 0 clicks removes the limit. A new destination, and the URLs routing rules redirect to, pass the same checks as POST /shorten and may put the link back into review. A renamed link's old short code keeps resolving for ALIAS_RENAME_GRACE (see GET /links/{shortCode}/aliases). Locked links cannot be updated. Every edit raises the link's revision, returned in the ETag header and the revision field; sending it back in If-Match applies the edit only if nobody changed the link since, answering 412 otherwise.
+// @Tags Links
+// @Accept json
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param If-Match header string false "ETag of the revision the edit is based on, e.g. \"3\""
+// @Param request body models.UpdateLinkRequest true "Fields to change"
+// @Success 200 {object} models.URL
+// @Header 200 {string} ETag "Revision of the updated link"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope, or the link is locked"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 409 {object} models.ErrorResponse "Custom alias is already taken"
+// @Failure 412 {object} models.ErrorResponse "If-Match does not match the current revision"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode} [put]
+func UpdateLink(c *gin.Context) {
+	var request models.UpdateLinkRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	if request.ExpiresIn != nil && !checkExpiryAllowed(c, *request.ExpiresIn) {
+		return
+	}
+	if !checkRenameAllowed(c, request) {
+		return
+	}
+
+	urlRecord, ok := ownedLink(c)
+	if !ok {
+		return
+	}
+	if updateLink(c, urlRecord, request) {
+		c.JSON(http.StatusOK, urlRecord)
+	}
+}
+
+// DeleteLink godoc
+// @Summary Delete one of your links
+// @ID deleteLink
+// @Description Delete a link owned by the caller so it stops redirecting. Its short code is not reused. Locked links cannot be deleted.
+// @Tags Links
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 204 "Link deleted"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the delete scope, or the link is locked"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode} [delete]
+func DeleteLink(c *gin.Context) {
+	urlRecord, ok := ownedLink(c)
+	if !ok {
+		return
+	}
+	if deleteLink(c, urlRecord) {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// ListURLs godoc
+// @Summary List all links
+// @ID listURLs
+// @Description List every link regardless of owner, newest first so the first page shows the links created most recently, with the same filters as GET /links
+// @Tags Admin
+// @Produce json
+// @Param limit query int false "Links per page (default 50, max 200)"
+// @Param offset query int false "Links to skip"
+// @Param created_after query string false "Only links created at or after this RFC 3339 time"
+// @Param created_before query string false "Only links created before this RFC 3339 time"
+// @Param expired query bool false "Only expired links (true) or links not expired (false)"
+// @Param domain query string false "Only links to this destination host or its subdomains; unavailable while destinations are encrypted"
+// @Param environment query string false "Only production or staging links" Enums(production, staging)
+// @Param fields query string false "Comma-separated list of fields to return for each link (e.g. short_code,original_url)"
+// @Success 200 {array} models.URL
+// @Failure 400 {object} models.ErrorResponse "Invalid filter"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/urls [get]
+func ListURLs(c *gin.Context) {
+	filter, ok := parseLinkFilter(c)
+	if !ok {
+		return
+	}
+	listLinks(c, filter, database.DB)
+}
+
+// UpdateURL godoc
+// @Summary Update any link
+// @ID updateURL
+// @Description Change the destination, short code, expiry, tags, noindex setting, UTM parameters, redirect type, routing rules, rollout or velocity limit of any link, including anonymous ones. Same rules as PUT /links/{shortCode}; the action is audit-logged.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param If-Match header string false "ETag of the revision the edit is based on, e.g. \"3\""
+// @Param request body models.UpdateLinkRequest true "Fields to change"
+// @Success 200 {object} models.URL
+// @Header 200 {string} ETag "Revision of the updated link"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 403 {object} models.ErrorResponse "Short URL is locked"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 409 {object} models.ErrorResponse "Custom alias is already taken"
+// @Failure 412 {object} models.ErrorResponse "If-Match does not match the current revision"
+// @Security AdminAuth
+// @Router /admin/urls/{shortCode} [put]
+func UpdateURL(c *gin.Context) {
+	var request models.UpdateLinkRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	if request.ExpiresIn != nil && !checkExpiryAllowed(c, *request.ExpiresIn) {
+		return
+	}
+	if !checkRenameAllowed(c, request) {
+		return
+	}
+
+	urlRecord, ok := unlockedLink(c, database.DB.Where("short_code = ?", pathLinkKey(c)))
+	if !ok {
+		return
+	}
+	if updateLink(c, urlRecord, request) {
+		recordAudit(c, models.AuditActionUpdate, urlRecord.ShortCode, "")
+		c.JSON(http.StatusOK, urlRecord)
+	}
+}
+
+// DeleteURL godoc
+// @Summary Delete any link
+// @ID deleteURL
+// @Description Delete any link, including anonymous ones, so it stops redirecting. Its short code is not reused. Locked links must be unlocked first; the action is audit-logged.
+// @Tags Admin
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 204 "Link deleted"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 403 {object} models.ErrorResponse "Short URL is locked"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Security AdminAuth
+// @Router /admin/urls/{shortCode} [delete]
+func DeleteURL(c *gin.Context) {
+	urlRecord, ok := unlockedLink(c, database.DB.Where("short_code = ?", pathLinkKey(c)))
+	if !ok {
+		return
+	}
+	if deleteLink(c, urlRecord) {
+		recordAudit(c, models.AuditActionDelete, urlRecord.ShortCode, urlRecord.OriginalURL)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// linkFilter narrows a listing of links
+type linkFilter struct {
+	limit         int
+	offset        int
+	createdAfter  *time.Time
+	createdBefore *time.Time
+	expired       *bool
+	domain        string
+	environment   string
+}
+
+// parseLinkFilter reads the pagination and filter query parameters, writing
+// the error response when one is invalid
+func parseLinkFilter(c *gin.Context) (linkFilter, bool) {
+	var filter linkFilter
+	var err error
+	filter.limit, err = queryInt(c, "limit", defaultLinkPageSize)
+	if err != nil || filter.limit < 1 || filter.limit > maxLinkPageSize {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "limit must be between 1 and 200"))
+		return filter, false
+	}
+	filter.offset, err = queryInt(c, "offset", 0)
+	if err != nil || filter.offset < 0 {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "offset must be a non-negative integer"))
+		return filter, false
+	}
+
+	for name, target := range map[string]**time.Time{"created_after": &filter.createdAfter, "created_before": &filter.createdBefore} {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		value, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, name+" must be an RFC 3339 time"))
+			return filter, false
+		}
+		*target = &value
+	}
+
+	if raw := c.Query("expired"); raw != "" {
+		expired, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "expired must be true or false"))
+			return filter, false
+		}
+		filter.expired = &expired
+	}
+
+	if raw := c.Query("domain"); raw != "" {
+		domain, ok := parseDomainFilter(c, raw)
+		if !ok {
+			return filter, false
+		}
+		filter.domain = domain
+	}
+
+	if filter.environment = c.Query("environment"); filter.environment != "" &&
+		filter.environment != models.EnvironmentProduction && filter.environment != models.EnvironmentStaging {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "environment must be production or staging"))
+		return filter, false
+	}
+	return filter, true
+}
+
+// listLinks writes the page of links matching query and filter, newest
+// first, with the fields requested by the `fields` query parameter
+func listLinks(c *gin.Context, filter linkFilter, query *gorm.DB) {
+	query = filterLinks(query.WithContext(c.Request.Context()), filter)
+
+	links := []models.URL{}
+	err := query.Order("created_at desc, id desc").Limit(filter.limit).Offset(filter.offset).Find(&links).Error
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list links"))
+		return
+	}
+
+	respondWithFields(c, http.StatusOK, links)
+}
+
+// filterLinks narrows query to the links matching filter, leaving out
+// pagination
+func filterLinks(query *gorm.DB, filter linkFilter) *gorm.DB {
+	if filter.createdAfter != nil {
+		query = query.Where("created_at >= ?", *filter.createdAfter)
+	}
+	if filter.createdBefore != nil {
+		query = query.Where("created_at < ?", *filter.createdBefore)
+	}
+	if filter.expired != nil {
+		now := time.Now()
+		if *filter.expired {
+			query = query.Where("expires_at IS NOT NULL AND expires_at <= ?", now)
+		} else {
+			query = query.Where("expires_at IS NULL OR expires_at > ?", now)
+		}
+	}
+
+	if filter.domain != "" {
+		query = database.WhereDestinationDomain(query, filter.domain)
+	}
+	if filter.environment != "" {
+		query = query.Where("environment = ?", filter.environment)
+	}
+	return query
+}
+
+// updateLink applies the fields set in request to urlRecord and invalidates
+// its cached mappings, writing the error response and returning false when
+// the update is refused or fails
+func updateLink(c *gin.Context, urlRecord *models.URL, request models.UpdateLinkRequest) bool {
+	// Edits of a stale copy of the link would silently undo someone else's
+	conditional, ok := checkIfMatch(c, urlRecord)
+	if !ok {
+		return false
+	}
+	if request.Tags != nil {
+		if apiErr := service.CheckTags(requestCaller(c), *request.Tags); apiErr != nil {
+			c.Error(apiErr)
+			return false
+		}
+	}
+
+	renamed := false
+	if request.CustomAlias != nil && models.LinkKey(urlRecord.ShortHost(), *request.CustomAlias) != urlRecord.ShortCode {
+		if apiErr := service.CheckFeatureAllowed(c.Request.Context(), requestCaller(c), "custom_alias"); apiErr != nil {
+			c.Error(apiErr)
+			return false
+		}
+		if !renameLink(c, urlRecord, *request.CustomAlias) {
+			return false
+		}
+		renamed = true
+	}
+
+	var columns []string
+	held := false
+	previous := *urlRecord
+	previousURL := urlRecord.OriginalURL
+	if request.URL != nil && *request.URL != urlRecord.OriginalURL {
+		safetyAction, ok := checkShortenAllowed(c, *request.URL, "")
+		if !ok {
+			return false
+		}
+		urlRecord.OriginalURL = *request.URL
+		urlRecord.OriginalURLHash = nil
+		columns = append(columns, "original_url", "original_url_hash")
+
+		// The preview page describes the new destination once fetched
+		urlRecord.PageTitle, urlRecord.PageDescription, urlRecord.PageFetchedAt = "", "", nil
+		columns = append(columns, "page_title", "page_description", "page_fetched_at")
+
+		// A new destination is reviewed like a new link
+		if service.RequiresApproval(c.Request.Context(), requestCaller(c)) && !domains.SkipsApproval(*request.URL) ||
+			safetyAction == models.SafetyActionReview || service.HeldForAbuse(c.Request.Context(), requestCaller(c)) {
+			urlRecord.Status = models.StatusPending
+			held = true
+			columns = append(columns, "status")
+		}
+	}
+	if request.RoutingRules != nil {
+		safetyAction, ok := checkRoutingAllowed(c, *request.RoutingRules, "")
+		if !ok {
+			return false
+		}
+		urlRecord.RoutingRules = *request.RoutingRules
+		columns = append(columns, "routing_rules")
+
+		// Rules sending visitors to a destination under review hold the link
+		if safetyAction == models.SafetyActionReview && !held {
+			urlRecord.Status = models.StatusPending
+			held = true
+			columns = append(columns, "status")
+		}
+	}
+	if request.VelocityLimit != nil {
+		safetyAction, apiErr := service.CheckVelocityLimit(c.Request.Context(), requestCaller(c), request.VelocityLimit, "")
+		if apiErr != nil {
+			c.Error(apiErr)
+			return false
+		}
+		urlRecord.VelocityLimit = service.VelocityLimit(request.VelocityLimit)
+		columns = append(columns, "velocity_limit")
+
+		// An overflow_url under review holds the link
+		if safetyAction == models.SafetyActionReview && !held {
+			urlRecord.Status = models.StatusPending
+			held = true
+			columns = append(columns, "status")
+		}
+
+		// Shortening the destination again must not return a limited link
+		if urlRecord.VelocityLimit != nil && urlRecord.OriginalURLHash != nil {
+			urlRecord.OriginalURLHash = nil
+			columns = append(columns, "original_url_hash")
+		}
+	}
+	if request.ExpiresIn != nil {
+		urlRecord.ExpiresAt = service.LinkExpiry.ExpiresAt(*request.ExpiresIn, time.Now())
+		columns = append(columns, "expires_at")
+	}
+	if request.Tags != nil {
+		urlRecord.Tags = *request.Tags
+		columns = append(columns, "tags")
+	}
+	if request.NoIndex != nil {
+		urlRecord.NoIndex = *request.NoIndex
+		columns = append(columns, "no_index")
+	}
+	if request.UTMSource != nil {
+		urlRecord.UTMSource = *request.UTMSource
+		columns = append(columns, "utm_source")
+	}
+	if request.UTMMedium != nil {
+		urlRecord.UTMMedium = *request.UTMMedium
+		columns = append(columns, "utm_medium")
+	}
+	if request.UTMCampaign != nil {
+		urlRecord.UTMCampaign = *request.UTMCampaign
+		columns = append(columns, "utm_campaign")
+	}
+	if request.RedirectType != nil {
+		urlRecord.RedirectType = *request.RedirectType
+		columns = append(columns, "redirect_type")
+	}
+	if request.RolloutPercent != nil {
+		urlRecord.RolloutPercent = service.RolloutPercent(request.RolloutPercent)
+		columns = append(columns, "rollout_percent")
+
+		// Shortening the destination again must not return a soft launched link
+		if urlRecord.RolloutPercent != nil && urlRecord.OriginalURLHash != nil {
+			urlRecord.OriginalURLHash = nil
+			columns = append(columns, "original_url_hash")
+		}
+	}
+	if request.TrackLandings != nil && *request.TrackLandings != urlRecord.TrackLandings {
+		if apiErr := service.CheckTrackLandings(*request.TrackLandings, urlRecord.AnalyticsMode()); apiErr != nil {
+			c.Error(apiErr)
+			return false
+		}
+		// Landings count from zero against the clicks from now on
+		urlRecord.TrackLandings = *request.TrackLandings
+		urlRecord.Landings, urlRecord.LandingBaseClicks = 0, urlRecord.ClickCount
+		columns = append(columns, "track_landings", "landings", "landing_base_clicks")
+
+		// Shortening the destination again must not return a tracked link
+		if urlRecord.TrackLandings && urlRecord.OriginalURLHash != nil {
+			urlRecord.OriginalURLHash = nil
+			columns = append(columns, "original_url_hash")
+		}
+	}
+	if request.RedirectHeaders != nil {
+		if apiErr := service.CheckRedirectHeaders(*request.RedirectHeaders); apiErr != nil {
+			c.Error(apiErr)
+			return false
+		}
+		urlRecord.RedirectHeaders = service.RedirectHeaders(*request.RedirectHeaders)
+		columns = append(columns, "redirect_headers")
+
+		// Shortening the destination again must not return a link with headers
+		if urlRecord.RedirectHeaders != nil && urlRecord.OriginalURLHash != nil {
+			urlRecord.OriginalURLHash = nil
+			columns = append(columns, "original_url_hash")
+		}
+	}
+	if request.AttributionWindowHours != nil {
+		if apiErr := service.CheckAttributionWindow(*request.AttributionWindowHours, urlRecord.VariantMode != ""); apiErr != nil {
+			c.Error(apiErr)
+			return false
+		}
+		urlRecord.AttributionWindowHours = *request.AttributionWindowHours
+		columns = append(columns, "attribution_window_hours")
+	}
+	// A rename alone is an edit too, raising the revision
+	if len(columns) == 0 && !renamed {
+		c.Header("ETag", linkETag(urlRecord))
+		return true
+	}
+
+	// Go through the model so the destination is encrypted and tags
+	// serialized. Conditional edits only apply to the revision they were
+	// checked against. Changes to where clicks go record a new version.
+	err := database.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		expected := previous.Revision
+		if !conditional {
+			// Others apply to whichever revision is current
+			err := tx.Model(&models.URL{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ?", urlRecord.ID).Pluck("revision", &expected).Error
+			if err != nil {
+				return err
+			}
+		}
+		urlRecord.Revision = expected + 1
+		result := tx.Model(urlRecord).Select(append(columns, "revision")).Where("revision = ?", expected).Updates(urlRecord)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errEditedConcurrently
+		}
+		if reflect.DeepEqual(previous.Snapshot(), urlRecord.Snapshot()) {
+			return nil
+		}
+		return database.RecordLinkVersion(tx, &previous, urlRecord)
+	})
+	if errors.Is(err, errEditedConcurrently) {
+		c.Error(errRevisionMismatch)
+		return false
+	}
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update link"))
+		return false
+	}
+	c.Header("ETag", linkETag(urlRecord))
+
+	cache.InvalidateCache(urlRecord.ShortCode)
+	if urlRecord.OriginalURL != previousURL || previous.OriginalURLHash != nil && urlRecord.OriginalURLHash == nil {
+		cache.InvalidateOriginalURLMapping(urlRecord.OwnerID, previousURL)
+	}
+	if held && !urlRecord.Inert {
+		background.Go(func() { service.NotifyApprovers(context.WithoutCancel(c.Request.Context()), urlRecord) })
+	}
+	fireLinkHook(c, models.HookLinkUpdated, urlRecord)
+	return true
+}
+
+// deleteLink removes urlRecord and its cached mappings, writing the error
+// response and returning false when the deletion fails
+func deleteLink(c *gin.Context, urlRecord *models.URL) bool {
+	if err := database.DB.WithContext(c.Request.Context()).Delete(urlRecord).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete link"))
+		return false
+	}
+
+	cache.InvalidateCache(urlRecord.ShortCode)
+	cache.InvalidateOriginalURLMapping(urlRecord.OwnerID, urlRecord.OriginalURL)
+	fireLinkHook(c, models.HookLinkDeleted, urlRecord)
+	return true
+}
+
+// ownedLink loads the link in the path if the caller owns it and it is not
+// locked, writing the error response otherwise. Links of other users are
+// reported as not found.
+func ownedLink(c *gin.Context) (*models.URL, bool) {
+	return unlockedLink(c, database.DB.Where("short_code = ? AND owner_id = ?", pathLinkKey(c), *middleware.CurrentOwnerID(c)))
+}
+
+// unlockedLink loads the link matching query if it is not locked, writing
+// the error response otherwise
+func unlockedLink(c *gin.Context, query *gorm.DB) (*models.URL, bool) {
+	var urlRecord models.URL
+	if err := query.WithContext(c.Request.Context()).First(&urlRecord).Error; err != nil {
+		c.Error(models.ErrLinkNotFound)
+		return nil, false
+	}
+	if urlRecord.Locked {
+		c.Error(models.NewAPIError(http.StatusForbidden, models.ErrCodeForbidden, "Short URL is locked"))
+		return nil, false
+	}
+	return &urlRecord, true
+}
+
+// queryInt reads an optional integer query parameter
+func queryInt(c *gin.Context, name string, fallback int) (int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(raw)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+
+	"url-shortener/cache"
+	"url-shortener/domains"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Short links pointing at short links are followed at most this many hops
+// when looking for a loop; longer chains are treated as loops too
+const maxShortLinkHops = 5
+
+// serviceHosts returns the host names short links are served on: the
+// request's host, the host of BASE_URL, SMS_DOMAIN and the comma-separated
+// SHORT_LINK_HOSTS
+func serviceHosts(c *gin.Context) map[string]bool {
+	hosts := make(map[string]bool)
+	candidates := append([]string{requestHost(c), os.Getenv("SMS_DOMAIN")}, strings.Split(os.Getenv("SHORT_LINK_HOSTS"), ",")...)
+	if baseURL, err := url.Parse(baseURL); err == nil {
+		candidates = append(candidates, baseURL.Host)
+	}
+	for _, candidate := range candidates {
+		candidate = strings.ToLower(strings.TrimSpace(candidate))
+		if candidate == "" {
+			continue
+		}
+		// Ports are ignored, so http and https addresses of a host match
+		if parsed, err := url.Parse("//" + candidate); err == nil && parsed.Hostname() != "" {
+			candidate = parsed.Hostname()
+		}
+		hosts[candidate] = true
+	}
+	return hosts
+}
+
+// internalShortCode returns the key of the link a destination points to
+// when it is a short link served by this service, on one of hosts or a
+// branded domain
+func internalShortCode(destination string, hosts map[string]bool) (string, bool) {
+	parsed, err := url.Parse(destination)
+	if err != nil {
+		return "", false
+	}
+	domain := domains.ShortDomain(parsed.Hostname())
+	if domain == nil && !hosts[strings.ToLower(parsed.Hostname())] {
+		return "", false
+	}
+	code := strings.TrimSuffix(strings.TrimPrefix(parsed.Path, "/"), "/")
+	if code == "" || strings.Contains(code, "/") {
+		return "", false
+	}
+	if domain != nil {
+		return models.LinkKey(domain.Host, code), true
+	}
+	return code, true
+}
+
+// followsIntoLoop reports whether following destination from the link
+// shortCode through other short links of the service comes back to a link
+// already visited, or takes more than maxShortLinkHops. lookup returns the
+// destination of a short code, or false when it cannot be followed.
+func followsIntoLoop(shortCode, destination string, hosts map[string]bool, lookup func(string) (string, bool)) bool {
+	visited := map[string]bool{shortCode: true}
+	for hops := 0; hops < maxShortLinkHops; hops++ {
+		next, ok := internalShortCode(destination, hosts)
+		if !ok {
+			return false
+		}
+		if visited[next] {
+			return true
+		}
+		visited[next] = true
+
+		if destination, ok = lookup(next); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// redirectLoops reports whether redirecting to entry would bounce visitors
+// between short links of this service without ever leaving it
+func (h *Handler) redirectLoops(c *gin.Context, shortCode string, entry *cache.RedirectEntry) bool {
+	hosts := serviceHosts(c)
+	if _, ok := internalShortCode(entry.Destination, hosts); !ok {
+		return false
+	}
+	return followsIntoLoop(shortCode, entry.Destination, hosts, h.redirectLookup(c.Request.Context()))
+}
+
+// destinationWarnings warns about destinations that are short links of this
+// service; the link is still created
+func (h *Handler) destinationWarnings(c *gin.Context, shortCode, destination string) []string {
+	hosts := serviceHosts(c)
+	if _, ok := internalShortCode(destination, hosts); !ok {
+		return nil
+	}
+	if followsIntoLoop(shortCode, destination, hosts, h.redirectLookup(c.Request.Context())) {
+		return []string{"The destination is a short link that redirects in a loop; visitors will get an error page"}
+	}
+	return []string{"The destination is a short link on this service; visitors take an extra redirect"}
+}
+
+func (h *Handler) redirectLookup(ctx context.Context) func(string) (string, bool) {
+	return func(shortCode string) (string, bool) {
+		entry, err := h.stores.LoadRedirectEntry(ctx, shortCode)
+		if err != nil {
+			return "", false
+		}
+		return entry.Destination, true
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import "testing"
+
+func TestFollowsIntoLoop(t *testing.T) {
+	hosts := map[string]bool{"sho.rt": true, "go.example.com": true}
+	links := map[string]string{
+		"a":     "https://sho.rt/b",
+		"b":     "http://go.example.com:8080/a/",
+		"c":     "https://sho.rt/d",
+		"d":     "https://example.com/landing",
+		"self":  "https://SHO.RT/self",
+		"deep1": "https://sho.rt/deep2",
+		"deep2": "https://sho.rt/deep3",
+		"deep3": "https://sho.rt/deep4",
+		"deep4": "https://sho.rt/deep5",
+		"deep5": "https://sho.rt/deep6",
+		"deep6": "https://example.com/",
+	}
+	lookup := func(code string) (string, bool) {
+		destination, ok := links[code]
+		return destination, ok
+	}
+
+	cases := map[string]bool{
+		"a":     true,
+		"c":     false,
+		"self":  true,
+		"deep1": true,
+		"deep3": false,
+	}
+	for code, want := range cases {
+		if got := followsIntoLoop(code, links[code], hosts, lookup); got != want {
+			t.Errorf("followsIntoLoop(%s) = %v, want %v", code, got, want)
+		}
+	}
+
+	// Missing links and other paths on the service end the chain
+	if followsIntoLoop("x", "https://sho.rt/missing", hosts, lookup) {
+		t.Error("a chain ending at a missing link is not a loop")
+	}
+	if followsIntoLoop("x", "https://sho.rt/admin/health", hosts, lookup) {
+		t.Error("a destination that is not a short link is not a loop")
+	}
+}
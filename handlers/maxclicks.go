@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"url-shortener/background"
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/storage"
+)
+
+// consumeClick uses up one of the remaining clicks of a link with
+// max_clicks, reporting false when none were left. Concurrent redirects
+// count down a Redis counter, seeded from the database, which follows in
+// the background; without Redis the database counts down on its own. The
+// last click expires the link, so later redirects answer 410 Gone anyway.
+func (h *Handler) consumeClick(ctx context.Context, shortCode string, entry *cache.RedirectEntry) bool {
+	remaining, err := h.stores.Cache.ConsumeRemainingClick(shortCode)
+	if errors.Is(err, storage.ErrNotFound) && h.seedRemainingClicks(ctx, shortCode) {
+		remaining, err = h.stores.Cache.ConsumeRemainingClick(shortCode)
+	}
+	if err != nil {
+		// The database decides, atomically, when Redis can't
+		remaining, err := h.stores.Links.ConsumeClick(ctx, entry.URLID)
+		if err != nil {
+			if !errors.Is(err, database.ErrNoClicksLeft) {
+				slog.ErrorContext(ctx, "Failed to use up a click", "short_code", shortCode, "error", err)
+			}
+			return false
+		}
+		if remaining == 0 {
+			h.stores.Cache.InvalidateCache(shortCode)
+		}
+		return true
+	}
+	if remaining < 0 {
+		return false
+	}
+
+	background.Go(func() {
+		ctx := database.WithRoute(context.WithoutCancel(ctx), clickRoute)
+		if _, err := h.stores.Links.ConsumeClick(ctx, entry.URLID); err != nil && !errors.Is(err, database.ErrNoClicksLeft) {
+			slog.ErrorContext(ctx, "Failed to store a used up click", "short_code", shortCode, "error", err)
+		}
+		// Cached entries learn that the link expired
+		if remaining == 0 {
+			h.stores.Cache.InvalidateCache(shortCode)
+		}
+	})
+	return true
+}
+
+// seedRemainingClicks caches the clicks a link has left from the database,
+// reporting whether the counter can be used
+func (h *Handler) seedRemainingClicks(ctx context.Context, shortCode string) bool {
+	urlRecord, err := h.stores.Links.GetByShortCode(ctx, shortCode)
+	if err != nil || urlRecord.ClicksRemaining == nil {
+		return false
+	}
+	return h.stores.Cache.SeedRemainingClicks(shortCode, *urlRecord.ClicksRemaining) == nil
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/mirror"
+	"url-shortener/models"
+	"url-shortener/service"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterMirrorShadows registers the built-in shadow resolvers:
+//   - database resolves redirects straight from the database, bypassing the
+//     cache, to verify cached redirect entries match their links
+func RegisterMirrorShadows() {
+	mirror.RegisterShadow("database", resolveFromDatabase)
+}
+
+func resolveFromDatabase(ctx context.Context, redirect models.MirroredRedirect) (mirror.Outcome, error) {
+	var urlRecord models.URL
+	if err := database.DB.WithContext(ctx).Where("short_code = ?", redirect.ShortCode).First(&urlRecord).Error; err != nil {
+		archived, archiveErr := database.FindArchivedURL(ctx, redirect.ShortCode)
+		if archiveErr != nil {
+			return mirror.Outcome{Status: http.StatusNotFound}, nil
+		}
+		urlRecord = *archived
+	}
+
+	entry := cache.NewRedirectEntry(&urlRecord)
+	if apiErr := service.UnavailableLinkError(entry, redirect.ObservedAt); apiErr != nil {
+		return mirror.Outcome{Status: apiErr.Status}, nil
+	}
+	if entry.Has(cache.RedirectPreview) && isPreviewCrawler(redirect.UserAgent) {
+		return mirror.Outcome{Status: http.StatusOK}, nil
+	}
+	return mirror.Outcome{Status: entry.StatusCode, DestinationHash: utils.HashURL(entry.Destination)}, nil
+}
+
+// GetMirrorStatus godoc
+// @Summary Traffic mirroring status
+// @ID getMirrorStatus
+// @Description Mirroring configuration and counters of this instance: redirects sampled, dropped, sent to the shadow backend and compared with the shadow resolver, including mismatches
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} models.MirrorStatus
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/mirror [get]
+func GetMirrorStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, mirror.Status())
+}
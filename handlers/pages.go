@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"html/template"
+	"strings"
+
+	"url-shortener/i18n"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Translation key prefixes of the pages shown for links that cannot be followed
+var linkPageKeys = map[models.ErrorCode]string{
+	models.ErrCodeLinkNotFound:    "not_found",
+	models.ErrCodeLinkExpired:     "expired",
+	models.ErrCodeLinkPending:     "pending",
+	models.ErrCodeLinkDisabled:    "disabled",
+	models.ErrCodeLinkLoop:        "loop",
+	models.ErrCodeLinkNotLaunched: "not_launched",
+	models.ErrCodeLinkBusy:        "busy",
+}
+
+var linkPageTemplate = template.Must(template.New("link-page").Parse(`<!DOCTYPE html>
+<html lang="{{.Locale}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+{{if .Refresh}}<meta http-equiv="refresh" content="{{.Refresh}}">
+{{end}}<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 32rem; margin: 15vh auto; padding: 0 1rem; color: #222; }
+h1 { font-size: 1.5rem; }
+footer { margin-top: 3rem; color: #888; font-size: 0.875rem; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+{{if .Suggestions}}<p>{{.SuggestionsLabel}}</p>
+<ul>{{range .Suggestions}}<li><a href="/{{.}}">{{.}}</a></li>{{end}}</ul>
+{{end}}<footer>{{.Footer}}</footer>
+</body>
+</html>
+`))
+
+// respondLinkError shows browsers a page in their language for a link that
+// cannot be followed, negotiated from Accept-Language. API clients, and
+// errors without a page, get the usual JSON error response.
+func respondLinkError(c *gin.Context, err *models.APIError) {
+	respondLinkPage(c, err, nil)
+}
+
+// respondLinkPage is respondLinkError, listing suggestions as links to
+// short codes of the same host the visitor may have meant. Pages with
+// suggestions are made for one visitor, so shared caches must not keep them.
+// Pages answered with Retry-After reload the link once it has passed.
+func respondLinkPage(c *gin.Context, err *models.APIError, suggestions []string) {
+	key, ok := linkPageKeys[err.Code]
+	if !ok || !strings.Contains(c.GetHeader("Accept"), "text/html") {
+		c.Error(err)
+		return
+	}
+
+	locale := i18n.Negotiate(c.GetHeader("Accept-Language"))
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Content-Language", locale)
+	c.Header("Vary", "Accept, Accept-Language")
+	if len(suggestions) > 0 {
+		c.Header("Cache-Control", "private, no-store")
+	}
+	c.Status(err.Status)
+	linkPageTemplate.Execute(c.Writer, gin.H{
+		"Locale":           locale,
+		"Title":            i18n.T(locale, key+".title"),
+		"Message":          i18n.T(locale, key+".message"),
+		"Refresh":          c.Writer.Header().Get("Retry-After"),
+		"Suggestions":      suggestions,
+		"SuggestionsLabel": i18n.T(locale, key+".suggestions"),
+		"Footer":           i18n.T(locale, "footer"),
+	})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRespondLinkErrorNegotiates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Errors())
+	router.GET("/:shortCode", func(c *gin.Context) { respondLinkError(c, models.ErrLinkExpired) })
+
+	cases := []struct {
+		name, accept, acceptLanguage string
+		contentType, language, body  string
+	}{
+		{"api client", "application/json", "fr", "application/json", "", `"code":"LINK_EXPIRED"`},
+		{"browser in French", "text/html,application/xhtml+xml,*/*;q=0.8", "fr-FR,fr;q=0.9", "text/html", "fr", "Lien expiré"},
+		{"browser in unsupported language", "text/html", "xx", "text/html", "en", "Link expired"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			request.Header.Set("Accept", tc.accept)
+			request.Header.Set("Accept-Language", tc.acceptLanguage)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != http.StatusGone {
+				t.Errorf("status = %d, want %d", recorder.Code, http.StatusGone)
+			}
+			if got := recorder.Header().Get("Content-Type"); !strings.HasPrefix(got, tc.contentType) {
+				t.Errorf("Content-Type = %q, want %s", got, tc.contentType)
+			}
+			if got := recorder.Header().Get("Content-Language"); got != tc.language {
+				t.Errorf("Content-Language = %q, want %q", got, tc.language)
+			}
+			if !strings.Contains(recorder.Body.String(), tc.body) {
+				t.Errorf("body %q does not contain %q", recorder.Body.String(), tc.body)
+			}
+		})
+	}
+}
+
+func TestRespondLinkPageListsSuggestions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Errors())
+	router.GET("/:shortCode", func(c *gin.Context) {
+		respondLinkPage(c, models.ErrLinkNotFound, []string{"abc124", "abc12"})
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	request.Header.Set("Accept", "text/html")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", recorder.Code, http.StatusNotFound)
+	}
+	if got := recorder.Header().Get("Cache-Control"); got != "private, no-store" {
+		t.Errorf("Cache-Control = %q, want private, no-store", got)
+	}
+	for _, want := range []string{"Did you mean:", `<a href="/abc124">abc124</a>`, `<a href="/abc12">abc12</a>`} {
+		if !strings.Contains(recorder.Body.String(), want) {
+			t.Errorf("body %q does not contain %q", recorder.Body.String(), want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// User agent fragments of crawlers that render link previews
+var previewCrawlers = []string{
+	"facebookexternalhit", "facebot", "twitterbot", "linkedinbot", "slackbot",
+	"discordbot", "whatsapp", "telegrambot", "pinterest", "redditbot",
+	"skypeuripreview", "vkshare", "embedly", "mastodon", "applebot",
+}
+
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<meta property="og:type" content="website">
+<meta property="og:url" content="{{.ShortURL}}">
+{{if .Title}}<meta property="og:title" content="{{.Title}}">
+<meta name="twitter:title" content="{{.Title}}">{{end}}
+{{if .Description}}<meta property="og:description" content="{{.Description}}">
+<meta name="twitter:description" content="{{.Description}}">{{end}}
+{{if .Image}}<meta property="og:image" content="{{.Image}}">
+<meta name="twitter:image" content="{{.Image}}">
+<meta name="twitter:card" content="summary_large_image">{{else}}<meta name="twitter:card" content="summary">{{end}}
+{{if .NoIndex}}<meta name="robots" content="noindex">
+{{end}}<meta http-equiv="refresh" content="0; url={{.Destination}}">
+</head>
+<body>
+<p>Redirecting to <a href="{{.Destination}}">{{.Destination}}</a></p>
+<script>window.location.replace({{.Destination}});</script>
+</body>
+</html>
+`))
+
+// isPreviewCrawler reports whether userAgent belongs to a link preview crawler
+func isPreviewCrawler(userAgent string) bool {
+	userAgent = strings.ToLower(userAgent)
+	for _, crawler := range previewCrawlers {
+		if strings.Contains(userAgent, crawler) {
+			return true
+		}
+	}
+	return false
+}
+
+// servePreviewCard renders an HTML shell carrying the link's Open Graph
+// tags, which still forwards browsers to the destination
+func servePreviewCard(c *gin.Context, shortCode string, entry *cache.RedirectEntry) {
+	var urlRecord models.URL
+	if err := database.DB.WithContext(c.Request.Context()).Where("short_code = ?", shortCode).First(&urlRecord).Error; err != nil {
+		c.Redirect(entry.StatusCode, entry.Target(entry.Destination))
+		return
+	}
+
+	// The page navigates by script, so only ever embed web destinations
+	destination := strings.ToLower(urlRecord.OriginalURL)
+	if !strings.HasPrefix(destination, "http://") && !strings.HasPrefix(destination, "https://") {
+		c.Redirect(entry.StatusCode, entry.Target(entry.Destination))
+		return
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	previewTemplate.Execute(c.Writer, gin.H{
+		"Title":       urlRecord.OGTitle,
+		"Description": urlRecord.OGDescription,
+		"Image":       urlRecord.OGImage,
+		"ShortURL":    buildShortURL(c, shortCode),
+		"Destination": entry.Target(urlRecord.OriginalURL),
+		"NoIndex":     urlRecord.NoIndex,
+	})
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/models"
+	"url-shortener/qrcode"
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Bounds of the size of QR code images, in pixels
+const (
+	defaultQRSize = 256
+	minQRSize     = 128
+	maxQRSize     = 1024
+)
+
+// GetQRCode godoc
+// @Summary QR code of a short link
+// @ID getQRCode
+// @Description Render a QR code encoding the short URL, as PNG (default) or SVG, for printing on posters and packaging. Images are cached, and scanning the code goes through the usual redirect, so clicks are counted.
+// @Tags URL Shortener
+// @Produce png
+// @Produce image/svg+xml
+// @Param shortCode path string true "Short code"
+// @Param format query string false "png or svg (default png)"
+// @Param size query int false "Width and height in pixels, including the quiet zone (default 256, 128 to 1024)"
+// @Param ecc query string false "Error correction level: L, M, Q or H (default M); higher levels survive more damage or a logo over the center"
+// @Success 200 {file} binary "QR code image"
+// @Failure 400 {object} models.ErrorResponse "Invalid format, size or error correction level"
+// @Failure 403 {object} models.ErrorResponse "Short URL is pending approval"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 410 {object} models.ErrorResponse "Short URL has expired"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Router /{shortCode}/qr [get]
+func GetQRCode(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", "png"))
+	if format != "png" && format != "svg" {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "format must be png or svg"))
+		return
+	}
+	size, err := queryInt(c, "size", defaultQRSize)
+	if err != nil || size < minQRSize || size > maxQRSize {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "size must be between 128 and 1024"))
+		return
+	}
+	level, err := qrcode.ParseLevel(c.DefaultQuery("ecc", "M"))
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	shortCode := hostLinkKey(c, c.Param("shortCode"))
+	entry, err := service.Default().LoadRedirectEntry(c.Request.Context(), shortCode)
+	if err != nil {
+		c.Error(service.LinkLookupError(err))
+		return
+	}
+	if apiErr := service.UnavailableLinkError(entry, time.Now()); apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+
+	contentType := "image/png"
+	if format == "svg" {
+		contentType = "image/svg+xml"
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+
+	shortURL := buildShortURL(c, shortCode)
+	key := format + ":" + strconv.Itoa(size) + ":" + level.String() + ":" + shortURL
+	if image, err := cache.GetQRCode(key); err == nil {
+		c.Data(http.StatusOK, contentType, image)
+		return
+	}
+
+	code, err := qrcode.Encode([]byte(shortURL), level)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Short URL is too long for a QR code"))
+		return
+	}
+	var image []byte
+	if format == "svg" {
+		image = code.SVG(size)
+	} else if image, err = code.PNG(size); err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to render QR code"))
+		return
+	}
+
+	cache.CacheQRCode(key, image)
+	c.Data(http.StatusOK, contentType, image)
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"fmt"
+
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// quotaWarnings warns the caller once their user used quota.WarnPercent of
+// the links their plan may create this month: the X-Quota-Warning header
+// carries the usage for clients to act on, and the returned warnings
+// explain it
+func quotaWarnings(c *gin.Context) []string {
+	usage, ok := service.LinkQuota(c.Request.Context(), requestCaller(c))
+	if !ok || !usage.Warned() {
+		return nil
+	}
+	c.Header("X-Quota-Warning", fmt.Sprintf("plan=%s; used=%d; limit=%d; resets=%s",
+		usage.Plan, usage.Used, usage.Limit, usage.Since.AddDate(0, 1, 0).Format("2006-01-02")))
+	return []string{usage.Warning()}
+}
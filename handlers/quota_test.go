@@ -0,0 +1,64 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"url-shortener/database"
+	"url-shortener/database/databasetest"
+	"url-shortener/handlers"
+	"url-shortener/handlers/handlertest"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/quota"
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestShortenWarnsNearQuota(t *testing.T) {
+	t.Setenv("SHORTEN_ALLOW_PRIVATE_DESTINATIONS", "true")
+	databasetest.UseSQLite(t)
+	gin.SetMode(gin.TestMode)
+	previous := service.LinkQuotas
+	service.LinkQuotas = quota.Policy{Links: map[string]int{"free": 5}}
+	t.Cleanup(func() { service.LinkQuotas = previous })
+
+	user := models.User{Email: "free@example.com", PasswordHash: "x"}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.Use(middleware.Errors(), func(c *gin.Context) {
+		middleware.APIKeyContextKey.Set(c, &models.APIKey{UserID: &user.ID})
+	})
+	router.POST("/shorten", handlers.New(database.Links, handlertest.NewCache()).ShortenURL)
+
+	for i, path := range []string{"a", "b", "c", "d", "e", "f"} {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/shorten", strings.NewReader(`{"url":"https://example.org/`+path+`"}`))
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(recorder, request)
+
+		var response models.ShortenResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		header := recorder.Header().Get("X-Quota-Warning")
+		switch used := i + 1; {
+		case used < 4:
+			if recorder.Code != http.StatusCreated || header != "" || len(response.Warnings) != 0 {
+				t.Errorf("link %d: %d, header %q, warnings %q, want no warning", used, recorder.Code, header, response.Warnings)
+			}
+		case used <= 5:
+			if recorder.Code != http.StatusCreated || !strings.HasPrefix(header, "plan=free; used=") || len(response.Warnings) != 1 {
+				t.Errorf("link %d: %d, header %q, warnings %q, want a quota warning", used, recorder.Code, header, response.Warnings)
+			}
+		default:
+			if recorder.Code != http.StatusTooManyRequests || !strings.Contains(recorder.Body.String(), string(models.ErrCodeQuotaExceeded)) {
+				t.Errorf("link %d: %d %s, want 429 QUOTA_EXCEEDED", used, recorder.Code, recorder.Body)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/cache"
+	"url-shortener/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BenchmarkRedirectURLWarmCache measures the redirect handler with the
+// redirect entry already cached. It needs Redis (REDIS_ADDR, default
+// localhost:6379) and is skipped without it. Target: well under 1ms per
+// redirect, so p99 stays below 1ms under load with a warm cache.
+func BenchmarkRedirectURLWarmCache(b *testing.B) {
+	gin.SetMode(gin.ReleaseMode)
+	cfg, err := config.FromEnv()
+	if err != nil {
+		b.Fatal(err)
+	}
+	cache.InitRedis(cfg.Redis)
+	if cache.RedisClient == nil {
+		b.Skip("Redis is not available")
+	}
+
+	shortCode := "bench1"
+	entry := &cache.RedirectEntry{URLID: 1, Destination: "https://example.com/", StatusCode: http.StatusMovedPermanently}
+	if err := cache.CacheRedirectEntry(shortCode, entry); err != nil {
+		b.Fatal(err)
+	}
+	defer cache.InvalidateCache(shortCode)
+
+	// Clicks are dropped once the queue fills since no workers are running
+	router := gin.New()
+	router.GET("/:shortCode", RedirectURL)
+	request := httptest.NewRequest(http.MethodGet, "/"+shortCode, nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusMovedPermanently {
+			b.Fatalf("status = %d, want %d", recorder.Code, http.StatusMovedPermanently)
+		}
+	}
+}
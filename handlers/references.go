@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Most external references a link may carry
+const maxLinkReferences = 50
+
+// ListReferences godoc
+// @Summary List the external references of one of your links
+// @ID listReferences
+// @Description List the Jira tickets, documents, designs and other pages attached to a link owned by the caller, oldest first
+// @Tags Links
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {array} models.LinkReference
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode}/references [get]
+func ListReferences(c *gin.Context) {
+	urlRecord, ok := annotatedLink(c)
+	if !ok {
+		return
+	}
+	references, err := database.LinkReferences(c.Request.Context(), urlRecord.ID)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list references"))
+		return
+	}
+	c.JSON(http.StatusOK, references)
+}
+
+// CreateReference godoc
+// @Summary Attach an external reference to one of your links
+// @ID createReference
+// @Description Attach a page explaining why a link owned by the caller exists, such as a Jira ticket, a Google Doc or a Figma design. Its kind is detected from the URL's host when omitted. Locked links can carry references; a link carries at most 50.
+// @Tags Links
+// @Accept json
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param request body models.ReferenceRequest true "Reference to attach"
+// @Success 201 {object} models.LinkReference
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 409 {object} models.ErrorResponse "The link carries 50 references already"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode}/references [post]
+func CreateReference(c *gin.Context) {
+	request, ok := bindReferenceRequest(c)
+	if !ok {
+		return
+	}
+	urlRecord, ok := annotatedLink(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	count, err := database.CountLinkReferences(ctx, urlRecord.ID)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to count references"))
+		return
+	}
+	if count >= maxLinkReferences {
+		c.Error(models.NewAPIError(http.StatusConflict, models.ErrCodeConflict, "The link carries 50 references already, delete some first"))
+		return
+	}
+
+	reference := models.LinkReference{
+		URLID:  urlRecord.ID,
+		Kind:   request.Kind,
+		URL:    request.URL,
+		Title:  request.Title,
+		Author: "user:" + strconv.FormatUint(uint64(*middleware.CurrentOwnerID(c)), 10),
+	}
+	if err := database.CreateLinkReference(ctx, &reference); err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create reference"))
+		return
+	}
+	c.JSON(http.StatusCreated, reference)
+}
+
+// UpdateReference godoc
+// @Summary Edit an external reference of one of your links
+// @ID updateReference
+// @Description Replace the URL, kind and title of a reference attached to a link owned by the caller. The kind is detected from the new URL's host when omitted.
+// @Tags Links
+// @Accept json
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param id path int true "Reference ID"
+// @Param request body models.ReferenceRequest true "New reference"
+// @Success 200 {object} models.LinkReference
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope"
+// @Failure 404 {object} models.ErrorResponse "Short URL or reference not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode}/references/{id} [put]
+func UpdateReference(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Reference not found"))
+		return
+	}
+	request, ok := bindReferenceRequest(c)
+	if !ok {
+		return
+	}
+	urlRecord, ok := annotatedLink(c)
+	if !ok {
+		return
+	}
+
+	reference := models.LinkReference{ID: uint(id), URLID: urlRecord.ID, Kind: request.Kind, URL: request.URL, Title: request.Title}
+	updated, err := database.UpdateLinkReference(c.Request.Context(), &reference)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update reference"))
+		return
+	}
+	if !updated {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Reference not found"))
+		return
+	}
+	c.JSON(http.StatusOK, reference)
+}
+
+// DeleteReference godoc
+// @Summary Detach an external reference from one of your links
+// @ID deleteReference
+// @Description Remove a reference attached to a link owned by the caller
+// @Tags Links
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param id path int true "Reference ID"
+// @Success 204 "Reference deleted"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope"
+// @Failure 404 {object} models.ErrorResponse "Short URL or reference not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode}/references/{id} [delete]
+func DeleteReference(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Reference not found"))
+		return
+	}
+	urlRecord, ok := annotatedLink(c)
+	if !ok {
+		return
+	}
+	deleted, err := database.DeleteLinkReference(c.Request.Context(), urlRecord.ID, uint(id))
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete reference"))
+		return
+	}
+	if !deleted {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Reference not found"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// bindReferenceRequest reads a reference, which must be an http or https
+// URL, detecting its kind when omitted
+func bindReferenceRequest(c *gin.Context) (*models.ReferenceRequest, bool) {
+	var request models.ReferenceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return nil, false
+	}
+	parsed, err := url.Parse(request.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "url must be an http or https URL"))
+		return nil, false
+	}
+	if request.Kind == "" {
+		request.Kind = referenceKind(parsed)
+	}
+	return &request, true
+}
+
+// referenceKind tells the kind of a reference from the host, and for Jira
+// the path, of its URL
+func referenceKind(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	hostIs := func(domain string) bool {
+		return host == domain || strings.HasSuffix(host, "."+domain)
+	}
+	switch {
+	case hostIs("atlassian.net") && strings.HasPrefix(u.Path, "/browse/"),
+		strings.HasPrefix(host, "jira.") && strings.HasPrefix(u.Path, "/browse/"):
+		return models.ReferenceKindJira
+	case host == "docs.google.com", host == "drive.google.com":
+		return models.ReferenceKindGoogleDoc
+	case hostIs("figma.com"):
+		return models.ReferenceKindFigma
+	case host == "github.com":
+		return models.ReferenceKindGitHub
+	case hostIs("notion.so"), hostIs("notion.site"):
+		return models.ReferenceKindNotion
+	}
+	return models.ReferenceKindLink
+}
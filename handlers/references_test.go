@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+
+	"url-shortener/models"
+)
+
+func TestReferenceKind(t *testing.T) {
+	for rawURL, want := range map[string]string{
+		"https://acme.atlassian.net/browse/MKT-142":      models.ReferenceKindJira,
+		"https://jira.acme.com/browse/MKT-142":           models.ReferenceKindJira,
+		"https://acme.atlassian.net/wiki/spaces/MKT":     models.ReferenceKindLink,
+		"https://docs.google.com/document/d/1aBc/edit":   models.ReferenceKindGoogleDoc,
+		"https://drive.google.com/file/d/1aBc/view":      models.ReferenceKindGoogleDoc,
+		"https://www.figma.com/file/aBc/Spring-campaign": models.ReferenceKindFigma,
+		"https://GitHub.com/acme/site/issues/7":          models.ReferenceKindGitHub,
+		"https://www.notion.so/acme/Spring-1a2b":         models.ReferenceKindNotion,
+		"https://notfigma.com/file/aBc":                  models.ReferenceKindLink,
+		"https://acme.com/spring":                        models.ReferenceKindLink,
+	} {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := referenceKind(u); got != want {
+			t.Errorf("referenceKind(%q) = %q, want %q", rawURL, got, want)
+		}
+	}
+}
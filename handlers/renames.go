@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"url-shortener/background"
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/service"
+	"url-shortener/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How long a renamed link's old short code keeps resolving, from
+// ALIAS_RENAME_GRACE. 0 retires old codes right away; they are never reused.
+var aliasRenameGrace = aliasRenameGraceFromEnv()
+
+// Where old short codes send visitors, from ALIAS_RENAME_TARGET
+var aliasRenameTarget = aliasRenameTargetFromEnv()
+
+func aliasRenameGraceFromEnv() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("ALIAS_RENAME_GRACE")); err == nil && value >= 0 {
+		return value
+	}
+	return 30 * 24 * time.Hour
+}
+
+func aliasRenameTargetFromEnv() string {
+	if strings.ToLower(os.Getenv("ALIAS_RENAME_TARGET")) == models.AliasTargetDestination {
+		return models.AliasTargetDestination
+	}
+	return models.AliasTargetShortURL
+}
+
+// checkRenameAllowed validates the new alias of an update request before any
+// lookup, writing the error response when it is invalid
+func checkRenameAllowed(c *gin.Context, request models.UpdateLinkRequest) bool {
+	if request.CustomAlias == nil {
+		return true
+	}
+	if err := service.ValidateAlias(*request.CustomAlias); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return false
+	}
+	return true
+}
+
+// renameLink gives urlRecord the short code alias on its domain, keeping its
+// old code as a renamed alias, and writes the error response when the alias
+// is taken or the rename fails
+func renameLink(c *gin.Context, urlRecord *models.URL, alias string) bool {
+	ctx := c.Request.Context()
+	alias = models.LinkKey(urlRecord.ShortHost(), alias)
+
+	// A link may take back an alias it was renamed away from
+	owner, renamed, err := database.RenamedAliasOwner(ctx, alias)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to check custom alias"))
+		return false
+	}
+	if !renamed || owner != urlRecord.ID {
+		taken, err := service.Default().AliasTaken(ctx, alias)
+		if err != nil {
+			c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to check custom alias"))
+			return false
+		}
+		if taken {
+			c.Error(models.ErrAliasTaken)
+			return false
+		}
+	}
+
+	previousCode := urlRecord.ShortCode
+	if err := database.RenameURL(ctx, urlRecord.ID, previousCode, alias, time.Now().Add(aliasRenameGrace)); err != nil {
+		if service.IsUniqueViolation(err) {
+			c.Error(models.ErrAliasTaken)
+		} else {
+			c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to rename link"))
+		}
+		return false
+	}
+
+	urlRecord.ShortCode = alias
+	cache.InvalidateCache(previousCode)
+	cache.InvalidateCache(alias)
+	cache.InvalidateOriginalURLMapping(urlRecord.OwnerID, urlRecord.OriginalURL)
+	return true
+}
+
+// followRenamedAlias redirects a visit to a short code that a link was
+// renamed away from, reporting false when shortCode is no such alias
+func (h *Handler) followRenamedAlias(c *gin.Context, shortCode string) bool {
+	ctx := c.Request.Context()
+	now := time.Now()
+	alias, currentCode, err := h.stores.Links.FindRenamedAlias(ctx, shortCode, now)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			slog.ErrorContext(ctx, "Failed to look up renamed alias", "short_code", shortCode, "error", err)
+		}
+		return false
+	}
+
+	// Hits are only counted, so they are recorded in the background
+	background.Go(func() {
+		ctx, cancel := context.WithTimeout(database.WithRoute(context.WithoutCancel(ctx), "renamed_alias_hit"), 5*time.Second)
+		defer cancel()
+		if err := h.stores.Links.RecordRenamedAliasHit(ctx, alias.Alias, now); err != nil {
+			slog.ErrorContext(ctx, "Failed to record hit of renamed alias", "alias", alias.Alias, "error", err)
+		}
+	})
+
+	if aliasRenameTarget == models.AliasTargetDestination {
+		entry, err := h.stores.LoadRedirectEntry(ctx, currentCode)
+		if errors.Is(err, storage.ErrNotFound) {
+			respondLinkError(c, models.ErrLinkNotFound)
+			return true
+		}
+		if err != nil {
+			c.Error(err)
+			return true
+		}
+		if apiErr := service.UnavailableLinkError(entry, now); apiErr != nil {
+			respondLinkError(c, apiErr)
+			return true
+		}
+		// Split links, links with routing rules, a rollout, a velocity
+		// limit or landing tracking and links pointing back into the
+		// service go through their short URL, which handles them
+		if !entry.Has(cache.RedirectVariants|cache.RedirectRollout|cache.RedirectVelocity|cache.RedirectLandings) && len(entry.Rules) == 0 && !h.redirectLoops(c, currentCode, entry) {
+			enqueueClick(c, currentCode, entry, 0)
+			setRedirectHeaders(c, entry)
+			c.Redirect(entry.StatusCode, entry.Target(entry.Destination))
+			return true
+		}
+	}
+
+	target := buildShortURL(c, currentCode)
+	if c.Request.URL.RawQuery != "" {
+		target += "?" + c.Request.URL.RawQuery
+	}
+	c.Redirect(http.StatusMovedPermanently, target)
+	return true
+}
+
+// ListRenamedAliases godoc
+// @Summary List the old short codes of one of your links
+// @ID listRenamedAliases
+// @Description List the short codes a link owned by the caller was renamed away from, newest first. Each keeps resolving until expires_at; hits and last_hit_at show whether it is still used and safe to retire.
+// @Tags Links
+// @Produce json
+// @Param shortCode path string true "Current short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {array} models.RenamedAlias
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode}/aliases [get]
+func ListRenamedAliases(c *gin.Context) {
+	var urlRecord models.URL
+	err := database.DB.WithContext(c.Request.Context()).
+		Where("short_code = ? AND owner_id = ?", pathLinkKey(c), *middleware.CurrentOwnerID(c)).First(&urlRecord).Error
+	if err != nil {
+		c.Error(models.ErrLinkNotFound)
+		return
+	}
+
+	aliases, err := database.RenamedAliases(c.Request.Context(), urlRecord.ID)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list renamed aliases"))
+		return
+	}
+	c.JSON(http.StatusOK, aliases)
+}
+
+// RetireRenamedAlias godoc
+// @Summary Retire an old short code of one of your links
+// @ID retireRenamedAlias
+// @Description End the grace period of a short code a link owned by the caller was renamed away from, so it stops resolving. The code is not given to another link.
+// @Tags Links
+// @Param shortCode path string true "Current short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param alias path string true "Old short code"
+// @Success 204 "Alias retired"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope, or the link is locked"
+// @Failure 404 {object} models.ErrorResponse "Short URL or alias not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode}/aliases/{alias} [delete]
+func RetireRenamedAlias(c *gin.Context) {
+	urlRecord, ok := ownedLink(c)
+	if !ok {
+		return
+	}
+
+	retired, err := database.RetireRenamedAlias(c.Request.Context(), urlRecord.ID, models.LinkKey(urlRecord.ShortHost(), c.Param("alias")), time.Now())
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retire alias"))
+		return
+	}
+	if !retired {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Alias not found or already retired"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"url-shortener/models"
+)
+
+func TestAliasRenameConfig(t *testing.T) {
+	cases := []struct {
+		grace, target string
+		wantGrace     time.Duration
+		wantTarget    string
+	}{
+		{wantGrace: 30 * 24 * time.Hour, wantTarget: models.AliasTargetShortURL},
+		{grace: "0", target: "Destination", wantGrace: 0, wantTarget: models.AliasTargetDestination},
+		{grace: "48h", target: "short_url", wantGrace: 48 * time.Hour, wantTarget: models.AliasTargetShortURL},
+		{grace: "-1h", target: "bogus", wantGrace: 30 * 24 * time.Hour, wantTarget: models.AliasTargetShortURL},
+	}
+	for _, tc := range cases {
+		t.Setenv("ALIAS_RENAME_GRACE", tc.grace)
+		t.Setenv("ALIAS_RENAME_TARGET", tc.target)
+		if got := aliasRenameGraceFromEnv(); got != tc.wantGrace {
+			t.Errorf("ALIAS_RENAME_GRACE=%q: grace = %v, want %v", tc.grace, got, tc.wantGrace)
+		}
+		if got := aliasRenameTargetFromEnv(); got != tc.wantTarget {
+			t.Errorf("ALIAS_RENAME_TARGET=%q: target = %q, want %q", tc.target, got, tc.wantTarget)
+		}
+	}
+}
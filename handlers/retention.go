@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/retention"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statsRetention is the click history kept per plan, from
+// STATS_RETENTION_DAYS
+var statsRetention = loadStatsRetentionPolicy()
+
+// loadStatsRetentionPolicy reads the stats retention policy, falling back to
+// keeping click history on every plan when it is invalid
+func loadStatsRetentionPolicy() retention.Policy {
+	policy, err := retention.PolicyFromEnv()
+	if err != nil {
+		slog.Warn("Invalid stats retention policy, stats ranges are not limited by plan", "error", err)
+	}
+	return policy
+}
+
+// clampStatsRange moves from forward to the start of the click history kept
+// on the plan of ownerID, returning the notice explaining the change, nil
+// when from is within it. It writes the error response when the plan can't
+// be looked up.
+func clampStatsRange(c *gin.Context, ownerID *uint, from, to time.Time) (time.Time, *models.RetentionNotice, bool) {
+	// Without limited plans, no plan needs looking up
+	if len(statsRetention.Limited()) == 0 {
+		return from, nil, true
+	}
+	plan, err := database.UserPlan(c.Request.Context(), ownerID)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to look up the plan"))
+		return time.Time{}, nil, false
+	}
+	clamped, notice := clampToRetention(statsRetention, plan, from, to, time.Now())
+	return clamped, notice, true
+}
+
+// clampToRetention moves from forward to the start of the click history kept
+// on plan at now, and no further than to
+func clampToRetention(policy retention.Policy, plan string, from, to, now time.Time) (time.Time, *models.RetentionNotice) {
+	cutoff, limited := policy.Cutoff(plan, now)
+	if !limited || !from.Before(cutoff) {
+		return from, nil
+	}
+	clamped := cutoff
+	if to.Before(clamped) {
+		clamped = to
+	}
+	days := policy.DaysFor(plan)
+	return clamped, &models.RetentionNotice{
+		Plan:          plan,
+		Days:          days,
+		RequestedFrom: from,
+		Message:       fmt.Sprintf("Click history is kept for %d days on the %s plan, from was moved to %s", days, plan, clamped.Format(time.RFC3339)),
+	}
+}
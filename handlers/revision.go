@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errRevisionMismatch = models.NewAPIError(http.StatusPreconditionFailed, models.ErrCodeRevisionMismatch, "Link was changed since this revision was read; fetch it again and reapply the edit")
+
+	// A conditional edit lost the race to another edit of the link
+	errEditedConcurrently = errors.New("link was edited concurrently")
+)
+
+// linkETag is the entity tag of the link's revision
+func linkETag(urlRecord *models.URL) string {
+	return `"` + strconv.Itoa(urlRecord.Revision) + `"`
+}
+
+// ifMatch reports whether the If-Match header lists etag, or * for any
+// revision. Comparison is strong, as If-Match requires, so weak tags never
+// match.
+func ifMatch(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch refuses an edit of urlRecord when the request carries an
+// If-Match header naming another revision, writing 412 with the current
+// ETag. It reports whether the edit is conditional.
+func checkIfMatch(c *gin.Context, urlRecord *models.URL) (conditional, ok bool) {
+	header := c.GetHeader("If-Match")
+	if header == "" || strings.TrimSpace(header) == "*" {
+		return false, true
+	}
+	if !ifMatch(header, linkETag(urlRecord)) {
+		c.Header("ETag", linkETag(urlRecord))
+		c.Error(errRevisionMismatch)
+		return true, false
+	}
+	return true, true
+}
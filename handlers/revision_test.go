@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"testing"
+
+	"url-shortener/models"
+)
+
+func TestIfMatch(t *testing.T) {
+	etag := linkETag(&models.URL{Revision: 3})
+	if etag != `"3"` {
+		t.Fatalf("linkETag = %s, want \"3\"", etag)
+	}
+	cases := map[string]bool{
+		`"3"`:        true,
+		`"2", "3"`:   true,
+		`*`:          true,
+		`"2"`:        false,
+		`W/"3"`:      false, // weak tags never match If-Match
+		`3`:          false,
+		`"3", "4" ,`: true,
+	}
+	for header, want := range cases {
+		if got := ifMatch(header, etag); got != want {
+			t.Errorf("ifMatch(%s) = %v, want %v", header, got, want)
+		}
+	}
+}
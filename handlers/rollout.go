@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"hash/fnv"
+	"strconv"
+
+	"url-shortener/cache"
+
+	"github.com/gin-gonic/gin"
+)
+
+// inRollout reports whether the visitor is let through a soft launched link
+func inRollout(c *gin.Context, entry *cache.RedirectEntry) bool {
+	return rolloutBucket(entry.URLID, c.ClientIP()+" "+c.GetHeader("User-Agent")) < entry.Rollout
+}
+
+// rolloutBucket places a visitor, identified like unique visitors by
+// address and user agent, in one of 100 buckets. Buckets are stable, so
+// visitors let through stay so as the rollout grows, and differ per link,
+// so the same visitors are not always the first ones in.
+func rolloutBucket(urlID uint, visitor string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(strconv.FormatUint(uint64(urlID), 10) + "\x00" + visitor))
+	return int(hash.Sum32() % 100)
+}
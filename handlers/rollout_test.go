@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRolloutBucketIsStickyAndSpread(t *testing.T) {
+	counts := make([]int, 10)
+	for i := 0; i < 10000; i++ {
+		visitor := fmt.Sprintf("198.51.100.%d Mozilla/5.0 (%d)", i%256, i)
+		bucket := rolloutBucket(42, visitor)
+		if bucket < 0 || bucket >= 100 {
+			t.Fatalf("bucket = %d, want 0 to 99", bucket)
+		}
+		if again := rolloutBucket(42, visitor); again != bucket {
+			t.Fatalf("visitor moved from bucket %d to %d", bucket, again)
+		}
+		counts[bucket/10]++
+	}
+	// Each tenth of the rollout lets through about a tenth of the visitors
+	for i, count := range counts {
+		if count < 800 || count > 1200 {
+			t.Errorf("buckets %d-%d hold %d of 10000 visitors", i*10, i*10+9, count)
+		}
+	}
+
+	// Links order visitors differently
+	same := 0
+	for i := 0; i < 100; i++ {
+		visitor := fmt.Sprintf("203.0.113.%d curl/8.0", i)
+		if rolloutBucket(1, visitor) == rolloutBucket(2, visitor) {
+			same++
+		}
+	}
+	if same > 10 {
+		t.Errorf("%d of 100 visitors share their bucket across links", same)
+	}
+}
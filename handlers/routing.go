@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/geo"
+	"url-shortener/models"
+	"url-shortener/routing"
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRoutingSchema godoc
+// @Summary Routing rules schema
+// @ID getRoutingSchema
+// @Description JSON schema of the routing_rules of a link, as accepted by POST /shorten and PUT /links/{shortCode}. Rules are checked in order; a rule matches when all of its conditions hold, and the first matching rule redirects the visitor to its url, sends them to the link's usual destination or answers as if the link did not exist.
+// @Tags URL Shortener
+// @Produce json
+// @Success 200 {object} object "JSON schema (draft 2020-12)"
+// @Router /routing/schema [get]
+func GetRoutingSchema(c *gin.Context) {
+	c.Data(http.StatusOK, "application/schema+json", routing.Schema)
+}
+
+// checkRoutingAllowed validates routing rules and applies the checks of the
+// link's URL to the URLs they redirect to, returning the strictest safety
+// action. It writes the error response and returns false when the rules may
+// not be used.
+func checkRoutingAllowed(c *gin.Context, rules []models.RoutingRule, safetyAction string) (string, bool) {
+	safetyAction, apiErr := service.CheckRouting(c.Request.Context(), requestCaller(c), rules, safetyAction)
+	return safetyActionOrError(c, safetyAction, apiErr)
+}
+
+// requestVisit describes the visitor making the request to routing rules
+func requestVisit(c *gin.Context) routing.Visit {
+	return routing.Visit{
+		Time:     time.Now(),
+		Country:  geo.FromRequest(c.Request).Country,
+		Device:   deviceType(c.GetHeader("User-Agent")),
+		OS:       operatingSystem(c.GetHeader("User-Agent")),
+		Language: routing.PrimaryLanguage(c.GetHeader("Accept-Language")),
+	}
+}
+
+// matchRoutingRule returns the routing rule of the link deciding the visit,
+// or nil when the link has none or none matches
+func matchRoutingRule(c *gin.Context, entry *cache.RedirectEntry) *models.RoutingRule {
+	if len(entry.Rules) == 0 {
+		return nil
+	}
+	return routing.Match(entry.Rules, requestVisit(c))
+}
+
+// visitDestination returns where a visit goes and the variant serving it,
+// if any: the URL of the routing rule redirecting it, one of the variants of
+// a split link, or else the link's destination
+func visitDestination(c *gin.Context, entry *cache.RedirectEntry, rule *models.RoutingRule) (string, uint) {
+	if rule != nil && rule.Action.Type == models.RoutingActionRedirect {
+		return rule.Action.URL, 0
+	}
+	// Split links send each visitor to one of their variants
+	if entry.Has(cache.RedirectVariants) {
+		if variant := pickVariant(c.Request.Context(), entry); variant != nil {
+			return variant.Destination, variant.ID
+		}
+	}
+	return entry.Destination, 0
+}
+
+// operatingSystem classifies a user agent as ios, android, windows, macos or
+// linux, or returns an empty string when it is none of them. iPads asking
+// for desktop sites present themselves as macOS.
+func operatingSystem(userAgent string) string {
+	userAgent = strings.ToLower(userAgent)
+	switch {
+	case strings.Contains(userAgent, "iphone") || strings.Contains(userAgent, "ipad") || strings.Contains(userAgent, "ipod"):
+		return "ios"
+	case strings.Contains(userAgent, "android"):
+		return "android"
+	case strings.Contains(userAgent, "windows"):
+		return "windows"
+	case strings.Contains(userAgent, "macintosh") || strings.Contains(userAgent, "mac os x"):
+		return "macos"
+	case strings.Contains(userAgent, "linux"):
+		return "linux"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListRoutingRules godoc
+// @Summary List the routing rules of one of your links
+// @ID listRoutingRules
+// @Description List the routing rules of a link owned by the caller in the order they are checked. A rule's index in the list, from 0, is its priority and identifies it in PUT and DELETE /links/{shortCode}/rules/{index}.
+// @Tags Links
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {array} models.RoutingRule
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode}/rules [get]
+func ListRoutingRules(c *gin.Context) {
+	var urlRecord models.URL
+	err := database.DB.WithContext(c.Request.Context()).
+		Where("short_code = ? AND owner_id = ?", pathLinkKey(c), *middleware.CurrentOwnerID(c)).First(&urlRecord).Error
+	if err != nil {
+		c.Error(models.ErrLinkNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, ruleList(urlRecord.RoutingRules))
+}
+
+// ReplaceRoutingRules godoc
+// @Summary Replace the routing rules of one of your links
+// @ID replaceRoutingRules
+// @Description Replace every routing rule of a link owned by the caller, e.g. to reorder them, like routing_rules of PUT /links/{shortCode}. The URLs rules redirect to pass the same checks as POST /shorten and may put the link back into review. Locked links cannot be changed.
+// @Tags Links
+// @Accept json
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param request body []models.RoutingRule true "Rules in the order they are checked"
+// @Success 200 {array} models.RoutingRule
+// @Failure 400 {object} models.ErrorResponse "Invalid rules"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope, or the link is locked"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode}/rules [put]
+func ReplaceRoutingRules(c *gin.Context) {
+	var request []models.RoutingRule
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	editRoutingRules(c, http.StatusOK, func([]models.RoutingRule) ([]models.RoutingRule, *models.APIError) {
+		return request, nil
+	})
+}
+
+// AddRoutingRule godoc
+// @Summary Add a routing rule to one of your links
+// @ID addRoutingRule
+// @Description Add a routing rule to a link owned by the caller, checked after the existing rules unless position says otherwise. Rules sending app users to a store typically match on os, e.g. ios to the App Store and android to Google Play, with the link's destination serving everyone else. Locked links cannot be changed.
+// @Tags Links
+// @Accept json
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param position query int false "Index the rule is inserted at, from 0; appended when omitted"
+// @Param request body models.RoutingRule true "Rule to add"
+// @Success 201 {array} models.RoutingRule
+// @Failure 400 {object} models.ErrorResponse "Invalid rule or position"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope, or the link is locked"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode}/rules [post]
+func AddRoutingRule(c *gin.Context) {
+	var rule models.RoutingRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	editRoutingRules(c, http.StatusCreated, func(rules []models.RoutingRule) ([]models.RoutingRule, *models.APIError) {
+		position, err := queryInt(c, "position", len(rules))
+		if err != nil || position < 0 || position > len(rules) {
+			return nil, models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "position must be between 0 and "+strconv.Itoa(len(rules)))
+		}
+		return insertRule(rules, position, rule), nil
+	})
+}
+
+// UpdateRoutingRule godoc
+// @Summary Change a routing rule of one of your links
+// @ID updateRoutingRule
+// @Description Replace the routing rule at index of a link owned by the caller, keeping its priority. Locked links cannot be changed.
+// @Tags Links
+// @Accept json
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param index path int true "Index of the rule, from 0"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param request body models.RoutingRule true "New rule"
+// @Success 200 {array} models.RoutingRule
+// @Failure 400 {object} models.ErrorResponse "Invalid rule"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope, or the link is locked"
+// @Failure 404 {object} models.ErrorResponse "Short URL or routing rule not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode}/rules/{index} [put]
+func UpdateRoutingRule(c *gin.Context) {
+	var rule models.RoutingRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	editRoutingRules(c, http.StatusOK, func(rules []models.RoutingRule) ([]models.RoutingRule, *models.APIError) {
+		index, apiErr := ruleIndex(c, rules)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		rules[index] = rule
+		return rules, nil
+	})
+}
+
+// DeleteRoutingRule godoc
+// @Summary Remove a routing rule from one of your links
+// @ID deleteRoutingRule
+// @Description Remove the routing rule at index of a link owned by the caller; later rules move up one place. Locked links cannot be changed.
+// @Tags Links
+// @Param shortCode path string true "Short code"
+// @Param index path int true "Index of the rule, from 0"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 204 "Routing rule removed"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope, or the link is locked"
+// @Failure 404 {object} models.ErrorResponse "Short URL or routing rule not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode}/rules/{index} [delete]
+func DeleteRoutingRule(c *gin.Context) {
+	editRoutingRules(c, http.StatusNoContent, func(rules []models.RoutingRule) ([]models.RoutingRule, *models.APIError) {
+		index, apiErr := ruleIndex(c, rules)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		return append(rules[:index], rules[index+1:]...), nil
+	})
+}
+
+// editRoutingRules applies edit to a copy of the routing rules of a link
+// owned by the caller and saves the result like PUT /links/{shortCode},
+// answering with status and the rules saved
+func editRoutingRules(c *gin.Context, status int, edit func([]models.RoutingRule) ([]models.RoutingRule, *models.APIError)) {
+	urlRecord, ok := ownedLink(c)
+	if !ok {
+		return
+	}
+	rules, apiErr := edit(append([]models.RoutingRule(nil), urlRecord.RoutingRules...))
+	if apiErr != nil {
+		c.Error(apiErr)
+		return
+	}
+	if !updateLink(c, urlRecord, models.UpdateLinkRequest{RoutingRules: &rules}) {
+		return
+	}
+	if status == http.StatusNoContent {
+		c.Status(status)
+		return
+	}
+	c.JSON(status, ruleList(urlRecord.RoutingRules))
+}
+
+// ruleIndex reads the index path parameter of a rule in rules
+func ruleIndex(c *gin.Context, rules []models.RoutingRule) (int, *models.APIError) {
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 || index >= len(rules) {
+		return 0, models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Routing rule not found")
+	}
+	return index, nil
+}
+
+// insertRule returns rules with rule inserted at position
+func insertRule(rules []models.RoutingRule, position int, rule models.RoutingRule) []models.RoutingRule {
+	rules = append(rules, models.RoutingRule{})
+	copy(rules[position+1:], rules[position:])
+	rules[position] = rule
+	return rules
+}
+
+// ruleList returns rules, answering links without any with an empty list
+func ruleList(rules []models.RoutingRule) []models.RoutingRule {
+	if rules == nil {
+		return []models.RoutingRule{}
+	}
+	return rules
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"testing"
+
+	"url-shortener/models"
+)
+
+func TestInsertRule(t *testing.T) {
+	names := func(rules []models.RoutingRule) string {
+		joined := ""
+		for _, rule := range rules {
+			joined += rule.Name
+		}
+		return joined
+	}
+	rules := []models.RoutingRule{{Name: "a"}, {Name: "c"}}
+	for position, want := range map[int]string{0: "bac", 1: "abc", 2: "acb"} {
+		got := insertRule(append([]models.RoutingRule(nil), rules...), position, models.RoutingRule{Name: "b"})
+		if names(got) != want {
+			t.Errorf("insert at %d = %q, want %q", position, names(got), want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/safety"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSafetyRules godoc
+// @Summary List safety rules
+// @ID listSafetyRules
+// @Description List brand safety rules in evaluation order (highest priority first)
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} models.SafetyRule
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/safety-rules [get]
+func ListSafetyRules(c *gin.Context) {
+	var rules []models.SafetyRule
+	if err := database.DB.Order("priority desc, id asc").Find(&rules).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list safety rules"))
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateSafetyRule godoc
+// @Summary Create a safety rule
+// @ID createSafetyRule
+// @Description Create a regex, domain or keyword rule that allows, denies or requires review for matching URLs
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body models.SafetyRuleRequest true "Safety rule"
+// @Success 201 {object} models.SafetyRule
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/safety-rules [post]
+func CreateSafetyRule(c *gin.Context) {
+	var request models.SafetyRuleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	var rule models.SafetyRule
+	applySafetyRuleRequest(&rule, &request)
+	if err := safety.Validate(&rule); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	if err := database.DB.Create(&rule).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create safety rule"))
+		return
+	}
+	safety.Invalidate()
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateSafetyRule godoc
+// @Summary Update a safety rule
+// @ID updateSafetyRule
+// @Description Replace an existing safety rule
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Param request body models.SafetyRuleRequest true "Safety rule"
+// @Success 200 {object} models.SafetyRule
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Safety rule not found"
+// @Security AdminAuth
+// @Router /admin/safety-rules/{id} [put]
+func UpdateSafetyRule(c *gin.Context) {
+	var rule models.SafetyRule
+	if err := database.DB.First(&rule, c.Param("id")).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Safety rule not found"))
+		return
+	}
+
+	var request models.SafetyRuleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	applySafetyRuleRequest(&rule, &request)
+	if err := safety.Validate(&rule); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	if err := database.DB.Save(&rule).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update safety rule"))
+		return
+	}
+	safety.Invalidate()
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteSafetyRule godoc
+// @Summary Delete a safety rule
+// @ID deleteSafetyRule
+// @Description Delete a safety rule
+// @Tags Admin
+// @Param id path int true "Rule ID"
+// @Success 204 "Rule deleted"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Safety rule not found"
+// @Security AdminAuth
+// @Router /admin/safety-rules/{id} [delete]
+func DeleteSafetyRule(c *gin.Context) {
+	result := database.DB.Delete(&models.SafetyRule{}, c.Param("id"))
+	if result.Error != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete safety rule"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Safety rule not found"))
+		return
+	}
+	safety.Invalidate()
+
+	c.Status(http.StatusNoContent)
+}
+
+func applySafetyRuleRequest(rule *models.SafetyRule, request *models.SafetyRuleRequest) {
+	rule.Type = request.Type
+	rule.Pattern = request.Pattern
+	rule.Action = request.Action
+	rule.Priority = request.Priority
+	rule.Description = request.Description
+	rule.Enabled = request.Enabled == nil || *request.Enabled
+}
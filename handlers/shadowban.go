@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+
+	"url-shortener/database"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListShadowBans godoc
+// @Summary List shadow bans
+// @ID listShadowBans
+// @Description List creators whose new links are silently made inert
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} models.ShadowBan
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/shadow-bans [get]
+func ListShadowBans(c *gin.Context) {
+	var bans []models.ShadowBan
+	if err := database.DB.Order("created_at desc").Find(&bans).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list shadow bans"))
+		return
+	}
+
+	c.JSON(http.StatusOK, bans)
+}
+
+// CreateShadowBan godoc
+// @Summary Shadow-ban a creator
+// @ID createShadowBan
+// @Description Shadow-ban an IP address: its shorten calls still succeed but the links never redirect
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body models.ShadowBanRequest true "Creator to shadow-ban"
+// @Success 201 {object} models.ShadowBan
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/shadow-bans [post]
+func CreateShadowBan(c *gin.Context) {
+	var request models.ShadowBanRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	ban := models.ShadowBan{IPAddress: request.IPAddress, Reason: request.Reason}
+	if err := database.DB.Create(&ban).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create shadow ban"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, ban)
+}
+
+// DeleteShadowBan godoc
+// @Summary Lift a shadow ban
+// @ID deleteShadowBan
+// @Description Lift a shadow ban. Links already created stay inert.
+// @Tags Admin
+// @Param id path int true "Shadow ban ID"
+// @Success 204 "Shadow ban lifted"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Shadow ban not found"
+// @Security AdminAuth
+// @Router /admin/shadow-bans/{id} [delete]
+func DeleteShadowBan(c *gin.Context) {
+	result := database.DB.Delete(&models.ShadowBan{}, c.Param("id"))
+	if result.Error != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete shadow ban"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Shadow ban not found"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"url-shortener/certs"
+	"url-shortener/database"
+	"url-shortener/domains"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListShortDomains godoc
+// @Summary List short link domains
+// @ID listShortDomains
+// @Description List the branded domains short links can be served on, besides the default one
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} models.Domain
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/short-domains [get]
+func ListShortDomains(c *gin.Context) {
+	var stored []models.Domain
+	if err := database.DB.Order("host asc").Find(&stored).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list short link domains"))
+		return
+	}
+	c.JSON(http.StatusOK, stored)
+}
+
+// CreateShortDomain godoc
+// @Summary Add a short link domain
+// @ID createShortDomain
+// @Description Add a branded domain to serve short links on. Point its DNS at this service first; links created with its name as domain resolve only on that host and have short codes of their own. With ACME_ENABLED=true its TLS certificate is obtained in the background.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body models.ShortDomainRequest true "Domain to serve"
+// @Success 201 {object} models.Domain
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 409 {object} models.ErrorResponse "Domain already added"
+// @Security AdminAuth
+// @Router /admin/short-domains [post]
+func CreateShortDomain(c *gin.Context) {
+	var request models.ShortDomainRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	host, err := domains.Normalize(request.Host)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.Domain{}).Where("host = ?", host).Count(&count).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to add short link domain"))
+		return
+	}
+	if count > 0 {
+		c.Error(models.NewAPIError(http.StatusConflict, models.ErrCodeConflict, "Short link domain has already been added"))
+		return
+	}
+
+	environment := request.Environment
+	if environment == "" {
+		environment = models.EnvironmentProduction
+	}
+	domain := models.Domain{Host: host, Environment: environment}
+	if err := database.DB.Create(&domain).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to add short link domain"))
+		return
+	}
+	domains.InvalidateShortDomains()
+
+	// Obtain its TLS certificate ahead of the first visit
+	if certs.Enabled() {
+		ctx := context.WithoutCancel(c.Request.Context())
+		go func() {
+			if err := certs.Provision(host); err != nil {
+				slog.ErrorContext(ctx, "Failed to obtain a TLS certificate", "host", host, "error", err)
+			}
+		}()
+	}
+
+	c.JSON(http.StatusCreated, domain)
+}
+
+// DeleteShortDomain godoc
+// @Summary Remove a short link domain
+// @ID deleteShortDomain
+// @Description Remove a branded domain that no link uses anymore, including deleted and archived links
+// @Tags Admin
+// @Param id path int true "Domain ID"
+// @Success 204 "Domain removed"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Domain not found"
+// @Failure 409 {object} models.ErrorResponse "Links still use the domain"
+// @Security AdminAuth
+// @Router /admin/short-domains/{id} [delete]
+func DeleteShortDomain(c *gin.Context) {
+	var domain models.Domain
+	if err := database.DB.First(&domain, c.Param("id")).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Short link domain not found"))
+		return
+	}
+
+	var links, archived int64
+	err := database.DB.Unscoped().Model(&models.URL{}).Where("domain_id = ?", domain.ID).Count(&links).Error
+	if err == nil {
+		err = database.DB.Model(&models.ArchivedURL{}).Where("domain_id = ?", domain.ID).Count(&archived).Error
+	}
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to remove short link domain"))
+		return
+	}
+	if links+archived > 0 {
+		c.Error(models.NewAPIError(http.StatusConflict, models.ErrCodeConflict, "Links still use the short link domain"))
+		return
+	}
+
+	if err := database.DB.Delete(&domain).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to remove short link domain"))
+		return
+	}
+	domains.InvalidateShortDomains()
+
+	c.Status(http.StatusNoContent)
+}
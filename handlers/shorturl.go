@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/url"
+	"os"
+	"strings"
+
+	"url-shortener/domains"
+	"url-shortener/models"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// baseURL is BASE_URL, set by ConfigureBaseURL
+var baseURL string
+
+// ConfigureBaseURL sets BASE_URL, the address short links are built on
+// unless they are on a branded domain; empty to use the request's
+func ConfigureBaseURL(url string) {
+	baseURL = url
+}
+
+// buildShortURL returns the public URL of the link whose key (see
+// models.LinkKey) is shortCode
+func buildShortURL(c *gin.Context, shortCode string) string {
+	host, code := models.SplitLinkKey(shortCode)
+	return shortURLBase(c, host) + "/" + code
+}
+
+// shortURLBase returns the scheme and host short links are served on:
+// https on the branded domain host when set, otherwise BASE_URL when set,
+// or the scheme and host the client used, as told by a trusted proxy in
+// X-Forwarded-Proto and X-Forwarded-Host. Links on the default domain do
+// not resolve on branded ones, so set BASE_URL when serving any.
+func shortURLBase(c *gin.Context, host string) string {
+	if host != "" {
+		return "https://" + host
+	}
+	if baseURL != "" {
+		return strings.TrimRight(baseURL, "/")
+	}
+	return requestScheme(c) + "://" + requestHost(c)
+}
+
+// smsShortURL omits the scheme to save characters, using the branded domain
+// of the link, then SMS_DOMAIN when set, then the host of BASE_URL
+func smsShortURL(c *gin.Context, shortCode string) string {
+	host, code := models.SplitLinkKey(shortCode)
+	if host == "" {
+		host = os.Getenv("SMS_DOMAIN")
+	}
+	if host == "" {
+		if base, err := url.Parse(baseURL); err == nil && base.Host != "" {
+			host = base.Host + strings.TrimRight(base.Path, "/")
+		} else {
+			host = requestHost(c)
+		}
+	}
+	return host + "/" + code
+}
+
+// hostLinkKey returns the key of the link shortCode names on the host the
+// client asked for, which is a branded domain or the default one
+func hostLinkKey(c *gin.Context, shortCode string) string {
+	if domain := domains.ShortDomain(requestHost(c)); domain != nil {
+		return models.LinkKey(domain.Host, shortCode)
+	}
+	return shortCode
+}
+
+// pathLinkKey returns the key of the link named by the shortCode path
+// parameter, on the branded domain in the short_domain query parameter
+// when set
+func pathLinkKey(c *gin.Context) string {
+	return models.LinkKey(strings.ToLower(c.Query("short_domain")), c.Param("shortCode"))
+}
+
+// requestScheme returns the scheme the client used, http or https
+func requestScheme(c *gin.Context) string {
+	if proto, ok := forwardedHeader(c, "X-Forwarded-Proto"); ok {
+		if proto = strings.ToLower(proto); proto == "http" || proto == "https" {
+			return proto
+		}
+	}
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// requestHost returns the host the client asked for
+func requestHost(c *gin.Context) string {
+	if host, ok := forwardedHeader(c, "X-Forwarded-Host"); ok {
+		return host
+	}
+	return c.Request.Host
+}
+
+// forwardedHeader returns the value set by the trusted proxy the request
+// came through (see TRUSTED_PROXIES)
+func forwardedHeader(c *gin.Context, name string) (string, bool) {
+	return utils.ForwardedValue(c.GetHeader(name), c.RemoteIP())
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBuildShortURLBehindProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name           string
+		trustedProxies string
+		baseURL        string
+		remoteAddr     string
+		tls            bool
+		header         map[string]string
+		want           string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.7:50000", want: "http://sho.rt/abc123"},
+		{name: "direct over TLS", remoteAddr: "203.0.113.7:50000", tls: true, want: "https://sho.rt/abc123"},
+		{
+			name:       "no proxy trusted when unset",
+			remoteAddr: "10.0.0.5:50000",
+			header:     map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "evil.example"},
+			want:       "http://sho.rt/abc123",
+		},
+		{
+			name:           "trusted proxy",
+			trustedProxies: "10.0.0.0/8, 192.0.2.1",
+			remoteAddr:     "10.0.0.5:50000",
+			header:         map[string]string{"X-Forwarded-Proto": "https"},
+			want:           "https://sho.rt/abc123",
+		},
+		{
+			name:           "chain of proxies",
+			trustedProxies: "192.0.2.1",
+			remoteAddr:     "192.0.2.1:50000",
+			header:         map[string]string{"X-Forwarded-Proto": "http, HTTPS", "X-Forwarded-Host": "evil.example, links.example.com"},
+			want:           "https://links.example.com/abc123", // the values the trusted proxy appended, not the client's
+		},
+		{
+			name:           "trusted proxy appending nothing",
+			trustedProxies: "192.0.2.1",
+			remoteAddr:     "192.0.2.1:50000",
+			header:         map[string]string{"X-Forwarded-Host": "evil.example,"},
+			want:           "http://sho.rt/abc123",
+		},
+		{
+			name:           "untrusted client",
+			trustedProxies: "10.0.0.0/8",
+			remoteAddr:     "203.0.113.7:50000",
+			header:         map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "evil.example"},
+			want:           "http://sho.rt/abc123",
+		},
+		{
+			name:           "invalid list trusts nobody",
+			trustedProxies: "10.0.0.0/33",
+			remoteAddr:     "10.0.0.5:50000",
+			header:         map[string]string{"X-Forwarded-Proto": "https"},
+			want:           "http://sho.rt/abc123",
+		},
+		{
+			name:           "unknown scheme ignored",
+			trustedProxies: "10.0.0.0/8",
+			remoteAddr:     "10.0.0.5:50000",
+			header:         map[string]string{"X-Forwarded-Proto": "gopher"},
+			want:           "http://sho.rt/abc123",
+		},
+		{
+			name:       "base URL wins",
+			baseURL:    "https://go.example.com/s/",
+			remoteAddr: "10.0.0.5:50000",
+			header:     map[string]string{"X-Forwarded-Host": "links.example.com"},
+			want:       "https://go.example.com/s/abc123",
+		},
+	}
+	for _, tt := range tests {
+		t.Setenv("TRUSTED_PROXIES", tt.trustedProxies)
+		setBaseURL(t, tt.baseURL)
+
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "http://sho.rt/shorten", nil)
+		c.Request.RemoteAddr = tt.remoteAddr
+		if tt.tls {
+			c.Request.TLS = &tls.ConnectionState{}
+		}
+		for name, value := range tt.header {
+			c.Request.Header.Set(name, value)
+		}
+
+		if got := buildShortURL(c, "abc123"); got != tt.want {
+			t.Errorf("%s: short URL = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildShortURLOnBrandedDomain(t *testing.T) {
+	setBaseURL(t, "https://sho.rt")
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "http://evil.example/shorten", nil)
+	if got := buildShortURL(c, models.LinkKey("go.acme.com", "abc123")); got != "https://go.acme.com/abc123" {
+		t.Errorf("branded short URL = %q", got)
+	}
+	if got := buildShortURL(c, "abc123"); got != "https://sho.rt/abc123" {
+		t.Errorf("default short URL = %q", got)
+	}
+	if got := smsShortURL(c, models.LinkKey("go.acme.com", "b7")); got != "go.acme.com/b7" {
+		t.Errorf("branded SMS short URL = %q", got)
+	}
+}
+
+func TestSMSShortURLUsesBaseURLHost(t *testing.T) {
+	t.Setenv("SMS_DOMAIN", "")
+	setBaseURL(t, "https://go.example.com/s")
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "http://sho.rt/shorten", nil)
+	if got := smsShortURL(c, "b7"); got != "go.example.com/s/b7" {
+		t.Errorf("SMS short URL = %q", got)
+	}
+}
+
+// setBaseURL configures BASE_URL for the test
+func setBaseURL(t *testing.T, url string) {
+	previous := baseURL
+	ConfigureBaseURL(url)
+	t.Cleanup(func() { ConfigureBaseURL(previous) })
+}
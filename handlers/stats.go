@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseMaxAge reads the optional max_age query parameter (seconds)
+func parseMaxAge(c *gin.Context) (time.Duration, error) {
+	raw := c.Query("max_age")
+	if raw == "" {
+		return service.DefaultStatsMaxAge, nil
+	}
+
+	seconds, err := strconv.Atoi(raw)
+	if err != nil || seconds < 0 || time.Duration(seconds)*time.Second > service.MaxStatsMaxAge {
+		return 0, errors.New("max_age must be between 0 and 300 seconds")
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
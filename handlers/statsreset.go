@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"url-shortener/buildinfo"
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How long a reset waits for another instance to finish flushing clicks
+const statsResetLockWait = 5 * time.Second
+
+// ResetLinkStats godoc
+// @Summary Reset the stats of one of your links
+// @ID resetLinkStats
+// @Description Zero the click counters of a link owned by the caller and of its variants, for campaigns reusing a short code for a new push. The counters, including clicks not written yet, are kept as a stats period listed by GET /stats/{shortCode}/resets. Click events, and so time series, referrers and unique visitors, are kept; query them from the reset time. Locked links cannot be reset; the action is audit-logged.
+// @Tags Links
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {object} models.LinkStatsReset
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope, or the link is locked"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Failure 503 {object} models.ErrorResponse "Clicks are being written, try again"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode}/stats/reset [post]
+func ResetLinkStats(c *gin.Context) {
+	urlRecord, ok := ownedLink(c)
+	if !ok {
+		return
+	}
+	resetStats(c, urlRecord, "user:"+strconv.FormatUint(uint64(*middleware.CurrentOwnerID(c)), 10))
+}
+
+// ResetURLStats godoc
+// @Summary Reset the stats of any link
+// @ID resetURLStats
+// @Description Zero the click counters of any link, including anonymous ones. Same rules as POST /links/{shortCode}/stats/reset; the action is audit-logged.
+// @Tags Admin
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {object} models.LinkStatsReset
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 403 {object} models.ErrorResponse "Short URL is locked"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 503 {object} models.ErrorResponse "Clicks are being written, try again"
+// @Security AdminAuth
+// @Router /admin/urls/{shortCode}/stats/reset [post]
+func ResetURLStats(c *gin.Context) {
+	urlRecord, ok := unlockedLink(c, database.DB.Where("short_code = ?", pathLinkKey(c)))
+	if !ok {
+		return
+	}
+	resetStats(c, urlRecord, "admin")
+}
+
+// ListStatsResets godoc
+// @Summary Stats periods of a link
+// @ID listStatsResets
+// @Description List the counters a link had each time its stats were reset, latest first. The current period, since the last reset_at, is reported by GET /stats/{shortCode}.
+// @Tags URL Shortener
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {object} models.StatsResetsResponse
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the read_stats scope"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Router /stats/{shortCode}/resets [get]
+func ListStatsResets(c *gin.Context) {
+	urlRecord, err := findStatsLink(c.Request.Context(), pathLinkKey(c))
+	if err != nil {
+		c.Error(models.ErrLinkNotFound)
+		return
+	}
+	resets, err := database.LinkStatsResets(c.Request.Context(), urlRecord.ID)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list stats resets"))
+		return
+	}
+	c.JSON(http.StatusOK, models.StatsResetsResponse{ShortCode: urlRecord.ShortCode, Resets: resets})
+}
+
+// resetStats zeroes a link's counters, recording the action as actor.
+// Click flushes, here and on other instances, are held off meanwhile so
+// that every pending click is counted either before or after the reset.
+func resetStats(c *gin.Context, urlRecord *models.URL, actor string) {
+	ctx := c.Request.Context()
+	clickWriteMu.Lock()
+	defer clickWriteMu.Unlock()
+	if !lockClickFlushFor(ctx, statsResetLockWait) {
+		c.Error(models.NewAPIError(http.StatusServiceUnavailable, models.ErrCodeUnavailable, "Clicks are being written, try again"))
+		return
+	}
+	defer cache.UnlockClickFlush(buildinfo.Instance)
+
+	var variantIDs []uint
+	if err := database.DB.WithContext(ctx).Model(&models.LinkVariant{}).Where("url_id = ?", urlRecord.ID).Pluck("id", &variantIDs).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to reset stats"))
+		return
+	}
+	pendingClicks, pendingVariantClicks := takePendingClicks(urlRecord.ID, variantIDs)
+
+	reset, err := database.ResetLinkStats(ctx, urlRecord.ID, actor, pendingClicks, pendingVariantClicks)
+	if err != nil {
+		// The taken clicks are written with the next flush instead
+		pendingMu.Lock()
+		pendingURLs[urlRecord.ID] += pendingClicks
+		for id, count := range pendingVariantClicks {
+			pendingVariants[id] += count
+		}
+		pendingMu.Unlock()
+		slog.ErrorContext(c.Request.Context(), "Failed to reset the stats of link", "short_code", urlRecord.ShortCode, "error", err)
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to reset stats"))
+		return
+	}
+
+	cache.InvalidateStats(urlRecord.ShortCode)
+	recordAuditAs(c, actor, models.AuditActionReset, urlRecord.ShortCode,
+		fmt.Sprintf("%d clicks since %s", reset.ClickCount, reset.PeriodStart.UTC().Format(time.RFC3339)))
+	c.JSON(http.StatusOK, reset)
+}
+
+// lockClickFlushFor takes the Redis click flush lock, waiting up to wait
+// for another instance holding it. Without Redis there is nothing to lock.
+func lockClickFlushFor(ctx context.Context, wait time.Duration) bool {
+	deadline := time.Now().Add(wait)
+	for {
+		locked, err := cache.LockClickFlush(buildinfo.Instance, clickFlushShutdownTimeout)
+		if err != nil {
+			return cache.RedisClient == nil
+		}
+		if locked {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// takePendingClicks removes the click increments of a link and its variants
+// not written to the database yet, from Redis and from memory
+func takePendingClicks(urlID uint, variantIDs []uint) (int64, map[uint]int64) {
+	clicks, variants, err := cache.TakePendingClicks(urlID, variantIDs)
+	if err != nil {
+		variants = make(map[uint]int64)
+	}
+
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	clicks += pendingURLs[urlID]
+	delete(pendingURLs, urlID)
+	for _, id := range variantIDs {
+		if count := pendingVariants[id]; count > 0 {
+			variants[id] += count
+			delete(pendingVariants, id)
+		}
+	}
+	return clicks, variants
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"url-shortener/buildinfo"
+	"url-shortener/fleet"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// An endpoint is degraded when it answers fewer requests than this without
+// a server error over the last hour
+const degradedAvailability = 99.0
+
+// Endpoints with fewer requests in the last hour than this can't degrade the
+// overall status, so a single failure on a quiet route isn't an incident
+const degradedMinRequests = 20
+
+// GetStatus godoc
+// @Summary Service status
+// @ID getStatus
+// @Description Rolling availability and latency per endpoint over the last hour and day, for generating a public status page. Computed from the answering instance's own request metrics since it started, or from every instance's when scope is fleet and METRICS_AGGREGATION is on. The overall status is degraded when a busy endpoint answered less than 99% of requests in the last hour without a server error.
+// @Tags System
+// @Produce json
+// @Param scope query string false "instance (default) or fleet; fleet falls back to instance when aggregation is unavailable"
+// @Success 200 {object} models.StatusResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid scope"
+// @Router /status [get]
+func GetStatus(c *gin.Context) {
+	now := time.Now().UTC()
+	scope, snapshots, ok := metricsScope(c, now)
+	if !ok {
+		return
+	}
+
+	response := models.StatusResponse{
+		Status:      models.StatusOperational,
+		Scope:       scope,
+		Instance:    buildinfo.Instance,
+		GeneratedAt: now,
+	}
+	if scope == models.MetricsScopeFleet {
+		merged := fleet.Merge(snapshots)
+		response.Instances = fleet.Instances(snapshots)
+		response.Since = merged.Since
+		response.Endpoints = middleware.SummarizeEndpoints(merged.Endpoints)
+	} else {
+		response.Since = middleware.MetricsSince()
+		response.Endpoints = middleware.EndpointMetrics(now)
+	}
+
+	for _, endpoint := range response.Endpoints {
+		if len(endpoint.Windows) == 0 {
+			continue
+		}
+		latest := endpoint.Windows[0]
+		if latest.Requests >= degradedMinRequests && latest.Availability < degradedAvailability {
+			response.Status = models.StatusDegraded
+		}
+	}
+
+	c.Header("Cache-Control", "public, max-age=30")
+	c.JSON(http.StatusOK, response)
+}
+
+// metricsScope reads the scope query parameter. In the fleet scope it returns
+// the snapshot of every instance; when aggregation is off or Redis fails it
+// falls back to the instance scope rather than failing the request.
+func metricsScope(c *gin.Context, now time.Time) (string, []fleet.Snapshot, bool) {
+	switch c.Query("scope") {
+	case "", models.MetricsScopeInstance:
+		return models.MetricsScopeInstance, nil, true
+	case models.MetricsScopeFleet:
+	default:
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "scope must be instance or fleet"))
+		return "", nil, false
+	}
+
+	if !fleet.Enabled() {
+		return models.MetricsScopeInstance, nil, true
+	}
+	snapshots, err := fleet.Collect(now)
+	if err != nil {
+		slog.Error("Failed to collect fleet metrics, reporting this instance only", "error", err)
+		return models.MetricsScopeInstance, nil, true
+	}
+	return models.MetricsScopeFleet, snapshots, true
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Most short codes suggested on the not found page
+const maxCodeSuggestions = 3
+
+// Codes longer than this are only matched against the visitor's recent
+// codes, as their near misses on a branded domain are too many to look up
+const maxNearMissLookupLength = 16
+
+// codeSuggestionsEnabled reports whether the not found page suggests short
+// codes one typo away from the one asked for, as set by
+// NOT_FOUND_SUGGESTIONS
+func codeSuggestionsEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("NOT_FOUND_SUGGESTIONS"))
+	return enabled
+}
+
+// recordRecentCode remembers that the visitor of a click followed its link,
+// so it can be suggested when they mistype it
+func recordRecentCode(visitorHash string, click clickRecord) {
+	if visitorHash == "" || !codeSuggestionsEnabled() {
+		return
+	}
+	cache.RecordRecentCode(visitorHash, click.shortCode, click.clickedAt)
+}
+
+// suggestCodes returns up to 3 live short codes one typo away from the link
+// key a browser asked for in vain: first those the visitor followed lately,
+// newest first, then, on a branded domain, the domain's other codes. Other
+// codes on the default domain are never suggested, as that would let anyone
+// discover links they were not given.
+func suggestCodes(c *gin.Context, key string) []string {
+	if !codeSuggestionsEnabled() || !strings.Contains(c.GetHeader("Accept"), "text/html") || database.DB == nil {
+		return nil
+	}
+	host, code := models.SplitLinkKey(key)
+	ctx := c.Request.Context()
+
+	var candidates []string
+	visitor := c.ClientIP() + " " + truncateHeader(c.Request.UserAgent())
+	if visitorHash, err := cache.VisitorHash(visitor); err == nil {
+		recent, _ := cache.RecentCodes(visitorHash)
+		for _, recentKey := range recent {
+			if recentHost, recentCode := models.SplitLinkKey(recentKey); recentHost == host && utils.OneTypoApart(code, recentCode) {
+				candidates = append(candidates, recentKey)
+			}
+		}
+	}
+	recentCount := len(candidates)
+	if host != "" && len(code) <= maxNearMissLookupLength {
+		for _, nearMiss := range utils.NearMissCodes(code) {
+			candidates = append(candidates, models.LinkKey(host, nearMiss))
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	liveKeys, err := database.LiveShortCodes(ctx, candidates)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to look up short code suggestions", "short_code", key, "error", err)
+		return nil
+	}
+	live := make(map[string]bool, len(liveKeys))
+	for _, liveKey := range liveKeys {
+		live[liveKey] = true
+	}
+	sort.Strings(candidates[recentCount:])
+
+	var suggestions []string
+	for _, candidate := range candidates {
+		if !live[candidate] {
+			continue
+		}
+		// Recent codes are listed again among the domain's
+		delete(live, candidate)
+		_, suggestion := models.SplitLinkKey(candidate)
+		if suggestions = append(suggestions, suggestion); len(suggestions) == maxCodeSuggestions {
+			break
+		}
+	}
+	return suggestions
+}
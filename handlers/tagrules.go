@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"url-shortener/autotag"
+	"url-shortener/database"
+	"url-shortener/jobs"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListTagRules godoc
+// @Summary List tag rules
+// @ID listTagRules
+// @Description List the rules tagging new links by destination, in the order their tags are added
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} models.TagRule
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/tag-rules [get]
+func ListTagRules(c *gin.Context) {
+	rules := []models.TagRule{}
+	if err := database.DB.Order("id").Find(&rules).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list tag rules"))
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateTagRule godoc
+// @Summary Create a tag rule
+// @ID createTagRule
+// @Description Create a rule adding a tag to new links whose destination is on a domain (the host or any of its subdomains, or only its subdomains as *.host) or matches a regex. Existing links get it with POST /admin/tag-rules/backfill.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body models.TagRuleRequest true "Tag rule"
+// @Success 201 {object} models.TagRule
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/tag-rules [post]
+func CreateTagRule(c *gin.Context) {
+	var request models.TagRuleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	var rule models.TagRule
+	applyTagRuleRequest(&rule, &request)
+	if err := autotag.Validate(&rule); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	if err := database.DB.Create(&rule).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create tag rule"))
+		return
+	}
+	autotag.Invalidate()
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateTagRule godoc
+// @Summary Update a tag rule
+// @ID updateTagRule
+// @Description Replace an existing tag rule. Links it tagged keep their tags.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Param request body models.TagRuleRequest true "Tag rule"
+// @Success 200 {object} models.TagRule
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Tag rule not found"
+// @Security AdminAuth
+// @Router /admin/tag-rules/{id} [put]
+func UpdateTagRule(c *gin.Context) {
+	var rule models.TagRule
+	if err := database.DB.First(&rule, c.Param("id")).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Tag rule not found"))
+		return
+	}
+
+	var request models.TagRuleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	applyTagRuleRequest(&rule, &request)
+	if err := autotag.Validate(&rule); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	if err := database.DB.Save(&rule).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update tag rule"))
+		return
+	}
+	autotag.Invalidate()
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteTagRule godoc
+// @Summary Delete a tag rule
+// @ID deleteTagRule
+// @Description Delete a tag rule. Links it tagged keep their tags.
+// @Tags Admin
+// @Param id path int true "Rule ID"
+// @Success 204 "Rule deleted"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "Tag rule not found"
+// @Security AdminAuth
+// @Router /admin/tag-rules/{id} [delete]
+func DeleteTagRule(c *gin.Context) {
+	result := database.DB.Delete(&models.TagRule{}, c.Param("id"))
+	if result.Error != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete tag rule"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Tag rule not found"))
+		return
+	}
+	autotag.Invalidate()
+
+	c.Status(http.StatusNoContent)
+}
+
+// BackfillTagRules godoc
+// @Summary Apply the tag rules to existing links
+// @ID backfillTagRules
+// @Description Queue a bulk operation adding the tags of the enabled tag rules to every existing link whose destination matches them, as they are when each batch runs. Follow its progress with GET /admin/links/bulk/{id}. Locked links are skipped, links keep at most 20 tags, and every link tagged is audit-logged.
+// @Tags Admin
+// @Produce json
+// @Success 202 {object} models.BulkOperation
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/tag-rules/backfill [post]
+func BackfillTagRules(c *gin.Context) {
+	op := models.BulkOperation{Action: models.BulkActionAutoTag, Status: models.BulkStatusQueued}
+	matched, err := database.CountBulkLinks(c.Request.Context(), &op)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to count links"))
+		return
+	}
+	op.Matched = matched
+	if err := database.DB.WithContext(c.Request.Context()).Create(&op).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to queue bulk operation"))
+		return
+	}
+	jobs.WakeBulkOperationRunner()
+
+	recordAudit(c, models.AuditActionBulkTag, "", fmt.Sprintf("bulk operation %d applying tag rules to %d links", op.ID, op.Matched))
+	c.JSON(http.StatusAccepted, op)
+}
+
+func applyTagRuleRequest(rule *models.TagRule, request *models.TagRuleRequest) {
+	rule.Type = request.Type
+	rule.Pattern = request.Pattern
+	rule.Tag = request.Tag
+	rule.Description = request.Description
+	rule.Enabled = request.Enabled == nil || *request.Enabled
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Number of backup codes issued when 2FA is enabled
+const backupCodeCount = 10
+
+// EnrollTwoFactor godoc
+// @Summary Start two-factor enrollment
+// @ID enrollTwoFactor
+// @Description Generate a TOTP secret for the current user. 2FA is enabled once a code is verified.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} models.TwoFactorEnrollResponse
+// @Failure 401 {object} models.ErrorResponse "Invalid or expired session"
+// @Failure 409 {object} models.ErrorResponse "Two-factor authentication already enabled"
+// @Security SessionAuth
+// @Router /auth/2fa/enroll [post]
+func EnrollTwoFactor(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user.TwoFactorEnabled {
+		c.Error(models.NewAPIError(http.StatusConflict, models.ErrCodeConflict, "Two-factor authentication already enabled"))
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to generate secret"))
+		return
+	}
+	if err := database.DB.Model(user).Updates(map[string]interface{}{"totp_secret": secret, "totp_last_step": 0}).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to start enrollment"))
+		return
+	}
+
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "URL Shortener"
+	}
+
+	c.JSON(http.StatusOK, models.TwoFactorEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(secret, issuer, user.Email),
+	})
+}
+
+// VerifyTwoFactor godoc
+// @Summary Complete two-factor enrollment
+// @ID verifyTwoFactor
+// @Description Verify a TOTP code for the enrolled secret, enable 2FA and return backup codes
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} models.BackupCodesResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid code"
+// @Failure 401 {object} models.ErrorResponse "Invalid or expired session"
+// @Security SessionAuth
+// @Router /auth/2fa/verify [post]
+func VerifyTwoFactor(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var request models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	if user.TOTPSecret == "" || !acceptTOTP(c.Request.Context(), user, request.Code) {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeTwoFactorInvalid, "Invalid two-factor code"))
+		return
+	}
+
+	if err := database.DB.Model(user).Update("two_factor_enabled", true).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to enable two-factor authentication"))
+		return
+	}
+
+	codes, err := regenerateBackupCodes(user.ID)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to generate backup codes"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.BackupCodesResponse{BackupCodes: codes})
+}
+
+// RegenerateBackupCodes godoc
+// @Summary Regenerate backup codes
+// @ID regenerateBackupCodes
+// @Description Replace all backup codes after confirming a current TOTP code
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} models.BackupCodesResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid code"
+// @Failure 401 {object} models.ErrorResponse "Invalid or expired session"
+// @Security SessionAuth
+// @Router /auth/2fa/backup-codes [post]
+func RegenerateBackupCodes(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var request models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	if !user.TwoFactorEnabled || !acceptTOTP(c.Request.Context(), user, request.Code) {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeTwoFactorInvalid, "Invalid two-factor code"))
+		return
+	}
+
+	codes, err := regenerateBackupCodes(user.ID)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to generate backup codes"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.BackupCodesResponse{BackupCodes: codes})
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @ID disableTwoFactor
+// @Description Disable 2FA after confirming a TOTP or backup code
+// @Tags Auth
+// @Accept json
+// @Param request body models.TwoFactorCodeRequest true "TOTP or backup code"
+// @Success 204 "Two-factor authentication disabled"
+// @Failure 400 {object} models.ErrorResponse "Invalid code"
+// @Failure 401 {object} models.ErrorResponse "Invalid or expired session"
+// @Failure 403 {object} models.ErrorResponse "Two-factor authentication is required for admins"
+// @Security SessionAuth
+// @Router /auth/2fa/disable [post]
+func DisableTwoFactor(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var request models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	if user.Role == models.RoleAdmin && middleware.AdminTwoFactorRequired() {
+		c.Error(models.NewAPIError(http.StatusForbidden, models.ErrCodeTwoFactorRequired, "Two-factor authentication is required for admins"))
+		return
+	}
+
+	if !user.TwoFactorEnabled || !checkSecondFactor(c.Request.Context(), user, request.Code) {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeTwoFactorInvalid, "Invalid two-factor code"))
+		return
+	}
+
+	err := database.DB.Model(user).Updates(map[string]interface{}{"two_factor_enabled": false, "totp_secret": "", "totp_last_step": 0}).Error
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to disable two-factor authentication"))
+		return
+	}
+	database.DB.Where("user_id = ?", user.ID).Delete(&models.BackupCode{})
+
+	c.Status(http.StatusNoContent)
+}
+
+// checkSecondFactor accepts a current TOTP code or an unused backup code,
+// consuming the backup code if one is used
+func checkSecondFactor(ctx context.Context, user *models.User, code string) bool {
+	code = strings.TrimSpace(code)
+	if acceptTOTP(ctx, user, code) {
+		return true
+	}
+
+	result := database.DB.WithContext(ctx).Model(&models.BackupCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, utils.HashToken(strings.ToLower(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		slog.ErrorContext(ctx, "Failed to check backup code", "user_id", user.ID, "error", result.Error)
+		return false
+	}
+	return result.RowsAffected > 0
+}
+
+// acceptTOTP accepts a TOTP code of user once: codes of the time step of the
+// last one accepted, or of an earlier step, are refused even while they are
+// still current
+func acceptTOTP(ctx context.Context, user *models.User, code string) bool {
+	step, ok := utils.MatchTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return false
+	}
+
+	// Conditional, so concurrent requests with the same code accept it once
+	result := database.DB.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", user.ID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		slog.ErrorContext(ctx, "Failed to record the TOTP step", "user_id", user.ID, "error", result.Error)
+		return false
+	}
+	if result.RowsAffected == 0 {
+		return false
+	}
+	user.TOTPLastStep = step
+	return true
+}
+
+// regenerateBackupCodes replaces a user's backup codes, returning the plaintext codes
+func regenerateBackupCodes(userID uint) ([]string, error) {
+	codes := make([]string, 0, backupCodeCount)
+	records := make([]models.BackupCode, 0, backupCodeCount)
+	for i := 0; i < backupCodeCount; i++ {
+		code, err := utils.GenerateToken(5)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.BackupCode{UserID: userID, CodeHash: utils.HashToken(code)})
+	}
+
+	if err := database.DB.Where("user_id = ?", userID).Delete(&models.BackupCode{}).Error; err != nil {
+		return nil, err
+	}
+	if err := database.DB.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
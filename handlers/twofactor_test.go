@@ -0,0 +1,104 @@
+package handlers_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/handlers"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/utils"
+)
+
+// currentTOTP computes the code of secret for the current time step
+func currentTOTP(t *testing.T, secret string) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(time.Now().Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+func TestTOTPCodesAreAcceptedOnce(t *testing.T) {
+	router := sessionsRouter(t)
+	twoFactor := router.Group("/auth/2fa", middleware.SessionAuth())
+	twoFactor.POST("/enroll", handlers.EnrollTwoFactor)
+	twoFactor.POST("/verify", handlers.VerifyTwoFactor)
+	twoFactor.POST("/backup-codes", handlers.RegenerateBackupCodes)
+
+	createAccount(t, "user@example.com", models.RoleUser)
+	session := logIn(t, router, "user@example.com")
+	recorder := sendJSON(router, http.MethodPost, "/auth/2fa/enroll", session.AccessToken, "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("enroll = %d: %s", recorder.Code, recorder.Body)
+	}
+	var enrollment models.TwoFactorEnrollResponse
+	json.Unmarshal(recorder.Body.Bytes(), &enrollment)
+
+	// Codes change every 30s; start a fresh step so one code serves the test
+	for time.Now().Unix()%30 > 25 {
+		time.Sleep(time.Second)
+	}
+	code := currentTOTP(t, enrollment.Secret)
+	if recorder := sendJSON(router, http.MethodPost, "/auth/2fa/verify", session.AccessToken, `{"code":"`+code+`"}`); recorder.Code != http.StatusOK {
+		t.Fatalf("verify = %d: %s", recorder.Code, recorder.Body)
+	}
+	if recorder := sendJSON(router, http.MethodPost, "/auth/2fa/backup-codes", session.AccessToken, `{"code":"`+code+`"}`); recorder.Code != http.StatusBadRequest {
+		t.Errorf("backup codes with the code just used = %d, want 400", recorder.Code)
+	}
+	if recorder := sendJSON(router, http.MethodPost, "/auth/login", "", `{"email":"user@example.com","password":"correct horse","otp_code":"`+code+`"}`); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("login with the code just used = %d, want 401", recorder.Code)
+	}
+
+	// A code of an earlier step than the last one accepted is refused too
+	var user models.User
+	database.DB.Where("email = ?", "user@example.com").First(&user)
+	database.DB.Model(&user).Update("totp_last_step", user.TOTPLastStep-1)
+	if recorder := sendJSON(router, http.MethodPost, "/auth/2fa/backup-codes", session.AccessToken, `{"code":"`+code+`"}`); recorder.Code != http.StatusOK {
+		t.Errorf("backup codes once the code's step is newer than the last = %d: %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestAdminTwoFactorRequiredOnAdminRoutes(t *testing.T) {
+	t.Setenv("REQUIRE_ADMIN_2FA", "true")
+	router := sessionsRouter(t)
+	admin := createAccount(t, "admin@example.com", models.RoleAdmin)
+	key := "usk_admin-without-2fa"
+	apiKey := models.APIKey{Name: "admin", KeyHash: utils.HashToken(key), SigningSecret: "secret", Scopes: []string{models.ScopeAdmin, models.ScopeReadStats}, UserID: &admin.ID}
+	if err := database.DB.Create(&apiKey).Error; err != nil {
+		t.Fatal(err)
+	}
+	session := logIn(t, router, "admin@example.com")
+
+	for path, token := range map[string]string{"/admin/users": key, "/links": key} {
+		if recorder := sendJSON(router, http.MethodGet, path, token, ""); recorder.Code != http.StatusForbidden {
+			t.Errorf("API key of an admin without 2FA on %s = %d, want 403", path, recorder.Code)
+		}
+	}
+	if recorder := sendJSON(router, http.MethodGet, "/admin/users", session.AccessToken, ""); recorder.Code != http.StatusForbidden {
+		t.Errorf("session of an admin without 2FA on /admin = %d, want 403", recorder.Code)
+	}
+
+	database.DB.Model(&admin).Update("two_factor_enabled", true)
+	if recorder := sendJSON(router, http.MethodGet, "/admin/users", key, ""); recorder.Code != http.StatusOK {
+		t.Errorf("API key of an admin with 2FA = %d: %s", recorder.Code, recorder.Body)
+	}
+	if recorder := sendJSON(router, http.MethodGet, "/admin/users", session.AccessToken, ""); recorder.Code != http.StatusOK {
+		t.Errorf("session of an admin with 2FA = %d: %s", recorder.Code, recorder.Body)
+	}
+}
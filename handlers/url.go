@@ -1,260 +1,311 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
-	"net/url"
+	"strings"
 	"time"
 
 	"url-shortener/cache"
-	"url-shortener/database"
+	"url-shortener/middleware"
 	"url-shortener/models"
-	"url-shortener/utils"
+	"url-shortener/service"
+	"url-shortener/storage"
 
 	"github.com/gin-gonic/gin"
 )
 
 // ShortenURL godoc
 // @Summary Create a short URL
+// @ID shortenURL
 // @Description Create a short URL from a long URL with optional expiration
 // @Tags URL Shortener
 // @Accept json
 // @Produce json
 // @Param request body models.ShortenRequest true "URL to shorten"
 // @Success 201 {object} models.ShortenResponse
+// @Header 201 {string} X-Quota-Warning "Usage of the monthly link quota, once past 80%: plan, used, limit and resets"
 // @Success 200 {object} models.ShortenResponse "URL already exists"
-// @Failure 400 {object} map[string]string "Invalid request"
-// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 400 {object} models.ErrorResponse "Invalid request or unknown domain"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key or request signature, or anonymous shortening is disabled"
+// @Failure 403 {object} models.ErrorResponse "CAPTCHA verification failed or API key not permitted"
+// @Failure 409 {object} models.ErrorResponse "The caller already shortened the URL and if_exists is error, or the custom alias is taken"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 503 {object} models.ErrorResponse "CAPTCHA verification unavailable"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded, or the monthly link quota is used up"
+// @Security ApiKeyAuth
 // @Router /shorten [post]
 func ShortenURL(c *gin.Context) {
+	defaultHandler().ShortenURL(c)
+}
+
+// ShortenURL serves POST /shorten over h's stores
+func (h *Handler) ShortenURL(c *gin.Context) {
 	var request models.ShortenRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
 		return
 	}
 
-	// Validate URL
-	if !isValidURL(request.URL) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid URL format"})
+	urlRecord, created, err := h.stores.Shorten(c.Request.Context(), requestCaller(c), request)
+	if err != nil {
+		c.Error(err)
 		return
 	}
-
-	// Check cache first for existing URL
-	if shortCode, err := cache.GetShortCodeForOriginalURL(request.URL); err == nil {
-		// Found in cache, get the full URL data
-		if urlData, err := cache.GetURLMapping(shortCode); err == nil {
-			shortURL := buildShortURL(c, urlData.ShortCode)
-			response := models.ShortenResponse{
-				ShortURL:    shortURL,
-				OriginalURL: urlData.OriginalURL,
-				ShortCode:   urlData.ShortCode,
-				ExpiresAt:   urlData.ExpiresAt,
-			}
-			c.JSON(http.StatusOK, response)
-			return
-		}
-	}
-
-	// Check database if not in cache
-	var existingURL models.URL
-	if err := database.DB.Where("original_url = ?", request.URL).First(&existingURL).Error; err == nil {
-		// URL already exists in database, cache it and return
-		cache.CacheURLMapping(existingURL.ShortCode, &existingURL)
-		cache.CacheOriginalURLMapping(existingURL.OriginalURL, existingURL.ShortCode)
-
-		shortURL := buildShortURL(c, existingURL.ShortCode)
-		response := models.ShortenResponse{
-			ShortURL:    shortURL,
-			OriginalURL: existingURL.OriginalURL,
-			ShortCode:   existingURL.ShortCode,
-			ExpiresAt:   existingURL.ExpiresAt,
-		}
-		c.JSON(http.StatusOK, response)
+	if !created {
+		c.JSON(http.StatusOK, buildShortenResponse(c, urlRecord))
 		return
 	}
 
-	// Generate short code
-	shortCode := utils.GenerateShortCode()
-
-	// Create URL record
-	urlRecord := models.URL{
-		OriginalURL: request.URL,
-		ShortCode:   shortCode,
-		ClickCount:  0,
+	response := buildShortenResponse(c, urlRecord)
+	if request.CodeStyle == models.CodeStyleSMS {
+		response.ShortURL = smsShortURL(c, urlRecord.ShortCode)
 	}
-
-	// Set expiration if provided
-	if request.ExpiresIn > 0 {
-		expiresAt := time.Now().AddDate(0, 0, request.ExpiresIn)
-		urlRecord.ExpiresAt = &expiresAt
+	response.Warnings = append(h.destinationWarnings(c, urlRecord.ShortCode, urlRecord.OriginalURL), quotaWarnings(c)...)
+	if len(request.Variants) > 0 {
+		response.Variants = newVariantStats(c, urlRecord)
 	}
+	c.JSON(http.StatusCreated, response)
+}
 
-	// Save to database
-	if err := database.DB.Create(&urlRecord).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create short URL"})
-		return
+// requestCaller describes the client making a request to the service layer
+func requestCaller(c *gin.Context) service.Caller {
+	return service.Caller{
+		APIKey:   middleware.CurrentAPIKey(c),
+		Policy:   middleware.CurrentPolicy(c),
+		ClientIP: c.ClientIP(),
+		Admin:    middleware.IsAdmin(c),
+		ShortURL: func(shortCode string) string { return buildShortURL(c, shortCode) },
 	}
+}
 
-	// Cache the new URL mapping
-	cache.CacheURLMapping(urlRecord.ShortCode, &urlRecord)
-	cache.CacheOriginalURLMapping(urlRecord.OriginalURL, urlRecord.ShortCode)
+// checkShortenAllowed validates a URL to shorten and applies CAPTCHA, API key
+// domain restrictions, malicious URL screening and brand safety rules. It writes the error response
+// and returns false when the request must stop; otherwise it returns the
+// safety action that applies to the URL.
+func checkShortenAllowed(c *gin.Context, rawURL, captchaToken string) (string, bool) {
+	safetyAction, apiErr := service.CheckDestination(c.Request.Context(), requestCaller(c), rawURL, captchaToken)
+	return safetyActionOrError(c, safetyAction, apiErr)
+}
 
-	// Build response
-	shortURL := buildShortURL(c, shortCode)
-	response := models.ShortenResponse{
-		ShortURL:    shortURL,
-		OriginalURL: urlRecord.OriginalURL,
-		ShortCode:   urlRecord.ShortCode,
-		ExpiresAt:   urlRecord.ExpiresAt,
+// safetyActionOrError writes apiErr, if any, returning false, or returns the
+// safety action a check found
+func safetyActionOrError(c *gin.Context, safetyAction string, apiErr *models.APIError) (string, bool) {
+	if apiErr != nil {
+		c.Error(apiErr)
+		return "", false
 	}
+	return safetyAction, true
+}
 
-	c.JSON(http.StatusCreated, response)
+// createURLRecord stores a new link for an already validated request,
+// caches it and notifies approvers and hook subscribers
+func createURLRecord(c *gin.Context, request models.ShortenRequest, safetyAction string, shadowBanned bool) (*models.URL, error) {
+	// Set expiration if provided, or the caller's default lifetime
+	expiresAt := service.ExpiryPolicy(requestCaller(c)).ExpiresAt(request.ExpiresIn, time.Now())
+	return createURLRecordUntil(c, request, expiresAt, safetyAction, shadowBanned)
+}
+
+// createURLRecordUntil is createURLRecord for a link expiring at expiresAt
+// (nil for never) regardless of request.ExpiresIn
+func createURLRecordUntil(c *gin.Context, request models.ShortenRequest, expiresAt *time.Time, safetyAction string, shadowBanned bool) (*models.URL, error) {
+	return service.Default().CreateLink(c.Request.Context(), requestCaller(c), request, expiresAt, safetyAction, shadowBanned)
 }
 
 // RedirectURL godoc
 // @Summary Redirect to original URL
-// @Description Redirect to the original URL using the short code and increment click count
+// @ID redirectURL
+// @Description Redirect to the original URL using the short code and increment click count. Browsers (Accept: text/html) get an HTML page for missing, expired and pending links instead of JSON, translated according to Accept-Language. With ?info=1, or an Accept header preferring text/plain, a plaintext summary of the destination, creation date and clicks is returned instead of redirecting, and no click is counted. With ?preview=1, or + appended to the short code (GET /abc123+), an HTML page shows the same along with the title and description of the destination page, so recipients can inspect the link before following it; no click is counted either. Links created with max_clicks expire after that many redirects, which neither the summary, the preview page nor link preview crawlers (answered with 204) use up. Soft launched links only redirect the rollout_percent of visitors whose address and user agent hash into it; the others get a holding page. Links with a velocity_limit redirect at most that many visitors per second or minute; the others get a waiting page retrying on its own (503 with Retry-After), 429 with Retry-After, or a 302 to the limit's overflow_url, and are not counted as clicks. Requests to a branded short link domain resolve the short code among that domain's links only.
 // @Tags URL Shortener
-// @Param shortCode path string true "Short code"
-// @Success 301 "Redirects to original URL"
-// @Failure 404 {object} map[string]string "Short URL not found"
-// @Failure 410 {object} map[string]string "Short URL has expired"
+// @Produce plain,html
+// @Param shortCode path string true "Short code, followed by + for the preview page"
+// @Param info query int false "Set to 1 for a plaintext summary instead of a redirect"
+// @Param preview query int false "Set to 1 for an HTML preview page instead of a redirect"
+// @Success 200 {string} string "Plaintext link summary, or HTML preview page"
+// @Success 301 "Redirects to original URL, or from the old short code of a renamed link to its new short URL"
+// @Success 302 "Split links redirect to one of their variants, and velocity limited links beyond their limit to their overflow_url"
+// @Success 204 "Link preview crawlers fetching a link with max_clicks"
+// @Failure 403 {object} models.ErrorResponse "Short URL is pending approval"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 410 {object} models.ErrorResponse "Short URL has expired or used up its max_clicks"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded, or the link's velocity_limit with overflow reject"
+// @Failure 508 {object} models.ErrorResponse "Short URL redirects in a loop"
+// @Failure 503 {object} models.ErrorResponse "Short URL is soft launched and not open to this visitor yet, or over its velocity_limit with overflow queue"
+// @Failure 504 {object} models.ErrorResponse "Request timed out"
 // @Router /{shortCode} [get]
 func RedirectURL(c *gin.Context) {
-	shortCode := c.Param("shortCode")
-
-	// Try cache first
-	var urlRecord *models.URL
-	var err error
-
-	if cachedURL, cacheErr := cache.GetURLMapping(shortCode); cacheErr == nil {
-		urlRecord = cachedURL
-	} else {
-		// Cache miss, check database
-		var dbURL models.URL
-		if err = database.DB.Where("short_code = ?", shortCode).First(&dbURL).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Short URL not found"})
+	defaultHandler().RedirectURL(c)
+}
+
+// RedirectURL serves GET /{shortCode} over h's stores
+func (h *Handler) RedirectURL(c *gin.Context) {
+	shortCode, preview := strings.CutSuffix(c.Param("shortCode"), "+")
+	shortCode = hostLinkKey(c, shortCode)
+	preview = preview || wantsLinkPreview(c)
+
+	entry, err := h.stores.LoadRedirectEntry(c.Request.Context(), shortCode)
+	if err != nil {
+		// An outage is not reported as a missing link
+		if !errors.Is(err, storage.ErrNotFound) {
+			c.Error(err)
 			return
 		}
-		urlRecord = &dbURL
-		// Cache the result for next time
-		cache.CacheURLMapping(shortCode, urlRecord)
+		// Renamed links keep their old short code for a grace period
+		if !h.followRenamedAlias(c, shortCode) {
+			respondLinkPage(c, models.ErrLinkNotFound, suggestCodes(c, shortCode))
+		}
+		return
 	}
 
-	// Check if URL has expired
-	if urlRecord.ExpiresAt != nil && urlRecord.ExpiresAt.Before(time.Now()) {
-		c.JSON(http.StatusGone, gin.H{"error": "Short URL has expired"})
+	if apiErr := service.UnavailableLinkError(entry, time.Now()); apiErr != nil {
+		respondLinkError(c, apiErr)
 		return
 	}
 
-	// Increment click count in cache (async)
-	go func() {
-		cache.IncrementClickCount(shortCode)
-		// Also update in database (less frequently - could be batched)
-		database.DB.Model(urlRecord).Update("click_count", urlRecord.ClickCount+1)
-		// Invalidate stats cache since click count changed
-		cache.InvalidateCache(shortCode)
-	}()
-
-	// Redirect to original URL
-	c.Redirect(http.StatusMovedPermanently, urlRecord.OriginalURL)
-}
+	// curl users can inspect a link without following it
+	if wantsLinkInfo(c) {
+		h.serveLinkInfo(c, shortCode, entry)
+		return
+	}
 
-// GetURLStats godoc
-// @Summary Get URL statistics
-// @Description Get statistics for a shortened URL including click count and creation date
-// @Tags URL Shortener
-// @Produce json
-// @Param shortCode path string true "Short code"
-// @Success 200 {object} models.StatsResponse
-// @Failure 404 {object} map[string]string "Short URL not found"
-// @Router /stats/{shortCode} [get]
-func GetURLStats(c *gin.Context) {
-	shortCode := c.Param("shortCode")
+	// Recipients wary of a link can see where it leads first
+	if preview {
+		serveLinkPreview(c, shortCode, entry)
+		return
+	}
+
+	// Links pointing back into the service could bounce visitors forever
+	if h.redirectLoops(c, shortCode, entry) {
+		respondLinkError(c, models.ErrLinkLoop)
+		return
+	}
 
-	// Try cache first
-	if cachedStats, err := cache.GetURLStats(shortCode); err == nil {
-		c.JSON(http.StatusOK, cachedStats)
+	if entry.Has(cache.RedirectNoIndex) {
+		c.Header("X-Robots-Tag", "noindex")
+	}
+
+	// Social network crawlers get the custom preview card instead of a
+	// redirect, and are not counted as clicks
+	if entry.Has(cache.RedirectPreview) && isPreviewCrawler(c.GetHeader("User-Agent")) {
+		servePreviewCard(c, shortCode, entry)
 		return
 	}
 
-	// Cache miss, get from database
-	var urlRecord models.URL
-	if err := database.DB.Where("short_code = ?", shortCode).First(&urlRecord).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Short URL not found"})
+	// Soft launched links only let their rollout share of visitors through
+	if entry.Has(cache.RedirectRollout) && !inRollout(c, entry) {
+		respondLinkError(c, models.ErrLinkNotLaunched)
 		return
 	}
 
-	// Get current click count from cache if available, otherwise use DB value
-	clickCount := urlRecord.ClickCount
-	if cachedClicks, err := cache.GetClickCount(shortCode); err == nil {
-		clickCount = int(cachedClicks)
+	// Routing rules may send the visitor elsewhere, or turn them away
+	rule := matchRoutingRule(c, entry)
+	if rule != nil && rule.Action.Type == models.RoutingActionDeny {
+		respondLinkError(c, models.ErrLinkNotFound)
+		return
 	}
 
-	response := models.StatsResponse{
-		OriginalURL: urlRecord.OriginalURL,
-		ShortCode:   urlRecord.ShortCode,
-		ClickCount:  clickCount,
-		CreatedAt:   urlRecord.CreatedAt,
-		ExpiresAt:   urlRecord.ExpiresAt,
+	// Fragile destinations are shielded from more visitors than they can take
+	if entry.Has(cache.RedirectVelocity) {
+		if untilReset, exceeded := h.velocityExceeded(shortCode, entry); exceeded {
+			respondVelocityOverflow(c, entry, untilReset)
+			return
+		}
+	}
+
+	// Chat apps unfurling a one-time link must not use it up for its recipient
+	if entry.Has(cache.RedirectLimited) {
+		if isPreviewCrawler(c.GetHeader("User-Agent")) {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		if !h.consumeClick(c.Request.Context(), shortCode, entry) {
+			respondLinkError(c, models.ErrLinkExpired)
+			return
+		}
 	}
 
-	// Cache the stats for a short time
-	cache.CacheURLStats(shortCode, &response)
+	destination, variantID := visitDestination(c, entry, rule)
 
-	c.JSON(http.StatusOK, response)
+	// Count the click asynchronously
+	enqueueClick(c, shortCode, entry, variantID)
+
+	// Redirect to original URL, with a token reporting the landing
+	target := entry.Target(destination)
+	if entry.Has(cache.RedirectLandings) {
+		target = landingTarget(c, shortCode, target)
+	}
+	setRedirectHeaders(c, entry)
+	c.Redirect(entry.StatusCode, target)
 }
 
-// HealthCheck godoc
-// @Summary Health check
-// @Description Check if the service is healthy and running
-// @Tags System
-// @Produce json
-// @Success 200 {object} map[string]interface{}
-// @Router /health [get]
-func HealthCheck(c *gin.Context) {
-	// Check database health
-	sqlDB, err := database.DB.DB()
-	dbHealthy := err == nil
-	if dbHealthy {
-		err = sqlDB.Ping()
-		dbHealthy = err == nil
+// setRedirectHeaders adds the link's extra headers to its redirect
+func setRedirectHeaders(c *gin.Context, entry *cache.RedirectEntry) {
+	for name, value := range entry.Headers {
+		c.Header(name, value)
 	}
+}
 
-	// Check Redis health
-	redisHealthy := cache.IsRedisHealthy()
+// GetURLStats godoc
+// @Summary Get URL statistics
+// @ID getURLStats
+// @Description Get statistics for a shortened URL including click count and creation date. Concurrent requests share one database lookup, and results up to max_age seconds old may be served. Responses are also shared between instances through the response cache, for up to RESPONSE_CACHE_TTL unless max_age is lower.
+// @Tags URL Shortener
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Param fields query string false "Comma-separated list of fields to return (e.g. click_count,original_url)"
+// @Param max_age query int false "Accept stats up to this many seconds old (default 1, max 300)"
+// @Success 200 {object} models.StatsResponse
+// @Failure 400 {object} models.ErrorResponse "Unknown field requested or invalid max_age"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /stats/{shortCode} [get]
+func GetURLStats(c *gin.Context) {
+	defaultHandler().GetURLStats(c)
+}
 
-	response := gin.H{
-		"status":    "healthy",
-		"timestamp": time.Now().UTC(),
-		"service":   "url-shortener",
-		"database":  map[string]bool{"healthy": dbHealthy},
-		"cache":     map[string]bool{"healthy": redisHealthy},
-	}
+// GetURLStats serves GET /stats/{shortCode} over h's stores
+func (h *Handler) GetURLStats(c *gin.Context) {
+	shortCode := pathLinkKey(c)
 
-	// Return 503 if any critical service is down
-	if !dbHealthy {
-		response["status"] = "unhealthy"
-		c.JSON(http.StatusServiceUnavailable, response)
+	maxAge, err := parseMaxAge(c)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
 		return
 	}
 
-	// Redis is optional, so we don't fail if it's down
-	if !redisHealthy {
-		response["status"] = "degraded"
+	stats, err := h.stores.Stats(c.Request.Context(), shortCode, maxAge)
+	if err != nil {
+		c.Error(service.LinkLookupError(err))
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
-}
-
-func isValidURL(str string) bool {
-	u, err := url.Parse(str)
-	return err == nil && u.Scheme != "" && u.Host != ""
+	respondWithFields(c, http.StatusOK, stats)
 }
 
-func buildShortURL(c *gin.Context, shortCode string) string {
-	scheme := "http"
-	if c.Request.TLS != nil {
-		scheme = "https"
+func buildShortenResponse(c *gin.Context, urlRecord *models.URL) models.ShortenResponse {
+	shortURL := buildShortURL(c, urlRecord.ShortCode)
+	host, shortCode := models.SplitLinkKey(urlRecord.ShortCode)
+	return models.ShortenResponse{
+		ShortURL:    shortURL,
+		QRURL:       shortURL + "/qr",
+		OriginalURL: urlRecord.OriginalURL,
+		ShortCode:   shortCode,
+		Domain:      host,
+		Environment: urlRecord.Environment,
+		ExternalID:  externalID(urlRecord),
+		ExpiresAt:   urlRecord.ExpiresAt,
+		Status:      urlRecord.Status,
+		Analytics:   urlRecord.AnalyticsMode(),
+		MaxClicks:   urlRecord.MaxClicks,
+		// The status actually used, split links never redirecting with 301
+		RedirectType:    cache.NewRedirectEntry(urlRecord).StatusCode,
+		RolloutPercent:  urlRecord.RolloutPercent,
+		VelocityLimit:   urlRecord.VelocityLimit,
+		TrackLandings:   urlRecord.TrackLandings,
+		RedirectHeaders: urlRecord.RedirectHeaders,
+
+		AttributionWindowHours: urlRecord.AttributionWindowHours,
 	}
-	return scheme + "://" + c.Request.Host + "/" + shortCode
 }
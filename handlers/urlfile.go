@@ -0,0 +1,485 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Formats of link files
+const (
+	urlFileCSV  = "csv"
+	urlFileJSON = "json"
+)
+
+// Upper bounds for an imported file and the links in it
+const (
+	maxURLFileSize  = 20 << 20
+	maxURLFileLinks = 10000
+)
+
+// Most tags an imported link may carry, as validated when links are created
+const maxLinkTags = 20
+
+// Links loaded per query while exporting
+const urlExportBatchSize = 500
+
+// Separates the tags of a link in CSV files
+const urlFileTagSeparator = "|"
+
+// ExportURLs godoc
+// @Summary Export links as CSV or JSON
+// @ID exportURLs
+// @Description Stream every link, or those matching the same filters as GET /admin/urls, with its short code, destination, click count, status, creation and expiry times and tags, oldest first. Links of branded domains are exported as host/code. CSV files have a header row and separate tags with |; JSON files hold an array. Files can be imported again with POST /admin/urls/import, e.g. to restore a backup. To export many links without holding the request open, generate the file in the background with POST /admin/artifacts.
+// @Tags Admin
+// @Produce json,text/csv
+// @Param format query string false "csv (default) or json"
+// @Param created_after query string false "Only links created at or after this RFC 3339 time"
+// @Param created_before query string false "Only links created before this RFC 3339 time"
+// @Param expired query bool false "Only expired links (true) or links not expired (false)"
+// @Param domain query string false "Only links to this destination host or its subdomains; unavailable while destinations are encrypted"
+// @Param environment query string false "Only production or staging links" Enums(production, staging)
+// @Success 200 {array} models.URLFileRecord
+// @Failure 400 {object} models.ErrorResponse "Invalid format or filter"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/urls/export [get]
+func ExportURLs(c *gin.Context) {
+	format := c.DefaultQuery("format", urlFileCSV)
+	if format != urlFileCSV && format != urlFileJSON {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "format must be csv or json"))
+		return
+	}
+	filter, ok := parseLinkFilter(c)
+	if !ok {
+		return
+	}
+
+	filename := urlExportFilename(format, time.Now())
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Content-Type", urlFileContentType(format))
+	c.Status(http.StatusOK)
+
+	// Headers are sent with the first links, so a failure past this point
+	// can only cut the file short
+	query := filterLinks(database.DB.WithContext(c.Request.Context()), filter)
+	if _, err := writeURLFile(query, newURLFileWriter(c.Writer, format), c.Writer.Flush); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to export links", "error", err)
+	}
+}
+
+// urlExportFilename names a file of links exported at now
+func urlExportFilename(format string, now time.Time) string {
+	return "links-" + now.UTC().Format("20060102-150405") + "." + format
+}
+
+func urlFileContentType(format string) string {
+	if format == urlFileJSON {
+		return "application/json"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// writeURLFile writes the links of query to writer oldest first, calling
+// flushed after each batch, and returns how many it wrote
+func writeURLFile(query *gorm.DB, writer urlFileWriter, flushed func()) (int, error) {
+	if err := writer.begin(); err != nil {
+		return 0, err
+	}
+	written := 0
+	var batch []models.URL
+	err := query.FindInBatches(&batch, urlExportBatchSize, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if err := writer.write(urlFileRecord(&batch[i])); err != nil {
+				return err
+			}
+			written++
+		}
+		if err := writer.flush(); err != nil {
+			return err
+		}
+		flushed()
+		return nil
+	}).Error
+	if err != nil {
+		return written, err
+	}
+	return written, writer.end()
+}
+
+// ImportURLs godoc
+// @Summary Import links from CSV or JSON
+// @ID importURLs
+// @Description Create links from a file exported by GET /admin/urls/export or by another link shortener, sent as the request body or as the file field of a multipart form. CSV files need a header row with at least an original_url (or url, long_url, destination) column; short_code (or code, alias, slug, keyword), click_count (or clicks), created_at, expires_at and tags columns are optional. Short codes are kept where possible: links whose code is missing, invalid, reserved or taken get a generated one and are reported as renamed. Links whose destination fails this instance's checks or whose branded domain does not exist are skipped. Click counts and creation times are kept; expiry is capped by LINK_MAX_EXPIRY_DAYS, and links are held for approval when REQUIRE_APPROVAL is set. At most 10000 links and 20 MiB per file.
+// @Tags Admin
+// @Accept json,text/csv,mpfd
+// @Produce json
+// @Param format query string false "csv or json; defaults to the Content-Type or file extension"
+// @Param file formData file false "File to import, for multipart requests"
+// @Success 200 {object} models.URLImportResponse
+// @Failure 400 {object} models.ErrorResponse "Unknown format or invalid file"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 413 {object} models.ErrorResponse "File too large"
+// @Security AdminAuth
+// @Router /admin/urls/import [post]
+func ImportURLs(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxURLFileSize)
+	body, format, err := urlImportFile(c)
+	if err != nil {
+		c.Error(urlImportError(err))
+		return
+	}
+	defer body.Close()
+
+	records, err := readURLFile(body, format)
+	if err != nil {
+		c.Error(urlImportError(err))
+		return
+	}
+
+	response := models.URLImportResponse{}
+	now := time.Now()
+	for i, record := range records {
+		row := i + 1
+		shortCode, renamed, err := importURLRecord(c, record, now)
+		if err != nil {
+			response.Skipped = append(response.Skipped, models.URLImportSkip{Row: row, ShortCode: record.ShortCode, Reason: err.Error()})
+			continue
+		}
+		response.Imported++
+		if renamed != "" {
+			response.Renamed = append(response.Renamed, models.URLImportRename{Row: row, From: record.ShortCode, ShortCode: shortCode, Reason: renamed})
+		}
+	}
+
+	slog.InfoContext(c.Request.Context(), "Imported links from a file", "format", format, "imported", response.Imported, "renamed", len(response.Renamed), "skipped", len(response.Skipped))
+	c.JSON(http.StatusOK, response)
+}
+
+// urlImportFile returns the file of an import request and its format
+func urlImportFile(c *gin.Context) (io.ReadCloser, string, error) {
+	format := c.Query("format")
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+
+	body := c.Request.Body
+	if mediaType == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", fmt.Errorf("file is required: %w", err)
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		body = file
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(path.Ext(header.Filename)), ".")
+		}
+	} else if format == "" {
+		switch mediaType {
+		case "text/csv", "application/csv":
+			format = urlFileCSV
+		case "application/json":
+			format = urlFileJSON
+		}
+	}
+
+	if format != urlFileCSV && format != urlFileJSON {
+		body.Close()
+		return nil, "", errors.New("format must be csv or json; set it with ?format, the Content-Type or the file extension")
+	}
+	return body, format, nil
+}
+
+// urlImportError maps a failure to read an imported file to its API error
+func urlImportError(err error) *models.APIError {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return models.NewAPIError(http.StatusRequestEntityTooLarge, models.ErrCodeInvalidRequest, "File too large, import at most 20 MiB at a time")
+	}
+	return models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error())
+}
+
+// importURLRecord creates the link of one record, returning its short code
+// and why the code of the file was not kept, or why the link was skipped
+func importURLRecord(c *gin.Context, record models.URLFileRecord, now time.Time) (string, string, error) {
+	if len(record.Tags) > maxLinkTags {
+		return "", "", fmt.Errorf("at most %d tags per link", maxLinkTags)
+	}
+	for _, tag := range record.Tags {
+		if len(tag) > maxTagLength {
+			return "", "", fmt.Errorf("tags must be at most %d characters long", maxTagLength)
+		}
+	}
+	safetyAction, err := checkImportedDestinations(c, []string{record.OriginalURL})
+	if err != nil {
+		return "", "", err
+	}
+
+	// Links of a branded domain keep it, which must exist on this instance
+	host, shortCode := models.SplitLinkKey(record.ShortCode)
+	renamed := ""
+	if shortCode == "" {
+		renamed = "no short code"
+	} else if err := service.ValidateAlias(shortCode); err != nil {
+		renamed = strings.Replace(err.Error(), "custom_alias", "short code", 1)
+		shortCode = ""
+	}
+
+	request := models.ShortenRequest{
+		URL:         record.OriginalURL,
+		CustomAlias: shortCode,
+		Domain:      host,
+		IfExists:    models.IfExistsNew,
+		Tags:        record.Tags,
+	}
+	expiresAt := service.LinkExpiry.Enforce(now, record.ExpiresAt, now)
+	urlRecord, err := createURLRecordUntil(c, request, expiresAt, safetyAction, false)
+	if errors.Is(err, service.ErrAliasTaken) {
+		renamed = "short code is taken"
+		request.CustomAlias = ""
+		urlRecord, err = createURLRecordUntil(c, request, expiresAt, safetyAction, false)
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownDomain) {
+			return "", "", errors.New("unknown short link domain")
+		}
+		slog.ErrorContext(c.Request.Context(), "Failed to import link", "short_code", record.ShortCode, "error", err)
+		return "", "", errors.New("failed to save link")
+	}
+
+	// Keep the history of the link from the file
+	updates := map[string]interface{}{}
+	if record.ClickCount > 0 {
+		updates["click_count"] = record.ClickCount
+	}
+	if record.CreatedAt != nil && record.CreatedAt.Before(now) {
+		updates["created_at"] = *record.CreatedAt
+	}
+	if len(updates) > 0 {
+		if err := database.DB.WithContext(c.Request.Context()).Model(&models.URL{}).Where("id = ?", urlRecord.ID).UpdateColumns(updates).Error; err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to keep clicks and creation time of imported link", "short_code", urlRecord.ShortCode, "error", err)
+		}
+	}
+	return urlRecord.ShortCode, renamed, nil
+}
+
+// urlFileRecord describes a link in an exported file
+func urlFileRecord(urlRecord *models.URL) models.URLFileRecord {
+	createdAt := urlRecord.CreatedAt
+	return models.URLFileRecord{
+		ShortCode:   urlRecord.ShortCode,
+		OriginalURL: urlRecord.OriginalURL,
+		ClickCount:  urlRecord.ClickCount,
+		Status:      urlRecord.Status,
+		CreatedAt:   &createdAt,
+		ExpiresAt:   urlRecord.ExpiresAt,
+		Tags:        urlRecord.Tags,
+	}
+}
+
+// urlFileWriter writes the links of an exported file
+type urlFileWriter interface {
+	begin() error
+	write(record models.URLFileRecord) error
+	flush() error
+	end() error
+}
+
+// newURLFileWriter returns the writer of files of format to w
+func newURLFileWriter(w io.Writer, format string) urlFileWriter {
+	if format == urlFileJSON {
+		return &jsonURLFileWriter{w: w}
+	}
+	return &csvURLFileWriter{w: csv.NewWriter(w)}
+}
+
+// jsonURLFileWriter writes a JSON array one link at a time
+type jsonURLFileWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (j *jsonURLFileWriter) begin() error {
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+func (j *jsonURLFileWriter) write(record models.URLFileRecord) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if j.written {
+		if _, err := io.WriteString(j.w, ",\n"); err != nil {
+			return err
+		}
+	}
+	j.written = true
+	_, err = j.w.Write(body)
+	return err
+}
+
+func (j *jsonURLFileWriter) flush() error { return nil }
+
+func (j *jsonURLFileWriter) end() error {
+	_, err := io.WriteString(j.w, "]\n")
+	return err
+}
+
+// csvURLFileWriter writes a header row then a row per link
+type csvURLFileWriter struct {
+	w *csv.Writer
+}
+
+func (w *csvURLFileWriter) begin() error {
+	return w.w.Write(models.URLFileColumns)
+}
+
+func (w *csvURLFileWriter) write(record models.URLFileRecord) error {
+	return w.w.Write([]string{
+		record.ShortCode,
+		record.OriginalURL,
+		strconv.Itoa(record.ClickCount),
+		record.Status,
+		formatFileTime(record.CreatedAt),
+		formatFileTime(record.ExpiresAt),
+		strings.Join(record.Tags, urlFileTagSeparator),
+	})
+}
+
+func (w *csvURLFileWriter) flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvURLFileWriter) end() error {
+	return w.flush()
+}
+
+func formatFileTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// readURLFile parses the links of an imported file
+func readURLFile(r io.Reader, format string) ([]models.URLFileRecord, error) {
+	if format == urlFileJSON {
+		var records []models.URLFileRecord
+		if err := json.NewDecoder(r).Decode(&records); err != nil {
+			return nil, fmt.Errorf("invalid JSON file: %w", err)
+		}
+		if len(records) > maxURLFileLinks {
+			return nil, fmt.Errorf("too many links, import at most %d at a time", maxURLFileLinks)
+		}
+		for i, record := range records {
+			if record.OriginalURL == "" {
+				return nil, fmt.Errorf("link %d: original_url is required", i+1)
+			}
+		}
+		return records, nil
+	}
+	return readURLCSV(r)
+}
+
+// readURLCSV parses a CSV file of links, matching the columns of its header
+// row by name
+func readURLCSV(r io.Reader) ([]models.URLFileRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV file: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		name = strings.ReplaceAll(name, " ", "_")
+		if alias, ok := models.URLFileColumnAliases[name]; ok {
+			name = alias
+		}
+		if _, seen := columns[name]; !seen {
+			columns[name] = i
+		}
+	}
+	if _, ok := columns["original_url"]; !ok {
+		return nil, errors.New("CSV header must have an original_url column")
+	}
+
+	var records []models.URLFileRecord
+	for row := 1; ; row++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV file: %w", err)
+		}
+		if len(records) == maxURLFileLinks {
+			return nil, fmt.Errorf("too many links, import at most %d at a time", maxURLFileLinks)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+
+		record := models.URLFileRecord{ShortCode: field("short_code"), OriginalURL: field("original_url")}
+		if record.OriginalURL == "" {
+			return nil, fmt.Errorf("row %d: original_url is required", row)
+		}
+		if raw := field("click_count"); raw != "" {
+			if record.ClickCount, err = strconv.Atoi(raw); err != nil || record.ClickCount < 0 {
+				return nil, fmt.Errorf("row %d: click_count must be a non-negative integer", row)
+			}
+		}
+		for name, target := range map[string]**time.Time{"created_at": &record.CreatedAt, "expires_at": &record.ExpiresAt} {
+			if raw := field(name); raw != "" {
+				value, err := parseFileTime(raw)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: %s must be an RFC 3339 time or a date", row, name)
+				}
+				*target = &value
+			}
+		}
+		for _, tag := range strings.Split(field("tags"), urlFileTagSeparator) {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				record.Tags = append(record.Tags, tag)
+			}
+		}
+		records = append(records, record)
+	}
+}
+
+// parseFileTime reads an RFC 3339 time, or a date as exported by most
+// spreadsheets
+func parseFileTime(raw string) (time.Time, error) {
+	if value, err := time.Parse(time.RFC3339, raw); err == nil {
+		return value, nil
+	}
+	return time.Parse("2006-01-02", raw)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/models"
+)
+
+func TestURLFileSurvivesRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	expiresAt := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	records := []models.URLFileRecord{
+		{ShortCode: "promo2024", OriginalURL: "https://example.com/spring?a=1,b=2", ClickCount: 1520, Status: models.StatusActive, CreatedAt: &createdAt, ExpiresAt: &expiresAt, Tags: []string{"spring", "email"}},
+		{ShortCode: "go.acme.com/sale", OriginalURL: "https://example.com/sale", CreatedAt: &createdAt},
+	}
+
+	for _, format := range []string{urlFileCSV, urlFileJSON} {
+		var out bytes.Buffer
+		var writer urlFileWriter = &jsonURLFileWriter{w: &out}
+		if format == urlFileCSV {
+			writer = &csvURLFileWriter{w: csv.NewWriter(&out)}
+		}
+		if err := writer.begin(); err != nil {
+			t.Fatal(err)
+		}
+		for _, record := range records {
+			if err := writer.write(record); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := writer.end(); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := readURLFile(&out, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if len(got) != len(records) {
+			t.Fatalf("%s: got %d links, want %d", format, len(got), len(records))
+		}
+		for i := range records {
+			// Status is exported for reference only
+			if format == urlFileCSV {
+				got[i].Status = records[i].Status
+			}
+			if !reflect.DeepEqual(got[i], records[i]) {
+				t.Errorf("%s: link %d = %+v, want %+v", format, i, got[i], records[i])
+			}
+		}
+	}
+}
+
+func TestReadURLCSVMatchesOtherShortenersColumns(t *testing.T) {
+	file := "\ufeffLong URL,Slug,Clicks,Created\nhttps://example.com/a,promo,7,2023-05-04\nhttps://example.com/b,,,\n"
+	records, err := readURLCSV(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d links, want 2", len(records))
+	}
+	first := records[0]
+	if first.OriginalURL != "https://example.com/a" || first.ShortCode != "promo" || first.ClickCount != 7 {
+		t.Errorf("first link = %+v", first)
+	}
+	if first.CreatedAt == nil || !first.CreatedAt.Equal(time.Date(2023, 5, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("created_at = %v, want 2023-05-04", first.CreatedAt)
+	}
+	if records[1].ShortCode != "" || records[1].CreatedAt != nil {
+		t.Errorf("second link = %+v, want no code or creation time", records[1])
+	}
+}
+
+func TestReadURLCSVRejectsInvalidRows(t *testing.T) {
+	for name, file := range map[string]string{
+		"no destination column": "code,clicks\npromo,3\n",
+		"missing destination":   "url,code\n,promo\n",
+		"negative clicks":       "url,clicks\nhttps://example.com,-1\n",
+		"invalid time":          "url,expires_at\nhttps://example.com,tomorrow\n",
+		"empty":                 "",
+	} {
+		if _, err := readURLCSV(strings.NewReader(file)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
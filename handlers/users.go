@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/retention"
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ListUsers godoc
+// @Summary List users
+// @ID listUsers
+// @Description List dashboard user accounts
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} models.User
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/users [get]
+func ListUsers(c *gin.Context) {
+	var users []models.User
+	if err := database.DB.Order("created_at desc").Find(&users).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list users"))
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// CreateUser godoc
+// @Summary Create a user
+// @ID createUser
+// @Description Create a dashboard user account
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body models.CreateUserRequest true "User details"
+// @Success 201 {object} models.User
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 409 {object} models.ErrorResponse "Email already registered"
+// @Security AdminAuth
+// @Router /admin/users [post]
+func CreateUser(c *gin.Context) {
+	var request models.CreateUserRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	if request.Plan != "" && !knownPlan(request.Plan) {
+		c.Error(unknownPlanError())
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.User{}).Where("email = ?", request.Email).Count(&existing)
+	if existing > 0 {
+		c.Error(models.NewAPIError(http.StatusConflict, models.ErrCodeConflict, "Email already registered"))
+		return
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create user"))
+		return
+	}
+
+	role := request.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+	plan := request.Plan
+	if plan == "" {
+		plan = retention.PlanFree
+	}
+
+	user := models.User{Email: request.Email, PasswordHash: string(passwordHash), Role: role, Plan: plan}
+	if err := database.DB.Create(&user).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create user"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// RevokeUserSessions godoc
+// @Summary Force logout a user
+// @ID revokeUserSessions
+// @Description Revoke all of a user's sessions, e.g. after an account compromise
+// @Tags Admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Security AdminAuth
+// @Router /admin/users/{id}/logout [post]
+func RevokeUserSessions(c *gin.Context) {
+	result := database.DB.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", c.Param("id")).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to revoke sessions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revoked_sessions": result.RowsAffected})
+}
+
+// SetUserPlan godoc
+// @Summary Change a user's plan
+// @ID setUserPlan
+// @Description Move a user to the free plan or a plan of STATS_RETENTION_DAYS, which sets how long the click history of their links is kept. Moving to a plan keeping less history purges the older history at the next daily run.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body models.SetUserPlanRequest true "New plan"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse "Unknown plan"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "User not found"
+// @Security AdminAuth
+// @Router /admin/users/{id}/plan [put]
+func SetUserPlan(c *gin.Context) {
+	var request models.SetUserPlanRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+	if !knownPlan(request.Plan) {
+		c.Error(unknownPlanError())
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", c.Param("id")).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "User not found"))
+		return
+	}
+	if err := database.DB.Model(&user).Update("plan", request.Plan).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to change the plan"))
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// SetUserAttributionWindow godoc
+// @Summary Change a user's attribution window
+// @ID setUserAttributionWindow
+// @Description Set how many hours after a click the conversions of a user's split links are attributed to it, in variant stats, unless a link sets its own attribution_window_hours. 0 restores CONVERSION_ATTRIBUTION_WINDOW. Applies to past conversions too, as they are attributed when reported.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body models.SetAttributionWindowRequest true "New window"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse "Invalid window"
+// @Failure 401 {object} models.ErrorResponse "Invalid admin token"
+// @Failure 404 {object} models.ErrorResponse "User not found"
+// @Security AdminAuth
+// @Router /admin/users/{id}/attribution-window [put]
+func SetUserAttributionWindow(c *gin.Context) {
+	var request models.SetAttributionWindowRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", c.Param("id")).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "User not found"))
+		return
+	}
+	if err := database.DB.Model(&user).Update("attribution_window_hours", *request.Hours).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to change the attribution window"))
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func unknownPlanError() *models.APIError {
+	plans := statsRetention.Plans()
+	for plan := range service.LinkQuotas.Links {
+		if !statsRetention.Known(plan) {
+			plans = append(plans, plan)
+		}
+	}
+	sort.Strings(plans)
+	return models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "plan must be one of "+strings.Join(plans, ", "))
+}
+
+// knownPlan reports whether users can be assigned plan: the free plan and
+// those of STATS_RETENTION_DAYS or LINK_QUOTAS
+func knownPlan(plan string) bool {
+	return statsRetention.Known(plan) || service.LinkQuotas.Known(plan)
+}
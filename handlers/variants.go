@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"url-shortener/bandit"
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// How long redirects reuse a split link's variants and their counts
+const variantsCacheTTL = 10 * time.Second
+
+// Samples drawn to estimate which variant converts best
+const probabilityBestDraws = 10000
+
+// defaultAttributionWindow is how long after a click a conversion is
+// attributed to it, for links and owners without a window of their own
+var defaultAttributionWindow = loadAttributionWindow()
+
+// loadAttributionWindow reads CONVERSION_ATTRIBUTION_WINDOW, 7 days by
+// default
+func loadAttributionWindow() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("CONVERSION_ATTRIBUTION_WINDOW")); err == nil && value > 0 {
+		return value
+	}
+	return 7 * 24 * time.Hour
+}
+
+// attributionWindow returns the window attributing the conversions of a
+// split link: its own, else its owner's, else the default
+func attributionWindow(ctx context.Context, urlRecord *models.URL) (time.Duration, error) {
+	if urlRecord.AttributionWindowHours > 0 {
+		return time.Duration(urlRecord.AttributionWindowHours) * time.Hour, nil
+	}
+	if urlRecord.OwnerID != nil {
+		var owner models.User
+		err := database.DB.WithContext(ctx).Select("attribution_window_hours").First(&owner, *urlRecord.OwnerID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, err
+		}
+		if owner.AttributionWindowHours > 0 {
+			return time.Duration(owner.AttributionWindowHours) * time.Hour, nil
+		}
+	}
+	return defaultAttributionWindow, nil
+}
+
+// A transparent 1x1 GIF returned by the conversion pixel
+var pixelGIF, _ = base64.StdEncoding.DecodeString("R0lGODlhAQABAIAAAAAAAP///yH5BAEAAAAALAAAAAABAAEAAAIBRAA7")
+
+type cachedVariants struct {
+	variants []models.LinkVariant
+	loadedAt time.Time
+}
+
+var (
+	variantsMu    sync.Mutex
+	variantsByURL = make(map[uint]cachedVariants)
+)
+
+// checkVariantsAllowed applies the checks of the link's URL to every variant
+// destination, returning the strictest safety action. It writes the error
+// response and returns false when a variant may not be used.
+func checkVariantsAllowed(c *gin.Context, variants []models.VariantRequest, safetyAction string) (string, bool) {
+	safetyAction, apiErr := service.CheckVariants(c.Request.Context(), requestCaller(c), variants, safetyAction)
+	return safetyActionOrError(c, safetyAction, apiErr)
+}
+
+// checkAlternateDestination applies the checks of the link's URL to another
+// destination visitors may be sent to, the kind of which is named by noun.
+// It returns the strictest safety action, or writes the error response and
+// returns false when the destination may not be used.
+func checkAlternateDestination(c *gin.Context, rawURL, noun, safetyAction string) (string, bool) {
+	safetyAction, apiErr := service.CheckAlternateDestination(c.Request.Context(), requestCaller(c), rawURL, noun, safetyAction)
+	return safetyActionOrError(c, safetyAction, apiErr)
+}
+
+// pickVariant chooses the variant of a split link for a visitor, or nil
+// when its variants cannot be loaded
+func pickVariant(ctx context.Context, entry *cache.RedirectEntry) *models.LinkVariant {
+	variants := loadVariants(ctx, entry.URLID)
+	if len(variants) == 0 {
+		return nil
+	}
+
+	if entry.Has(cache.RedirectBandit) {
+		return &variants[bandit.Choose(variantArms(variants))]
+	}
+
+	total := 0
+	for _, variant := range variants {
+		total += variant.Weight
+	}
+	pick := rand.Intn(max(total, 1))
+	for i := range variants {
+		if pick -= variants[i].Weight; pick < 0 {
+			return &variants[i]
+		}
+	}
+	return &variants[len(variants)-1]
+}
+
+// loadVariants returns a split link's variants, reusing them for
+// variantsCacheTTL so the counts bandit mode decides on are slightly stale
+func loadVariants(ctx context.Context, urlID uint) []models.LinkVariant {
+	variantsMu.Lock()
+	cached, ok := variantsByURL[urlID]
+	variantsMu.Unlock()
+	if ok && time.Since(cached.loadedAt) < variantsCacheTTL {
+		return cached.variants
+	}
+
+	var variants []models.LinkVariant
+	if err := database.Prepared.WithContext(ctx).Where("url_id = ?", urlID).Order("id").Find(&variants).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to load variants of link", "url_id", urlID, "error", err)
+		return cached.variants
+	}
+
+	variantsMu.Lock()
+	defer variantsMu.Unlock()
+	// Drop stale entries so many split links don't grow the map forever
+	if len(variantsByURL) >= 10000 {
+		for id, entry := range variantsByURL {
+			if time.Since(entry.loadedAt) > variantsCacheTTL {
+				delete(variantsByURL, id)
+			}
+		}
+	}
+	variantsByURL[urlID] = cachedVariants{variants: variants, loadedAt: time.Now()}
+	return variants
+}
+
+func variantArms(variants []models.LinkVariant) []bandit.Arm {
+	arms := make([]bandit.Arm, len(variants))
+	for i, variant := range variants {
+		arms[i] = bandit.Arm{Trials: variant.Clicks, Successes: variant.Conversions}
+	}
+	return arms
+}
+
+// buildVariantStats reports the performance and current allocation of the
+// variants of a split link
+func buildVariantStats(c *gin.Context, urlRecord *models.URL, variants []models.LinkVariant, attributed map[uint]int64) []models.VariantStats {
+	probabilityBest := bandit.ProbabilityBest(variantArms(variants), probabilityBestDraws)
+
+	totalWeight := 0
+	for _, variant := range variants {
+		totalWeight += variant.Weight
+	}
+
+	stats := make([]models.VariantStats, len(variants))
+	for i, variant := range variants {
+		stats[i] = models.VariantStats{
+			ID:              variant.ID,
+			Name:            variant.Name,
+			Destination:     variant.Destination,
+			Weight:          variant.Weight,
+			Clicks:          variant.Clicks,
+			Conversions:     variant.Conversions,
+			ProbabilityBest: probabilityBest[i],
+			PixelURL:        pixelURL(c, urlRecord.ShortCode, variant.ID),
+
+			AttributedConversions: attributed[variant.ID],
+		}
+		if variant.Clicks > 0 {
+			stats[i].ConversionRate = float64(variant.Conversions) / float64(variant.Clicks)
+		}
+		if urlRecord.VariantMode == models.VariantModeBandit {
+			stats[i].Allocation = probabilityBest[i]
+		} else if totalWeight > 0 {
+			stats[i].Allocation = float64(variant.Weight) / float64(totalWeight)
+		}
+	}
+	return stats
+}
+
+// newVariantStats reports the variants of a split link just created
+func newVariantStats(c *gin.Context, urlRecord *models.URL) []models.VariantStats {
+	var variants []models.LinkVariant
+	if err := database.DB.WithContext(c.Request.Context()).Where("url_id = ?", urlRecord.ID).Order("id").Find(&variants).Error; err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to load variants of link", "short_code", urlRecord.ShortCode, "error", err)
+		return nil
+	}
+	return buildVariantStats(c, urlRecord, variants, nil)
+}
+
+// GetVariantStats godoc
+// @Summary Get split link variants
+// @ID getVariantStats
+// @Description Report the clicks, conversions and conversion rate of each variant of a split link, the share of traffic each currently gets and the probability that each converts best. In bandit mode traffic follows that probability (Thompson sampling), updated every few seconds. attributed_conversions counts the conversions by visitors who clicked the link within the attribution window before, the link's attribution_window_hours, else its owner's, else CONVERSION_ATTRIBUTION_WINDOW; visitors are told apart as for unique visitors, so only conversions and clicks recorded with Redis can be attributed.
+// @Tags URL Shortener
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {object} models.VariantStatsResponse
+// @Failure 401 {object} models.ErrorResponse "Invalid API key or request signature"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found or has no variants"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /stats/{shortCode}/variants [get]
+func GetVariantStats(c *gin.Context) {
+	urlRecord, err := findStatsLink(c.Request.Context(), pathLinkKey(c))
+	if err != nil {
+		c.Error(models.ErrLinkNotFound)
+		return
+	}
+	if urlRecord.VariantMode == "" {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Short URL has no variants"))
+		return
+	}
+
+	var variants []models.LinkVariant
+	if err := database.DB.WithContext(c.Request.Context()).Where("url_id = ?", urlRecord.ID).Order("id").Find(&variants).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to load variants"))
+		return
+	}
+
+	window, err := attributionWindow(c.Request.Context(), urlRecord)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to load the attribution window"))
+		return
+	}
+	var since time.Time
+	if urlRecord.StatsResetAt != nil {
+		since = *urlRecord.StatsResetAt
+	}
+	attributed, err := database.AttributedConversions(c.Request.Context(), urlRecord.ID, since, window)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to attribute conversions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.VariantStatsResponse{
+		ShortCode:              urlRecord.ShortCode,
+		Mode:                   urlRecord.VariantMode,
+		AttributionWindowHours: int(window / time.Hour),
+		Variants:               buildVariantStats(c, urlRecord, variants, attributed),
+	})
+}
+
+// pixelURL returns the conversion pixel of a variant, served on the same
+// domain as its link
+func pixelURL(c *gin.Context, shortCode string, variantID uint) string {
+	host, code := models.SplitLinkKey(shortCode)
+	return shortURLBase(c, host) + "/px/" + code + "/" + strconv.FormatUint(uint64(variantID), 10)
+}
+
+// TrackConversion godoc
+// @Summary Conversion pixel
+// @ID trackConversion
+// @Description Record a conversion for a variant of a split link and return a transparent 1x1 GIF. Embed it on the variant's destination where a visitor converts, e.g. after a purchase; pixel_url of each variant holds its address. Unknown links and variants are ignored. Conversions are attributed to the visitor's click when it falls within the link's attribution window.
+// @Tags URL Shortener
+// @Produce image/gif
+// @Param shortCode path string true "Short code"
+// @Param variant path int true "Variant ID"
+// @Success 200 "Transparent GIF"
+// @Router /px/{shortCode}/{variant} [get]
+func TrackConversion(c *gin.Context) {
+	shortCode := hostLinkKey(c, c.Param("shortCode"))
+	variantID, err := strconv.ParseUint(c.Param("variant"), 10, 64)
+	if err == nil {
+		urlIDs := database.DB.Model(&models.URL{}).Select("id").Where("short_code = ?", shortCode)
+		result := database.DB.WithContext(c.Request.Context()).Model(&models.LinkVariant{}).
+			Where("id = ? AND url_id IN (?)", variantID, urlIDs).
+			Update("conversions", gorm.Expr("conversions + ?", 1))
+		if result.Error != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to record conversion", "short_code", shortCode, "error", result.Error)
+		}
+		// Kept for attribution when the visitor can be told apart, hashed as
+		// their clicks are
+		visitor := c.ClientIP() + " " + truncateHeader(c.Request.UserAgent())
+		if visitorHash, hashErr := cache.VisitorHash(visitor); result.RowsAffected > 0 && hashErr == nil {
+			if err := database.RecordConversion(c.Request.Context(), uint(variantID), visitorHash, time.Now()); err != nil {
+				slog.ErrorContext(c.Request.Context(), "Failed to record conversion event", "short_code", shortCode, "error", err)
+			}
+		}
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/gif", pixelGIF)
+}
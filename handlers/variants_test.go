@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"url-shortener/models"
+)
+
+func TestAttributionWindow(t *testing.T) {
+	ctx := context.Background()
+	window, err := attributionWindow(ctx, &models.URL{AttributionWindowHours: 72})
+	if err != nil || window != 72*time.Hour {
+		t.Errorf("link window = %v, %v, want 72h", window, err)
+	}
+	// Links without an owner or a window of their own use the default
+	window, err = attributionWindow(ctx, &models.URL{})
+	if err != nil || window != defaultAttributionWindow {
+		t.Errorf("default window = %v, %v, want %v", window, err, defaultAttributionWindow)
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Redirects of velocity limited links counted when Redis is unavailable,
+// per instance
+var (
+	localVelocityMu sync.Mutex
+	localVelocity   = make(map[string]velocityWindow)
+)
+
+type velocityWindow struct {
+	start time.Time
+	count int64
+}
+
+// velocityExceeded counts a redirect of a link against its velocity limit,
+// reporting whether the limit is exceeded and how long until the window
+// ends. Windows are fixed, starting on the second or minute. Counts are
+// shared through Redis and kept per instance without it.
+func (h *Handler) velocityExceeded(shortCode string, entry *cache.RedirectEntry) (time.Duration, bool) {
+	window := entry.Velocity.Window()
+	now := time.Now()
+	start := now.Truncate(window)
+
+	count, err := h.stores.Cache.IncrementVelocity(shortCode, start, window)
+	if err != nil {
+		count = countLocalVelocity(shortCode, start)
+	}
+	return start.Add(window).Sub(now), count > int64(entry.Velocity.Clicks)
+}
+
+func countLocalVelocity(shortCode string, start time.Time) int64 {
+	localVelocityMu.Lock()
+	defer localVelocityMu.Unlock()
+	counted := localVelocity[shortCode]
+	if !counted.start.Equal(start) {
+		counted = velocityWindow{start: start}
+	}
+	counted.count++
+	localVelocity[shortCode] = counted
+	return counted.count
+}
+
+// respondVelocityOverflow answers a visitor beyond the link's velocity
+// limit as its overflow behavior says. Visitors told to retry come back
+// spread over up to a window after the current one ends, rather than all
+// at once.
+func respondVelocityOverflow(c *gin.Context, entry *cache.RedirectEntry, untilReset time.Duration) {
+	limit := entry.Velocity
+	if limit.Overflow == models.VelocityOverflowRedirect {
+		c.Header("Cache-Control", "no-store")
+		c.Redirect(http.StatusFound, limit.OverflowURL)
+		return
+	}
+
+	windowSeconds := int(limit.Window() / time.Second)
+	retryAfter := strconv.Itoa(int(math.Ceil(untilReset.Seconds())) + rand.Intn(windowSeconds+1))
+	c.Header("Retry-After", retryAfter)
+	c.Header("Cache-Control", "no-store")
+	if limit.Overflow == models.VelocityOverflowReject {
+		c.Error(models.NewAPIError(http.StatusTooManyRequests, models.ErrCodeRateLimited, "Short URL is receiving too many clicks, retry after "+retryAfter+" seconds"))
+		return
+	}
+	respondLinkError(c, models.ErrLinkBusy)
+}
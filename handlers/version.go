@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"runtime"
+
+	"url-shortener/buildinfo"
+	"url-shortener/cache"
+	"url-shortener/captcha"
+	"url-shortener/encryption"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/notify"
+	"url-shortener/policy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetVersion godoc
+// @Summary Build and feature information
+// @ID getVersion
+// @Description Return the deployed version, git commit and build date, and which optional features are enabled
+// @Tags System
+// @Produce json
+// @Success 200 {object} models.VersionResponse
+// @Router /version [get]
+func GetVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, models.VersionResponse{
+		Version:   buildinfo.Version,
+		Commit:    buildinfo.Commit,
+		BuildDate: buildinfo.BuildDate,
+		GoVersion: runtime.Version(),
+		Features:  enabledFeatures(),
+	})
+}
+
+// enabledFeatures reports the optional features turned on by configuration
+func enabledFeatures() map[string]bool {
+	return map[string]bool{
+		"approval_required":         policy.ApprovalRequired(),
+		"captcha":                   captcha.Enabled(),
+		"encryption_at_rest":        encryption.Enabled(),
+		"email_gateway":             os.Getenv("INBOUND_EMAIL_TOKEN") != "",
+		"email_replies":             notify.EmailEnabled(),
+		"admin_two_factor_required": middleware.AdminTwoFactorRequired(),
+		"redis_cache":               cache.RedisClient != nil,
+		"pprof":                     os.Getenv("ENABLE_PPROF") == "true",
+		"anonymous_shorten":         policy.AnonymousShortenAllowed(),
+		"link_bundles":              os.Getenv("LINK_BUNDLE_SECRET") != "",
+	}
+}
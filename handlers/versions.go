@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListLinkVersions godoc
+// @Summary List the configuration versions of one of your links
+// @ID listLinkVersions
+// @Description List the versions of the destination, routing rules, UTM parameters and redirect type of a link owned by the caller, newest first. Version 1 is the configuration the link was created with and each change records the next one; versions never change afterwards. clicks counts the click events recorded while each version was active, which carry it as link_version, including in click event exports. Clicks recorded before links were versioned have link_version 0 and are not counted.
+// @Tags Links
+// @Produce json
+// @Param shortCode path string true "Short code"
+// @Param short_domain query string false "Branded domain serving the link, omitted for the default one"
+// @Success 200 {array} models.LinkVersion
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope"
+// @Failure 404 {object} models.ErrorResponse "Short URL not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /links/{shortCode}/versions [get]
+func ListLinkVersions(c *gin.Context) {
+	var urlRecord models.URL
+	err := database.DB.WithContext(c.Request.Context()).
+		Where("short_code = ? AND owner_id = ?", pathLinkKey(c), *middleware.CurrentOwnerID(c)).First(&urlRecord).Error
+	if err != nil {
+		c.Error(models.ErrLinkNotFound)
+		return
+	}
+
+	versions, err := database.LinkVersions(c.Request.Context(), &urlRecord)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list link versions"))
+		return
+	}
+	c.JSON(http.StatusOK, versions)
+}
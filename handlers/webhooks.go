@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/outbound"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Webhooks a user may register
+const maxWebhooksPerUser = 10
+
+// ListWebhookEvents godoc
+// @Summary List webhook events
+// @ID listWebhookEvents
+// @Description List the events on your links that webhooks can subscribe to
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {array} models.HookTrigger
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /webhooks/events [get]
+func ListWebhookEvents(c *gin.Context) {
+	c.JSON(http.StatusOK, models.WebhookEvents)
+}
+
+// ListWebhooks godoc
+// @Summary List your webhooks
+// @ID listWebhooks
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {array} models.Webhook
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /webhooks [get]
+func ListWebhooks(c *gin.Context) {
+	webhooks := []models.Webhook{}
+	err := database.DB.WithContext(c.Request.Context()).
+		Where("owner_id = ?", *middleware.CurrentOwnerID(c)).Order("id").Find(&webhooks).Error
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list webhooks"))
+		return
+	}
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// CreateWebhook godoc
+// @Summary Register a webhook
+// @ID createWebhook
+// @Description Register a URL receiving a JSON POST for each subscribed event on the links owned by the caller: link.created, link.expired, link.clicks each time a link's click count reaches a multiple of click_threshold, link.disabled when an admin disables a link, or link.cleaned_up when the expired link cleanup deletes one; and on the caller's monthly link quota: quota.warning at 80% of it, quota.exhausted when it is used up. Deliveries carry X-Hook-Delivery, X-Hook-Event, X-Hook-Attempt, X-Timestamp and X-Signature headers, where the signature is hex(HMAC-SHA256(secret, timestamp + "\n" + body)) with the secret returned here once. Failed deliveries are retried with backoff. Responding 410 Gone to a delivery deletes the webhook.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param request body models.WebhookRequest true "Webhook"
+// @Success 201 {object} models.WebhookCreatedResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope"
+// @Failure 409 {object} models.ErrorResponse "Too many webhooks"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /webhooks [post]
+func CreateWebhook(c *gin.Context) {
+	var request models.WebhookRequest
+	if !bindWebhookRequest(c, &request) {
+		return
+	}
+
+	ownerID := *middleware.CurrentOwnerID(c)
+	var count int64
+	if err := database.DB.WithContext(c.Request.Context()).Model(&models.Webhook{}).Where("owner_id = ?", ownerID).Count(&count).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create webhook"))
+		return
+	}
+	if count >= maxWebhooksPerUser {
+		c.Error(models.NewAPIError(http.StatusConflict, models.ErrCodeConflict, "You already have the maximum number of webhooks"))
+		return
+	}
+
+	secret, err := utils.GenerateToken(signingSecretBytes)
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create webhook"))
+		return
+	}
+
+	webhook := models.Webhook{
+		OwnerID:         ownerID,
+		URL:             request.URL,
+		Events:          request.Events,
+		ClickThreshold:  request.ClickThreshold,
+		Secret:          secret,
+		ExpiryCheckedAt: time.Now(),
+	}
+	if err := database.DB.WithContext(c.Request.Context()).Create(&webhook).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create webhook"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.WebhookCreatedResponse{Webhook: webhook, Secret: secret})
+}
+
+// GetWebhook godoc
+// @Summary Get one of your webhooks
+// @ID getWebhook
+// @Tags Webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} models.Webhook
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope"
+// @Failure 404 {object} models.ErrorResponse "Webhook not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /webhooks/{id} [get]
+func GetWebhook(c *gin.Context) {
+	if webhook, ok := ownedWebhook(c); ok {
+		c.JSON(http.StatusOK, webhook)
+	}
+}
+
+// UpdateWebhook godoc
+// @Summary Replace one of your webhooks
+// @ID updateWebhook
+// @Description Change the URL, events and click threshold of a webhook owned by the caller. Its signing secret is kept. Subscribing to link.expired again only reports links expiring from now on.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param request body models.WebhookRequest true "Webhook"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope"
+// @Failure 404 {object} models.ErrorResponse "Webhook not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /webhooks/{id} [put]
+func UpdateWebhook(c *gin.Context) {
+	webhook, ok := ownedWebhook(c)
+	if !ok {
+		return
+	}
+	var request models.WebhookRequest
+	if !bindWebhookRequest(c, &request) {
+		return
+	}
+
+	columns := []string{"url", "events", "click_threshold"}
+	if !webhook.Subscribes(models.HookLinkExpired) {
+		webhook.ExpiryCheckedAt = time.Now()
+		columns = append(columns, "expiry_checked_at")
+	}
+	webhook.URL, webhook.Events, webhook.ClickThreshold = request.URL, request.Events, request.ClickThreshold
+	if err := database.DB.WithContext(c.Request.Context()).Model(webhook).Select(columns).Updates(webhook).Error; err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update webhook"))
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete one of your webhooks
+// @ID deleteWebhook
+// @Description Delete a webhook owned by the caller. Deliveries still being retried are moved to the dead letters.
+// @Tags Webhooks
+// @Param id path int true "Webhook ID"
+// @Success 204 "Webhook deleted"
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the update scope"
+// @Failure 404 {object} models.ErrorResponse "Webhook not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /webhooks/{id} [delete]
+func DeleteWebhook(c *gin.Context) {
+	result := database.DB.WithContext(c.Request.Context()).
+		Where("id = ? AND owner_id = ?", c.Param("id"), *middleware.CurrentOwnerID(c)).Delete(&models.Webhook{})
+	if result.Error != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete webhook"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Webhook not found"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries godoc
+// @Summary List the deliveries of one of your webhooks
+// @ID listWebhookDeliveries
+// @Description List the 50 most recent deliveries to a webhook owned by the caller, newest first, with their attempts and last error
+// @Tags Webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {array} models.HookDelivery
+// @Failure 401 {object} models.ErrorResponse "API key missing or invalid"
+// @Failure 403 {object} models.ErrorResponse "API key is not assigned to a user or lacks the read_stats scope"
+// @Failure 404 {object} models.ErrorResponse "Webhook not found"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Router /webhooks/{id}/deliveries [get]
+func ListWebhookDeliveries(c *gin.Context) {
+	webhook, ok := ownedWebhook(c)
+	if !ok {
+		return
+	}
+	deliveries := []models.HookDelivery{}
+	err := database.DB.WithContext(c.Request.Context()).
+		Where("webhook_id = ?", webhook.ID).Order("id desc").Limit(50).Find(&deliveries).Error
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to list webhook deliveries"))
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// bindWebhookRequest binds and checks a webhook, writing the error response
+// and returning false when it is invalid
+func bindWebhookRequest(c *gin.Context, request *models.WebhookRequest) bool {
+	if err := c.ShouldBindJSON(request); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error()))
+		return false
+	}
+	if err := outbound.CheckURL(request.URL); err != nil {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "Invalid url: "+err.Error()))
+		return false
+	}
+
+	clicks := false
+	for _, event := range request.Events {
+		clicks = clicks || event == models.HookLinkClicks
+	}
+	if clicks != (request.ClickThreshold > 0) {
+		c.Error(models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "click_threshold is required with link.clicks, and only allowed with it"))
+		return false
+	}
+	return true
+}
+
+// ownedWebhook loads the webhook in the path if the caller owns it, writing
+// the error response otherwise
+func ownedWebhook(c *gin.Context) (*models.Webhook, bool) {
+	var webhook models.Webhook
+	err := database.DB.WithContext(c.Request.Context()).
+		Where("id = ? AND owner_id = ?", c.Param("id"), *middleware.CurrentOwnerID(c)).First(&webhook).Error
+	if err != nil {
+		c.Error(models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Webhook not found"))
+		return nil, false
+	}
+	return &webhook, true
+}
@@ -0,0 +1,104 @@
+// Package i18n translates the HTML pages shown to people following short
+// links. Translations live in locales/<language>.json and are embedded in
+// the binary; add a file to support another language.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is used when no requested language is supported, and for
+// keys missing from a translation
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Messages by locale, then key
+var catalog = loadCatalog()
+
+func loadCatalog() map[string]map[string]string {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+
+	result := make(map[string]map[string]string, len(files))
+	for _, file := range files {
+		data, err := localeFiles.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic(err)
+		}
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic("i18n: invalid " + file.Name() + ": " + err.Error())
+		}
+		result[strings.TrimSuffix(file.Name(), ".json")] = messages
+	}
+	return result
+}
+
+// Locales returns the supported locales, sorted
+func Locales() []string {
+	locales := make([]string, 0, len(catalog))
+	for locale := range catalog {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// T returns the message for key in locale, falling back to DefaultLocale
+// and then to the key itself
+func T(locale, key string) string {
+	if message, ok := catalog[locale][key]; ok {
+		return message
+	}
+	if message, ok := catalog[DefaultLocale][key]; ok {
+		return message
+	}
+	return key
+}
+
+// Negotiate picks the supported locale best matching an Accept-Language
+// header, such as "fr-CA,fr;q=0.9,en;q=0.8". Regional variants match their
+// base language, and DefaultLocale is returned when nothing matches.
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		locale  string
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if tag == "" || quality <= 0 {
+			continue
+		}
+
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, ok := catalog[base]; ok {
+			candidates = append(candidates, candidate{locale: base, quality: quality})
+		}
+	}
+
+	// Stable, so equal qualities keep the client's order
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].quality > candidates[j].quality })
+	if len(candidates) > 0 {
+		return candidates[0].locale
+	}
+	return DefaultLocale
+}
@@ -0,0 +1,35 @@
+package i18n
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]string{
+		"":                              DefaultLocale,
+		"*":                             DefaultLocale,
+		"fr":                            "fr",
+		"fr-CA,fr;q=0.9,en;q=0.8":       "fr",
+		"de-DE":                         "de",
+		"zz,es;q=0.5":                   "es",
+		"en;q=0.4,vi;q=0.9":             "vi",
+		"ja;q=0,pt-BR":                  "pt",
+		"xx-YY":                         DefaultLocale,
+		"es;q=invalid,de":               "de",
+		"  PT-br ; q=0.7 , en ; q=0.6 ": "pt",
+	}
+	for header, want := range cases {
+		if got := Negotiate(header); got != want {
+			t.Errorf("Negotiate(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+// Every locale must translate every key of the default locale
+func TestLocalesComplete(t *testing.T) {
+	for _, locale := range Locales() {
+		for key := range catalog[DefaultLocale] {
+			if _, ok := catalog[locale][key]; !ok {
+				t.Errorf("locale %s is missing %q", locale, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/notify"
+)
+
+// How often API keys are checked for staleness
+const staleKeyCheckInterval = time.Hour
+
+// StartStaleAPIKeyMonitor periodically alerts on API keys that have not been
+// used within API_KEY_STALE_AFTER (default 90 days). Alerts are logged and
+// posted to API_KEY_ALERT_WEBHOOK_URL when set; with
+// API_KEY_AUTO_REVOKE_STALE=true stale keys are revoked as well.
+func StartStaleAPIKeyMonitor() {
+	go func() {
+		ticker := time.NewTicker(staleKeyCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			checkStaleAPIKeys()
+			beat("stale_api_keys", staleKeyCheckInterval)
+			<-ticker.C
+		}
+	}()
+}
+
+func checkStaleAPIKeys() {
+	staleAfter := 90 * 24 * time.Hour
+	if value, err := time.ParseDuration(os.Getenv("API_KEY_STALE_AFTER")); err == nil && value > 0 {
+		staleAfter = value
+	}
+	autoRevoke, _ := strconv.ParseBool(os.Getenv("API_KEY_AUTO_REVOKE_STALE"))
+	cutoff := time.Now().Add(-staleAfter)
+
+	var staleKeys []models.APIKey
+	err := database.DB.
+		Where("revoked_at IS NULL AND stale_alerted = ?", false).
+		Where("last_used_at < ? OR (last_used_at IS NULL AND created_at < ?)", cutoff, cutoff).
+		Find(&staleKeys).Error
+	if err != nil {
+		slog.Error("Failed to check for stale API keys", "error", err)
+		return
+	}
+
+	for _, key := range staleKeys {
+		updates := map[string]interface{}{"stale_alerted": true}
+		if autoRevoke {
+			updates["revoked_at"] = time.Now()
+		}
+		if err := database.DB.Model(&models.APIKey{}).Where("id = ?", key.ID).Updates(updates).Error; err != nil {
+			slog.Error("Failed to update stale API key", "api_key_id", key.ID, "error", err)
+			continue
+		}
+
+		slog.Warn("API key has gone stale", "api_key_id", key.ID, "name", key.Name, "key_prefix", key.KeyPrefix,
+			"last_used", lastUsed(&key), "revoked", autoRevoke)
+
+		if webhookURL := os.Getenv("API_KEY_ALERT_WEBHOOK_URL"); webhookURL != "" {
+			payload := map[string]interface{}{
+				"event":        "api_key.stale",
+				"api_key_id":   key.ID,
+				"name":         key.Name,
+				"key_prefix":   key.KeyPrefix,
+				"last_used_at": key.LastUsedAt,
+				"revoked":      autoRevoke,
+			}
+			message := notify.Message{
+				Title: "Stale API key",
+				Text:  "An API key has not been used for " + staleAfter.String() + ".",
+				Facts: []notify.Fact{
+					{Name: "Key", Value: key.Name + " (" + key.KeyPrefix + ")"},
+					{Name: "Last used", Value: lastUsed(&key)},
+					{Name: "Revoked", Value: strconv.FormatBool(autoRevoke)},
+				},
+			}
+			if err := notify.PostMessage(webhookURL, message, payload); err != nil {
+				slog.Error("Failed to send stale API key alert", "api_key_id", key.ID, "error", err)
+			}
+		}
+	}
+}
+
+func lastUsed(key *models.APIKey) string {
+	if key.LastUsedAt == nil {
+		return "never"
+	}
+	return key.LastUsedAt.Format(time.RFC3339)
+}
@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/database"
+)
+
+// How often idle links are archived, and how many are moved per statement
+const (
+	archiveInterval  = time.Hour
+	archiveBatchSize = 1000
+)
+
+// StartLinkArchiver moves links untouched for LINK_ARCHIVE_AFTER (e.g.
+// 4320h for about six months) out of the urls table and the cache into
+// archived_urls. Archived links still resolve and are rehydrated when
+// accessed. Nothing is archived when LINK_ARCHIVE_AFTER is unset.
+func StartLinkArchiver() {
+	archiveAfter, err := time.ParseDuration(os.Getenv("LINK_ARCHIVE_AFTER"))
+	if err != nil || archiveAfter <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(archiveInterval)
+		defer ticker.Stop()
+
+		for {
+			archiveIdleLinks(archiveAfter)
+			beat("link_archiver", archiveInterval)
+			<-ticker.C
+		}
+	}()
+}
+
+func archiveIdleLinks(archiveAfter time.Duration) {
+	ctx := database.WithRoute(context.Background(), "link_archiver")
+	cutoff := time.Now().Add(-archiveAfter)
+
+	archived := 0
+	for {
+		shortCodes, err := database.ArchiveIdleURLs(ctx, cutoff, archiveBatchSize)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to archive idle links", "error", err)
+			break
+		}
+		for _, shortCode := range shortCodes {
+			cache.InvalidateCache(shortCode)
+		}
+		archived += len(shortCodes)
+		if len(shortCodes) < archiveBatchSize {
+			break
+		}
+	}
+
+	if archived > 0 {
+		slog.InfoContext(ctx, "Archived idle links", "links", archived, "untouched_since", cutoff)
+	}
+}
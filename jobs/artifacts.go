@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"url-shortener/artifacts"
+	"url-shortener/database"
+)
+
+// Expired artifacts are deleted every artifactCleanupInterval,
+// artifactCleanupBatchSize at a time
+const (
+	artifactCleanupInterval  = 15 * time.Minute
+	artifactCleanupBatchSize = 100
+)
+
+// StartArtifactCleaner deletes generated files once they expire, after
+// ARTIFACT_RETENTION, and marks those whose generation was interrupted as
+// failed. It does nothing unless artifact storage is configured.
+func StartArtifactCleaner() {
+	store := artifacts.Current()
+	if store == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(artifactCleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			cleanUpArtifacts(context.Background(), store, time.Now())
+			beat("artifact_cleaner", artifactCleanupInterval)
+			<-ticker.C
+		}
+	}()
+}
+
+func cleanUpArtifacts(ctx context.Context, store artifacts.Store, now time.Time) {
+	ctx = database.WithRoute(ctx, "artifact_cleaner")
+	if failed, err := database.FailStalledArtifacts(ctx, now.Add(-artifacts.GenerationTimeout)); err != nil {
+		slog.ErrorContext(ctx, "Failed to fail interrupted artifacts", "error", err)
+	} else if failed > 0 {
+		slog.InfoContext(ctx, "Marked interrupted artifacts as failed", "artifacts", failed)
+	}
+
+	for {
+		expired, err := database.ExpiredArtifacts(ctx, now, artifactCleanupBatchSize)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to load expired artifacts", "error", err)
+			return
+		}
+		for _, artifact := range expired {
+			if artifact.Key != "" {
+				if err := store.Delete(ctx, artifact.Key); err != nil {
+					// Kept, so the deletion is retried on the next run
+					slog.ErrorContext(ctx, "Failed to delete artifact", "artifact_id", artifact.ID, "error", err)
+					return
+				}
+			}
+			if err := database.DB.WithContext(ctx).Delete(&artifact).Error; err != nil {
+				slog.ErrorContext(ctx, "Failed to delete artifact", "artifact_id", artifact.ID, "error", err)
+				return
+			}
+		}
+		if len(expired) < artifactCleanupBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"url-shortener/autotag"
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/notify"
+)
+
+// Bulk operations are looked for every bulkPollInterval, or as soon as one
+// is queued on this instance, and processed bulkBatchSize links at a time.
+// An instance holds an operation for bulkLease past each batch, after which
+// another instance takes it over, e.g. when the first one stopped.
+const (
+	bulkPollInterval = 30 * time.Second
+	bulkBatchSize    = 500
+	bulkLease        = 2 * time.Minute
+)
+
+// Wakes the bulk operation runner up early
+var bulkWake = make(chan struct{}, 1)
+
+// StartBulkOperationRunner processes queued bulk link operations, resuming
+// those interrupted by a restart
+func StartBulkOperationRunner() {
+	go func() {
+		ticker := time.NewTicker(bulkPollInterval)
+		defer ticker.Stop()
+
+		for {
+			runBulkOperations(context.Background())
+			beat("bulk_operation_runner", bulkPollInterval)
+			select {
+			case <-ticker.C:
+			case <-bulkWake:
+			}
+		}
+	}()
+}
+
+// WakeBulkOperationRunner asks the runner to look for queued operations now
+func WakeBulkOperationRunner() {
+	select {
+	case bulkWake <- struct{}{}:
+	default:
+	}
+}
+
+// runBulkOperations processes bulk operations until none is left unclaimed
+func runBulkOperations(ctx context.Context) {
+	ctx = database.WithRoute(ctx, "bulk_operation_runner")
+	for {
+		op, err := database.ClaimBulkOperation(ctx, bulkLease)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to claim a bulk operation", "error", err)
+			return
+		}
+		if op == nil {
+			return
+		}
+		runBulkOperation(ctx, op)
+	}
+}
+
+// runBulkOperation applies the action of op to its links batch by batch,
+// recording progress after each one
+func runBulkOperation(ctx context.Context, op *models.BulkOperation) {
+	for {
+		links, err := database.BulkLinksAfter(ctx, op, op.LastURLID, bulkBatchSize)
+		if err == nil && len(links) > 0 {
+			if op.Action == models.BulkActionAutoTag {
+				err = applyBulkTags(ctx, op, links)
+			} else {
+				err = applyBulkAction(ctx, op, links)
+			}
+		}
+		if err != nil {
+			// Not retried; the links are skipped when queued again, as they
+			// are expired, disabled or tagged already
+			op.Status = models.BulkStatusFailed
+			op.Error = err.Error()
+			slog.ErrorContext(ctx, "Bulk operation failed", "bulk_operation_id", op.ID, "action", op.Action, "processed", op.Processed, "error", err)
+		} else if len(links) < bulkBatchSize {
+			op.Status = models.BulkStatusCompleted
+			slog.InfoContext(ctx, "Bulk operation completed", "bulk_operation_id", op.ID, "action", op.Action, "updated", op.Updated, "skipped", op.Skipped)
+		}
+		if op.Status != models.BulkStatusRunning {
+			now := time.Now()
+			op.FinishedAt = &now
+		}
+
+		if err := database.SaveBulkProgress(ctx, op, bulkLease); err != nil {
+			// The lease runs out and another run resumes from the last save
+			slog.ErrorContext(ctx, "Failed to save the progress of bulk operation", "bulk_operation_id", op.ID, "error", err)
+			return
+		}
+		if op.Status != models.BulkStatusRunning {
+			return
+		}
+	}
+}
+
+// applyBulkAction expires the unlocked links of a batch not expired yet, or
+// disables the active ones, audit-logging each change and evicting it from the cache.
+// Disabled links are reported to their owners' link.disabled webhooks.
+func applyBulkAction(ctx context.Context, op *models.BulkOperation, links []models.URL) error {
+	now := time.Now()
+	var ids []uint
+	for _, link := range links {
+		switch {
+		case link.Locked:
+			op.Skipped++
+		case op.Action == models.BulkActionExpire && (link.ExpiresAt == nil || link.ExpiresAt.After(now)),
+			op.Action == models.BulkActionDisable && link.Status == models.StatusActive:
+			ids = append(ids, link.ID)
+		}
+	}
+
+	if len(ids) > 0 {
+		var shortCodes []string
+		var err error
+		action := models.AuditActionBulkExpire
+		if op.Action == models.BulkActionDisable {
+			shortCodes, err = database.DisableLinks(ctx, ids)
+			action = models.AuditActionBulkDisable
+		} else {
+			shortCodes, err = database.ExpireLinks(ctx, ids, now)
+		}
+		if err != nil {
+			return err
+		}
+
+		entries := make([]models.AuditLog, len(shortCodes))
+		for i, shortCode := range shortCodes {
+			entries[i] = models.AuditLog{Action: action, ShortCode: shortCode, Actor: "admin", Details: fmt.Sprintf("bulk operation %d", op.ID)}
+			cache.InvalidateCache(shortCode)
+		}
+		if op.Action == models.BulkActionDisable {
+			notify.FireLinkWebhooks(ctx, models.HookLinkDisabled, linksNamed(links, shortCodes, models.StatusDisabled))
+		}
+		if len(entries) > 0 {
+			if err := database.DB.WithContext(ctx).Create(&entries).Error; err != nil {
+				slog.ErrorContext(ctx, "Failed to record audit logs of bulk operation", "bulk_operation_id", op.ID, "error", err)
+			}
+		}
+		// Links locked since the batch was loaded were left alone
+		op.Updated += int64(len(shortCodes))
+		op.Skipped += int64(len(ids) - len(shortCodes))
+	}
+
+	op.Processed += int64(len(links))
+	op.LastURLID = links[len(links)-1].ID
+	return nil
+}
+
+// linksNamed returns the links with the given short codes, now in status
+func linksNamed(links []models.URL, shortCodes []string, status string) []models.URL {
+	named := make(map[string]bool, len(shortCodes))
+	for _, shortCode := range shortCodes {
+		named[shortCode] = true
+	}
+	var matched []models.URL
+	for _, link := range links {
+		if named[link.ShortCode] {
+			link.Status = status
+			matched = append(matched, link)
+		}
+	}
+	return matched
+}
+
+// applyBulkTags adds the tags of the current tag rules to the unlocked links
+// of a batch missing some, audit-logging each change and evicting it from
+// the cache
+func applyBulkTags(ctx context.Context, op *models.BulkOperation, links []models.URL) error {
+	rules := autotag.Current()
+	tags := make(map[uint][]string)
+	for _, link := range links {
+		switch tagged := rules.Apply(link.OriginalURL, link.Tags); {
+		case link.Locked:
+			op.Skipped++
+		case len(tagged) > len(link.Tags):
+			tags[link.ID] = tagged
+		}
+	}
+
+	if len(tags) > 0 {
+		shortCodes, err := database.TagLinks(ctx, tags)
+		if err != nil {
+			return err
+		}
+
+		entries := make([]models.AuditLog, len(shortCodes))
+		for i, shortCode := range shortCodes {
+			entries[i] = models.AuditLog{Action: models.AuditActionBulkTag, ShortCode: shortCode, Actor: "admin", Details: fmt.Sprintf("bulk operation %d", op.ID)}
+			cache.InvalidateCache(shortCode)
+		}
+		if len(entries) > 0 {
+			if err := database.DB.WithContext(ctx).Create(&entries).Error; err != nil {
+				slog.ErrorContext(ctx, "Failed to record audit logs of bulk operation", "bulk_operation_id", op.ID, "error", err)
+			}
+		}
+		// Links locked since the batch was loaded were left alone
+		op.Updated += int64(len(shortCodes))
+		op.Skipped += int64(len(tags) - len(shortCodes))
+	}
+
+	op.Processed += int64(len(links))
+	op.LastURLID = links[len(links)-1].ID
+	return nil
+}
@@ -0,0 +1,155 @@
+package jobs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/database/databasetest"
+	"url-shortener/models"
+)
+
+// queueBulkOperation stores op as queued, with every link matching
+func queueBulkOperation(t *testing.T, op *models.BulkOperation) {
+	t.Helper()
+	if op.Status == "" {
+		op.Status = models.BulkStatusQueued
+	}
+	if err := database.DB.Create(op).Error; err != nil {
+		t.Fatalf("queueing bulk operation: %v", err)
+	}
+}
+
+// reloadBulkOperation returns op as stored
+func reloadBulkOperation(t *testing.T, op *models.BulkOperation) models.BulkOperation {
+	t.Helper()
+	var stored models.BulkOperation
+	if err := database.DB.First(&stored, op.ID).Error; err != nil {
+		t.Fatalf("loading bulk operation: %v", err)
+	}
+	return stored
+}
+
+func TestBulkDisableSkipsLockedLinks(t *testing.T) {
+	databasetest.UseSQLite(t)
+	owner := uint(1)
+	links := []models.URL{
+		{OriginalURL: "https://example.com/active", ShortCode: "active", OwnerID: &owner},
+		{OriginalURL: "https://example.com/locked", ShortCode: "locked", OwnerID: &owner, Locked: true},
+		{OriginalURL: "https://example.com/disabled", ShortCode: "disabled", Status: models.StatusDisabled},
+	}
+	if err := database.DB.Create(&links).Error; err != nil {
+		t.Fatalf("creating links: %v", err)
+	}
+	webhook := databasetest.CreateWebhook(t, owner, models.HookLinkDisabled)
+	op := &models.BulkOperation{Action: models.BulkActionDisable, Matched: 3}
+	queueBulkOperation(t, op)
+
+	runBulkOperations(context.Background())
+
+	stored := reloadBulkOperation(t, op)
+	if stored.Status != models.BulkStatusCompleted || stored.Processed != 3 || stored.Updated != 1 || stored.Skipped != 1 || stored.FinishedAt == nil {
+		t.Errorf("bulk operation = %+v, want completed with 3 processed, 1 updated, 1 skipped", stored)
+	}
+	for shortCode, want := range map[string]string{"active": models.StatusDisabled, "locked": models.StatusActive} {
+		var link models.URL
+		database.DB.Where("short_code = ?", shortCode).First(&link)
+		if link.Status != want {
+			t.Errorf("%s is %s, want %s", shortCode, link.Status, want)
+		}
+	}
+	var audits []models.AuditLog
+	database.DB.Where("action = ?", models.AuditActionBulkDisable).Find(&audits)
+	if len(audits) != 1 || audits[0].ShortCode != "active" {
+		t.Errorf("audit logs = %+v, want one for active", audits)
+	}
+	if deliveries := databasetest.Deliveries(t, webhook); len(deliveries) != 1 || deliveries[0].Event != models.HookLinkDisabled {
+		t.Errorf("deliveries = %+v, want link.disabled for active only", deliveries)
+	}
+}
+
+func TestBulkOperationResumesAfterRestart(t *testing.T) {
+	databasetest.UseSQLite(t)
+	var links []models.URL
+	for i := 0; i < 4; i++ {
+		code := "link" + strconv.Itoa(i)
+		links = append(links, models.URL{OriginalURL: "https://example.com/" + code, ShortCode: code})
+	}
+	if err := database.DB.Create(&links).Error; err != nil {
+		t.Fatalf("creating links: %v", err)
+	}
+
+	// An instance stopped after the first two links, and its lease ran out
+	expired := time.Now().Add(-time.Minute)
+	interrupted := &models.BulkOperation{
+		Action: models.BulkActionExpire, Status: models.BulkStatusRunning, Matched: 4,
+		Processed: 2, Updated: 2, LastURLID: links[1].ID, LeaseUntil: &expired,
+	}
+	queueBulkOperation(t, interrupted)
+	// Another is still held by a running instance
+	held := time.Now().Add(time.Minute)
+	running := &models.BulkOperation{Action: models.BulkActionDisable, Status: models.BulkStatusRunning, Matched: 4, LeaseUntil: &held}
+	queueBulkOperation(t, running)
+
+	runBulkOperations(context.Background())
+
+	stored := reloadBulkOperation(t, interrupted)
+	if stored.Status != models.BulkStatusCompleted || stored.Processed != 4 || stored.Updated != 4 || stored.LastURLID != links[3].ID {
+		t.Errorf("resumed operation = %+v, want completed with 4 processed and updated", stored)
+	}
+	for i, link := range links {
+		var reloaded models.URL
+		database.DB.First(&reloaded, link.ID)
+		// The links before the resume point were left as they are
+		if expiredNow := reloaded.ExpiresAt != nil; expiredNow != (i >= 2) {
+			t.Errorf("%s expires at %v, want expired: %t", link.ShortCode, reloaded.ExpiresAt, i >= 2)
+		}
+		if reloaded.Status != models.StatusActive {
+			t.Errorf("%s is %s, want the held operation not run", link.ShortCode, reloaded.Status)
+		}
+	}
+	if stored := reloadBulkOperation(t, running); stored.Processed != 0 || stored.Status != models.BulkStatusRunning {
+		t.Errorf("held operation = %+v, want it left to its instance", stored)
+	}
+}
+
+func TestBulkOperationReportsProgress(t *testing.T) {
+	databasetest.UseSQLite(t)
+	total := 2*bulkBatchSize + 10
+	links := make([]models.URL, total)
+	for i := range links {
+		code := "link" + strconv.Itoa(i)
+		links[i] = models.URL{OriginalURL: "https://example.com/" + code, ShortCode: code, Locked: i%100 == 0}
+	}
+	if err := database.DB.CreateInBatches(&links, 200).Error; err != nil {
+		t.Fatalf("creating links: %v", err)
+	}
+	op := &models.BulkOperation{Action: models.BulkActionExpire, Status: models.BulkStatusRunning, Matched: int64(total)}
+	queueBulkOperation(t, op)
+
+	// Progress is saved after each batch
+	ctx := context.Background()
+	batch, err := database.BulkLinksAfter(ctx, op, op.LastURLID, bulkBatchSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyBulkAction(ctx, op, batch); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SaveBulkProgress(ctx, op, bulkLease); err != nil {
+		t.Fatal(err)
+	}
+	stored := reloadBulkOperation(t, op)
+	if stored.Processed != int64(bulkBatchSize) || stored.Updated != int64(bulkBatchSize-5) || stored.Skipped != 5 {
+		t.Errorf("after a batch: %+v, want %d processed, %d updated, 5 skipped", stored, bulkBatchSize, bulkBatchSize-5)
+	}
+
+	runBulkOperation(ctx, op)
+	stored = reloadBulkOperation(t, op)
+	locked := int64(total/100 + 1)
+	if stored.Status != models.BulkStatusCompleted || stored.Processed != int64(total) || stored.Updated != int64(total)-locked || stored.Skipped != locked {
+		t.Errorf("completed operation = %+v, want %d processed, %d skipped", stored, total, locked)
+	}
+}
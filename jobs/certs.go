@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"time"
+
+	"url-shortener/certs"
+)
+
+// How often every branded domain's certificate is checked
+const certificateInterval = 24 * time.Hour
+
+// StartCertificateProvisioner obtains TLS certificates for branded domains
+// ahead of their first visit and keeps them renewed when ACME_ENABLED=true.
+// Certificates of domains added since are obtained the next day at the
+// latest, or on their first handshake.
+func StartCertificateProvisioner() {
+	if !certs.Enabled() {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(certificateInterval)
+		defer ticker.Stop()
+
+		for {
+			certs.ProvisionAll()
+			beat("certificate_provisioner", certificateInterval)
+			<-ticker.C
+		}
+	}()
+}
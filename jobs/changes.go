@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"url-shortener/database"
+)
+
+// How often link changes past their retention are pruned
+const linkChangePruneInterval = time.Hour
+
+// StartLinkChangePruner deletes the link changes older than
+// CHANGE_FEED_RETENTION (default 168h). Readers further behind than that
+// must rescan the links.
+func StartLinkChangePruner() {
+	go func() {
+		ticker := time.NewTicker(linkChangePruneInterval)
+		defer ticker.Stop()
+
+		for {
+			pruneLinkChanges()
+			beat("link_change_pruner", linkChangePruneInterval)
+			<-ticker.C
+		}
+	}()
+}
+
+func pruneLinkChanges() {
+	ctx := database.WithRoute(context.Background(), "link_change_pruner")
+	pruned, err := database.PruneLinkChanges(ctx, time.Now().Add(-linkChangeRetention()))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to prune link changes", "error", err)
+		return
+	}
+	if pruned > 0 {
+		slog.InfoContext(ctx, "Pruned link changes", "changes", pruned)
+	}
+}
+
+// linkChangeRetention reads CHANGE_FEED_RETENTION, how long link changes
+// are kept for readers of the change feed
+func linkChangeRetention() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("CHANGE_FEED_RETENTION")); err == nil && value > 0 {
+		return value
+	}
+	return 7 * 24 * time.Hour
+}
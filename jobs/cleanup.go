@@ -0,0 +1,157 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/notify"
+)
+
+// How many expired links are cleaned up per statement
+const expiredLinkBatchSize = 500
+
+// Serializes scheduled and on-demand cleanups on an instance
+var expiredLinkCleanupMu sync.Mutex
+
+// StartExpiredLinkCleaner cleans up links once they have been expired for
+// EXPIRED_LINK_RETENTION (default 720h), every
+// EXPIRED_LINK_CLEANUP_INTERVAL (default 1h, 0 to only clean up when an
+// admin asks to). Until then expired links answer 410 Gone.
+func StartExpiredLinkCleaner() {
+	interval := expiredLinkCleanupInterval()
+	if interval == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			CleanUpExpiredLinks(context.Background())
+			beat("expired_link_cleaner", interval)
+			<-ticker.C
+		}
+	}()
+}
+
+// CleanUpExpiredLinks soft-deletes or purges the links expired for longer
+// than EXPIRED_LINK_RETENTION, as set by EXPIRED_LINK_CLEANUP, and evicts
+// them from the cache. Purging also removes expired archived links and frees
+// every short code cleaned up for reuse. Locked links are never cleaned up.
+// The owners' webhooks subscribed to link.cleaned_up are told about each
+// link cleaned up.
+func CleanUpExpiredLinks(ctx context.Context) models.ExpiredLinkCleanupReport {
+	expiredLinkCleanupMu.Lock()
+	defer expiredLinkCleanupMu.Unlock()
+
+	ctx = database.WithRoute(ctx, "expired_link_cleaner")
+	report := models.ExpiredLinkCleanupReport{
+		StartedAt:     time.Now(),
+		Mode:          expiredLinkCleanupMode(),
+		ExpiredBefore: time.Now().Add(-expiredLinkRetention()),
+	}
+	purge := report.Mode == models.CleanupPurge
+
+	if err := cleanUpExpiredURLs(ctx, &report, purge); err != nil {
+		report.Error = err.Error()
+	} else if purge {
+		if err := purgeExpiredArchivedURLs(ctx, &report); err != nil {
+			report.Error = err.Error()
+		}
+	}
+
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+	if report.Error != "" {
+		slog.ErrorContext(ctx, "Failed to clean up expired links", "error", report.Error)
+	}
+	if report.Links > 0 || report.ArchivedLinks > 0 {
+		slog.InfoContext(ctx, "Cleaned up expired links", "links", report.Links, "archived_links", report.ArchivedLinks,
+			"expired_before", report.ExpiredBefore, "mode", report.Mode)
+	}
+	return report
+}
+
+func cleanUpExpiredURLs(ctx context.Context, report *models.ExpiredLinkCleanupReport, purge bool) error {
+	for {
+		urls, err := database.ExpiredURLs(ctx, report.ExpiredBefore, expiredLinkBatchSize, purge)
+		if err != nil || len(urls) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(urls))
+		for i, url := range urls {
+			ids[i] = url.ID
+		}
+		if purge {
+			err = database.PurgeURLs(ctx, ids)
+		} else {
+			err = database.SoftDeleteURLs(ctx, ids)
+		}
+		if err != nil {
+			return fmt.Errorf("cleaning up %d links: %w", len(ids), err)
+		}
+
+		for _, url := range urls {
+			cache.InvalidateCache(url.ShortCode)
+			cache.InvalidateOriginalURLMapping(url.OwnerID, url.OriginalURL)
+		}
+		notify.FireLinkWebhooks(ctx, models.HookLinkCleanedUp, urls)
+		report.Links += len(urls)
+		if len(urls) < expiredLinkBatchSize {
+			return nil
+		}
+	}
+}
+
+func purgeExpiredArchivedURLs(ctx context.Context, report *models.ExpiredLinkCleanupReport) error {
+	for {
+		shortCodes, err := database.PurgeExpiredArchivedURLs(ctx, report.ExpiredBefore, expiredLinkBatchSize)
+		if err != nil {
+			return fmt.Errorf("purging archived links: %w", err)
+		}
+		// Evicted in case a lookup cached them
+		for _, shortCode := range shortCodes {
+			cache.InvalidateCache(shortCode)
+		}
+		report.ArchivedLinks += len(shortCodes)
+		if len(shortCodes) < expiredLinkBatchSize {
+			return nil
+		}
+	}
+}
+
+// expiredLinkCleanupMode reads EXPIRED_LINK_CLEANUP, soft-deleting unless
+// set to purge
+func expiredLinkCleanupMode() string {
+	if strings.EqualFold(os.Getenv("EXPIRED_LINK_CLEANUP"), models.CleanupPurge) {
+		return models.CleanupPurge
+	}
+	return models.CleanupSoftDelete
+}
+
+// expiredLinkCleanupInterval reads EXPIRED_LINK_CLEANUP_INTERVAL, how often
+// expired links are cleaned up, 0 when only an admin cleans them up
+func expiredLinkCleanupInterval() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("EXPIRED_LINK_CLEANUP_INTERVAL")); err == nil && value >= 0 {
+		return value
+	}
+	return time.Hour
+}
+
+// expiredLinkRetention reads EXPIRED_LINK_RETENTION, how long expired links
+// are kept answering 410 Gone before they are cleaned up
+func expiredLinkRetention() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("EXPIRED_LINK_RETENTION")); err == nil && value >= 0 {
+		return value
+	}
+	return 30 * 24 * time.Hour
+}
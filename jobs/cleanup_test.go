@@ -0,0 +1,45 @@
+package jobs_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/database/databasetest"
+	"url-shortener/jobs"
+	"url-shortener/models"
+)
+
+func TestCleanUpExpiredLinksFiresWebhooks(t *testing.T) {
+	t.Setenv("EXPIRED_LINK_RETENTION", "1h")
+	databasetest.UseSQLite(t)
+
+	owner := uint(1)
+	longAgo, recently := time.Now().Add(-2*time.Hour), time.Now().Add(-time.Minute)
+	links := []models.URL{
+		{OriginalURL: "https://example.com/old", ShortCode: "old", OwnerID: &owner, ExpiresAt: &longAgo},
+		{OriginalURL: "https://example.com/recent", ShortCode: "recent", OwnerID: &owner, ExpiresAt: &recently},
+		{OriginalURL: "https://example.com/locked", ShortCode: "locked", OwnerID: &owner, ExpiresAt: &longAgo, Locked: true},
+	}
+	if err := database.DB.Create(&links).Error; err != nil {
+		t.Fatalf("creating links: %v", err)
+	}
+	webhook := databasetest.CreateWebhook(t, owner, models.HookLinkCleanedUp)
+
+	report := jobs.CleanUpExpiredLinks(context.Background())
+	if report.Error != "" || report.Links != 1 || report.Mode != models.CleanupSoftDelete {
+		t.Fatalf("CleanUpExpiredLinks() = %+v, want one link soft-deleted", report)
+	}
+
+	// Only the link cleaned up is reported, not those kept
+	deliveries := databasetest.Deliveries(t, webhook)
+	if len(deliveries) != 1 || deliveries[0].Event != models.HookLinkCleanedUp {
+		t.Fatalf("deliveries = %+v, want one link.cleaned_up", deliveries)
+	}
+	var payload models.HookLinkPayload
+	if err := json.Unmarshal(deliveries[0].Payload, &payload); err != nil || payload.ShortCode != "old" {
+		t.Errorf("link.cleaned_up payload = %+v, %v, want old", payload, err)
+	}
+}
@@ -0,0 +1,202 @@
+package jobs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/objectstore"
+)
+
+// Click events are exported by the hour once the hour has been over for
+// clickExportDelay, as clicks are recorded in the background
+const (
+	clickExportInterval    = 15 * time.Minute
+	clickExportDelay       = 10 * time.Minute
+	clickExportHoursPerRun = 24 // so a backlog is caught up gradually
+	clickExportPageSize    = 5000
+	clickExportObjectSize  = 100000 // events per object
+
+	// Exported hours this recent are checked for clicks stored late, e.g.
+	// flushed by a recovering instance or imported, and exported again
+	clickExportRecheckWindow = 24 * time.Hour
+)
+
+// Objects are written under this prefix unless CLICK_EXPORT_PREFIX is set
+const defaultClickExportPrefix = "click_events/"
+
+// StartClickEventExporter writes click events to the object storage bucket
+// in CLICK_EXPORT_BUCKET as gzipped NDJSON, one directory per day and hour
+// (dt=YYYY-MM-DD/hour=HH/), for warehouses to ingest without database
+// access. It does nothing unless a bucket is configured. Exported hours are
+// recorded in click_exports with their event count and last event ID, so the
+// exporter resumes after restarts and re-exports recent hours that gained
+// clicks; the first run starts at the oldest click event.
+func StartClickEventExporter() {
+	config, err := objectstore.FromEnv("CLICK_EXPORT_")
+	if errors.Is(err, objectstore.ErrNotConfigured) {
+		return
+	}
+	if err != nil {
+		slog.Warn("Invalid click export configuration, not exporting click events", "error", err)
+		return
+	}
+
+	prefix := os.Getenv("CLICK_EXPORT_PREFIX")
+	if prefix == "" {
+		prefix = defaultClickExportPrefix
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	exporter := &clickExporter{store: objectstore.New(config), prefix: prefix}
+
+	go func() {
+		ticker := time.NewTicker(clickExportInterval)
+		defer ticker.Stop()
+
+		for {
+			exporter.run(time.Now())
+			beat("click_event_exporter", clickExportInterval)
+			<-ticker.C
+		}
+	}()
+}
+
+type clickExporter struct {
+	store  *objectstore.Client
+	prefix string
+}
+
+// run exports the complete hours not exported yet
+func (e *clickExporter) run(now time.Time) {
+	ctx := database.WithRoute(context.Background(), "click_event_exporter")
+	e.recheck(ctx, now)
+
+	hour, ok, err := database.NextClickExportHour(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to find the next hour of click events to export", "error", err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	for i := 0; i < clickExportHoursPerRun && !hour.Add(time.Hour+clickExportDelay).After(now); i++ {
+		export, err := e.exportHour(ctx, hour)
+		if err != nil {
+			// Retried from the same hour on the next run
+			slog.ErrorContext(ctx, "Failed to export click events", "hour", hour, "error", err)
+			return
+		}
+		if export.Events > 0 {
+			slog.InfoContext(ctx, "Exported click events", "hour", hour, "events", export.Events, "objects", export.Objects)
+		}
+		hour = hour.Add(time.Hour)
+	}
+}
+
+// recheck exports again the recently exported hours whose click events no
+// longer match the checkpoint recorded when they were exported
+func (e *clickExporter) recheck(ctx context.Context, now time.Time) {
+	exports, err := database.ClickExportsSince(ctx, now.Add(-clickExportRecheckWindow).Truncate(time.Hour))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load recent click exports", "error", err)
+		return
+	}
+
+	for _, previous := range exports {
+		count, lastID, err := database.ClickEventTotals(ctx, previous.Hour, previous.Hour.Add(time.Hour))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to check exported click events", "hour", previous.Hour, "error", err)
+			return
+		}
+		if count == previous.Events && lastID == previous.LastEventID {
+			continue
+		}
+
+		slog.WarnContext(ctx, "Click events changed since they were exported, exporting again", "hour", previous.Hour,
+			"exported_events", previous.Events, "exported_last_id", previous.LastEventID, "events", count, "last_id", lastID)
+		if _, err := e.exportHour(ctx, previous.Hour); err != nil {
+			slog.ErrorContext(ctx, "Failed to export click events again", "hour", previous.Hour, "error", err)
+			return
+		}
+	}
+}
+
+// exportHour uploads the click events of the hour starting at hour. Objects
+// have deterministic keys, so exporting an hour again replaces them.
+func (e *clickExporter) exportHour(ctx context.Context, hour time.Time) (models.ClickExport, error) {
+	export := models.ClickExport{Hour: hour}
+	if previous, err := database.FindClickExport(ctx, hour); err == nil {
+		export.Exports = previous.Exports
+	}
+
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	encoder := json.NewEncoder(writer)
+	inObject := 0
+	upload := func() error {
+		if err := writer.Close(); err != nil {
+			return err
+		}
+		if err := e.store.Put(ctx, clickExportKey(e.prefix, hour, export.Objects), buffer.Bytes(), "application/gzip"); err != nil {
+			return err
+		}
+		export.Objects++
+		inObject = 0
+		buffer.Reset()
+		writer.Reset(&buffer)
+		return nil
+	}
+
+	var afterID uint
+	for {
+		events, err := database.ClickEventsBetween(ctx, hour, hour.Add(time.Hour), afterID, clickExportPageSize)
+		if err != nil {
+			return export, err
+		}
+		for _, event := range events {
+			if err := encoder.Encode(event); err != nil {
+				return export, err
+			}
+			export.Events++
+			export.LastEventID = event.ID
+			inObject++
+			if inObject == clickExportObjectSize {
+				if err := upload(); err != nil {
+					return export, err
+				}
+			}
+		}
+		if len(events) < clickExportPageSize {
+			break
+		}
+		afterID = events[len(events)-1].ID
+	}
+	if inObject > 0 {
+		if err := upload(); err != nil {
+			return export, err
+		}
+	}
+
+	export.Exports++
+	export.ExportedAt = time.Now()
+	return export, database.RecordClickExport(ctx, export)
+}
+
+// clickExportKey names an object of the export of an hour, partitioned by
+// day and hour for warehouse ingestion
+func clickExportKey(prefix string, hour time.Time, part int) string {
+	hour = hour.UTC()
+	return fmt.Sprintf("%sdt=%s/hour=%s/part-%05d.ndjson.gz", prefix, hour.Format("2006-01-02"), hour.Format("15"), part)
+}
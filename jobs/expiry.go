@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/expiry"
+)
+
+// How often existing links are checked against the maximum lifetime
+const linkExpiryInterval = 24 * time.Hour
+
+// StartLinkExpiryEnforcer holds existing links to LINK_MAX_EXPIRY_DAYS, so
+// lowering the maximum also applies to links created before the change. New
+// links get their expiry at creation. Links exempted by an admin are skipped.
+func StartLinkExpiryEnforcer() {
+	policy, err := expiry.PolicyFromEnv()
+	if err != nil {
+		slog.Warn("Invalid link lifetime policy, not enforcing it on existing links", "error", err)
+		return
+	}
+	if !policy.Enforced() {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(linkExpiryInterval)
+		defer ticker.Stop()
+
+		for {
+			enforceLinkExpiry(policy)
+			beat("link_expiry_enforcer", linkExpiryInterval)
+			<-ticker.C
+		}
+	}()
+}
+
+func enforceLinkExpiry(policy expiry.Policy) {
+	ctx := database.WithRoute(context.Background(), "link_expiry_enforcer")
+	shortCodes, err := database.EnforceLinkExpiry(ctx, policy, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to enforce the maximum link lifetime", "error", err)
+		return
+	}
+
+	// Cached redirects still carry the previous expiry
+	for _, shortCode := range shortCodes {
+		cache.InvalidateCache(shortCode)
+	}
+	if len(shortCodes) > 0 {
+		slog.InfoContext(ctx, "Capped the expiry of links", "links", len(shortCodes), "max_days", policy.MaxDays)
+	}
+}
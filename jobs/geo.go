@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/geo"
+)
+
+// How often stored click locations are checked against the precision policy
+const clickGeoInterval = 24 * time.Hour
+
+// StartClickGeoEnforcer coarsens stored click locations to the precision
+// CLICK_GEO_PRECISION and CLICK_GEO_ZONES allow. New clicks are coarsened
+// before they are stored, so this only changes clicks recorded under a more
+// precise policy or imported from elsewhere.
+func StartClickGeoEnforcer() {
+	policy, err := geo.PolicyFromEnv()
+	if err != nil {
+		slog.Warn("Invalid click location precision, not coarsening stored clicks", "error", err)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(clickGeoInterval)
+		defer ticker.Stop()
+
+		for {
+			coarsenClickLocations(policy)
+			beat("click_geo_enforcer", clickGeoInterval)
+			<-ticker.C
+		}
+	}()
+}
+
+func coarsenClickLocations(policy geo.Policy) {
+	ctx := database.WithRoute(context.Background(), "click_geo_enforcer")
+	changed, err := database.CoarsenClickEvents(ctx, policy)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to coarsen stored click locations", "error", err)
+		return
+	}
+	if changed > 0 {
+		slog.InfoContext(ctx, "Coarsened the location of stored clicks", "clicks", changed)
+	}
+}
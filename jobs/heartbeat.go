@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"url-shortener/models"
+)
+
+// A job is stale when it missed this many runs
+const staleAfterIntervals = 2
+
+type heartbeat struct {
+	interval time.Duration
+	lastRun  time.Time
+}
+
+var (
+	heartbeatsMu sync.Mutex
+	heartbeats   = make(map[string]*heartbeat)
+)
+
+// beat records that a background job ran
+func beat(name string, interval time.Duration) {
+	heartbeatsMu.Lock()
+	defer heartbeatsMu.Unlock()
+	heartbeats[name] = &heartbeat{interval: interval, lastRun: time.Now()}
+}
+
+// Heartbeats reports when each background job last ran and whether it is
+// overdue, sorted by job name
+func Heartbeats() []models.JobHeartbeat {
+	heartbeatsMu.Lock()
+	defer heartbeatsMu.Unlock()
+
+	now := time.Now()
+	result := make([]models.JobHeartbeat, 0, len(heartbeats))
+	for name, hb := range heartbeats {
+		result = append(result, models.JobHeartbeat{
+			Name:     name,
+			LastRun:  hb.lastRun,
+			Interval: hb.interval.String(),
+			Stale:    now.Sub(hb.lastRun) > staleAfterIntervals*hb.interval,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"url-shortener/notify"
+)
+
+// How often due hook deliveries are retried, and how long successful
+// deliveries are kept
+const (
+	hookRetryInterval     = 30 * time.Second
+	hookDeliveryRetention = 7 * 24 * time.Hour
+)
+
+// StartHookDeliveryRetrier retries failed REST Hooks deliveries when their
+// next attempt is due, including redriven dead letters, and hourly prunes
+// successful deliveries older than a week
+func StartHookDeliveryRetrier() {
+	go func() {
+		ticker := time.NewTicker(hookRetryInterval)
+		defer ticker.Stop()
+
+		var lastPrune time.Time
+		for {
+			ctx := context.Background()
+			if _, err := notify.RetryHookDeliveries(ctx); err != nil {
+				slog.ErrorContext(ctx, "Failed to retry hook deliveries", "error", err)
+			}
+			if time.Since(lastPrune) >= time.Hour {
+				if _, err := notify.PruneHookDeliveries(ctx, hookDeliveryRetention); err != nil {
+					slog.ErrorContext(ctx, "Failed to prune hook deliveries", "error", err)
+				}
+				lastPrune = time.Now()
+			}
+			beat("hook_delivery_retrier", hookRetryInterval)
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,168 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/storage"
+)
+
+// How many links are compared with their cached redirect per verification
+const integrityCacheSampleSize = 100
+
+var (
+	// Serializes verifications on an instance
+	integrityRunMu sync.Mutex
+
+	integrityMu     sync.Mutex
+	integrityStatus models.IntegrityStatus
+)
+
+// StartIntegrityVerifier checks the invariants of the link data once at
+// startup when INTEGRITY_CHECK_ON_STARTUP is on, and every
+// INTEGRITY_CHECK_INTERVAL when set, to catch corruption early after a
+// migration or a restore. Violations are logged and reported by Integrity,
+// never repaired.
+func StartIntegrityVerifier() {
+	onStartup, _ := strconv.ParseBool(os.Getenv("INTEGRITY_CHECK_ON_STARTUP"))
+	interval, err := time.ParseDuration(os.Getenv("INTEGRITY_CHECK_INTERVAL"))
+	if err != nil || interval < 0 {
+		interval = 0
+	}
+	if !onStartup && interval == 0 {
+		return
+	}
+
+	go func() {
+		if onStartup {
+			VerifyIntegrity(context.Background(), models.IntegrityTriggerStartup)
+		}
+		if interval == 0 {
+			return
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			beat("integrity_verifier", interval)
+			<-ticker.C
+			VerifyIntegrity(context.Background(), models.IntegrityTriggerScheduled)
+		}
+	}()
+}
+
+// Integrity returns the last integrity verification report and totals
+func Integrity() models.IntegrityStatus {
+	integrityMu.Lock()
+	defer integrityMu.Unlock()
+	return integrityStatus
+}
+
+// VerifyIntegrity checks the invariants of the link data and records the
+// report returned. A check that fails reports its error and the others
+// still run.
+func VerifyIntegrity(ctx context.Context, trigger string) models.IntegrityReport {
+	integrityRunMu.Lock()
+	defer integrityRunMu.Unlock()
+
+	ctx = database.WithRoute(ctx, "integrity_verifier")
+	report := models.IntegrityReport{StartedAt: time.Now(), Trigger: trigger}
+
+	checks := []func(context.Context) (models.IntegrityCheck, error){
+		database.DuplicateShortCodes,
+		checkExpiredLinks,
+		database.OrphanedAliases,
+		checkCacheDrift,
+	}
+	for _, run := range checks {
+		check, err := run(ctx)
+		if err != nil {
+			check.Error = err.Error()
+			slog.ErrorContext(ctx, "Failed to run integrity check", "check", check.Name, "error", err)
+		}
+		if check.Violations > 0 {
+			slog.WarnContext(ctx, "Integrity check found violations", "check", check.Name,
+				"violations", check.Violations, "checked", check.Checked, "samples", check.Samples)
+		}
+		report.Violations += check.Violations
+		report.Checks = append(report.Checks, check)
+	}
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+
+	integrityMu.Lock()
+	integrityStatus.LastRun = &report
+	integrityStatus.Runs++
+	if report.Violations > 0 {
+		integrityStatus.RunsWithViolations++
+	}
+	integrityStatus.TotalViolations += report.Violations
+	integrityMu.Unlock()
+
+	return report
+}
+
+// checkExpiredLinks counts the links the expired link cleanup should have
+// cleaned up by now, allowing it two intervals to catch up
+func checkExpiredLinks(ctx context.Context) (models.IntegrityCheck, error) {
+	interval := expiredLinkCleanupInterval()
+	if interval == 0 {
+		return models.IntegrityCheck{
+			Name:    models.IntegrityExpiredLinks,
+			Skipped: "expired links are only cleaned up on request",
+		}, nil
+	}
+	return database.ExpiredLinks(ctx, time.Now().Add(-expiredLinkRetention()-2*interval))
+}
+
+// checkCacheDrift compares the cached redirects of a sample of links with
+// the ones their records build. Drifted entries expire with the cache TTL,
+// so they are reported but left alone.
+func checkCacheDrift(ctx context.Context) (models.IntegrityCheck, error) {
+	check := models.IntegrityCheck{Name: models.IntegrityCacheDrift}
+	if cache.RedisClient == nil {
+		check.Skipped = "Redis is not connected"
+		return check, nil
+	}
+
+	links, err := database.SampleLinks(ctx, integrityCacheSampleSize)
+	if err != nil {
+		return check, err
+	}
+	for i := range links {
+		cached, err := cache.GetRedirectEntry(links[i].ShortCode)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return check, err
+		}
+		check.Checked++
+		if redirectDrifted(cached, cache.NewRedirectEntry(&links[i])) {
+			check.Violations++
+			if len(check.Samples) < models.IntegritySamples {
+				check.Samples = append(check.Samples, links[i].ShortCode)
+			}
+		}
+	}
+	return check, nil
+}
+
+// redirectDrifted reports whether a cached redirect entry answers
+// differently from the one built from the link's record
+func redirectDrifted(cached, expected *cache.RedirectEntry) bool {
+	return cached.URLID != expected.URLID ||
+		cached.Destination != expected.Destination ||
+		cached.StatusCode != expected.StatusCode ||
+		cached.ExpiresAt != expected.ExpiresAt ||
+		cached.Flags != expected.Flags ||
+		cached.Version != expected.Version
+}
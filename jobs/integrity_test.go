@@ -0,0 +1,179 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/config"
+	"url-shortener/database"
+	"url-shortener/database/databasetest"
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+)
+
+// integrityCheck runs the integrity verifier and returns the check named
+// name from its report
+func integrityCheck(t *testing.T, name string) models.IntegrityCheck {
+	t.Helper()
+	report := VerifyIntegrity(context.Background(), models.IntegrityTriggerAdmin)
+	for _, check := range report.Checks {
+		if check.Name == name {
+			if check.Error != "" {
+				t.Fatalf("check %s failed: %s", name, check.Error)
+			}
+			return check
+		}
+	}
+	t.Fatalf("report %+v has no check %s", report, name)
+	return models.IntegrityCheck{}
+}
+
+func TestIntegrityDuplicateShortCodes(t *testing.T) {
+	databasetest.UseSQLite(t)
+	links := []models.URL{
+		{OriginalURL: "https://example.com/spring", ShortCode: "spring"},
+		{OriginalURL: "https://example.com/summer", ShortCode: "summer"},
+	}
+	if err := database.DB.Create(&links).Error; err != nil {
+		t.Fatalf("creating links: %v", err)
+	}
+	// A restore brought back an archived copy of a live link
+	archived := models.ArchivedURL{ID: 99, ArchivedAt: time.Now(), OriginalURL: "https://example.com/old", ShortCode: "spring"}
+	if err := database.DB.Create(&archived).Error; err != nil {
+		t.Fatalf("creating archived link: %v", err)
+	}
+
+	check := integrityCheck(t, models.IntegrityDuplicateShortCodes)
+	if check.Checked != 3 || check.Violations != 1 || len(check.Samples) != 1 || check.Samples[0] != "spring" {
+		t.Errorf("duplicate short codes = %+v, want spring among 3", check)
+	}
+}
+
+func TestIntegrityExpiredLinks(t *testing.T) {
+	databasetest.UseSQLite(t)
+	t.Setenv("EXPIRED_LINK_RETENTION", "1h")
+	t.Setenv("EXPIRED_LINK_CLEANUP_INTERVAL", "1h")
+
+	// The cleanup has until the retention and two intervals to catch up
+	overdue, catchingUp := time.Now().Add(-4*time.Hour), time.Now().Add(-2*time.Hour)
+	links := []models.URL{
+		{OriginalURL: "https://example.com/overdue", ShortCode: "overdue", ExpiresAt: &overdue},
+		{OriginalURL: "https://example.com/catching-up", ShortCode: "catching-up", ExpiresAt: &catchingUp},
+		{OriginalURL: "https://example.com/locked", ShortCode: "locked", ExpiresAt: &overdue, Locked: true},
+		{OriginalURL: "https://example.com/deleted", ShortCode: "deleted", ExpiresAt: &overdue, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}},
+		{OriginalURL: "https://example.com/forever", ShortCode: "forever"},
+	}
+	if err := database.DB.Create(&links).Error; err != nil {
+		t.Fatalf("creating links: %v", err)
+	}
+
+	check := integrityCheck(t, models.IntegrityExpiredLinks)
+	if check.Violations != 1 || len(check.Samples) != 1 || check.Samples[0] != "overdue" {
+		t.Errorf("expired links = %+v, want overdue only", check)
+	}
+
+	// Without scheduled cleanups, expired links are expected to linger
+	t.Setenv("EXPIRED_LINK_CLEANUP_INTERVAL", "0")
+	if check := integrityCheck(t, models.IntegrityExpiredLinks); check.Skipped == "" || check.Violations != 0 {
+		t.Errorf("expired links without scheduled cleanups = %+v, want skipped", check)
+	}
+}
+
+func TestIntegrityOrphanedAliases(t *testing.T) {
+	databasetest.UseSQLite(t)
+	link := models.URL{OriginalURL: "https://example.com/renamed", ShortCode: "renamed"}
+	if err := database.DB.Create(&link).Error; err != nil {
+		t.Fatalf("creating link: %v", err)
+	}
+	archived := models.ArchivedURL{ID: 99, ArchivedAt: time.Now(), OriginalURL: "https://example.com/archived", ShortCode: "archived"}
+	if err := database.DB.Create(&archived).Error; err != nil {
+		t.Fatalf("creating archived link: %v", err)
+	}
+	expires := time.Now().Add(time.Hour)
+	aliases := []models.RenamedAlias{
+		{Alias: "old-name", URLID: link.ID, ExpiresAt: expires},
+		{Alias: "old-archived", URLID: archived.ID, ExpiresAt: expires},
+		// Its link was purged without it
+		{Alias: "orphan", URLID: 12345, ExpiresAt: expires},
+	}
+	if err := database.DB.Create(&aliases).Error; err != nil {
+		t.Fatalf("creating aliases: %v", err)
+	}
+
+	check := integrityCheck(t, models.IntegrityOrphanedAliases)
+	if check.Checked != 3 || check.Violations != 1 || len(check.Samples) != 1 || check.Samples[0] != "orphan" {
+		t.Errorf("orphaned aliases = %+v, want orphan among 3", check)
+	}
+}
+
+// TestIntegrityCacheDrift needs Redis (REDIS_ADDR, default localhost:6379)
+// for the drift itself, and checks the check is skipped without it
+func TestIntegrityCacheDrift(t *testing.T) {
+	databasetest.UseSQLite(t)
+	links := []models.URL{
+		{OriginalURL: "https://example.com/fresh", ShortCode: "integrity-fresh"},
+		{OriginalURL: "https://example.com/edited", ShortCode: "integrity-drifted"},
+		{OriginalURL: "https://example.com/uncached", ShortCode: "integrity-uncached"},
+	}
+	if err := database.DB.Create(&links).Error; err != nil {
+		t.Fatalf("creating links: %v", err)
+	}
+
+	if cache.RedisClient == nil {
+		if check := integrityCheck(t, models.IntegrityCacheDrift); check.Skipped == "" {
+			t.Errorf("cache drift without Redis = %+v, want skipped", check)
+		}
+		cfg, err := config.FromEnv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cache.InitRedis(cfg.Redis)
+		if cache.RedisClient == nil {
+			t.Skip("Redis is not available")
+		}
+		t.Cleanup(func() { cache.RedisClient = nil })
+	}
+
+	cache.CacheRedirectEntry(links[0].ShortCode, cache.NewRedirectEntry(&links[0]))
+	// Cached before the destination was edited
+	drifted := cache.NewRedirectEntry(&links[1])
+	drifted.Destination = "https://example.com/before-the-edit"
+	cache.CacheRedirectEntry(links[1].ShortCode, drifted)
+	t.Cleanup(func() {
+		for _, link := range links {
+			cache.InvalidateCache(link.ShortCode)
+		}
+	})
+
+	check := integrityCheck(t, models.IntegrityCacheDrift)
+	if check.Checked != 2 || check.Violations != 1 || len(check.Samples) != 1 || check.Samples[0] != "integrity-drifted" {
+		t.Errorf("cache drift = %+v, want integrity-drifted among 2 cached", check)
+	}
+}
+
+func TestRedirectDrifted(t *testing.T) {
+	expires := time.Now().Add(time.Hour)
+	link := &models.URL{ID: 7, OriginalURL: "https://example.com/", ExpiresAt: &expires, Version: 2}
+	expected := cache.NewRedirectEntry(link)
+
+	for name, edit := range map[string]func(*cache.RedirectEntry){
+		"destination": func(e *cache.RedirectEntry) { e.Destination = "https://example.org/" },
+		"link ID":     func(e *cache.RedirectEntry) { e.URLID = 8 },
+		"status code": func(e *cache.RedirectEntry) { e.StatusCode = 302 },
+		"expiry":      func(e *cache.RedirectEntry) { e.ExpiresAt = 0 },
+		"flags":       func(e *cache.RedirectEntry) { e.Flags |= cache.RedirectInert },
+		"version":     func(e *cache.RedirectEntry) { e.Version = 1 },
+	} {
+		cached := *expected
+		edit(&cached)
+		if !redirectDrifted(&cached, expected) {
+			t.Errorf("a cached entry with another %s did not drift", name)
+		}
+	}
+	if same := *expected; redirectDrifted(&same, expected) {
+		t.Error("an identical cached entry drifted")
+	}
+}
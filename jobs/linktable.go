@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/linktable"
+)
+
+// StartLinkTable loads every link into the in-memory link table when
+// LINK_TABLE_ENABLED is on, then applies the link changes recorded since
+// every LINK_TABLE_REFRESH_INTERVAL, rebuilding it when it fell behind the
+// retained changes. Invalidated links are reloaded right away.
+// Call it before serving requests; the first load blocks so redirects are
+// answered from memory from the start.
+func StartLinkTable() {
+	if !linktable.Enabled() {
+		return
+	}
+	if err := linktable.Rebuild(context.Background()); err != nil {
+		slog.Error("Link table disabled, failed to load links", "error", err)
+		return
+	}
+	cache.OnInvalidate(linktable.Invalidate)
+	slog.Info("Link table loaded", "links", linktable.Status().Links)
+
+	interval := linktable.RefreshInterval()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			_, err := linktable.Refresh(context.Background())
+			if errors.Is(err, linktable.ErrBehind) {
+				slog.Warn("Rebuilding the link table", "error", err)
+				err = linktable.Rebuild(context.Background())
+			}
+			if err != nil {
+				slog.Error("Failed to refresh the link table", "error", err)
+			}
+			beat("link_table", interval)
+		}
+	}()
+}
@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"log/slog"
+	"time"
+
+	"url-shortener/fleet"
+)
+
+// StartMetricsPublisher publishes this instance's request and query metrics
+// to Redis so any replica can report them for the whole fleet. It does
+// nothing unless METRICS_AGGREGATION is on and Redis is available.
+func StartMetricsPublisher() {
+	if !fleet.Enabled() {
+		return
+	}
+
+	interval := fleet.PublishInterval()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := fleet.Publish(time.Now()); err != nil {
+				slog.Error("Failed to publish instance metrics", "error", err)
+			}
+			beat("metrics_publisher", interval)
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"log/slog"
+	"os"
+	"time"
+
+	"url-shortener/database"
+)
+
+// How often click_events partitions are maintained
+const partitionCheckInterval = time.Hour
+
+// StartClickEventPartitionManager keeps upcoming click_events partitions
+// created and, when CLICK_EVENT_RETENTION is set, drops partitions whose
+// month is entirely older than the retention period.
+func StartClickEventPartitionManager() {
+	go func() {
+		ticker := time.NewTicker(partitionCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			maintainClickEventPartitions()
+			beat("click_event_partitions", partitionCheckInterval)
+			<-ticker.C
+		}
+	}()
+}
+
+func maintainClickEventPartitions() {
+	now := time.Now()
+	if err := database.EnsureClickEventPartitions(now); err != nil {
+		slog.Error("Failed to create click_events partitions", "error", err)
+	}
+
+	retention, err := time.ParseDuration(os.Getenv("CLICK_EVENT_RETENTION"))
+	if err != nil || retention <= 0 {
+		return
+	}
+	if _, err := database.DropExpiredClickEventPartitions(now, retention); err != nil {
+		slog.Error("Failed to drop expired click_events partitions", "error", err)
+	}
+}
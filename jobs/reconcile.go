@@ -0,0 +1,153 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+)
+
+// How often click counts are reconciled, and how many links are compared per query
+const (
+	reconcileInterval  = time.Hour
+	reconcileBatchSize = 1000
+)
+
+var (
+	reconcileMu     sync.Mutex
+	reconcileStatus models.ClickReconciliationStatus
+)
+
+// StartClickCountReconciler periodically compares the click count of every
+// link clicked or edited since the previous run across the three tiers that
+// count clicks: urls.click_count, click_events and the Redis counter.
+// Clicks can be lost between tiers but never invented, so the highest count
+// wins: a lagging click_count is raised and a differing Redis counter is
+// invalidated. Discrepancies are logged and reported by
+// ClickReconciliation.
+func StartClickCountReconciler() {
+	go func() {
+		ticker := time.NewTicker(reconcileInterval)
+		defer ticker.Stop()
+
+		for {
+			// Overlap runs so links clicked during the previous run are not missed
+			ReconcileClickCounts(context.Background(), time.Now().Add(-2*reconcileInterval))
+			beat("click_count_reconciler", reconcileInterval)
+			<-ticker.C
+		}
+	}()
+}
+
+// ClickReconciliation returns the last reconciliation report and totals
+func ClickReconciliation() models.ClickReconciliationStatus {
+	reconcileMu.Lock()
+	defer reconcileMu.Unlock()
+	return reconcileStatus
+}
+
+// ReconcileClickCounts reconciles the links updated since since, repairing
+// drift, and records the report returned
+func ReconcileClickCounts(ctx context.Context, since time.Time) models.ClickReconciliationReport {
+	ctx = database.WithRoute(ctx, "click_count_reconciler")
+	report := models.ClickReconciliationReport{StartedAt: time.Now(), Since: since}
+
+	var batch []models.URL
+	err := database.DB.WithContext(ctx).Select("id", "short_code", "click_count").
+		Where("updated_at >= ?", since).
+		FindInBatches(&batch, reconcileBatchSize, func(tx *gorm.DB, _ int) error {
+			return reconcileBatch(ctx, batch, &report)
+		}).Error
+	if err != nil {
+		report.Error = err.Error()
+		slog.ErrorContext(ctx, "Failed to reconcile click counts", "error", err)
+	}
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+
+	if report.DatabaseBehind > 0 || report.CacheMismatched > 0 || report.EventsMissing > 0 {
+		slog.WarnContext(ctx, "Click count reconciliation found drift", "links_checked", report.LinksChecked,
+			"database_behind", report.DatabaseBehind, "cache_mismatched", report.CacheMismatched, "events_missing", report.EventsMissing)
+	}
+
+	reconcileMu.Lock()
+	reconcileStatus.LastRun = &report
+	reconcileStatus.Runs++
+	reconcileStatus.TotalDatabaseBehind += int64(report.DatabaseBehind)
+	reconcileStatus.TotalCacheMismatched += int64(report.CacheMismatched)
+	reconcileStatus.TotalEventsMissing += int64(report.EventsMissing)
+	reconcileMu.Unlock()
+
+	return report
+}
+
+func reconcileBatch(ctx context.Context, links []models.URL, report *models.ClickReconciliationReport) error {
+	ids := make([]uint, len(links))
+	shortCodes := make([]string, len(links))
+	for i, link := range links {
+		ids[i] = link.ID
+		shortCodes[i] = link.ShortCode
+	}
+
+	var eventCounts []struct {
+		URLID  uint
+		Clicks int
+	}
+	// Links whose stats were reset only count the click events since
+	err := database.DB.WithContext(ctx).Raw(`SELECT e.url_id, count(*) AS clicks
+		FROM click_events e JOIN urls u ON u.id = e.url_id
+		WHERE e.url_id IN ? AND (u.stats_reset_at IS NULL OR e.clicked_at >= u.stats_reset_at)
+		GROUP BY e.url_id`, ids,
+	).Scan(&eventCounts).Error
+	if err != nil {
+		return err
+	}
+	events := make(map[uint]int, len(eventCounts))
+	for _, row := range eventCounts {
+		events[row.URLID] = row.Clicks
+	}
+
+	// Without Redis there are no cached counters to compare. Counters include
+	// clicks not flushed to the database yet, so those are subtracted; pending
+	// increments are read second so clicks in between are never overcounted.
+	cached, _ := cache.GetClickCounts(shortCodes)
+	pending, _ := cache.PendingURLClicks(ids)
+
+	for _, link := range links {
+		report.LinksChecked++
+		expected := link.ClickCount
+		if events[link.ID] > expected {
+			expected = events[link.ID]
+		}
+		if counter, ok := cached[link.ShortCode]; ok && int(counter-pending[link.ID]) > expected {
+			expected = int(counter - pending[link.ID])
+		}
+
+		if expected > link.ClickCount {
+			// GREATEST keeps clicks counted since the link was read, and
+			// UpdateColumn leaves updated_at alone so archiving is not delayed
+			err := database.DB.WithContext(ctx).Model(&models.URL{}).Where("id = ?", link.ID).
+				UpdateColumn("click_count", gorm.Expr("GREATEST(click_count, ?)", expected)).Error
+			if err != nil {
+				return err
+			}
+			report.DatabaseBehind++
+		} else if events[link.ID] > 0 && events[link.ID] < link.ClickCount {
+			// Links without any click events predate event recording
+			report.EventsMissing++
+		}
+
+		if counter, ok := cached[link.ShortCode]; ok && int(counter-pending[link.ID]) != expected {
+			report.CacheMismatched++
+			cache.InvalidateStats(link.ShortCode)
+		} else if expected > link.ClickCount {
+			cache.InvalidateStats(link.ShortCode)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/retention"
+)
+
+// How often click history past its plan's retention is purged
+const statsRetentionInterval = 24 * time.Hour
+
+// StartStatsRetentionEnforcer purges the click events and hourly rollups of
+// links older than the retention of their owner's plan, as set by
+// STATS_RETENTION_DAYS. Plans without a retention keep their history until
+// CLICK_EVENT_RETENTION drops it.
+func StartStatsRetentionEnforcer() {
+	policy, err := retention.PolicyFromEnv()
+	if err != nil {
+		slog.Warn("Invalid stats retention policy, not purging click history by plan", "error", err)
+		return
+	}
+	if len(policy.Limited()) == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(statsRetentionInterval)
+		defer ticker.Stop()
+
+		for {
+			enforceStatsRetention(policy)
+			beat("stats_retention_enforcer", statsRetentionInterval)
+			<-ticker.C
+		}
+	}()
+}
+
+func enforceStatsRetention(policy retention.Policy) {
+	ctx := database.WithRoute(context.Background(), "stats_retention_enforcer")
+	now := time.Now()
+	for _, plan := range policy.Limited() {
+		cutoff, _ := policy.Cutoff(plan, now)
+		events, rollups, err := database.PurgeClickHistory(ctx, plan, cutoff)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to purge click history", "plan", plan, "error", err)
+			continue
+		}
+		if events > 0 || rollups > 0 {
+			slog.InfoContext(ctx, "Purged click history", "plan", plan, "days", policy.DaysFor(plan), "events", events, "rollups", rollups)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"url-shortener/database"
+)
+
+// Click rollups are rebuilt this often, up to the current hour
+const clickRollupInterval = 5 * time.Minute
+
+const (
+	// Hours before the latest rollup that are counted again, for clicks
+	// recorded late (see Click Batching)
+	clickRollupRecheck = 24 * time.Hour
+	// Most hours rolled up per run, so a backlog is caught up gradually
+	clickRollupHoursPerRun = 7 * 24
+)
+
+// StartClickRollupBuilder keeps click_rollups, the hourly click counts per
+// link behind the tag stats, up to date with click_events. The first run
+// starts at the oldest click event.
+func StartClickRollupBuilder() {
+	go func() {
+		ticker := time.NewTicker(clickRollupInterval)
+		defer ticker.Stop()
+
+		for {
+			rollUpClicks(time.Now())
+			beat("click_rollup_builder", clickRollupInterval)
+			<-ticker.C
+		}
+	}()
+}
+
+// rollUpClicks counts the clicks of the hours after the latest rollup again,
+// along with the hours just before it
+func rollUpClicks(now time.Time) {
+	ctx := database.WithRoute(context.Background(), "click_rollup_builder")
+	latest, next, ok, err := database.ClickRollupProgress(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to find the next hour of clicks to roll up", "error", err)
+		return
+	}
+	if latest.IsZero() && !ok {
+		return // no clicks yet
+	}
+
+	from, to := next, now
+	if !latest.IsZero() {
+		from = latest.Add(-clickRollupRecheck)
+	}
+	if ok {
+		// Skips hours without clicks, so a gap doesn't stall the backlog
+		if end := next.Add(clickRollupHoursPerRun * time.Hour); end.Before(to) {
+			to = end
+		}
+	}
+	if _, err := database.RollUpClicks(ctx, from, to); err != nil {
+		slog.ErrorContext(ctx, "Failed to roll up clicks", "from", from, "to", to, "error", err)
+	}
+}
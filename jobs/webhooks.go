@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/notify"
+)
+
+// How often links are checked for expiries to tell webhooks about
+const webhookExpiryInterval = time.Minute
+
+// StartWebhookExpiryNotifier fires link.expired to the webhooks subscribed
+// to it for the links of their owner that expired since the last check,
+// whether at their expiry time or with their last allowed click. Each
+// webhook keeps its own checkpoint, so expiries are not missed while the
+// server is down nor notified twice by several instances.
+func StartWebhookExpiryNotifier() {
+	go func() {
+		ticker := time.NewTicker(webhookExpiryInterval)
+		defer ticker.Stop()
+
+		for {
+			notifyLinkExpiries(time.Now())
+			beat("webhook_expiry_notifier", webhookExpiryInterval)
+			<-ticker.C
+		}
+	}()
+}
+
+func notifyLinkExpiries(now time.Time) {
+	ctx := database.WithRoute(context.Background(), "webhook_expiry_notifier")
+	ids, err := database.WebhookIDsFor(ctx, models.HookLinkExpired)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load link.expired webhooks", "error", err)
+		return
+	}
+
+	for _, id := range ids {
+		webhook, urls, ok, err := database.ClaimExpiredLinks(ctx, id, now)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to find expired links for webhook", "webhook_id", id, "error", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		for i := range urls {
+			notify.FireWebhook(ctx, &webhook, models.HookLinkExpired, notify.LinkPayload(models.HookLinkExpired, &urls[i]))
+		}
+	}
+}
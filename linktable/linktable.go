@@ -0,0 +1,266 @@
+// Package linktable keeps the redirect entry of every link in memory, for
+// deployments with a modest number of very hot links such as corporate
+// go-links (LINK_TABLE_ENABLED). Redirects then resolve without a cache or
+// database round trip. The database remains the source of truth: the table
+// is loaded from it at startup, follows the links changed since through
+// their updated_at and the cache invalidation feed, and is rebuilt
+// periodically to drop links deleted while an invalidation was missed.
+// Links missing from the table still resolve through the cache and
+// database.
+package linktable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+)
+
+// Defaults of LINK_TABLE_REFRESH_INTERVAL and LINK_TABLE_MAX_LINKS
+const (
+	defaultRefreshInterval = 5 * time.Second
+	defaultMaxLinks        = 100000
+)
+
+// Links and changes are loaded this many at a time
+const loadBatchSize = 1000
+
+// ErrBehind is returned by Refresh when changes the table has not applied
+// were pruned from the change feed; the table must be rebuilt
+var ErrBehind = errors.New("the link table is behind the retained link changes")
+
+var (
+	mu          sync.RWMutex
+	entries     map[string]*cache.RedirectEntry // nil until loaded
+	cursor      uint64                          // last link change applied
+	lastRefresh time.Time
+	lastRebuild time.Time
+
+	hits, misses atomic.Int64
+)
+
+// Enabled reports whether LINK_TABLE_ENABLED is on
+func Enabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("LINK_TABLE_ENABLED"))
+	return enabled
+}
+
+// RefreshInterval returns LINK_TABLE_REFRESH_INTERVAL, at least one second
+func RefreshInterval() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("LINK_TABLE_REFRESH_INTERVAL")); err == nil && value >= time.Second {
+		return value
+	}
+	return defaultRefreshInterval
+}
+
+// maxLinks returns LINK_TABLE_MAX_LINKS
+func maxLinks() int64 {
+	if value, err := strconv.ParseInt(os.Getenv("LINK_TABLE_MAX_LINKS"), 10, 64); err == nil && value > 0 {
+		return value
+	}
+	return defaultMaxLinks
+}
+
+// Lookup returns the redirect entry of a link key, if the table has it
+func Lookup(key string) (*cache.RedirectEntry, bool) {
+	mu.RLock()
+	entry, ok := entries[key]
+	loaded := entries != nil
+	mu.RUnlock()
+
+	if !loaded {
+		return nil, false
+	}
+	if !ok {
+		misses.Add(1)
+		return nil, false
+	}
+	hits.Add(1)
+	copied := *entry
+	return &copied, true
+}
+
+// Rebuild loads every link into a new table and swaps it in. It fails
+// without loading anything when there are more than LINK_TABLE_MAX_LINKS.
+func Rebuild(ctx context.Context) error {
+	ctx = database.WithRoute(ctx, "link_table")
+	// Changes after the head are applied by the next refresh, including
+	// those the load below already sees
+	head, err := database.LinkChangeHead(ctx)
+	if err != nil {
+		return err
+	}
+	var count int64
+	if err := database.DB.WithContext(ctx).Model(&models.URL{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if limit := maxLinks(); count > limit {
+		return fmt.Errorf("%d links exceed LINK_TABLE_MAX_LINKS (%d)", count, limit)
+	}
+
+	started := time.Now()
+	loaded := make(map[string]*cache.RedirectEntry, count)
+	var batch []models.URL
+	err = database.DB.WithContext(ctx).FindInBatches(&batch, loadBatchSize, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			loaded[batch[i].ShortCode] = cache.NewRedirectEntry(&batch[i])
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	entries = loaded
+	cursor = head
+	lastRefresh, lastRebuild = started, started
+	mu.Unlock()
+	return nil
+}
+
+// Refresh applies the link changes recorded since the last one applied,
+// and returns how many it read. It returns ErrBehind when some of them were
+// pruned already.
+func Refresh(ctx context.Context) (int, error) {
+	mu.RLock()
+	loaded, since := entries != nil, cursor
+	mu.RUnlock()
+	if !loaded {
+		return 0, errors.New("the link table is not loaded")
+	}
+
+	ctx = database.WithRoute(ctx, "link_table")
+	started := time.Now()
+	expired, err := database.LinkChangeCursorExpired(ctx, since)
+	if err != nil {
+		return 0, err
+	}
+	if expired {
+		return 0, ErrBehind
+	}
+
+	read := 0
+	for {
+		changes, err := database.LinkChanges(ctx, since, loadBatchSize)
+		if err != nil {
+			return read, err
+		}
+		if len(changes) == 0 {
+			break
+		}
+		if err = apply(ctx, changes); err != nil {
+			return read, err
+		}
+		read += len(changes)
+		since = changes[len(changes)-1].ID
+		if len(changes) < loadBatchSize {
+			break
+		}
+	}
+
+	mu.Lock()
+	lastRefresh = started
+	mu.Unlock()
+	return read, nil
+}
+
+// apply updates the table with link changes, in order, using the current
+// state of the links changed
+func apply(ctx context.Context, changes []models.LinkChange) error {
+	var ids []uint
+	for _, change := range changes {
+		if change.Event != models.ChangeDeleted {
+			ids = append(ids, change.URLID)
+		}
+	}
+	links := make(map[uint]*models.URL, len(ids))
+	if len(ids) > 0 {
+		var urls []models.URL
+		if err := database.DB.WithContext(ctx).Where("id IN ?", ids).Find(&urls).Error; err != nil {
+			return err
+		}
+		for i := range urls {
+			links[urls[i].ID] = &urls[i]
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, change := range changes {
+		if change.PreviousShortCode != "" {
+			delete(entries, change.PreviousShortCode)
+		}
+		// Links deleted since are dropped by their own change
+		link, ok := links[change.URLID]
+		if change.Event == models.ChangeDeleted || !ok {
+			delete(entries, change.ShortCode)
+			continue
+		}
+		entries[link.ShortCode] = cache.NewRedirectEntry(link)
+	}
+	cursor = changes[len(changes)-1].ID
+	return nil
+}
+
+// Invalidate drops a link from the table and reloads it in the background,
+// as links can change without updating updated_at, e.g. when their last
+// click is used up
+func Invalidate(key string) {
+	mu.Lock()
+	if entries == nil {
+		mu.Unlock()
+		return
+	}
+	delete(entries, key)
+	mu.Unlock()
+
+	go reload(key)
+}
+
+func reload(key string) {
+	ctx := database.WithRoute(context.Background(), "link_table")
+	url, err := database.Links.GetByShortCode(ctx, key)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.ErrorContext(ctx, "Failed to reload link into the link table", "short_code", key, "error", err)
+		}
+		return
+	}
+
+	mu.Lock()
+	if entries != nil {
+		entries[key] = cache.NewRedirectEntry(url)
+	}
+	mu.Unlock()
+}
+
+// Status reports the size, freshness and hit rate of the table
+func Status() models.LinkTableStatus {
+	mu.RLock()
+	status := models.LinkTableStatus{
+		Enabled:     Enabled(),
+		Loaded:      entries != nil,
+		Links:       len(entries),
+		LastRefresh: lastRefresh,
+		LastRebuild: lastRebuild,
+	}
+	mu.RUnlock()
+
+	status.Hits, status.Misses = hits.Load(), misses.Load()
+	if lookups := status.Hits + status.Misses; lookups > 0 {
+		status.HitRate = float64(status.Hits) / float64(lookups)
+	}
+	return status
+}
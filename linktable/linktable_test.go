@@ -0,0 +1,44 @@
+package linktable
+
+import (
+	"testing"
+
+	"url-shortener/cache"
+	"url-shortener/models"
+)
+
+func TestLookup(t *testing.T) {
+	mu.Lock()
+	entries = nil
+	mu.Unlock()
+	if _, ok := Lookup("go"); ok {
+		t.Fatal("lookup succeeded before the table was loaded")
+	}
+	if status := Status(); status.Loaded || status.Misses != 0 {
+		t.Errorf("status before loading = %+v", status)
+	}
+
+	mu.Lock()
+	entries = map[string]*cache.RedirectEntry{
+		"go":                cache.NewRedirectEntry(&models.URL{OriginalURL: "https://intranet.example.com/"}),
+		"go.example.com/hr": cache.NewRedirectEntry(&models.URL{OriginalURL: "https://hr.example.com/"}),
+	}
+	mu.Unlock()
+
+	entry, ok := Lookup("go")
+	if !ok || entry.Destination != "https://intranet.example.com/" {
+		t.Fatalf("Lookup(go) = %+v, %v", entry, ok)
+	}
+	entry.Destination = "https://changed.example.com/"
+	if again, _ := Lookup("go"); again.Destination != "https://intranet.example.com/" {
+		t.Error("changing a returned entry changed the table")
+	}
+	if _, ok := Lookup("hr"); ok {
+		t.Error("branded domain links are keyed by host and code")
+	}
+
+	status := Status()
+	if !status.Loaded || status.Links != 2 || status.Hits != 2 || status.Misses != 1 {
+		t.Errorf("status = %+v", status)
+	}
+}
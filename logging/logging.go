@@ -0,0 +1,129 @@
+// Package logging sets up structured logging with log/slog. Lines are
+// written as JSON by default, or as key=value text with LOG_FORMAT=text,
+// from LOG_LEVEL up. Messages still written with the standard log package
+// go through the same handler at the info level.
+//
+// Destinations can carry secrets, such as signed URLs or access tokens, so
+// the query strings, fragments and credentials of URLs in log lines are
+// redacted unless LOG_DESTINATIONS=full. This covers messages, attributes
+// and errors alike, including failed queries and errors of HTTP clients.
+//
+// Records logged with a context carry the request ID stored in it by
+// WithRequestID, so the lines of a request, including those of the queries
+// and background work it started, can be found together.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Log formats accepted by LOG_FORMAT
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Settings of LOG_DESTINATIONS
+const (
+	DestinationsRedacted = "redacted" // query strings, fragments and credentials removed
+	DestinationsFull     = "full"     // URLs logged as they are
+)
+
+// Init makes the logger configured by LOG_LEVEL, LOG_FORMAT and
+// LOG_DESTINATIONS the default one, for slog and the standard log package
+func Init() {
+	slog.SetDefault(New(os.Stderr, os.Getenv("LOG_FORMAT"), os.Getenv("LOG_LEVEL"), os.Getenv("LOG_DESTINATIONS")))
+}
+
+// New returns a logger writing to w in format, from level up, with URLs
+// logged as destinations asks. Unknown formats, levels and destination
+// settings fall back to JSON, info and redacted URLs, with a warning.
+func New(w io.Writer, format, level, destinations string) *slog.Logger {
+	var lvl slog.Level
+	invalidLevel := level != "" && lvl.UnmarshalText([]byte(level)) != nil
+	if invalidLevel {
+		lvl = slog.LevelInfo
+	}
+	options := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	invalidFormat := false
+	switch strings.ToLower(format) {
+	case "", FormatJSON:
+		handler = slog.NewJSONHandler(w, options)
+	case FormatText:
+		handler = slog.NewTextHandler(w, options)
+	default:
+		handler = slog.NewJSONHandler(w, options)
+		invalidFormat = true
+	}
+
+	redact := true
+	invalidDestinations := false
+	switch strings.ToLower(destinations) {
+	case "", DestinationsRedacted:
+	case DestinationsFull:
+		redact = false
+	default:
+		invalidDestinations = true
+	}
+
+	logger := slog.New(contextHandler{Handler: handler, redact: redact})
+	if invalidFormat {
+		logger.Warn("Unknown LOG_FORMAT, logging JSON", "format", format)
+	}
+	if invalidLevel {
+		logger.Warn("Unknown LOG_LEVEL, logging from info up", "level", level)
+	}
+	if invalidDestinations {
+		logger.Warn("Unknown LOG_DESTINATIONS, redacting URLs", "destinations", destinations)
+	}
+	return logger
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose log records carry the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID stored in the context, if any
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request ID of the context to each record and
+// redacts the URLs in it unless told otherwise
+type contextHandler struct {
+	slog.Handler
+	redact bool
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if h.redact {
+		record = redactRecord(record)
+	}
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			record.AddAttrs(slog.String("request_id", id))
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if h.redact {
+		attrs = redactAttrs(attrs)
+	}
+	return contextHandler{h.Handler.WithAttrs(attrs), h.redact}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name), h.redact}
+}
@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// urlPattern matches the URLs of any scheme in a log line, up to the
+// whitespace or quote ending them
+var urlPattern = regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.-]*://[^\s"'<>` + "`" + `]+`)
+
+// redacted replaces the parts of URLs that may hold secrets
+const redacted = "REDACTED"
+
+// RedactURLs returns s with the query strings, fragments and credentials of
+// the URLs in it replaced, keeping the scheme, host and path that tell
+// destinations apart
+func RedactURLs(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+	return urlPattern.ReplaceAllStringFunc(s, redactURL)
+}
+
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		// Keep what comes before anything that may be a secret
+		if i := strings.IndexAny(rawURL, "?#@"); i >= 0 {
+			return rawURL[:i] + "?" + redacted
+		}
+		return rawURL
+	}
+	if parsed.User != nil {
+		parsed.User = url.User(redacted)
+	}
+	if parsed.RawQuery != "" || parsed.ForceQuery {
+		parsed.RawQuery = redacted
+		parsed.ForceQuery = false
+	}
+	if parsed.Fragment != "" {
+		parsed.Fragment = redacted
+		parsed.RawFragment = ""
+	}
+	return parsed.String()
+}
+
+// redactRecord returns a copy of record with the URLs of its message and
+// attributes redacted
+func redactRecord(record slog.Record) slog.Record {
+	redactedRecord := slog.NewRecord(record.Time, record.Level, RedactURLs(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redactedRecord.AddAttrs(redactAttr(attr))
+		return true
+	})
+	return redactedRecord
+}
+
+func redactAttrs(attrs []slog.Attr) []slog.Attr {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redactedAttrs[i] = redactAttr(attr)
+	}
+	return redactedAttrs
+}
+
+// redactAttr redacts the URLs of string, error and Stringer values, and
+// of the attributes of groups
+func redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, RedactURLs(value.String()))
+	case slog.KindGroup:
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(redactAttrs(value.Group())...)}
+	case slog.KindAny:
+		switch v := value.Any().(type) {
+		case error:
+			if message := v.Error(); strings.Contains(message, "://") {
+				return slog.String(attr.Key, RedactURLs(message))
+			}
+		case fmt.Stringer:
+			if text := v.String(); strings.Contains(text, "://") {
+				return slog.String(attr.Key, RedactURLs(text))
+			}
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth protects admin routes with the token configured in ADMIN_TOKEN.
+// The token is accepted as `Authorization: Bearer <token>` or `X-Admin-Token`.
+// API keys with the admin scope (authenticated by APIKeyAuth) are also
+// accepted, unless REQUIRE_ADMIN_2FA is set and they belong to an admin
+// account without 2FA. When no token is configured only admin API keys are
+// allowed.
+func AdminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKey := CurrentAPIKey(c); apiKey != nil {
+			if !apiKey.HasScope(models.ScopeAdmin) {
+				c.Error(models.NewAPIError(http.StatusForbidden, models.ErrCodeScopeMissing, "API key lacks the admin scope"))
+				c.Abort()
+				return
+			}
+			if keyOfAdminWithoutTwoFactor(c, apiKey) {
+				c.Error(errAdminTwoFactorRequired())
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		adminToken := os.Getenv("ADMIN_TOKEN")
+		if adminToken == "" {
+			c.Error(models.NewAPIError(http.StatusForbidden, models.ErrCodeForbidden, "Admin API is disabled"))
+			c.Abort()
+			return
+		}
+
+		if !validAdminToken(c, adminToken) {
+			c.Error(models.NewAPIError(http.StatusUnauthorized, models.ErrCodeUnauthorized, "Invalid admin token"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// IsAdmin reports whether the request carries admin credentials, without
+// rejecting it. APIKeyAuth must run first for admin API keys to be seen.
+func IsAdmin(c *gin.Context) bool {
+	if apiKey := CurrentAPIKey(c); apiKey != nil {
+		return apiKey.HasScope(models.ScopeAdmin)
+	}
+
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	return adminToken != "" && validAdminToken(c, adminToken)
+}
+
+func validAdminToken(c *gin.Context, adminToken string) bool {
+	token := c.GetHeader("X-Admin-Token")
+	if token == "" {
+		token = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1
+}
@@ -0,0 +1,236 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"url-shortener/background"
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// APIKeyPrefix distinguishes API keys from other bearer tokens such as the admin token
+const APIKeyPrefix = "usk_"
+
+// Signed requests older or newer than this are rejected
+const signatureMaxSkew = 5 * time.Minute
+
+// Minimum interval between last_used_at updates for a key
+const lastUsedResolution = time.Minute
+
+// Signatures remembered without Redis before signed requests are refused
+const maxLocalSignatures = 10000
+
+// Signatures seen when Redis is unavailable, per instance, with when they
+// can be forgotten, up to maxLocalSignatures at once
+var (
+	localSignaturesMu sync.Mutex
+	localSignatures   = make(map[string]time.Time)
+)
+
+// APIKeyAuth authenticates requests carrying either `Authorization: Bearer <key>`
+// or an HMAC signature (X-Key-ID, X-Timestamp, X-Signature headers). Requests
+// without credentials continue anonymously; invalid credentials are rejected.
+// Impersonation tokens stand in for a key of the impersonated user.
+func APIKeyAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var (
+			apiKey *models.APIKey
+			errMsg string
+		)
+
+		switch {
+		case strings.HasPrefix(c.GetHeader("Authorization"), "Bearer "+ImpersonationTokenPrefix):
+			impersonate(c)
+			return
+		case c.GetHeader("X-Signature") != "":
+			apiKey, errMsg = authenticateSignature(c)
+		case strings.HasPrefix(c.GetHeader("Authorization"), "Bearer "+APIKeyPrefix):
+			apiKey, errMsg = authenticateBearer(c)
+		default:
+			c.Next()
+			return
+		}
+
+		if apiKey == nil {
+			c.Error(models.NewAPIError(http.StatusUnauthorized, models.ErrCodeUnauthorized, errMsg))
+			c.Abort()
+			return
+		}
+
+		touchAPIKey(c.Request.Context(), apiKey)
+		APIKeyContextKey.Set(c, apiKey)
+		c.Next()
+	}
+}
+
+// RequireScope rejects requests authenticated with an API key lacking scope.
+// Anonymous requests are left to the route's own policy.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKey := CurrentAPIKey(c); apiKey != nil && !apiKey.HasScope(scope) {
+			c.Error(models.NewAPIError(http.StatusForbidden, models.ErrCodeScopeMissing, "API key lacks the "+scope+" scope"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentAPIKey returns the API key that authenticated the request, if any
+func CurrentAPIKey(c *gin.Context) *models.APIKey {
+	return APIKeyContextKey.Value(c)
+}
+
+// activeAPIKeys scopes a query to keys that are neither revoked nor expired
+func activeAPIKeys() *gorm.DB {
+	return database.DB.Where("revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", time.Now())
+}
+
+// touchAPIKey records when a key was last used, at most once per minute,
+// in the background of the request of ctx
+func touchAPIKey(ctx context.Context, apiKey *models.APIKey) {
+	now := time.Now()
+	if apiKey.LastUsedAt != nil && now.Sub(*apiKey.LastUsedAt) < lastUsedResolution {
+		return
+	}
+
+	id := apiKey.ID
+	ctx = context.WithoutCancel(ctx)
+	background.Go(func() {
+		if err := database.DB.WithContext(ctx).Model(&models.APIKey{}).Where("id = ?", id).
+			Updates(map[string]interface{}{"last_used_at": now, "stale_alerted": false}).Error; err != nil {
+			slog.ErrorContext(ctx, "Failed to update last_used_at for API key", "api_key_id", id, "error", err)
+		}
+	})
+}
+
+func authenticateBearer(c *gin.Context) (*models.APIKey, string) {
+	apiKey := findAPIKey(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+	if apiKey == nil {
+		return nil, "Invalid API key"
+	}
+	return apiKey, ""
+}
+
+// AuthenticateAPIKey returns the active API key key, recording its use, or
+// nil when it is unknown, revoked or expired. It authenticates callers of
+// other transports the way APIKeyAuth does bearer tokens.
+func AuthenticateAPIKey(ctx context.Context, key string) *models.APIKey {
+	apiKey := findAPIKey(key)
+	if apiKey != nil {
+		touchAPIKey(ctx, apiKey)
+	}
+	return apiKey
+}
+
+// findAPIKey looks up an active key by its value
+func findAPIKey(key string) *models.APIKey {
+	var apiKey models.APIKey
+	if err := activeAPIKeys().Where("key_hash = ?", utils.HashToken(key)).First(&apiKey).Error; err != nil {
+		return nil
+	}
+	return &apiKey
+}
+
+// authenticateSignature verifies X-Signature = hex(HMAC-SHA256(secret,
+// timestamp + "\n" + method + "\n" + path + "\n" + body)) for the key in X-Key-ID
+func authenticateSignature(c *gin.Context) (*models.APIKey, string) {
+	keyID := c.GetHeader("X-Key-ID")
+	timestamp := c.GetHeader("X-Timestamp")
+	signature := c.GetHeader("X-Signature")
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if keyID == "" || err != nil {
+		return nil, "X-Key-ID and X-Timestamp headers are required for signed requests"
+	}
+
+	skew := time.Since(time.Unix(unix, 0))
+	if skew > signatureMaxSkew || skew < -signatureMaxSkew {
+		return nil, "Request timestamp is outside the allowed window"
+	}
+
+	var apiKey models.APIKey
+	if err := activeAPIKeys().Where("id = ?", keyID).First(&apiKey).Error; err != nil {
+		return nil, "Invalid API key"
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, "Failed to read request body"
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	mac := hmac.New(sha256.New, []byte(apiKey.SigningSecret))
+	mac.Write([]byte(timestamp + "\n" + c.Request.Method + "\n" + c.Request.URL.RequestURI() + "\n"))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return nil, "Invalid request signature"
+	}
+
+	// Each signature may only be used once within the timestamp window,
+	// whatever the case of its hex digits
+	fresh, err := markSignatureUsed(expected, 2*signatureMaxSkew)
+	if err != nil {
+		return nil, "Too many signed requests to check for replays, retry later"
+	}
+	if !fresh {
+		return nil, "Request signature has already been used"
+	}
+
+	return &apiKey, ""
+}
+
+// errTooManySignatures is returned by markSignatureUsed when this instance
+// remembers as many unexpired signatures as it may without Redis
+var errTooManySignatures = errors.New("too many signatures to remember")
+
+// markSignatureUsed records a request signature for ttl, returning false if
+// it was already seen (replay). Without Redis, signatures are remembered by
+// this instance, so a replay sent to another one is only stopped by the
+// timestamp window. Once maxLocalSignatures unexpired ones are remembered,
+// new signatures are refused with errTooManySignatures rather than
+// forgetting any that could be replayed.
+func markSignatureUsed(signature string, ttl time.Duration) (bool, error) {
+	if fresh, err := cache.MarkSignatureUsed(signature, ttl); err == nil {
+		return fresh, nil
+	}
+
+	localSignaturesMu.Lock()
+	defer localSignaturesMu.Unlock()
+
+	now := time.Now()
+	if forgetAt, ok := localSignatures[signature]; ok && now.Before(forgetAt) {
+		return false, nil
+	}
+	if len(localSignatures) >= maxLocalSignatures {
+		for seen, forgetAt := range localSignatures {
+			if !now.Before(forgetAt) {
+				delete(localSignatures, seen)
+			}
+		}
+		if len(localSignatures) >= maxLocalSignatures {
+			return false, errTooManySignatures
+		}
+	}
+	localSignatures[signature] = now.Add(ttl)
+	return true, nil
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/background"
+	"url-shortener/cache"
+	"url-shortener/database"
+	"url-shortener/database/databasetest"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// signedRequest signs a POST of body to uri with the key's signing secret
+func signedRequest(apiKey *models.APIKey, uri, body string) *http.Request {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(apiKey.SigningSecret))
+	mac.Write([]byte(timestamp + "\n" + http.MethodPost + "\n" + uri + "\n" + body))
+
+	request := httptest.NewRequest(http.MethodPost, uri, strings.NewReader(body))
+	request.Header.Set("X-Key-ID", strconv.FormatUint(uint64(apiKey.ID), 10))
+	request.Header.Set("X-Timestamp", timestamp)
+	request.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+	return request
+}
+
+func TestSignatureReplayWithoutRedis(t *testing.T) {
+	databasetest.UseSQLite(t)
+	// Key uses are recorded in the background, before the database closes
+	t.Cleanup(func() { background.Wait(5 * time.Second) })
+	gin.SetMode(gin.TestMode)
+
+	apiKey := &models.APIKey{Name: "signer", KeyHash: "hash", SigningSecret: "secret"}
+	if err := database.DB.Create(apiKey).Error; err != nil {
+		t.Fatalf("creating API key: %v", err)
+	}
+	router := gin.New()
+	router.Use(Errors(), APIKeyAuth())
+	router.POST("/signed", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	previous := cache.RedisClient
+	t.Cleanup(func() { cache.RedisClient = previous })
+	for name, client := range map[string]*redis.Client{
+		"not connected": nil,
+		// Every command fails, as when Redis went down after startup
+		"down": redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond}),
+	} {
+		cache.RedisClient = client
+
+		request := signedRequest(apiKey, "/signed", `{"case":"`+name+`"}`)
+		// The hex digits of a replay may be in another case
+		replay := request.Clone(request.Context())
+		replay.Body = httptest.NewRequest(http.MethodPost, "/signed", strings.NewReader(`{"case":"`+name+`"}`)).Body
+		replay.Header.Set("X-Signature", strings.ToUpper(request.Header.Get("X-Signature")))
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusNoContent {
+			t.Errorf("Redis %s: signed request = %d: %s", name, recorder.Code, recorder.Body)
+		}
+		recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, replay)
+		if recorder.Code != http.StatusUnauthorized || !strings.Contains(recorder.Body.String(), "already been used") {
+			t.Errorf("Redis %s: replayed request = %d: %s, want 401", name, recorder.Code, recorder.Body)
+		}
+		if client != nil {
+			client.Close()
+		}
+	}
+}
+
+func TestMarkSignatureUsedForgetsExpiredSignatures(t *testing.T) {
+	previous := cache.RedisClient
+	cache.RedisClient = nil
+	t.Cleanup(func() { cache.RedisClient = previous })
+
+	if fresh, err := markSignatureUsed("short-lived", time.Millisecond); !fresh || err != nil {
+		t.Fatalf("first use of a signature = %t, %v", fresh, err)
+	}
+	if fresh, _ := markSignatureUsed("short-lived", time.Millisecond); fresh {
+		t.Error("replay within the TTL allowed")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if fresh, err := markSignatureUsed("short-lived", time.Millisecond); !fresh || err != nil {
+		t.Errorf("signature after its TTL = %t, %v", fresh, err)
+	}
+}
+
+func TestMarkSignatureUsedRefusesSignaturesWhenFull(t *testing.T) {
+	previous := cache.RedisClient
+	cache.RedisClient = nil
+	t.Cleanup(func() {
+		cache.RedisClient = previous
+		localSignaturesMu.Lock()
+		clear(localSignatures)
+		localSignaturesMu.Unlock()
+	})
+
+	localSignaturesMu.Lock()
+	clear(localSignatures)
+	forgetAt := time.Now().Add(time.Hour)
+	for i := range maxLocalSignatures {
+		localSignatures[strconv.Itoa(i)] = forgetAt
+	}
+	localSignaturesMu.Unlock()
+
+	if fresh, err := markSignatureUsed("one-too-many", time.Minute); fresh || err != errTooManySignatures {
+		t.Fatalf("signature past the cap = %t, %v, want errTooManySignatures", fresh, err)
+	}
+	if fresh, err := markSignatureUsed("0", time.Minute); fresh || err != nil {
+		t.Errorf("replay of a remembered signature = %t, %v, want refused as a replay", fresh, err)
+	}
+
+	// Expired signatures make room again
+	localSignaturesMu.Lock()
+	localSignatures["0"] = time.Now()
+	localSignaturesMu.Unlock()
+	if fresh, err := markSignatureUsed("one-too-many", time.Minute); !fresh || err != nil {
+		t.Errorf("signature once one expired = %t, %v", fresh, err)
+	}
+}
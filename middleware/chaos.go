@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"url-shortener/chaos"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Chaos injects the configured latency and failures into requests when
+// CHAOS_ENABLED is set. Admin routes are exempt so faults can always be
+// switched off again.
+func Chaos() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, "/admin/") {
+			c.Next()
+			return
+		}
+		if err := chaos.Inject(c.Request.Context(), models.ChaosTargetHTTP); err != nil {
+			c.Error(models.NewAPIError(http.StatusServiceUnavailable, models.ErrCodeUnavailable, "Injected fault"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
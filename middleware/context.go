@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"url-shortener/models"
+	"url-shortener/policy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKey names a value of type T kept on a gin context. Setting and
+// getting through it keeps the type checked by the compiler instead of at
+// each c.Get type assertion.
+type ContextKey[T any] string
+
+// Gin context keys of the values middleware attaches to a request
+const (
+	APIKeyContextKey        ContextKey[*models.APIKey]        = "api_key"
+	UserContextKey          ContextKey[*models.User]          = "user"
+	SessionContextKey       ContextKey[*models.Session]       = "session"
+	ImpersonationContextKey ContextKey[*models.Impersonation] = "impersonation"
+	PolicyContextKey        ContextKey[*policy.Snapshot]      = "policy"
+	RequestIDContextKey     ContextKey[string]                = "request_id"
+)
+
+// Set attaches value to the request
+func (k ContextKey[T]) Set(c *gin.Context, value T) {
+	c.Set(string(k), value)
+}
+
+// Get returns the value attached to the request, reporting whether there
+// is one
+func (k ContextKey[T]) Get(c *gin.Context) (T, bool) {
+	value, _ := c.Get(string(k))
+	typed, ok := value.(T)
+	return typed, ok
+}
+
+// Value returns the value attached to the request, or the zero value of T
+func (k ContextKey[T]) Value(c *gin.Context) T {
+	value, _ := k.Get(c)
+	return value
+}
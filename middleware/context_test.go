@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestContextKey(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	if apiKey, ok := APIKeyContextKey.Get(c); ok || apiKey != nil {
+		t.Errorf("Get on an empty context = %v, %v", apiKey, ok)
+	}
+
+	apiKey := &models.APIKey{Name: "ci"}
+	APIKeyContextKey.Set(c, apiKey)
+	if got := CurrentAPIKey(c); got != apiKey {
+		t.Errorf("CurrentAPIKey = %v, want %v", got, apiKey)
+	}
+
+	// A value of another type under the same name is not returned
+	c.Set(string(UserContextKey), "not a user")
+	if user, ok := UserContextKey.Get(c); ok || user != nil {
+		t.Errorf("Get of a mistyped value = %v, %v", user, ok)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, CurrentRequestID(c))
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		echoed bool
+	}{
+		{name: "none", header: "", echoed: false},
+		{name: "client ID", header: "req-42", echoed: true},
+		{name: "too long", header: strings.Repeat("a", maxRequestIDLength+1), echoed: false},
+		{name: "unprintable", header: "req 42", echoed: false},
+	}
+	for _, tt := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			request.Header.Set(RequestIDHeader, tt.header)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		id := recorder.Header().Get(RequestIDHeader)
+		if id == "" || id != recorder.Body.String() {
+			t.Errorf("%s: header %q, handler saw %q", tt.name, id, recorder.Body.String())
+		}
+		if (id == tt.header) != tt.echoed {
+			t.Errorf("%s: request ID = %q, echoed want %v", tt.name, id, tt.echoed)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSPolicy is who may call a surface from a browser, and how
+type CORSPolicy struct {
+	Origins       []string // allowed origins, or "*" for any
+	Methods       []string
+	Headers       []string // request headers allowed besides the simple ones
+	ExposeHeaders []string // response headers readable by scripts
+	MaxAge        time.Duration
+	Credentials   bool // allow cookies and HTTP authentication
+}
+
+// CORS answers preflight requests and adds CORS headers to the responses
+// of surface, following policy unless CORS_<SURFACE>_ORIGINS, _METHODS,
+// _HEADERS, _MAX_AGE or _CREDENTIALS override it. Requests from origins not
+// allowed get no CORS headers, and their preflights 403, so browsers block
+// them. It must run before anything that may reject the request, as
+// preflights carry no credentials.
+func CORS(surface string, policy CORSPolicy) gin.HandlerFunc {
+	policy = corsConfig(surface, policy)
+	anyOrigin := slices.Contains(policy.Origins, "*")
+	methods := strings.Join(policy.Methods, ", ")
+	headers := strings.Join(policy.Headers, ", ")
+	exposeHeaders := strings.Join(policy.ExposeHeaders, ", ")
+	maxAge := strconv.Itoa(int(policy.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		if !anyOrigin && !slices.Contains(policy.Origins, origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if anyOrigin && !policy.Credentials {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			// Credentials are only sent to an origin named in the response
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Add("Vary", "Origin")
+		}
+		if policy.Credentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			c.Header("Access-Control-Allow-Methods", methods)
+			if headers != "" {
+				c.Header("Access-Control-Allow-Headers", headers)
+			}
+			if policy.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		if exposeHeaders != "" {
+			c.Header("Access-Control-Expose-Headers", exposeHeaders)
+		}
+		c.Next()
+	}
+}
+
+// corsConfig applies the CORS_<SURFACE>_* overrides to policy
+func corsConfig(surface string, policy CORSPolicy) CORSPolicy {
+	prefix := "CORS_" + strings.ToUpper(surface) + "_"
+	if value := os.Getenv(prefix + "ORIGINS"); value != "" {
+		policy.Origins = splitList(value)
+	}
+	if value := os.Getenv(prefix + "METHODS"); value != "" {
+		policy.Methods = splitList(strings.ToUpper(value))
+	}
+	if value, ok := os.LookupEnv(prefix + "HEADERS"); ok {
+		policy.Headers = splitList(value)
+	}
+	if value, err := time.ParseDuration(os.Getenv(prefix + "MAX_AGE")); err == nil && value >= 0 {
+		policy.MaxAge = value
+	}
+	if value, err := strconv.ParseBool(os.Getenv(prefix + "CREDENTIALS")); err == nil {
+		policy.Credentials = value
+	}
+
+	if policy.Credentials && slices.Contains(policy.Origins, "*") {
+		slog.Warn(prefix + "CREDENTIALS needs " + prefix + "ORIGINS to list origins, not allowing credentials")
+		policy.Credentials = false
+	}
+	return policy
+}
+
+// splitList splits a comma-separated setting, dropping blank entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
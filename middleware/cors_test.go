@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policy := CORSPolicy{Origins: []string{"https://dashboard.example.com"}, Methods: []string{http.MethodGet}, Credentials: true}
+
+	router := gin.New()
+	router.GET("/", CORS("test", policy), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Origin", "https://dashboard.example.com")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != "https://dashboard.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := recorder.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Access-Control-Allow-Credentials = %q", got)
+	}
+	if got := recorder.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("Vary = %q", got)
+	}
+}
+
+// Browsers refuse credentials for any origin, so the combination is not allowed
+func TestCORSConfigDropsCredentialsForAnyOrigin(t *testing.T) {
+	t.Setenv("CORS_TEST_ORIGINS", "*")
+	t.Setenv("CORS_TEST_CREDENTIALS", "true")
+	t.Setenv("CORS_TEST_HEADERS", "")
+
+	policy := corsConfig("test", CORSPolicy{Headers: []string{"Authorization"}})
+	if policy.Credentials {
+		t.Error("credentials allowed for any origin")
+	}
+	if len(policy.Headers) != 0 {
+		t.Errorf("headers = %v, want none", policy.Headers)
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"url-shortener/models"
+	"url-shortener/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Errors writes the response for errors handlers attach with c.Error,
+// unless the handler already responded. *models.APIError values are written
+// as is; other errors are mapped by APIErrorFor.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		renderErrors(c)
+	}
+}
+
+// APIErrorFor maps an internal error to the API error returned to clients.
+// Errors of the cache and database are mapped by their storage kind.
+func APIErrorFor(err error) *models.APIError {
+	var apiErr *models.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		return models.NewAPIError(http.StatusNotFound, models.ErrCodeNotFound, "Not found")
+	case errors.Is(err, storage.ErrExpired):
+		return models.ErrLinkExpired
+	case errors.Is(err, storage.ErrConflict):
+		return models.NewAPIError(http.StatusConflict, models.ErrCodeConflict, "Conflicts with an existing resource")
+	case errors.Is(err, context.DeadlineExceeded):
+		return models.ErrTimeout
+	case errors.Is(err, storage.ErrBackendUnavailable):
+		return models.ErrUnavailable
+	default:
+		return models.ErrInternal
+	}
+}
+
+// renderErrors writes the last attached error, if no response was written yet
+func renderErrors(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+
+	err := c.Errors.Last().Err
+	apiErr := APIErrorFor(err)
+	if apiErr == models.ErrInternal && err != models.ErrInternal {
+		slog.ErrorContext(c.Request.Context(), "Request failed", "method", c.Request.Method, "route", c.FullPath(), "error", err)
+	}
+	c.JSON(apiErr.Status, apiErr.Response())
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"url-shortener/models"
+	"url-shortener/storage"
+
+	"gorm.io/gorm"
+)
+
+func TestAPIErrorForStorageErrors(t *testing.T) {
+	cause := errors.New("backend said no")
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{storage.Wrap(storage.ErrNotFound, cause), http.StatusNotFound},
+		{gorm.ErrRecordNotFound, http.StatusNotFound},
+		{storage.Wrap(storage.ErrExpired, cause), http.StatusGone},
+		{fmt.Errorf("saving link: %w", storage.Wrap(storage.ErrConflict, cause)), http.StatusConflict},
+		{storage.Wrap(storage.ErrBackendUnavailable, cause), http.StatusServiceUnavailable},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{models.ErrLinkNotFound, http.StatusNotFound},
+		{cause, http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		if got := APIErrorFor(tc.err); got.Status != tc.want {
+			t.Errorf("APIErrorFor(%v) status = %d, want %d", tc.err, got.Status, tc.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"url-shortener/background"
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonationTokenPrefix distinguishes impersonation tokens from other
+// bearer tokens
+const ImpersonationTokenPrefix = "usi_"
+
+// impersonate authenticates a request carrying an impersonation token as the
+// impersonated user, through an API key of theirs limited to the
+// impersonation's scopes. The response carries the X-Impersonation header
+// and, when it is a JSON object, the impersonation banner, and the request
+// is audit logged.
+func impersonate(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	var impersonation models.Impersonation
+	err := database.DB.Where("token_hash = ? AND ended_at IS NULL AND expires_at > ?", utils.HashToken(token), time.Now()).
+		First(&impersonation).Error
+	if err != nil || len(impersonation.Scopes) == 0 {
+		c.Error(models.NewAPIError(http.StatusUnauthorized, models.ErrCodeUnauthorized, "Invalid or expired impersonation token"))
+		c.Abort()
+		return
+	}
+
+	userID := impersonation.UserID
+	APIKeyContextKey.Set(c, &models.APIKey{Name: "impersonation", Scopes: impersonation.Scopes, UserID: &userID})
+	ImpersonationContextKey.Set(c, &impersonation)
+	banner := ImpersonationBannerFor(&impersonation)
+	c.Header("X-Impersonation", fmt.Sprintf("id=%d; user=%d; expires=%s", impersonation.ID, impersonation.UserID, impersonation.ExpiresAt.UTC().Format(time.RFC3339)))
+
+	writer := &bannerWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	c.Next()
+	// Errors are rendered here rather than by Timeout so they carry the banner too
+	renderErrors(c)
+	c.Writer = writer.ResponseWriter
+	writer.finish(banner)
+
+	entry := models.AuditLog{
+		Action:    models.AuditActionImpersonateRequest,
+		ShortCode: c.Param("shortCode"),
+		Actor:     impersonation.Actor,
+		IPAddress: c.ClientIP(),
+		Details:   fmt.Sprintf("impersonation %d of user %d: %s %s answered %d", impersonation.ID, impersonation.UserID, c.Request.Method, c.Request.URL.Path, c.Writer.Status()),
+	}
+	ctx := context.WithoutCancel(c.Request.Context())
+	background.Go(func() {
+		if err := database.DB.WithContext(ctx).Create(&entry).Error; err != nil {
+			slog.ErrorContext(ctx, "Failed to record audit log for impersonation", "action", entry.Action, "impersonation_id", impersonation.ID, "error", err)
+		}
+	})
+}
+
+// CurrentImpersonation returns the impersonation the request is made
+// through, if any
+func CurrentImpersonation(c *gin.Context) *models.Impersonation {
+	return ImpersonationContextKey.Value(c)
+}
+
+// ImpersonationBannerFor describes an impersonation to the responses of
+// requests made through it
+func ImpersonationBannerFor(impersonation *models.Impersonation) *models.ImpersonationBanner {
+	expiresAt := impersonation.ExpiresAt.UTC()
+	return &models.ImpersonationBanner{
+		ID:        impersonation.ID,
+		UserID:    impersonation.UserID,
+		Actor:     impersonation.Actor,
+		ExpiresAt: expiresAt,
+		Message:   fmt.Sprintf("An admin is acting as user %d until %s", impersonation.UserID, expiresAt.Format(time.RFC3339)),
+	}
+}
+
+// withBanner adds banner to a JSON object body as its first field,
+// impersonation, leaving other bodies unchanged
+func withBanner(body []byte, banner *models.ImpersonationBanner) []byte {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return body
+	}
+	encoded, err := json.Marshal(banner)
+	if err != nil {
+		return body
+	}
+
+	rest := trimmed[1:]
+	var out bytes.Buffer
+	out.WriteString(`{"impersonation":`)
+	out.Write(encoded)
+	if next := bytes.TrimLeft(rest, " \t\r\n"); len(next) > 0 && next[0] != '}' {
+		out.WriteByte(',')
+	}
+	out.Write(rest)
+	return out.Bytes()
+}
+
+// bannerWriter holds the response of an impersonated request until the
+// banner is added
+type bannerWriter struct {
+	gin.ResponseWriter
+	body    bytes.Buffer
+	written bool
+}
+
+func (w *bannerWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bannerWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bannerWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bannerWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bannerWriter) Written() bool {
+	return w.written
+}
+
+// finish writes the held response, with banner when it is JSON
+func (w *bannerWriter) finish(banner *models.ImpersonationBanner) {
+	body := w.body.Bytes()
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		body = withBanner(body, banner)
+		w.Header().Del("Content-Length")
+	}
+	if !w.written {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+	w.ResponseWriter.Write(body)
+}
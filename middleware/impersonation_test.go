@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWithBanner(t *testing.T) {
+	banner := &models.ImpersonationBanner{ID: 3, UserID: 7, Actor: "admin", ExpiresAt: time.Date(2024, 6, 3, 9, 30, 0, 0, time.UTC), Message: "m"}
+	prefix := `{"impersonation":{"id":3,"user_id":7,"actor":"admin","expires_at":"2024-06-03T09:30:00Z","message":"m"}`
+	for body, want := range map[string]string{
+		`{"short_code":"abc"}`: prefix + `,"short_code":"abc"}`,
+		`{}`:                   prefix + `}`,
+		"\n{ }":                prefix + ` }`,
+		`[{"id":1}]`:           `[{"id":1}]`,
+		`null`:                 `null`,
+		``:                     ``,
+	} {
+		if got := string(withBanner([]byte(body), banner)); got != want {
+			t.Errorf("withBanner(%q) = %s, want %s", body, got, want)
+		}
+	}
+}
+
+func TestBannerWriterAddsBannerToJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	banner := &models.ImpersonationBanner{ID: 3, UserID: 7}
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		writer := &bannerWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+		writer.finish(banner)
+	})
+	router.GET("/object", func(c *gin.Context) { c.JSON(http.StatusCreated, gin.H{"ok": true}) })
+	router.GET("/text", func(c *gin.Context) { c.String(http.StatusOK, "{not json}") })
+	router.DELETE("/empty", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/object", nil))
+	var body struct {
+		Impersonation *models.ImpersonationBanner `json:"impersonation"`
+		OK            bool                        `json:"ok"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %s: %v", recorder.Body, err)
+	}
+	if recorder.Code != http.StatusCreated || !body.OK || body.Impersonation == nil || body.Impersonation.UserID != 7 {
+		t.Errorf("got %d %s", recorder.Code, recorder.Body)
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/text", nil))
+	if got := recorder.Body.String(); got != "{not json}" {
+		t.Errorf("text body = %q, want it unchanged", got)
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/empty", nil))
+	if recorder.Code != http.StatusNoContent || recorder.Body.Len() != 0 {
+		t.Errorf("empty response = %d %q", recorder.Code, recorder.Body)
+	}
+}
@@ -0,0 +1,289 @@
+package middleware
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rolling windows reported on the status page, shortest first; the longest
+// bounds how long per-minute buckets are kept
+var StatusWindows = []time.Duration{time.Hour, 24 * time.Hour}
+
+// Upper bounds of the latency histogram buckets in milliseconds. Slower
+// requests fall into a final overflow bucket.
+var latencyBucketsMs = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// minuteBucket counts the requests to one endpoint within one minute
+type minuteBucket struct {
+	requests int64
+	errors   int64
+	latency  []int64
+}
+
+type endpointKey struct {
+	method string
+	route  string
+}
+
+var (
+	requestMetricsMu sync.Mutex
+	requestMetrics   = make(map[endpointKey]map[int64]*minuteBucket)
+	metricsSince     = time.Now().UTC()
+)
+
+// RequestMetrics records the status and latency of every request to a known
+// route in per-minute buckets, kept in memory for the longest status window.
+// Unmatched paths are not recorded, so scanners can't grow the table.
+func RequestMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			return
+		}
+		recordRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start), start)
+	}
+}
+
+func recordRequest(method, route string, status int, elapsed time.Duration, at time.Time) {
+	minute := at.Unix() / 60
+	ms := float64(elapsed) / float64(time.Millisecond)
+	bucket := sort.SearchFloat64s(latencyBucketsMs, ms)
+
+	requestMetricsMu.Lock()
+	defer requestMetricsMu.Unlock()
+
+	key := endpointKey{method: method, route: route}
+	minutes := requestMetrics[key]
+	if minutes == nil {
+		minutes = make(map[int64]*minuteBucket)
+		requestMetrics[key] = minutes
+	}
+	entry := minutes[minute]
+	if entry == nil {
+		entry = &minuteBucket{latency: make([]int64, len(latencyBucketsMs)+1)}
+		minutes[minute] = entry
+		pruneMinutes(minutes, minute)
+	}
+	entry.requests++
+	if status >= 500 {
+		entry.errors++
+	}
+	entry.latency[bucket]++
+}
+
+// pruneMinutes drops buckets older than the longest window; it runs once per
+// new bucket so the cost stays proportional to traffic
+func pruneMinutes(minutes map[int64]*minuteBucket, current int64) {
+	oldest := current - int64(longestStatusWindow()/time.Minute)
+	for minute := range minutes {
+		if minute <= oldest {
+			delete(minutes, minute)
+		}
+	}
+}
+
+func longestStatusWindow() time.Duration {
+	longest := time.Duration(0)
+	for _, window := range StatusWindows {
+		if window > longest {
+			longest = window
+		}
+	}
+	return longest
+}
+
+// EndpointSnapshot holds the counts behind an endpoint's status windows.
+// Unlike the percentages and percentiles derived from them, counts from
+// several instances can be added up.
+type EndpointSnapshot struct {
+	Method  string           `json:"method"`
+	Route   string           `json:"route"`
+	Windows []WindowSnapshot `json:"windows"`
+}
+
+// WindowSnapshot counts the requests to an endpoint within one status window
+type WindowSnapshot struct {
+	Window   string  `json:"window"`
+	Requests int64   `json:"requests"`
+	Errors   int64   `json:"errors"`
+	Latency  []int64 `json:"latency"` // requests per latency bucket
+}
+
+// EndpointMetrics returns the rolling window statistics for every endpoint
+// that served requests within the longest window, sorted by route and method
+func EndpointMetrics(now time.Time) []models.EndpointStatus {
+	return SummarizeEndpoints(EndpointSnapshots(now))
+}
+
+// EndpointSnapshots returns this instance's counts for every endpoint that
+// served requests within the longest window, sorted by route and method
+func EndpointSnapshots(now time.Time) []EndpointSnapshot {
+	current := now.Unix() / 60
+
+	requestMetricsMu.Lock()
+	defer requestMetricsMu.Unlock()
+
+	snapshots := make([]EndpointSnapshot, 0, len(requestMetrics))
+	for key, minutes := range requestMetrics {
+		pruneMinutes(minutes, current)
+		if len(minutes) == 0 {
+			delete(requestMetrics, key)
+			continue
+		}
+
+		snapshot := EndpointSnapshot{Method: key.method, Route: key.route}
+		for _, window := range StatusWindows {
+			snapshot.Windows = append(snapshot.Windows, countWindow(minutes, current, window))
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sortSnapshots(snapshots)
+	return snapshots
+}
+
+// MergeEndpointSnapshots adds up the snapshots of several instances. Windows
+// are matched by name; latency histograms of another bucket layout, from
+// instances running a different version, are left out.
+func MergeEndpointSnapshots(sets ...[]EndpointSnapshot) []EndpointSnapshot {
+	merged := make(map[endpointKey]*EndpointSnapshot)
+	for _, set := range sets {
+		for _, snapshot := range set {
+			key := endpointKey{method: snapshot.Method, route: snapshot.Route}
+			target := merged[key]
+			if target == nil {
+				target = &EndpointSnapshot{Method: snapshot.Method, Route: snapshot.Route}
+				for _, window := range StatusWindows {
+					target.Windows = append(target.Windows, WindowSnapshot{
+						Window:  formatWindow(window),
+						Latency: make([]int64, len(latencyBucketsMs)+1),
+					})
+				}
+				merged[key] = target
+			}
+			for _, window := range snapshot.Windows {
+				for i := range target.Windows {
+					if target.Windows[i].Window != window.Window {
+						continue
+					}
+					target.Windows[i].Requests += window.Requests
+					target.Windows[i].Errors += window.Errors
+					if len(window.Latency) == len(target.Windows[i].Latency) {
+						for j, count := range window.Latency {
+							target.Windows[i].Latency[j] += count
+						}
+					}
+				}
+			}
+		}
+	}
+
+	snapshots := make([]EndpointSnapshot, 0, len(merged))
+	for _, snapshot := range merged {
+		snapshots = append(snapshots, *snapshot)
+	}
+	sortSnapshots(snapshots)
+	return snapshots
+}
+
+// SummarizeEndpoints derives availability and latency percentiles from counts
+func SummarizeEndpoints(snapshots []EndpointSnapshot) []models.EndpointStatus {
+	endpoints := make([]models.EndpointStatus, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		endpoint := models.EndpointStatus{Method: snapshot.Method, Route: snapshot.Route}
+		for _, window := range snapshot.Windows {
+			endpoint.Windows = append(endpoint.Windows, summarizeWindow(window))
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+func sortSnapshots(snapshots []EndpointSnapshot) {
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Route != snapshots[j].Route {
+			return snapshots[i].Route < snapshots[j].Route
+		}
+		return snapshots[i].Method < snapshots[j].Method
+	})
+}
+
+// MetricsSince returns when this instance started recording request metrics
+func MetricsSince() time.Time {
+	return metricsSince
+}
+
+func countWindow(minutes map[int64]*minuteBucket, current int64, window time.Duration) WindowSnapshot {
+	oldest := current - int64(window/time.Minute)
+	snapshot := WindowSnapshot{Window: formatWindow(window), Latency: make([]int64, len(latencyBucketsMs)+1)}
+	for minute, entry := range minutes {
+		if minute <= oldest || minute > current {
+			continue
+		}
+		snapshot.Requests += entry.requests
+		snapshot.Errors += entry.errors
+		for i, count := range entry.latency {
+			snapshot.Latency[i] += count
+		}
+	}
+	return snapshot
+}
+
+func summarizeWindow(window WindowSnapshot) models.StatusWindow {
+	summary := models.StatusWindow{
+		Window:       window.Window,
+		Requests:     window.Requests,
+		Errors:       window.Errors,
+		Availability: 100,
+	}
+	if window.Requests == 0 {
+		return summary
+	}
+
+	summary.Availability = float64(window.Requests-window.Errors) / float64(window.Requests) * 100
+	summary.LatencyP50Ms = latencyPercentile(window.Latency, 0.50)
+	summary.LatencyP95Ms = latencyPercentile(window.Latency, 0.95)
+	summary.LatencyP99Ms = latencyPercentile(window.Latency, 0.99)
+	return summary
+}
+
+// latencyPercentile returns the upper bound of the bucket holding the
+// requested percentile; the overflow bucket reports the last finite bound
+func latencyPercentile(latency []int64, percentile float64) float64 {
+	total := int64(0)
+	for _, count := range latency {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := int64(float64(total)*percentile + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	seen := int64(0)
+	for i, count := range latency {
+		seen += count
+		if seen >= rank && i < len(latencyBucketsMs) {
+			return latencyBucketsMs[i]
+		} else if seen >= rank {
+			break
+		}
+	}
+	return latencyBucketsMs[len(latencyBucketsMs)-1]
+}
+
+func formatWindow(window time.Duration) string {
+	if window%time.Hour == 0 {
+		return strconv.FormatInt(int64(window/time.Hour), 10) + "h"
+	}
+	return window.String()
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEndpointMetricsWindows(t *testing.T) {
+	requestMetricsMu.Lock()
+	requestMetrics = make(map[endpointKey]map[int64]*minuteBucket)
+	requestMetricsMu.Unlock()
+
+	now := time.Now()
+	for i := 0; i < 98; i++ {
+		recordRequest("GET", "/:shortCode", 302, 3*time.Millisecond, now)
+	}
+	recordRequest("GET", "/:shortCode", 500, 40*time.Millisecond, now)
+	recordRequest("GET", "/:shortCode", 302, time.Minute, now)
+	recordRequest("GET", "/:shortCode", 503, time.Millisecond, now.Add(-3*time.Hour))
+	recordRequest("POST", "/shorten", 201, time.Millisecond, now.Add(-25*time.Hour))
+
+	endpoints := EndpointMetrics(now)
+	if len(endpoints) != 1 {
+		t.Fatalf("got %d endpoints, want 1 after dropping expired buckets", len(endpoints))
+	}
+	hour, day := endpoints[0].Windows[0], endpoints[0].Windows[1]
+	if hour.Window != "1h" || day.Window != "24h" {
+		t.Errorf("windows = %q, %q, want 1h, 24h", hour.Window, day.Window)
+	}
+	if hour.Requests != 100 || hour.Errors != 1 || hour.Availability != 99 {
+		t.Errorf("last hour = %+v, want 100 requests, 1 error, 99%% available", hour)
+	}
+	if day.Requests != 101 || day.Errors != 2 {
+		t.Errorf("last day = %+v, want 101 requests and 2 errors", day)
+	}
+	if hour.LatencyP50Ms != 5 || hour.LatencyP99Ms != 50 {
+		t.Errorf("latency p50 = %v, p99 = %v, want 5 and 50", hour.LatencyP50Ms, hour.LatencyP99Ms)
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"time"
+
+	"url-shortener/mirror"
+	"url-shortener/models"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Mirror samples redirects for traffic mirroring. Place it before Timeout,
+// which renders error responses, so the final status is seen.
+func Mirror() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !mirror.Sample() {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+
+		redirect := models.MirroredRedirect{
+			ShortCode:     c.Param("shortCode"),
+			Status:        c.Writer.Status(),
+			LatencyMicros: time.Since(start).Microseconds(),
+			UserAgent:     c.GetHeader("User-Agent"),
+			ObservedAt:    start,
+		}
+		if location := c.Writer.Header().Get("Location"); location != "" {
+			redirect.DestinationHash = utils.HashURL(location)
+		}
+		if len(c.Errors) > 0 {
+			apiErr := APIErrorFor(c.Errors.Last().Err)
+			redirect.ErrorCode = apiErr.Code
+			if !c.Writer.Written() {
+				redirect.Status = apiErr.Status
+			}
+		}
+		mirror.Observe(redirect)
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnonymousShorten rejects requests without an API key when anonymous
+// shortening is disabled. It must run after APIKeyAuth.
+func AnonymousShorten() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if CurrentAPIKey(c) == nil && !CurrentPolicy(c).AnonymousShorten {
+			c.Error(models.NewAPIError(http.StatusUnauthorized, models.ErrCodeUnauthorized, "An API key is required to shorten URLs"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireKeyOwner admits requests authenticated with an API key assigned to
+// a user, whose links the route manages, refusing the keys of admin accounts
+// without 2FA while REQUIRE_ADMIN_2FA is set. It must run after APIKeyAuth.
+func RequireKeyOwner() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := CurrentAPIKey(c)
+		if apiKey == nil {
+			c.Error(models.NewAPIError(http.StatusUnauthorized, models.ErrCodeUnauthorized, "An API key is required"))
+			c.Abort()
+			return
+		}
+		if apiKey.UserID == nil {
+			c.Error(models.NewAPIError(http.StatusForbidden, models.ErrCodeForbidden, "API key is not assigned to a user"))
+			c.Abort()
+			return
+		}
+		if keyOfAdminWithoutTwoFactor(c, apiKey) {
+			c.Error(errAdminTwoFactorRequired())
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentOwnerID returns the user owning links created by the request, if any
+func CurrentOwnerID(c *gin.Context) *uint {
+	if apiKey := CurrentAPIKey(c); apiKey != nil {
+		return apiKey.UserID
+	}
+	return nil
+}
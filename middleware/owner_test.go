@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireKeyOwner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userID := uint(7)
+	tests := []struct {
+		name   string
+		apiKey *models.APIKey
+		want   int
+	}{
+		{name: "anonymous", apiKey: nil, want: http.StatusUnauthorized},
+		{name: "key without user", apiKey: &models.APIKey{}, want: http.StatusForbidden},
+		{name: "key with user", apiKey: &models.APIKey{UserID: &userID}, want: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		router := gin.New()
+		router.Use(Errors())
+		router.GET("/links", func(c *gin.Context) {
+			if tt.apiKey != nil {
+				APIKeyContextKey.Set(c, tt.apiKey)
+			}
+		}, RequireKeyOwner(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/links", nil))
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, recorder.Code, tt.want)
+		}
+	}
+}
+
+func TestAnonymousShorten(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Errors())
+	router.POST("/shorten", AnonymousShorten(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for value, want := range map[string]int{"": http.StatusNoContent, "true": http.StatusNoContent, "false": http.StatusUnauthorized} {
+		t.Setenv("ALLOW_ANONYMOUS_SHORTEN", value)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/shorten", nil))
+		if recorder.Code != want {
+			t.Errorf("ALLOW_ANONYMOUS_SHORTEN=%q: status = %d, want %d", value, recorder.Code, want)
+		}
+	}
+}
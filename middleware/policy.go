@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"url-shortener/policy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Policy attaches a snapshot of the policies in force to the request, so
+// everything handling it sees the same view (see policy.Snapshot)
+func Policy() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		PolicyContextKey.Set(c, policy.Load(c.ClientIP()))
+		c.Next()
+	}
+}
+
+// CurrentPolicy returns the request's policy snapshot, taking it now on
+// routes mounted without the Policy middleware
+func CurrentPolicy(c *gin.Context) *policy.Snapshot {
+	if snapshot, ok := PolicyContextKey.Get(c); ok {
+		return snapshot
+	}
+	snapshot := policy.Load(c.ClientIP())
+	PolicyContextKey.Set(c, snapshot)
+	return snapshot
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Switches changed while a request is handled don't apply to it
+func TestPolicySnapshotIsStableWithinRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("REQUIRE_APPROVAL", "false")
+
+	router := gin.New()
+	router.Use(Policy())
+	router.GET("/", func(c *gin.Context) {
+		first := CurrentPolicy(c)
+		t.Setenv("REQUIRE_APPROVAL", "true")
+		if second := CurrentPolicy(c); second != first || second.RequireApproval {
+			t.Error("policy changed within the request")
+		}
+		c.Status(http.StatusNoContent)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// The next request sees the change
+	router = gin.New()
+	router.GET("/", func(c *gin.Context) {
+		if !CurrentPolicy(c).RequireApproval {
+			t.Error("new request did not see REQUIRE_APPROVAL")
+		}
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
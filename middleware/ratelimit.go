@@ -0,0 +1,239 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"url-shortener/abuse"
+	"url-shortener/cache"
+	"url-shortener/config"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rate limit scopes, each with its own limit. Clients spending their
+// allowance on one scope keep the others.
+const (
+	RateLimitDefault  = ""         // RATE_LIMIT_REQUESTS per RATE_LIMIT_WINDOW
+	RateLimitShorten  = "shorten"  // RATE_LIMIT_SHORTEN_REQUESTS per RATE_LIMIT_SHORTEN_WINDOW
+	RateLimitRedirect = "redirect" // RATE_LIMIT_REDIRECT_REQUESTS per RATE_LIMIT_REDIRECT_WINDOW
+)
+
+// Limits of each scope, set by ConfigureRateLimits
+var rateLimits = config.Default().RateLimit
+
+// ConfigureRateLimits sets the limits of each scope. Call it before
+// building the routes.
+func ConfigureRateLimits(limits config.RateLimits) {
+	rateLimits = limits
+}
+
+type rateLimitPolicy struct {
+	limit  int64
+	window time.Duration
+}
+
+// Counters used when Redis is unavailable, per instance
+var (
+	localLimitsMu sync.Mutex
+	localLimits   = make(map[string]localWindow)
+)
+
+type localWindow struct {
+	start    time.Time
+	count    int64
+	previous int64 // requests counted in the window before start
+}
+
+// RateLimit limits requests with the default scope, see RateLimitScope
+func RateLimit() gin.HandlerFunc {
+	return RateLimitScope(RateLimitDefault)
+}
+
+// RateLimitScope allows each client the requests per window configured for
+// scope, 0 disabling the limit. Windows slide: requests of the previous
+// window still count in proportion to how much of it overlaps the last
+// window, so a client cannot spend twice the limit around a window boundary.
+// Clients are told where they stand with the RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers on every response, and get
+// 429 with Retry-After once their requests are used up.
+//
+// Clients are identified by impersonation, then API key, then dashboard user, then admin token,
+// then IP address, so it must run after the authentication middleware.
+// Outside the redirect scope, the limit is divided for creators with a raised
+// abuse level (see abuse.RateLimitDivisor).
+// Requests identified otherwise also count against their IP address when
+// RATE_LIMIT_IP_REQUESTS is set. Counts are shared through Redis and kept
+// per instance without it.
+func RateLimitScope(scope string) gin.HandlerFunc {
+	policy := rateLimitConfig(scope)
+	ipLimit := rateLimits.PerIP
+
+	return func(c *gin.Context) {
+		if policy.limit == 0 {
+			c.Next()
+			return
+		}
+
+		now := time.Now()
+		client := rateLimitClient(c)
+		limit := policy.limit
+		if scope != RateLimitRedirect && client != "admin" {
+			limit = abuseRateLimit(c, limit)
+		}
+		status := checkRateLimit(scope, client, limit, policy.window, now)
+		if ip := "ip:" + c.ClientIP(); ipLimit > 0 && client != ip {
+			// Whichever limit is exceeded, or closer to running out, is reported
+			ipStatus := checkRateLimit(scope, ip, ipLimit, policy.window, now)
+			if ipStatus.exceeded && !status.exceeded || ipStatus.exceeded == status.exceeded && ipStatus.remaining < status.remaining {
+				status = ipStatus
+			}
+		}
+
+		resetSeconds := strconv.Itoa(ceilSeconds(status.reset))
+		c.Header("RateLimit-Limit", strconv.FormatInt(status.limit, 10))
+		c.Header("RateLimit-Remaining", strconv.FormatInt(status.remaining, 10))
+		c.Header("RateLimit-Reset", resetSeconds)
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", status.limit, int(policy.window.Seconds())))
+
+		if status.exceeded {
+			retryAfter := strconv.Itoa(ceilSeconds(status.retryAfter))
+			c.Header("Retry-After", retryAfter)
+			c.Error(models.NewAPIError(http.StatusTooManyRequests, models.ErrCodeRateLimited, "Rate limit exceeded, retry after "+retryAfter+" seconds"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitStatus is where a client stands against one limit
+type rateLimitStatus struct {
+	limit      int64
+	remaining  int64
+	reset      time.Duration // until the current window ends
+	exceeded   bool
+	retryAfter time.Duration // until a request would be allowed again
+}
+
+// checkRateLimit counts a request of client and weighs it against limit
+// with a sliding window
+func checkRateLimit(scope, client string, limit int64, window time.Duration, now time.Time) rateLimitStatus {
+	start := now.Truncate(window)
+	elapsed := now.Sub(start)
+	current, previous := countRequest(scope+":"+client, start, window)
+
+	// The share of the previous window still inside the sliding window
+	overlap := float64(window-elapsed) / float64(window)
+	used := current + int64(math.Floor(float64(previous)*overlap))
+
+	status := rateLimitStatus{limit: limit, remaining: limit - used, reset: window - elapsed}
+	if status.remaining < 0 {
+		status.remaining = 0
+	}
+	if used <= limit {
+		return status
+	}
+
+	status.exceeded = true
+	if current >= limit {
+		// This window becomes the previous one, which has to slide out
+		// until the next request fits
+		status.retryAfter = status.reset + time.Duration(float64(window)*(1-float64(limit-1)/float64(current)))
+	} else {
+		// Until enough of the previous window has slid out
+		status.retryAfter = time.Duration(float64(window)*(1-float64(limit-current)/float64(previous))) - elapsed
+	}
+	return status
+}
+
+func ceilSeconds(d time.Duration) int {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// rateLimitConfig returns the limit of scope
+func rateLimitConfig(scope string) rateLimitPolicy {
+	limit := rateLimits.Default
+	switch scope {
+	case RateLimitShorten:
+		limit = rateLimits.Shorten
+	case RateLimitRedirect:
+		limit = rateLimits.Redirect
+	}
+	return rateLimitPolicy{limit: limit.Requests, window: limit.Window}
+}
+
+// rateLimitClient identifies who a request is counted against
+func rateLimitClient(c *gin.Context) string {
+	if impersonation := CurrentImpersonation(c); impersonation != nil {
+		return fmt.Sprintf("impersonation:%d", impersonation.ID)
+	}
+	// Sessions standing in for an API key are counted against their user
+	if user := CurrentUser(c); user != nil {
+		return fmt.Sprintf("user:%d", user.ID)
+	}
+	if apiKey := CurrentAPIKey(c); apiKey != nil {
+		return fmt.Sprintf("key:%d", apiKey.ID)
+	}
+	if adminToken := os.Getenv("ADMIN_TOKEN"); adminToken != "" && validAdminToken(c, adminToken) {
+		return "admin"
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// abuseRateLimit divides limit as the abuse level of the creator making
+// the request says, leaving at least one request per window
+func abuseRateLimit(c *gin.Context, limit int64) int64 {
+	creator := abuse.Creator(nil, c.ClientIP())
+	if apiKey := CurrentAPIKey(c); apiKey != nil {
+		creator = abuse.Creator(apiKey.UserID, c.ClientIP())
+	} else if user := CurrentUser(c); user != nil {
+		creator = abuse.Creator(&user.ID, c.ClientIP())
+	}
+	if limit /= abuse.RateLimitDivisor(abuse.Level(c.Request.Context(), creator)); limit < 1 {
+		return 1
+	}
+	return limit
+}
+
+// countRequest counts a request for client in the window starting at start,
+// returning the requests counted in it and in the window before
+func countRequest(client string, start time.Time, window time.Duration) (int64, int64) {
+	if current, previous, err := cache.IncrementRateLimit(client, start, window); err == nil {
+		return current, previous
+	}
+
+	localLimitsMu.Lock()
+	defer localLimitsMu.Unlock()
+
+	// Drop windows too old to count so many clients don't grow the map forever
+	if len(localLimits) >= 10000 {
+		for key, counted := range localLimits {
+			if counted.start.Before(start.Add(-window)) {
+				delete(localLimits, key)
+			}
+		}
+	}
+
+	counted := localLimits[client]
+	if !counted.start.Equal(start) {
+		previous := int64(0)
+		if counted.start.Equal(start.Add(-window)) {
+			previous = counted.count
+		}
+		counted = localWindow{start: start, previous: previous}
+	}
+	counted.count++
+	localLimits[client] = counted
+	return counted.count, counted.previous
+}
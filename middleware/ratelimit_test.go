@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"url-shortener/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setRateLimits(t, func(limits *config.RateLimits) {
+		limits.Default = config.RateLimit{Requests: 2, Window: time.Hour}
+	})
+
+	router := gin.New()
+	router.Use(Errors())
+	router.GET("/limited", RateLimit(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	request := func() *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.RemoteAddr = "198.51.100.20:4000"
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		recorder := request()
+		if recorder.Code != http.StatusNoContent {
+			t.Fatalf("request %d: status = %d, want 204", i+1, recorder.Code)
+		}
+		if got := recorder.Header().Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("RateLimit-Limit = %q, want 2", got)
+		}
+		if got := recorder.Header().Get("RateLimit-Remaining"); got != wantRemaining {
+			t.Errorf("request %d: RateLimit-Remaining = %q, want %s", i+1, got, wantRemaining)
+		}
+		if reset, err := strconv.Atoi(recorder.Header().Get("RateLimit-Reset")); err != nil || reset < 1 || reset > 3600 {
+			t.Errorf("RateLimit-Reset = %q, want seconds within the window", recorder.Header().Get("RateLimit-Reset"))
+		}
+	}
+
+	recorder := request()
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", recorder.Code)
+	}
+	if recorder.Header().Get("Retry-After") == "" {
+		t.Error("429 response has no Retry-After header")
+	}
+	if got := recorder.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want 0", got)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setRateLimits(t, func(limits *config.RateLimits) { limits.Default.Requests = 0 })
+
+	router := gin.New()
+	router.GET("/open", RateLimit(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/open", nil))
+	if recorder.Header().Get("RateLimit-Limit") != "" {
+		t.Error("disabled rate limit still sends headers")
+	}
+}
+
+func TestRateLimitSlidingWindow(t *testing.T) {
+	window := time.Minute
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		checkRateLimit(RateLimitShorten, "ip:203.0.113.7", 10, window, start.Add(-45*time.Second))
+	}
+
+	// Half of the previous window's 10 requests still count
+	now := start.Add(30 * time.Second)
+	for i := 1; i <= 5; i++ {
+		status := checkRateLimit(RateLimitShorten, "ip:203.0.113.7", 10, window, now)
+		if status.exceeded || status.remaining != int64(5-i) {
+			t.Fatalf("request %d: exceeded = %t, remaining = %d, want %d left", i, status.exceeded, status.remaining, 5-i)
+		}
+	}
+
+	status := checkRateLimit(RateLimitShorten, "ip:203.0.113.7", 10, window, now)
+	if !status.exceeded {
+		t.Fatal("request beyond the sliding limit was allowed")
+	}
+	if status.retryAfter != 6*time.Second {
+		t.Errorf("retry after %v, want 6s for 4 of the previous requests to slide out", status.retryAfter)
+	}
+
+	// Scopes are counted separately
+	if status := checkRateLimit(RateLimitRedirect, "ip:203.0.113.7", 10, window, now); status.exceeded || status.remaining != 9 {
+		t.Errorf("redirect scope: exceeded = %t, remaining = %d, want 9", status.exceeded, status.remaining)
+	}
+}
+
+func TestRateLimitScopeConfig(t *testing.T) {
+	setRateLimits(t, func(limits *config.RateLimits) {
+		limits.Default.Requests = 100
+		limits.Shorten = config.RateLimit{Requests: 5, Window: 10 * time.Second}
+	})
+
+	if got := rateLimitConfig(RateLimitDefault); got.limit != 100 || got.window != time.Minute {
+		t.Errorf("default = %+v", got)
+	}
+	if got := rateLimitConfig(RateLimitShorten); got.limit != 5 || got.window != 10*time.Second {
+		t.Errorf("shorten = %+v", got)
+	}
+	if got := rateLimitConfig(RateLimitRedirect); got.limit != 1200 || got.window != time.Minute {
+		t.Errorf("redirect = %+v, want the default", got)
+	}
+}
+
+// setRateLimits changes the default rate limits for the duration of a test
+func setRateLimits(t *testing.T, change func(*config.RateLimits)) {
+	t.Helper()
+	previous := rateLimits
+	limits := config.Default().RateLimit
+	change(&limits)
+	ConfigureRateLimits(limits)
+	t.Cleanup(func() { ConfigureRateLimits(previous) })
+}
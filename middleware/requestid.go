@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"log/slog"
+
+	"url-shortener/logging"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request ID both ways
+const RequestIDHeader = "X-Request-ID"
+
+// Longest X-Request-ID accepted from clients
+const maxRequestIDLength = 128
+
+// RequestID identifies each request by the X-Request-ID it came with, or a
+// new random one, and echoes it in the response so clients and proxies can
+// match their logs with ours. The ID is also stored in the request context,
+// so lines logged with it carry the ID.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := NewRequestID(c.GetHeader(RequestIDHeader))
+		RequestIDContextKey.Set(c, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// NewRequestID returns the ID a client sent when it is usable, or a new
+// random one
+func NewRequestID(clientID string) string {
+	if validRequestID(clientID) {
+		return clientID
+	}
+	id, err := utils.GenerateToken(16)
+	if err != nil {
+		slog.Error("Failed to generate request ID", "error", err)
+	}
+	return id
+}
+
+// CurrentRequestID returns the ID RequestID gave the request, if any
+func CurrentRequestID(c *gin.Context) string {
+	return RequestIDContextKey.Value(c)
+}
+
+// validRequestID accepts printable ASCII IDs short enough to log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
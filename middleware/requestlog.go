@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Request log verbosities, set per surface with REQUEST_LOG_<SURFACE>
+const (
+	RequestLogAll    = "all"    // every request (default)
+	RequestLogErrors = "errors" // requests answered with a 4xx or 5xx status
+	RequestLogNone   = "none"   // no requests
+)
+
+// RequestLogVerbosities lists the accepted values of REQUEST_LOG_<SURFACE>
+var RequestLogVerbosities = []string{RequestLogAll, RequestLogErrors, RequestLogNone}
+
+// RequestLog logs a structured line for the requests of surface that
+// REQUEST_LOG_<SURFACE> asks for, so the busy redirect surface can log only
+// failures while admin calls are all kept. Lines carry the request ID,
+// method, path, status, latency and the short code of link routes; failed
+// requests are logged as warnings, and server errors as errors.
+func RequestLog(surface string) gin.HandlerFunc {
+	verbosity := requestLogVerbosity(surface)
+	if verbosity == RequestLogNone {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			// Rendered by Errors once this returns
+			status = APIErrorFor(c.Errors.Last().Err).Status
+		}
+		if verbosity == RequestLogErrors && status < http.StatusBadRequest {
+			return
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("surface", surface),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if shortCode := c.Param("shortCode"); shortCode != "" {
+			attrs = append(attrs, slog.String("short_code", shortCode))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.Last().Error()))
+		}
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+func requestLogVerbosity(surface string) string {
+	env := "REQUEST_LOG_" + strings.ToUpper(surface)
+	switch verbosity := strings.ToLower(os.Getenv(env)); verbosity {
+	case "", RequestLogAll:
+		return RequestLogAll
+	case RequestLogErrors, RequestLogNone:
+		return verbosity
+	default:
+		slog.Warn("Unknown "+env+", logging every request", "value", verbosity)
+		return RequestLogAll
+	}
+}
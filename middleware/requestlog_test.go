@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"url-shortener/logging"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// captureLogs sends slog's default logger to a buffer for the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var output bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&output, logging.FormatJSON, "debug", ""))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &output
+}
+
+// logLines decodes the JSON lines logged with the message msg
+func logLines(t *testing.T, output *bytes.Buffer, msg string) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	for _, raw := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		if raw == "" {
+			continue
+		}
+		var line map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatalf("log line is not JSON: %s", raw)
+		}
+		if line["msg"] == msg {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestRequestLogVerbosity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	output := captureLogs(t)
+
+	tests := []struct {
+		verbosity string
+		logged    []string
+	}{
+		{verbosity: "", logged: []string{"/ok", "/missing"}},
+		{verbosity: RequestLogErrors, logged: []string{"/missing"}},
+		{verbosity: RequestLogNone, logged: nil},
+	}
+	for _, tt := range tests {
+		t.Setenv("REQUEST_LOG_TEST", tt.verbosity)
+		output.Reset()
+
+		router := gin.New()
+		router.Use(RequestLog("test"))
+		router.GET("/ok", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+		router.GET("/missing", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+		for _, path := range []string{"/ok", "/missing"} {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+
+		lines := logLines(t, output, "request")
+		if len(lines) != len(tt.logged) {
+			t.Errorf("%q: logged %d requests, want %d:\n%s", tt.verbosity, len(lines), len(tt.logged), output.String())
+			continue
+		}
+		for i, path := range tt.logged {
+			if lines[i]["path"] != path {
+				t.Errorf("%q: logged %v, want %s", tt.verbosity, lines[i]["path"], path)
+			}
+		}
+	}
+}
+
+func TestRequestLogFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	output := captureLogs(t)
+
+	router := gin.New()
+	router.Use(RequestID(), Errors(), RequestLog("test"))
+	router.GET("/:shortCode", func(c *gin.Context) { c.Error(models.ErrLinkNotFound) })
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.Header.Set(RequestIDHeader, "req-42")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := logLines(t, output, "request")
+	if len(lines) != 1 {
+		t.Fatalf("logged %d requests, want 1:\n%s", len(lines), output.String())
+	}
+	want := map[string]interface{}{
+		"level":      "WARN",
+		"request_id": "req-42",
+		"method":     "GET",
+		"route":      "/:shortCode",
+		"short_code": "abc123",
+		"status":     float64(http.StatusNotFound),
+		"surface":    "test",
+	}
+	for key, value := range want {
+		if lines[0][key] != value {
+			t.Errorf("%s = %v, want %v", key, lines[0][key], value)
+		}
+	}
+	if _, ok := lines[0]["latency_ms"].(float64); !ok {
+		t.Errorf("latency_ms missing: %v", lines[0])
+	}
+}
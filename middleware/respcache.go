@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/config"
+	"url-shortener/i18n"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Responses are shared for RESPONSE_CACHE_TTL, 0 disabling the cache
+var responseCacheTTL = config.Default().Cache.ResponseTTL
+
+// ConfigureResponseCache sets RESPONSE_CACHE_TTL. Call it before building
+// the routes.
+func ConfigureResponseCache(ttl time.Duration) {
+	responseCacheTTL = ttl
+}
+
+// Response headers replayed with a cached body
+var cachedResponseHeaders = []string{"Content-Type", "Content-Language", "Cache-Control", "Vary", "Content-Security-Policy"}
+
+// ResponseCache answers GET requests from responses cached in Redis for
+// RESPONSE_CACHE_TTL, so a popular public page is rendered once for all
+// instances instead of once per request. Requests share a response when
+// they ask for the same host, path and query in the same language. Only
+// successful responses are cached; a request with a max_age query parameter
+// is never answered from a response older than that many seconds. When
+// cacheable is set, only the requests it accepts are cached. Place it after
+// Timeout and the authentication middleware.
+func ResponseCache(cacheable func(*gin.Context) bool) gin.HandlerFunc {
+	ttl := responseCacheTTL
+
+	return func(c *gin.Context) {
+		if ttl <= 0 || cache.RedisClient == nil || c.Request.Method != http.MethodGet || (cacheable != nil && !cacheable(c)) {
+			c.Next()
+			return
+		}
+
+		key := responseCacheKey(c)
+		if cached, err := cache.GetCachedResponse(key); err == nil && freshResponse(c, cached, ttl) {
+			for name, value := range cached.Header {
+				c.Header(name, value)
+			}
+			c.Header("X-Cache", "HIT")
+			c.Data(cached.Status, cached.Header["Content-Type"], cached.Body)
+			c.Abort()
+			return
+		}
+
+		writer := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Header("X-Cache", "MISS")
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if len(c.Errors) > 0 || writer.Status() != http.StatusOK {
+			return
+		}
+		response := &cache.CachedResponse{
+			Status:   http.StatusOK,
+			Header:   make(map[string]string),
+			Body:     writer.body.Bytes(),
+			StoredAt: time.Now().UnixMilli(),
+		}
+		for _, name := range cachedResponseHeaders {
+			if value := writer.Header().Get(name); value != "" {
+				response.Header[name] = value
+			}
+		}
+		if err := cache.CacheResponse(key, response, ttl); err != nil {
+			slog.WarnContext(c.Request.Context(), "Failed to cache response", "path", c.Request.URL.Path, "error", err)
+		}
+	}
+}
+
+// responseCacheKey identifies what a request asks for. Query parameters are
+// sorted so their order does not matter. Responses may hold short URLs
+// built on the scheme and host a trusted proxy forwarded, so those are part
+// of the key too.
+func responseCacheKey(c *gin.Context) string {
+	forwardedProto, _ := utils.ForwardedValue(c.GetHeader("X-Forwarded-Proto"), c.RemoteIP())
+	forwardedHost, _ := utils.ForwardedValue(c.GetHeader("X-Forwarded-Host"), c.RemoteIP())
+	hash := sha256.New()
+	for _, part := range []string{
+		c.Request.Host,
+		strings.ToLower(forwardedProto),
+		forwardedHost,
+		c.Request.URL.Path,
+		c.Request.URL.Query().Encode(),
+		i18n.Negotiate(c.GetHeader("Accept-Language")),
+	} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// freshResponse reports whether a cached response is recent enough for the request
+func freshResponse(c *gin.Context, cached *cache.CachedResponse, ttl time.Duration) bool {
+	maxAge := ttl
+	if seconds, err := strconv.Atoi(c.Query("max_age")); err == nil && time.Duration(seconds)*time.Second < maxAge {
+		maxAge = time.Duration(seconds) * time.Second
+	}
+	return time.Since(time.UnixMilli(cached.StoredAt)) <= maxAge
+}
+
+// capturingWriter keeps a copy of the response body while writing it
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
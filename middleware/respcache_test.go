@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestResponseCacheKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	key := func(target, language string) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		c.Request.Header.Set("Accept-Language", language)
+		return responseCacheKey(c)
+	}
+
+	base := key("/stats/abc?fields=click_count&max_age=5", "en-US")
+	if got := key("/stats/abc?max_age=5&fields=click_count", "en"); got != base {
+		t.Error("query order and language variants should share a key")
+	}
+	forwarded := func(remoteAddr, host string) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/stats/abc?fields=click_count&max_age=5", nil)
+		c.Request.RemoteAddr = remoteAddr
+		c.Request.Header.Set("Accept-Language", "en")
+		c.Request.Header.Set("X-Forwarded-Host", host)
+		return responseCacheKey(c)
+	}
+	// Only a trusted proxy's forwarded host changes the short URLs in a response
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8")
+	if forwarded("203.0.113.7:50000", "evil.example") != base {
+		t.Error("a client's forwarded host should not change the key")
+	}
+	for _, other := range []string{
+		forwarded("10.0.0.5:50000", "links.example.com"),
+		key("/stats/abc?fields=click_count&max_age=6", "en"),
+		key("/stats/abd?fields=click_count&max_age=5", "en"),
+		key("/stats/abc?fields=click_count&max_age=5", "fr"),
+	} {
+		if other == base {
+			t.Error("different requests share a key")
+		}
+	}
+}
+
+func TestFreshResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cached := &cache.CachedResponse{StoredAt: time.Now().Add(-3 * time.Second).UnixMilli()}
+	cases := map[string]bool{
+		"/stats/abc":            true,
+		"/stats/abc?max_age=10": true,
+		"/stats/abc?max_age=2":  false,
+		"/stats/abc?max_age=0":  false,
+	}
+	for target, want := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		if got := freshResponse(c, cached, 5*time.Second); got != want {
+			t.Errorf("%s: fresh = %v, want %v", target, got, want)
+		}
+	}
+	if freshResponse(&gin.Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}, cached, 2*time.Second) {
+		t.Error("responses older than the TTL should not be served")
+	}
+}
+
+// TestResponseCacheServesRepeats needs Redis (REDIS_ADDR, default
+// localhost:6379) and is skipped without it
+func TestResponseCacheServesRepeats(t *testing.T) {
+	cfg, err := config.FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.InitRedis(cfg.Redis)
+	if cache.RedisClient == nil {
+		t.Skip("Redis is not available")
+	}
+	gin.SetMode(gin.TestMode)
+
+	rendered := 0
+	router := gin.New()
+	router.Use(Errors())
+	router.GET("/page/:id", ResponseCache(nil), func(c *gin.Context) {
+		rendered++
+		if c.Param("id") == "missing" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Header("Content-Language", "en")
+		c.String(http.StatusOK, "page %s", c.Param("id"))
+	})
+
+	id := time.Now().Format("150405.000000")
+	request := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	first, second := request("/page/"+id), request("/page/"+id)
+	if rendered != 1 {
+		t.Errorf("page rendered %d times, want 1", rendered)
+	}
+	if first.Header().Get("X-Cache") != "MISS" || second.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("X-Cache = %q then %q, want MISS then HIT", first.Header().Get("X-Cache"), second.Header().Get("X-Cache"))
+	}
+	if second.Body.String() != "page "+id || second.Header().Get("Content-Language") != "en" {
+		t.Errorf("cached response = %q (%v)", second.Body.String(), second.Header())
+	}
+
+	request("/page/missing")
+	request("/page/missing")
+	if rendered != 3 {
+		t.Errorf("error responses should not be cached, rendered %d times", rendered)
+	}
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"url-shortener/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteContext attaches the matched route to the request context so
+// database instrumentation can attribute slow queries to it
+func RouteContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(database.WithRoute(c.Request.Context(), c.FullPath()))
+		c.Next()
+	}
+}
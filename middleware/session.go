@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener/background"
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionTokenPrefix distinguishes session access tokens from other bearer tokens
+const SessionTokenPrefix = "uss_"
+
+// SessionAuth requires `Authorization: Bearer <access token>` for a valid,
+// unrevoked session and loads the session and its user into the context
+func SessionAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.GetHeader("Authorization"), "Bearer "+SessionTokenPrefix) {
+			c.Error(models.NewAPIError(http.StatusUnauthorized, models.ErrCodeUnauthorized, "Session token required"))
+			c.Abort()
+			return
+		}
+		if err := authenticateSession(c); err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// SessionKeyAuth lets a dashboard session stand in for an API key of its
+// user on routes authenticated by APIKeyAuth, which must run after it. The
+// session grants users every scope but admin, and admins every scope.
+// Requests without a session token are left to APIKeyAuth.
+func SessionKeyAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.GetHeader("Authorization"), "Bearer "+SessionTokenPrefix) {
+			c.Next()
+			return
+		}
+		if err := authenticateSession(c); err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		user := CurrentUser(c)
+		userID := user.ID
+		apiKey := &models.APIKey{Name: "session", UserID: &userID}
+		if user.Role == models.RoleAdmin {
+			apiKey.Scopes = []string{models.ScopeCreate, models.ScopeReadStats, models.ScopeUpdate, models.ScopeDelete, models.ScopeAdmin}
+		}
+		APIKeyContextKey.Set(c, apiKey)
+		c.Next()
+	}
+}
+
+// authenticateSession loads the valid, unrevoked session of the request's
+// bearer access token and its user into the context
+func authenticateSession(c *gin.Context) *models.APIError {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+	var session models.Session
+	err := database.DB.
+		Where("token_hash = ? AND revoked_at IS NULL AND expires_at > ?", utils.HashToken(token), time.Now()).
+		First(&session).Error
+	if err != nil {
+		return models.NewAPIError(http.StatusUnauthorized, models.ErrCodeUnauthorized, "Invalid or expired session")
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, session.UserID).Error; err != nil {
+		return models.NewAPIError(http.StatusUnauthorized, models.ErrCodeUnauthorized, "Invalid or expired session")
+	}
+
+	// Admins without 2FA may only use their session to enroll when it is required
+	if user.Role == models.RoleAdmin && !user.TwoFactorEnabled && AdminTwoFactorRequired() &&
+		!strings.HasPrefix(c.Request.URL.Path, "/auth/2fa/") {
+		return errAdminTwoFactorRequired()
+	}
+
+	if time.Since(session.LastSeenAt) > lastUsedResolution {
+		id := session.ID
+		ctx := context.WithoutCancel(c.Request.Context())
+		background.Go(func() {
+			if err := database.DB.WithContext(ctx).Model(&models.Session{}).Where("id = ?", id).Update("last_seen_at", time.Now()).Error; err != nil {
+				slog.ErrorContext(ctx, "Failed to update last_seen_at for session", "session_id", id, "error", err)
+			}
+		})
+	}
+
+	SessionContextKey.Set(c, &session)
+	UserContextKey.Set(c, &user)
+	return nil
+}
+
+// AdminTwoFactorRequired reports whether REQUIRE_ADMIN_2FA is enabled
+func AdminTwoFactorRequired() bool {
+	required, _ := strconv.ParseBool(os.Getenv("REQUIRE_ADMIN_2FA"))
+	return required
+}
+
+// keyOfAdminWithoutTwoFactor reports whether apiKey belongs to an admin
+// account without 2FA while REQUIRE_ADMIN_2FA is enabled. Sessions were
+// checked as they were authenticated.
+func keyOfAdminWithoutTwoFactor(c *gin.Context, apiKey *models.APIKey) bool {
+	if !AdminTwoFactorRequired() || apiKey.UserID == nil || CurrentUser(c) != nil {
+		return false
+	}
+	var owner models.User
+	if err := database.DB.Select("role", "two_factor_enabled").First(&owner, *apiKey.UserID).Error; err != nil {
+		return false
+	}
+	return owner.Role == models.RoleAdmin && !owner.TwoFactorEnabled
+}
+
+// errAdminTwoFactorRequired refuses admin accounts without 2FA
+func errAdminTwoFactorRequired() *models.APIError {
+	return models.NewAPIError(http.StatusForbidden, models.ErrCodeTwoFactorRequired, "Two-factor authentication must be enabled for admin accounts")
+}
+
+// CurrentUser returns the dashboard user authenticated by SessionAuth, if any
+func CurrentUser(c *gin.Context) *models.User {
+	return UserContextKey.Value(c)
+}
+
+// CurrentSession returns the session authenticated by SessionAuth, if any
+func CurrentSession(c *gin.Context) *models.Session {
+	return SessionContextKey.Value(c)
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route classes with their own timeouts
+const (
+	TimeoutRedirect = "redirect" // the redirect hot path, TIMEOUT_REDIRECT (default 2s)
+	TimeoutDefault  = "default"  // API and admin endpoints, TIMEOUT_DEFAULT (default 15s)
+	TimeoutExport   = "export"   // long-running exports, TIMEOUT_EXPORT (default 5m)
+)
+
+var defaultTimeouts = map[string]time.Duration{
+	TimeoutRedirect: 2 * time.Second,
+	TimeoutDefault:  15 * time.Second,
+	TimeoutExport:   5 * time.Minute,
+}
+
+// Timeout cancels the request context once the timeout for the route class
+// expires. Handlers pass that context to their queries, so a slow
+// dependency fails fast instead of piling up requests. The response is
+// buffered and replaced with 504 Gateway Timeout when the deadline passes.
+func Timeout(class string) gin.HandlerFunc {
+	timeout := routeTimeout(class)
+
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer, header: make(http.Header), status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		renderErrors(c)
+		c.Writer = writer.ResponseWriter
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			slog.WarnContext(ctx, "Request timed out", "method", c.Request.Method, "route", c.FullPath(), "timeout", timeout.String())
+			c.JSON(http.StatusGatewayTimeout, models.ErrTimeout.Response())
+			return
+		}
+		writer.flush()
+	}
+}
+
+func routeTimeout(class string) time.Duration {
+	env := map[string]string{
+		TimeoutRedirect: "TIMEOUT_REDIRECT",
+		TimeoutDefault:  "TIMEOUT_DEFAULT",
+		TimeoutExport:   "TIMEOUT_EXPORT",
+	}[class]
+
+	if value, err := time.ParseDuration(os.Getenv(env)); err == nil && value > 0 {
+		return value
+	}
+	if timeout, ok := defaultTimeouts[class]; ok {
+		return timeout
+	}
+	return defaultTimeouts[TimeoutDefault]
+}
+
+// bufferedWriter holds the response until the handler finished in time
+type bufferedWriter struct {
+	gin.ResponseWriter
+	header  http.Header
+	body    bytes.Buffer
+	status  int
+	written bool
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// flush writes the buffered response to the client
+func (w *bufferedWriter) flush() {
+	header := w.ResponseWriter.Header()
+	for key, values := range w.header {
+		header[key] = values
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(w.body.Bytes())
+}
@@ -0,0 +1,200 @@
+// Package mirror dark-launches changes by mirroring a sample of redirect
+// traffic, as metadata only, to a shadow backend (MIRROR_URL) or an
+// in-process shadow resolver (MIRROR_SHADOW) whose answers are compared with
+// the live ones. Mirroring runs in the background and never affects the
+// redirect itself; when it falls behind, samples are dropped.
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"url-shortener/models"
+	"url-shortener/outbound"
+)
+
+// Sampled redirects are sent to MIRROR_URL in batches of up to this size,
+// at least every mirrorFlushInterval
+const (
+	mirrorQueueSize     = 10000
+	mirrorBatchSize     = 100
+	mirrorFlushInterval = time.Second
+)
+
+// Outcome is how a redirect was answered, compared between the live path
+// and a shadow resolver
+type Outcome struct {
+	Status          int
+	DestinationHash string
+}
+
+// Resolver answers a mirrored redirect through a new code path, e.g. a new
+// cache layer or storage backend, without side effects
+type Resolver func(ctx context.Context, redirect models.MirroredRedirect) (Outcome, error)
+
+var (
+	percent    float64
+	targetURL  string
+	shadowName string
+	shadow     Resolver
+	queue      chan models.MirroredRedirect
+	resolvers  = make(map[string]Resolver)
+	httpClient = outbound.NewClient(outbound.Options{Timeout: 5 * time.Second})
+
+	mirrored, dropped, sent, failed, compared, mismatch atomic.Int64
+)
+
+// RegisterShadow makes a resolver available as MIRROR_SHADOW=name. Call it
+// before Start.
+func RegisterShadow(name string, resolver Resolver) {
+	resolvers[name] = resolver
+}
+
+// Start enables mirroring of MIRROR_PERCENT (0-100, default 0) percent of
+// redirects when MIRROR_URL or MIRROR_SHADOW is set
+func Start() {
+	value, err := strconv.ParseFloat(os.Getenv("MIRROR_PERCENT"), 64)
+	if err != nil || value <= 0 {
+		return
+	}
+	percent = min(value, 100)
+	targetURL = os.Getenv("MIRROR_URL")
+
+	if name := os.Getenv("MIRROR_SHADOW"); name != "" {
+		resolver, ok := resolvers[name]
+		if !ok {
+			slog.Warn("Unknown MIRROR_SHADOW, shadow comparison disabled", "shadow", name)
+		} else {
+			shadowName, shadow = name, resolver
+		}
+	}
+	if targetURL == "" && shadow == nil {
+		slog.Warn("MIRROR_PERCENT is set without MIRROR_URL or MIRROR_SHADOW, mirroring disabled")
+		return
+	}
+
+	queue = make(chan models.MirroredRedirect, mirrorQueueSize)
+	go run()
+	slog.Info("Mirroring redirects", "percent", percent)
+}
+
+// Sample reports whether the current redirect should be mirrored
+func Sample() bool {
+	return queue != nil && rand.Float64()*100 < percent
+}
+
+// Observe queues a sampled redirect without blocking
+func Observe(redirect models.MirroredRedirect) {
+	select {
+	case queue <- redirect:
+		mirrored.Add(1)
+	default:
+		dropped.Add(1)
+	}
+}
+
+// Status reports the mirroring configuration and counters
+func Status() models.MirrorStatus {
+	status := models.MirrorStatus{
+		Enabled:  queue != nil,
+		Percent:  percent,
+		Shadow:   shadowName,
+		Mirrored: mirrored.Load(),
+		Dropped:  dropped.Load(),
+		Sent:     sent.Load(),
+		Failed:   failed.Load(),
+		Compared: compared.Load(),
+		Mismatch: mismatch.Load(),
+	}
+	if parsed, err := url.Parse(targetURL); err == nil {
+		status.Target = parsed.Host
+	}
+	return status
+}
+
+func run() {
+	ticker := time.NewTicker(mirrorFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.MirroredRedirect, 0, mirrorBatchSize)
+	flush := func() {
+		if len(batch) > 0 && targetURL != "" {
+			send(batch)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case redirect := <-queue:
+			if shadow != nil {
+				compare(redirect)
+			}
+			batch = append(batch, redirect)
+			if len(batch) == mirrorBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// send POSTs a batch as a JSON array to MIRROR_URL
+func send(batch []models.MirroredRedirect) {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return
+	}
+
+	err = func() error {
+		resp, err := httpClient.Post(targetURL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return nil
+	}()
+
+	if err != nil {
+		if failed.Add(int64(len(batch))) == int64(len(batch)) {
+			slog.Error("Failed to mirror redirects to shadow backend", "error", err)
+		}
+		return
+	}
+	sent.Add(int64(len(batch)))
+}
+
+// compare replays a redirect against the shadow resolver
+func compare(redirect models.MirroredRedirect) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	outcome, err := shadow(ctx, redirect)
+	if err != nil {
+		outcome = Outcome{Status: http.StatusInternalServerError}
+	}
+	compared.Add(1)
+
+	if outcome.Status != redirect.Status || outcome.DestinationHash != redirect.DestinationHash {
+		// Log the first mismatch and then every hundredth
+		if mismatch.Add(1)%100 == 1 {
+			slog.Warn("Shadow disagrees on a redirect", "shadow", shadowName, "short_code", redirect.ShortCode,
+				"live_status", redirect.Status, "live_destination_hash", redirect.DestinationHash,
+				"shadow_status", outcome.Status, "shadow_destination_hash", outcome.DestinationHash, "error", err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Abuse levels of a creator of links, from its abuse score or an admin
+// override, each restricting the creator more than the one before
+const (
+	AbuseLevelNormal   = "normal"
+	AbuseLevelElevated = "elevated" // rate limits halved
+	AbuseLevelHigh     = "high"     // rate limits quartered, risky features disabled
+	AbuseLevelSevere   = "severe"   // rate limits cut to a tenth, new links held for approval
+)
+
+// AbuseScore is the abuse score of a creator of links: user:<id> for links
+// created with an API key of a user, ip:<address> for the others. The score
+// decays over time; Score is its value as of UpdatedAt.
+type AbuseScore struct {
+	Creator   string    `json:"creator" gorm:"primaryKey"`
+	Score     float64   `json:"score" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Level set by an admin, which applies whatever the score; nil to
+	// follow the score
+	Override *string `json:"override,omitempty" enums:"normal,elevated,high,severe"`
+	Reason   string  `json:"reason,omitempty"` // why the override was set
+
+	Level string `json:"level" gorm:"-" enums:"normal,elevated,high,severe"` // in force, from Override or Score
+}
+
+// AbuseScoreOverrideRequest sets or clears the level of a creator
+type AbuseScoreOverrideRequest struct {
+	// Level applying whatever the score, null to follow the score again
+	Level  *string `json:"level" binding:"omitempty,oneof=normal elevated high severe" enums:"normal,elevated,high,severe"`
+	Reason string  `json:"reason" binding:"max=500"`
+}
+
+// AbuseReport is a visitor's report that a link is abusive, counted against
+// the link's creator
+type AbuseReport struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ShortCode string `json:"short_code" gorm:"not null;uniqueIndex:idx_abuse_reports_reporter,priority:1"`
+	Creator   string `json:"creator" gorm:"index"`
+	Reason    string `json:"reason,omitempty"`
+	// Hash of the reporter's IP address with the short code, so each
+	// visitor reports a link once
+	ReporterHash string `json:"-" gorm:"not null;uniqueIndex:idx_abuse_reports_reporter,priority:2"`
+}
+
+type AbuseReportRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
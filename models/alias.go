@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// RenamedAlias is a short code a link was renamed away from. Until ExpiresAt
+// it keeps resolving to the link, and Hits tell the owner whether anyone
+// still uses it. The code is never given to another link.
+type RenamedAlias struct {
+	Alias     string     `json:"alias" gorm:"primaryKey"`
+	URLID     uint       `json:"-" gorm:"not null;index"`
+	CreatedAt time.Time  `json:"renamed_at"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	Hits      int64      `json:"hits" gorm:"not null;default:0"`
+	LastHitAt *time.Time `json:"last_hit_at,omitempty"`
+}
+
+// Where a renamed alias sends visitors during its grace period
+const (
+	AliasTargetShortURL    = "short_url"   // 301 to the link's new short URL
+	AliasTargetDestination = "destination" // straight to the destination, counted as a click
+)
@@ -0,0 +1,184 @@
+package models
+
+import "time"
+
+// Click time series granularities
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+)
+
+// DirectReferrer groups clicks that carried no referrer
+const DirectReferrer = "(direct)"
+
+// TimeseriesResponse counts a link's clicks per hour or day of TimeZone, with
+// times in that zone; buckets without clicks are included with zero clicks.
+// Links whose analytics are not full record no click events, so all their
+// buckets are empty.
+type TimeseriesResponse struct {
+	ShortCode string            `json:"short_code" example:"abc123"`
+	Analytics string            `json:"analytics" example:"full"` // full, count or none
+	Interval  string            `json:"interval" example:"day"`
+	TimeZone  string            `json:"tz" example:"Europe/Berlin"`
+	From      time.Time         `json:"from"`
+	To        time.Time         `json:"to"`
+	Total     int64             `json:"total" example:"42"`
+	Points    []TimeseriesPoint `json:"points"`
+	Previous  *PreviousPeriod   `json:"previous,omitempty"` // with compare=previous_period
+	// Events annotated on the link's timeline between From and To, oldest
+	// first
+	Annotations []LinkAnnotation `json:"annotations"`
+	// Set when from was moved forward to the click history kept on the
+	// plan of the link's owner
+	Retention *RetentionNotice `json:"retention,omitempty"`
+}
+
+// TimeseriesPoint is the number of clicks in one bucket
+type TimeseriesPoint struct {
+	Time   time.Time `json:"time"`
+	Clicks int64     `json:"clicks" example:"7"`
+	// Percentage change from the bucket at the same position of the
+	// previous period, with compare=previous_period; absent when that bucket
+	// had no clicks
+	Change *float64 `json:"change,omitempty" example:"16.7"`
+}
+
+// PreviousPeriod is a time series over the period of the same length right
+// before the requested one, to compare it with
+type PreviousPeriod struct {
+	From   time.Time         `json:"from"`
+	To     time.Time         `json:"to"`
+	Total  int64             `json:"total" example:"36"`
+	Points []TimeseriesPoint `json:"points"`
+	// Percentage change of the total from this period to the requested
+	// one, rounded to a tenth; null when this period had no clicks
+	Change *float64 `json:"change" example:"16.7"`
+}
+
+// ReferrersResponse ranks the sites that sent a link's clicks. Links whose
+// analytics are not full record no referrers.
+type ReferrersResponse struct {
+	ShortCode string          `json:"short_code" example:"abc123"`
+	Analytics string          `json:"analytics" example:"full"` // full, count or none
+	From      time.Time       `json:"from"`
+	To        time.Time       `json:"to"`
+	Referrers []ReferrerCount `json:"referrers"`
+	// Set when from was moved forward to the click history kept on the
+	// plan of the link's owner
+	Retention *RetentionNotice `json:"retention,omitempty"`
+}
+
+// ReferrerCount is the number of clicks from one referring host
+type ReferrerCount struct {
+	Referrer string `json:"referrer" example:"news.ycombinator.com"`
+	Clicks   int64  `json:"clicks" example:"120"`
+}
+
+// DestinationStatsResponse adds up the clicks of a user's links per
+// destination domain. Total counts the clicks of every domain, including
+// those past the limit.
+type DestinationStatsResponse struct {
+	From         time.Time          `json:"from"`
+	To           time.Time          `json:"to"`
+	Total        int64              `json:"total" example:"5200"`
+	Destinations []DestinationCount `json:"destinations"`
+	// Set when from was moved forward to the click history kept on the
+	// caller's plan
+	Retention *RetentionNotice `json:"retention,omitempty"`
+}
+
+// DestinationCount is the number of clicks on the links to one destination
+// domain
+type DestinationCount struct {
+	Domain string `json:"domain" example:"example.com"`
+	Links  int64  `json:"links" example:"14"` // links to the domain clicked in the range
+	Clicks int64  `json:"clicks" example:"3100"`
+}
+
+// HeatmapResponse counts a link's clicks per hour of the week in TimeZone,
+// so owners can see when its audience clicks. Clicks[d][h] counts the clicks
+// on weekday d, 0 for Monday to 6 for Sunday, between hour h and h+1.
+type HeatmapResponse struct {
+	ShortCode string       `json:"short_code" example:"abc123"`
+	Analytics string       `json:"analytics" example:"full"` // full, count or none
+	TimeZone  string       `json:"tz" example:"Europe/Berlin"`
+	From      time.Time    `json:"from"`
+	To        time.Time    `json:"to"`
+	Total     int64        `json:"total" example:"420"`
+	Clicks    [][]int64    `json:"clicks"`         // 7 weekdays of 24 hours
+	Peak      *HeatmapPeak `json:"peak,omitempty"` // the hour with the most clicks, absent without clicks
+	// Set when from was moved forward to the click history kept on the
+	// plan of the link's owner
+	Retention *RetentionNotice `json:"retention,omitempty"`
+}
+
+// HeatmapPeak is the hour of the week with the most clicks, the earliest in
+// the week on ties
+type HeatmapPeak struct {
+	Weekday string `json:"weekday" example:"tuesday"`
+	Hour    int    `json:"hour" example:"18"`
+	Clicks  int64  `json:"clicks" example:"31"`
+}
+
+// UniqueVisitorsResponse estimates how many different visitors clicked a
+// link over whole UTC days, from HyperLogLogs with a 0.81% standard error.
+// Visitors are told apart by IP address and user agent.
+type UniqueVisitorsResponse struct {
+	ShortCode      string    `json:"short_code" example:"abc123"`
+	Analytics      string    `json:"analytics" example:"full"` // full, count or none
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	UniqueVisitors int64     `json:"unique_visitors" example:"3120"`
+	Approximate    bool      `json:"approximate" example:"true"`
+	// Set when from was moved forward to the click history kept on the
+	// plan of the link's owner
+	Retention *RetentionNotice `json:"retention,omitempty"`
+}
+
+// TagStatsResponse adds up the clicks of every link carrying a tag. Links and
+// ClickCount cover all time; Total and Points cover the requested range.
+type TagStatsResponse struct {
+	Tag        string            `json:"tag" example:"spring-sale"`
+	Links      int64             `json:"links" example:"12"`
+	ClickCount int64             `json:"click_count" example:"3400"`
+	Interval   string            `json:"interval" example:"day"`
+	TimeZone   string            `json:"tz" example:"Europe/Berlin"`
+	From       time.Time         `json:"from"`
+	To         time.Time         `json:"to"`
+	Total      int64             `json:"total" example:"420"`
+	Points     []TimeseriesPoint `json:"points"`
+	Previous   *PreviousPeriod   `json:"previous,omitempty"` // with compare=previous_period
+	// Set when from was moved forward to the click history kept on the
+	// caller's plan
+	Retention *RetentionNotice `json:"retention,omitempty"`
+}
+
+// RetentionNotice explains that a stats range started before the click
+// history kept on a plan, as set by STATS_RETENTION_DAYS, and was shortened
+// to start with it
+type RetentionNotice struct {
+	Plan          string    `json:"plan" example:"free"`
+	Days          int       `json:"days" example:"30"` // days of click history kept on the plan
+	RequestedFrom time.Time `json:"requested_from"`
+	Message       string    `json:"message" example:"Click history is kept for 30 days on the free plan, from was moved to 2024-03-01T00:00:00Z"`
+}
+
+// GlobalStatsResponse sums up the links of the whole service for admins.
+// Click counts cover the time since each link's last stats reset.
+type GlobalStatsResponse struct {
+	TotalURLs      int64            `json:"total_urls" example:"1250"`     // live links, not deleted or archived
+	ArchivedURLs   int64            `json:"archived_urls" example:"310"`   // idle links moved to the archive
+	ByStatus       map[string]int64 `json:"by_status"`                     // live links per status
+	TotalClicks    int64            `json:"total_clicks" example:"98321"`  // clicks of live and archived links
+	CreatedLast24h int64            `json:"created_last_24h" example:"42"` // live links created in the last 24 hours
+	TopLinks       []TopLink        `json:"top_links"`
+}
+
+// TopLink is one of the most clicked live links
+type TopLink struct {
+	ShortCode   string    `json:"short_code" example:"abc123"`
+	OriginalURL string    `json:"original_url"`
+	ClickCount  int64     `json:"click_count" example:"5120"`
+	Status      string    `json:"status" example:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// LinkAnnotation marks an event on a link's timeline, such as a newsletter
+// going out or a TV spot airing, returned with the link's click time series
+// so spikes can be explained
+type LinkAnnotation struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	URLID  uint      `json:"-" gorm:"not null;index:idx_link_annotations_url_time,priority:1"`
+	Time   time.Time `json:"time" gorm:"not null;index:idx_link_annotations_url_time,priority:2"` // when the event happened
+	Text   string    `json:"text" gorm:"not null" example:"Newsletter sent"`
+	Author string    `json:"author" example:"user:7"` // the owner as user:<id>
+}
+
+// AnnotationRequest adds an annotation to a link's timeline
+type AnnotationRequest struct {
+	Time *time.Time `json:"time"` // when the event happened, RFC 3339; now when omitted
+	Text string     `json:"text" binding:"required,max=200" example:"Newsletter sent"`
+}
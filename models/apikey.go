@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// APIKey authenticates machine clients. Only a hash of the key is stored;
+// the signing secret is kept to verify HMAC request signatures.
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+
+	Name          string `json:"name" gorm:"not null"`
+	KeyHash       string `json:"-" gorm:"uniqueIndex;not null"`
+	KeyPrefix     string `json:"key_prefix"` // first characters of the key, for identification
+	SigningSecret string `json:"-" gorm:"not null"`
+
+	Scopes         []string `json:"scopes" gorm:"serializer:json"`
+	AllowedDomains []string `json:"allowed_domains,omitempty" gorm:"serializer:json"` // destination domains this key may shorten
+	AllowedTags    []string `json:"allowed_tags,omitempty" gorm:"serializer:json"`    // tags this key may put on links
+
+	UserID        *uint `json:"user_id,omitempty" gorm:"index"` // user owning the links created with this key
+	RotatedFromID *uint `json:"rotated_from_id,omitempty"`      // key this one replaced
+	StaleAlerted  bool  `json:"-" gorm:"default:false"`
+}
+
+// API key scopes
+const (
+	ScopeCreate    = "create"
+	ScopeReadStats = "read_stats"
+	ScopeUpdate    = "update"
+	ScopeDelete    = "delete"
+	ScopeAdmin     = "admin"
+)
+
+// DefaultScopes are granted when a key is created without explicit scopes
+var DefaultScopes = []string{ScopeCreate, ScopeReadStats}
+
+// HasScope reports whether the key grants scope. Keys issued before scopes
+// existed carry none and keep access to every non-admin operation.
+func (k *APIKey) HasScope(scope string) bool {
+	if len(k.Scopes) == 0 {
+		return scope != ScopeAdmin
+	}
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateAPIKeyRequest struct {
+	Name           string   `json:"name" binding:"required"`
+	Scopes         []string `json:"scopes" binding:"omitempty,dive,oneof=create read_stats update delete admin"`
+	AllowedDomains []string `json:"allowed_domains"`
+	AllowedTags    []string `json:"allowed_tags" binding:"omitempty,dive,min=1,max=64"`
+	ExpiresIn      int      `json:"expires_in"` // in days, optional
+	UserID         *uint    `json:"user_id"`    // optional, links created with the key belong to this user
+}
+
+// APIKeyCreatedResponse is the only response that includes the key and signing secret
+type APIKeyCreatedResponse struct {
+	ID             uint       `json:"id"`
+	Name           string     `json:"name"`
+	Key            string     `json:"key"`
+	SigningSecret  string     `json:"signing_secret"`
+	Scopes         []string   `json:"scopes"`
+	AllowedDomains []string   `json:"allowed_domains,omitempty"`
+	AllowedTags    []string   `json:"allowed_tags,omitempty"`
+	UserID         *uint      `json:"user_id,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
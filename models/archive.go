@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// ArchivedURL is a link moved out of the urls table by the archival policy
+// after going untouched for LINK_ARCHIVE_AFTER. It keeps the link's ID so
+// click events stay attached when it is rehydrated.
+type ArchivedURL struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	ArchivedAt time.Time `json:"archived_at" gorm:"not null;index"`
+
+	OriginalURL     string        `json:"original_url" gorm:"not null;serializer:encrypted"`
+	OriginalURLHash *string       `json:"-"` // restored only when no live link took over the destination
+	ShortCode       string        `json:"short_code" gorm:"uniqueIndex;not null"`
+	DomainID        *uint         `json:"domain_id,omitempty" gorm:"index"`
+	OwnerID         *uint         `json:"owner_id,omitempty" gorm:"index"`
+	ExternalID      *string       `json:"external_id,omitempty"`
+	Creator         string        `json:"-"`
+	ClickCount      int           `json:"click_count"`
+	ExpiresAt       *time.Time    `json:"expires_at"`
+	ExpiryExempt    bool          `json:"expiry_exempt" gorm:"default:false"`
+	Locked          bool          `json:"locked"`
+	Status          string        `json:"status"`
+	Inert           bool          `json:"inert"`
+	Tags            []string      `json:"tags,omitempty" gorm:"type:jsonb;serializer:json"`
+	NoIndex         bool          `json:"noindex" gorm:"default:false"`
+	VariantMode     string        `json:"variant_mode,omitempty"`
+	Analytics       string        `json:"analytics" gorm:"default:full"`
+	MaxClicks       *int          `json:"max_clicks,omitempty"`
+	ClicksRemaining *int          `json:"clicks_remaining,omitempty"`
+	StatsResetAt    *time.Time    `json:"stats_reset_at,omitempty"`
+	UTMSource       string        `json:"utm_source,omitempty"`
+	UTMMedium       string        `json:"utm_medium,omitempty"`
+	UTMCampaign     string        `json:"utm_campaign,omitempty"`
+	RedirectType    int           `json:"redirect_type,omitempty"`
+	RoutingRules    []RoutingRule `json:"routing_rules,omitempty" gorm:"type:jsonb;serializer:json"`
+	Version         int           `json:"version" gorm:"not null;default:1"`
+
+	OGTitle       string `json:"og_title,omitempty"`
+	OGDescription string `json:"og_description,omitempty"`
+	OGImage       string `json:"og_image,omitempty"`
+
+	PageTitle       string     `json:"page_title,omitempty"`
+	PageDescription string     `json:"page_description,omitempty"`
+	PageFetchedAt   *time.Time `json:"page_fetched_at,omitempty"`
+}
+
+// ToURL returns the link as it was before it was archived
+func (a *ArchivedURL) ToURL() *URL {
+	return &URL{
+		ID:              a.ID,
+		CreatedAt:       a.CreatedAt,
+		UpdatedAt:       a.UpdatedAt,
+		OriginalURL:     a.OriginalURL,
+		OriginalURLHash: a.OriginalURLHash,
+		ShortCode:       a.ShortCode,
+		DomainID:        a.DomainID,
+		OwnerID:         a.OwnerID,
+		ExternalID:      a.ExternalID,
+		Creator:         a.Creator,
+		ClickCount:      a.ClickCount,
+		ExpiresAt:       a.ExpiresAt,
+		ExpiryExempt:    a.ExpiryExempt,
+		Locked:          a.Locked,
+		Status:          a.Status,
+		Inert:           a.Inert,
+		Tags:            a.Tags,
+		NoIndex:         a.NoIndex,
+		VariantMode:     a.VariantMode,
+		Analytics:       a.Analytics,
+		MaxClicks:       a.MaxClicks,
+		ClicksRemaining: a.ClicksRemaining,
+		StatsResetAt:    a.StatsResetAt,
+		UTMSource:       a.UTMSource,
+		UTMMedium:       a.UTMMedium,
+		UTMCampaign:     a.UTMCampaign,
+		RedirectType:    a.RedirectType,
+		RoutingRules:    a.RoutingRules,
+		Version:         a.Version,
+		OGTitle:         a.OGTitle,
+		OGDescription:   a.OGDescription,
+		OGImage:         a.OGImage,
+		PageTitle:       a.PageTitle,
+		PageDescription: a.PageDescription,
+		PageFetchedAt:   a.PageFetchedAt,
+	}
+}
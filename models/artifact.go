@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Kinds of generated files
+const (
+	ArtifactLinksExport = "links_export" // links as CSV or JSON, like GET /admin/urls/export
+	ArtifactQRBatch     = "qr_batch"     // a ZIP archive of the QR codes of links
+)
+
+// Artifact states
+const (
+	ArtifactStatusPending = "pending"
+	ArtifactStatusReady   = "ready"
+	ArtifactStatusFailed  = "failed"
+)
+
+// ArtifactRequest is the payload of POST /admin/artifacts. Links exports
+// take the filters of GET /admin/urls/export; QR batches take short codes.
+type ArtifactRequest struct {
+	Kind          string     `json:"kind" binding:"required,oneof=links_export qr_batch" example:"qr_batch"`
+	Format        string     `json:"format" binding:"omitempty,oneof=csv json png svg" example:"png"` // csv (default) or json for exports, png (default) or svg for QR codes
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	Expired       *bool      `json:"expired,omitempty"`
+	Domain        string     `json:"domain,omitempty" binding:"omitempty,max=253"`
+	ShortCodes    []string   `json:"short_codes,omitempty" binding:"omitempty,max=1000,dive,required"`
+	Size          int        `json:"size,omitempty" binding:"omitempty,min=128,max=1024" example:"512"` // QR code width in pixels, 256 by default
+	ECC           string     `json:"ecc,omitempty" binding:"omitempty,oneof=L M Q H" example:"M"`       // QR error correction level, M by default
+}
+
+// Artifact is a file generated in the background and kept in the artifact
+// store until it expires. DownloadURL is set once it is ready, valid for a
+// limited time; fetch the artifact again for a fresh one.
+type Artifact struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+
+	Kind        string          `json:"kind" gorm:"not null"`
+	Request     ArtifactRequest `json:"request" gorm:"type:jsonb;serializer:json"`
+	Status      string          `json:"status" gorm:"not null"`
+	Filename    string          `json:"filename" example:"links-20240501-120000.csv"`
+	ContentType string          `json:"content_type,omitempty" example:"text/csv"`
+	Size        int64           `json:"size,omitempty"`  // bytes
+	Items       int             `json:"items,omitempty"` // links in the file
+	Error       string          `json:"error,omitempty"`
+
+	DownloadURL string `json:"download_url,omitempty" gorm:"-"`
+
+	Key     string `json:"-"` // in the artifact store
+	BaseURL string `json:"-"` // short links of the default domain are built on
+}
+
+// ArtifactsResponse lists the latest artifacts
+type ArtifactsResponse struct {
+	Artifacts []Artifact `json:"artifacts"`
+}
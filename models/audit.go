@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// AuditLog records sensitive administrative actions on links and users
+type AuditLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Action    string `json:"action" gorm:"not null;index"`
+	ShortCode string `json:"short_code" gorm:"index"`
+	Actor     string `json:"actor"`
+	IPAddress string `json:"ip_address"`
+	Details   string `json:"details"`
+}
+
+// Audit actions
+const (
+	AuditActionLock    = "link.lock"
+	AuditActionUnlock  = "link.unlock"
+	AuditActionApprove = "link.approve"
+	AuditActionReject  = "link.reject"
+	AuditActionExempt  = "link.expiry_exempt"
+	AuditActionEnforce = "link.expiry_enforce"
+	AuditActionUpdate  = "link.update"
+	AuditActionDelete  = "link.delete"
+	AuditActionReset   = "link.stats_reset"
+	AuditActionDisable = "link.disable"
+	AuditActionEnable  = "link.enable"
+
+	AuditActionBulkExpire  = "link.bulk_expire"
+	AuditActionBulkDisable = "link.bulk_disable"
+	AuditActionBulkTag     = "link.bulk_tag"
+
+	AuditActionAbuseOverride = "creator.abuse_override"
+	AuditActionAbuseReset    = "creator.abuse_reset"
+
+	AuditActionImpersonateStart   = "user.impersonate_start"
+	AuditActionImpersonateEnd     = "user.impersonate_end"
+	AuditActionImpersonateRequest = "user.impersonate_request"
+)
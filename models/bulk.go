@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Bulk link actions, set with BulkLinkRequest.Action; tag rule backfills
+// queue BulkActionAutoTag
+const (
+	BulkActionExpire  = "expire"   // expire the links now, so they answer 410 Gone
+	BulkActionDisable = "disable"  // disable the links, so they answer 410 Gone until re-enabled
+	BulkActionAutoTag = "auto_tag" // add the tags of the matching tag rules, see TagRule
+)
+
+// Bulk operation states
+const (
+	BulkStatusQueued    = "queued"
+	BulkStatusRunning   = "running"
+	BulkStatusCompleted = "completed"
+	BulkStatusFailed    = "failed"
+)
+
+// BulkLinkRequest is the payload of POST /admin/links/bulk. At least one
+// filter is required; links must match all of those set.
+type BulkLinkRequest struct {
+	Action        string     `json:"action" binding:"required,oneof=expire disable" example:"disable"`
+	Tag           string     `json:"tag" binding:"omitempty,max=64"`                        // links with this tag
+	Domain        string     `json:"domain" binding:"omitempty,max=253" example:"evil.com"` // links to this host or its subdomains
+	CreatedBefore *time.Time `json:"created_before"`                                        // links created before this time
+}
+
+// BulkOperation is an admin action on every link matching a filter, run in
+// the background by whichever instance holds its lease and resumed after
+// restarts from the last link processed
+type BulkOperation struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	Action        string     `json:"action" gorm:"not null"`
+	Tag           string     `json:"tag,omitempty"`
+	Domain        string     `json:"domain,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+
+	Status    string `json:"status" gorm:"not null;index"`
+	Matched   int64  `json:"matched"`   // links matching the filter when the operation was queued
+	Processed int64  `json:"processed"` // links checked so far
+	Updated   int64  `json:"updated"`   // links the action changed
+	Skipped   int64  `json:"skipped"`   // locked links, left alone
+	Error     string `json:"error,omitempty"`
+
+	LastURLID  uint       `json:"-"` // links are processed in ID order, resuming after this one
+	LeaseUntil *time.Time `json:"-"` // held by an instance processing the operation
+}
+
+// BulkOperationsResponse lists the latest bulk operations
+type BulkOperationsResponse struct {
+	Operations []BulkOperation `json:"operations"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Version of the link bundle format
+const LinkBundleVersion = 1
+
+// LinkBundle carries links from one instance to another, such as from
+// staging to production, keeping their short codes. It is signed with
+// LINK_BUNDLE_SECRET, which both instances share.
+type LinkBundle struct {
+	Version    int          `json:"version" example:"1"`
+	Source     string       `json:"source" example:"staging.sho.rt"`
+	ExportedAt time.Time    `json:"exported_at"`
+	Links      []BundleLink `json:"links"`
+	// Hex HMAC-SHA256 of the bundle encoded as JSON with an empty signature
+	Signature string `json:"signature"`
+}
+
+// BundleLink is a link with its short code and settings; clicks and
+// history stay behind
+type BundleLink struct {
+	ShortCode     string           `json:"short_code" example:"promo2024"`
+	URL           string           `json:"url" example:"https://example.com/spring"`
+	ExpiresAt     *time.Time       `json:"expires_at,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	NoIndex       bool             `json:"noindex,omitempty"`
+	VariantMode   string           `json:"variant_mode,omitempty"`
+	Analytics     AnalyticsMode    `json:"analytics,omitempty" swaggertype:"string" enums:"full,count,none"`
+	MaxClicks     *int             `json:"max_clicks,omitempty"` // clicks the link had left when exported
+	UTMSource     string           `json:"utm_source,omitempty"`
+	UTMMedium     string           `json:"utm_medium,omitempty"`
+	UTMCampaign   string           `json:"utm_campaign,omitempty"`
+	RedirectType  int              `json:"redirect_type,omitempty"`
+	Variants      []VariantRequest `json:"variants,omitempty"`
+	RoutingRules  []RoutingRule    `json:"routing_rules,omitempty"`
+	OGTitle       string           `json:"og_title,omitempty"`
+	OGDescription string           `json:"og_description,omitempty"`
+	OGImage       string           `json:"og_image,omitempty"`
+}
+
+// LinkExportRequest selects the links to export by short code, tag or both
+type LinkExportRequest struct {
+	ShortCodes []string `json:"short_codes" binding:"omitempty,max=1000,dive,min=1,max=64"`
+	Tag        string   `json:"tag" binding:"omitempty,max=64" example:"spring-sale"`
+}
+
+// LinkImportResponse reports the outcome of importing a bundle
+type LinkImportResponse struct {
+	Imported []string         `json:"imported"`
+	Skipped  []LinkImportSkip `json:"skipped,omitempty"`
+}
+
+// LinkImportSkip is a bundled link that was not imported, and why
+type LinkImportSkip struct {
+	ShortCode string `json:"short_code"`
+	Reason    string `json:"reason" example:"short code is taken"`
+}
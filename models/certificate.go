@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// TLSCertificate is an entry of the ACME certificate cache shared by every
+// instance: a certificate with its private key, the ACME account key or a
+// pending HTTP-01 challenge response, stored encrypted with
+// URL_ENCRYPTION_KEY
+type TLSCertificate struct {
+	Key       string    `gorm:"primaryKey"`
+	UpdatedAt time.Time `gorm:"not null"`
+	Data      string    `gorm:"not null;serializer:encrypted"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Link change events, see LinkChange
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// LinkChange is an entry of the link change feed. A database trigger on
+// urls records one in the same transaction as every insert, delete and
+// change to a link's configuration, so the feed misses no change and lists
+// them in order of their sequence number ID. Click counts and the fetched
+// metadata of the destination page are not configuration and record none.
+type LinkChange struct {
+	ID        uint64 `gorm:"primaryKey"`
+	Event     string `gorm:"not null"`
+	URLID     uint   `gorm:"not null"`
+	ShortCode string `gorm:"not null"`
+	// Short code before a rename, empty otherwise
+	PreviousShortCode string
+	RecordedAt        time.Time `gorm:"not null;index"`
+	// Transaction ID horizon when the change was recorded: once every
+	// transaction below it has finished, no change with a lower ID can
+	// still appear
+	Horizon int64 `gorm:"not null"`
+}
+
+// LinkChangeEvent is a change of the feed served by GET /changes
+type LinkChangeEvent struct {
+	// Opaque cursor of the change; pass the last one seen as since
+	Cursor    string `json:"cursor" example:"1042"`
+	Event     string `json:"event" enums:"created,updated,deleted" example:"updated"`
+	URLID     uint   `json:"url_id"`
+	ShortCode string `json:"short_code" example:"abc123"`
+	// Short code the link had before this change renamed it
+	PreviousShortCode string    `json:"previous_short_code,omitempty"`
+	ChangedAt         time.Time `json:"changed_at"`
+	// The link as it is now, when it still exists; later changes may
+	// already be reflected
+	Link *URL `json:"link,omitempty"`
+}
+
+// LinkChangesResponse is a page of the link change feed
+type LinkChangesResponse struct {
+	Changes []LinkChangeEvent `json:"changes"`
+	// Cursor to pass as since for the next page; unchanged when there were
+	// no new changes
+	NextCursor string `json:"next_cursor" example:"1042"`
+	// More changes are ready to be read right away
+	HasMore bool `json:"has_more"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// Fault injection targets
+const (
+	ChaosTargetHTTP  = "http"  // requests fail with 503 before reaching their handler
+	ChaosTargetDB    = "db"    // database queries
+	ChaosTargetCache = "cache" // Redis commands
+)
+
+// ChaosConfig is the fault injection applied when CHAOS_ENABLED is set
+type ChaosConfig struct {
+	LatencyMs int      `json:"latency_ms" binding:"min=0,max=60000"`                           // added to every affected operation
+	ErrorRate float64  `json:"error_rate" binding:"min=0,max=1"`                               // share of affected operations that fail
+	Targets   []string `json:"targets,omitempty" binding:"omitempty,dive,oneof=http db cache"` // all targets when empty
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// What the expired link cleanup does with links past their expiry, set by
+// EXPIRED_LINK_CLEANUP
+const (
+	CleanupSoftDelete = "soft"  // soft-delete them, keeping their short codes taken (default)
+	CleanupPurge      = "purge" // delete them for good, freeing their short codes
+)
+
+// ExpiredLinkCleanupReport describes one run of the expired link cleanup
+type ExpiredLinkCleanupReport struct {
+	StartedAt     time.Time `json:"started_at"`
+	DurationMs    int64     `json:"duration_ms"`
+	Mode          string    `json:"mode" example:"soft"`
+	ExpiredBefore time.Time `json:"expired_before"` // links expired before then were cleaned up
+	Links         int       `json:"links"`          // live links soft-deleted or purged
+	ArchivedLinks int       `json:"archived_links"` // archived links purged
+	Error         string    `json:"error,omitempty"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// ClickEvent records a single redirect. The click_events table is range
+// partitioned by month on clicked_at (see database/partitions.go), so its
+// primary key includes the partition column.
+type ClickEvent struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ClickedAt  time.Time `json:"clicked_at" gorm:"primaryKey;not null"`
+	URLID      uint      `json:"url_id" gorm:"not null"`
+	ShortCode  string    `json:"short_code" gorm:"not null"`
+	Referrer   string    `json:"referrer"`
+	UserAgent  string    `json:"user_agent"`
+	Country    string    `json:"country"`
+	Region     string    `json:"region"`
+	City       string    `json:"city"`
+	DeviceType string    `json:"device_type"`
+	// Version of the link's configuration the click was redirected by, see
+	// LinkVersion; 0 for clicks recorded before links were versioned
+	LinkVersion int `json:"link_version"`
+	// Salted hash of the visitor's IP address and user agent, as counted in
+	// unique visitors, so funnels can follow visitors across links; empty
+	// without Redis. Not exported.
+	VisitorHash string `json:"-"`
+}
+
+// ClickExport records an hour of click events written to object storage for
+// warehouse ingestion, so the exporter resumes after the last exported hour.
+// Events and LastEventID are the checkpoint compared against the table to
+// find clicks stored after the hour was exported.
+type ClickExport struct {
+	Hour        time.Time `json:"hour" gorm:"primaryKey"`
+	Events      int64     `json:"events"`
+	LastEventID uint      `json:"last_event_id"`
+	Objects     int       `json:"objects"`
+	Exports     int       `json:"exports"` // times the hour was written, more than 1 when late clicks were added
+	ExportedAt  time.Time `json:"exported_at"`
+}
+
+// ClickRollup counts a link's click events in one UTC hour. Rollups are
+// rebuilt from click_events in the background and answer reports spanning
+// many links without scanning their events.
+type ClickRollup struct {
+	URLID  uint      `json:"url_id" gorm:"primaryKey;autoIncrement:false"`
+	Hour   time.Time `json:"hour" gorm:"primaryKey;index"`
+	Clicks int64     `json:"clicks" gorm:"not null"`
+}
@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// Collection is a named set of a user's links, such as the links of one
+// client's campaign, shared with other users as viewers or editors and,
+// through a share link, with anyone holding its token
+type Collection struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	OwnerID   uint      `json:"owner_id" gorm:"not null;index"`
+	Name      string    `json:"name" gorm:"not null" example:"Acme spring campaign"`
+	// Short codes of the owner's links, in the order shown
+	Links []string `json:"links" gorm:"type:jsonb;serializer:json;not null" example:"spring,spring-fb"`
+	// Hash of the token of the read-only share link, nil when not shared
+	ShareTokenHash *string    `json:"-" gorm:"uniqueIndex"`
+	SharedAt       *time.Time `json:"shared_at,omitempty"` // when the current share link was created
+
+	// The caller's role, in listings
+	Role string `json:"role,omitempty" gorm:"-" example:"owner"`
+}
+
+// CollectionMember grants a user other than the owner access to a collection
+type CollectionMember struct {
+	ID           uint      `json:"-" gorm:"primaryKey"`
+	CreatedAt    time.Time `json:"created_at"`
+	CollectionID uint      `json:"-" gorm:"not null;uniqueIndex:idx_collection_members_user"`
+	UserID       uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_collection_members_user;index"`
+	Email        string    `json:"email" gorm:"-"`
+	Role         string    `json:"role" gorm:"not null" example:"viewer"`
+}
+
+// Roles on a collection, each allowed what the previous ones are
+const (
+	CollectionRoleViewer = "viewer" // sees the links and their stats
+	CollectionRoleEditor = "editor" // renames the collection and adds or removes links
+	CollectionRoleOwner  = "owner"  // shares and deletes the collection
+)
+
+// CollectionRequest creates a collection, or edits one with its fields
+// omitted kept
+type CollectionRequest struct {
+	Name *string `json:"name" binding:"omitempty,min=1,max=100" example:"Acme spring campaign"`
+	// Short codes of the owner's links, host/code on branded domains;
+	// replaces the links when editing
+	Links *[]string `json:"links" binding:"omitempty,max=500,dive,required" example:"spring,spring-fb"`
+}
+
+// CollectionMemberRequest grants a user a role on a collection, or changes
+// the role they have
+type CollectionMemberRequest struct {
+	Email string `json:"email" binding:"required,email" example:"client@acme.com"`
+	Role  string `json:"role" binding:"required,oneof=viewer editor" enums:"viewer,editor" example:"viewer"`
+}
+
+// CollectionShareResponse holds a new read-only share link; the token is
+// only shown once
+type CollectionShareResponse struct {
+	ShareURL string    `json:"share_url" example:"https://sho.rt/shared/collections/3f9a..."`
+	Token    string    `json:"token" example:"3f9a..."`
+	SharedAt time.Time `json:"shared_at"`
+}
+
+// CollectionView is a collection's links with their stats. RecentClicks
+// count the clicks since From, from the hourly click rollups.
+type CollectionView struct {
+	ID           uint             `json:"id" example:"3"`
+	Name         string           `json:"name" example:"Acme spring campaign"`
+	Role         string           `json:"role,omitempty" example:"viewer"` // the caller's, absent through a share link
+	From         time.Time        `json:"from"`
+	ClickCount   int64            `json:"click_count" example:"5400"`
+	RecentClicks int64            `json:"recent_clicks" example:"820"`
+	Links        []CollectionLink `json:"links"`
+}
+
+// CollectionLink is a link of a collection with its stats
+type CollectionLink struct {
+	ShortCode    string     `json:"short_code" example:"spring"`
+	ShortURL     string     `json:"short_url" example:"https://sho.rt/spring"`
+	OriginalURL  string     `json:"original_url" example:"https://acme.com/spring"`
+	Status       string     `json:"status" example:"active"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	ClickCount   int64      `json:"click_count" example:"4200"`
+	RecentClicks int64      `json:"recent_clicks" example:"610"`
+}
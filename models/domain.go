@@ -0,0 +1,103 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// VerifiedDomain is a destination domain whose ownership is proven with a
+// DNS TXT record or a meta tag carrying Token. Links to the domain and its
+// subdomains are marked verified once VerifiedAt is set.
+type VerifiedDomain struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Domain     string     `json:"domain" gorm:"uniqueIndex;not null"`
+	Token      string     `json:"token" gorm:"not null"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	Method     string     `json:"method,omitempty"` // dns or meta, once verified
+	// Links to the domain skip the approval required of their creator's
+	// role once it is verified, not safety or abuse reviews
+	SkipApproval bool `json:"skip_approval"`
+}
+
+// Domain verification methods
+const (
+	DomainVerifyDNS  = "dns"
+	DomainVerifyMeta = "meta"
+)
+
+// DomainRequest adds a destination domain to verify
+type DomainRequest struct {
+	Domain       string `json:"domain" binding:"required" example:"example.com"`
+	SkipApproval bool   `json:"skip_approval"`
+}
+
+// DomainUpdateRequest changes the options of a domain
+type DomainUpdateRequest struct {
+	SkipApproval *bool `json:"skip_approval" binding:"required"`
+}
+
+// DomainVerifyRequest picks how ownership is checked
+type DomainVerifyRequest struct {
+	Method string `json:"method" binding:"required,oneof=dns meta"`
+}
+
+// DomainResponse is a domain with the ways to prove its ownership
+type DomainResponse struct {
+	VerifiedDomain
+	DNSRecordName  string `json:"dns_record_name" example:"_url-shortener-verification.example.com"`
+	DNSRecordValue string `json:"dns_record_value"`
+	MetaTag        string `json:"meta_tag"`
+}
+
+// Domain is a branded host serving short links of its own, such as
+// go.acme.com. Its links resolve only on that host, so each domain has its
+// own short codes.
+type Domain struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Host string `json:"host" gorm:"uniqueIndex;not null" example:"go.acme.com"`
+	// Environment of the links served on the domain, see
+	// EnvironmentProduction
+	Environment string `json:"environment" gorm:"not null;default:production" enums:"production,staging" example:"production"`
+}
+
+// ShortDomainRequest adds a branded domain for short links
+type ShortDomainRequest struct {
+	Host        string `json:"host" binding:"required" example:"go.acme.com"`
+	Environment string `json:"environment" binding:"omitempty,oneof=production staging" enums:"production,staging" example:"staging"` // production when omitted
+}
+
+// Link environments. Staging links are campaigns under test, served on
+// staging domains only, and production links are the live ones, served on
+// production domains only.
+const (
+	EnvironmentProduction = "production"
+	EnvironmentStaging    = "staging"
+)
+
+// Environments lists the link environments
+var Environments = []string{EnvironmentProduction, EnvironmentStaging}
+
+// LinkKey identifies a link across domains: its short code on the default
+// domain, and host/code on a branded one. Links store it as their short
+// code, and caches are keyed by it, so the same code can be used on
+// several domains. Short codes never contain a slash.
+func LinkKey(host, shortCode string) string {
+	if host == "" {
+		return shortCode
+	}
+	return host + "/" + shortCode
+}
+
+// SplitLinkKey returns the branded domain, empty for the default one, and
+// the short code of a link key
+func SplitLinkKey(key string) (host, shortCode string) {
+	if i := strings.IndexByte(key, '/'); i >= 0 {
+		return key[:i], key[i+1:]
+	}
+	return "", key
+}
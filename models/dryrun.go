@@ -0,0 +1,46 @@
+package models
+
+// Rules a redirect goes through, in the order they are checked
+const (
+	TraceRuleLookup       = "lookup"        // the short code is resolved from the cache, database or archive
+	TraceRuleRenamedAlias = "renamed_alias" // the short code is an old code of a renamed link
+	TraceRuleAvailability = "availability"  // inert, pending, rejected and disabled links never redirect
+	TraceRuleExpiry       = "expiry"        // expired links answer 410
+	TraceRuleInfo         = "info"          // plaintext summary instead of a redirect
+	TraceRulePreview      = "preview"       // HTML preview page instead of a redirect
+	TraceRuleLoop         = "loop"          // destinations leading back into the service
+	TraceRulePreviewCard  = "preview_card"  // custom Open Graph card for social crawlers
+	TraceRuleRollout      = "rollout"       // soft launched links only redirect a share of visitors
+	TraceRuleRouting      = "routing"       // routing rules matching the visitor
+	TraceRuleVelocity     = "velocity"      // velocity limited links redirect a number of visitors per window
+	TraceRuleMaxClicks    = "max_clicks"    // links expiring after max_clicks redirects
+	TraceRuleVariants     = "variants"      // split links pick a variant per visitor
+	TraceRuleRedirect     = "redirect"      // the visitor is redirected
+)
+
+// RedirectTrace reports how GET /{shortCode} would answer a visitor sending
+// the same headers, without redirecting or counting a click
+type RedirectTrace struct {
+	ShortCode string `json:"short_code" example:"abc123"`
+	// Rule that decided the response
+	Rule   string `json:"rule" example:"variants"`
+	Status int    `json:"status" example:"302"`
+	// Where the visitor would be redirected
+	Location string `json:"location,omitempty" example:"https://example.com/b?utm_source=newsletter"`
+	// Error answered instead of a redirect
+	ErrorCode ErrorCode `json:"error_code,omitempty" example:"LINK_EXPIRED"`
+	// Variant picked for this visit, for split links
+	Variant string `json:"variant,omitempty" example:"B"`
+	// Every variant with its current share of traffic, for split links
+	Variants []VariantStats `json:"variants,omitempty"`
+	// Rules checked, in order
+	Steps []TraceStep `json:"steps"`
+}
+
+// TraceStep is one rule checked while resolving a redirect
+type TraceStep struct {
+	Rule string `json:"rule" example:"expiry"`
+	// Whether the rule changed the response
+	Fired  bool   `json:"fired"`
+	Detail string `json:"detail" example:"Expires at 2025-01-01T00:00:00Z"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// DuplicatesReport lists the groups of a user's links leading to the same
+// normalized destination, those with the most links first
+type DuplicatesReport struct {
+	LinksScanned int `json:"links_scanned" example:"1200"`
+	// Groups of links sharing a destination, including those past the limit
+	TotalGroups int `json:"total_groups" example:"35"`
+	// Links that are not the oldest of their group, including those past
+	// the limit; what strict deduplication would have avoided
+	DuplicateLinks int              `json:"duplicate_links" example:"48"`
+	Groups         []DuplicateGroup `json:"groups"`
+}
+
+// DuplicateGroup is a set of links whose destinations share a canonical
+// form, oldest first
+type DuplicateGroup struct {
+	// The canonical form: lowercased scheme and host, no default port,
+	// tracking parameters or trailing slash, sorted query parameters
+	Destination string          `json:"destination" example:"https://example.com/pricing"`
+	TotalClicks int64           `json:"total_clicks" example:"310"`
+	Links       []DuplicateLink `json:"links"`
+}
+
+// DuplicateLink is one link of a DuplicateGroup
+type DuplicateLink struct {
+	ShortCode   string    `json:"short_code" example:"abc123"`
+	Domain      string    `json:"domain,omitempty" example:"go.acme.com"` // branded domain serving the link
+	OriginalURL string    `json:"original_url" example:"https://Example.com/pricing/?utm_source=x"`
+	ClickCount  int       `json:"click_count" example:"300"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,134 @@
+package models
+
+import "net/http"
+
+// ErrorCode is a stable, machine-readable identifier returned as "code" in
+// every error payload. Messages may change; codes do not.
+type ErrorCode string
+
+const (
+	ErrCodeInvalidRequest    ErrorCode = "INVALID_REQUEST"
+	ErrCodeURLInvalid        ErrorCode = "URL_INVALID"
+	ErrCodeURLBlocked        ErrorCode = "URL_BLOCKED"
+	ErrCodeURLUnsafe         ErrorCode = "URL_UNSAFE"
+	ErrCodeURLExists         ErrorCode = "URL_EXISTS"
+	ErrCodeAliasTaken        ErrorCode = "ALIAS_TAKEN"
+	ErrCodeLinkNotFound      ErrorCode = "LINK_NOT_FOUND"
+	ErrCodeLinkExpired       ErrorCode = "LINK_EXPIRED"
+	ErrCodeLinkPending       ErrorCode = "LINK_PENDING"
+	ErrCodeLinkDisabled      ErrorCode = "LINK_DISABLED"
+	ErrCodeLinkLoop          ErrorCode = "LINK_LOOP"
+	ErrCodeLinkNotLaunched   ErrorCode = "LINK_NOT_LAUNCHED"
+	ErrCodeLinkBusy          ErrorCode = "LINK_BUSY"
+	ErrCodeCaptchaFailed     ErrorCode = "CAPTCHA_FAILED"
+	ErrCodeUnauthorized      ErrorCode = "UNAUTHORIZED"
+	ErrCodeTwoFactorRequired ErrorCode = "TWO_FACTOR_REQUIRED"
+	ErrCodeTwoFactorInvalid  ErrorCode = "TWO_FACTOR_INVALID"
+	ErrCodeForbidden         ErrorCode = "FORBIDDEN"
+	ErrCodeScopeMissing      ErrorCode = "SCOPE_MISSING"
+	ErrCodeNotFound          ErrorCode = "NOT_FOUND"
+	ErrCodeConflict          ErrorCode = "CONFLICT"
+	ErrCodeCursorExpired     ErrorCode = "CURSOR_EXPIRED"
+	ErrCodeRevisionMismatch  ErrorCode = "REVISION_MISMATCH"
+	ErrCodeDomainUnverified  ErrorCode = "DOMAIN_VERIFICATION_FAILED"
+	ErrCodeRateLimited       ErrorCode = "RATE_LIMITED"
+	ErrCodeAbuseRestricted   ErrorCode = "ABUSE_RESTRICTED"
+	ErrCodeQuotaExceeded     ErrorCode = "QUOTA_EXCEEDED"
+	ErrCodeTimeout           ErrorCode = "TIMEOUT"
+	ErrCodeUnavailable       ErrorCode = "SERVICE_UNAVAILABLE"
+	ErrCodeInternal          ErrorCode = "INTERNAL_ERROR"
+)
+
+// ErrorCodeInfo describes an error code in the catalog
+type ErrorCodeInfo struct {
+	Code        ErrorCode `json:"code"`
+	Status      int       `json:"status"`
+	Description string    `json:"description"`
+}
+
+// ErrorCatalog lists every error code the API returns with its usual status
+var ErrorCatalog = []ErrorCodeInfo{
+	{ErrCodeInvalidRequest, http.StatusBadRequest, "The request body or parameters failed validation"},
+	{ErrCodeURLInvalid, http.StatusBadRequest, "The URL to shorten is not a valid http(s) URL"},
+	{ErrCodeURLBlocked, http.StatusBadRequest, "The URL is blocked by a brand safety rule"},
+	{ErrCodeURLUnsafe, http.StatusBadRequest, "The URL points to a private network address or is flagged as malicious by Safe Browsing"},
+	{ErrCodeURLExists, http.StatusConflict, "The URL was already shortened and if_exists is error; short_code holds the existing link"},
+	{ErrCodeAliasTaken, http.StatusConflict, "The custom alias is already used by another short URL"},
+	{ErrCodeLinkNotFound, http.StatusNotFound, "No short URL exists for the short code"},
+	{ErrCodeLinkExpired, http.StatusGone, "The short URL has expired"},
+	{ErrCodeLinkPending, http.StatusForbidden, "The short URL is waiting for approval"},
+	{ErrCodeLinkDisabled, http.StatusGone, "The short URL was disabled by an admin"},
+	{ErrCodeLinkLoop, http.StatusLoopDetected, "The short URL redirects to short URLs of this service that lead back to it"},
+	{ErrCodeLinkNotLaunched, http.StatusServiceUnavailable, "The short URL is soft launched and the visitor is not in its rollout_percent yet"},
+	{ErrCodeLinkBusy, http.StatusServiceUnavailable, "The short URL is receiving more clicks than its velocity_limit allows; retry after the Retry-After header's seconds"},
+	{ErrCodeCaptchaFailed, http.StatusForbidden, "The CAPTCHA token is missing or failed verification"},
+	{ErrCodeUnauthorized, http.StatusUnauthorized, "Credentials are missing, invalid or expired"},
+	{ErrCodeTwoFactorRequired, http.StatusUnauthorized, "A two-factor code is required, or the account must enable two-factor authentication"},
+	{ErrCodeTwoFactorInvalid, http.StatusUnauthorized, "The two-factor code is invalid"},
+	{ErrCodeForbidden, http.StatusForbidden, "The caller may not perform this action"},
+	{ErrCodeScopeMissing, http.StatusForbidden, "The API key lacks the scope the route requires"},
+	{ErrCodeNotFound, http.StatusNotFound, "The requested resource does not exist"},
+	{ErrCodeConflict, http.StatusConflict, "The resource already exists or is in a conflicting state"},
+	{ErrCodeCursorExpired, http.StatusGone, "The change feed cursor is older than the retained changes; rescan and start from a new cursor"},
+	{ErrCodeRevisionMismatch, http.StatusPreconditionFailed, "The If-Match header does not match the current revision of the link, which was changed since it was read; ETag holds the current one"},
+	{ErrCodeDomainUnverified, http.StatusUnprocessableEntity, "The domain verification token was not found, or the domain could not be checked"},
+	{ErrCodeRateLimited, http.StatusTooManyRequests, "The client sent too many requests; retry after the Retry-After header's seconds"},
+	{ErrCodeAbuseRestricted, http.StatusForbidden, "The feature is disabled for the creator until its abuse score decays or an admin overrides its level"},
+	{ErrCodeQuotaExceeded, http.StatusTooManyRequests, "The user created as many links this month as their plan allows; creation resumes on the first of next month (UTC)"},
+	{ErrCodeTimeout, http.StatusGatewayTimeout, "The request did not finish within its timeout"},
+	{ErrCodeUnavailable, http.StatusServiceUnavailable, "A dependency needed for the request is unavailable"},
+	{ErrCodeInternal, http.StatusInternalServerError, "An unexpected server error occurred"},
+}
+
+// ErrorResponse is the payload of every error response
+type ErrorResponse struct {
+	Error             string    `json:"error" validate:"required" example:"Short URL not found"`
+	Code              ErrorCode `json:"code" validate:"required" example:"LINK_NOT_FOUND"`
+	ShortCode         string    `json:"short_code,omitempty"`
+	TwoFactorRequired bool      `json:"two_factor_required,omitempty"`
+}
+
+// APIError is an error with the status and code to respond with. Handlers
+// attach it with c.Error and the error middleware writes the response.
+type APIError struct {
+	Status            int
+	Code              ErrorCode
+	Message           string
+	ShortCode         string
+	TwoFactorRequired bool
+}
+
+// NewAPIError creates an APIError
+func NewAPIError(status int, code ErrorCode, message string) *APIError {
+	return &APIError{Status: status, Code: code, Message: message}
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Response returns the payload for the error
+func (e *APIError) Response() ErrorResponse {
+	return ErrorResponse{
+		Error:             e.Message,
+		Code:              e.Code,
+		ShortCode:         e.ShortCode,
+		TwoFactorRequired: e.TwoFactorRequired,
+	}
+}
+
+// Errors shared by several handlers
+var (
+	ErrURLInvalid      = NewAPIError(http.StatusBadRequest, ErrCodeURLInvalid, "Invalid URL format")
+	ErrAliasTaken      = NewAPIError(http.StatusConflict, ErrCodeAliasTaken, "Custom alias is already taken")
+	ErrLinkNotFound    = NewAPIError(http.StatusNotFound, ErrCodeLinkNotFound, "Short URL not found")
+	ErrLinkExpired     = NewAPIError(http.StatusGone, ErrCodeLinkExpired, "Short URL has expired")
+	ErrLinkPending     = NewAPIError(http.StatusForbidden, ErrCodeLinkPending, "Short URL is pending approval")
+	ErrLinkDisabled    = NewAPIError(http.StatusGone, ErrCodeLinkDisabled, "Short URL has been disabled")
+	ErrLinkLoop        = NewAPIError(http.StatusLoopDetected, ErrCodeLinkLoop, "Short URL redirects in a loop")
+	ErrLinkNotLaunched = NewAPIError(http.StatusServiceUnavailable, ErrCodeLinkNotLaunched, "Short URL is not launched for this visitor yet")
+	ErrLinkBusy        = NewAPIError(http.StatusServiceUnavailable, ErrCodeLinkBusy, "Short URL is receiving too many clicks, retry shortly")
+	ErrTimeout         = NewAPIError(http.StatusGatewayTimeout, ErrCodeTimeout, "Request timed out")
+	ErrUnavailable     = NewAPIError(http.StatusServiceUnavailable, ErrCodeUnavailable, "Service temporarily unavailable")
+	ErrInternal        = NewAPIError(http.StatusInternalServerError, ErrCodeInternal, "Internal server error")
+)
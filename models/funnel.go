@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Funnel is an ordered list of a user's links, such as a teaser, a landing
+// page and a signup link, whose report counts the visitors clicking each of
+// them in order
+type Funnel struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	OwnerID   uint      `json:"-" gorm:"not null;index"`
+	Name      string    `json:"name" gorm:"not null" example:"Spring launch"`
+	Steps     []string  `json:"steps" gorm:"type:jsonb;serializer:json;not null" example:"teaser,launch,signup"` // short codes, in order
+}
+
+// FunnelRequest declares a funnel
+type FunnelRequest struct {
+	Name  string   `json:"name" binding:"required,max=100" example:"Spring launch"`
+	Steps []string `json:"steps" binding:"required,min=2,max=10,dive,required" example:"teaser,launch,signup"`
+}
+
+// FunnelReport counts the unique visitors who went through each step of a
+// funnel between From and To
+type FunnelReport struct {
+	ID    uint         `json:"id" example:"3"`
+	Name  string       `json:"name" example:"Spring launch"`
+	From  time.Time    `json:"from"`
+	To    time.Time    `json:"to"`
+	Steps []FunnelStep `json:"steps"`
+}
+
+// FunnelStep is the number of visitors who clicked a funnel's link after
+// clicking those of every previous step
+type FunnelStep struct {
+	ShortCode string `json:"short_code" example:"launch"`
+	Visitors  int64  `json:"visitors" example:"240"`
+	// Percentage of the visitors of the previous step, or of the first step
+	// for conversion, rounded to a tenth; null when that step had none
+	StepRate   *float64 `json:"step_rate" example:"40"`
+	Conversion *float64 `json:"conversion" example:"40"`
+}
@@ -0,0 +1,116 @@
+package models
+
+import "time"
+
+// Health statuses
+const (
+	HealthHealthy   = "healthy"   // all services operational
+	HealthDegraded  = "degraded"  // cache unavailable or a background job is overdue
+	HealthUnhealthy = "unhealthy" // database unavailable
+)
+
+// HealthResponse is returned by the health check
+type HealthResponse struct {
+	Status        string           `json:"status" example:"healthy"`
+	Timestamp     time.Time        `json:"timestamp"`
+	Service       string           `json:"service" example:"url-shortener"`
+	Version       string           `json:"version" example:"1.4.0"`
+	Commit        string           `json:"commit,omitempty"`
+	Instance      string           `json:"instance" example:"url-shortener-7d9f8-abcde"`
+	Uptime        string           `json:"uptime" example:"72h3m0s"`
+	UptimeSeconds int64            `json:"uptime_seconds"`
+	Database      DependencyHealth `json:"database"`
+	Cache         DependencyHealth `json:"cache"`
+
+	// Admin-only details
+	Migrations *MigrationStatus  `json:"migrations,omitempty"`
+	Jobs       []JobHeartbeat    `json:"jobs,omitempty"`
+	ClickQueue *ClickQueueStatus `json:"click_queue,omitempty"`
+	DBPool     *DBPoolStatus     `json:"db_pool,omitempty"`
+	Runtime    *RuntimeStatus    `json:"runtime,omitempty"`
+	LocalCache *LocalCacheStatus `json:"local_cache,omitempty"`
+	LinkTable  *LinkTableStatus  `json:"link_table,omitempty"`
+	Integrity  *IntegrityStatus  `json:"integrity,omitempty"`
+}
+
+// DependencyHealth reports a dependency's reachability and round-trip latency
+type DependencyHealth struct {
+	Healthy   bool    `json:"healthy"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// MigrationStatus reports the schema migration run at startup
+type MigrationStatus struct {
+	CompletedAt   time.Time `json:"completed_at"`
+	DurationMs    int64     `json:"duration_ms"`
+	MissingTables []string  `json:"missing_tables,omitempty"`
+}
+
+// JobHeartbeat reports when a background job last ran
+type JobHeartbeat struct {
+	Name     string    `json:"name" example:"stale_api_keys"`
+	LastRun  time.Time `json:"last_run"`
+	Interval string    `json:"interval" example:"1h0m0s"`
+	Stale    bool      `json:"stale"`
+}
+
+// ClickQueueStatus reports the backlog of redirect clicks waiting to be counted
+type ClickQueueStatus struct {
+	Depth    int   `json:"depth"`
+	Capacity int   `json:"capacity"`
+	Dropped  int64 `json:"dropped"`
+	// Clicks counted without their click event during spikes over
+	// CLICK_EVENT_SHED_RATE
+	Shed int64 `json:"shed"`
+}
+
+// DBPoolStatus reports database connection pool usage
+type DBPoolStatus struct {
+	Open      int   `json:"open"`
+	InUse     int   `json:"in_use"`
+	Idle      int   `json:"idle"`
+	WaitCount int64 `json:"wait_count"`
+}
+
+// LocalCacheStatus reports the in-process cache in front of Redis. Hits and
+// misses count lookups since the instance started.
+type LocalCacheStatus struct {
+	Enabled   bool    `json:"enabled"`
+	Size      int     `json:"size"`
+	Capacity  int     `json:"capacity"`
+	TTL       string  `json:"ttl,omitempty" example:"5s"`
+	Hits      int64   `json:"hits"`
+	Misses    int64   `json:"misses"`
+	HitRate   float64 `json:"hit_rate" example:"0.93"`
+	Evictions int64   `json:"evictions"`
+}
+
+// LinkTableStatus reports the in-memory table of every link's redirect
+// entry, when LINK_TABLE_ENABLED is on. Hits and misses count redirect
+// lookups since the instance started.
+type LinkTableStatus struct {
+	Enabled     bool      `json:"enabled"`
+	Loaded      bool      `json:"loaded"`
+	Links       int       `json:"links"`
+	LastRefresh time.Time `json:"last_refresh"`
+	LastRebuild time.Time `json:"last_rebuild"`
+	Hits        int64     `json:"hits"`
+	Misses      int64     `json:"misses"`
+	HitRate     float64   `json:"hit_rate" example:"0.99"`
+}
+
+// RuntimeStatus reports Go runtime details
+type RuntimeStatus struct {
+	GoVersion  string `json:"go_version"`
+	Goroutines int    `json:"goroutines"`
+}
+
+// VersionResponse describes the running build
+type VersionResponse struct {
+	Version   string          `json:"version" example:"v1.4.0"`
+	Commit    string          `json:"commit,omitempty"`
+	BuildDate string          `json:"build_date,omitempty" example:"2024-01-15T10:30:00Z"`
+	GoVersion string          `json:"go_version" example:"go1.23.0"`
+	Features  map[string]bool `json:"features"`
+}
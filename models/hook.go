@@ -0,0 +1,105 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// HookSubscription is a REST Hooks subscription (as used by Zapier and
+// IFTTT): link events are POSTed as JSON to TargetURL until unsubscribed
+type HookSubscription struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	Event     string    `json:"event" gorm:"not null;index"`
+	TargetURL string    `json:"target_url" gorm:"not null"`
+	APIKeyID  *uint     `json:"api_key_id,omitempty"` // key that subscribed, if any
+	// Deliveries are signed with this secret; subscriptions created before
+	// signing was introduced have none and receive unsigned deliveries
+	Secret string `json:"-" gorm:"not null;default:''"`
+	// Checkpoint: the latest event the target acknowledged and when it
+	// occurred. Events before it were delivered or are dead letters.
+	LastDeliveryID string     `json:"last_delivery_id,omitempty" gorm:"not null;default:''"`
+	LastEventAt    *time.Time `json:"last_event_at,omitempty"`
+}
+
+// HookSubscriptionCreatedResponse is returned once when subscribing, the only
+// time the signing secret is shown
+type HookSubscriptionCreatedResponse struct {
+	HookSubscription
+	Secret string `json:"secret"`
+}
+
+// Hook events
+const (
+	HookLinkCreated  = "link.created"
+	HookLinkApproved = "link.approved"
+	HookLinkRejected = "link.rejected"
+	HookLinkUpdated  = "link.updated"
+	HookLinkDeleted  = "link.deleted"
+)
+
+// HookTrigger describes an event that can be subscribed to
+type HookTrigger struct {
+	Event       string `json:"event"`
+	Description string `json:"description"`
+}
+
+// HookTriggers lists the events available to REST Hooks subscribers
+var HookTriggers = []HookTrigger{
+	{Event: HookLinkCreated, Description: "A new short link was created"},
+	{Event: HookLinkApproved, Description: "A pending short link was approved and now redirects"},
+	{Event: HookLinkRejected, Description: "A pending short link was rejected"},
+	{Event: HookLinkUpdated, Description: "A short link was edited by its owner"},
+	{Event: HookLinkDeleted, Description: "A short link was deleted by its owner"},
+}
+
+// HookLinkPayload is delivered for link events. It is flat and carries a
+// unique id, as Zapier expects for deduplication.
+type HookLinkPayload struct {
+	ID          uint      `json:"id"`
+	Event       string    `json:"event"`
+	ShortCode   string    `json:"short_code"`
+	ShortURL    string    `json:"short_url"`
+	OriginalURL string    `json:"original_url"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type SubscribeHookRequest struct {
+	TargetURL string `json:"target_url" binding:"required,url"`
+	Event     string `json:"event" binding:"required,oneof=link.created link.approved link.rejected"`
+}
+
+// Hook delivery statuses
+const (
+	DeliveryPending   = "pending"   // waiting for its first or next attempt
+	DeliveryDelivered = "delivered" // acknowledged with a 2xx response
+	DeliveryDead      = "dead"      // retries exhausted or unsubscribed; kept for redrive
+)
+
+// HookDelivery tracks one event sent to one subscription or webhook. It is
+// stored before the first attempt and keeps its DeliveryID across retries
+// and redrives, so subscribers can discard duplicates and process each event
+// exactly once.
+type HookDelivery struct {
+	ID             uint            `json:"id" gorm:"primaryKey"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeliveryID     string          `json:"delivery_id" gorm:"uniqueIndex;not null"`
+	SubscriptionID uint            `json:"subscription_id" gorm:"not null;index"` // 0 for webhook deliveries
+	WebhookID      *uint           `json:"webhook_id,omitempty" gorm:"index"`
+	Event          string          `json:"event" gorm:"not null"`
+	TargetURL      string          `json:"target_url" gorm:"not null"`
+	Payload        json.RawMessage `json:"payload" gorm:"type:jsonb;not null" swaggertype:"object"`
+	Status         string          `json:"status" gorm:"not null;index:idx_hook_deliveries_due,priority:1"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty" gorm:"index:idx_hook_deliveries_due,priority:2"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// RedriveHookDeliveriesResponse reports how many dead deliveries were queued again
+type RedriveHookDeliveriesResponse struct {
+	Redriven int64 `json:"redriven"`
+}
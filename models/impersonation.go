@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Impersonation lets an admin act as a user for a limited time, for support
+// debugging, through a token standing in for one of the user's API keys.
+// Starting, ending and every request made with it are audit logged.
+type Impersonation struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	Actor     string     `json:"actor" example:"admin"` // the admin credential that started it, admin or api_key:<id>
+	Reason    string     `json:"reason" gorm:"not null" example:"Ticket #4821: links missing from dashboard"`
+	Scopes    []string   `json:"scopes" gorm:"serializer:json" example:"read_stats"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"` // when an admin ended it early
+}
+
+// Active reports whether the impersonation can still be used at now
+func (i *Impersonation) Active(now time.Time) bool {
+	return i.EndedAt == nil && now.Before(i.ExpiresAt)
+}
+
+// ImpersonationRequest starts impersonating a user
+type ImpersonationRequest struct {
+	Reason string `json:"reason" binding:"required,max=500" example:"Ticket #4821: links missing from dashboard"`
+	// How long the token is valid, 1 to 240 minutes (default 30)
+	Minutes int `json:"minutes" binding:"omitempty,min=1,max=240" example:"30"`
+	// What the token may do as the user (default read_stats only)
+	Scopes []string `json:"scopes" binding:"omitempty,dive,oneof=create read_stats update delete" example:"read_stats"`
+}
+
+// ImpersonationResponse is the only response that includes the token
+type ImpersonationResponse struct {
+	Impersonation
+	Token string `json:"token" example:"usi_3f9a..."`
+}
+
+// ImpersonationBanner is added to the JSON object responses of requests
+// made while impersonating, as the impersonation field
+type ImpersonationBanner struct {
+	ID        uint      `json:"id" example:"12"`
+	UserID    uint      `json:"user_id" example:"7"`
+	Actor     string    `json:"actor" example:"admin"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Message   string    `json:"message" example:"An admin is acting as user 7 until 2024-06-03T09:30:00Z"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Invariants checked by the integrity verifier
+const (
+	// Every short code, live, deleted or archived, names a single link
+	IntegrityDuplicateShortCodes = "duplicate_short_codes"
+	// Expired links are cleaned up once EXPIRED_LINK_RETENTION has passed
+	IntegrityExpiredLinks = "expired_links_not_cleaned_up"
+	// Renamed aliases point to a link, live, deleted or archived
+	IntegrityOrphanedAliases = "orphaned_aliases"
+	// Cached redirects of a sample of links match the database
+	IntegrityCacheDrift = "cache_drift"
+)
+
+// What started an integrity verification
+const (
+	IntegrityTriggerStartup   = "startup"
+	IntegrityTriggerScheduled = "scheduled"
+	IntegrityTriggerAdmin     = "admin"
+)
+
+// Offending values reported per integrity check
+const IntegritySamples = 10
+
+// IntegrityCheck is the outcome of checking one invariant
+type IntegrityCheck struct {
+	Name       string `json:"name" example:"orphaned_aliases"`
+	Checked    int64  `json:"checked"` // rows or links examined
+	Violations int64  `json:"violations"`
+	// Up to IntegritySamples of the offending short codes or aliases
+	Samples []string `json:"samples,omitempty" example:"spring"`
+	Skipped string   `json:"skipped,omitempty"` // why the check did not run
+	Error   string   `json:"error,omitempty"`
+}
+
+// IntegrityReport is the outcome of one integrity verification. Findings
+// are reported, never repaired.
+type IntegrityReport struct {
+	StartedAt  time.Time        `json:"started_at"`
+	DurationMs int64            `json:"duration_ms"`
+	Trigger    string           `json:"trigger" example:"startup"`
+	Violations int64            `json:"violations"`
+	Checks     []IntegrityCheck `json:"checks"`
+}
+
+// IntegrityStatus reports the last integrity verification and totals since
+// the server started
+type IntegrityStatus struct {
+	LastRun            *IntegrityReport `json:"last_run,omitempty"`
+	Runs               int64            `json:"runs"`
+	RunsWithViolations int64            `json:"runs_with_violations"`
+	TotalViolations    int64            `json:"total_violations"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// MirroredRedirect is the metadata of a sampled redirect sent to the
+// shadow backend. It carries no client addresses, and the destination only
+// as a hash.
+type MirroredRedirect struct {
+	ShortCode       string    `json:"short_code"`
+	Status          int       `json:"status"`
+	DestinationHash string    `json:"destination_hash,omitempty"` // SHA-256 of the Location header
+	ErrorCode       ErrorCode `json:"error_code,omitempty"`
+	LatencyMicros   int64     `json:"latency_us"`
+	UserAgent       string    `json:"user_agent,omitempty"`
+	ObservedAt      time.Time `json:"observed_at"`
+}
+
+// MirrorStatus reports traffic mirroring on this instance
+type MirrorStatus struct {
+	Enabled  bool    `json:"enabled"`
+	Percent  float64 `json:"percent"`
+	Target   string  `json:"target,omitempty"` // host of MIRROR_URL
+	Shadow   string  `json:"shadow,omitempty"` // MIRROR_SHADOW resolver
+	Mirrored int64   `json:"mirrored"`         // redirects sampled and queued
+	Dropped  int64   `json:"dropped"`          // sampled redirects dropped because the queue was full
+	Sent     int64   `json:"sent"`             // redirects delivered to MIRROR_URL
+	Failed   int64   `json:"failed"`           // redirects MIRROR_URL did not accept
+	Compared int64   `json:"compared"`         // redirects replayed against the shadow resolver
+	Mismatch int64   `json:"mismatch"`         // replays whose status or destination differed
+}
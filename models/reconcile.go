@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// ClickReconciliationReport describes a run of the click count
+// reconciliation job, which compares each recently clicked link's
+// urls.click_count with its recorded click events and cached counter
+type ClickReconciliationReport struct {
+	StartedAt    time.Time `json:"started_at"`
+	DurationMs   int64     `json:"duration_ms"`
+	Since        time.Time `json:"since"` // links updated since then were checked
+	LinksChecked int       `json:"links_checked"`
+	// click_count was below the click events or cached counter and was raised
+	DatabaseBehind int `json:"database_behind"`
+	// The cached counter differed from the database and was invalidated
+	CacheMismatched int `json:"cache_mismatched"`
+	// click_count exceeds the link's recorded click events; expected for
+	// clicks older than CLICK_EVENT_RETENTION, so it is reported but not repaired
+	EventsMissing int    `json:"events_missing"`
+	Error         string `json:"error,omitempty"`
+}
+
+// ClickReconciliationStatus reports the last reconciliation run and totals
+// since the server started
+type ClickReconciliationStatus struct {
+	LastRun              *ClickReconciliationReport `json:"last_run,omitempty"`
+	Runs                 int64                      `json:"runs"`
+	TotalDatabaseBehind  int64                      `json:"total_database_behind"`
+	TotalCacheMismatched int64                      `json:"total_cache_mismatched"`
+	TotalEventsMissing   int64                      `json:"total_events_missing"`
+}
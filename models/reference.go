@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// LinkReference attaches an external document, such as the Jira ticket or
+// design a link was made for, to a link, so the context of why it exists
+// travels with it
+type LinkReference struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	URLID  uint   `json:"-" gorm:"not null;index"`
+	Kind   string `json:"kind" gorm:"not null" example:"jira"`
+	URL    string `json:"url" gorm:"not null" example:"https://acme.atlassian.net/browse/MKT-142"`
+	Title  string `json:"title,omitempty" example:"Spring campaign launch"`
+	Author string `json:"author" example:"user:7"` // the owner as user:<id>
+}
+
+// Kinds of link references
+const (
+	ReferenceKindJira      = "jira"
+	ReferenceKindGoogleDoc = "google_doc"
+	ReferenceKindFigma     = "figma"
+	ReferenceKindGitHub    = "github"
+	ReferenceKindNotion    = "notion"
+	ReferenceKindLink      = "link" // any other page
+)
+
+// ReferenceRequest attaches an external reference to a link, or edits one
+// with its kind and title kept when omitted
+type ReferenceRequest struct {
+	URL string `json:"url" binding:"required,url,max=2048" example:"https://acme.atlassian.net/browse/MKT-142"`
+	// Detected from the URL's host when omitted
+	Kind  string `json:"kind" binding:"omitempty,oneof=jira google_doc figma github notion link" enums:"jira,google_doc,figma,github,notion,link" example:"jira"`
+	Title string `json:"title" binding:"max=200" example:"Spring campaign launch"`
+}
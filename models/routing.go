@@ -0,0 +1,50 @@
+package models
+
+// RoutingRule sends visitors matching all of its conditions somewhere other
+// than the link's destination. A link's rules are checked in order and the
+// first matching one decides; visitors matching none follow the link as
+// usual. See GET /routing/schema for the JSON schema of a rule set.
+type RoutingRule struct {
+	Name       string             `json:"name,omitempty" example:"mobile-us"`
+	Conditions []RoutingCondition `json:"conditions"`
+	Action     RoutingAction      `json:"action"`
+}
+
+// RoutingCondition compares one attribute of a visit with Values
+type RoutingCondition struct {
+	Field  string   `json:"field" enums:"country,device,os,language,weekday,hour,time" example:"country"`
+	Op     string   `json:"op" enums:"in,not_in,before,after" example:"in"`
+	Values []string `json:"values" example:"US,CA"`
+}
+
+// RoutingAction is what a matching rule does with the visit
+type RoutingAction struct {
+	Type string `json:"type" enums:"redirect,destination,deny" example:"redirect"`
+	URL  string `json:"url,omitempty" example:"https://example.com/us"` // for redirect only
+}
+
+// Visit attributes routing conditions compare
+const (
+	RoutingFieldCountry  = "country"  // ISO 3166-1 alpha-2 code from GEO_HEADERS, e.g. US
+	RoutingFieldDevice   = "device"   // bot, tablet, mobile or desktop, from the User-Agent
+	RoutingFieldOS       = "os"       // ios, android, windows, macos or linux, from the User-Agent
+	RoutingFieldLanguage = "language" // primary subtag of the first Accept-Language entry, e.g. fr
+	RoutingFieldWeekday  = "weekday"  // mon to sun, in UTC
+	RoutingFieldHour     = "hour"     // 0 to 23, in UTC
+	RoutingFieldTime     = "time"     // the time of the visit, compared with an RFC 3339 timestamp
+)
+
+// Routing condition operators
+const (
+	RoutingOpIn     = "in"     // the attribute is one of the values
+	RoutingOpNotIn  = "not_in" // the attribute is none of the values, or unknown
+	RoutingOpBefore = "before" // the visit happens before the single time value
+	RoutingOpAfter  = "after"  // the visit happens at or after the single time value
+)
+
+// Routing rule actions
+const (
+	RoutingActionRedirect    = "redirect"    // redirect to the action's URL
+	RoutingActionDestination = "destination" // follow the link as usual, skipping later rules
+	RoutingActionDeny        = "deny"        // answer as if the link did not exist
+)
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// SafetyRule is a brand safety rule evaluated against destination URLs at shorten time
+type SafetyRule struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Type        string `json:"type" gorm:"not null"` // regex, domain or keyword
+	Pattern     string `json:"pattern" gorm:"not null"`
+	Action      string `json:"action" gorm:"not null"` // allow, deny or review
+	Priority    int    `json:"priority" gorm:"default:0"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled" gorm:"default:true"`
+}
+
+// Safety rule types
+const (
+	SafetyRuleRegex   = "regex"   // Go regular expression matched against the full URL
+	SafetyRuleDomain  = "domain"  // host or any of its subdomains
+	SafetyRuleKeyword = "keyword" // case-insensitive substring of the URL
+)
+
+// Safety rule actions
+const (
+	SafetyActionAllow  = "allow"
+	SafetyActionDeny   = "deny"
+	SafetyActionReview = "review" // link is created pending admin approval
+)
+
+type SafetyRuleRequest struct {
+	Type        string `json:"type" binding:"required,oneof=regex domain keyword"`
+	Pattern     string `json:"pattern" binding:"required"`
+	Action      string `json:"action" binding:"required,oneof=allow deny review"`
+	Priority    int    `json:"priority"`
+	Description string `json:"description"`
+	Enabled     *bool  `json:"enabled"` // defaults to true
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ShadowBan marks a creator whose links are silently made inert
+type ShadowBan struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	IPAddress string `json:"ip_address" gorm:"uniqueIndex;not null"`
+	Reason    string `json:"reason"`
+}
+
+type ShadowBanRequest struct {
+	IPAddress string `json:"ip_address" binding:"required,ip"`
+	Reason    string `json:"reason"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// LinkStatsReset keeps the counters of a link from before its stats were
+// reset, covering PeriodStart until the reset, for campaigns reusing a short
+// code for a new push
+type LinkStatsReset struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"reset_at"`
+
+	URLID       uint            `json:"-" gorm:"not null;index"`
+	ShortCode   string          `json:"short_code" example:"promo2024"`
+	Actor       string          `json:"actor" example:"user:7"` // admin, or the owner as user:<id>
+	PeriodStart time.Time       `json:"period_start"`           // creation or the previous reset
+	ClickCount  int64           `json:"click_count" example:"4200"`
+	Landings    *int            `json:"landings,omitempty" example:"3600"` // for links tracking landings
+	Variants    []VariantTotals `json:"variants,omitempty" gorm:"type:jsonb;serializer:json"`
+}
+
+// VariantTotals are the counters of a split link variant at a reset
+type VariantTotals struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Clicks      int64  `json:"clicks"`
+	Conversions int64  `json:"conversions"`
+}
+
+// StatsResetsResponse lists the stats periods of a link, latest first
+type StatsResetsResponse struct {
+	ShortCode string           `json:"short_code" example:"promo2024"`
+	Resets    []LinkStatsReset `json:"resets"`
+}
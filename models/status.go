@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Public status page statuses
+const (
+	StatusOperational = "operational" // every endpoint meets its availability target
+	StatusDegraded    = "degraded"    // an endpoint served too many server errors in the last hour
+)
+
+// Scopes of reported metrics
+const (
+	MetricsScopeInstance = "instance" // the answering instance only
+	MetricsScopeFleet    = "fleet"    // every instance publishing to Redis
+)
+
+// StatusResponse summarises availability and latency per endpoint for a
+// public status page. Figures cover the answering instance, or every
+// instance in the fleet scope, since it started, up to the longest window.
+type StatusResponse struct {
+	Status      string           `json:"status" example:"operational"`
+	Scope       string           `json:"scope" example:"instance"`
+	Instance    string           `json:"instance" example:"url-shortener-7d9f8-abcde"`
+	Instances   []string         `json:"instances,omitempty"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Since       time.Time        `json:"since"`
+	Endpoints   []EndpointStatus `json:"endpoints"`
+}
+
+// EndpointStatus reports one route over each rolling window
+type EndpointStatus struct {
+	Method  string         `json:"method" example:"GET"`
+	Route   string         `json:"route" example:"/:shortCode"`
+	Windows []StatusWindow `json:"windows"`
+}
+
+// StatusWindow aggregates the requests to an endpoint within a rolling window.
+// Availability is the percentage of requests not answered with a 5xx status.
+// Latency percentiles are upper bounds of histogram buckets.
+type StatusWindow struct {
+	Window       string  `json:"window" example:"24h"`
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`
+	Availability float64 `json:"availability" example:"99.95"`
+	LatencyP50Ms float64 `json:"latency_p50_ms" example:"10"`
+	LatencyP95Ms float64 `json:"latency_p95_ms" example:"50"`
+	LatencyP99Ms float64 `json:"latency_p99_ms" example:"250"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// TagRule adds a tag to the new links whose destination matches it, such as
+// "store" to every link to a *.shopify.com shop. Existing links get it when
+// a tag rule backfill runs.
+type TagRule struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Type        string `json:"type" gorm:"not null"` // domain or regex
+	Pattern     string `json:"pattern" gorm:"not null" example:"*.shopify.com"`
+	Tag         string `json:"tag" gorm:"not null" example:"store"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled" gorm:"default:true"`
+}
+
+// Tag rule types
+const (
+	TagRuleDomain = "domain" // host or any of its subdomains; *.host for its subdomains only
+	TagRuleRegex  = "regex"  // Go regular expression matched against the full URL
+)
+
+type TagRuleRequest struct {
+	Type        string `json:"type" binding:"required,oneof=domain regex"`
+	Pattern     string `json:"pattern" binding:"required" example:"*.shopify.com"`
+	Tag         string `json:"tag" binding:"required,max=64" example:"store"`
+	Description string `json:"description"`
+	Enabled     *bool  `json:"enabled"` // defaults to true
+}
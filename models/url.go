@@ -1,6 +1,9 @@
 package models
 
 import (
+	"encoding/json"
+	"errors"
+	"net/url"
 	"time"
 
 	"gorm.io/gorm"
@@ -12,22 +15,308 @@ type URL struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 
-	OriginalURL string     `json:"original_url" gorm:"not null"`
-	ShortCode   string     `json:"short_code" gorm:"uniqueIndex;not null"`
-	ClickCount  int        `json:"click_count" gorm:"default:0"`
-	ExpiresAt   *time.Time `json:"expires_at"`
+	OriginalURL string `json:"original_url" gorm:"not null;serializer:encrypted"` // encrypted when URL_ENCRYPTION_KEY is set
+	// SHA-256 of the destination, set only on the link returned for
+	// deduplication to its owner; unique per owner among live links
+	OriginalURLHash *string    `json:"-"`
+	ShortCode       string     `json:"short_code" gorm:"uniqueIndex;not null"` // host/code on branded domains, see LinkKey
+	DomainID        *uint      `json:"domain_id,omitempty" gorm:"index"`       // branded domain serving the link, nil for the default one
+	OwnerID         *uint      `json:"owner_id,omitempty" gorm:"index"`        // user whose API key created the link
+	ClickCount      int        `json:"click_count" gorm:"default:0"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+	ExpiryExempt    bool       `json:"expiry_exempt" gorm:"default:false"` // exempt from the maximum link lifetime by an admin
+	Locked          bool       `json:"locked" gorm:"default:false"`        // locked links cannot be edited or deleted
+	Status          string     `json:"status" gorm:"default:active;index"`
+	Inert           bool       `json:"inert" gorm:"default:false"` // created by a shadow-banned creator, never redirects
+	Tags            []string   `json:"tags,omitempty" gorm:"type:jsonb;serializer:json"`
+	NoIndex         bool       `json:"noindex" gorm:"default:false"`  // asks search engines not to index the link
+	VariantMode     string     `json:"variant_mode,omitempty"`        // weighted or bandit for split links with variants
+	Analytics       string     `json:"analytics" gorm:"default:full"` // full, count or none, see ShortenRequest.Analytics
+	// Identifier of the link in the owner's CMS, unique among the owner's
+	// links, see PUT /external/{external_id}
+	ExternalID *string `json:"external_id,omitempty"`
+	// Creator the link's abuse reports and flags count against, see
+	// AbuseScore
+	Creator string `json:"-" gorm:"index"`
+	// Redirects a link allows before expiring, and how many are left; nil
+	// for links without a limit
+	MaxClicks       *int `json:"max_clicks,omitempty"`
+	ClicksRemaining *int `json:"clicks_remaining,omitempty"`
+	// When the click counters were last reset; click_count and variant
+	// counters cover the time since
+	StatsResetAt *time.Time `json:"stats_reset_at,omitempty"`
+	// UTM parameters added to the destination on redirect, unless it has
+	// its own
+	UTMSource   string `json:"utm_source,omitempty"`
+	UTMMedium   string `json:"utm_medium,omitempty"`
+	UTMCampaign string `json:"utm_campaign,omitempty"`
+	// HTTP status of redirects: 301, 302 or 307; 0 for the default, 301
+	// (302 for split links)
+	RedirectType int `json:"redirect_type,omitempty"`
+	// Rules sending matching visitors elsewhere, checked in order; see
+	// RoutingRule
+	RoutingRules []RoutingRule `json:"routing_rules,omitempty" gorm:"type:jsonb;serializer:json"`
+	// Percentage of visitors a soft launched link redirects, 0 to 100;
+	// the others see a holding page. nil once fully launched.
+	RolloutPercent *int `json:"rollout_percent,omitempty"`
+	// Most redirects per second or minute, and what visitors beyond it get;
+	// nil for links without a limit
+	VelocityLimit *VelocityLimit `json:"velocity_limit,omitempty" gorm:"type:jsonb;serializer:json"`
+	// Extra response headers of the link's redirects, such as
+	// Referrer-Policy; nil for none, see ShortenRequest.RedirectHeaders
+	RedirectHeaders map[string]string `json:"redirect_headers,omitempty" gorm:"type:jsonb;serializer:json"`
+	// Redirects carry a landing token the destination reports back through
+	// /js/track.js, telling clicks that loaded the destination from bounces
+	TrackLandings bool `json:"track_landings" gorm:"default:false"`
+	// Hours after a click that a conversion of a split link is attributed to
+	// it; 0 follows the owner's window, see ConversionAttributionWindow
+	AttributionWindowHours int `json:"attribution_window_hours,omitempty" gorm:"default:0"`
+	// Environment of the domain serving the link, see EnvironmentProduction
+	Environment string `json:"environment" gorm:"not null;default:production;index" example:"production"`
+	// Landings reported since landing tracking was turned on or the stats
+	// reset, and click_count at that time
+	Landings          int `json:"landings,omitempty" gorm:"default:0"`
+	LandingBaseClicks int `json:"-" gorm:"default:0"`
+	// Version of the configuration above, see LinkVersion
+	Version int `json:"version" gorm:"not null;default:1"`
+	// Revision of the link, raised by every edit; sent as the ETag of
+	// edits, and matched against If-Match to refuse edits of a stale copy
+	Revision int `json:"revision" gorm:"not null;default:1"`
+
+	// Open Graph card shown when the short link is shared on social networks
+	OGTitle       string `json:"og_title,omitempty"`
+	OGDescription string `json:"og_description,omitempty"`
+	OGImage       string `json:"og_image,omitempty"`
+
+	// Title and description of the destination page, shown on the link's
+	// preview page and fetched when it is first viewed
+	PageTitle       string     `json:"page_title,omitempty"`
+	PageDescription string     `json:"page_description,omitempty"`
+	PageFetchedAt   *time.Time `json:"page_fetched_at,omitempty"`
+}
+
+// Link statuses
+const (
+	StatusActive   = "active"
+	StatusPending  = "pending"  // awaiting admin approval, does not redirect
+	StatusRejected = "rejected" // rejected by an admin, does not redirect
+	StatusDisabled = "disabled" // disabled by an admin, answers 410 Gone
+)
+
+// Behaviors for ShortenRequest.IfExists when the URL was already shortened
+const (
+	IfExistsReturn = "return" // return the existing short URL (default)
+	IfExistsError  = "error"  // fail with 409 Conflict
+	IfExistsNew    = "new"    // always create another short code
+)
+
+// Short code styles for ShortenRequest.CodeStyle
+const (
+	CodeStyleRandom = "random" // 6 random alphanumeric characters (default)
+	CodeStyleSMS    = "sms"    // shortest sequential codes, for SMS character budgets
+	CodeStyleWords  = "words"  // pronounceable word pairs such as blue-tiger-42
+)
+
+// How random style short codes are generated, set with SHORT_CODE_STRATEGY
+const (
+	ShortCodeStrategyRandom     = "random"     // random characters, retried on collision (default)
+	ShortCodeStrategySequential = "sequential" // base62 encoded database sequence, never colliding
+)
+
+// Click analytics kept for a link, set with ShortenRequest.Analytics
+const (
+	AnalyticsFull  = "full"  // click count and click events (default)
+	AnalyticsCount = "count" // click count only, no referrer, user agent or location
+	AnalyticsNone  = "none"  // nothing at all, the click count stays 0
+)
+
+// AnalyticsMode accepts true (full), false (count) or a mode name
+type AnalyticsMode string
+
+// UnmarshalJSON reads a boolean or one of the Analytics* modes
+func (m *AnalyticsMode) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true":
+		*m = AnalyticsFull
+		return nil
+	case "false":
+		*m = AnalyticsCount
+		return nil
+	case "null":
+		return nil
+	}
+	var mode string
+	if err := json.Unmarshal(data, &mode); err != nil {
+		return errors.New("analytics must be true, false, full, count or none")
+	}
+	switch mode {
+	case "", AnalyticsFull, AnalyticsCount, AnalyticsNone:
+		*m = AnalyticsMode(mode)
+		return nil
+	}
+	return errors.New("analytics must be true, false, full, count or none")
+}
+
+// CapturesEvents reports whether clicks of links in mode are recorded as
+// click events, for the time series and referrers
+func CapturesEvents(mode string) bool {
+	return mode == "" || mode == AnalyticsFull
 }
 
 type ShortenRequest struct {
-	URL       string `json:"url" binding:"required"`
-	ExpiresIn int    `json:"expires_in"` // in days, optional
+	URL       string   `json:"url" binding:"required"`
+	ExpiresIn int      `json:"expires_in"`                                            // in days, optional
+	IfExists  string   `json:"if_exists" binding:"omitempty,oneof=return error new"`  // return (default), error or new
+	CodeStyle string   `json:"code_style" binding:"omitempty,oneof=random sms words"` // random (default), sms or words
+	Tags      []string `json:"tags" binding:"omitempty,max=20,dive,min=1,max=64"`
+	// Branded short code such as promo2024 instead of a generated one:
+	// 3-64 letters, digits, hyphens or underscores, not a reserved route name
+	CustomAlias string `json:"custom_alias" example:"promo2024"`
+	// Always create a fresh code, like if_exists=new, instead of returning
+	// the link of the same destination
+	NoDedup bool `json:"no_dedup"`
+	// Send X-Robots-Tag: noindex with redirects so search engines don't index the link
+	NoIndex bool `json:"noindex"`
+	// Clicks kept for privacy-sensitive links: true or full (default) counts
+	// clicks and records click events, false or count only counts them,
+	// none keeps nothing
+	Analytics AnalyticsMode `json:"analytics" swaggertype:"string" enums:"full,count,none"`
+	// Expire the link after this many redirects, 1 for a one-time link
+	MaxClicks *int `json:"max_clicks" binding:"omitempty,min=1" example:"1"`
+	// UTM parameters added to the destination, and to those of variants,
+	// when a redirect happens; parameters already in a destination win
+	UTMSource   string `json:"utm_source" binding:"omitempty,max=100" example:"newsletter"`
+	UTMMedium   string `json:"utm_medium" binding:"omitempty,max=100" example:"email"`
+	UTMCampaign string `json:"utm_campaign" binding:"omitempty,max=100" example:"spring_sale"`
+	// Redirect with 301 (default, cached by browsers), 302 or 307 (not
+	// cached, so later destination changes and every click are seen)
+	RedirectType int `json:"redirect_type" binding:"omitempty,oneof=301 302 307" example:"302"`
+	// Split traffic between these destinations instead of url, which is only
+	// used when they cannot be loaded
+	Variants    []VariantRequest `json:"variants" binding:"omitempty,min=2,max=10,dive"`
+	VariantMode string           `json:"variant_mode" binding:"omitempty,oneof=weighted bandit"` // weighted (default) or bandit
+	// Send visitors matching a rule's conditions elsewhere, or turn them
+	// away; the first matching rule decides, see GET /routing/schema
+	RoutingRules []RoutingRule `json:"routing_rules" binding:"omitempty,max=20"`
+	// Soft launch the link: only this percentage of visitors is redirected
+	// and the others see a holding page, see UpdateLinkRequest to ramp up
+	RolloutPercent *int `json:"rollout_percent" binding:"omitempty,min=0,max=100" example:"10"`
+	// Redirect at most this many visitors per second or minute, queueing,
+	// rejecting or redirecting elsewhere the others
+	VelocityLimit *VelocityLimit `json:"velocity_limit"`
+	// Extra headers sent with the link's redirects, such as Referrer-Policy:
+	// no-referrer or a Cache-Control for a CDN; up to 10 of Referrer-Policy,
+	// Cache-Control, CDN-Cache-Control, Surrogate-Control, Surrogate-Key,
+	// Cache-Tag, Vary, Expires or X- headers such as X-Robots-Tag
+	RedirectHeaders map[string]string `json:"redirect_headers" example:"Referrer-Policy:no-referrer"`
+	// Count the visitors who load the destination, reported by /js/track.js
+	// embedded there; redirects carry a landing token for it
+	TrackLandings bool `json:"track_landings"`
+	// Attribute conversions of a split link to clicks by the same visitor
+	// within this many hours before them, instead of the owner's window
+	AttributionWindowHours int `json:"attribution_window_hours" binding:"omitempty,min=1,max=2160" example:"72"`
+	// Optional Open Graph card for social previews of the short link
+	OGTitle       string `json:"og_title" binding:"omitempty,max=200"`
+	OGDescription string `json:"og_description" binding:"omitempty,max=500"`
+	OGImage       string `json:"og_image" binding:"omitempty,url"`
+	// Branded domain to serve the link on instead of the default one, see
+	// GET /admin/short-domains
+	Domain string `json:"domain" binding:"omitempty,max=253" example:"go.acme.com"`
+	// Environment of the link, which must be that of its domain; the
+	// domain's when omitted
+	Environment string `json:"environment" binding:"omitempty,oneof=production staging" enums:"production,staging" example:"staging"`
+	// Token from the configured CAPTCHA widget, required for anonymous requests when CAPTCHA is enabled
+	CaptchaToken string `json:"captcha_token"`
+	// Set from the path of PUT /external/{external_id}, never from the body
+	ExternalID string `json:"-" swaggerignore:"true"`
 }
 
 type ShortenResponse struct {
 	ShortURL    string     `json:"short_url"`
+	QRURL       string     `json:"qr_url"` // PNG QR code of short_url, see GET /{shortCode}/qr
 	OriginalURL string     `json:"original_url"`
 	ShortCode   string     `json:"short_code"`
+	Domain      string     `json:"domain,omitempty"` // branded domain serving the link
+	Environment string     `json:"environment"`      // production or staging
+	ExternalID  string     `json:"external_id,omitempty"`
 	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Status      string     `json:"status"`
+	Analytics   string     `json:"analytics"` // full, count or none
+	MaxClicks   *int       `json:"max_clicks,omitempty"`
+	// HTTP status of the link's redirects
+	RedirectType int `json:"redirect_type"`
+	// Percentage of visitors redirected, for soft launched links
+	RolloutPercent *int           `json:"rollout_percent,omitempty"`
+	VelocityLimit  *VelocityLimit `json:"velocity_limit,omitempty"`
+	TrackLandings  bool           `json:"track_landings,omitempty"`
+	// Extra headers of the link's redirects
+	RedirectHeaders map[string]string `json:"redirect_headers,omitempty"`
+	// Attribution window of the split link's conversions, when set
+	AttributionWindowHours int `json:"attribution_window_hours,omitempty"`
+	// Problems with the new link that did not stop its creation
+	Warnings []string `json:"warnings,omitempty"`
+	// Variants of a split link, with their conversion pixels
+	Variants []VariantStats `json:"variants,omitempty"`
+}
+
+// Default channels for ShortenChannelsRequest
+var DefaultShareChannels = []string{"twitter", "facebook", "email"}
+
+// UpdateLinkRequest edits a link owned by the caller; omitted fields are kept
+type UpdateLinkRequest struct {
+	URL *string `json:"url" example:"https://example.com/new"`
+	// Renames the link; the old short code keeps resolving for ALIAS_RENAME_GRACE
+	CustomAlias *string   `json:"custom_alias" example:"promo2025"`
+	ExpiresIn   *int      `json:"expires_in" binding:"omitempty,min=0"` // in days from now, 0 removes the expiry
+	Tags        *[]string `json:"tags" binding:"omitempty,max=20,dive,min=1,max=64"`
+	NoIndex     *bool     `json:"noindex"`
+	// See ShortenRequest; an empty UTM parameter removes it and redirect
+	// type 0 restores the default
+	UTMSource    *string `json:"utm_source" binding:"omitempty,max=100"`
+	UTMMedium    *string `json:"utm_medium" binding:"omitempty,max=100"`
+	UTMCampaign  *string `json:"utm_campaign" binding:"omitempty,max=100"`
+	RedirectType *int    `json:"redirect_type" binding:"omitempty,oneof=0 301 302 307"`
+	// Replaces the routing rules, an empty list removes them
+	RoutingRules *[]RoutingRule `json:"routing_rules" binding:"omitempty,max=20"`
+	// Ramps a soft launched link, or soft launches a live one; visitors
+	// already let through stay so as it grows, and 100 launches it fully
+	RolloutPercent *int `json:"rollout_percent" binding:"omitempty,min=0,max=100" example:"50"`
+	// Replaces the velocity limit, clicks 0 removes it
+	VelocityLimit *VelocityLimit `json:"velocity_limit"`
+	// Turning landing tracking on starts counting landings from zero
+	TrackLandings *bool `json:"track_landings"`
+	// Replaces the redirect headers, an empty object removes them
+	RedirectHeaders *map[string]string `json:"redirect_headers"`
+	// Attribution window of a split link's conversions in hours, 0 to follow
+	// the owner's window again
+	AttributionWindowHours *int `json:"attribution_window_hours" binding:"omitempty,min=0,max=2160"`
+}
+
+// ShortenChannelsRequest creates one link per share channel for a URL
+type ShortenChannelsRequest struct {
+	URL       string   `json:"url" binding:"required"`
+	Channels  []string `json:"channels" binding:"omitempty,max=20,dive,alphanum,max=32"` // default twitter, facebook, email
+	ExpiresIn int      `json:"expires_in"`                                               // in days, optional
+	Tags      []string `json:"tags" binding:"omitempty,max=20,dive,min=1,max=64"`
+	NoIndex   bool     `json:"noindex"` // see ShortenRequest.NoIndex
+	// See ShortenRequest.Analytics
+	Analytics AnalyticsMode `json:"analytics" swaggertype:"string" enums:"full,count,none"`
+	// Append utm_source=<channel> and utm_medium to each destination (default true)
+	AddUTM       *bool  `json:"add_utm"`
+	CaptchaToken string `json:"captcha_token"`
+}
+
+// ChannelLink is the link created for one share channel
+type ChannelLink struct {
+	Channel string `json:"channel"`
+	ShortenResponse
+}
+
+type ShortenChannelsResponse struct {
+	OriginalURL string        `json:"original_url"`
+	Links       []ChannelLink `json:"links"`
+	// Problems that did not stop the links' creation
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 type StatsResponse struct {
@@ -36,4 +325,78 @@ type StatsResponse struct {
 	ClickCount  int        `json:"click_count"`
 	CreatedAt   time.Time  `json:"created_at"`
 	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	// The destination is on a domain whose ownership has been verified
+	Verified bool `json:"verified"`
+	// Clicks kept for the link: full, count (click_count only) or none
+	// (click_count stays 0)
+	Analytics string `json:"analytics"`
+	// Redirects the link allows and how many are left, for links with
+	// max_clicks; the link expires once none are left
+	MaxClicks       *int `json:"max_clicks,omitempty"`
+	ClicksRemaining *int `json:"clicks_remaining,omitempty"`
+	// Set once the stats were reset, click_count covers the time since
+	StatsResetAt *time.Time `json:"stats_reset_at,omitempty"`
+	// Clicks that loaded the destination, for links with track_landings
+	Landings *LandingStats `json:"landings,omitempty"`
+}
+
+// LandingStats tells the clicks of a link that loaded the destination from
+// those that bounced at the redirect, since landing tracking was turned on
+// or the stats reset
+type LandingStats struct {
+	TrackedClicks int `json:"tracked_clicks" example:"1200"`
+	Landings      int `json:"landings" example:"1020"` // reported by /js/track.js on the destination
+	Bounces       int `json:"bounces" example:"180"`
+	// Share of tracked clicks that landed, 0 to 1
+	LandingRate float64 `json:"landing_rate" example:"0.85"`
+}
+
+// LandingStats returns the landing counts of the link given its current
+// click count, nil when landings are not tracked. Landings are capped to
+// the tracked clicks, as they can be reported before their clicks are
+// counted.
+func (u *URL) LandingStats(clickCount int) *LandingStats {
+	if !u.TrackLandings {
+		return nil
+	}
+	stats := &LandingStats{TrackedClicks: max(clickCount-u.LandingBaseClicks, 0)}
+	stats.Landings = min(u.Landings, stats.TrackedClicks)
+	stats.Bounces = stats.TrackedClicks - stats.Landings
+	if stats.TrackedClicks > 0 {
+		stats.LandingRate = float64(stats.Landings) / float64(stats.TrackedClicks)
+	}
+	return stats
+}
+
+// HasPreview reports whether a custom Open Graph card was set
+func (u *URL) HasPreview() bool {
+	return u.OGTitle != "" || u.OGDescription != "" || u.OGImage != ""
+}
+
+// AnalyticsMode returns the link's click analytics, full for links created
+// before they could be turned off
+func (u *URL) AnalyticsMode() string {
+	if u.Analytics == "" {
+		return AnalyticsFull
+	}
+	return u.Analytics
+}
+
+// UTMQuery returns the link's UTM parameters encoded as a query string,
+// empty when it has none
+func (u *URL) UTMQuery() string {
+	query := url.Values{}
+	for name, value := range map[string]string{"utm_source": u.UTMSource, "utm_medium": u.UTMMedium, "utm_campaign": u.UTMCampaign} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	return query.Encode()
+}
+
+// ShortHost returns the branded domain serving the link, empty for the
+// default one
+func (u *URL) ShortHost() string {
+	host, _ := SplitLinkKey(u.ShortCode)
+	return host
 }
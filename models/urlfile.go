@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// URLFileRecord is a link in the CSV and JSON files of GET
+// /admin/urls/export and POST /admin/urls/import
+type URLFileRecord struct {
+	ShortCode   string     `json:"short_code" example:"promo2024"` // host/code on branded domains
+	OriginalURL string     `json:"original_url" example:"https://example.com/spring"`
+	ClickCount  int        `json:"click_count"`
+	Status      string     `json:"status,omitempty"` // exported, ignored by imports
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Tags        []string   `json:"tags,omitempty"` // separated by | in CSV files
+}
+
+// URLFileColumns is the CSV header of exported links
+var URLFileColumns = []string{"short_code", "original_url", "click_count", "status", "created_at", "expires_at", "tags"}
+
+// URLFileColumnAliases maps the CSV headers of other shorteners' exports to
+// URLFileColumns
+var URLFileColumnAliases = map[string]string{
+	"code": "short_code", "alias": "short_code", "slug": "short_code", "keyword": "short_code", "back_half": "short_code",
+	"url": "original_url", "long_url": "original_url", "destination": "original_url", "target": "original_url",
+	"clicks": "click_count", "visits": "click_count",
+	"created": "created_at", "date": "created_at",
+	"expires": "expires_at", "expiration": "expires_at",
+}
+
+// URLImportResponse reports the outcome of importing a file of links
+type URLImportResponse struct {
+	Imported int `json:"imported"`
+	// Imported links whose short code could not be kept, with the code they got
+	Renamed []URLImportRename `json:"renamed,omitempty"`
+	Skipped []URLImportSkip   `json:"skipped,omitempty"`
+}
+
+// URLImportRename is an imported link that got a new short code
+type URLImportRename struct {
+	Row       int    `json:"row"`                                  // 1 for the first link of the file
+	From      string `json:"from" example:"promo"`                 // short code in the file
+	ShortCode string `json:"short_code" example:"aB3dE9"`          // short code of the imported link
+	Reason    string `json:"reason" example:"short code is taken"` // why the code could not be kept
+}
+
+// URLImportSkip is a link of the file that was not imported, and why
+type URLImportSkip struct {
+	Row       int    `json:"row"`
+	ShortCode string `json:"short_code,omitempty"`
+	Reason    string `json:"reason" example:"URL is blocked by safety policy"`
+}
@@ -0,0 +1,108 @@
+package models
+
+import "time"
+
+// User is a dashboard account
+type User struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Email        string `json:"email" gorm:"uniqueIndex;not null"`
+	PasswordHash string `json:"-" gorm:"not null"`
+	Role         string `json:"role" gorm:"default:user"`
+	// Plan sets how long the click history of the user's links is kept
+	// and how many links they may create per month
+	Plan string `json:"plan" gorm:"default:free;not null" example:"free"`
+	// Hours after a click that conversions of the user's split links are
+	// attributed to it, unless a link sets its own; 0 for
+	// CONVERSION_ATTRIBUTION_WINDOW
+	AttributionWindowHours int `json:"attribution_window_hours,omitempty" gorm:"default:0"`
+
+	TOTPSecret       string `json:"-"`
+	TwoFactorEnabled bool   `json:"two_factor_enabled" gorm:"default:false"`
+	// TOTPLastStep is the time step of the last TOTP code accepted, so no
+	// code of it or an earlier step is accepted again
+	TOTPLastStep int64 `json:"-" gorm:"default:0;not null"`
+}
+
+// BackupCode is a single-use recovery code for two-factor authentication
+type BackupCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+
+	UserID   uint   `json:"user_id" gorm:"index;not null"`
+	CodeHash string `json:"-" gorm:"not null"`
+}
+
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Session is a server-side login session for a dashboard user. Only hashes
+// of the access and refresh tokens are stored.
+type Session struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+
+	UserID           uint      `json:"user_id" gorm:"index;not null"`
+	TokenHash        string    `json:"-" gorm:"uniqueIndex;not null"`
+	RefreshTokenHash string    `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	LastSeenAt       time.Time `json:"last_seen_at"`
+	UserAgent        string    `json:"user_agent"`
+	IPAddress        string    `json:"ip_address"`
+}
+
+type CreateUserRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8"`
+	Role     string `json:"role" binding:"omitempty,oneof=user admin"`
+	Plan     string `json:"plan,omitempty" example:"pro"` // a plan of STATS_RETENTION_DAYS or LINK_QUOTAS, free by default
+}
+
+// SetAttributionWindowRequest sets a user's conversion attribution window
+type SetAttributionWindowRequest struct {
+	Hours *int `json:"hours" binding:"required,min=0,max=2160" example:"72"` // 0 for CONVERSION_ATTRIBUTION_WINDOW
+}
+
+// SetUserPlanRequest moves a user to another plan
+type SetUserPlanRequest struct {
+	Plan string `json:"plan" binding:"required" example:"pro"` // free or a plan of STATS_RETENTION_DAYS or LINK_QUOTAS
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	OTPCode  string `json:"otp_code"` // TOTP or backup code, required when 2FA is enabled
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type BackupCodesResponse struct {
+	BackupCodes []string `json:"backup_codes"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type SessionTokensResponse struct {
+	SessionID        uint      `json:"session_id"`
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
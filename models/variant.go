@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// LinkVariant is one destination of an A/B split link. Clicks count the
+// redirects to it and Conversions the hits on its conversion pixel.
+type LinkVariant struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	URLID       uint   `json:"-" gorm:"not null;index"`
+	Name        string `json:"name"`
+	Destination string `json:"destination" gorm:"not null;serializer:encrypted"` // encrypted when URL_ENCRYPTION_KEY is set
+	Weight      int    `json:"weight"`
+	Clicks      int64  `json:"clicks" gorm:"default:0"`
+	Conversions int64  `json:"conversions" gorm:"default:0"`
+}
+
+// How split links pick a variant for each visitor
+const (
+	VariantModeWeighted = "weighted" // at random, in proportion to the weights
+	VariantModeBandit   = "bandit"   // Thompson sampling on conversions
+)
+
+// VariantRequest is a destination of a split link
+type VariantRequest struct {
+	Name string `json:"name" binding:"omitempty,max=64" example:"B"`
+	URL  string `json:"url" binding:"required"`
+	// Share of traffic in weighted mode, relative to the other variants (default 1)
+	Weight int `json:"weight" binding:"omitempty,min=1,max=1000"`
+}
+
+// VariantStats reports a variant's performance and traffic share
+type VariantStats struct {
+	ID             uint    `json:"id"`
+	Name           string  `json:"name"`
+	Destination    string  `json:"destination"`
+	Weight         int     `json:"weight"`
+	Clicks         int64   `json:"clicks"`
+	Conversions    int64   `json:"conversions"`
+	ConversionRate float64 `json:"conversion_rate"`
+	// Conversions by visitors who clicked the link within the attribution
+	// window before, see VariantStatsResponse.AttributionWindowHours
+	AttributedConversions int64 `json:"attributed_conversions"`
+	// Share of new visitors currently sent to the variant
+	Allocation float64 `json:"allocation"`
+	// Probability that the variant converts best, given the data so far
+	ProbabilityBest float64 `json:"probability_best"`
+	// Load this from the variant's destination to record a conversion
+	PixelURL string `json:"pixel_url"`
+}
+
+// VariantStatsResponse reports every variant of a split link
+type VariantStatsResponse struct {
+	ShortCode string `json:"short_code"`
+	Mode      string `json:"mode"`
+	// Hours after a click that conversions were attributed to it
+	AttributionWindowHours int            `json:"attribution_window_hours" example:"168"`
+	Variants               []VariantStats `json:"variants"`
+}
+
+// ConversionEvent records a hit on a variant's conversion pixel by a visitor,
+// told apart by the same salted hash as ClickEvent.VisitorHash, so
+// conversions can be attributed to the visitor's earlier click
+type ConversionEvent struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ConvertedAt time.Time `json:"converted_at" gorm:"not null;index"`
+	URLID       uint      `json:"url_id" gorm:"not null;index"`
+	VariantID   uint      `json:"variant_id" gorm:"not null"`
+	VisitorHash string    `json:"-" gorm:"not null"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// VelocityLimit caps how fast a link redirects, so a traffic spike through
+// the short link cannot overwhelm a fragile destination. Clicks beyond the
+// limit within a second or minute get the Overflow behavior instead.
+type VelocityLimit struct {
+	// Redirects allowed per window; 0 removes the limit in an update
+	Clicks int    `json:"clicks" binding:"min=0,max=1000000" example:"50"`
+	Per    string `json:"per" binding:"omitempty,oneof=second minute" enums:"second,minute" example:"second"` // second (default) or minute
+	// queue (default) shows a waiting page retrying on its own, reject
+	// answers 429 Too Many Requests, redirect sends the visitor to
+	// overflow_url
+	Overflow    string `json:"overflow" binding:"omitempty,oneof=queue reject redirect" enums:"queue,reject,redirect" example:"queue"`
+	OverflowURL string `json:"overflow_url,omitempty" example:"https://status.example.com/busy"` // for redirect only
+}
+
+// Velocity limit windows
+const (
+	VelocityPerSecond = "second"
+	VelocityPerMinute = "minute"
+)
+
+// What visitors beyond a velocity limit get
+const (
+	VelocityOverflowQueue    = "queue"    // a waiting page that retries the link once the window is over
+	VelocityOverflowReject   = "reject"   // 429 Too Many Requests with Retry-After
+	VelocityOverflowRedirect = "redirect" // a redirect to OverflowURL
+)
+
+// Window returns how long the limit counts clicks for
+func (l *VelocityLimit) Window() time.Duration {
+	if l.Per == VelocityPerMinute {
+		return time.Minute
+	}
+	return time.Second
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// LinkVersion is an immutable snapshot of the configuration deciding where
+// a link's clicks go. Version 1 is the configuration a link was created
+// with; each change of its destination, routing rules, UTM parameters or
+// redirect type records the next version, and click events record the
+// version active when they happened.
+type LinkVersion struct {
+	ID           uint          `json:"-" gorm:"primaryKey"`
+	URLID        uint          `json:"-" gorm:"not null;uniqueIndex:idx_link_versions_url_version"`
+	Version      int           `json:"version" gorm:"not null;uniqueIndex:idx_link_versions_url_version"`
+	CreatedAt    time.Time     `json:"created_at"` // when the version became active
+	Destination  string        `json:"destination" gorm:"not null;serializer:encrypted"`
+	RoutingRules []RoutingRule `json:"routing_rules,omitempty" gorm:"type:jsonb;serializer:json"`
+	UTMSource    string        `json:"utm_source,omitempty"`
+	UTMMedium    string        `json:"utm_medium,omitempty"`
+	UTMCampaign  string        `json:"utm_campaign,omitempty"`
+	RedirectType int           `json:"redirect_type,omitempty"`
+	// Click events recorded while the version was active
+	Clicks int64 `json:"clicks" gorm:"-"`
+}
+
+// Snapshot returns the current version of the link's configuration
+func (u *URL) Snapshot() *LinkVersion {
+	return &LinkVersion{
+		URLID:        u.ID,
+		Version:      u.Version,
+		Destination:  u.OriginalURL,
+		RoutingRules: u.RoutingRules,
+		UTMSource:    u.UTMSource,
+		UTMMedium:    u.UTMMedium,
+		UTMCampaign:  u.UTMCampaign,
+		RedirectType: u.RedirectType,
+	}
+}
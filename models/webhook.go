@@ -0,0 +1,95 @@
+package models
+
+import "time"
+
+// Webhook is a callback URL a user registered for events on the links they
+// own and on their link quota. Deliveries are signed like REST Hooks
+// deliveries and go through the same queue, retries and dead letters in
+// hook_deliveries.
+type Webhook struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	OwnerID   uint      `json:"-" gorm:"not null;index"`
+	URL       string    `json:"url" gorm:"not null"`
+	Events    []string  `json:"events" gorm:"type:jsonb;serializer:json;not null"`
+	// link.clicks fires each time a link's click_count reaches a multiple of
+	// this, 0 when not subscribed
+	ClickThreshold int64  `json:"click_threshold,omitempty"`
+	Secret         string `json:"-" gorm:"not null"`
+	// Links expiring up to this time were notified of
+	ExpiryCheckedAt time.Time `json:"-" gorm:"not null"`
+}
+
+// Events webhooks can subscribe to, besides HookLinkCreated
+const (
+	HookLinkExpired    = "link.expired"
+	HookLinkClicks     = "link.clicks"
+	HookLinkDisabled   = "link.disabled"
+	HookLinkCleanedUp  = "link.cleaned_up"
+	HookQuotaWarning   = "quota.warning"
+	HookQuotaExhausted = "quota.exhausted"
+)
+
+// WebhookEvents lists the events available to webhooks
+var WebhookEvents = []HookTrigger{
+	{Event: HookLinkCreated, Description: "One of your links was created"},
+	{Event: HookLinkExpired, Description: "One of your links expired, at its expiry time or with its last allowed click"},
+	{Event: HookLinkClicks, Description: "One of your links reached a multiple of click_threshold clicks"},
+	{Event: HookLinkDisabled, Description: "One of your links was disabled by an admin, alone or in bulk, and stopped redirecting"},
+	{Event: HookLinkCleanedUp, Description: "One of your links was deleted by the expired link cleanup, EXPIRED_LINK_RETENTION after it expired"},
+	{Event: HookQuotaWarning, Description: "You created 80% of the links your plan may create this month"},
+	{Event: HookQuotaExhausted, Description: "You created all the links your plan may create this month; more are refused until the next"},
+}
+
+// IsWebhookEvent reports whether webhooks can subscribe to event
+func IsWebhookEvent(event string) bool {
+	for _, trigger := range WebhookEvents {
+		if trigger.Event == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribes reports whether the webhook receives event
+func (w *Webhook) Subscribes(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookRequest registers or replaces a webhook
+type WebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url" example:"https://example.com/hooks/links"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=link.created link.expired link.clicks link.disabled link.cleaned_up quota.warning quota.exhausted"`
+	// Required with link.clicks, e.g. 100 to be told every 100 clicks
+	ClickThreshold int64 `json:"click_threshold" binding:"omitempty,min=1" example:"100"`
+}
+
+// WebhookCreatedResponse is returned once when registering a webhook, the
+// only time the signing secret is shown
+type WebhookCreatedResponse struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// HookClicksPayload is delivered for link.clicks
+type HookClicksPayload struct {
+	HookLinkPayload
+	ClickCount int64 `json:"click_count"` // the multiple of click_threshold reached
+	Threshold  int64 `json:"click_threshold"`
+}
+
+// HookQuotaPayload is delivered for quota.warning and quota.exhausted
+type HookQuotaPayload struct {
+	Event       string    `json:"event"`
+	Plan        string    `json:"plan"`
+	Used        int       `json:"used"`  // links created this month
+	Limit       int       `json:"limit"` // links the plan may create per month
+	PeriodStart time.Time `json:"period_start"`
+	ResetsAt    time.Time `json:"resets_at"`
+}
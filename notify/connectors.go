@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Message is an alert or report rendered for chat tools
+type Message struct {
+	Title string
+	Text  string
+	URL   string // optional link to open from the message
+	Facts []Fact
+}
+
+// Fact is a labelled value shown in a message
+type Fact struct {
+	Name  string
+	Value string
+}
+
+// Webhook formats
+const (
+	FormatGeneric = "generic"
+	FormatDiscord = "discord"
+	FormatTeams   = "teams"
+)
+
+// DetectFormat picks the message format from the webhook URL: Discord and
+// Microsoft Teams incoming webhooks are recognised by host, anything else
+// receives the generic JSON payload
+func DetectFormat(webhookURL string) string {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil {
+		return FormatGeneric
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	switch {
+	case (host == "discord.com" || host == "discordapp.com") && strings.HasPrefix(parsed.Path, "/api/webhooks/"):
+		return FormatDiscord
+	case strings.HasSuffix(host, ".webhook.office.com") || host == "outlook.office.com":
+		return FormatTeams
+	}
+	return FormatGeneric
+}
+
+// PostMessage sends an alert to webhookURL, formatted for Discord or Teams
+// when the URL belongs to one of them and as the generic payload otherwise
+func PostMessage(webhookURL string, message Message, payload interface{}) error {
+	switch DetectFormat(webhookURL) {
+	case FormatDiscord:
+		return PostJSON(webhookURL, discordPayload(message))
+	case FormatTeams:
+		return PostJSON(webhookURL, teamsPayload(message))
+	}
+	return PostJSON(webhookURL, payload)
+}
+
+func discordPayload(message Message) map[string]interface{} {
+	fields := make([]map[string]interface{}, 0, len(message.Facts))
+	for _, fact := range message.Facts {
+		fields = append(fields, map[string]interface{}{"name": fact.Name, "value": fact.Value, "inline": true})
+	}
+
+	embed := map[string]interface{}{
+		"title":       message.Title,
+		"description": message.Text,
+		"fields":      fields,
+	}
+	if message.URL != "" {
+		embed["url"] = message.URL
+	}
+	return map[string]interface{}{"embeds": []interface{}{embed}}
+}
+
+// teamsPayload renders an Office 365 connector MessageCard
+func teamsPayload(message Message) map[string]interface{} {
+	facts := make([]map[string]string, 0, len(message.Facts))
+	for _, fact := range message.Facts {
+		facts = append(facts, map[string]string{"name": fact.Name, "value": fact.Value})
+	}
+
+	card := map[string]interface{}{
+		"@type":    "MessageCard",
+		"@context": "https://schema.org/extensions",
+		"summary":  message.Title,
+		"title":    message.Title,
+		"text":     message.Text,
+		"sections": []interface{}{map[string]interface{}{"facts": facts}},
+	}
+	if message.URL != "" {
+		card["potentialAction"] = []interface{}{map[string]interface{}{
+			"@type":   "OpenUri",
+			"name":    "Open",
+			"targets": []interface{}{map[string]string{"os": "default", "uri": message.URL}},
+		}}
+	}
+	return card
+}
@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// EmailEnabled reports whether outgoing email is configured (SMTP_HOST and SMTP_FROM)
+func EmailEnabled() bool {
+	return os.Getenv("SMTP_HOST") != "" && os.Getenv("SMTP_FROM") != ""
+}
+
+// SendEmail sends a plain text email through the configured SMTP server
+func SendEmail(to, subject, body string) error {
+	if !EmailEnabled() {
+		return errors.New("email is not configured")
+	}
+
+	host := os.Getenv("SMTP_HOST")
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+
+	var auth smtp.Auth
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+
+	// Header values come from inbound mail, so strip line breaks
+	clean := strings.NewReplacer("\r", "", "\n", "").Replace
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s",
+		clean(from), clean(to), clean(subject), body)
+
+	return smtp.SendMail(host+":"+port, auth, from, []string{to}, []byte(message))
+}
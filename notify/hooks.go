@@ -0,0 +1,334 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/utils"
+)
+
+// Delays before each retry of a failed delivery; a delivery still failing
+// after the last one is moved to the dead letters
+var hookRetrySchedule = []time.Duration{
+	time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour, 12 * time.Hour,
+}
+
+// A delivery being attempted is not picked up by another instance until
+// this lease runs out, so deliveries of a crashed instance are retried
+const hookDeliveryLease = 5 * time.Minute
+
+// Due deliveries retried per run
+const hookRetryBatchSize = 100
+
+// Events fired but not yet recorded as deliveries, waited for by Drain
+var firing sync.WaitGroup
+
+// Fire records a delivery of payload for every subscription to event and
+// sends them in the background. Failed deliveries are retried on
+// hookRetrySchedule by RetryHookDeliveries. Subscribers answering 410 Gone
+// are unsubscribed, per the REST Hooks convention.
+func Fire(ctx context.Context, event string, payload interface{}) {
+	// Nothing can have subscribed without a database
+	if database.DB == nil {
+		return
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to encode hook payload", "event", event, "error", err)
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	firing.Add(1)
+	go func() {
+		defer firing.Done()
+
+		var subscriptions []models.HookSubscription
+		if err := database.DB.WithContext(ctx).Where("event = ?", event).Find(&subscriptions).Error; err != nil {
+			slog.ErrorContext(ctx, "Failed to load hook subscriptions", "event", event, "error", err)
+			return
+		}
+
+		for i := range subscriptions {
+			deliver(ctx, subscriptionTarget(&subscriptions[i]), event, body)
+		}
+	}()
+}
+
+// hookTarget is where a delivery is sent: a REST Hooks subscription or a
+// user's webhook
+type hookTarget struct {
+	subscriptionID uint
+	webhookID      uint
+	url            string
+	secret         string
+}
+
+func subscriptionTarget(subscription *models.HookSubscription) hookTarget {
+	return hookTarget{subscriptionID: subscription.ID, url: subscription.TargetURL, secret: subscription.Secret}
+}
+
+func webhookTarget(webhook *models.Webhook) hookTarget {
+	return hookTarget{webhookID: webhook.ID, url: webhook.URL, secret: webhook.Secret}
+}
+
+func (t hookTarget) String() string {
+	if t.webhookID != 0 {
+		return fmt.Sprintf("webhook %d", t.webhookID)
+	}
+	return fmt.Sprintf("hook subscription %d", t.subscriptionID)
+}
+
+// remove deletes the subscription or webhook
+func (t hookTarget) remove(ctx context.Context) {
+	if t.webhookID != 0 {
+		database.DB.WithContext(ctx).Delete(&models.Webhook{}, t.webhookID)
+		return
+	}
+	database.DB.WithContext(ctx).Delete(&models.HookSubscription{}, t.subscriptionID)
+}
+
+// deliver records a delivery of body to target and makes its first attempt
+func deliver(ctx context.Context, target hookTarget, event string, body []byte) {
+	delivery, err := recordDelivery(ctx, target, event, body)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to record hook delivery", "event", event, "target", target.String(), "error", err)
+		return
+	}
+	attemptDelivery(ctx, delivery, target)
+}
+
+// Drain waits up to timeout for fired events to be recorded as deliveries,
+// so none are lost when the server stops. Deliveries recorded but not yet
+// sent are retried by RetryHookDeliveries once their lease runs out.
+func Drain(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		firing.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// recordDelivery stores a pending delivery, leased to this instance for
+// its first attempt
+func recordDelivery(ctx context.Context, target hookTarget, event string, body []byte) (*models.HookDelivery, error) {
+	deliveryID, err := utils.GenerateToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	leaseEnd := time.Now().Add(hookDeliveryLease)
+	delivery := models.HookDelivery{
+		DeliveryID:     deliveryID,
+		SubscriptionID: target.subscriptionID,
+		Event:          event,
+		TargetURL:      target.url,
+		Payload:        body,
+		Status:         models.DeliveryPending,
+		NextAttemptAt:  &leaseEnd,
+	}
+	if target.webhookID != 0 {
+		delivery.WebhookID = &target.webhookID
+	}
+	if err := database.DB.WithContext(ctx).Create(&delivery).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// attemptDelivery sends a delivery once and records the outcome, scheduling
+// the next retry or moving it to the dead letters
+func attemptDelivery(ctx context.Context, delivery *models.HookDelivery, target hookTarget) {
+	delivery.Attempts++
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	headers := map[string]string{
+		"X-Hook-Delivery": delivery.DeliveryID,
+		"X-Hook-Event":    delivery.Event,
+		"X-Hook-Attempt":  strconv.Itoa(delivery.Attempts),
+		"X-Timestamp":     timestamp,
+	}
+	if target.secret != "" {
+		headers["X-Signature"] = signDelivery(target.secret, timestamp, delivery.Payload)
+	}
+
+	err := post(target.url, delivery.Payload, headers)
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"attempts":         delivery.Attempts,
+		"target_url":       target.url,
+		"last_status_code": 0,
+		"last_error":       "",
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		updates["last_status_code"] = statusErr.StatusCode
+	}
+
+	switch {
+	case err == nil:
+		updates["status"] = models.DeliveryDelivered
+		updates["delivered_at"] = now
+		updates["next_attempt_at"] = nil
+	case statusErr != nil && statusErr.StatusCode == http.StatusGone:
+		slog.InfoContext(ctx, "Hook target returned 410 Gone, unsubscribing", "target", target.String())
+		target.remove(ctx)
+		updates["status"] = models.DeliveryDead
+		updates["last_error"] = "target returned 410 Gone and was unsubscribed"
+		updates["next_attempt_at"] = nil
+	case delivery.Attempts > len(hookRetrySchedule):
+		slog.WarnContext(ctx, "Giving up on hook delivery", "event", delivery.Event, "delivery_id", delivery.DeliveryID,
+			"target", target.String(), "attempts", delivery.Attempts, "error", err)
+		updates["status"] = models.DeliveryDead
+		updates["last_error"] = err.Error()
+		updates["next_attempt_at"] = nil
+	default:
+		updates["last_error"] = err.Error()
+		updates["next_attempt_at"] = now.Add(hookRetrySchedule[delivery.Attempts-1])
+	}
+
+	if err := database.DB.WithContext(ctx).Model(&models.HookDelivery{}).Where("id = ?", delivery.ID).Updates(updates).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to record outcome of hook delivery", "delivery_id", delivery.DeliveryID, "error", err)
+	}
+	if err == nil && target.subscriptionID != 0 {
+		checkpointDelivery(ctx, delivery)
+	}
+}
+
+// checkpointDelivery records a delivery as the subscription's latest
+// acknowledged one, unless a delivery of a later event already is
+func checkpointDelivery(ctx context.Context, delivery *models.HookDelivery) {
+	err := database.DB.WithContext(ctx).Model(&models.HookSubscription{}).
+		Where("id = ? AND (last_event_at IS NULL OR last_event_at < ?)", delivery.SubscriptionID, delivery.CreatedAt).
+		Updates(map[string]interface{}{"last_delivery_id": delivery.DeliveryID, "last_event_at": delivery.CreatedAt}).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to checkpoint hook delivery", "delivery_id", delivery.DeliveryID, "error", err)
+	}
+}
+
+// signDelivery returns hex(HMAC-SHA256(secret, timestamp + "\n" + body)),
+// sent as X-Signature
+func signDelivery(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RetryHookDeliveries attempts pending deliveries whose next attempt is due,
+// returning how many were attempted. Deliveries are leased while they are
+// sent, so several instances can retry concurrently.
+func RetryHookDeliveries(ctx context.Context) (int, error) {
+	var due []models.HookDelivery
+	err := database.DB.WithContext(ctx).Raw(`
+		UPDATE hook_deliveries SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM hook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, time.Now().Add(hookDeliveryLease), models.DeliveryPending, time.Now(), hookRetryBatchSize,
+	).Scan(&due).Error
+	if err != nil || len(due) == 0 {
+		return 0, err
+	}
+
+	var subscriptionIDs, webhookIDs []uint
+	for _, delivery := range due {
+		if delivery.WebhookID != nil {
+			webhookIDs = append(webhookIDs, *delivery.WebhookID)
+		} else {
+			subscriptionIDs = append(subscriptionIDs, delivery.SubscriptionID)
+		}
+	}
+	subscriptions := make(map[uint]hookTarget)
+	webhooks := make(map[uint]hookTarget)
+	if len(subscriptionIDs) > 0 {
+		var found []models.HookSubscription
+		if err := database.DB.WithContext(ctx).Where("id IN ?", subscriptionIDs).Find(&found).Error; err != nil {
+			return 0, err
+		}
+		for i := range found {
+			subscriptions[found[i].ID] = subscriptionTarget(&found[i])
+		}
+	}
+	if len(webhookIDs) > 0 {
+		var found []models.Webhook
+		if err := database.DB.WithContext(ctx).Where("id IN ?", webhookIDs).Find(&found).Error; err != nil {
+			return 0, err
+		}
+		for i := range found {
+			webhooks[found[i].ID] = webhookTarget(&found[i])
+		}
+	}
+
+	for i := range due {
+		target, ok := subscriptions[due[i].SubscriptionID]
+		gone := "hook subscription was deleted"
+		if due[i].WebhookID != nil {
+			target, ok = webhooks[*due[i].WebhookID]
+			gone = "webhook was deleted"
+		}
+		if !ok {
+			database.DB.WithContext(ctx).Model(&models.HookDelivery{}).Where("id = ?", due[i].ID).Updates(map[string]interface{}{
+				"status":          models.DeliveryDead,
+				"last_error":      gone,
+				"next_attempt_at": nil,
+			})
+			continue
+		}
+		attemptDelivery(ctx, &due[i], target)
+	}
+	return len(due), nil
+}
+
+// RedriveHookDeliveries queues dead deliveries again with a fresh retry
+// schedule, keeping their delivery IDs. With a non-zero deliveryID only that
+// delivery is redriven, and with a non-zero subscriptionID only that
+// subscription's deliveries are.
+func RedriveHookDeliveries(ctx context.Context, deliveryID, subscriptionID uint) (int64, error) {
+	query := database.DB.WithContext(ctx).Model(&models.HookDelivery{}).Where("status = ?", models.DeliveryDead)
+	if deliveryID != 0 {
+		query = query.Where("id = ?", deliveryID)
+	}
+	if subscriptionID != 0 {
+		query = query.Where("subscription_id = ?", subscriptionID)
+	}
+
+	result := query.Updates(map[string]interface{}{
+		"status":          models.DeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	})
+	return result.RowsAffected, result.Error
+}
+
+// PruneHookDeliveries deletes successful deliveries older than retention.
+// Dead letters are kept until they are redriven.
+func PruneHookDeliveries(ctx context.Context, retention time.Duration) (int64, error) {
+	result := database.DB.WithContext(ctx).
+		Where("status = ? AND delivered_at < ?", models.DeliveryDelivered, time.Now().Add(-retention)).
+		Delete(&models.HookDelivery{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"url-shortener/outbound"
+)
+
+var httpClient = outbound.NewClient(outbound.Options{Timeout: 10 * time.Second})
+
+// StatusError is returned when a webhook responds with a non-2xx status
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook returned status %d", e.StatusCode)
+}
+
+// PostJSON sends payload as a JSON POST request to the given webhook URL
+func PostJSON(url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return post(url, body, nil)
+}
+
+// post sends a JSON body with extra headers
+func post(url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
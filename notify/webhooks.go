@@ -0,0 +1,195 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"url-shortener/database"
+	"url-shortener/models"
+)
+
+// baseURL is BASE_URL, set by ConfigureBaseURL
+var baseURL string
+
+// ConfigureBaseURL sets BASE_URL, the address the short URLs of payloads
+// are built on for links not on a branded domain; empty to leave them out
+func ConfigureBaseURL(url string) {
+	baseURL = url
+}
+
+// FireWebhooks records a delivery of payload for every webhook of the owner
+// subscribed to event and sends them in the background, like Fire
+func FireWebhooks(ctx context.Context, ownerID uint, event string, payload interface{}) {
+	// Nothing can have been registered without a database
+	if database.DB == nil {
+		return
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to encode webhook payload", "event", event, "error", err)
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	firing.Add(1)
+	go func() {
+		defer firing.Done()
+
+		webhooks, err := database.WebhooksFor(ctx, []uint{ownerID}, event)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to load webhooks of user", "event", event, "user_id", ownerID, "error", err)
+			return
+		}
+		for i := range webhooks {
+			deliver(ctx, webhookTarget(&webhooks[i]), event, body)
+		}
+	}()
+}
+
+// FireWebhook delivers payload to one webhook, waiting for the delivery to
+// be recorded and attempted once
+func FireWebhook(ctx context.Context, webhook *models.Webhook, event string, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to encode webhook payload", "event", event, "error", err)
+		return
+	}
+	deliver(ctx, webhookTarget(webhook), event, body)
+}
+
+// FireLinkWebhooks fires event about urls to the webhooks of their owners
+// subscribed to it, in the background like Fire. Links without an owner,
+// and inert ones, are not reported.
+func FireLinkWebhooks(ctx context.Context, event string, urls []models.URL) {
+	owned := make(map[uint][]*models.URL)
+	for i := range urls {
+		if urls[i].OwnerID != nil && !urls[i].Inert {
+			owned[*urls[i].OwnerID] = append(owned[*urls[i].OwnerID], &urls[i])
+		}
+	}
+	if len(owned) == 0 || database.DB == nil {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	firing.Add(1)
+	go func() {
+		defer firing.Done()
+
+		ownerIDs := make([]uint, 0, len(owned))
+		for id := range owned {
+			ownerIDs = append(ownerIDs, id)
+		}
+		webhooks, err := database.WebhooksFor(ctx, ownerIDs, event)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to load webhooks", "event", event, "error", err)
+			return
+		}
+		for i := range webhooks {
+			for _, url := range owned[webhooks[i].OwnerID] {
+				body, err := json.Marshal(LinkPayload(event, url))
+				if err != nil {
+					slog.ErrorContext(ctx, "Failed to encode webhook payload", "event", event, "error", err)
+					continue
+				}
+				deliver(ctx, webhookTarget(&webhooks[i]), event, body)
+			}
+		}
+	}()
+}
+
+// FireClickThresholds fires link.clicks for the links whose click count
+// reached a multiple of a webhook's click_threshold, given their new counts
+// and the increments that led to them. A batch crossing several multiples
+// is reported once, for the highest.
+func FireClickThresholds(ctx context.Context, counts, increments map[uint]int64) {
+	if len(counts) == 0 {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	firing.Add(1)
+	go func() {
+		defer firing.Done()
+
+		urlIDs := make([]uint, 0, len(counts))
+		for id := range counts {
+			urlIDs = append(urlIDs, id)
+		}
+		urls, err := database.ClickThresholdLinks(ctx, urlIDs)
+		if err != nil || len(urls) == 0 {
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to load links for click threshold webhooks", "error", err)
+			}
+			return
+		}
+
+		owned := make(map[uint][]*models.URL)
+		for i := range urls {
+			if !urls[i].Inert {
+				owned[*urls[i].OwnerID] = append(owned[*urls[i].OwnerID], &urls[i])
+			}
+		}
+		ownerIDs := make([]uint, 0, len(owned))
+		for id := range owned {
+			ownerIDs = append(ownerIDs, id)
+		}
+		webhooks, err := database.WebhooksFor(ctx, ownerIDs, models.HookLinkClicks)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to load click threshold webhooks", "error", err)
+			return
+		}
+
+		for i := range webhooks {
+			webhook := &webhooks[i]
+			for _, url := range owned[webhook.OwnerID] {
+				after := counts[url.ID]
+				reached, ok := ThresholdReached(after-increments[url.ID], after, webhook.ClickThreshold)
+				if !ok {
+					continue
+				}
+				body, err := json.Marshal(models.HookClicksPayload{
+					HookLinkPayload: LinkPayload(models.HookLinkClicks, url),
+					ClickCount:      reached,
+					Threshold:       webhook.ClickThreshold,
+				})
+				if err != nil {
+					slog.ErrorContext(ctx, "Failed to encode webhook payload", "event", models.HookLinkClicks, "error", err)
+					continue
+				}
+				deliver(ctx, webhookTarget(webhook), models.HookLinkClicks, body)
+			}
+		}
+	}()
+}
+
+// ThresholdReached returns the highest multiple of threshold a click count
+// going from before to after reached, and false when it reached none
+func ThresholdReached(before, after, threshold int64) (int64, bool) {
+	if threshold <= 0 || after/threshold <= before/threshold {
+		return 0, false
+	}
+	return after / threshold * threshold, true
+}
+
+// LinkPayload describes a link for events fired outside of a request, with
+// its short URL on BASE_URL, or on its branded domain
+func LinkPayload(event string, url *models.URL) models.HookLinkPayload {
+	payload := models.HookLinkPayload{
+		ID:          url.ID,
+		Event:       event,
+		ShortCode:   url.ShortCode,
+		OriginalURL: url.OriginalURL,
+		Status:      url.Status,
+		CreatedAt:   url.CreatedAt,
+	}
+	host, code := models.SplitLinkKey(url.ShortCode)
+	if host != "" {
+		payload.ShortURL = "https://" + host + "/" + code
+	} else if baseURL != "" {
+		payload.ShortURL = strings.TrimRight(baseURL, "/") + "/" + code
+	}
+	return payload
+}
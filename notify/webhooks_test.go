@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"testing"
+
+	"url-shortener/models"
+)
+
+func TestThresholdReached(t *testing.T) {
+	tests := []struct {
+		before, after, threshold int64
+		want                     int64
+		ok                       bool
+	}{
+		{before: 98, after: 100, threshold: 100, want: 100, ok: true},
+		{before: 100, after: 150, threshold: 100},
+		{before: 95, after: 99, threshold: 100},
+		{before: 180, after: 420, threshold: 100, want: 400, ok: true},
+		{before: 0, after: 1, threshold: 1, want: 1, ok: true},
+		{before: 10, after: 20, threshold: 0},
+	}
+	for _, tt := range tests {
+		got, ok := ThresholdReached(tt.before, tt.after, tt.threshold)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ThresholdReached(%d, %d, %d) = %d, %v, want %d, %v", tt.before, tt.after, tt.threshold, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLinkPayloadShortURL(t *testing.T) {
+	ConfigureBaseURL("https://sho.rt/")
+	t.Cleanup(func() { ConfigureBaseURL("") })
+
+	payload := LinkPayload(models.HookLinkExpired, &models.URL{ShortCode: "abc123"})
+	if payload.ShortURL != "https://sho.rt/abc123" || payload.Event != models.HookLinkExpired {
+		t.Errorf("payload = %+v", payload)
+	}
+	if payload := LinkPayload(models.HookLinkExpired, &models.URL{ShortCode: models.LinkKey("go.acme.com", "promo")}); payload.ShortURL != "https://go.acme.com/promo" {
+		t.Errorf("branded short URL = %q", payload.ShortURL)
+	}
+}
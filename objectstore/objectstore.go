@@ -0,0 +1,284 @@
+// Package objectstore uploads objects to S3 or any storage speaking the S3
+// API, such as Google Cloud Storage through its interoperability endpoint
+// with HMAC keys, or MinIO, and presigns URLs downloading them. Requests are
+// signed with AWS Signature Version 4 and sent through the outbound client.
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener/outbound"
+)
+
+// Uploads may carry large batches, so they get longer than outbound requests
+const uploadTimeout = 2 * time.Minute
+
+// SHA-256 of an empty body, signed for requests without one
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// Longest validity of a presigned URL allowed by Signature Version 4
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// ErrNotConfigured is returned by FromEnv when no bucket is set
+var ErrNotConfigured = errors.New("object storage bucket is not configured")
+
+// Config locates a bucket and the credentials to write to it
+type Config struct {
+	Endpoint        string // e.g. https://s3.eu-west-1.amazonaws.com or https://storage.googleapis.com
+	Region          string // e.g. eu-west-1, "auto" for Google Cloud Storage
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// FromEnv reads the configuration from environment variables starting with
+// prefix, e.g. CLICK_EXPORT_BUCKET for prefix CLICK_EXPORT_. Credentials
+// fall back to AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, the region to
+// us-east-1 and the endpoint to the S3 endpoint of the region.
+func FromEnv(prefix string) (Config, error) {
+	config := Config{
+		Endpoint:        os.Getenv(prefix + "ENDPOINT"),
+		Region:          os.Getenv(prefix + "REGION"),
+		Bucket:          os.Getenv(prefix + "BUCKET"),
+		AccessKeyID:     firstEnv(prefix+"ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: firstEnv(prefix+"SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY"),
+	}
+	if config.Bucket == "" {
+		return config, ErrNotConfigured
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || (endpoint.Scheme != "https" && endpoint.Scheme != "http") || endpoint.Host == "" {
+		return config, fmt.Errorf("%sENDPOINT must be an http(s) URL", prefix)
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return config, fmt.Errorf("%sACCESS_KEY_ID and %sSECRET_ACCESS_KEY are required", prefix, prefix)
+	}
+	return config, nil
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Client writes objects to one bucket
+type Client struct {
+	config Config
+	http   *http.Client
+	now    func() time.Time
+}
+
+// New returns a client for the bucket in config
+func New(config Config) *Client {
+	return &Client{
+		config: config,
+		http:   outbound.NewClient(outbound.Options{Timeout: uploadTimeout, MaxRedirects: -1}),
+		now:    time.Now,
+	}
+}
+
+// Put uploads body as the object key, replacing any object with that key
+func (c *Client) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, c.objectURL(key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", contentType)
+
+	sum := sha256.Sum256(body)
+	c.sign(request, hex.EncodeToString(sum[:]), c.now())
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("uploading %s: %s: %s", key, response.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// Delete removes the object key; deleting a missing object succeeds
+func (c *Client) Delete(ctx context.Context, key string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+	c.sign(request, emptyPayloadHash, c.now())
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 && response.StatusCode != http.StatusNotFound {
+		detail, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("deleting %s: %s: %s", key, response.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// PresignGet returns a URL downloading the object key without credentials
+// until expires has passed, at most 7 days. Browsers save the download as
+// filename when it is set.
+func (c *Client) PresignGet(key, filename string, expires time.Duration) (string, error) {
+	request, err := http.NewRequest(http.MethodGet, c.objectURL(key), nil)
+	if err != nil {
+		return "", err
+	}
+	if filename != "" {
+		query := request.URL.Query()
+		query.Set("response-content-disposition", `attachment; filename="`+filename+`"`)
+		request.URL.RawQuery = canonicalQuery(query)
+	}
+	c.presign(request, expires, c.now())
+	return request.URL.String(), nil
+}
+
+// objectURL addresses key path-style, which every S3 compatible store accepts
+func (c *Client) objectURL(key string) string {
+	endpoint := strings.TrimSuffix(c.config.Endpoint, "/")
+	return endpoint + escapePath("/"+c.config.Bucket+"/"+strings.TrimPrefix(key, "/"))
+}
+
+// sign adds the AWS Signature Version 4 Authorization header, signing every
+// header already set on request
+func (c *Client) sign(request *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	signedHeaders, signature := c.signature(request, headers, payloadHash, amzDate)
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.config.AccessKeyID, c.scope(amzDate), signedHeaders, signature))
+}
+
+// presign adds the query parameters of a Signature Version 4 presigned URL
+// to request, valid for expires from now
+func (c *Client) presign(request *http.Request, expires time.Duration, now time.Time) {
+	if expires > maxPresignExpiry {
+		expires = maxPresignExpiry
+	}
+	amzDate := now.UTC().Format("20060102T150405Z")
+	query := request.URL.Query()
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", c.config.AccessKeyID+"/"+c.scope(amzDate))
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+	request.URL.RawQuery = canonicalQuery(query)
+
+	_, signature := c.signature(request, map[string]string{"host": request.URL.Host}, "UNSIGNED-PAYLOAD", amzDate)
+	request.URL.RawQuery += "&X-Amz-Signature=" + signature
+}
+
+// scope is the credential scope of requests signed at amzDate
+func (c *Client) scope(amzDate string) string {
+	return amzDate[:8] + "/" + c.config.Region + "/s3/aws4_request"
+}
+
+// signature signs the canonical form of request with headers, returning the
+// names of the headers signed and the signature
+func (c *Client) signature(request *http.Request, headers map[string]string, payloadHash, amzDate string) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		canonicalQuery(request.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + c.scope(amzDate) + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.config.SecretAccessKey), amzDate[:8])
+	key = hmacSHA256(key, c.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// escapePath URI-encodes each segment of path the way Signature Version 4
+// expects, so the path sent is the path signed
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func canonicalQuery(values url.Values) string {
+	pairs := make([]string, 0, len(values))
+	for name, list := range values {
+		for _, value := range list {
+			pairs = append(pairs, escape(name)+"="+escape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// escape percent-encodes everything but the RFC 3986 unreserved characters
+func escape(s string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(s) {
+		if 'A' <= b && b <= 'Z' || 'a' <= b && b <= 'z' || '0' <= b && b <= '9' || b == '-' || b == '_' || b == '.' || b == '~' {
+			escaped.WriteByte(b)
+		} else {
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
@@ -0,0 +1,149 @@
+// Package outbound provides the hardened HTTP client used for every request
+// the service makes to third parties: webhooks, REST Hooks deliveries,
+// CAPTCHA, domain verification and link previews. Requests to private
+// networks are refused, redirects and response sizes are bounded, and each
+// destination host is rate limited.
+package outbound
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Errors returned by clients from NewClient, wrapped in *url.Error
+var (
+	ErrBlockedDestination = errors.New("destination address is not allowed")
+	ErrTooManyRedirects   = errors.New("too many redirects")
+	ErrResponseTooLarge   = errors.New("response body exceeds the size limit")
+	ErrRateLimited        = errors.New("rate limit for destination exceeded")
+)
+
+// Options configures a client; zero values use the defaults
+type Options struct {
+	Timeout          time.Duration // whole request including the body (default 10s)
+	MaxRedirects     int           // redirects followed, -1 for none (default 3)
+	MaxResponseBytes int64         // response bodies are cut off after this (default 1 MiB)
+}
+
+// Defaults for Options
+const (
+	defaultTimeout          = 10 * time.Second
+	defaultMaxRedirects     = 3
+	defaultMaxResponseBytes = 1 << 20
+)
+
+// NewClient returns an http.Client that:
+//   - refuses to connect to loopback, private, link-local and other
+//     non-public addresses, checked after DNS resolution so rebinding cannot
+//     bypass it, unless OUTBOUND_ALLOW_PRIVATE_NETWORKS=true
+//   - follows only http(s) redirects, at most MaxRedirects
+//   - fails reading a response body larger than MaxResponseBytes
+//   - allows OUTBOUND_RATE_LIMIT requests per second (default 10, 0 for no
+//     limit) to each destination host, failing fast beyond that
+//
+// Proxy environment variables are ignored, as a proxy would hide the
+// destination from the address check.
+func NewClient(options Options) *http.Client {
+	if options.Timeout <= 0 {
+		options.Timeout = defaultTimeout
+	}
+	if options.MaxRedirects == 0 {
+		options.MaxRedirects = defaultMaxRedirects
+	}
+	if options.MaxResponseBytes <= 0 {
+		options.MaxResponseBytes = defaultMaxResponseBytes
+	}
+
+	allowPrivate, _ := strconv.ParseBool(os.Getenv("OUTBOUND_ALLOW_PRIVATE_NETWORKS"))
+	dialer := &net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = guardAddress
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: options.Timeout,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+	}
+
+	return &http.Client{
+		Timeout: options.Timeout,
+		Transport: &limitedTransport{
+			next:             transport,
+			limiter:          newHostLimiter(rateLimit()),
+			maxResponseBytes: options.MaxResponseBytes,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("%w: redirect to %s URL", ErrBlockedDestination, req.URL.Scheme)
+			}
+			if len(via) > options.MaxRedirects {
+				return ErrTooManyRedirects
+			}
+			return nil
+		},
+	}
+}
+
+// limitedTransport rate limits requests per host and bounds response bodies
+type limitedTransport struct {
+	next             http.RoundTripper
+	limiter          *hostLimiter
+	maxResponseBytes int64
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.limiter.allow(req.URL.Host) {
+		return nil, ErrRateLimited
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ContentLength > t.maxResponseBytes {
+		resp.Body.Close()
+		return nil, ErrResponseTooLarge
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.maxResponseBytes}
+	return resp, nil
+}
+
+// limitedBody fails with ErrResponseTooLarge once more than the limit is read
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	// Read one byte past the limit to tell a body of exactly the limit apart
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n, ErrResponseTooLarge
+	}
+	return n, err
+}
+
+func rateLimit() float64 {
+	if value, err := strconv.ParseFloat(os.Getenv("OUTBOUND_RATE_LIMIT"), 64); err == nil && value >= 0 {
+		return value
+	}
+	return 10
+}
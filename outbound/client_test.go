@@ -0,0 +1,127 @@
+package outbound
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestBlocked(t *testing.T) {
+	cases := map[string]bool{
+		"127.0.0.1":        true,
+		"10.1.2.3":         true,
+		"172.16.0.1":       true,
+		"192.168.1.1":      true,
+		"169.254.169.254":  true,
+		"100.64.0.1":       true,
+		"0.0.0.0":          true,
+		"224.0.0.1":        true,
+		"::1":              true,
+		"fd00::1":          true,
+		"fe80::1":          true,
+		"::ffff:127.0.0.1": true,
+		"64:ff9b::a00:1":   true,
+		"93.184.216.34":    false,
+		"8.8.8.8":          false,
+		"2606:4700::1111":  false,
+	}
+	for address, blocked := range cases {
+		if got := Blocked(netip.MustParseAddr(address)); got != blocked {
+			t.Errorf("Blocked(%s) = %t, want %t", address, got, blocked)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	cases := map[string]bool{
+		"https://hooks.example.com/abc":     true,
+		"http://93.184.216.34/hook":         true,
+		"ftp://example.com/file":            false,
+		"http://localhost:8080/hook":        false,
+		"http://api.localhost/hook":         false,
+		"http://127.0.0.1/hook":             false,
+		"http://[::1]:9000/hook":            false,
+		"http://169.254.169.254/latest/iam": false,
+	}
+	for rawURL, allowed := range cases {
+		if err := CheckURL(rawURL); (err == nil) != allowed {
+			t.Errorf("CheckURL(%s) = %v, want allowed %t", rawURL, err, allowed)
+		}
+	}
+}
+
+func TestClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := NewClient(Options{}).Get(server.URL)
+	if !errors.Is(err, ErrBlockedDestination) {
+		t.Fatalf("expected ErrBlockedDestination, got %v", err)
+	}
+}
+
+func TestClientLimits(t *testing.T) {
+	// httptest listens on loopback
+	t.Setenv("OUTBOUND_ALLOW_PRIVATE_NETWORKS", "true")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/large":
+			io.WriteString(w, strings.Repeat("x", 2048))
+		case "/exact":
+			io.WriteString(w, strings.Repeat("x", 1024))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(Options{MaxRedirects: 2, MaxResponseBytes: 1024})
+
+	if _, err := client.Get(server.URL + "/loop"); !errors.Is(err, ErrTooManyRedirects) {
+		t.Errorf("redirect loop: expected ErrTooManyRedirects, got %v", err)
+	}
+
+	if resp, err := client.Get(server.URL + "/large"); !errors.Is(err, ErrResponseTooLarge) {
+		if err == nil {
+			_, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+		if !errors.Is(err, ErrResponseTooLarge) {
+			t.Errorf("large body: expected ErrResponseTooLarge, got %v", err)
+		}
+	}
+
+	resp, err := client.Get(server.URL + "/exact")
+	if err != nil {
+		t.Fatalf("body at the limit: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || len(body) != 1024 {
+		t.Errorf("body at the limit: read %d bytes, err %v", len(body), err)
+	}
+}
+
+func TestClientRateLimitsPerHost(t *testing.T) {
+	t.Setenv("OUTBOUND_ALLOW_PRIVATE_NETWORKS", "true")
+	t.Setenv("OUTBOUND_RATE_LIMIT", "1")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := NewClient(Options{})
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	resp.Body.Close()
+
+	if _, err := client.Get(server.URL); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("second request: expected ErrRateLimited, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package outbound
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// Ranges that are not publicly routable beyond what netip classifies
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved, including broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, can reach IPv4 private ranges
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("2001:db8::/32"),  // documentation
+	netip.MustParsePrefix("fec0::/10"),      // deprecated site-local
+}
+
+// Blocked reports whether ip is not a public unicast address, such as
+// loopback, private, link-local (including cloud metadata endpoints) or
+// multicast
+func Blocked(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// guardAddress is the dialer control refusing blocked addresses. It runs
+// after DNS resolution, on the address actually connected to.
+func guardAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if Blocked(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedDestination, ip)
+	}
+	return nil
+}
+
+// CheckURL validates a destination given by a user, such as a hook target,
+// without resolving it: the URL must be http(s) and its host must not be
+// localhost or a blocked IP address. Names resolving to blocked addresses are
+// still refused when connecting.
+func CheckURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("%w: only http and https URLs are allowed", ErrBlockedDestination)
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrBlockedDestination)
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrBlockedDestination, host)
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && Blocked(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedDestination, ip)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package outbound
+
+import (
+	"sync"
+	"time"
+)
+
+// Hosts idle for this long are forgotten by the limiter
+const limiterIdleTimeout = 10 * time.Minute
+
+// hostLimiter is a token bucket per destination host, holding up to a
+// second's worth of requests
+type hostLimiter struct {
+	rate float64 // tokens added per second, 0 for no limit
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func newHostLimiter(rate float64) *hostLimiter {
+	return &hostLimiter{rate: rate, buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+// allow takes a token for host, reporting false when none is left
+func (l *hostLimiter) allow(host string) bool {
+	if l.rate == 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	burst := max(l.rate, 1)
+	if now.Sub(l.lastSweep) > limiterIdleTimeout {
+		for key, b := range l.buckets {
+			if now.Sub(b.updated) > limiterIdleTimeout {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[host]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		l.buckets[host] = b
+	}
+	b.tokens = min(burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
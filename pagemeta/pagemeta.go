@@ -0,0 +1,121 @@
+// Package pagemeta reads the title and description a web page declares in
+// its HTML head, for showing recipients of a short link where it leads
+package pagemeta
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"url-shortener/outbound"
+)
+
+// Metadata is what a page says about itself
+type Metadata struct {
+	Title       string
+	Description string
+}
+
+// Longest title and description kept, in bytes
+const (
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+)
+
+var (
+	// Only the start of a page is read, where the head normally is
+	httpClient = outbound.NewClient(outbound.Options{Timeout: 5 * time.Second, MaxResponseBytes: 256 << 10})
+
+	titlePattern    = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	metaTagPattern  = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	keyPattern      = regexp.MustCompile(`(?i)\b(?:name|property)\s*=\s*["']?([a-z:]+)`)
+	contentPattern  = regexp.MustCompile(`(?i)\bcontent\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	spacePattern    = regexp.MustCompile(`\s+`)
+	descriptionKeys = []string{"og:description", "twitter:description", "description"}
+	titleKeys       = []string{"og:title", "twitter:title"}
+)
+
+// Fetch downloads rawURL through the outbound client and parses its
+// metadata. Pages answering with an error status or something other than
+// HTML are reported as errors.
+func Fetch(ctx context.Context, rawURL string) (Metadata, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return Metadata{}, err
+	}
+	request.Header.Set("Accept", "text/html")
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return Metadata{}, fmt.Errorf("page responded with status %d", response.StatusCode)
+	}
+	if contentType := response.Header.Get("Content-Type"); contentType != "" && !strings.Contains(strings.ToLower(contentType), "html") {
+		return Metadata{}, fmt.Errorf("page is %s, not HTML", contentType)
+	}
+
+	// A page cut off at the size limit still has its head
+	body, err := io.ReadAll(response.Body)
+	if err != nil && len(body) == 0 {
+		return Metadata{}, err
+	}
+	return Parse(string(body)), nil
+}
+
+// Parse reads the title and description of page, preferring its Open Graph
+// and Twitter card tags over <title> and the description meta tag
+func Parse(page string) Metadata {
+	tags := make(map[string]string)
+	for _, tag := range metaTagPattern.FindAllString(page, -1) {
+		key := keyPattern.FindStringSubmatch(tag)
+		content := contentPattern.FindStringSubmatch(tag)
+		if key == nil || content == nil {
+			continue
+		}
+		name := strings.ToLower(key[1])
+		if _, seen := tags[name]; !seen {
+			tags[name] = content[1] + content[2]
+		}
+	}
+
+	var metadata Metadata
+	for _, key := range titleKeys {
+		if metadata.Title = clean(tags[key], maxTitleLength); metadata.Title != "" {
+			break
+		}
+	}
+	if metadata.Title == "" {
+		if title := titlePattern.FindStringSubmatch(page); title != nil {
+			metadata.Title = clean(title[1], maxTitleLength)
+		}
+	}
+	for _, key := range descriptionKeys {
+		if metadata.Description = clean(tags[key], maxDescriptionLength); metadata.Description != "" {
+			break
+		}
+	}
+	return metadata
+}
+
+// clean unescapes text, collapses its whitespace and cuts it to limit bytes
+// without splitting a character
+func clean(text string, limit int) string {
+	text = strings.TrimSpace(spacePattern.ReplaceAllString(html.UnescapeString(text), " "))
+	if len(text) <= limit {
+		return text
+	}
+	text = text[:limit]
+	for !utf8.ValidString(text) {
+		text = text[:len(text)-1]
+	}
+	return strings.TrimSpace(text) + "…"
+}
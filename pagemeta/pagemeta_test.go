@@ -0,0 +1,49 @@
+package pagemeta
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name string
+		page string
+		want Metadata
+	}{
+		{
+			name: "open graph wins over title",
+			page: `<head><title>Plain</title><meta property="og:title" content="Spring Sale"><meta name="description" content="Up to 50% off"></head>`,
+			want: Metadata{Title: "Spring Sale", Description: "Up to 50% off"},
+		},
+		{
+			name: "title and description tags",
+			page: "<HEAD><TITLE>\n  Docs &amp; Guides\n</TITLE><META content='Read the docs' NAME='Description'></HEAD>",
+			want: Metadata{Title: "Docs & Guides", Description: "Read the docs"},
+		},
+		{
+			name: "first of repeated tags",
+			page: `<meta name="twitter:description" content="first"><meta name="twitter:description" content="second">`,
+			want: Metadata{Description: "first"},
+		},
+		{
+			name: "nothing declared",
+			page: `<p>Hello</p>`,
+			want: Metadata{},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.page); got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCutsLongTitles(t *testing.T) {
+	metadata := Parse("<title>" + strings.Repeat("é", maxTitleLength) + "</title>")
+	if len(metadata.Title) > maxTitleLength+len("…") || !strings.HasSuffix(metadata.Title, "…") {
+		t.Errorf("title was not cut: %d bytes", len(metadata.Title))
+	}
+}
@@ -0,0 +1,143 @@
+// Package policy snapshots the policies consulted while handling a request:
+// the configured switches, brand safety and tag rules and whether the client
+// is shadow-banned. A handler checking several URLs, such as a link with
+// variants or per-channel links, sees one consistent view even when the
+// rules are reloaded or a ban is added meanwhile, and each policy is loaded
+// at most once per request.
+package policy
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"url-shortener/autotag"
+	"url-shortener/captcha"
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/safety"
+)
+
+// Snapshot is the policy in force for one request. The switches are read
+// when it is taken; the safety and tag rules and shadow ban, which need the
+// database, are loaded on first use.
+type Snapshot struct {
+	RequireApproval  bool // links start pending, from REQUIRE_APPROVAL
+	CaptchaRequired  bool // anonymous creation needs a CAPTCHA token
+	AnonymousShorten bool // links may be created without an API key
+	// Creator roles whose links start pending with RequireApproval, all
+	// when empty, from APPROVAL_ROLES
+	ApprovalRoles []string
+
+	clientIP string
+
+	safetyOnce  sync.Once
+	safetyRules safety.RuleSet
+
+	tagOnce  sync.Once
+	tagRules autotag.RuleSet
+
+	banOnce      sync.Once
+	shadowBanned bool
+}
+
+// Load takes a snapshot for a request from clientIP
+func Load(clientIP string) *Snapshot {
+	return &Snapshot{
+		RequireApproval:  ApprovalRequired(),
+		ApprovalRoles:    ApprovalRoles(),
+		CaptchaRequired:  captcha.Enabled(),
+		AnonymousShorten: AnonymousShortenAllowed(),
+		clientIP:         clientIP,
+	}
+}
+
+// EvaluateSafety applies the brand safety rules of the snapshot to rawURL,
+// see safety.Evaluate
+func (s *Snapshot) EvaluateSafety(rawURL string) (string, *models.SafetyRule) {
+	s.safetyOnce.Do(func() {
+		s.safetyRules = safety.Current()
+	})
+	return s.safetyRules.Evaluate(rawURL)
+}
+
+// AutoTag adds the tags of the snapshot's tag rules matching rawURL to tags,
+// see autotag.RuleSet.Apply
+func (s *Snapshot) AutoTag(rawURL string, tags []string) []string {
+	s.tagOnce.Do(func() {
+		s.tagRules = autotag.Current()
+	})
+	return s.tagRules.Apply(rawURL, tags)
+}
+
+// ShadowBanned reports whether the client is shadow-banned. Lookup failures
+// are logged and treated as not banned.
+func (s *Snapshot) ShadowBanned() bool {
+	s.banOnce.Do(func() {
+		if database.DB == nil {
+			return
+		}
+		var count int64
+		if err := database.DB.Model(&models.ShadowBan{}).Where("ip_address = ?", s.clientIP).Count(&count).Error; err != nil {
+			slog.Error("Failed to check shadow bans", "error", err)
+			return
+		}
+		s.shadowBanned = count > 0
+	})
+	return s.shadowBanned
+}
+
+// ApprovalRequired reports whether newly created links start in the pending
+// state, as set by REQUIRE_APPROVAL
+func ApprovalRequired() bool {
+	required, _ := strconv.ParseBool(os.Getenv("REQUIRE_APPROVAL"))
+	return required
+}
+
+// Roles of link creators, as listed in APPROVAL_ROLES
+const (
+	CreatorAnonymous = "anonymous"      // without an API key
+	CreatorService   = "service"        // with an API key owned by no user
+	CreatorUser      = models.RoleUser  // with a user's API key
+	CreatorAdmin     = models.RoleAdmin // as an admin, or with an admin's API key
+)
+
+// CreatorRoles lists the roles of link creators
+var CreatorRoles = []string{CreatorAnonymous, CreatorService, CreatorUser, CreatorAdmin}
+
+// HoldsForApproval reports whether links created by role start pending
+func (s *Snapshot) HoldsForApproval(role string) bool {
+	if !s.RequireApproval {
+		return false
+	}
+	if len(s.ApprovalRoles) == 0 {
+		return true
+	}
+	for _, held := range s.ApprovalRoles {
+		if held == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ApprovalRoles returns the creator roles listed in APPROVAL_ROLES, whose
+// links alone need approval, or nil to hold the links of every creator
+func ApprovalRoles() []string {
+	var roles []string
+	for _, role := range strings.Split(os.Getenv("APPROVAL_ROLES"), ",") {
+		if role = strings.ToLower(strings.TrimSpace(role)); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// AnonymousShortenAllowed reports whether links may be created without an
+// API key, as set by ALLOW_ANONYMOUS_SHORTEN (default true)
+func AnonymousShortenAllowed() bool {
+	allowed, err := strconv.ParseBool(os.Getenv("ALLOW_ANONYMOUS_SHORTEN"))
+	return err != nil || allowed
+}
@@ -0,0 +1,520 @@
+// Package qrcode encodes short URLs as QR codes (ISO/IEC 18004) and renders
+// them as PNG or SVG. Only byte mode and versions 1 to 10 are supported,
+// which holds 119 to 271 bytes depending on the error correction level,
+// plenty for a short URL.
+package qrcode
+
+import (
+	"errors"
+	"strings"
+)
+
+// Level is an error correction level; higher levels survive more damage
+// to the printed code at the cost of a denser code
+type Level int
+
+const (
+	Low      Level = iota // recovers about 7% of the code
+	Medium                // about 15%
+	Quartile              // about 25%
+	High                  // about 30%
+)
+
+// ErrTooLong is returned for data beyond the capacity of version 10
+var ErrTooLong = errors.New("data too long for a QR code of version 10 or lower")
+
+// ParseLevel reads an error correction level given as L, M, Q or H
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToUpper(s) {
+	case "L":
+		return Low, nil
+	case "M":
+		return Medium, nil
+	case "Q":
+		return Quartile, nil
+	case "H":
+		return High, nil
+	}
+	return 0, errors.New("error correction level must be L, M, Q or H")
+}
+
+// String returns the letter of the level
+func (l Level) String() string {
+	return [...]string{"L", "M", "Q", "H"}[l]
+}
+
+// formatBits identify the level in the format information
+func (l Level) formatBits() int {
+	return [...]int{1, 0, 3, 2}[l]
+}
+
+// blockLayout describes how the codewords of a version and level are split
+// into Reed-Solomon blocks: blocks1 blocks of data1 data codewords followed
+// by blocks2 blocks of data1+1, each with ec error correction codewords
+type blockLayout struct {
+	ec, blocks1, data1, blocks2 int
+}
+
+func (b blockLayout) dataCodewords() int {
+	return b.blocks1*b.data1 + b.blocks2*(b.data1+1)
+}
+
+// Block layouts per version (index 0 is version 1) and level, from table 9
+// of the standard
+var layouts = [10][4]blockLayout{
+	{{7, 1, 19, 0}, {10, 1, 16, 0}, {13, 1, 13, 0}, {17, 1, 9, 0}},
+	{{10, 1, 34, 0}, {16, 1, 28, 0}, {22, 1, 22, 0}, {28, 1, 16, 0}},
+	{{15, 1, 55, 0}, {26, 1, 44, 0}, {18, 2, 17, 0}, {22, 2, 13, 0}},
+	{{20, 1, 80, 0}, {18, 2, 32, 0}, {26, 2, 24, 0}, {16, 4, 9, 0}},
+	{{26, 1, 108, 0}, {24, 2, 43, 0}, {18, 2, 15, 2}, {22, 2, 11, 2}},
+	{{18, 2, 68, 0}, {16, 4, 27, 0}, {24, 4, 19, 0}, {28, 4, 15, 0}},
+	{{20, 2, 78, 0}, {18, 4, 31, 0}, {18, 2, 14, 4}, {26, 4, 13, 1}},
+	{{24, 2, 97, 0}, {22, 2, 38, 2}, {22, 4, 18, 2}, {26, 4, 14, 2}},
+	{{30, 2, 116, 0}, {22, 3, 36, 2}, {20, 4, 16, 4}, {24, 4, 12, 4}},
+	{{18, 2, 68, 2}, {26, 4, 43, 1}, {24, 6, 19, 2}, {28, 6, 15, 2}},
+}
+
+// Centers of the alignment patterns per version, in both directions
+var alignmentCenters = [10][]int{
+	nil, {6, 18}, {6, 22}, {6, 26}, {6, 30}, {6, 34},
+	{6, 22, 38}, {6, 24, 42}, {6, 26, 46}, {6, 28, 50},
+}
+
+// Code is an encoded QR code, a square of dark and light modules
+type Code struct {
+	Version int
+	Level   Level
+	Mask    int
+	Size    int // modules per side, without the quiet zone
+
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// Encode encodes data in the smallest version holding it at level
+func Encode(data []byte, level Level) (*Code, error) {
+	version := 0
+	for v := 1; v <= len(layouts); v++ {
+		if byteModeBits(v, len(data)) <= layouts[v-1][level].dataCodewords()*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	size := 17 + 4*version
+	code := &Code{Version: version, Level: level, Size: size}
+	code.modules = newGrid(size)
+	code.isFunction = newGrid(size)
+	code.drawFunctionPatterns()
+	code.drawCodewords(interleave(dataCodewords(data, version, level), layouts[version-1][level]))
+
+	// Keep the mask giving the fewest patterns that confuse scanners
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		code.applyMask(mask)
+		code.drawFormatBits(mask)
+		if penalty := code.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		code.applyMask(mask) // masks are their own inverse
+	}
+	code.Mask = best
+	code.applyMask(best)
+	code.drawFormatBits(best)
+	return code, nil
+}
+
+// Dark reports whether the module in column x and row y is dark
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+func newGrid(size int) [][]bool {
+	grid := make([][]bool, size)
+	for i := range grid {
+		grid[i] = make([]bool, size)
+	}
+	return grid
+}
+
+// byteModeBits is the length of the bit stream encoding n bytes
+func byteModeBits(version, n int) int {
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	return 4 + countBits + 8*n
+}
+
+// dataCodewords encodes data in byte mode, terminated and padded to the
+// data capacity of the version and level
+func dataCodewords(data []byte, version int, level Level) []byte {
+	capacity := layouts[version-1][level].dataCodewords()
+	var bits bitBuffer
+	bits.append(0b0100, 4) // byte mode
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	terminator := capacity*8 - bits.len()
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-bits.len()%8)%8)
+
+	codewords := bits.bytes()
+	for pad := byte(0xEC); len(codewords) < capacity; pad ^= 0xEC ^ 0x11 {
+		codewords = append(codewords, pad)
+	}
+	return codewords
+}
+
+// interleave splits data into blocks, adds the error correction codewords
+// of each and interleaves them as they are placed in the code
+func interleave(data []byte, layout blockLayout) []byte {
+	divisor := reedSolomonDivisor(layout.ec)
+	var blocks, ecBlocks [][]byte
+	for i := 0; i < layout.blocks1+layout.blocks2; i++ {
+		n := layout.data1
+		if i >= layout.blocks1 {
+			n++
+		}
+		blocks = append(blocks, data[:n])
+		ecBlocks = append(ecBlocks, reedSolomonRemainder(data[:n], divisor))
+		data = data[n:]
+	}
+
+	var result []byte
+	for i := 0; i <= layout.data1; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < layout.ec; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// drawFunctionPatterns draws the timing, finder and alignment patterns and
+// reserves the format and version information areas
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.Size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(c.Size-4, 3)
+	c.drawFinder(3, c.Size-4)
+
+	centers := alignmentCenters[c.Version-1]
+	last := len(centers) - 1
+	for i, x := range centers {
+		for j, y := range centers {
+			// The corners already hold finder patterns
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	c.drawFormatBits(0) // reserved, drawn for real once the mask is known
+	c.drawVersionBits()
+}
+
+// drawFinder draws a finder pattern centered on x, y with its separator
+func (c *Code) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx >= 0 && xx < c.Size && yy >= 0 && yy < c.Size {
+				distance := max(abs(dx), abs(dy))
+				c.setFunction(xx, yy, distance != 2 && distance != 4)
+			}
+		}
+	}
+}
+
+// drawFormatBits draws both copies of the level and mask, protected by a
+// BCH code, and the dark module
+func (c *Code) drawFormatBits(mask int) {
+	bits := formatInformation(c.Level, mask)
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(bits, i))
+	}
+	c.setFunction(8, 7, bit(bits, 6))
+	c.setFunction(8, 8, bit(bits, 7))
+	c.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.Size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.Size-15+i, bit(bits, i))
+	}
+	c.setFunction(8, c.Size-8, true)
+}
+
+// formatInformation returns the 15 format bits of a level and mask
+func formatInformation(level Level, mask int) int {
+	data := level.formatBits()<<3 | mask
+	remainder := data
+	for i := 0; i < 10; i++ {
+		remainder = remainder<<1 ^ (remainder>>9)*0x537
+	}
+	return (data<<10 | remainder) ^ 0x5412
+}
+
+// drawVersionBits draws both copies of the version from version 7 on
+func (c *Code) drawVersionBits() {
+	if c.Version < 7 {
+		return
+	}
+	bits := versionInformation(c.Version)
+	for i := 0; i < 18; i++ {
+		a, b := c.Size-11+i%3, i/3
+		c.setFunction(a, b, bit(bits, i))
+		c.setFunction(b, a, bit(bits, i))
+	}
+}
+
+// versionInformation returns the 18 version bits, protected by a BCH code
+func versionInformation(version int) int {
+	remainder := version
+	for i := 0; i < 12; i++ {
+		remainder = remainder<<1 ^ (remainder>>11)*0x1F25
+	}
+	return version<<12 | remainder
+}
+
+// drawCodewords places the codewords in the zigzag order of the standard,
+// two columns at a time from the bottom right corner
+func (c *Code) drawCodewords(codewords []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skips the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vertical := 0; vertical < c.Size; vertical++ {
+			y := vertical
+			if upward {
+				y = c.Size - 1 - vertical
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if !c.isFunction[y][x] && i < len(codewords)*8 {
+					c.modules[y][x] = bit(int(codewords[i>>3]), 7-i&7)
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask inverts the data modules selected by mask
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.isFunction[y][x] && masked(mask, x, y) {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+func masked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// penalty scores the patterns that make a code hard to scan: long runs,
+// 2x2 blocks, finder-like sequences and an unbalanced share of dark modules
+func (c *Code) penalty() int {
+	penalty := 0
+	for i := 0; i < c.Size; i++ {
+		row := make([]bool, c.Size)
+		column := make([]bool, c.Size)
+		for j := 0; j < c.Size; j++ {
+			row[j] = c.modules[i][j]
+			column[j] = c.modules[j][i]
+		}
+		penalty += linePenalty(row) + linePenalty(column)
+	}
+
+	dark := 0
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < c.Size && y+1 < c.Size {
+				color := c.modules[y][x]
+				if color == c.modules[y][x+1] && color == c.modules[y+1][x] && color == c.modules[y+1][x+1] {
+					penalty += 3
+				}
+			}
+		}
+	}
+	total := c.Size * c.Size
+	penalty += ((abs(dark*20-total*10)+total-1)/total - 1) * 10
+	return penalty
+}
+
+// Dark and light sequences resembling a finder pattern
+var finderLike = [2][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// linePenalty scores runs of five or more modules of one color and
+// finder-like sequences in a row or column
+func linePenalty(line []bool) int {
+	penalty := 0
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			penalty += run - 2
+		}
+		run = 1
+	}
+
+	for i := 0; i+11 <= len(line); i++ {
+		for _, pattern := range finderLike {
+			matches := true
+			for j, dark := range pattern {
+				if line[i+j] != dark {
+					matches = false
+					break
+				}
+			}
+			if matches {
+				penalty += 40
+			}
+		}
+	}
+	return penalty
+}
+
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.isFunction[y][x] = true
+}
+
+func bit(value, i int) bool {
+	return value>>i&1 != 0
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// bitBuffer accumulates a bit stream, most significant bit first
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, bit(value, i))
+	}
+}
+
+func (b bitBuffer) len() int {
+	return len(b)
+}
+
+func (b bitBuffer) bytes() []byte {
+	result := make([]byte, len(b)/8)
+	for i, set := range b {
+		if set {
+			result[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return result
+}
+
+// reedSolomonDivisor returns the generator polynomial of the given degree,
+// highest coefficient first without the leading 1
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// reedSolomonRemainder returns the error correction codewords of data
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coefficient := range divisor {
+			result[i] ^= gfMultiply(coefficient, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
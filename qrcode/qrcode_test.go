@@ -0,0 +1,179 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// The 1-M "HELLO WORLD" example of the standard
+func TestReedSolomonMatchesStandardExample(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	if got := reedSolomonRemainder(data, reedSolomonDivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("error correction = %v, want %v", got, want)
+	}
+}
+
+func TestFormatAndVersionInformation(t *testing.T) {
+	cases := []struct {
+		level Level
+		mask  int
+		want  int
+	}{
+		{Low, 0, 0b111011111000100},
+		{Medium, 0, 0b101010000010010},
+		{Quartile, 0, 0b011010101011111},
+		{High, 0, 0b001011010001001},
+		{Medium, 5, 0b100000011001110},
+	}
+	for _, tc := range cases {
+		if got := formatInformation(tc.level, tc.mask); got != tc.want {
+			t.Errorf("format of %s mask %d = %015b, want %015b", tc.level, tc.mask, got, tc.want)
+		}
+	}
+
+	if got, want := versionInformation(7), 0b000111110010010100; got != want {
+		t.Errorf("version 7 information = %018b, want %018b", got, want)
+	}
+}
+
+func TestEncodePicksSmallestVersion(t *testing.T) {
+	cases := []struct {
+		length  int
+		level   Level
+		version int
+	}{
+		{17, Low, 1},
+		{18, Low, 2},
+		{14, Medium, 1},
+		{84, Medium, 5},
+		{85, Medium, 6},
+		{119, High, 10},
+		{271, Low, 10},
+	}
+	for _, tc := range cases {
+		code, err := Encode(bytes.Repeat([]byte("a"), tc.length), tc.level)
+		if err != nil {
+			t.Errorf("%d bytes at %s: %v", tc.length, tc.level, err)
+			continue
+		}
+		if code.Version != tc.version || code.Size != 17+4*tc.version {
+			t.Errorf("%d bytes at %s: version %d of size %d, want version %d", tc.length, tc.level, code.Version, code.Size, tc.version)
+		}
+	}
+
+	if _, err := Encode(bytes.Repeat([]byte("a"), 272), Low); err != ErrTooLong {
+		t.Errorf("err = %v, want ErrTooLong", err)
+	}
+}
+
+// Reads the code back the way a scanner would once it found the modules:
+// format bits, then the unmasked codewords in zigzag order
+func TestEncodeRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		data  string
+		level Level
+	}{
+		{"https://sho.rt/abc123", Medium},
+		{"https://links.example.com/spring-sale-2025?utm_source=poster&utm_medium=print", Quartile},
+		{strings.Repeat("https://example.com/", 8), Low},
+	} {
+		code, err := Encode([]byte(tc.data), tc.level)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		format := 0
+		for i := 0; i <= 5; i++ {
+			format |= boolBit(code.Dark(8, i)) << i
+		}
+		format |= boolBit(code.Dark(8, 7))<<6 | boolBit(code.Dark(8, 8))<<7 | boolBit(code.Dark(7, 8))<<8
+		for i := 9; i < 15; i++ {
+			format |= boolBit(code.Dark(14-i, 8)) << i
+		}
+		if want := formatInformation(tc.level, code.Mask); format != want {
+			t.Errorf("%q: format bits %015b, want %015b", tc.data, format, want)
+		}
+
+		want := interleave(dataCodewords([]byte(tc.data), code.Version, tc.level), layouts[code.Version-1][tc.level])
+		got := make([]byte, len(want))
+		i := 0
+		for right := code.Size - 1; right >= 1; right -= 2 {
+			if right == 6 {
+				right = 5
+			}
+			for vertical := 0; vertical < code.Size; vertical++ {
+				y := vertical
+				if (right+1)&2 == 0 {
+					y = code.Size - 1 - vertical
+				}
+				for j := 0; j < 2; j++ {
+					x := right - j
+					if !code.isFunction[y][x] && i < len(got)*8 {
+						if code.Dark(x, y) != masked(code.Mask, x, y) {
+							got[i>>3] |= 1 << (7 - i&7)
+						}
+						i++
+					}
+				}
+			}
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%q: codewords read back differ from those encoded", tc.data)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	code, err := Encode([]byte("https://sho.rt/abc123"), Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := code.PNG(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 256 || img.Bounds().Dy() != 256 {
+		t.Errorf("PNG bounds = %v", img.Bounds())
+	}
+	// Top left corner of the top left finder pattern, past the quiet zone
+	total := code.Size + 2*quietZone
+	corner := (quietZone*256 + total - 1) / total
+	if r, _, _, _ := img.At(corner, corner).RGBA(); r != 0 {
+		t.Error("finder pattern corner is not dark")
+	}
+	if r, _, _, _ := img.At(0, 0).RGBA(); r == 0 {
+		t.Error("quiet zone is not light")
+	}
+
+	svg := string(code.SVG(256))
+	if !strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="256" height="256"`) || !strings.Contains(svg, "M4 4h1v1h-1z") {
+		t.Errorf("SVG = %.200s", svg)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]Level{"L": Low, "m": Medium, "Q": Quartile, "h": High} {
+		if got, err := ParseLevel(s); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v", s, got, err)
+		}
+	}
+	if _, err := ParseLevel("X"); err == nil {
+		t.Error("expected an error for X")
+	}
+}
+
+func boolBit(dark bool) int {
+	if dark {
+		return 1
+	}
+	return 0
+}
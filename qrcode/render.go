@@ -0,0 +1,54 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// Light modules around the code that scanners need to find it
+const quietZone = 4
+
+// PNG renders the code with its quiet zone as a size by size pixel PNG.
+// Modules are scaled to whole pixels where possible; size should be at
+// least Size+8 for every module to get a pixel.
+func (c *Code) PNG(size int) ([]byte, error) {
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{color.White, color.Black})
+	total := c.Size + 2*quietZone
+	for py := 0; py < size; py++ {
+		y := py*total/size - quietZone
+		for px := 0; px < size; px++ {
+			x := px*total/size - quietZone
+			if x >= 0 && x < c.Size && y >= 0 && y < c.Size && c.modules[y][x] {
+				img.SetColorIndex(px, py, 1)
+			}
+		}
+	}
+
+	var buffer bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buffer, img); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// SVG renders the code with its quiet zone as an SVG image of size by size
+// pixels, one path drawing the dark modules
+func (c *Code) SVG(size int) []byte {
+	total := c.Size + 2*quietZone
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, total, total)
+	fmt.Fprintf(&buffer, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, total, total)
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				fmt.Fprintf(&buffer, "M%d %dh1v1h-1z", x+quietZone, y+quietZone)
+			}
+		}
+	}
+	buffer.WriteString(`"/></svg>`)
+	return buffer.Bytes()
+}
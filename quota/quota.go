@@ -0,0 +1,128 @@
+// Package quota applies the monthly link quotas of plans: how many links a
+// user may create per calendar month (UTC), such as 100 on the free plan
+// and 10000 on a paid one. Creators are warned once they used WarnPercent
+// of their quota, before creation is refused at the quota itself.
+package quota
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener/retention"
+)
+
+// WarnPercent is the share of a quota from which creators are warned
+const WarnPercent = 80
+
+// Policy holds the links each plan may create per month; plans absent or
+// with zero links create without limit
+type Policy struct {
+	Links map[string]int
+}
+
+// ParsePolicy parses a comma-separated list of plan=links, such as
+// free=100,pro=10000
+func ParsePolicy(spec string) (Policy, error) {
+	policy := Policy{Links: map[string]int{}}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		plan, rawLinks, ok := strings.Cut(entry, "=")
+		plan = strings.ToLower(strings.TrimSpace(plan))
+		if !ok || !retention.ValidPlanName(plan) {
+			return Policy{}, fmt.Errorf("invalid entry %q, expected plan=links", entry)
+		}
+		links, err := strconv.Atoi(strings.TrimSpace(rawLinks))
+		if err != nil || links < 0 {
+			return Policy{}, fmt.Errorf("quota of plan %s is not a non-negative number of links", plan)
+		}
+		if _, duplicate := policy.Links[plan]; duplicate {
+			return Policy{}, fmt.Errorf("plan %s is listed twice", plan)
+		}
+		policy.Links[plan] = links
+	}
+	return policy, nil
+}
+
+// PolicyFromEnv reads LINK_QUOTAS
+func PolicyFromEnv() (Policy, error) {
+	return ParsePolicy(os.Getenv("LINK_QUOTAS"))
+}
+
+// LinksFor returns the links plan may create per month, 0 for no limit.
+// An empty plan is the free plan.
+func (p Policy) LinksFor(plan string) int {
+	if plan == "" {
+		plan = retention.PlanFree
+	}
+	return p.Links[plan]
+}
+
+// Known reports whether plan is listed, with or without a quota
+func (p Policy) Known(plan string) bool {
+	_, ok := p.Links[plan]
+	return ok
+}
+
+// Plans lists the plans with a quota, sorted
+func (p Policy) Plans() []string {
+	var plans []string
+	for plan, links := range p.Links {
+		if links > 0 {
+			plans = append(plans, plan)
+		}
+	}
+	sort.Strings(plans)
+	return plans
+}
+
+// PeriodStart returns the start of the month now counts against, at
+// midnight UTC on its first day
+func PeriodStart(now time.Time) time.Time {
+	year, month, _ := now.UTC().Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Usage is how much of a quota a user used this month
+type Usage struct {
+	Plan  string
+	Used  int       // links created since Since
+	Limit int       // links the plan may create per month
+	Since time.Time // start of the month
+}
+
+// Percent returns the share of the quota used, rounded down
+func (u Usage) Percent() int {
+	return u.Used * 100 / u.Limit
+}
+
+// Exhausted reports whether no more links may be created this month
+func (u Usage) Exhausted() bool {
+	return u.Used >= u.Limit
+}
+
+// Warned reports whether creators are warned about the usage
+func (u Usage) Warned() bool {
+	return u.Used*100 >= u.Limit*WarnPercent
+}
+
+// StartsWarning reports whether the last link created brought the usage
+// to WarnPercent, so it is the first to be warned about
+func (u Usage) StartsWarning() bool {
+	return u.Warned() && !(Usage{Used: u.Used - 1, Limit: u.Limit}).Warned()
+}
+
+// Warning describes the usage for creators, empty when not warned
+func (u Usage) Warning() string {
+	if !u.Warned() {
+		return ""
+	}
+	return fmt.Sprintf("%d of the %d links the %s plan may create this month are used (%d%%); links past the quota are refused until %s",
+		u.Used, u.Limit, u.Plan, u.Percent(), u.Since.AddDate(0, 1, 0).Format("2006-01-02"))
+}
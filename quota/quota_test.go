@@ -0,0 +1,54 @@
+package quota
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	for _, spec := range []string{"free", "free=-1", "free=10,free=20", "Pro Plan=10", "free=many"} {
+		if _, err := ParsePolicy(spec); err == nil {
+			t.Errorf("ParsePolicy(%q) should fail", spec)
+		}
+	}
+	policy, err := ParsePolicy(" free=100, PRO=10000 ,team=0,")
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	if want := map[string]int{"free": 100, "pro": 10000, "team": 0}; !reflect.DeepEqual(policy.Links, want) {
+		t.Errorf("Links = %v, want %v", policy.Links, want)
+	}
+	if got, want := policy.Plans(), []string{"free", "pro"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Plans() = %v, want %v", got, want)
+	}
+	if policy.LinksFor("") != 100 || policy.LinksFor("team") != 0 || policy.LinksFor("enterprise") != 0 {
+		t.Error("LinksFor() should read the free plan for an empty one and no limit for unlisted plans")
+	}
+}
+
+func TestUsage(t *testing.T) {
+	since := PeriodStart(time.Date(2024, 2, 29, 23, 0, 0, 0, time.FixedZone("", -3*3600)))
+	if want := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC); !since.Equal(want) {
+		t.Errorf("PeriodStart() = %v, want %v in UTC", since, want)
+	}
+
+	for _, tc := range []struct {
+		used                      int
+		warned, starts, exhausted bool
+	}{
+		{79, false, false, false},
+		{80, true, true, false},
+		{99, true, false, false},
+		{100, true, false, true},
+	} {
+		usage := Usage{Plan: "free", Used: tc.used, Limit: 100, Since: since}
+		if usage.Warned() != tc.warned || usage.StartsWarning() != tc.starts || usage.Exhausted() != tc.exhausted {
+			t.Errorf("%d used: warned %t, starts warning %t, exhausted %t, want %t, %t, %t", tc.used,
+				usage.Warned(), usage.StartsWarning(), usage.Exhausted(), tc.warned, tc.starts, tc.exhausted)
+		}
+		if (usage.Warning() != "") != tc.warned {
+			t.Errorf("%d used: Warning() = %q", tc.used, usage.Warning())
+		}
+	}
+}
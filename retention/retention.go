@@ -0,0 +1,115 @@
+// Package retention applies the stats retention policy: how long the click
+// history of a link is kept, depending on the plan of the link's owner, such
+// as 30 days on the free plan and two years on a paid one. Links without an
+// owner are on the free plan.
+package retention
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PlanFree is the plan of users not assigned another one, and of links
+// without an owner
+const PlanFree = "free"
+
+var planPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// Policy holds the days of click history kept per plan; plans absent or
+// with zero days keep it for as long as the click events are kept
+type Policy struct {
+	Days map[string]int
+}
+
+// ParsePolicy parses a comma-separated list of plan=days, such as
+// free=30,pro=730
+func ParsePolicy(spec string) (Policy, error) {
+	policy := Policy{Days: map[string]int{}}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		plan, rawDays, ok := strings.Cut(entry, "=")
+		plan = strings.ToLower(strings.TrimSpace(plan))
+		if !ok || !ValidPlanName(plan) {
+			return Policy{}, fmt.Errorf("invalid entry %q, expected plan=days", entry)
+		}
+		days, err := strconv.Atoi(strings.TrimSpace(rawDays))
+		if err != nil || days < 0 {
+			return Policy{}, fmt.Errorf("retention of plan %s is not a non-negative number of days", plan)
+		}
+		if _, duplicate := policy.Days[plan]; duplicate {
+			return Policy{}, fmt.Errorf("plan %s is listed twice", plan)
+		}
+		policy.Days[plan] = days
+	}
+	return policy, nil
+}
+
+// PolicyFromEnv reads STATS_RETENTION_DAYS
+func PolicyFromEnv() (Policy, error) {
+	return ParsePolicy(os.Getenv("STATS_RETENTION_DAYS"))
+}
+
+// ValidPlanName reports whether name can name a plan: lowercase letters,
+// digits, - and _, up to 32 characters
+func ValidPlanName(name string) bool {
+	return planPattern.MatchString(name)
+}
+
+// Plans lists the plans users can be assigned, the free plan and those
+// with a retention, sorted
+func (p Policy) Plans() []string {
+	plans := []string{PlanFree}
+	for plan := range p.Days {
+		if plan != PlanFree {
+			plans = append(plans, plan)
+		}
+	}
+	sort.Strings(plans)
+	return plans
+}
+
+// Known reports whether users can be assigned plan
+func (p Policy) Known(plan string) bool {
+	_, ok := p.Days[plan]
+	return ok || plan == PlanFree
+}
+
+// DaysFor returns the days of click history kept on plan, 0 for no limit.
+// An empty plan is the free plan.
+func (p Policy) DaysFor(plan string) int {
+	if plan == "" {
+		plan = PlanFree
+	}
+	return p.Days[plan]
+}
+
+// Cutoff returns the start of the click history kept on plan at now, at
+// midnight UTC so a day is kept whole, and false when it is kept without
+// limit
+func (p Policy) Cutoff(plan string, now time.Time) (time.Time, bool) {
+	days := p.DaysFor(plan)
+	if days == 0 {
+		return time.Time{}, false
+	}
+	return now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -days), true
+}
+
+// Limited lists the plans keeping a limited click history
+func (p Policy) Limited() []string {
+	var plans []string
+	for plan, days := range p.Days {
+		if days > 0 {
+			plans = append(plans, plan)
+		}
+	}
+	sort.Strings(plans)
+	return plans
+}
@@ -0,0 +1,47 @@
+package retention
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	for _, spec := range []string{"free", "free=-1", "free=30,free=60", "Pro Plan=30", "free=lots"} {
+		if _, err := ParsePolicy(spec); err == nil {
+			t.Errorf("ParsePolicy(%q) should fail", spec)
+		}
+	}
+	policy, err := ParsePolicy(" free=30, PRO=730 ,team=0,")
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	if want := map[string]int{"free": 30, "pro": 730, "team": 0}; !reflect.DeepEqual(policy.Days, want) {
+		t.Errorf("Days = %v, want %v", policy.Days, want)
+	}
+	if got, want := policy.Plans(), []string{"free", "pro", "team"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Plans() = %v, want %v", got, want)
+	}
+	if got, want := policy.Limited(), []string{"free", "pro"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Limited() = %v, want %v", got, want)
+	}
+	if policy.Known("enterprise") || !policy.Known("team") {
+		t.Error("Known() should accept listed plans only")
+	}
+}
+
+func TestCutoff(t *testing.T) {
+	policy := Policy{Days: map[string]int{"free": 30}}
+	now := time.Date(2024, 3, 31, 15, 4, 5, 0, time.UTC)
+
+	cutoff, limited := policy.Cutoff("", now)
+	if want := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC); !limited || !cutoff.Equal(want) {
+		t.Errorf("Cutoff(free) = %v, %v, want %v", cutoff, limited, want)
+	}
+	if _, limited := policy.Cutoff("pro", now); limited {
+		t.Error("plans without a retention should keep their history")
+	}
+	if _, limited := (Policy{}).Cutoff(PlanFree, now); limited {
+		t.Error("an empty policy should keep all history")
+	}
+}
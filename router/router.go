@@ -0,0 +1,150 @@
+// Package router wires the server's routes. They are split into surfaces,
+// route groups with their own middleware chains, so authentication, rate
+// limits and request logging can differ between the redirect hot path, the
+// public and authenticated APIs and the admin API.
+package router
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"url-shortener/chaos"
+	"url-shortener/middleware"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Surfaces of the server. Their names select per-surface settings such as
+// REQUEST_LOG_<SURFACE>.
+const (
+	SurfaceRedirect = "redirect" // short links and pixels followed by browsers
+	SurfacePublic   = "public"   // API usable without credentials, API keys optional
+	SurfaceAPI      = "api"      // API requiring an API key or dashboard session
+	SurfaceAdmin    = "admin"    // admin API and profiling
+)
+
+// Surfaces lists every surface
+var Surfaces = []string{SurfaceRedirect, SurfacePublic, SurfaceAPI, SurfaceAdmin}
+
+// Headers API clients send and read, besides the simple ones
+var (
+	apiRequestHeaders  = []string{"Content-Type", "Authorization", "X-Key-ID", "X-Timestamp", "X-Signature", "X-Request-ID"}
+	apiResponseHeaders = []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After", "X-Request-ID"}
+)
+
+// CORS policy of each surface unless configured otherwise with
+// CORS_<SURFACE>_*. Redirects are only ever read; the APIs are called by
+// dashboards and scripts hosted anywhere, authenticating with bearer tokens
+// rather than cookies.
+var corsPolicies = map[string]middleware.CORSPolicy{
+	SurfaceRedirect: {
+		Origins:       []string{"*"},
+		Methods:       []string{http.MethodGet, http.MethodHead},
+		ExposeHeaders: []string{"X-Request-ID"},
+		MaxAge:        24 * time.Hour,
+	},
+	SurfacePublic: {
+		Origins:       []string{"*"},
+		Methods:       []string{http.MethodGet, http.MethodPost},
+		Headers:       apiRequestHeaders,
+		ExposeHeaders: apiResponseHeaders,
+		MaxAge:        10 * time.Minute,
+	},
+	SurfaceAPI: {
+		Origins:       []string{"*"},
+		Methods:       []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+		Headers:       apiRequestHeaders,
+		ExposeHeaders: apiResponseHeaders,
+		MaxAge:        10 * time.Minute,
+	},
+	SurfaceAdmin: {
+		Origins:       []string{"*"},
+		Methods:       []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+		Headers:       apiRequestHeaders,
+		ExposeHeaders: apiResponseHeaders,
+		MaxAge:        10 * time.Minute,
+	},
+}
+
+// New returns the server's router with every route registered
+func New() *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	// Believe client addresses forwarded by TRUSTED_PROXIES only, and by no
+	// proxy when unset
+	if _, err := utils.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES")); err != nil {
+		slog.Warn("Invalid TRUSTED_PROXIES, not trusting any proxy", "error", err)
+	}
+	if err := r.SetTrustedProxies(utils.TrustedProxies()); err != nil {
+		slog.Error("Failed to set trusted proxies", "error", err)
+	}
+
+	// Tag every request with an ID echoed in X-Request-ID
+	r.Use(middleware.RequestID())
+
+	// Attribute database queries to the calling route
+	r.Use(middleware.RouteContext())
+
+	// Record per-endpoint availability and latency for the status page
+	r.Use(middleware.RequestMetrics())
+
+	// Write error responses with their stable error codes
+	r.Use(middleware.Errors())
+
+	// Give every handler of a request the same view of policies
+	r.Use(middleware.Policy())
+
+	// Inject faults into requests when enabled for resilience tests
+	if chaos.Enabled() {
+		r.Use(middleware.Chaos())
+	}
+
+	// Unknown paths are logged with the public surface
+	r.NoRoute(middleware.RequestLog(SurfacePublic))
+
+	registerSurface(r, SurfaceRedirect, registerRedirectRoutes)
+	registerSurface(r, SurfacePublic, func(r *gin.Engine) {
+		// Swagger documentation routes, per API version
+		registerSwagger(r)
+		registerPublicRoutes(r)
+	})
+	registerSurface(r, SurfaceAPI, registerAPIRoutes)
+	registerSurface(r, SurfaceAdmin, registerAdminRoutes)
+	return r
+}
+
+// surface returns a route group of the named surface, logging its requests
+// and applying its CORS policy before running handlers
+func surface(r *gin.Engine, name, path string, handlers ...gin.HandlerFunc) *gin.RouterGroup {
+	return r.Group(path, append(surfaceHandlers(name), handlers...)...)
+}
+
+func surfaceHandlers(name string) []gin.HandlerFunc {
+	return []gin.HandlerFunc{middleware.RequestLog(name), middleware.CORS(name, corsPolicies[name])}
+}
+
+// registerSurface registers the routes of a surface with register, then
+// answers preflight requests to their paths with the surface's CORS policy
+func registerSurface(r *gin.Engine, name string, register func(*gin.Engine)) {
+	registered := make(map[string]bool)
+	for _, route := range r.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	register(r)
+
+	preflight := append(surfaceHandlers(name), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	for _, route := range r.Routes() {
+		if registered[route.Method+" "+route.Path] || registered[http.MethodOptions+" "+route.Path] {
+			continue
+		}
+		r.OPTIONS(route.Path, preflight...)
+		registered[http.MethodOptions+" "+route.Path] = true
+	}
+}
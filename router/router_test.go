@@ -0,0 +1,135 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewRegistersEverySurface(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := New()
+
+	registered := make(map[string]bool)
+	for _, route := range r.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for _, route := range []string{
+		"GET /:shortCode",
+		"GET /:shortCode/qr",
+		"POST /shorten",
+		"GET /stats/tags/:tag",
+		"POST /auth/login",
+		"GET /auth/sessions",
+		"DELETE /links/:shortCode",
+		"POST /admin/expired-links/cleanup",
+		"GET /swagger/*any",
+	} {
+		if !registered[route] {
+			t.Errorf("%s is not registered", route)
+		}
+	}
+}
+
+// Each surface runs its own chain behind the global middleware
+func TestSurfaceMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ADMIN_TOKEN", "router-admin-token")
+	r := New()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{name: "public", method: http.MethodGet, path: "/version", want: http.StatusOK},
+		{name: "admin requires the token", method: http.MethodGet, path: "/admin/mirror", want: http.StatusUnauthorized},
+		{name: "sessions require a session", method: http.MethodGet, path: "/auth/sessions", want: http.StatusUnauthorized},
+		{name: "links require an API key", method: http.MethodGet, path: "/links", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.path, nil))
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, recorder.Code, tt.want)
+		}
+		if recorder.Header().Get("X-Request-ID") == "" {
+			t.Errorf("%s: no X-Request-ID", tt.name)
+		}
+	}
+}
+
+func TestSurfaceCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ADMIN_TOKEN", "router-admin-token")
+	t.Setenv("CORS_ADMIN_ORIGINS", "https://dashboard.example.com")
+	r := New()
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		origin  string
+		want    int
+		origins string
+		methods string
+	}{
+		{name: "redirect preflight", method: http.MethodOptions, path: "/abc123", origin: "https://blog.example.org", want: http.StatusNoContent, origins: "*", methods: "GET, HEAD"},
+		{name: "API preflight", method: http.MethodOptions, path: "/links/abc123", origin: "https://blog.example.org", want: http.StatusNoContent, origins: "*", methods: "GET, POST, PUT, DELETE"},
+		{name: "admin preflight from the dashboard", method: http.MethodOptions, path: "/admin/urls/abc123", origin: "https://dashboard.example.com", want: http.StatusNoContent, origins: "https://dashboard.example.com", methods: "GET, POST, PUT, DELETE"},
+		{name: "admin preflight from elsewhere", method: http.MethodOptions, path: "/admin/urls/abc123", origin: "https://blog.example.org", want: http.StatusForbidden},
+		{name: "public request", method: http.MethodGet, path: "/version", origin: "https://blog.example.org", want: http.StatusOK, origins: "*"},
+		// Handled, but without CORS headers the browser keeps the response from the script
+		{name: "admin request from elsewhere", method: http.MethodGet, path: "/admin/mirror", origin: "https://blog.example.org", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		request := httptest.NewRequest(tt.method, tt.path, nil)
+		request.Header.Set("Origin", tt.origin)
+		if tt.method == http.MethodOptions {
+			request.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		}
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, request)
+
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, recorder.Code, tt.want)
+		}
+		if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != tt.origins {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q, want %q", tt.name, got, tt.origins)
+		}
+		if got := recorder.Header().Get("Access-Control-Allow-Methods"); got != tt.methods {
+			t.Errorf("%s: Access-Control-Allow-Methods = %q, want %q", tt.name, got, tt.methods)
+		}
+	}
+}
+
+func TestSwaggerTarget(t *testing.T) {
+	for _, tc := range []struct {
+		baseURL  string
+		host     string
+		basePath string
+		scheme   string
+	}{
+		{"https://sho.rt", "sho.rt", "/", "https"},
+		{"http://localhost:8080/", "localhost:8080", "/", "http"},
+		{"https://example.com/links/", "example.com", "/links", "https"},
+	} {
+		host, basePath, schemes, err := swaggerTarget(tc.baseURL)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.baseURL, err)
+		}
+		if host != tc.host || basePath != tc.basePath || len(schemes) != 1 || schemes[0] != tc.scheme {
+			t.Errorf("%s: got %s %s %v", tc.baseURL, host, basePath, schemes)
+		}
+	}
+
+	if host, _, schemes, _ := swaggerTarget(""); host != "" || len(schemes) != 0 {
+		t.Errorf("without a base URL the serving host should be used, got %q %v", host, schemes)
+	}
+	if _, _, _, err := swaggerTarget("sho.rt"); err == nil {
+		t.Error("a base URL without a scheme should be rejected")
+	}
+}
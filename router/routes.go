@@ -0,0 +1,283 @@
+package router
+
+import (
+	"net/http/pprof"
+	"os"
+
+	"url-shortener/chaos"
+	"url-shortener/handlers"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerRedirectRoutes serves short links to browsers, rate limited with
+// the generous redirect scope
+func registerRedirectRoutes(r *gin.Engine) {
+	redirect := surface(r, SurfaceRedirect, "/", middleware.RateLimitScope(middleware.RateLimitRedirect))
+	{
+		redirect.GET("/:shortCode", middleware.Mirror(), middleware.Timeout(middleware.TimeoutRedirect), middleware.ResponseCache(handlers.IsLinkPreview), handlers.RedirectURL)
+		redirect.GET("/:shortCode/qr", middleware.Timeout(middleware.TimeoutDefault), handlers.GetQRCode)
+		redirect.POST("/:shortCode/report", middleware.Timeout(middleware.TimeoutDefault), handlers.ReportLink)
+		redirect.GET("/px/:shortCode/:variant", middleware.Timeout(middleware.TimeoutDefault), handlers.TrackConversion)
+		redirect.POST("/px/land", middleware.Timeout(middleware.TimeoutDefault), handlers.TrackLanding)
+		redirect.GET("/px/land", middleware.Timeout(middleware.TimeoutDefault), handlers.TrackLanding)
+		redirect.GET("/js/track.js", handlers.LandingScript)
+	}
+}
+
+// registerPublicRoutes serves the API usable without credentials. API keys
+// are optional and apply their scopes and restrictions when sent.
+func registerPublicRoutes(r *gin.Engine) {
+	public := surface(r, SurfacePublic, "/", middleware.Timeout(middleware.TimeoutDefault))
+	{
+		public.GET("/health", handlers.HealthCheck)
+		public.GET("/status", handlers.GetStatus)
+		public.GET("/version", handlers.GetVersion)
+		public.GET("/errors", handlers.ListErrorCodes)
+		public.GET("/routing/schema", handlers.GetRoutingSchema)
+		public.POST("/inbound/email", handlers.InboundEmail)
+		public.GET("/artifacts/*key", handlers.DownloadArtifact)
+		public.GET("/embed/:shortCode", middleware.RateLimit(), middleware.ResponseCache(nil), handlers.GetStatsWidget)
+		public.GET("/shared/collections/:token", middleware.RateLimit(), handlers.GetSharedCollection)
+	}
+
+	shorten := public.Group("/shorten", middleware.APIKeyAuth(), middleware.AnonymousShorten(), middleware.RateLimitScope(middleware.RateLimitShorten), middleware.RequireScope(models.ScopeCreate))
+	{
+		shorten.POST("", handlers.ShortenURL)
+		shorten.POST("/channels", handlers.ShortenChannels)
+	}
+
+	stats := public.Group("/stats", middleware.APIKeyAuth(), middleware.RateLimit(), middleware.RequireScope(models.ScopeReadStats), middleware.ResponseCache(nil))
+	{
+		stats.GET("/:shortCode", handlers.GetURLStats)
+		stats.GET("/tags/:tag", handlers.GetTagStats)
+		stats.GET("/:shortCode/timeseries", handlers.GetClickTimeseries)
+		stats.GET("/:shortCode/heatmap", handlers.GetClickHeatmap)
+		stats.GET("/:shortCode/referrers", handlers.GetTopReferrers)
+		stats.GET("/:shortCode/uniques", handlers.GetUniqueVisitors)
+		stats.GET("/:shortCode/variants", handlers.GetVariantStats)
+		stats.GET("/:shortCode/resets", handlers.ListStatsResets)
+	}
+
+	// Signing in, rate limited by IP address
+	login := public.Group("/auth", middleware.RateLimit())
+	{
+		login.POST("/login", handlers.Login)
+		login.POST("/refresh", handlers.RefreshSession)
+	}
+}
+
+// registerAPIRoutes serves the API requiring an API key, or a dashboard
+// session on link management and the dashboard's own routes
+func registerAPIRoutes(r *gin.Engine) {
+	// Links owned by the user of the calling API key or dashboard session
+	links := surface(r, SurfaceAPI, "/links", middleware.Timeout(middleware.TimeoutDefault), middleware.SessionKeyAuth(), middleware.APIKeyAuth(), middleware.RequireKeyOwner(), middleware.RateLimit())
+	{
+		links.GET("", middleware.RequireScope(models.ScopeReadStats), handlers.ListLinks)
+		links.PUT("/:shortCode", middleware.RequireScope(models.ScopeUpdate), handlers.UpdateLink)
+		links.DELETE("/:shortCode", middleware.RequireScope(models.ScopeDelete), handlers.DeleteLink)
+		links.POST("/:shortCode/stats/reset", middleware.RequireScope(models.ScopeUpdate), handlers.ResetLinkStats)
+		links.GET("/:shortCode/aliases", middleware.RequireScope(models.ScopeReadStats), handlers.ListRenamedAliases)
+		links.GET("/:shortCode/versions", middleware.RequireScope(models.ScopeReadStats), handlers.ListLinkVersions)
+		links.GET("/:shortCode/rules", middleware.RequireScope(models.ScopeReadStats), handlers.ListRoutingRules)
+		links.PUT("/:shortCode/rules", middleware.RequireScope(models.ScopeUpdate), handlers.ReplaceRoutingRules)
+		links.POST("/:shortCode/rules", middleware.RequireScope(models.ScopeUpdate), handlers.AddRoutingRule)
+		links.PUT("/:shortCode/rules/:index", middleware.RequireScope(models.ScopeUpdate), handlers.UpdateRoutingRule)
+		links.DELETE("/:shortCode/rules/:index", middleware.RequireScope(models.ScopeUpdate), handlers.DeleteRoutingRule)
+		links.DELETE("/:shortCode/aliases/:alias", middleware.RequireScope(models.ScopeUpdate), handlers.RetireRenamedAlias)
+		links.GET("/:shortCode/annotations", middleware.RequireScope(models.ScopeReadStats), handlers.ListAnnotations)
+		links.POST("/:shortCode/annotations", middleware.RequireScope(models.ScopeUpdate), handlers.CreateAnnotation)
+		links.DELETE("/:shortCode/annotations/:id", middleware.RequireScope(models.ScopeUpdate), handlers.DeleteAnnotation)
+		links.GET("/:shortCode/references", middleware.RequireScope(models.ScopeReadStats), handlers.ListReferences)
+		links.POST("/:shortCode/references", middleware.RequireScope(models.ScopeUpdate), handlers.CreateReference)
+		links.PUT("/:shortCode/references/:id", middleware.RequireScope(models.ScopeUpdate), handlers.UpdateReference)
+		links.DELETE("/:shortCode/references/:id", middleware.RequireScope(models.ScopeUpdate), handlers.DeleteReference)
+	}
+
+	// Clicks of the links owned by the user of the calling API key per
+	// destination domain, outside the stats group as its response cache is
+	// shared by every caller
+	destinations := surface(r, SurfaceAPI, "/stats/destinations", middleware.Timeout(middleware.TimeoutDefault), middleware.APIKeyAuth(), middleware.RequireKeyOwner(), middleware.RateLimit())
+	{
+		destinations.GET("", middleware.RequireScope(models.ScopeReadStats), handlers.GetDestinationStats)
+	}
+
+	// Housekeeping reports over the links of the user of the calling API key
+	reports := surface(r, SurfaceAPI, "/reports", middleware.Timeout(middleware.TimeoutDefault), middleware.APIKeyAuth(), middleware.RequireKeyOwner(), middleware.RateLimit())
+	{
+		reports.GET("/duplicates", middleware.RequireScope(models.ScopeReadStats), handlers.GetDuplicatesReport)
+	}
+
+	// Funnels across the links of the user of the calling API key
+	funnels := surface(r, SurfaceAPI, "/funnels", middleware.Timeout(middleware.TimeoutDefault), middleware.APIKeyAuth(), middleware.RequireKeyOwner(), middleware.RateLimit())
+	{
+		funnels.GET("", middleware.RequireScope(models.ScopeReadStats), handlers.ListFunnels)
+		funnels.POST("", middleware.RequireScope(models.ScopeUpdate), handlers.CreateFunnel)
+		funnels.DELETE("/:id", middleware.RequireScope(models.ScopeUpdate), handlers.DeleteFunnel)
+		funnels.GET("/:id/report", middleware.RequireScope(models.ScopeReadStats), handlers.GetFunnelReport)
+	}
+
+	// Collections owned by or shared with the user of the calling API key
+	collections := surface(r, SurfaceAPI, "/collections", middleware.Timeout(middleware.TimeoutDefault), middleware.APIKeyAuth(), middleware.RequireKeyOwner(), middleware.RateLimit())
+	{
+		collections.GET("", middleware.RequireScope(models.ScopeReadStats), handlers.ListCollections)
+		collections.POST("", middleware.RequireScope(models.ScopeUpdate), handlers.CreateCollection)
+		collections.GET("/:id", middleware.RequireScope(models.ScopeReadStats), handlers.GetCollection)
+		collections.PUT("/:id", middleware.RequireScope(models.ScopeUpdate), handlers.UpdateCollection)
+		collections.DELETE("/:id", middleware.RequireScope(models.ScopeUpdate), handlers.DeleteCollection)
+		collections.GET("/:id/members", middleware.RequireScope(models.ScopeReadStats), handlers.ListCollectionMembers)
+		collections.PUT("/:id/members", middleware.RequireScope(models.ScopeUpdate), handlers.GrantCollectionRole)
+		collections.DELETE("/:id/members/:userId", middleware.RequireScope(models.ScopeUpdate), handlers.RevokeCollectionRole)
+		collections.POST("/:id/share", middleware.RequireScope(models.ScopeUpdate), handlers.ShareCollection)
+		collections.DELETE("/:id/share", middleware.RequireScope(models.ScopeUpdate), handlers.UnshareCollection)
+	}
+
+	// Links of the user of the calling API key by their CMS identifier
+	external := surface(r, SurfaceAPI, "/external", middleware.Timeout(middleware.TimeoutDefault), middleware.APIKeyAuth(), middleware.RequireKeyOwner(), middleware.RateLimit())
+	{
+		external.PUT("/:external_id", middleware.RequireScope(models.ScopeCreate), middleware.RequireScope(models.ScopeUpdate), handlers.UpsertExternalLink)
+	}
+
+	// Webhooks of the user of the calling API key
+	webhooks := surface(r, SurfaceAPI, "/webhooks", middleware.Timeout(middleware.TimeoutDefault), middleware.APIKeyAuth(), middleware.RequireKeyOwner(), middleware.RateLimit())
+	{
+		webhooks.GET("/events", handlers.ListWebhookEvents)
+		webhooks.GET("", middleware.RequireScope(models.ScopeReadStats), handlers.ListWebhooks)
+		webhooks.POST("", middleware.RequireScope(models.ScopeUpdate), handlers.CreateWebhook)
+		webhooks.GET("/:id", middleware.RequireScope(models.ScopeReadStats), handlers.GetWebhook)
+		webhooks.PUT("/:id", middleware.RequireScope(models.ScopeUpdate), handlers.UpdateWebhook)
+		webhooks.DELETE("/:id", middleware.RequireScope(models.ScopeUpdate), handlers.DeleteWebhook)
+		webhooks.GET("/:id/deliveries", middleware.RequireScope(models.ScopeReadStats), handlers.ListWebhookDeliveries)
+	}
+
+	// Redirect dry runs for links owned by the user of the calling API key
+	debug := surface(r, SurfaceAPI, "/debug", middleware.Timeout(middleware.TimeoutDefault), middleware.APIKeyAuth(), middleware.RequireKeyOwner(), middleware.RateLimit())
+	{
+		debug.GET("/redirect/:shortCode", middleware.RequireScope(models.ScopeReadStats), handlers.SimulateRedirect)
+	}
+
+	// Dashboard sessions, rate limited by IP address before the session is
+	// checked so invalid tokens are limited too
+	sessions := surface(r, SurfaceAPI, "/auth", middleware.Timeout(middleware.TimeoutDefault), middleware.RateLimit(), middleware.SessionAuth())
+	{
+		sessions.POST("/logout", handlers.Logout)
+		sessions.GET("/sessions", handlers.ListSessions)
+		sessions.DELETE("/sessions/:id", handlers.RevokeSession)
+		sessions.POST("/2fa/enroll", handlers.EnrollTwoFactor)
+		sessions.POST("/2fa/verify", handlers.VerifyTwoFactor)
+		sessions.POST("/2fa/backup-codes", handlers.RegenerateBackupCodes)
+		sessions.POST("/2fa/disable", handlers.DisableTwoFactor)
+	}
+}
+
+// registerAdminRoutes serves the admin API and, with ENABLE_PPROF=true,
+// profiling endpoints
+func registerAdminRoutes(r *gin.Engine) {
+	admin := surface(r, SurfaceAdmin, "/admin", middleware.Timeout(middleware.TimeoutDefault), middleware.SessionKeyAuth(), middleware.APIKeyAuth(), middleware.AdminAuth(), middleware.RateLimit())
+	{
+		admin.GET("/stats", handlers.GetGlobalStats)
+		admin.GET("/urls", handlers.ListURLs)
+		admin.GET("/urls/export", handlers.ExportURLs)
+		admin.POST("/urls/import", handlers.ImportURLs)
+		admin.PUT("/urls/:shortCode", handlers.UpdateURL)
+		admin.DELETE("/urls/:shortCode", handlers.DeleteURL)
+		admin.POST("/urls/:shortCode/lock", handlers.LockURL)
+		admin.POST("/urls/:shortCode/unlock", handlers.UnlockURL)
+		admin.POST("/urls/:shortCode/disable", handlers.DisableURL)
+		admin.POST("/urls/:shortCode/enable", handlers.EnableURL)
+		admin.POST("/urls/:shortCode/expiry-exemption", handlers.ExemptURLExpiry)
+		admin.DELETE("/urls/:shortCode/expiry-exemption", handlers.RemoveURLExpiryExemption)
+		admin.POST("/urls/:shortCode/stats/reset", handlers.ResetURLStats)
+		admin.POST("/expired-links/cleanup", handlers.CleanUpExpiredLinks)
+		admin.POST("/links/export", handlers.ExportLinks)
+		admin.POST("/links/import", handlers.ImportLinks)
+		admin.GET("/links/bulk", handlers.ListBulkOperations)
+		admin.POST("/links/bulk", handlers.StartBulkOperation)
+		admin.GET("/links/bulk/:id", handlers.GetBulkOperation)
+		admin.GET("/artifacts", handlers.ListArtifacts)
+		admin.POST("/artifacts", handlers.CreateArtifact)
+		admin.GET("/artifacts/:id", handlers.GetArtifact)
+		admin.DELETE("/artifacts/:id", handlers.DeleteArtifact)
+		admin.GET("/approvals", handlers.ListPendingURLs)
+		admin.POST("/approvals/:shortCode/approve", handlers.ApproveURL)
+		admin.POST("/approvals/:shortCode/reject", handlers.RejectURL)
+		admin.GET("/safety-rules", handlers.ListSafetyRules)
+		admin.POST("/safety-rules", handlers.CreateSafetyRule)
+		admin.PUT("/safety-rules/:id", handlers.UpdateSafetyRule)
+		admin.DELETE("/safety-rules/:id", handlers.DeleteSafetyRule)
+		admin.GET("/tag-rules", handlers.ListTagRules)
+		admin.POST("/tag-rules", handlers.CreateTagRule)
+		admin.POST("/tag-rules/backfill", handlers.BackfillTagRules)
+		admin.PUT("/tag-rules/:id", handlers.UpdateTagRule)
+		admin.DELETE("/tag-rules/:id", handlers.DeleteTagRule)
+		admin.GET("/domains", handlers.ListDomains)
+		admin.POST("/domains", handlers.CreateDomain)
+		admin.PUT("/domains/:id", handlers.UpdateDomain)
+		admin.DELETE("/domains/:id", handlers.DeleteDomain)
+		admin.POST("/domains/:id/verify", handlers.VerifyDomain)
+		admin.GET("/short-domains", handlers.ListShortDomains)
+		admin.POST("/short-domains", handlers.CreateShortDomain)
+		admin.DELETE("/short-domains/:id", handlers.DeleteShortDomain)
+		admin.GET("/shadow-bans", handlers.ListShadowBans)
+		admin.POST("/shadow-bans", handlers.CreateShadowBan)
+		admin.DELETE("/shadow-bans/:id", handlers.DeleteShadowBan)
+		admin.GET("/abuse-scores", handlers.ListAbuseScores)
+		admin.PUT("/abuse-scores/:creator", handlers.OverrideAbuseScore)
+		admin.DELETE("/abuse-scores/:creator", handlers.ResetAbuseScore)
+		admin.GET("/abuse-reports", handlers.ListAbuseReports)
+		admin.GET("/api-keys", handlers.ListAPIKeys)
+		admin.POST("/api-keys", handlers.CreateAPIKey)
+		admin.DELETE("/api-keys/:id", handlers.RevokeAPIKey)
+		admin.POST("/api-keys/:id/rotate", handlers.RotateAPIKey)
+		admin.GET("/users", handlers.ListUsers)
+		admin.POST("/users", handlers.CreateUser)
+		admin.POST("/users/:id/logout", handlers.RevokeUserSessions)
+		admin.PUT("/users/:id/plan", handlers.SetUserPlan)
+		admin.PUT("/users/:id/attribution-window", handlers.SetUserAttributionWindow)
+		admin.POST("/users/:id/impersonate", handlers.StartImpersonation)
+		admin.GET("/impersonations", handlers.ListImpersonations)
+		admin.DELETE("/impersonations/:id", handlers.EndImpersonation)
+		admin.GET("/health", handlers.VerboseHealthCheck)
+		admin.GET("/db-metrics", handlers.GetDBMetrics)
+		admin.GET("/click-reconciliation", handlers.GetClickReconciliation)
+		admin.GET("/integrity", handlers.GetIntegrity)
+		admin.POST("/integrity/verify", handlers.VerifyIntegrity)
+		admin.GET("/mirror", handlers.GetMirrorStatus)
+		if chaos.Enabled() {
+			admin.GET("/chaos", handlers.GetChaos)
+			admin.PUT("/chaos", handlers.UpdateChaos)
+		}
+		admin.GET("/hooks/triggers", handlers.ListHookTriggers)
+		admin.GET("/hooks/triggers/:event/sample", handlers.SampleHookTrigger)
+		admin.GET("/hooks", handlers.ListHookSubscriptions)
+		admin.POST("/hooks", handlers.SubscribeHook)
+		admin.DELETE("/hooks/:id", handlers.UnsubscribeHook)
+		admin.GET("/hooks/deliveries", handlers.ListHookDeliveries)
+		admin.POST("/hooks/deliveries/redrive", handlers.RedriveHookDeliveries)
+		admin.POST("/hooks/deliveries/:id/redrive", handlers.RedriveHookDelivery)
+	}
+
+	// Link change feed for downstream copies of the links, admin only
+	changes := surface(r, SurfaceAdmin, "/changes", middleware.Timeout(middleware.TimeoutDefault), middleware.APIKeyAuth(), middleware.AdminAuth(), middleware.RateLimit())
+	{
+		changes.GET("", handlers.ListLinkChanges)
+	}
+
+	// Profiling endpoints, admin only
+	if os.Getenv("ENABLE_PPROF") == "true" {
+		debug := surface(r, SurfaceAdmin, "/debug/pprof", middleware.APIKeyAuth(), middleware.AdminAuth())
+		{
+			debug.GET("/", gin.WrapF(pprof.Index))
+			debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+			debug.GET("/profile", gin.WrapF(pprof.Profile))
+			debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+			debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+			debug.GET("/trace", gin.WrapF(pprof.Trace))
+			debug.GET("/:name", func(c *gin.Context) {
+				pprof.Handler(c.Param("name")).ServeHTTP(c.Writer, c.Request)
+			})
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	docs "url-shortener/docs/v1"
+	"url-shortener/middleware"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+	swaggerfiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"github.com/swaggo/swag"
+)
+
+// Swagger access levels, set with SWAGGER_ACCESS
+const (
+	SwaggerPublic   = "public"   // anyone can browse; admin routes are only listed for admins
+	SwaggerAdmin    = "admin"    // docs require admin credentials
+	SwaggerDisabled = "disabled" // docs are not served
+)
+
+// Generated specs by API version, each produced by `make swagger-gen`
+var docsVersions = map[string]*swag.Spec{
+	"v1": docs.SwaggerInfo,
+}
+
+// LatestDocsVersion is the API version /swagger/ redirects to
+const LatestDocsVersion = "v1"
+
+// Security scheme marking operations that are only listed for admins
+const adminSecurityScheme = "AdminAuth"
+
+// SwaggerAccess reads SWAGGER_ACCESS, serving docs publicly by default
+func SwaggerAccess() string {
+	switch access := strings.ToLower(os.Getenv("SWAGGER_ACCESS")); access {
+	case SwaggerAdmin, SwaggerDisabled:
+		return access
+	case "", SwaggerPublic:
+		return SwaggerPublic
+	default:
+		slog.Warn("Unknown SWAGGER_ACCESS, serving docs publicly", "access", access)
+		return SwaggerPublic
+	}
+}
+
+// ConfigureSwagger points the "Try it out" requests of every spec at the
+// deployment: SWAGGER_BASE_URL when set, for an API served apart from the
+// short links, else baseURL (BASE_URL). Without either, the spec names no
+// host, so requests go to the host and scheme serving the docs.
+func ConfigureSwagger(baseURL string) {
+	if override := os.Getenv("SWAGGER_BASE_URL"); override != "" {
+		baseURL = override
+	}
+	host, basePath, schemes, err := swaggerTarget(baseURL)
+	if err != nil {
+		slog.Warn("Invalid Swagger base URL, sending requests to the host serving the docs", "base_url", baseURL, "error", err)
+		host, basePath, schemes, _ = swaggerTarget("")
+	}
+	for _, spec := range docsVersions {
+		spec.Host, spec.BasePath, spec.Schemes = host, basePath, schemes
+	}
+}
+
+// swaggerTarget splits baseURL into the host, base path and schemes of a
+// spec, all left to the serving host when baseURL is empty
+func swaggerTarget(baseURL string) (host, basePath string, schemes []string, err error) {
+	if baseURL == "" {
+		return "", "/", []string{}, nil
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", "", nil, errors.New("expected an http or https URL with a host")
+	}
+	basePath = strings.TrimSuffix(u.Path, "/")
+	if basePath == "" {
+		basePath = "/"
+	}
+	return u.Host, basePath, []string{u.Scheme}, nil
+}
+
+// registerSwagger serves the Swagger UI and spec of each API version under
+// /swagger/<version>/. Requests without a known version are redirected to the
+// latest one, so /swagger/index.html keeps working.
+func registerSwagger(r *gin.Engine) {
+	access := SwaggerAccess()
+	if access == SwaggerDisabled {
+		slog.Info("Swagger docs disabled")
+		return
+	}
+
+	handlers := append(surfaceHandlers(SurfacePublic), middleware.APIKeyAuth())
+	if access == SwaggerAdmin {
+		handlers = append(handlers, middleware.AdminAuth())
+	}
+
+	uiHandlers := make(map[string]gin.HandlerFunc, len(docsVersions))
+	for version, spec := range docsVersions {
+		uiHandlers[version] = ginSwagger.WrapHandler(swaggerfiles.Handler, ginSwagger.InstanceName(spec.InstanceName()))
+	}
+
+	handlers = append(handlers, func(c *gin.Context) {
+		version, file, _ := strings.Cut(strings.TrimPrefix(c.Param("any"), "/"), "/")
+		spec, ok := docsVersions[version]
+		if !ok {
+			c.Redirect(http.StatusMovedPermanently, "/swagger/"+LatestDocsVersion+"/index.html")
+			return
+		}
+
+		if file == "doc.json" {
+			serveSpec(c, spec)
+			return
+		}
+		uiHandlers[version](c)
+	})
+
+	r.GET("/swagger/*any", handlers...)
+}
+
+// serveSpec writes the spec for the caller's role, leaving out admin-only
+// operations unless the request carries admin credentials
+func serveSpec(c *gin.Context, spec *swag.Spec) {
+	doc := spec.ReadDoc()
+	if middleware.IsAdmin(c) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(doc))
+		return
+	}
+
+	public, err := withoutAdminOperations(doc)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to filter Swagger spec", "error", err)
+		c.Error(models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to load API docs"))
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", public)
+}
+
+func withoutAdminOperations(doc string) ([]byte, error) {
+	var spec map[string]interface{}
+	if err := json.Unmarshal([]byte(doc), &spec); err != nil {
+		return nil, err
+	}
+
+	paths, _ := spec["paths"].(map[string]interface{})
+	for path, item := range paths {
+		operations, _ := item.(map[string]interface{})
+		for method, operation := range operations {
+			if requiresScheme(operation, adminSecurityScheme) {
+				delete(operations, method)
+			}
+		}
+		if len(operations) == 0 {
+			delete(paths, path)
+		}
+	}
+
+	return json.Marshal(spec)
+}
+
+func requiresScheme(operation interface{}, scheme string) bool {
+	op, _ := operation.(map[string]interface{})
+	requirements, _ := op["security"].([]interface{})
+	for _, requirement := range requirements {
+		if schemes, ok := requirement.(map[string]interface{}); ok {
+			if _, ok := schemes[scheme]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
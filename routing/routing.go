@@ -0,0 +1,153 @@
+// Package routing evaluates the routing rules of a link against a visit.
+// Rules are plain data (see models.RoutingRule and schema.json): they are
+// checked in order, a rule matches when all of its conditions hold, and the
+// first matching rule's action decides where the visitor goes.
+package routing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener/models"
+)
+
+// Visit holds the attributes of a visitor that conditions compare
+type Visit struct {
+	Time     time.Time
+	Country  string // ISO 3166-1 alpha-2 code, empty when unknown
+	Device   string // bot, tablet, mobile or desktop, empty when unknown
+	OS       string // ios, android, windows, macos or linux, empty when unknown
+	Language string // primary language subtag, empty when unknown
+}
+
+// Step reports how one rule fared against a visit
+type Step struct {
+	Index   int // position of the rule, from 0
+	Rule    models.RoutingRule
+	Matched bool
+	Detail  string // why the rule did not match, or that it did
+}
+
+// Match returns the first rule matching visit, or nil when none does
+func Match(rules []models.RoutingRule, visit Visit) *models.RoutingRule {
+	for i := range rules {
+		if _, ok := failedCondition(rules[i], visit); ok {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// Evaluate is Match reporting each rule checked, up to the matching one
+func Evaluate(rules []models.RoutingRule, visit Visit) (*models.RoutingRule, []Step) {
+	steps := make([]Step, 0, len(rules))
+	for i := range rules {
+		step := Step{Index: i, Rule: rules[i]}
+		condition, ok := failedCondition(rules[i], visit)
+		if ok {
+			step.Matched = true
+			step.Detail = fmt.Sprintf("All %d conditions hold", len(rules[i].Conditions))
+			if len(rules[i].Conditions) == 0 {
+				step.Detail = "The rule has no conditions"
+			}
+			return &rules[i], append(steps, step)
+		}
+		step.Detail = describeFailure(condition, visit)
+		steps = append(steps, step)
+	}
+	return nil, steps
+}
+
+// PrimaryLanguage returns the primary subtag of the first language in an
+// Accept-Language header, e.g. "pt" for "pt-BR,pt;q=0.9,en;q=0.5"
+func PrimaryLanguage(acceptLanguage string) string {
+	first, _, _ := strings.Cut(acceptLanguage, ",")
+	first, _, _ = strings.Cut(first, ";")
+	first, _, _ = strings.Cut(strings.TrimSpace(first), "-")
+	if first == "*" {
+		return ""
+	}
+	return strings.ToLower(first)
+}
+
+// failedCondition returns the first condition of rule not holding for
+// visit, or reports true when they all hold
+func failedCondition(rule models.RoutingRule, visit Visit) (models.RoutingCondition, bool) {
+	for _, condition := range rule.Conditions {
+		if !holds(condition, visit) {
+			return condition, false
+		}
+	}
+	return models.RoutingCondition{}, true
+}
+
+func holds(condition models.RoutingCondition, visit Visit) bool {
+	if condition.Field == models.RoutingFieldTime {
+		at, err := time.Parse(time.RFC3339, firstValue(condition))
+		if err != nil {
+			return false
+		}
+		if condition.Op == models.RoutingOpBefore {
+			return visit.Time.Before(at)
+		}
+		return !visit.Time.Before(at)
+	}
+
+	value := attribute(condition.Field, visit)
+	found := false
+	for _, candidate := range condition.Values {
+		if value != "" && strings.EqualFold(candidate, value) {
+			found = true
+			break
+		}
+	}
+	if condition.Op == models.RoutingOpNotIn {
+		return !found
+	}
+	return found
+}
+
+// attribute returns the value of field for visit, empty when unknown
+func attribute(field string, visit Visit) string {
+	switch field {
+	case models.RoutingFieldCountry:
+		return visit.Country
+	case models.RoutingFieldDevice:
+		return visit.Device
+	case models.RoutingFieldOS:
+		return visit.OS
+	case models.RoutingFieldLanguage:
+		return visit.Language
+	case models.RoutingFieldWeekday:
+		return strings.ToLower(visit.Time.UTC().Weekday().String()[:3])
+	case models.RoutingFieldHour:
+		return strconv.Itoa(visit.Time.UTC().Hour())
+	}
+	return ""
+}
+
+func firstValue(condition models.RoutingCondition) string {
+	if len(condition.Values) == 0 {
+		return ""
+	}
+	return condition.Values[0]
+}
+
+// describeFailure explains why condition does not hold for visit
+func describeFailure(condition models.RoutingCondition, visit Visit) string {
+	if condition.Field == models.RoutingFieldTime {
+		return fmt.Sprintf("Visit at %s is not %s %s", visit.Time.UTC().Format(time.RFC3339), condition.Op, firstValue(condition))
+	}
+
+	value := attribute(condition.Field, visit)
+	if value == "" {
+		value = "unknown"
+	}
+	values := strings.Join(condition.Values, ", ")
+	if condition.Op == models.RoutingOpNotIn {
+		return fmt.Sprintf("The visitor's %s is %s, one of the excluded %s", condition.Field, value, values)
+	}
+	return fmt.Sprintf("The visitor's %s is %s, not one of %s", condition.Field, value, values)
+}
@@ -0,0 +1,218 @@
+package routing
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"url-shortener/models"
+)
+
+// Monday 2024-01-15, 09:30 UTC
+var monday = time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+
+func redirectTo(url string) models.RoutingAction {
+	return models.RoutingAction{Type: models.RoutingActionRedirect, URL: url}
+}
+
+func TestMatch(t *testing.T) {
+	rules := []models.RoutingRule{
+		{Name: "staff", Conditions: []models.RoutingCondition{{Field: "country", Op: "in", Values: []string{"VN"}}}, Action: models.RoutingAction{Type: models.RoutingActionDestination}},
+		{Name: "mobile-us", Conditions: []models.RoutingCondition{
+			{Field: "country", Op: "in", Values: []string{"us", "CA"}},
+			{Field: "device", Op: "in", Values: []string{"mobile", "tablet"}},
+		}, Action: redirectTo("https://m.example.com/")},
+		{Name: "weekend", Conditions: []models.RoutingCondition{{Field: "weekday", Op: "in", Values: []string{"sat", "sun"}}}, Action: redirectTo("https://example.com/weekend")},
+		{Name: "non-english", Conditions: []models.RoutingCondition{{Field: "language", Op: "not_in", Values: []string{"en"}}}, Action: redirectTo("https://example.com/intl")},
+	}
+	tests := []struct {
+		name  string
+		visit Visit
+		want  string // name of the matching rule, empty for none
+	}{
+		{name: "first match wins", visit: Visit{Time: monday, Country: "VN", Device: "mobile"}, want: "staff"},
+		{name: "all conditions hold", visit: Visit{Time: monday, Country: "US", Device: "mobile", Language: "en"}, want: "mobile-us"},
+		{name: "one condition fails", visit: Visit{Time: monday, Country: "US", Device: "desktop", Language: "en"}, want: ""},
+		{name: "weekday in UTC", visit: Visit{Time: time.Date(2024, 1, 14, 23, 0, 0, 0, time.FixedZone("", -3*3600)), Language: "en"}, want: ""},
+		{name: "weekday", visit: Visit{Time: time.Date(2024, 1, 13, 12, 0, 0, 0, time.UTC), Language: "en"}, want: "weekend"},
+		{name: "unknown attribute is not excluded", visit: Visit{Time: monday}, want: "non-english"},
+	}
+	for _, tt := range tests {
+		got := ""
+		if rule := Match(rules, tt.visit); rule != nil {
+			got = rule.Name
+		}
+		if got != tt.want {
+			t.Errorf("%s: matched %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTimeAndHour(t *testing.T) {
+	rules := []models.RoutingRule{
+		{Name: "launch", Conditions: []models.RoutingCondition{
+			{Field: "time", Op: "after", Values: []string{"2024-01-15T09:00:00Z"}},
+			{Field: "time", Op: "before", Values: []string{"2024-01-16T00:00:00+07:00"}},
+		}, Action: redirectTo("https://example.com/launch")},
+		{Name: "office-hours", Conditions: []models.RoutingCondition{{Field: "hour", Op: "in", Values: []string{"8", "9", "10"}}}, Action: redirectTo("https://example.com/chat")},
+	}
+	for visit, want := range map[time.Time]string{
+		monday:                     "launch",
+		monday.Add(-time.Hour):     "office-hours",
+		monday.Add(10 * time.Hour): "",
+	} {
+		got := ""
+		if rule := Match(rules, Visit{Time: visit}); rule != nil {
+			got = rule.Name
+		}
+		if got != want {
+			t.Errorf("visit at %s: matched %q, want %q", visit.Format(time.RFC3339), got, want)
+		}
+	}
+}
+
+func TestMatchAppStores(t *testing.T) {
+	rules := []models.RoutingRule{
+		{Name: "app-store", Conditions: []models.RoutingCondition{{Field: "os", Op: "in", Values: []string{"iOS"}}}, Action: redirectTo("https://apps.apple.com/app/id1")},
+		{Name: "play-store", Conditions: []models.RoutingCondition{{Field: "os", Op: "in", Values: []string{"android"}}}, Action: redirectTo("https://play.google.com/store/apps/details?id=com.example")},
+	}
+	for system, want := range map[string]string{"ios": "app-store", "android": "play-store", "windows": "", "": ""} {
+		got := ""
+		if rule := Match(rules, Visit{Time: monday, OS: system}); rule != nil {
+			got = rule.Name
+		}
+		if got != want {
+			t.Errorf("os %q: matched %q, want %q", system, got, want)
+		}
+	}
+}
+
+func TestEvaluateReportsEachRule(t *testing.T) {
+	rules := []models.RoutingRule{
+		{Conditions: []models.RoutingCondition{{Field: "device", Op: "in", Values: []string{"mobile"}}}, Action: redirectTo("https://m.example.com/")},
+		{Conditions: []models.RoutingCondition{{Field: "country", Op: "not_in", Values: []string{"US"}}}, Action: models.RoutingAction{Type: models.RoutingActionDeny}},
+		{Action: redirectTo("https://example.com/fallback")},
+	}
+	rule, steps := Evaluate(rules, Visit{Time: monday, Country: "DE", Device: "desktop"})
+	if rule != &rules[1] {
+		t.Fatalf("matched %+v, want the second rule", rule)
+	}
+	if len(steps) != 2 || steps[0].Matched || !steps[1].Matched {
+		t.Fatalf("steps = %+v, want a miss then a match", steps)
+	}
+	if !strings.Contains(steps[0].Detail, "device is desktop") {
+		t.Errorf("miss detail = %q", steps[0].Detail)
+	}
+
+	if rule, steps := Evaluate(nil, Visit{Time: monday}); rule != nil || len(steps) != 0 {
+		t.Errorf("Evaluate(nil) = %v, %v", rule, steps)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []models.RoutingRule{
+		{Name: "ios", Conditions: []models.RoutingCondition{{Field: "device", Op: "in", Values: []string{"Mobile"}}}, Action: redirectTo("https://apps.apple.com/app/id1")},
+		{Conditions: []models.RoutingCondition{{Field: "time", Op: "before", Values: []string{"2030-01-01T00:00:00Z"}}}, Action: models.RoutingAction{Type: models.RoutingActionDeny}},
+		{Action: models.RoutingAction{Type: models.RoutingActionDestination}},
+	}
+	if err := Validate(valid); err != nil {
+		t.Errorf("valid rules rejected: %v", err)
+	}
+
+	invalid := map[string]models.RoutingRule{
+		"unknown field":          {Conditions: []models.RoutingCondition{{Field: "city", Op: "in", Values: []string{"Paris"}}}, Action: redirectTo("https://example.com/")},
+		"no values":              {Conditions: []models.RoutingCondition{{Field: "country", Op: "in"}}, Action: redirectTo("https://example.com/")},
+		"unknown os":             {Conditions: []models.RoutingCondition{{Field: "os", Op: "in", Values: []string{"symbian"}}}, Action: redirectTo("https://example.com/")},
+		"bad country":            {Conditions: []models.RoutingCondition{{Field: "country", Op: "in", Values: []string{"USA"}}}, Action: redirectTo("https://example.com/")},
+		"zero-padded hour":       {Conditions: []models.RoutingCondition{{Field: "hour", Op: "in", Values: []string{"09"}}}, Action: redirectTo("https://example.com/")},
+		"time with in":           {Conditions: []models.RoutingCondition{{Field: "time", Op: "in", Values: []string{"2030-01-01T00:00:00Z"}}}, Action: redirectTo("https://example.com/")},
+		"two times":              {Conditions: []models.RoutingCondition{{Field: "time", Op: "after", Values: []string{"2030-01-01T00:00:00Z", "2031-01-01T00:00:00Z"}}}, Action: redirectTo("https://example.com/")},
+		"before with weekday":    {Conditions: []models.RoutingCondition{{Field: "weekday", Op: "before", Values: []string{"mon"}}}, Action: redirectTo("https://example.com/")},
+		"redirect without url":   {Action: models.RoutingAction{Type: models.RoutingActionRedirect}},
+		"redirect to javascript": {Action: redirectTo("javascript:alert(1)")},
+		"deny with url":          {Action: models.RoutingAction{Type: models.RoutingActionDeny, URL: "https://example.com/"}},
+		"unknown action":         {Action: models.RoutingAction{Type: "rewrite"}},
+	}
+	for name, rule := range invalid {
+		if err := Validate([]models.RoutingRule{rule}); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+
+	if err := Validate(make([]models.RoutingRule, MaxRules+1)); err == nil {
+		t.Error("more than MaxRules rules accepted")
+	}
+}
+
+func TestSchemaMatchesValidation(t *testing.T) {
+	var schema struct {
+		MaxItems int `json:"maxItems"`
+		Defs     struct {
+			Condition struct {
+				Properties struct {
+					Field struct {
+						Enum []string `json:"enum"`
+					} `json:"field"`
+				} `json:"properties"`
+			} `json:"condition"`
+			Action struct {
+				Properties struct {
+					Type struct {
+						Enum []string `json:"enum"`
+					} `json:"type"`
+				} `json:"properties"`
+			} `json:"action"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(Schema, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	if schema.MaxItems != MaxRules {
+		t.Errorf("schema allows %d rules, Validate %d", schema.MaxItems, MaxRules)
+	}
+
+	// Every field and action of the schema passes validation
+	for _, field := range schema.Defs.Condition.Properties.Field.Enum {
+		condition := models.RoutingCondition{Field: field, Op: models.RoutingOpIn, Values: []string{"fr"}}
+		switch field {
+		case models.RoutingFieldTime:
+			condition = models.RoutingCondition{Field: field, Op: models.RoutingOpAfter, Values: []string{"2030-01-01T00:00:00Z"}}
+		case models.RoutingFieldDevice:
+			condition.Values = []string{"bot"}
+		case models.RoutingFieldOS:
+			condition.Values = []string{"ios"}
+		case models.RoutingFieldWeekday:
+			condition.Values = []string{"fri"}
+		case models.RoutingFieldHour:
+			condition.Values = []string{"23"}
+		}
+		rule := models.RoutingRule{Conditions: []models.RoutingCondition{condition}, Action: models.RoutingAction{Type: models.RoutingActionDeny}}
+		if err := Validate([]models.RoutingRule{rule}); err != nil {
+			t.Errorf("schema field %s rejected: %v", field, err)
+		}
+	}
+	for _, action := range schema.Defs.Action.Properties.Type.Enum {
+		rule := models.RoutingRule{Action: models.RoutingAction{Type: action}}
+		if action == models.RoutingActionRedirect {
+			rule.Action.URL = "https://example.com/"
+		}
+		if err := Validate([]models.RoutingRule{rule}); err != nil {
+			t.Errorf("schema action %s rejected: %v", action, err)
+		}
+	}
+}
+
+func TestPrimaryLanguage(t *testing.T) {
+	for header, want := range map[string]string{
+		"pt-BR,pt;q=0.9,en;q=0.5": "pt",
+		" FR ":                    "fr",
+		"en;q=0.8":                "en",
+		"*":                       "",
+		"":                        "",
+	} {
+		if got := PrimaryLanguage(header); got != want {
+			t.Errorf("PrimaryLanguage(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
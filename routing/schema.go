@@ -0,0 +1,9 @@
+package routing
+
+import _ "embed"
+
+// Schema is the JSON schema of a link's routing rules, served at
+// GET /routing/schema
+//
+//go:embed schema.json
+var Schema []byte
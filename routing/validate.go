@@ -0,0 +1,129 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"url-shortener/models"
+)
+
+// Limits on the size of a link's rule set
+const (
+	MaxRules      = 20
+	MaxConditions = 10
+	MaxValues     = 50
+)
+
+var (
+	countryPattern  = regexp.MustCompile(`^[A-Za-z]{2}$`)
+	languagePattern = regexp.MustCompile(`^[A-Za-z]{2,8}$`)
+	hourPattern     = regexp.MustCompile(`^([0-9]|1[0-9]|2[0-3])$`)
+
+	devices  = map[string]bool{"bot": true, "tablet": true, "mobile": true, "desktop": true}
+	systems  = map[string]bool{"ios": true, "android": true, "windows": true, "macos": true, "linux": true}
+	weekdays = map[string]bool{"mon": true, "tue": true, "wed": true, "thu": true, "fri": true, "sat": true, "sun": true}
+)
+
+// Validate checks a rule set against the rules of schema.json, returning
+// the first problem found
+func Validate(rules []models.RoutingRule) error {
+	if len(rules) > MaxRules {
+		return fmt.Errorf("at most %d routing rules are allowed", MaxRules)
+	}
+	for i, rule := range rules {
+		if err := validateRule(rule); err != nil {
+			return fmt.Errorf("routing rule %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// RedirectURLs returns the URLs redirect actions of rules send visitors to
+func RedirectURLs(rules []models.RoutingRule) []string {
+	var urls []string
+	for _, rule := range rules {
+		if rule.Action.Type == models.RoutingActionRedirect {
+			urls = append(urls, rule.Action.URL)
+		}
+	}
+	return urls
+}
+
+func validateRule(rule models.RoutingRule) error {
+	if len(rule.Name) > 64 {
+		return errors.New("name may be at most 64 characters")
+	}
+	if len(rule.Conditions) > MaxConditions {
+		return fmt.Errorf("at most %d conditions are allowed", MaxConditions)
+	}
+	for i, condition := range rule.Conditions {
+		if err := validateCondition(condition); err != nil {
+			return fmt.Errorf("condition %d: %w", i+1, err)
+		}
+	}
+
+	switch rule.Action.Type {
+	case models.RoutingActionRedirect:
+		parsed, err := url.Parse(rule.Action.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return errors.New("redirect action needs an http(s) url")
+		}
+	case models.RoutingActionDestination, models.RoutingActionDeny:
+		if rule.Action.URL != "" {
+			return fmt.Errorf("%s action takes no url", rule.Action.Type)
+		}
+	default:
+		return errors.New("action type must be redirect, destination or deny")
+	}
+	return nil
+}
+
+func validateCondition(condition models.RoutingCondition) error {
+	if condition.Field == models.RoutingFieldTime {
+		if condition.Op != models.RoutingOpBefore && condition.Op != models.RoutingOpAfter {
+			return errors.New("time conditions take the before or after operator")
+		}
+		if len(condition.Values) != 1 {
+			return errors.New("time conditions take a single value")
+		}
+		if _, err := time.Parse(time.RFC3339, condition.Values[0]); err != nil {
+			return fmt.Errorf("%q is not an RFC 3339 time", condition.Values[0])
+		}
+		return nil
+	}
+
+	if condition.Op != models.RoutingOpIn && condition.Op != models.RoutingOpNotIn {
+		return fmt.Errorf("%s conditions take the in or not_in operator", condition.Field)
+	}
+	if len(condition.Values) == 0 || len(condition.Values) > MaxValues {
+		return fmt.Errorf("conditions take 1 to %d values", MaxValues)
+	}
+
+	var valid func(string) bool
+	switch condition.Field {
+	case models.RoutingFieldCountry:
+		valid = countryPattern.MatchString
+	case models.RoutingFieldDevice:
+		valid = func(value string) bool { return devices[strings.ToLower(value)] }
+	case models.RoutingFieldOS:
+		valid = func(value string) bool { return systems[strings.ToLower(value)] }
+	case models.RoutingFieldLanguage:
+		valid = languagePattern.MatchString
+	case models.RoutingFieldWeekday:
+		valid = func(value string) bool { return weekdays[strings.ToLower(value)] }
+	case models.RoutingFieldHour:
+		valid = hourPattern.MatchString
+	default:
+		return errors.New("field must be country, device, os, language, weekday, hour or time")
+	}
+	for _, value := range condition.Values {
+		if !valid(value) {
+			return fmt.Errorf("%q is not a valid %s", value, condition.Field)
+		}
+	}
+	return nil
+}
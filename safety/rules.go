@@ -0,0 +1,122 @@
+package safety
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/utils"
+)
+
+// How long loaded rules are reused before being reloaded from the database
+const rulesCacheTTL = time.Minute
+
+type compiledRule struct {
+	rule   models.SafetyRule
+	regexp *regexp.Regexp
+}
+
+var (
+	mu       sync.RWMutex
+	rules    []compiledRule
+	loadedAt time.Time
+)
+
+// RuleSet is the enabled rules as loaded at one point in time. It does not
+// change when the rules are reloaded, so a request checking several URLs
+// holds on to one set and applies the same rules to all of them.
+type RuleSet []compiledRule
+
+// Current returns the enabled rules, reloading them once they are older than
+// a minute
+func Current() RuleSet {
+	return RuleSet(currentRules())
+}
+
+// Evaluate returns the action of the first enabled rule (by priority) matching
+// rawURL, or SafetyActionAllow if no rule matches
+func Evaluate(rawURL string) (string, *models.SafetyRule) {
+	return Current().Evaluate(rawURL)
+}
+
+// Evaluate returns the action of the first rule of the set (by priority)
+// matching rawURL, or SafetyActionAllow if no rule matches
+func (s RuleSet) Evaluate(rawURL string) (string, *models.SafetyRule) {
+	for _, compiled := range s {
+		if compiled.matches(rawURL) {
+			rule := compiled.rule
+			return rule.Action, &rule
+		}
+	}
+	return models.SafetyActionAllow, nil
+}
+
+// Validate checks that a rule pattern can be compiled
+func Validate(rule *models.SafetyRule) error {
+	if rule.Type == models.SafetyRuleRegex {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("invalid regex pattern: %v", err)
+		}
+	}
+	return nil
+}
+
+// Invalidate drops the cached rules so the next evaluation reloads them
+func Invalidate() {
+	mu.Lock()
+	loadedAt = time.Time{}
+	mu.Unlock()
+}
+
+func currentRules() []compiledRule {
+	mu.RLock()
+	if time.Since(loadedAt) < rulesCacheTTL || database.DB == nil {
+		defer mu.RUnlock()
+		return rules
+	}
+	mu.RUnlock()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	var stored []models.SafetyRule
+	if err := database.DB.Where("enabled = ?", true).Order("priority desc, id asc").Find(&stored).Error; err != nil {
+		slog.Error("Failed to load safety rules, using previous set", "error", err)
+		return rules
+	}
+
+	loaded := make([]compiledRule, 0, len(stored))
+	for _, rule := range stored {
+		compiled := compiledRule{rule: rule}
+		if rule.Type == models.SafetyRuleRegex {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				slog.Warn("Skipping safety rule with invalid regex", "rule_id", rule.ID, "error", err)
+				continue
+			}
+			compiled.regexp = re
+		}
+		loaded = append(loaded, compiled)
+	}
+
+	rules = loaded
+	loadedAt = time.Now()
+	return rules
+}
+
+func (r compiledRule) matches(rawURL string) bool {
+	switch r.rule.Type {
+	case models.SafetyRuleRegex:
+		return r.regexp.MatchString(rawURL)
+	case models.SafetyRuleDomain:
+		return utils.URLMatchesDomain(rawURL, r.rule.Pattern)
+	case models.SafetyRuleKeyword:
+		return strings.Contains(strings.ToLower(rawURL), strings.ToLower(r.rule.Pattern))
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package safety
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"url-shortener/buildinfo"
+	"url-shortener/outbound"
+)
+
+// Errors returned by Screen
+var (
+	ErrPrivateDestination   = errors.New("destination is a private, loopback or otherwise non-public address")
+	ErrMaliciousDestination = errors.New("destination is flagged as malicious")
+)
+
+// How long screening may wait for DNS
+const screenResolveTimeout = 2 * time.Second
+
+// Google Safe Browsing Lookup API
+const safeBrowsingEndpoint = "https://safebrowsing.googleapis.com/v4/threatMatches:find"
+
+// Threat lists checked with Safe Browsing
+var safeBrowsingThreatTypes = []string{"MALWARE", "SOCIAL_ENGINEERING", "UNWANTED_SOFTWARE", "POTENTIALLY_HARMFUL_APPLICATION"}
+
+var (
+	resolver           = net.DefaultResolver
+	safeBrowsingClient = outbound.NewClient(outbound.Options{Timeout: 5 * time.Second, MaxRedirects: -1})
+)
+
+// Screen rejects destinations that should never be shortened, whatever the
+// brand safety rules say:
+//   - hosts that are or resolve to private, loopback, link-local and other
+//     non-public addresses, so short links cannot lead into internal
+//     networks, unless SHORTEN_ALLOW_PRIVATE_DESTINATIONS=true
+//   - URLs on Google Safe Browsing's threat lists, when SAFE_BROWSING_API_KEY
+//     is set
+//
+// Hosts that don't resolve are allowed, as is everything when Safe Browsing
+// cannot be reached, so an outage does not stop link creation.
+func Screen(ctx context.Context, rawURL string) error {
+	if allow, _ := strconv.ParseBool(os.Getenv("SHORTEN_ALLOW_PRIVATE_DESTINATIONS")); !allow {
+		if err := checkPublicDestination(ctx, rawURL); err != nil {
+			return err
+		}
+	}
+
+	if key := os.Getenv("SAFE_BROWSING_API_KEY"); key != "" {
+		threat, err := lookupSafeBrowsing(ctx, key, rawURL)
+		if err != nil {
+			slog.WarnContext(ctx, "Safe Browsing lookup failed, allowing the URL", "error", err)
+			return nil
+		}
+		if threat != "" {
+			return fmt.Errorf("%w (%s)", ErrMaliciousDestination, threat)
+		}
+	}
+	return nil
+}
+
+// checkPublicDestination refuses URLs whose host is, or resolves to, an
+// address the outbound client would refuse to connect to
+func checkPublicDestination(ctx context.Context, rawURL string) error {
+	if err := outbound.CheckURL(rawURL); err != nil {
+		if errors.Is(err, outbound.ErrBlockedDestination) {
+			return ErrPrivateDestination
+		}
+		return err
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if _, err := netip.ParseAddr(parsed.Hostname()); err == nil {
+		return nil // a public address, checked above
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, screenResolveTimeout)
+	defer cancel()
+	addrs, err := resolver.LookupNetIP(ctx, "ip", parsed.Hostname())
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if outbound.Blocked(addr) {
+			return ErrPrivateDestination
+		}
+	}
+	return nil
+}
+
+type safeBrowsingRequest struct {
+	Client struct {
+		ClientID      string `json:"clientId"`
+		ClientVersion string `json:"clientVersion"`
+	} `json:"client"`
+	ThreatInfo struct {
+		ThreatTypes      []string            `json:"threatTypes"`
+		PlatformTypes    []string            `json:"platformTypes"`
+		ThreatEntryTypes []string            `json:"threatEntryTypes"`
+		ThreatEntries    []map[string]string `json:"threatEntries"`
+	} `json:"threatInfo"`
+}
+
+type safeBrowsingResponse struct {
+	Matches []struct {
+		ThreatType string `json:"threatType"`
+	} `json:"matches"`
+}
+
+// lookupSafeBrowsing returns the threat type rawURL is listed under, or ""
+// when it is not listed
+func lookupSafeBrowsing(ctx context.Context, key, rawURL string) (string, error) {
+	var body safeBrowsingRequest
+	body.Client.ClientID = "url-shortener"
+	body.Client.ClientVersion = buildinfo.Version
+	body.ThreatInfo.ThreatTypes = safeBrowsingThreatTypes
+	body.ThreatInfo.PlatformTypes = []string{"ANY_PLATFORM"}
+	body.ThreatInfo.ThreatEntryTypes = []string{"URL"}
+	body.ThreatInfo.ThreatEntries = []map[string]string{{"url": rawURL}}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, safeBrowsingEndpoint+"?key="+url.QueryEscape(key), bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := safeBrowsingClient.Do(request)
+	if err != nil {
+		// The request URL carries the API key, keep it out of logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Safe Browsing responded with status %d", response.StatusCode)
+	}
+
+	var result safeBrowsingResponse
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if len(result.Matches) == 0 {
+		return "", nil
+	}
+	return result.Matches[0].ThreatType, nil
+}
@@ -0,0 +1,33 @@
+package safety
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestScreenRefusesPrivateAddresses(t *testing.T) {
+	t.Setenv("SAFE_BROWSING_API_KEY", "")
+	blocked := []string{
+		"http://127.0.0.1/admin",
+		"http://10.1.2.3/",
+		"http://[::1]:8080/",
+		"http://169.254.169.254/latest/meta-data/",
+		"https://localhost/",
+		"http://printer.localhost/",
+	}
+	for _, rawURL := range blocked {
+		if err := Screen(context.Background(), rawURL); !errors.Is(err, ErrPrivateDestination) {
+			t.Errorf("Screen(%q) = %v, want ErrPrivateDestination", rawURL, err)
+		}
+	}
+
+	if err := Screen(context.Background(), "https://93.184.216.34/page"); err != nil {
+		t.Errorf("Screen(public address) = %v, want nil", err)
+	}
+
+	t.Setenv("SHORTEN_ALLOW_PRIVATE_DESTINATIONS", "true")
+	if err := Screen(context.Background(), "http://10.1.2.3/"); err != nil {
+		t.Errorf("Screen(private address) with SHORTEN_ALLOW_PRIVATE_DESTINATIONS = %v, want nil", err)
+	}
+}
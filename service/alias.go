@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"url-shortener/database"
+)
+
+// Length bounds for custom aliases
+const (
+	minAliasLength = 3
+	maxAliasLength = 64
+)
+
+// reservedAliases are the first path segments of the service's own routes,
+// which a custom alias would shadow or be shadowed by, and "tags" and
+// "destinations", which /stats/tags/{tag} and /stats/destinations would
+// shadow in the stats of a link
+var reservedAliases = map[string]bool{
+	"shorten": true, "stats": true, "health": true, "status": true, "version": true,
+	"errors": true, "inbound": true, "auth": true, "admin": true, "swagger": true,
+	"docs": true, "px": true, "debug": true, "api": true, "links": true, "external": true,
+	"tags": true, "destinations": true, "artifacts": true, "reports": true, "js": true,
+	"embed": true, "collections": true, "shared": true, "webhooks": true, "changes": true,
+	"funnels": true, "routing": true,
+}
+
+// ValidateAlias checks a custom alias' length, characters and that it is not
+// a reserved route name
+func ValidateAlias(alias string) error {
+	if len(alias) < minAliasLength || len(alias) > maxAliasLength {
+		return fmt.Errorf("custom_alias must be %d to %d characters long", minAliasLength, maxAliasLength)
+	}
+	for _, r := range alias {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return errors.New("custom_alias may only contain letters, digits, hyphens and underscores")
+		}
+	}
+	if reservedAliases[strings.ToLower(alias)] {
+		return fmt.Errorf("custom_alias %q is reserved", alias)
+	}
+	return nil
+}
+
+// AliasTaken reports whether a link already uses alias, checking the cache
+// before the database (including soft-deleted and archived links)
+func (s Stores) AliasTaken(ctx context.Context, alias string) (bool, error) {
+	if _, err := s.Cache.GetRedirectEntry(alias); err == nil {
+		return true, nil
+	}
+	return s.Links.ShortCodeTaken(ctx, alias)
+}
+
+// IsUniqueViolation reports whether err is a unique constraint violation,
+// such as two requests claiming the same alias at once
+func IsUniqueViolation(err error) bool {
+	return database.IsUniqueViolation(err)
+}
+
+// isShortCodeViolation reports whether err is a unique violation of the
+// short code of links, rather than of another unique column
+func isShortCodeViolation(err error) bool {
+	return database.IsShortCodeViolation(err)
+}
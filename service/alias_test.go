@@ -0,0 +1,19 @@
+package service
+
+import "testing"
+
+func TestValidateAlias(t *testing.T) {
+	valid := []string{"promo2024", "Spring-Sale", "a_b", "abc"}
+	for _, alias := range valid {
+		if err := ValidateAlias(alias); err != nil {
+			t.Errorf("ValidateAlias(%q) = %v, want nil", alias, err)
+		}
+	}
+
+	invalid := []string{"ab", "has space", "slash/path", "dot.ted", "ümlaut", "stats", "Admin", "swagger", "webhooks", "changes", "funnels", "routing"}
+	for _, alias := range invalid {
+		if err := ValidateAlias(alias); err == nil {
+			t.Errorf("ValidateAlias(%q) = nil, want an error", alias)
+		}
+	}
+}
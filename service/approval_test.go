@@ -0,0 +1,237 @@
+package service_test
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/domains"
+	"url-shortener/models"
+	"url-shortener/policy"
+	"url-shortener/safety"
+	"url-shortener/service"
+)
+
+func TestHoldsForApproval(t *testing.T) {
+	for _, tc := range []struct {
+		required bool
+		roles    []string
+		role     string
+		want     bool
+	}{
+		{false, nil, policy.CreatorAnonymous, false},
+		{true, nil, policy.CreatorAdmin, true},
+		{true, []string{policy.CreatorAnonymous}, policy.CreatorAnonymous, true},
+		{true, []string{policy.CreatorAnonymous}, policy.CreatorUser, false},
+		{false, []string{policy.CreatorUser}, policy.CreatorUser, false},
+	} {
+		snapshot := &policy.Snapshot{RequireApproval: tc.required, ApprovalRoles: tc.roles}
+		if got := snapshot.HoldsForApproval(tc.role); got != tc.want {
+			t.Errorf("required=%t roles=%q: HoldsForApproval(%s) = %t, want %t", tc.required, tc.roles, tc.role, got, tc.want)
+		}
+	}
+
+	t.Setenv("APPROVAL_ROLES", " Anonymous, ,service ")
+	if roles := policy.ApprovalRoles(); strings.Join(roles, ",") != "anonymous,service" {
+		t.Errorf("ApprovalRoles() = %q, want anonymous and service", roles)
+	}
+}
+
+func TestSQLiteApprovalByCreatorRole(t *testing.T) {
+	stores := openSQLite(t)
+	ctx := context.Background()
+	t.Setenv("REQUIRE_APPROVAL", "true")
+	t.Setenv("APPROVAL_ROLES", "anonymous,admin")
+
+	users := []models.User{
+		{Email: "user@example.com", PasswordHash: "x", Role: models.RoleUser},
+		{Email: "admin@example.com", PasswordHash: "x", Role: models.RoleAdmin},
+	}
+	if err := database.DB.Create(&users).Error; err != nil {
+		t.Fatalf("creating users: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		caller service.Caller
+		role   string
+		status string
+	}{
+		{"anonymous", service.Caller{Policy: policy.Load("")}, policy.CreatorAnonymous, models.StatusPending},
+		{"service key", service.Caller{APIKey: &models.APIKey{}, Policy: policy.Load("")}, policy.CreatorService, models.StatusActive},
+		{"user key", ownerCaller(users[0].ID), policy.CreatorUser, models.StatusActive},
+		{"admin's key", ownerCaller(users[1].ID), policy.CreatorAdmin, models.StatusPending},
+		{"admin token", service.Caller{Admin: true, Policy: policy.Load("")}, policy.CreatorAdmin, models.StatusPending},
+	} {
+		if role := service.CreatorRole(ctx, tc.caller); role != tc.role {
+			t.Errorf("%s: CreatorRole() = %s, want %s", tc.name, role, tc.role)
+		}
+		link, _, err := stores.Shorten(ctx, tc.caller, models.ShortenRequest{URL: "https://example.com/" + strings.ReplaceAll(tc.name, " ", "-")})
+		if err != nil {
+			t.Errorf("%s: Shorten() = %v", tc.name, err)
+			continue
+		}
+		if link.Status != tc.status {
+			t.Errorf("%s: status = %s, want %s", tc.name, link.Status, tc.status)
+		}
+	}
+}
+
+func TestSQLiteVerifiedDomainSkipsRoleApprovalOnly(t *testing.T) {
+	stores := openSQLite(t)
+	ctx := context.Background()
+	t.Setenv("REQUIRE_APPROVAL", "true")
+
+	user := models.User{Email: "user@example.com", PasswordHash: "x", Role: models.RoleUser}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	now := time.Now()
+	if err := database.DB.Create(&models.VerifiedDomain{Domain: "example.com", Token: "t", VerifiedAt: &now, SkipApproval: true}).Error; err != nil {
+		t.Fatalf("creating domain: %v", err)
+	}
+	domains.Invalidate()
+	t.Cleanup(domains.Invalidate)
+
+	link, _, err := stores.Shorten(ctx, ownerCaller(user.ID), models.ShortenRequest{URL: "https://example.com/trusted"})
+	if err != nil || link.Status != models.StatusActive {
+		t.Fatalf("Shorten() = %+v, %v, want an active link", link, err)
+	}
+
+	rule := models.SafetyRule{Type: models.SafetyRuleKeyword, Pattern: "giveaway", Action: models.SafetyActionReview, Enabled: true}
+	if err := database.DB.Create(&rule).Error; err != nil {
+		t.Fatalf("creating safety rule: %v", err)
+	}
+	safety.Invalidate()
+	t.Cleanup(safety.Invalidate)
+
+	link, _, err = stores.Shorten(ctx, ownerCaller(user.ID), models.ShortenRequest{URL: "https://example.com/giveaway"})
+	if err != nil || link.Status != models.StatusPending {
+		t.Errorf("Shorten() matching a review rule = %+v, %v, want a pending link", link, err)
+	}
+}
+
+func TestSQLiteNotifyApproversByEmail(t *testing.T) {
+	openSQLite(t)
+	server := startSMTPServer(t)
+	t.Setenv("SMTP_HOST", "127.0.0.1")
+	t.Setenv("SMTP_PORT", server.port)
+	t.Setenv("SMTP_FROM", "shortener@example.com")
+
+	admins := []models.User{
+		{Email: "first@example.com", PasswordHash: "x", Role: models.RoleAdmin},
+		{Email: "member@example.com", PasswordHash: "x", Role: models.RoleUser},
+		{Email: "second@example.com", PasswordHash: "x", Role: models.RoleAdmin},
+	}
+	if err := database.DB.Create(&admins).Error; err != nil {
+		t.Fatalf("creating users: %v", err)
+	}
+	link := &models.URL{ShortCode: "held", OriginalURL: "https://example.com/held"}
+
+	service.NotifyApprovers(context.Background(), link)
+	if got := server.recipients(); strings.Join(got, ",") != "first@example.com,second@example.com" {
+		t.Errorf("emailed %q, want the admins", got)
+	}
+	if messages := server.messages(); len(messages) == 0 || !strings.Contains(messages[0], "Subject: Link awaiting approval: held") {
+		t.Errorf("messages = %q, want the pending link's subject", messages)
+	}
+
+	// Listed addresses replace the admins
+	t.Setenv("APPROVAL_EMAILS", "reviewers@example.com")
+	server.reset()
+	service.NotifyApprovers(context.Background(), link)
+	if got := server.recipients(); strings.Join(got, ",") != "reviewers@example.com" {
+		t.Errorf("emailed %q, want APPROVAL_EMAILS", got)
+	}
+}
+
+// smtpServer is an SMTP server accepting every message, without
+// extensions, recording what it receives
+type smtpServer struct {
+	port string
+
+	mu   sync.Mutex
+	rcpt []string
+	data []string
+}
+
+func startSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	server := &smtpServer{port: port}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			text.PrintfLine("250 localhost")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			s.mu.Lock()
+			s.rcpt = append(s.rcpt, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			s.mu.Unlock()
+			text.PrintfLine("250 OK")
+		case command == "DATA":
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			lines, err := text.ReadDotLines()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = append(s.data, strings.Join(lines, "\n"))
+			s.mu.Unlock()
+			text.PrintfLine("250 OK")
+		case command == "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("250 OK")
+		}
+	}
+}
+
+func (s *smtpServer) recipients() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.rcpt...)
+}
+
+func (s *smtpServer) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.data...)
+}
+
+func (s *smtpServer) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rcpt, s.data = nil, nil
+}
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"url-shortener/abuse"
+	"url-shortener/captcha"
+	"url-shortener/database"
+	"url-shortener/domains"
+	"url-shortener/expiry"
+	"url-shortener/models"
+	"url-shortener/policy"
+	"url-shortener/routing"
+	"url-shortener/safety"
+	"url-shortener/utils"
+)
+
+// LinkExpiry is the link lifetime policy, from LINK_DEFAULT_EXPIRY_DAYS,
+// LINK_MAX_EXPIRY_DAYS and LINK_ANONYMOUS_EXPIRY_DAYS
+var LinkExpiry = loadLinkExpiryPolicy()
+
+// loadLinkExpiryPolicy reads the link lifetime policy, falling back to no
+// default or maximum lifetime when it is invalid
+func loadLinkExpiryPolicy() expiry.Policy {
+	policy, err := expiry.PolicyFromEnv()
+	if err != nil {
+		slog.Warn("Invalid link lifetime policy, links only expire when asked to", "error", err)
+	}
+	return policy
+}
+
+// ExpiryPolicy is the lifetime policy of links created by caller, shorter
+// for anonymous callers when LINK_ANONYMOUS_EXPIRY_DAYS is set
+func ExpiryPolicy(caller Caller) expiry.Policy {
+	if caller.APIKey == nil {
+		return LinkExpiry.Anonymous()
+	}
+	return LinkExpiry
+}
+
+// CheckExpiry rejects lifetimes longer than the maximum for caller
+func CheckExpiry(caller Caller, expiresIn int) *models.APIError {
+	if err := ExpiryPolicy(caller).Check(expiresIn); err != nil {
+		message := err.Error()
+		if caller.APIKey == nil && LinkExpiry.AnonymousDays > 0 {
+			message += " for links created without an API key"
+		}
+		return models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, message)
+	}
+	return nil
+}
+
+// CheckDestination validates a URL to shorten and applies CAPTCHA, API key
+// domain restrictions, malicious URL screening and brand safety rules,
+// returning the safety action that applies to the URL
+func CheckDestination(ctx context.Context, caller Caller, rawURL, captchaToken string) (string, *models.APIError) {
+	// Validate URL
+	if !IsValidURL(rawURL) {
+		return "", models.ErrURLInvalid
+	}
+
+	// Require a CAPTCHA token on anonymous creation when configured
+	if caller.Policy.CaptchaRequired && caller.APIKey == nil {
+		if captchaToken == "" {
+			return "", models.NewAPIError(http.StatusBadRequest, models.ErrCodeCaptchaFailed, "captcha_token is required")
+		}
+		if err := captcha.Verify(captchaToken, caller.ClientIP); err != nil {
+			if errors.Is(err, captcha.ErrInvalidToken) {
+				return "", models.NewAPIError(http.StatusForbidden, models.ErrCodeCaptchaFailed, "CAPTCHA verification failed")
+			}
+			slog.ErrorContext(ctx, "CAPTCHA verification error", "error", err)
+			return "", models.NewAPIError(http.StatusServiceUnavailable, models.ErrCodeUnavailable, "CAPTCHA verification unavailable")
+		}
+	}
+
+	// Keys restricted to destination domains may only shorten those
+	if caller.APIKey != nil && !destinationAllowed(caller.APIKey, rawURL) {
+		return "", models.NewAPIError(http.StatusForbidden, models.ErrCodeForbidden, "API key is not allowed to shorten this domain")
+	}
+
+	if apiErr := screenCallerDestination(ctx, caller, rawURL); apiErr != nil {
+		return "", apiErr
+	}
+
+	// Apply brand safety rules
+	safetyAction, _ := caller.Policy.EvaluateSafety(rawURL)
+	switch safetyAction {
+	case models.SafetyActionDeny:
+		abuse.Record(ctx, caller.Creator(), abuse.SignalBlocked)
+		return "", models.NewAPIError(http.StatusBadRequest, models.ErrCodeURLBlocked, "URL is blocked by safety policy")
+	case models.SafetyActionReview:
+		abuse.Record(ctx, caller.Creator(), abuse.SignalReview)
+	}
+
+	return safetyAction, nil
+}
+
+// CheckAlternateDestination applies the checks of the link's URL to another
+// destination visitors may be sent to, the kind of which is named by noun,
+// returning the strictest safety action
+func CheckAlternateDestination(ctx context.Context, caller Caller, rawURL, noun, safetyAction string) (string, *models.APIError) {
+	if !IsValidURL(rawURL) {
+		return "", models.NewAPIError(http.StatusBadRequest, models.ErrCodeURLInvalid, "Invalid "+noun+" URL format")
+	}
+	if caller.APIKey != nil && !destinationAllowed(caller.APIKey, rawURL) {
+		return "", models.NewAPIError(http.StatusForbidden, models.ErrCodeForbidden, "API key is not allowed to shorten this domain")
+	}
+	if apiErr := screenCallerDestination(ctx, caller, rawURL); apiErr != nil {
+		return "", apiErr
+	}
+
+	switch action, _ := caller.Policy.EvaluateSafety(rawURL); action {
+	case models.SafetyActionDeny:
+		abuse.Record(ctx, caller.Creator(), abuse.SignalBlocked)
+		return "", models.NewAPIError(http.StatusBadRequest, models.ErrCodeURLBlocked, strings.ToUpper(noun[:1])+noun[1:]+" URL is blocked by safety policy")
+	case models.SafetyActionReview:
+		abuse.Record(ctx, caller.Creator(), abuse.SignalReview)
+		safetyAction = models.SafetyActionReview
+	}
+	return safetyAction, nil
+}
+
+// CheckVariants applies the checks of the link's URL to every variant
+// destination, returning the strictest safety action
+func CheckVariants(ctx context.Context, caller Caller, variants []models.VariantRequest, safetyAction string) (string, *models.APIError) {
+	if len(variants) > 0 {
+		if apiErr := CheckFeatureAllowed(ctx, caller, "variants"); apiErr != nil {
+			return "", apiErr
+		}
+	}
+	for _, variant := range variants {
+		var apiErr *models.APIError
+		if safetyAction, apiErr = CheckAlternateDestination(ctx, caller, variant.URL, "variant", safetyAction); apiErr != nil {
+			return "", apiErr
+		}
+	}
+	return safetyAction, nil
+}
+
+// CheckRouting validates routing rules and applies the checks of the link's
+// URL to the URLs they redirect to, returning the strictest safety action
+func CheckRouting(ctx context.Context, caller Caller, rules []models.RoutingRule, safetyAction string) (string, *models.APIError) {
+	if err := routing.Validate(rules); err != nil {
+		return "", models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error())
+	}
+	if len(rules) > 0 {
+		if apiErr := CheckFeatureAllowed(ctx, caller, "routing_rules"); apiErr != nil {
+			return "", apiErr
+		}
+	}
+	for _, rawURL := range routing.RedirectURLs(rules) {
+		var apiErr *models.APIError
+		if safetyAction, apiErr = CheckAlternateDestination(ctx, caller, rawURL, "routing rule", safetyAction); apiErr != nil {
+			return "", apiErr
+		}
+	}
+	return safetyAction, nil
+}
+
+// CheckVelocityLimit validates a link's velocity limit, applying the checks
+// of the link's URL to its overflow_url and returning the strictest safety
+// action
+func CheckVelocityLimit(ctx context.Context, caller Caller, limit *models.VelocityLimit, safetyAction string) (string, *models.APIError) {
+	if limit == nil || limit.Clicks == 0 {
+		return safetyAction, nil
+	}
+	if limit.Overflow != models.VelocityOverflowRedirect {
+		if limit.OverflowURL != "" {
+			return "", models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "velocity_limit overflow_url is only used with overflow redirect")
+		}
+		return safetyAction, nil
+	}
+	if limit.OverflowURL == "" {
+		return "", models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "velocity_limit overflow redirect requires an overflow_url")
+	}
+	return CheckAlternateDestination(ctx, caller, limit.OverflowURL, "overflow", safetyAction)
+}
+
+// Most extra headers a link's redirects can carry, and their longest value
+const (
+	maxRedirectHeaders     = 10
+	maxRedirectHeaderValue = 512
+)
+
+// redirectHeaderNames are the headers links may add to their redirects,
+// besides X- headers. Anything that could set cookies, change the redirect
+// or break the response framing is left out.
+var redirectHeaderNames = map[string]bool{
+	"Referrer-Policy": true, "Cache-Control": true, "Cdn-Cache-Control": true,
+	"Surrogate-Control": true, "Surrogate-Key": true, "Cache-Tag": true,
+	"Vary": true, "Expires": true,
+}
+
+// CheckRedirectHeaders validates the extra headers of a link's redirects
+func CheckRedirectHeaders(headers map[string]string) *models.APIError {
+	if len(headers) > maxRedirectHeaders {
+		return models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, fmt.Sprintf("redirect_headers may hold at most %d headers", maxRedirectHeaders))
+	}
+	for name, value := range headers {
+		canonical := http.CanonicalHeaderKey(name)
+		custom := strings.HasPrefix(canonical, "X-") && !strings.HasPrefix(canonical, "X-Forwarded-") && canonical != "X-Request-Id"
+		if !redirectHeaderNames[canonical] && !custom || strings.IndexFunc(name, invalidHeaderNameRune) >= 0 {
+			return models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "redirect_headers cannot set "+name)
+		}
+		if value == "" || len(value) > maxRedirectHeaderValue || strings.IndexFunc(value, invalidHeaderValueRune) >= 0 {
+			return models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, fmt.Sprintf("redirect_headers %s must be 1 to %d printable characters", name, maxRedirectHeaderValue))
+		}
+	}
+	return nil
+}
+
+// invalidHeaderNameRune reports runes outside the tokens header names are
+// made of
+func invalidHeaderNameRune(r rune) bool {
+	return r > '~' || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r)
+}
+
+// invalidHeaderValueRune reports control characters, which could end the
+// header early, and non-ASCII ones
+func invalidHeaderValueRune(r rune) bool {
+	return r > '~' || r < ' ' && r != '\t'
+}
+
+// CheckTrackLandings refuses landing tracking on links whose clicks are not
+// counted, as landings are told from bounces by the click count
+func CheckTrackLandings(track bool, analytics string) *models.APIError {
+	if track && analytics == models.AnalyticsNone {
+		return models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "track_landings requires counting clicks, analytics cannot be none")
+	}
+	return nil
+}
+
+// CheckAttributionWindow refuses an attribution window on links without
+// variants, the only links with conversions to attribute
+func CheckAttributionWindow(hours int, split bool) *models.APIError {
+	if hours > 0 && !split {
+		return models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "attribution_window_hours only applies to split links with variants")
+	}
+	return nil
+}
+
+// CheckEnvironment refuses links of an environment other than that of the
+// domain named domainName, empty for the default one, so that staging
+// links are never served on production domains nor live links on staging
+// ones. Unknown domains are left to CreateLink.
+func CheckEnvironment(environment, domainName string) *models.APIError {
+	if environment == "" {
+		return nil
+	}
+	var domain *models.Domain
+	where := "the default domain"
+	if domainName != "" {
+		if domain = domains.ShortDomain(domainName); domain == nil {
+			return nil
+		}
+		where = domain.Host
+	}
+	if served := domains.Environment(domain); environment != served {
+		return models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest,
+			fmt.Sprintf("%s links cannot be created on %s, which serves %s links", environment, where, served))
+	}
+	return nil
+}
+
+// CheckFeatureAllowed refuses feature, a risky one such as custom aliases
+// or routing rules, to callers whose abuse level is high or severe
+func CheckFeatureAllowed(ctx context.Context, caller Caller, feature string) *models.APIError {
+	if level := abuse.Level(ctx, caller.Creator()); abuse.AtLeast(level, models.AbuseLevelHigh) {
+		return models.NewAPIError(http.StatusForbidden, models.ErrCodeAbuseRestricted, feature+" is disabled while the abuse level of the creator is "+level)
+	}
+	return nil
+}
+
+// HeldForAbuse reports whether the links of caller are held for approval
+// because its abuse level is severe
+func HeldForAbuse(ctx context.Context, caller Caller) bool {
+	return abuse.AtLeast(abuse.Level(ctx, caller.Creator()), models.AbuseLevelSevere)
+}
+
+// RequiresApproval reports whether the links of caller are held for
+// approval by its policy, which may only hold those of some creator roles
+// (see CreatorRole)
+func RequiresApproval(ctx context.Context, caller Caller) bool {
+	return caller.Policy.RequireApproval && caller.Policy.HoldsForApproval(CreatorRole(ctx, caller))
+}
+
+// CreatorRole returns the role caller creates links as, one of
+// policy.CreatorRoles. Callers whose user cannot be loaded count as users.
+func CreatorRole(ctx context.Context, caller Caller) string {
+	switch {
+	case caller.Admin:
+		return policy.CreatorAdmin
+	case caller.APIKey == nil:
+		return policy.CreatorAnonymous
+	case caller.APIKey.UserID == nil:
+		return policy.CreatorService
+	case database.DB == nil:
+		return policy.CreatorUser
+	}
+
+	var user models.User
+	if err := database.DB.WithContext(ctx).Select("role").First(&user, *caller.APIKey.UserID).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to load the role of user", "user_id", *caller.APIKey.UserID, "error", err)
+		return policy.CreatorUser
+	}
+	if user.Role == models.RoleAdmin {
+		return policy.CreatorAdmin
+	}
+	return policy.CreatorUser
+}
+
+// ScreenDestination refuses destinations leading into private networks or
+// flagged as malicious
+func ScreenDestination(ctx context.Context, rawURL string) *models.APIError {
+	return screenError(ctx, safety.Screen(ctx, rawURL))
+}
+
+// screenCallerDestination screens a destination of caller like
+// ScreenDestination, counting malicious ones against the caller
+func screenCallerDestination(ctx context.Context, caller Caller, rawURL string) *models.APIError {
+	err := safety.Screen(ctx, rawURL)
+	if errors.Is(err, safety.ErrMaliciousDestination) {
+		abuse.Record(ctx, caller.Creator(), abuse.SignalUnsafe)
+	}
+	return screenError(ctx, err)
+}
+
+// screenError maps the outcome of safety.Screen to the error answered
+func screenError(ctx context.Context, err error) *models.APIError {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, safety.ErrPrivateDestination):
+		return models.NewAPIError(http.StatusBadRequest, models.ErrCodeURLUnsafe, "URL points to a private network address")
+	case errors.Is(err, safety.ErrMaliciousDestination):
+		slog.WarnContext(ctx, "Refused to shorten a URL", "error", err)
+		return models.NewAPIError(http.StatusBadRequest, models.ErrCodeURLUnsafe, "URL is flagged as malicious by Safe Browsing")
+	default:
+		return models.ErrURLInvalid
+	}
+}
+
+// CheckTags refuses tags that the caller's API key may not put on links,
+// when it is restricted to some. Tags added by tag rules and channels are
+// not the caller's and are not checked.
+func CheckTags(caller Caller, tags []string) *models.APIError {
+	if caller.APIKey == nil || len(caller.APIKey.AllowedTags) == 0 {
+		return nil
+	}
+	for _, tag := range tags {
+		if !slices.Contains(caller.APIKey.AllowedTags, tag) {
+			return models.NewAPIError(http.StatusForbidden, models.ErrCodeForbidden, fmt.Sprintf("API key is not allowed to tag links %q", tag))
+		}
+	}
+	return nil
+}
+
+// destinationAllowed checks rawURL against the key's allowed destination domains
+func destinationAllowed(apiKey *models.APIKey, rawURL string) bool {
+	if len(apiKey.AllowedDomains) == 0 {
+		return true
+	}
+
+	for _, domain := range apiKey.AllowedDomains {
+		if utils.URLMatchesDomain(rawURL, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidURL reports whether str is an absolute URL with a host
+func IsValidURL(str string) bool {
+	u, err := url.Parse(str)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
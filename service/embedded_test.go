@@ -0,0 +1,129 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"url-shortener/cache"
+	"url-shortener/config"
+	"url-shortener/database"
+	"url-shortener/encryption"
+	"url-shortener/models"
+	"url-shortener/service"
+	"url-shortener/storage"
+)
+
+// openEmbedded migrates the embedded database in path for the test and
+// returns stores over its links and cache
+func openEmbedded(t *testing.T, path string) service.Stores {
+	t.Helper()
+	t.Setenv("SHORTEN_ALLOW_PRIVATE_DESTINATIONS", "true")
+	t.Setenv("URL_ENCRYPTION_KEY", "")
+	encryption.Init()
+
+	cfg := config.Default().Database
+	cfg.Driver = database.DriverEmbedded
+	cfg.Path = path
+	if err := database.Connect(cfg); err != nil {
+		t.Fatalf("Connect() = %v", err)
+	}
+	t.Cleanup(closeDatabase)
+	if err := database.Migrate(); err != nil {
+		t.Fatalf("Migrate() = %v", err)
+	}
+	linkCache, err := cache.NewBoltStore(database.Bolt)
+	if err != nil {
+		t.Fatalf("NewBoltStore() = %v", err)
+	}
+	return service.Stores{Links: database.Links, Cache: linkCache}
+}
+
+func TestEmbeddedShortenAndResolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "links.db")
+	stores := openEmbedded(t, path)
+	ctx := context.Background()
+
+	link, created, err := stores.Shorten(ctx, ownerCaller(1), models.ShortenRequest{URL: "https://example.com/embedded"})
+	if err != nil || !created || link.ID == 0 || link.Status != models.StatusActive {
+		t.Fatalf("Shorten() = %+v, %v, %v", link, created, err)
+	}
+	entry, err := stores.Resolve(ctx, link.ShortCode)
+	if err != nil || entry.Destination != "https://example.com/embedded" {
+		t.Errorf("Resolve(%s) = %+v, %v", link.ShortCode, entry, err)
+	}
+	if _, err := stores.Cache.GetRedirectEntry(link.ShortCode); err != nil {
+		t.Errorf("redirect entry not cached: %v", err)
+	}
+	if _, err := stores.Resolve(ctx, "missing"); !errors.Is(err, models.ErrLinkNotFound) {
+		t.Errorf("Resolve(missing) = %v, want not found", err)
+	}
+
+	// Links outlive the process
+	closeDatabase()
+	stores = openEmbedded(t, path)
+	stored, err := stores.Links.GetByShortCode(ctx, link.ShortCode)
+	if err != nil || stored.ID != link.ID || stored.OriginalURL != "https://example.com/embedded" || *stored.OwnerID != 1 {
+		t.Errorf("GetByShortCode() after reopening = %+v, %v", stored, err)
+	}
+}
+
+func TestEmbeddedDeduplicatesPerOwner(t *testing.T) {
+	stores := openEmbedded(t, filepath.Join(t.TempDir(), "links.db"))
+	ctx := context.Background()
+	request := models.ShortenRequest{URL: "https://example.com/shared"}
+
+	first, _, err := stores.Shorten(ctx, ownerCaller(1), request)
+	if err != nil {
+		t.Fatalf("Shorten() by user 1 = %v", err)
+	}
+	again, created, err := stores.Shorten(ctx, ownerCaller(1), request)
+	if err != nil || created || again.ShortCode != first.ShortCode {
+		t.Errorf("Shorten() again by user 1 = %+v, %v, %v, want %s back", again, created, err, first.ShortCode)
+	}
+	other, created, err := stores.Shorten(ctx, ownerCaller(2), request)
+	if err != nil || !created || other.ShortCode == first.ShortCode {
+		t.Errorf("Shorten() by user 2 = %+v, %v, %v, want a new link", other, created, err)
+	}
+}
+
+func TestEmbeddedStore(t *testing.T) {
+	openEmbedded(t, filepath.Join(t.TempDir(), "links.db"))
+	ctx := context.Background()
+
+	maxClicks := 1
+	link := &models.URL{OriginalURL: "https://example.com/a", ShortCode: "taken", MaxClicks: &maxClicks, ClicksRemaining: &maxClicks}
+	variants := []models.LinkVariant{{Name: "a", Destination: "https://example.com/a"}, {Name: "b", Destination: "https://example.com/b"}}
+	if err := database.Links.CreateURL(ctx, link, variants); err != nil {
+		t.Fatalf("CreateURL() = %v", err)
+	}
+	if variants[0].URLID != link.ID || variants[1].ID == variants[0].ID {
+		t.Errorf("variants = %+v, want IDs of their own on link %d", variants, link.ID)
+	}
+	err := database.Links.CreateURL(ctx, &models.URL{OriginalURL: "https://example.com/b", ShortCode: "taken"}, nil)
+	if !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("CreateURL() with a taken short code = %v, want a conflict", err)
+	}
+	if taken, err := database.Links.ShortCodeTaken(ctx, "taken"); err != nil || !taken {
+		t.Errorf("ShortCodeTaken(taken) = %v, %v", taken, err)
+	}
+
+	counts, err := database.Links.IncrementClicks(ctx, map[uint]int64{link.ID: 2}, map[uint]int64{variants[0].ID: 2})
+	if err != nil || counts[link.ID] != 2 {
+		t.Errorf("IncrementClicks() = %v, %v, want 2 clicks", counts, err)
+	}
+	if left, err := database.Links.ConsumeClick(ctx, link.ID); err != nil || left != 0 {
+		t.Errorf("ConsumeClick() = %d, %v, want 0 left", left, err)
+	}
+	if _, err := database.Links.ConsumeClick(ctx, link.ID); !errors.Is(err, database.ErrNoClicksLeft) {
+		t.Errorf("ConsumeClick() when used up = %v, want ErrNoClicksLeft", err)
+	}
+	stored, _ := database.Links.GetByShortCode(ctx, "taken")
+	if stored.ClickCount != 2 || stored.ExpiresAt == nil {
+		t.Errorf("stored link = %+v, want 2 clicks and expired", stored)
+	}
+	if _, err := database.Links.Rehydrate(ctx, "never"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Rehydrate(never) = %v, want not found", err)
+	}
+}
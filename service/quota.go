@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/models"
+	"url-shortener/notify"
+	"url-shortener/quota"
+)
+
+// LinkQuotas is the monthly link quota of each plan, from LINK_QUOTAS
+var LinkQuotas = loadLinkQuotas()
+
+// loadLinkQuotas reads the link quotas, falling back to none when they are
+// invalid
+func loadLinkQuotas() quota.Policy {
+	policy, err := quota.PolicyFromEnv()
+	if err != nil {
+		slog.Warn("Invalid link quotas, links are created without limit", "error", err)
+	}
+	return policy
+}
+
+// ErrQuotaExhausted is returned by CreateLink when the caller's user
+// created as many links this month as their plan allows
+var ErrQuotaExhausted = errors.New("monthly link quota exhausted")
+
+// LinkQuota returns how much of their plan's monthly link quota caller's
+// user used, and false when no quota applies: to admins, ownerless callers
+// and plans without one. Lookup failures are logged and apply no quota, so
+// they do not stop link creation.
+func LinkQuota(ctx context.Context, caller Caller) (quota.Usage, bool) {
+	ownerID := caller.OwnerID()
+	if caller.Admin || ownerID == nil || len(LinkQuotas.Plans()) == 0 || database.DB == nil {
+		return quota.Usage{}, false
+	}
+	plan, err := database.UserPlan(ctx, ownerID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load the plan of user", "user_id", *ownerID, "error", err)
+		return quota.Usage{}, false
+	}
+	limit := LinkQuotas.LinksFor(plan)
+	if limit == 0 {
+		return quota.Usage{}, false
+	}
+	since := quota.PeriodStart(time.Now())
+	used, err := database.LinksCreatedSince(ctx, *ownerID, since)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to count the links of user", "user_id", *ownerID, "error", err)
+		return quota.Usage{}, false
+	}
+	return quota.Usage{Plan: plan, Used: int(used), Limit: limit, Since: since}, true
+}
+
+// QuotaExhaustedError answers a link refused past the monthly quota
+func QuotaExhaustedError() *models.APIError {
+	resets := quota.PeriodStart(time.Now()).AddDate(0, 1, 0)
+	return models.NewAPIError(http.StatusTooManyRequests, models.ErrCodeQuotaExceeded,
+		"Your plan's monthly link quota is used up; creation resumes on "+resets.Format("2006-01-02"))
+}
+
+// fireQuotaHooks tells the webhooks of caller's user when the link they
+// just created brought them to quota.WarnPercent of their monthly quota,
+// or used it up
+func fireQuotaHooks(ctx context.Context, caller Caller) {
+	usage, ok := LinkQuota(ctx, caller)
+	if !ok {
+		return
+	}
+	var event string
+	switch {
+	case usage.Used == usage.Limit:
+		event = models.HookQuotaExhausted
+	case usage.StartsWarning():
+		event = models.HookQuotaWarning
+	default:
+		return
+	}
+	notify.FireWebhooks(ctx, *caller.OwnerID(), event, models.HookQuotaPayload{
+		Event:       event,
+		Plan:        usage.Plan,
+		Used:        usage.Used,
+		Limit:       usage.Limit,
+		PeriodStart: usage.Since,
+		ResetsAt:    usage.Since.AddDate(0, 1, 0),
+	})
+}
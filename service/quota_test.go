@@ -0,0 +1,114 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"url-shortener/database"
+	"url-shortener/database/databasetest"
+	"url-shortener/models"
+	"url-shortener/policy"
+	"url-shortener/quota"
+	"url-shortener/service"
+)
+
+// withLinkQuotas sets the link quotas for the test
+func withLinkQuotas(t *testing.T, links map[string]int) {
+	t.Helper()
+	previous := service.LinkQuotas
+	service.LinkQuotas = quota.Policy{Links: links}
+	t.Cleanup(func() { service.LinkQuotas = previous })
+}
+
+func TestSQLiteLinkQuota(t *testing.T) {
+	stores := openSQLite(t)
+	ctx := context.Background()
+	withLinkQuotas(t, map[string]int{"free": 3})
+
+	users := []models.User{
+		{Email: "free@example.com", PasswordHash: "x", Plan: "free"},
+		{Email: "pro@example.com", PasswordHash: "x", Plan: "pro"},
+	}
+	if err := database.DB.Create(&users).Error; err != nil {
+		t.Fatalf("creating users: %v", err)
+	}
+	free := ownerCaller(users[0].ID)
+
+	for i := 1; i <= 3; i++ {
+		if _, _, err := stores.Shorten(ctx, free, models.ShortenRequest{URL: "https://example.com/" + strconv.Itoa(i)}); err != nil {
+			t.Fatalf("Shorten() #%d = %v", i, err)
+		}
+		usage, ok := service.LinkQuota(ctx, free)
+		if !ok || usage.Used != i || usage.Limit != 3 || usage.Plan != "free" {
+			t.Errorf("LinkQuota() after %d links = %+v, %t", i, usage, ok)
+		}
+		if warned := i >= 3; usage.Warned() != warned {
+			t.Errorf("after %d links: Warned() = %t, want %t", i, usage.Warned(), warned)
+		}
+	}
+
+	// Deduplicated destinations create nothing, so they are still returned
+	if _, created, err := stores.Shorten(ctx, free, models.ShortenRequest{URL: "https://example.com/1"}); err != nil || created {
+		t.Errorf("Shorten() of a shortened destination = %t, %v, want the existing link", created, err)
+	}
+	_, _, err := stores.Shorten(ctx, free, models.ShortenRequest{URL: "https://example.com/4"})
+	var apiErr *models.APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusTooManyRequests || apiErr.Code != models.ErrCodeQuotaExceeded {
+		t.Errorf("Shorten() past the quota = %v, want 429 QUOTA_EXCEEDED", err)
+	}
+
+	// Deleting a link does not give its share back
+	if err := database.DB.Where("owner_id = ?", users[0].ID).Delete(&models.URL{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if usage, _ := service.LinkQuota(ctx, free); !usage.Exhausted() {
+		t.Errorf("LinkQuota() after deleting = %+v, want still exhausted", usage)
+	}
+
+	// Other plans, admins and ownerless callers have no quota
+	for name, caller := range map[string]service.Caller{
+		"pro plan":  ownerCaller(users[1].ID),
+		"admin":     {Admin: true, APIKey: free.APIKey, Policy: policy.Load("")},
+		"anonymous": {Policy: policy.Load("")},
+	} {
+		if usage, ok := service.LinkQuota(ctx, caller); ok {
+			t.Errorf("%s: LinkQuota() = %+v, want no quota", name, usage)
+		}
+	}
+}
+
+func TestSQLiteQuotaWebhooks(t *testing.T) {
+	stores := openSQLite(t)
+	ctx := context.Background()
+	withLinkQuotas(t, map[string]int{"free": 5})
+
+	user := models.User{Email: "free@example.com", PasswordHash: "x", Plan: "free"}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	webhook := databasetest.CreateWebhook(t, user.ID, models.HookQuotaWarning, models.HookQuotaExhausted)
+	caller := ownerCaller(user.ID)
+
+	for i := 1; i <= 5; i++ {
+		if _, _, err := stores.Shorten(ctx, caller, models.ShortenRequest{URL: "https://example.com/" + strconv.Itoa(i)}); err != nil {
+			t.Fatalf("Shorten() #%d = %v", i, err)
+		}
+	}
+
+	// Once on reaching 80%, then once on using the quota up
+	deliveries := databasetest.Deliveries(t, webhook)
+	if len(deliveries) != 2 || deliveries[0].Event != models.HookQuotaWarning || deliveries[1].Event != models.HookQuotaExhausted {
+		t.Fatalf("deliveries = %+v, want quota.warning then quota.exhausted", deliveries)
+	}
+	var payload models.HookQuotaPayload
+	if err := json.Unmarshal(deliveries[0].Payload, &payload); err != nil || payload.Plan != "free" || payload.Used != 4 || payload.Limit != 5 {
+		t.Errorf("quota.warning payload = %+v, %v, want 4 of 5 free links used", payload, err)
+	}
+	if !payload.ResetsAt.Equal(payload.PeriodStart.AddDate(0, 1, 0)) {
+		t.Errorf("quota.warning resets at %v, want a month after %v", payload.ResetsAt, payload.PeriodStart)
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/linktable"
+	"url-shortener/models"
+	"url-shortener/storage"
+)
+
+// Resolve returns the redirect entry of a link that can be followed now,
+// without counting a click. Errors are *models.APIError.
+func (s Stores) Resolve(ctx context.Context, shortCode string) (*cache.RedirectEntry, error) {
+	entry, err := s.LoadRedirectEntry(ctx, shortCode)
+	if err != nil {
+		return nil, LinkLookupError(err)
+	}
+	if apiErr := UnavailableLinkError(entry, time.Now()); apiErr != nil {
+		return nil, apiErr
+	}
+	return entry, nil
+}
+
+// LoadRedirectEntry returns the compact redirect entry of a link, from the
+// in-memory link table or the cache when possible
+func (s Stores) LoadRedirectEntry(ctx context.Context, shortCode string) (*cache.RedirectEntry, error) {
+	if entry, ok := linktable.Lookup(shortCode); ok {
+		return entry, nil
+	}
+
+	entry, err := s.Cache.GetRedirectEntry(shortCode)
+	if err == nil {
+		return entry, nil
+	}
+	// A miss, or running without Redis, is no reason to worry
+	if !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, cache.ErrNotConnected) {
+		slog.WarnContext(ctx, "Failed to read cached redirect entry", "short_code", shortCode, "error", err)
+	}
+
+	// Cache miss, check database
+	dbURL, err := s.Links.GetByShortCode(ctx, shortCode)
+	if errors.Is(err, storage.ErrNotFound) {
+		// Idle links are moved to the archive; bring them back on access
+		dbURL, err = s.Links.Rehydrate(ctx, shortCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry = cache.NewRedirectEntry(dbURL)
+	// Cache the result for next time
+	if err := s.Cache.CacheRedirectEntry(shortCode, entry); err != nil {
+		slog.WarnContext(ctx, "Failed to cache redirect entry", "short_code", shortCode, "error", err)
+	}
+	return entry, nil
+}
+
+// LinkLookupError returns the error answering a failed lookup of a link:
+// not found when the link does not exist, and the storage failure
+// otherwise, so an outage is not reported as a missing link
+func LinkLookupError(err error) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return models.ErrLinkNotFound
+	}
+	return err
+}
+
+// UnavailableLinkError returns why a link cannot be followed, or nil
+func UnavailableLinkError(entry *cache.RedirectEntry, now time.Time) *models.APIError {
+	switch {
+	// Inert links from shadow-banned creators behave as if they did not exist
+	case entry.Has(cache.RedirectInert):
+		return models.ErrLinkNotFound
+	// Links awaiting approval or rejected by an admin never redirect
+	case entry.Has(cache.RedirectPending):
+		return models.ErrLinkPending
+	case entry.Has(cache.RedirectRejected):
+		return models.ErrLinkNotFound
+	case entry.Has(cache.RedirectDisabled):
+		return models.ErrLinkDisabled
+	case entry.Expired(now):
+		return models.ErrLinkExpired
+	}
+	return nil
+}
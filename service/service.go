@@ -0,0 +1,73 @@
+// Package service holds the link operations shared by the REST handlers and
+// the gRPC API: shortening a URL, resolving a short code and reading a
+// link's stats, with the checks they apply. Transports authenticate the
+// request and describe who is calling with a Caller; failures meant for the
+// caller are *models.APIError values carrying the status and code to answer
+// with, which each transport maps to its own errors. Operations run against
+// Stores, those of the server's connections being Default().
+package service
+
+import (
+	"context"
+	"errors"
+
+	"url-shortener/abuse"
+	"url-shortener/models"
+	"url-shortener/notify"
+	"url-shortener/policy"
+)
+
+// Caller describes who is making a request
+type Caller struct {
+	APIKey   *models.APIKey   // key authenticating the request, nil when anonymous
+	Policy   *policy.Snapshot // policies in force for the request
+	ClientIP string
+	Admin    bool // authenticated as an admin, exempt from abuse scoring
+	// ShortURL builds the short URL of a link key the way the caller sees
+	// it, e.g. on the host a REST client used; nil for BASE_URL
+	ShortURL func(shortCode string) string
+}
+
+// OwnerID returns the user owning links created by the caller, if any
+func (c Caller) OwnerID() *uint {
+	if c.APIKey != nil {
+		return c.APIKey.UserID
+	}
+	return nil
+}
+
+// Creator returns who the caller's links and abuse signals count against,
+// empty for admins
+func (c Caller) Creator() string {
+	if c.Admin {
+		return ""
+	}
+	return abuse.Creator(c.OwnerID(), c.ClientIP)
+}
+
+// ErrAliasTaken is returned by CreateLink when the custom alias is in use
+var ErrAliasTaken = errors.New("custom alias is already taken")
+
+// ErrUnknownDomain is returned by CreateLink when the requested domain is
+// not a branded short link domain
+var ErrUnknownDomain = errors.New("unknown short link domain")
+
+// ErrEnvironmentMismatch is returned by CreateLink when the requested
+// environment is not that of the domain, see CheckEnvironment
+var ErrEnvironmentMismatch = errors.New("environment does not match the short link domain")
+
+// FireLinkHook notifies REST Hooks subscribers about a link event, and the
+// webhooks of the link's owner subscribed to it
+func FireLinkHook(ctx context.Context, caller Caller, event string, urlRecord *models.URL) {
+	if urlRecord.Inert {
+		return
+	}
+	payload := notify.LinkPayload(event, urlRecord)
+	if caller.ShortURL != nil {
+		payload.ShortURL = caller.ShortURL(urlRecord.ShortCode)
+	}
+	notify.Fire(ctx, event, payload)
+	if urlRecord.OwnerID != nil && models.IsWebhookEvent(event) {
+		notify.FireWebhooks(ctx, *urlRecord.OwnerID, event, payload)
+	}
+}
@@ -0,0 +1,582 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"url-shortener/background"
+	"url-shortener/database"
+	"url-shortener/domains"
+	"url-shortener/models"
+	"url-shortener/notify"
+	"url-shortener/utils"
+)
+
+// Shorten creates a short link for request, or returns the caller's link
+// already deduplicating its destination, reporting whether a link
+// was created. The request must have passed the validation of its binding
+// tags. Errors are *models.APIError.
+func (s Stores) Shorten(ctx context.Context, caller Caller, request models.ShortenRequest) (*models.URL, bool, error) {
+	if request.CustomAlias != "" {
+		if err := ValidateAlias(request.CustomAlias); err != nil {
+			return nil, false, models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, err.Error())
+		}
+		if request.CodeStyle != "" && request.CodeStyle != models.CodeStyleRandom {
+			return nil, false, models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "custom_alias cannot be combined with code_style")
+		}
+	}
+	if request.NoDedup && request.IfExists != "" && request.IfExists != models.IfExistsNew {
+		return nil, false, models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "no_dedup cannot be combined with if_exists "+request.IfExists)
+	}
+
+	if request.CustomAlias != "" {
+		if apiErr := CheckFeatureAllowed(ctx, caller, "custom_alias"); apiErr != nil {
+			return nil, false, apiErr
+		}
+	}
+	if request.OGTitle != "" || request.OGDescription != "" || request.OGImage != "" {
+		if apiErr := CheckFeatureAllowed(ctx, caller, "Open Graph overrides"); apiErr != nil {
+			return nil, false, apiErr
+		}
+	}
+
+	// Validate the URL and check the caller may shorten it
+	safetyAction, apiErr := CheckDestination(ctx, caller, request.URL, request.CaptchaToken)
+	if apiErr != nil {
+		return nil, false, apiErr
+	}
+	if safetyAction, apiErr = CheckVariants(ctx, caller, request.Variants, safetyAction); apiErr != nil {
+		return nil, false, apiErr
+	}
+	if safetyAction, apiErr = CheckRouting(ctx, caller, request.RoutingRules, safetyAction); apiErr != nil {
+		return nil, false, apiErr
+	}
+	if safetyAction, apiErr = CheckVelocityLimit(ctx, caller, request.VelocityLimit, safetyAction); apiErr != nil {
+		return nil, false, apiErr
+	}
+	if apiErr := CheckExpiry(caller, request.ExpiresIn); apiErr != nil {
+		return nil, false, apiErr
+	}
+	if apiErr := CheckTrackLandings(request.TrackLandings, analyticsMode(request.Analytics)); apiErr != nil {
+		return nil, false, apiErr
+	}
+	if apiErr := CheckRedirectHeaders(request.RedirectHeaders); apiErr != nil {
+		return nil, false, apiErr
+	}
+	if apiErr := CheckAttributionWindow(request.AttributionWindowHours, len(request.Variants) > 0); apiErr != nil {
+		return nil, false, apiErr
+	}
+	if apiErr := CheckEnvironment(request.Environment, request.Domain); apiErr != nil {
+		return nil, false, apiErr
+	}
+	if apiErr := CheckTags(caller, request.Tags); apiErr != nil {
+		return nil, false, apiErr
+	}
+
+	// Shadow-banned creators always get a fresh link that looks normal but never redirects
+	shadowBanned := caller.Policy.ShadowBanned()
+
+	// Ownerless callers have no links of their own to conflict with, and
+	// are never told about the links of others
+	if request.IfExists == models.IfExistsError && caller.OwnerID() == nil {
+		request.IfExists = models.IfExistsNew
+	}
+
+	// Look for an existing short URL unless the client always wants a new one
+	if Deduplicates(request, shadowBanned) {
+		if existingURL := s.FindExistingURL(ctx, caller.OwnerID(), request.URL); existingURL != nil {
+			if request.IfExists == models.IfExistsError {
+				return nil, false, URLExistsError(caller, existingURL)
+			}
+			return existingURL, false, nil
+		}
+	}
+
+	// Save the new link
+	expiresAt := ExpiryPolicy(caller).ExpiresAt(request.ExpiresIn, time.Now())
+	urlRecord, err := s.CreateLink(ctx, caller, request, expiresAt, safetyAction, shadowBanned)
+	if errors.Is(err, ErrAliasTaken) {
+		return nil, false, models.ErrAliasTaken
+	}
+	if errors.Is(err, ErrQuotaExhausted) {
+		return nil, false, QuotaExhaustedError()
+	}
+	if errors.Is(err, ErrUnknownDomain) {
+		return nil, false, models.NewAPIError(http.StatusBadRequest, models.ErrCodeInvalidRequest, "domain is not a short link domain of this service")
+	}
+	if errors.Is(err, ErrEnvironmentMismatch) {
+		// The domain's environment changed since it was checked
+		return nil, false, CheckEnvironment(request.Environment, request.Domain)
+	}
+	if err != nil {
+		// A concurrent request may have created the same destination first
+		if Deduplicates(request, shadowBanned) {
+			if existingURL := s.FindExistingURL(ctx, caller.OwnerID(), request.URL); existingURL != nil {
+				if request.IfExists == models.IfExistsError {
+					return nil, false, URLExistsError(caller, existingURL)
+				}
+				return existingURL, false, nil
+			}
+		}
+		return nil, false, models.NewAPIError(http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create short URL")
+	}
+	return urlRecord, true, nil
+}
+
+// URLExistsError reports the caller's existing link when if_exists is
+// error. The short code is only included for the caller's own link.
+func URLExistsError(caller Caller, existing *models.URL) *models.APIError {
+	apiErr := &models.APIError{
+		Status:  http.StatusConflict,
+		Code:    models.ErrCodeURLExists,
+		Message: "URL has already been shortened",
+	}
+	if ownerID := caller.OwnerID(); ownerID != nil && sameOwner(existing.OwnerID, ownerID) {
+		apiErr.ShortCode = existing.ShortCode
+	}
+	return apiErr
+}
+
+// Deduplicates reports whether a request may return, and become, the link
+// its owner gets back on shortening the same destination, or one with the same
+// canonical form. Requests asking for a fresh code with no_dedup or
+// if_exists=new never do. SMS and word codes, custom aliases, custom
+// preview cards, noindex, split links, links opting out of analytics, links
+// with max_clicks, links on a branded domain, links tracking landings,
+// tagged links and links with routing rules, a rollout, a velocity limit or
+// redirect headers always get a fresh link so that an existing one without
+// them is never returned instead.
+func Deduplicates(request models.ShortenRequest, shadowBanned bool) bool {
+	randomStyle := request.CodeStyle == "" || request.CodeStyle == models.CodeStyleRandom
+	customPreview := request.OGTitle != "" || request.OGDescription != "" || request.OGImage != ""
+	return request.IfExists != models.IfExistsNew && !request.NoDedup && randomStyle && request.CustomAlias == "" && !customPreview &&
+		!request.NoIndex && len(request.Variants) == 0 && analyticsMode(request.Analytics) == models.AnalyticsFull &&
+		request.MaxClicks == nil && request.UTMSource == "" && request.UTMMedium == "" && request.UTMCampaign == "" &&
+		request.RedirectType == 0 && request.Domain == "" && len(request.RoutingRules) == 0 &&
+		request.RolloutPercent == nil && VelocityLimit(request.VelocityLimit) == nil && !request.TrackLandings &&
+		len(request.RedirectHeaders) == 0 && len(request.Tags) == 0 && !shadowBanned
+}
+
+// analyticsMode is the analytics a link is created with, full unless the
+// request opted out
+func analyticsMode(mode models.AnalyticsMode) string {
+	if mode == "" {
+		return models.AnalyticsFull
+	}
+	return string(mode)
+}
+
+// variantMode returns the mode stored for a split link, empty for others
+func variantMode(request models.ShortenRequest) string {
+	if len(request.Variants) == 0 {
+		return ""
+	}
+	if request.VariantMode == "" {
+		return models.VariantModeWeighted
+	}
+	return request.VariantMode
+}
+
+// BuildVariants returns the variants of the split link urlID requested
+func BuildVariants(urlID uint, requests []models.VariantRequest) []models.LinkVariant {
+	variants := make([]models.LinkVariant, len(requests))
+	for i, request := range requests {
+		variants[i] = models.LinkVariant{
+			URLID:       urlID,
+			Name:        request.Name,
+			Destination: request.URL,
+			Weight:      max(request.Weight, 1),
+		}
+		if variants[i].Name == "" {
+			variants[i].Name = string(rune('A' + i))
+		}
+	}
+	return variants
+}
+
+// RolloutPercent is the rollout stored for a requested one: nil, a fully
+// launched link, for 100
+func RolloutPercent(percent *int) *int {
+	if percent == nil || *percent >= 100 {
+		return nil
+	}
+	value := *percent
+	return &value
+}
+
+// VelocityLimit is the velocity limit stored for a requested one: nil, no
+// limit, for 0 clicks, with the default window and overflow filled in
+func VelocityLimit(limit *models.VelocityLimit) *models.VelocityLimit {
+	if limit == nil || limit.Clicks == 0 {
+		return nil
+	}
+	value := *limit
+	if value.Per == "" {
+		value.Per = models.VelocityPerSecond
+	}
+	if value.Overflow == "" {
+		value.Overflow = models.VelocityOverflowQueue
+	}
+	return &value
+}
+
+// RedirectHeaders is the redirect headers stored for requested ones: nil
+// for none, with canonical names
+func RedirectHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	canonical := make(map[string]string, len(headers))
+	for name, value := range headers {
+		canonical[http.CanonicalHeaderKey(name)] = value
+	}
+	return canonical
+}
+
+// Attempts to find a free generated code before giving up
+const codeAttempts = 10
+
+// How random style codes are generated, from SHORT_CODE_STRATEGY
+var shortCodeStrategy = shortCodeStrategyFromEnv()
+
+func shortCodeStrategyFromEnv() string {
+	if strings.ToLower(os.Getenv("SHORT_CODE_STRATEGY")) == models.ShortCodeStrategySequential {
+		return models.ShortCodeStrategySequential
+	}
+	return models.ShortCodeStrategyRandom
+}
+
+// allocateShortCode picks a free short code in the style of request on the
+// domain host (empty for the default one), returning its link key
+func (s Stores) allocateShortCode(ctx context.Context, request models.ShortenRequest, host string) (string, error) {
+	switch {
+	case request.CodeStyle == models.CodeStyleSMS:
+		return s.allocateSMSCode(ctx, host)
+	case request.CodeStyle == models.CodeStyleWords:
+		return s.allocateWordCode(ctx, host)
+	case shortCodeStrategy == models.ShortCodeStrategySequential:
+		return s.allocateSequentialCode(ctx, host)
+	}
+	return s.allocateRandomCode(ctx, host)
+}
+
+// allocateRandomCode picks a random code that is not taken yet
+func (s Stores) allocateRandomCode(ctx context.Context, host string) (string, error) {
+	for i := 0; i < codeAttempts; i++ {
+		code := models.LinkKey(host, utils.GenerateShortCode())
+		taken, err := s.Links.ShortCodeTaken(ctx, code)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return code, nil
+		}
+	}
+	return "", errors.New("no free random short code found")
+}
+
+// allocateSequentialCode takes the next base62 encoded sequence value,
+// skipping codes already taken by random codes created before the switch,
+// custom aliases and reserved route names
+func (s Stores) allocateSequentialCode(ctx context.Context, host string) (string, error) {
+	for i := 0; i < codeAttempts; i++ {
+		value, err := database.NextShortCodeValue(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		code := utils.EncodeBase62(value)
+		if reservedAliases[strings.ToLower(code)] {
+			continue
+		}
+		code = models.LinkKey(host, code)
+		taken, err := s.Links.ShortCodeTaken(ctx, code)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return code, nil
+		}
+	}
+	return "", errors.New("no free sequential short code found")
+}
+
+// allocateWordCode picks a random word code that is not taken yet
+func (s Stores) allocateWordCode(ctx context.Context, host string) (string, error) {
+	for i := 0; i < codeAttempts; i++ {
+		code := models.LinkKey(host, utils.GenerateWordCode())
+		taken, err := s.Links.ShortCodeTaken(ctx, code)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return code, nil
+		}
+	}
+	return "", errors.New("no free word short code found")
+}
+
+// allocateSMSCode takes the next sequential SMS code, skipping values
+// already taken by other code styles (including soft-deleted links)
+func (s Stores) allocateSMSCode(ctx context.Context, host string) (string, error) {
+	for i := 0; i < codeAttempts; i++ {
+		value, err := database.NextSMSCodeValue(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		code := models.LinkKey(host, utils.EncodeSMSCode(value))
+		taken, err := s.Links.ShortCodeTaken(ctx, code)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return code, nil
+		}
+	}
+	return "", errors.New("no free SMS short code found")
+}
+
+// CreateLink stores a new link expiring at expiresAt for an already
+// validated request, caches it and notifies approvers and hook subscribers.
+// Links past the monthly quota of the caller's plan are refused with
+// ErrQuotaExhausted.
+func (s Stores) CreateLink(ctx context.Context, caller Caller, request models.ShortenRequest, expiresAt *time.Time, safetyAction string, shadowBanned bool) (*models.URL, error) {
+	if usage, ok := LinkQuota(ctx, caller); ok && usage.Exhausted() {
+		return nil, ErrQuotaExhausted
+	}
+
+	// Links on a branded domain get codes of their own
+	var domain *models.Domain
+	host := ""
+	if request.Domain != "" {
+		if domain = domains.ShortDomain(request.Domain); domain == nil {
+			return nil, ErrUnknownDomain
+		}
+		host = domain.Host
+	}
+	environment := domains.Environment(domain)
+	if request.Environment != "" && request.Environment != environment {
+		return nil, ErrEnvironmentMismatch
+	}
+
+	// Generate short code
+	var shortCode string
+	if request.CustomAlias != "" {
+		shortCode = models.LinkKey(host, request.CustomAlias)
+		taken, err := s.AliasTaken(ctx, shortCode)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrAliasTaken
+		}
+	} else {
+		var err error
+		if shortCode, err = s.allocateShortCode(ctx, request, host); err != nil {
+			return nil, err
+		}
+	}
+
+	// Create URL record
+	urlRecord := models.URL{
+		OriginalURL: request.URL,
+		ShortCode:   shortCode,
+		OwnerID:     caller.OwnerID(),
+		Creator:     caller.Creator(),
+		ClickCount:  0,
+		Status:      models.StatusActive,
+		Inert:       shadowBanned,
+		ExpiresAt:   expiresAt,
+
+		Tags:            caller.Policy.AutoTag(request.URL, request.Tags),
+		NoIndex:         request.NoIndex,
+		VariantMode:     variantMode(request),
+		Analytics:       analyticsMode(request.Analytics),
+		MaxClicks:       request.MaxClicks,
+		ClicksRemaining: request.MaxClicks,
+		UTMSource:       request.UTMSource,
+		UTMMedium:       request.UTMMedium,
+		UTMCampaign:     request.UTMCampaign,
+		RedirectType:    request.RedirectType,
+		RoutingRules:    request.RoutingRules,
+		RolloutPercent:  RolloutPercent(request.RolloutPercent),
+		VelocityLimit:   VelocityLimit(request.VelocityLimit),
+		TrackLandings:   request.TrackLandings,
+		RedirectHeaders: RedirectHeaders(request.RedirectHeaders),
+		OGTitle:         request.OGTitle,
+		OGDescription:   request.OGDescription,
+		OGImage:         request.OGImage,
+
+		AttributionWindowHours: request.AttributionWindowHours,
+		Environment:            environment,
+	}
+	if domain != nil {
+		urlRecord.DomainID = &domain.ID
+	}
+	if request.ExternalID != "" {
+		urlRecord.ExternalID = &request.ExternalID
+	}
+
+	// Hold new links for admin review when approval is required of the
+	// creator's role, unless they point to a verified domain trusted to
+	// skip it, and whatever the domain when a review safety rule matched or
+	// the creator's abuse level is severe
+	if RequiresApproval(ctx, caller) && !domains.SkipsApproval(request.URL) ||
+		safetyAction == models.SafetyActionReview || HeldForAbuse(ctx, caller) {
+		urlRecord.Status = models.StatusPending
+	}
+
+	// Only the first visible link for a destination is used for
+	// deduplication, and never one expiring early for being anonymous,
+	// which other callers would get
+	if Deduplicates(request, shadowBanned) && !(caller.APIKey == nil && LinkExpiry.AnonymousDays > 0) {
+		hash := DestinationHash(request.URL)
+		urlRecord.OriginalURLHash = &hash
+	}
+
+	// Save to database, with the variants of a split link
+	for attempt := 1; ; attempt++ {
+		err := s.Links.CreateURL(ctx, &urlRecord, BuildVariants(0, request.Variants))
+		if err == nil {
+			break
+		}
+		// Another request claimed the alias since it was checked
+		if request.CustomAlias != "" && IsUniqueViolation(err) {
+			return nil, ErrAliasTaken
+		}
+		// or the generated code, so another one is picked
+		if request.CustomAlias == "" && isShortCodeViolation(err) && attempt < codeAttempts {
+			if urlRecord.ShortCode, err = s.allocateShortCode(ctx, request, host); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return nil, err
+	}
+
+	// Cache the new URL mapping; additional codes for the same URL keep
+	// the original one as the deduplication target
+	s.Cache.CacheURLMapping(urlRecord.ShortCode, &urlRecord)
+	if urlRecord.OriginalURLHash != nil {
+		s.Cache.CacheOriginalURLMapping(urlRecord.OwnerID, urlRecord.OriginalURL, urlRecord.ShortCode)
+	}
+
+	if urlRecord.Status == models.StatusPending && !urlRecord.Inert {
+		background.Go(func() { NotifyApprovers(context.WithoutCancel(ctx), &urlRecord) })
+	}
+	FireLinkHook(ctx, caller, models.HookLinkCreated, &urlRecord)
+	fireQuotaHooks(ctx, caller)
+
+	return &urlRecord, nil
+}
+
+// FindExistingURL returns the link of ownerID, nil for ownerless links,
+// already deduplicating originalURL, checking the cache before falling back
+// to the database. Other owners' links are never returned.
+func (s Stores) FindExistingURL(ctx context.Context, ownerID *uint, originalURL string) *models.URL {
+	// Check cache first for existing URL
+	if shortCode, err := s.Cache.GetShortCodeForOriginalURL(ownerID, originalURL); err == nil {
+		// Found in cache, get the full URL data
+		if urlData, err := s.Cache.GetURLMapping(shortCode); err == nil && !urlData.Inert && sameOwner(urlData.OwnerID, ownerID) {
+			return urlData
+		}
+	}
+
+	// Check database if not in cache, matching on the indexed hash so
+	// encrypted destinations can be deduplicated too
+	existingURL, err := s.Links.GetByOriginalURL(ctx, ownerID, DestinationHash(originalURL))
+	if err != nil && utils.CanonicalURL(originalURL) != originalURL {
+		// Links created before destinations were canonicalized are hashed as given
+		existingURL, err = s.Links.GetByOriginalURL(ctx, ownerID, utils.HashURL(originalURL))
+	}
+	if err != nil {
+		return nil
+	}
+
+	// URL already exists in database, cache it for next time
+	s.Cache.CacheURLMapping(existingURL.ShortCode, existingURL)
+	s.Cache.CacheOriginalURLMapping(ownerID, existingURL.OriginalURL, existingURL.ShortCode)
+
+	return existingURL
+}
+
+// sameOwner reports whether a link of ownerID belongs to owner, ownerless
+// links sharing one scope
+func sameOwner(ownerID, owner *uint) bool {
+	if ownerID == nil || owner == nil {
+		return ownerID == nil && owner == nil
+	}
+	return *ownerID == *owner
+}
+
+// DestinationHash returns the original_url_hash of links deduplicating
+// rawURL, that of its canonical form
+func DestinationHash(rawURL string) string {
+	return utils.HashURL(utils.CanonicalURL(rawURL))
+}
+
+// NotifyApprovers tells the approvers about a pending link: it is posted
+// to APPROVAL_WEBHOOK_URL, if configured, and emailed to the approvers
+// when email is configured
+func NotifyApprovers(ctx context.Context, urlRecord *models.URL) {
+	if webhookURL := os.Getenv("APPROVAL_WEBHOOK_URL"); webhookURL != "" {
+		payload := map[string]interface{}{
+			"event":        "link.pending_approval",
+			"short_code":   urlRecord.ShortCode,
+			"original_url": urlRecord.OriginalURL,
+			"created_at":   urlRecord.CreatedAt.UTC().Format(time.RFC3339),
+		}
+
+		message := notify.Message{
+			Title: "Link awaiting approval",
+			Text:  "A new short link needs review before it redirects.",
+			Facts: []notify.Fact{
+				{Name: "Short code", Value: urlRecord.ShortCode},
+				{Name: "Destination", Value: urlRecord.OriginalURL},
+			},
+		}
+
+		if err := notify.PostMessage(webhookURL, message, payload); err != nil {
+			slog.ErrorContext(ctx, "Failed to notify approvers", "short_code", urlRecord.ShortCode, "error", err)
+		}
+	}
+
+	if !notify.EmailEnabled() {
+		return
+	}
+	subject := "Link awaiting approval: " + urlRecord.ShortCode
+	body := "A new short link needs review before it redirects.\n\n" +
+		"Short code: " + urlRecord.ShortCode + "\n" +
+		"Destination: " + urlRecord.OriginalURL + "\n\n" +
+		"Approve or reject it with POST /admin/approvals/" + urlRecord.ShortCode + "/approve or /reject.\n"
+	for _, to := range approverEmails(ctx) {
+		if err := notify.SendEmail(to, subject, body); err != nil {
+			slog.ErrorContext(ctx, "Failed to email approver", "to", to, "short_code", urlRecord.ShortCode, "error", err)
+		}
+	}
+}
+
+// approverEmails returns the addresses listed in APPROVAL_EMAILS, or those
+// of the admins when none are
+func approverEmails(ctx context.Context) []string {
+	var emails []string
+	for _, email := range strings.Split(os.Getenv("APPROVAL_EMAILS"), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			emails = append(emails, email)
+		}
+	}
+	if len(emails) > 0 || database.DB == nil {
+		return emails
+	}
+	err := database.DB.WithContext(ctx).Model(&models.User{}).Where("role = ?", models.RoleAdmin).Order("id").Pluck("email", &emails).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load the admins to notify", "error", err)
+	}
+	return emails
+}
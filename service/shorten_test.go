@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"url-shortener/models"
+)
+
+func TestRolloutPercent(t *testing.T) {
+	percent := func(value int) *int { return &value }
+	if got := RolloutPercent(nil); got != nil {
+		t.Errorf("RolloutPercent(nil) = %d, want nil", *got)
+	}
+	if got := RolloutPercent(percent(100)); got != nil {
+		t.Errorf("RolloutPercent(100) = %d, want nil for a full launch", *got)
+	}
+	if got := RolloutPercent(percent(0)); got == nil || *got != 0 {
+		t.Errorf("RolloutPercent(0) = %v, want 0", got)
+	}
+}
+
+func TestDeduplicates(t *testing.T) {
+	tests := []struct {
+		name    string
+		request models.ShortenRequest
+		want    bool
+	}{
+		{"plain", models.ShortenRequest{URL: "https://example.com"}, true},
+		{"no_dedup", models.ShortenRequest{URL: "https://example.com", NoDedup: true}, false},
+		{"if_exists new", models.ShortenRequest{URL: "https://example.com", IfExists: models.IfExistsNew}, false},
+		{"custom alias", models.ShortenRequest{URL: "https://example.com", CustomAlias: "promo"}, false},
+		{"redirect headers", models.ShortenRequest{URL: "https://example.com", RedirectHeaders: map[string]string{"Referrer-Policy": "no-referrer"}}, false},
+		{"tags", models.ShortenRequest{URL: "https://example.com", Tags: []string{"spring-sale"}}, false},
+	}
+	for _, tt := range tests {
+		if got := Deduplicates(tt.request, false); got != tt.want {
+			t.Errorf("%s: Deduplicates() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckRedirectHeaders(t *testing.T) {
+	allowed := map[string]string{"referrer-policy": "no-referrer", "CDN-Cache-Control": "max-age=60", "X-Campaign": "spring\tsale"}
+	if apiErr := CheckRedirectHeaders(allowed); apiErr != nil {
+		t.Errorf("CheckRedirectHeaders(%v) = %v", allowed, apiErr)
+	}
+	if got := RedirectHeaders(allowed); got["Referrer-Policy"] != "no-referrer" || got["Cdn-Cache-Control"] != "max-age=60" {
+		t.Errorf("RedirectHeaders() = %v, want canonical names", got)
+	}
+	for _, headers := range []map[string]string{
+		{"Set-Cookie": "session=1"},
+		{"Location": "https://evil.example"},
+		{"X-Forwarded-For": "10.0.0.1"},
+		{"X-Bad Name": "1"},
+		{"X-Split": "a\r\nSet-Cookie: session=1"},
+		{"Cache-Control": ""},
+	} {
+		if CheckRedirectHeaders(headers) == nil {
+			t.Errorf("CheckRedirectHeaders(%q) accepted them", headers)
+		}
+	}
+}
+
+func TestShortenRejectsNoDedupWithIfExists(t *testing.T) {
+	request := models.ShortenRequest{URL: "https://example.com", NoDedup: true, IfExists: models.IfExistsError}
+	_, _, err := Stores{}.Shorten(context.Background(), Caller{}, request)
+	var apiErr *models.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != models.ErrCodeInvalidRequest {
+		t.Errorf("Shorten() error = %v, want an invalid request", err)
+	}
+}
+
+func TestDestinationHashIsCanonical(t *testing.T) {
+	if DestinationHash("HTTPS://Example.com:443/?utm_source=x") != DestinationHash("https://example.com") {
+		t.Error("equivalent URLs hash differently")
+	}
+}
+
+func TestCheckAttributionWindow(t *testing.T) {
+	if apiErr := CheckAttributionWindow(72, false); apiErr == nil {
+		t.Error("a window on a link without variants should be rejected")
+	}
+	if apiErr := CheckAttributionWindow(72, true); apiErr != nil {
+		t.Errorf("window on a split link rejected: %v", apiErr)
+	}
+	if apiErr := CheckAttributionWindow(0, false); apiErr != nil {
+		t.Errorf("clearing the window rejected: %v", apiErr)
+	}
+}
+
+func TestCheckEnvironment(t *testing.T) {
+	if apiErr := CheckEnvironment("", ""); apiErr != nil {
+		t.Errorf("omitted environment rejected: %v", apiErr)
+	}
+	if apiErr := CheckEnvironment(models.EnvironmentProduction, ""); apiErr != nil {
+		t.Errorf("production link on the default production domain rejected: %v", apiErr)
+	}
+	if apiErr := CheckEnvironment(models.EnvironmentStaging, ""); apiErr == nil {
+		t.Error("a staging link on the default production domain should be rejected")
+	}
+
+	t.Setenv("DEFAULT_DOMAIN_ENVIRONMENT", "Staging")
+	if apiErr := CheckEnvironment(models.EnvironmentStaging, ""); apiErr != nil {
+		t.Errorf("staging link on the default staging domain rejected: %v", apiErr)
+	}
+	if apiErr := CheckEnvironment(models.EnvironmentProduction, ""); apiErr == nil {
+		t.Error("a production link on the default staging domain should be rejected")
+	}
+}
+
+func TestURLExistsErrorOnlyNamesOwnLinks(t *testing.T) {
+	owner, other := uint(1), uint(2)
+	existing := &models.URL{ShortCode: "abc123", OwnerID: &owner}
+
+	if apiErr := URLExistsError(Caller{APIKey: &models.APIKey{UserID: &owner}}, existing); apiErr.ShortCode != "abc123" {
+		t.Errorf("short_code for the owner = %q, want abc123", apiErr.ShortCode)
+	}
+	for _, caller := range []Caller{{APIKey: &models.APIKey{UserID: &other}}, {}} {
+		if apiErr := URLExistsError(caller, existing); apiErr.ShortCode != "" {
+			t.Errorf("short_code for %+v = %q, want none", caller.APIKey, apiErr.ShortCode)
+		}
+	}
+}
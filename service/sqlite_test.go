@@ -0,0 +1,187 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"url-shortener/database"
+	"url-shortener/database/databasetest"
+	"url-shortener/handlers/handlertest"
+	"url-shortener/models"
+	"url-shortener/notify"
+	"url-shortener/policy"
+	"url-shortener/service"
+	"url-shortener/storage"
+)
+
+// openSQLite migrates an in-memory SQLite database for the test and
+// returns stores over it with an in-memory cache
+func openSQLite(t *testing.T) service.Stores {
+	t.Helper()
+	t.Setenv("SHORTEN_ALLOW_PRIVATE_DESTINATIONS", "true")
+	databasetest.UseSQLite(t)
+	return service.Stores{Links: database.Links, Cache: handlertest.NewCache()}
+}
+
+// closeDatabase waits for the hooks fired in the background, which read
+// the database, before closing it
+func closeDatabase() {
+	notify.Drain(5 * time.Second)
+	database.Close()
+	database.DB, database.Prepared, database.Links = nil, nil, nil
+}
+
+func ownerCaller(userID uint) service.Caller {
+	return service.Caller{APIKey: &models.APIKey{UserID: &userID}, Policy: policy.Load("")}
+}
+
+func TestSQLiteShortenAndResolve(t *testing.T) {
+	stores := openSQLite(t)
+	ctx := context.Background()
+
+	link, created, err := stores.Shorten(ctx, ownerCaller(1), models.ShortenRequest{URL: "https://example.com/sqlite"})
+	if err != nil || !created {
+		t.Fatalf("Shorten() = %v, %v", created, err)
+	}
+	stored, err := database.Links.GetByShortCode(ctx, link.ShortCode)
+	if err != nil || stored.OriginalURL != "https://example.com/sqlite" || *stored.OwnerID != 1 {
+		t.Fatalf("GetByShortCode(%s) = %+v, %v", link.ShortCode, stored, err)
+	}
+
+	entry, err := stores.Resolve(ctx, link.ShortCode)
+	if err != nil || entry.Destination != "https://example.com/sqlite" {
+		t.Errorf("Resolve(%s) = %+v, %v", link.ShortCode, entry, err)
+	}
+	if _, err := stores.Resolve(ctx, "missing"); !errors.Is(err, models.ErrLinkNotFound) {
+		t.Errorf("Resolve(missing) = %v, want not found", err)
+	}
+}
+
+func TestSQLiteDeduplicatesPerOwner(t *testing.T) {
+	stores := openSQLite(t)
+	ctx := context.Background()
+	request := models.ShortenRequest{URL: "https://example.com/shared"}
+
+	first, _, err := stores.Shorten(ctx, ownerCaller(1), request)
+	if err != nil {
+		t.Fatalf("Shorten() by user 1 = %v", err)
+	}
+	again, created, err := stores.Shorten(ctx, ownerCaller(1), request)
+	if err != nil || created || again.ShortCode != first.ShortCode {
+		t.Errorf("Shorten() again by user 1 = %+v, %v, %v, want %s back", again, created, err, first.ShortCode)
+	}
+	other, created, err := stores.Shorten(ctx, ownerCaller(2), request)
+	if err != nil || !created || other.ShortCode == first.ShortCode {
+		t.Errorf("Shorten() by user 2 = %+v, %v, %v, want a new link", other, created, err)
+	}
+}
+
+func TestSQLiteUniqueViolations(t *testing.T) {
+	openSQLite(t)
+	ctx := context.Background()
+
+	link := &models.URL{OriginalURL: "https://example.com/a", ShortCode: "taken"}
+	if err := database.Links.CreateURL(ctx, link, nil); err != nil {
+		t.Fatalf("CreateURL() = %v", err)
+	}
+	err := database.Links.CreateURL(ctx, &models.URL{OriginalURL: "https://example.com/b", ShortCode: "taken"}, nil)
+	if !errors.Is(err, storage.ErrConflict) || !service.IsUniqueViolation(err) || !database.IsShortCodeViolation(err) {
+		t.Errorf("CreateURL() with a taken short code = %v, want a short code conflict", err)
+	}
+
+	// Deduplicating a destination twice for one owner violates another index
+	hash := service.DestinationHash("https://example.com/a")
+	first := &models.URL{OriginalURL: "https://example.com/a", ShortCode: "first", OriginalURLHash: &hash}
+	if err := database.Links.CreateURL(ctx, first, nil); err != nil {
+		t.Fatalf("CreateURL() = %v", err)
+	}
+	err = database.Links.CreateURL(ctx, &models.URL{OriginalURL: "https://example.com/a", ShortCode: "second", OriginalURLHash: &hash}, nil)
+	if !errors.Is(err, storage.ErrConflict) || database.IsShortCodeViolation(err) {
+		t.Errorf("CreateURL() deduplicating twice = %v, want a conflict not on the short code", err)
+	}
+	if taken, err := database.Links.ShortCodeTaken(ctx, "taken"); err != nil || !taken {
+		t.Errorf("ShortCodeTaken(taken) = %v, %v", taken, err)
+	}
+}
+
+func TestSQLiteSequences(t *testing.T) {
+	openSQLite(t)
+	ctx := context.Background()
+
+	for want := int64(1); want <= 3; want++ {
+		if got, err := database.NextShortCodeValue(ctx); err != nil || got != want {
+			t.Errorf("NextShortCodeValue() = %d, %v, want %d", got, err, want)
+		}
+	}
+	if got, err := database.NextSMSCodeValue(ctx); err != nil || got != 1 {
+		t.Errorf("NextSMSCodeValue() = %d, %v, want 1 from its own sequence", got, err)
+	}
+}
+
+func TestSQLiteClicks(t *testing.T) {
+	openSQLite(t)
+	ctx := context.Background()
+
+	maxClicks := 2
+	link := &models.URL{OriginalURL: "https://example.com/clicks", ShortCode: "clicks", MaxClicks: &maxClicks, ClicksRemaining: &maxClicks}
+	if err := database.Links.CreateURL(ctx, link, nil); err != nil {
+		t.Fatalf("CreateURL() = %v", err)
+	}
+
+	counts, err := database.Links.IncrementClicks(ctx, map[uint]int64{link.ID: 3}, nil)
+	if err != nil || counts[link.ID] != 3 {
+		t.Errorf("IncrementClicks() = %v, %v, want 3 clicks", counts, err)
+	}
+
+	if left, err := database.Links.ConsumeClick(ctx, link.ID); err != nil || left != 1 {
+		t.Errorf("first ConsumeClick() = %d, %v, want 1 left", left, err)
+	}
+	if left, err := database.Links.ConsumeClick(ctx, link.ID); err != nil || left != 0 {
+		t.Errorf("last ConsumeClick() = %d, %v, want 0 left", left, err)
+	}
+	if _, err := database.Links.ConsumeClick(ctx, link.ID); !errors.Is(err, database.ErrNoClicksLeft) {
+		t.Errorf("ConsumeClick() when used up = %v, want ErrNoClicksLeft", err)
+	}
+	stored, _ := database.Links.GetByShortCode(ctx, "clicks")
+	if stored.ExpiresAt == nil || stored.ExpiresAt.After(time.Now()) {
+		t.Errorf("expires_at after the last click = %v, want expired", stored.ExpiresAt)
+	}
+}
+
+func TestSQLiteRehydrate(t *testing.T) {
+	stores := openSQLite(t)
+	ctx := context.Background()
+
+	owner := uint(1)
+	hash := service.DestinationHash("https://example.com/archived")
+	archived := models.ArchivedURL{
+		ID: 42, ArchivedAt: time.Now(), OriginalURL: "https://example.com/archived",
+		OriginalURLHash: &hash, ShortCode: "archived", OwnerID: &owner,
+	}
+	if err := database.DB.Create(&archived).Error; err != nil {
+		t.Fatalf("creating archived link: %v", err)
+	}
+
+	// A live link of the owner took over the destination meanwhile
+	live := &models.URL{OriginalURL: "https://example.com/archived", ShortCode: "live", OwnerID: &owner, OriginalURLHash: &hash}
+	if err := database.Links.CreateURL(ctx, live, nil); err != nil {
+		t.Fatalf("CreateURL() = %v", err)
+	}
+
+	entry, err := stores.Resolve(ctx, "archived")
+	if err != nil || entry.Destination != "https://example.com/archived" {
+		t.Fatalf("Resolve(archived) = %+v, %v", entry, err)
+	}
+	rehydrated, err := database.Links.GetByShortCode(ctx, "archived")
+	if err != nil || rehydrated.ID != 42 || rehydrated.OriginalURLHash != nil {
+		t.Errorf("rehydrated link = %+v, %v, want ID 42 without its destination hash", rehydrated, err)
+	}
+	if _, err := database.Links.GetArchived(ctx, "archived"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetArchived() after rehydrating = %v, want not found", err)
+	}
+	if _, err := database.Links.Rehydrate(ctx, "never"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Rehydrate(never) = %v, want not found", err)
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"url-shortener/domains"
+	"url-shortener/models"
+	"url-shortener/storage"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultStatsMaxAge is how recent stats shared between pollers are unless
+// asked otherwise
+const DefaultStatsMaxAge = time.Second
+
+// MaxStatsMaxAge bounds the accepted age of stats, and is how long shared
+// results are kept
+const MaxStatsMaxAge = 5 * time.Minute
+
+// Timeout for a shared stats lookup, independent of the requests waiting on it
+const statsLoadTimeout = 10 * time.Second
+
+type sharedStats struct {
+	stats     *models.StatsResponse
+	fetchedAt time.Time
+}
+
+var (
+	statsGroup singleflight.Group
+
+	recentStatsMu sync.Mutex
+	recentStatsBy = make(map[string]sharedStats)
+)
+
+// Stats returns a link's stats no older than maxAge, preferring a result
+// shared with other pollers, then the cache, then the database
+func (s Stores) Stats(ctx context.Context, shortCode string, maxAge time.Duration) (*models.StatsResponse, error) {
+	// Serve a recent result shared with other pollers
+	if stats, ok := recentStats(shortCode, maxAge); ok {
+		return stats, nil
+	}
+
+	// Try cache next
+	if cachedStats, err := s.Cache.GetURLStats(shortCode); err == nil {
+		ShareStats(shortCode, cachedStats)
+		return cachedStats, nil
+	}
+
+	// Cache miss, load from the database once for all concurrent requests
+	return s.loadStats(ctx, shortCode)
+}
+
+// recentStats returns stats fetched by this instance within maxAge
+func recentStats(shortCode string, maxAge time.Duration) (*models.StatsResponse, bool) {
+	recentStatsMu.Lock()
+	defer recentStatsMu.Unlock()
+
+	shared, ok := recentStatsBy[shortCode]
+	if !ok || time.Since(shared.fetchedAt) > maxAge {
+		return nil, false
+	}
+	return shared.stats, true
+}
+
+// ShareStats makes freshly fetched stats available to other pollers
+func ShareStats(shortCode string, stats *models.StatsResponse) {
+	recentStatsMu.Lock()
+	defer recentStatsMu.Unlock()
+
+	// Drop expired entries so polling many links doesn't grow the map forever
+	if len(recentStatsBy) >= 10000 {
+		for code, shared := range recentStatsBy {
+			if time.Since(shared.fetchedAt) > MaxStatsMaxAge {
+				delete(recentStatsBy, code)
+			}
+		}
+	}
+
+	recentStatsBy[shortCode] = sharedStats{stats: stats, fetchedAt: time.Now()}
+}
+
+// loadStats builds stats from the database, coalescing concurrent lookups
+// for the same short code into a single query
+func (s Stores) loadStats(ctx context.Context, shortCode string) (*models.StatsResponse, error) {
+	result, err, _ := statsGroup.Do(shortCode, func() (interface{}, error) {
+		// Detach from the first caller's request so its cancellation doesn't fail the others
+		queryCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), statsLoadTimeout)
+		defer cancel()
+
+		urlRecord, err := s.Links.GetByShortCode(queryCtx, shortCode)
+		if errors.Is(err, storage.ErrNotFound) {
+			// Archived links report their stats without being rehydrated
+			if archived, archiveErr := s.Links.GetArchived(queryCtx, shortCode); archiveErr == nil {
+				urlRecord, err = archived, nil
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Get current click count from cache if available, otherwise use DB value
+		clickCount := urlRecord.ClickCount
+		if cachedClicks, err := s.Cache.GetClickCount(shortCode); err == nil {
+			clickCount = int(cachedClicks)
+		}
+
+		stats := &models.StatsResponse{
+			OriginalURL:  urlRecord.OriginalURL,
+			ShortCode:    urlRecord.ShortCode,
+			ClickCount:   clickCount,
+			CreatedAt:    urlRecord.CreatedAt,
+			ExpiresAt:    urlRecord.ExpiresAt,
+			Verified:     domains.Verified(urlRecord.OriginalURL),
+			Analytics:    urlRecord.AnalyticsMode(),
+			MaxClicks:    urlRecord.MaxClicks,
+			StatsResetAt: urlRecord.StatsResetAt,
+			Landings:     urlRecord.LandingStats(clickCount),
+		}
+		if urlRecord.ClicksRemaining != nil {
+			// The database follows the cached counter in the background
+			remaining := *urlRecord.ClicksRemaining
+			if cached, err := s.Cache.GetRemainingClicks(shortCode); err == nil {
+				remaining = max(int(cached), 0)
+			}
+			stats.ClicksRemaining = &remaining
+		}
+
+		// Cache the stats for a short time
+		s.Cache.CacheURLStats(shortCode, stats)
+		ShareStats(shortCode, stats)
+
+		return stats, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.StatsResponse), nil
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"url-shortener/cache"
+	"url-shortener/database"
+)
+
+// Stores are where the link operations read and write links: shortening,
+// resolving short codes and reading stats. Default returns those of the
+// server's connections; tests can run the operations against in-memory
+// ones instead.
+type Stores struct {
+	Links database.Store
+	Cache cache.Store
+}
+
+// Default returns the stores of the database and Redis connections
+func Default() Stores {
+	return Stores{Links: database.Links, Cache: cache.Links}
+}
@@ -0,0 +1,54 @@
+// Package storage defines the errors the cache and database layers return,
+// so callers can tell a missing value from a backend that is down without
+// knowing Redis or GORM. Backend errors are wrapped in an *Error, which
+// matches both its kind and the original error with errors.Is and
+// errors.As.
+package storage
+
+import (
+	"errors"
+	"io"
+	"net"
+)
+
+// Kinds of storage errors
+var (
+	// ErrNotFound means the value does not exist, e.g. a cache miss
+	ErrNotFound = errors.New("not found")
+	// ErrExpired means the value existed but can no longer be used
+	ErrExpired = errors.New("expired")
+	// ErrConflict means a write clashed with an existing value, e.g. a
+	// unique constraint
+	ErrConflict = errors.New("conflict")
+	// ErrBackendUnavailable means the backend could not be reached or is
+	// not configured
+	ErrBackendUnavailable = errors.New("backend unavailable")
+)
+
+// Error is a backend error classified as one of the kinds above
+type Error struct {
+	Kind error // ErrNotFound, ErrExpired, ErrConflict or ErrBackendUnavailable
+	Err  error // the backend's error
+}
+
+// Wrap classifies err as kind
+func Wrap(kind, err error) error {
+	return &Error{Kind: kind, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+// Unwrap returns both the kind and the backend's error, so checks for
+// either keep working
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// Unreachable reports whether err is a network failure talking to a
+// backend, such as a refused connection, a timeout or a dropped connection
+func Unreachable(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestWrapMatchesKindAndCause(t *testing.T) {
+	cause := errors.New("redis: nil")
+	err := fmt.Errorf("loading entry: %w", Wrap(ErrNotFound, cause))
+
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, cause) {
+		t.Errorf("%v does not match both its kind and its cause", err)
+	}
+	if errors.Is(err, ErrBackendUnavailable) {
+		t.Errorf("%v matches another kind", err)
+	}
+	var storageErr *Error
+	if !errors.As(err, &storageErr) || storageErr.Kind != ErrNotFound {
+		t.Errorf("errors.As(%v) = %+v", err, storageErr)
+	}
+	if got := Wrap(ErrNotFound, cause).Error(); got != "not found: redis: nil" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	cases := map[error]bool{
+		&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}: true,
+		fmt.Errorf("read: %w", io.EOF): true,
+		io.ErrUnexpectedEOF:            true,
+		errors.New("syntax error"):     false,
+	}
+	for err, want := range cases {
+		if got := Unreachable(err); got != want {
+			t.Errorf("Unreachable(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
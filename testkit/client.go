@@ -0,0 +1,99 @@
+package testkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"url-shortener/models"
+)
+
+// Client makes requests to the test server, authenticated with a bearer
+// token: an API key, a session access token or the ADMIN_TOKEN
+type Client struct {
+	env   *Env
+	token string
+	http  *http.Client
+}
+
+// Client returns a client sending token as a bearer token, or an anonymous
+// one when token is empty. Redirects are not followed, so responses of
+// short links can be checked.
+func (e *Env) Client(token string) *Client {
+	return &Client{
+		env:   e,
+		token: token,
+		http: &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Login signs a dashboard user in and returns a client using the session
+func (e *Env) Login(t *testing.T, email, password string) *Client {
+	t.Helper()
+	resp := e.Client("").Do(t, http.MethodPost, "/auth/login", models.LoginRequest{Email: email, Password: password})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Login as %s answered %d: %s", email, resp.StatusCode, resp.Body)
+	}
+	var tokens models.SessionTokensResponse
+	resp.JSON(t, &tokens)
+	return e.Client(tokens.AccessToken)
+}
+
+// Response is a response with its body read
+type Response struct {
+	*http.Response
+	Body []byte
+}
+
+// JSON decodes the response body into v
+func (r *Response) JSON(t *testing.T, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("Failed to decode response %s: %v", r.Body, err)
+	}
+}
+
+// Do sends a request to path, with body encoded as JSON unless nil
+func (c *Client) Do(t *testing.T, method, path string, body interface{}) *Response {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequest(method, c.env.URL(path), reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.Send(t, req)
+}
+
+// Send sends req, adding the client's bearer token
+func (c *Client) Send(t *testing.T, req *http.Request) *Response {
+	t.Helper()
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response of %s %s: %v", req.Method, req.URL.Path, err)
+	}
+	return &Response{Response: resp, Body: body}
+}
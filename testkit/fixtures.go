@@ -0,0 +1,81 @@
+package testkit
+
+import (
+	"context"
+	"testing"
+
+	"url-shortener/database"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/policy"
+	"url-shortener/service"
+	"url-shortener/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// CreateUser creates a dashboard user with password and role, e.g.
+// "user" or "admin"
+func (e *Env) CreateUser(t *testing.T, email, password, role string) *models.User {
+	t.Helper()
+	// The lowest cost keeps fixtures fast; logins verify any cost
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Email: email, PasswordHash: string(passwordHash), Role: role}
+	if err := database.DB.Create(user).Error; err != nil {
+		t.Fatalf("Failed to create user %s: %v", email, err)
+	}
+	return user
+}
+
+// CreateAPIKey issues an API key with scopes, the default scopes when none
+// are given, owned by owner when not nil, and returns the key to send as a
+// bearer token
+func (e *Env) CreateAPIKey(t *testing.T, owner *models.User, scopes ...string) string {
+	t.Helper()
+	if len(scopes) == 0 {
+		scopes = models.DefaultScopes
+	}
+	token, err := utils.GenerateToken(24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := utils.GenerateToken(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := middleware.APIKeyPrefix + token
+	apiKey := &models.APIKey{
+		Name:          "testkit",
+		KeyHash:       utils.HashToken(key),
+		KeyPrefix:     key[:len(middleware.APIKeyPrefix)+6],
+		SigningSecret: secret,
+		Scopes:        scopes,
+	}
+	if owner != nil {
+		apiKey.UserID = &owner.ID
+	}
+	if err := database.DB.Create(apiKey).Error; err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	return key
+}
+
+// CreateLink creates a link as shortening request would, owned by owner
+// when not nil. Fixture links skip the destination checks, safety rules and
+// approval applied to requests.
+func (e *Env) CreateLink(t *testing.T, request models.ShortenRequest, owner *models.User) *models.URL {
+	t.Helper()
+	caller := service.Caller{Policy: &policy.Snapshot{}}
+	if owner != nil {
+		caller.APIKey = &models.APIKey{UserID: &owner.ID}
+	}
+	link, err := service.Default().CreateLink(context.Background(), caller, request, nil, models.SafetyActionAllow, false)
+	if err != nil {
+		t.Fatalf("Failed to create link to %s: %v", request.URL, err)
+	}
+	return link
+}
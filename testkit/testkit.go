@@ -0,0 +1,198 @@
+// Package testkit runs the service against throwaway PostgreSQL and Redis
+// containers, for integration tests of code embedding or extending it.
+// Start brings up the containers and the HTTP server; fixtures create users,
+// API keys and links directly in the database, and Client makes
+// authenticated requests:
+//
+//	env := testkit.Start(t)
+//	key := env.CreateAPIKey(t, nil, models.ScopeCreate)
+//	resp := env.Client(key).Do(t, http.MethodPost, "/shorten", models.ShortenRequest{URL: "https://example.com"})
+//
+// The service keeps its connections in package variables, so a test binary
+// runs one environment at a time; Start waits for the previous one to be
+// cleaned up. Tests are skipped when Docker is not available.
+package testkit
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"url-shortener/cache"
+	"url-shortener/config"
+	"url-shortener/database"
+	"url-shortener/domains"
+	"url-shortener/encryption"
+	"url-shortener/grpcapi"
+	"url-shortener/handlers"
+	"url-shortener/middleware"
+	"url-shortener/notify"
+	"url-shortener/router"
+	"url-shortener/utils"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/modules/redis"
+)
+
+// Images started by default
+const (
+	DefaultPostgresImage = "postgres:16-alpine"
+	DefaultRedisImage    = "redis:7-alpine"
+)
+
+// How long the containers may take to start
+const startTimeout = 2 * time.Minute
+
+// Env is a running service backed by its own containers
+type Env struct {
+	// Server serves the service's routes; its URL is the base URL of short links
+	Server *httptest.Server
+	// Config is the configuration the service was started with
+	Config *config.Config
+}
+
+// Option customizes the environment started by Start
+type Option func(*options)
+
+type options struct {
+	postgresImage string
+	redisImage    string
+	configure     []func(*config.Config)
+	env           map[string]string
+}
+
+// WithPostgresImage starts PostgreSQL from another image, e.g. the version
+// run in production
+func WithPostgresImage(image string) Option {
+	return func(o *options) { o.postgresImage = image }
+}
+
+// WithRedisImage starts Redis from another image
+func WithRedisImage(image string) Option {
+	return func(o *options) { o.redisImage = image }
+}
+
+// WithConfig changes the configuration before the service starts. The
+// database and Redis connections are set to the containers afterwards.
+func WithConfig(configure func(*config.Config)) Option {
+	return func(o *options) { o.configure = append(o.configure, configure) }
+}
+
+// WithEnv sets an environment variable for the test before the service
+// starts, for settings read from the environment such as ADMIN_TOKEN
+func WithEnv(key, value string) Option {
+	return func(o *options) { o.env[key] = value }
+}
+
+// running is held from Start until the environment is cleaned up
+var running sync.Mutex
+
+// clickRecorder starts the click recorder once per test binary, as it
+// cannot be restarted once stopped
+var clickRecorder sync.Once
+
+// Start brings up PostgreSQL and Redis containers, migrates the database
+// and serves the service's routes. Everything is torn down when the test
+// ends. The test is skipped when Docker is not available.
+func Start(t *testing.T, opts ...Option) *Env {
+	t.Helper()
+	skipWithoutDocker(t)
+
+	o := options{
+		postgresImage: DefaultPostgresImage,
+		redisImage:    DefaultRedisImage,
+		env:           make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	for key, value := range o.env {
+		t.Setenv(key, value)
+	}
+
+	running.Lock()
+	t.Cleanup(running.Unlock)
+
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+
+	pg, err := postgres.Run(ctx, o.postgresImage,
+		postgres.WithDatabase("urlshortener"),
+		postgres.WithUsername("postgres"),
+		postgres.WithPassword("postgres"),
+		postgres.BasicWaitStrategies(),
+	)
+	testcontainers.CleanupContainer(t, pg)
+	if err != nil {
+		t.Fatalf("Failed to start PostgreSQL: %v", err)
+	}
+	rd, err := redis.Run(ctx, o.redisImage)
+	testcontainers.CleanupContainer(t, rd)
+	if err != nil {
+		t.Fatalf("Failed to start Redis: %v", err)
+	}
+
+	cfg := config.Default()
+	for _, configure := range o.configure {
+		configure(cfg)
+	}
+	if cfg.Database.URL, err = pg.ConnectionString(ctx, "sslmode=disable"); err != nil {
+		t.Fatalf("Failed to read the PostgreSQL address: %v", err)
+	}
+	cfg.Redis = config.Redis{}
+	if cfg.Redis.Addr, err = rd.Endpoint(ctx, ""); err != nil {
+		t.Fatalf("Failed to read the Redis address: %v", err)
+	}
+
+	// Initialize like the server does, minus the background jobs
+	cache.Configure(cfg.Cache)
+	middleware.ConfigureRateLimits(cfg.RateLimit)
+	middleware.ConfigureResponseCache(cfg.Cache.ResponseTTL)
+	utils.SetShortCodeLength(cfg.Links.ShortCodeLength)
+	encryption.Init()
+
+	if err := database.Connect(cfg.Database); err != nil {
+		t.Fatalf("Failed to connect to the database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Connect(ctx, cfg.Redis); err != nil {
+		t.Fatalf("Failed to connect to Redis: %v", err)
+	}
+	t.Cleanup(func() { cache.Close() })
+	domains.FollowShortDomainChanges()
+	clickRecorder.Do(handlers.StartClickRecorder)
+
+	server := httptest.NewServer(router.New())
+	t.Cleanup(server.Close)
+
+	// Short links point at the test server
+	cfg.Server.BaseURL = server.URL
+	handlers.ConfigureBaseURL(server.URL)
+	grpcapi.ConfigureBaseURL(server.URL)
+	notify.ConfigureBaseURL(server.URL)
+
+	return &Env{Server: server, Config: cfg}
+}
+
+// skipWithoutDocker skips the test when no Docker daemon can be reached
+func skipWithoutDocker(t *testing.T) {
+	t.Helper()
+	// testcontainers panics when it finds no Docker host at all
+	defer func() {
+		if r := recover(); r != nil {
+			t.Skipf("Docker is not available: %v", r)
+		}
+	}()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+}
+
+// URL returns the absolute URL of path on the test server
+func (e *Env) URL(path string) string {
+	return e.Server.URL + path
+}
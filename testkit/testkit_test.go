@@ -0,0 +1,36 @@
+package testkit
+
+import (
+	"net/http"
+	"testing"
+
+	"url-shortener/models"
+)
+
+func TestEnv(t *testing.T) {
+	env := Start(t)
+
+	user := env.CreateUser(t, "owner@example.com", "correct horse", "user")
+	key := env.CreateAPIKey(t, user, models.ScopeCreate, models.ScopeReadStats)
+
+	resp := env.Client(key).Do(t, http.MethodPost, "/shorten", models.ShortenRequest{URL: "https://example.com/docs"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST /shorten = %d: %s", resp.StatusCode, resp.Body)
+	}
+	var created models.ShortenResponse
+	resp.JSON(t, &created)
+	if created.ShortURL != env.URL("/"+created.ShortCode) {
+		t.Errorf("short_url = %q, want it on the test server", created.ShortURL)
+	}
+
+	link := env.CreateLink(t, models.ShortenRequest{URL: "https://example.com/fixture"}, user)
+	resp = env.Client("").Do(t, http.MethodGet, "/"+link.ShortCode, nil)
+	if resp.StatusCode != http.StatusMovedPermanently || resp.Header.Get("Location") != link.OriginalURL {
+		t.Errorf("GET /%s = %d to %q", link.ShortCode, resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	resp = env.Login(t, user.Email, "correct horse").Do(t, http.MethodGet, "/auth/sessions", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /auth/sessions = %d: %s", resp.StatusCode, resp.Body)
+	}
+}
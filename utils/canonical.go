@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Query parameters that only track where a visitor came from, dropped by
+// CanonicalURL along with every utm_ parameter
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_ga":     true,
+}
+
+// CanonicalURL returns the form of rawURL used to deduplicate destinations,
+// so URLs leading to the same page share a link: the scheme and host are
+// lowercased, default ports, tracking parameters and trailing slashes are
+// removed and the remaining query parameters are sorted. URLs that cannot
+// be parsed are returned unchanged.
+func CanonicalURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return rawURL
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Host)
+	if parsed.Scheme == "http" {
+		host = strings.TrimSuffix(host, ":80")
+	} else if parsed.Scheme == "https" {
+		host = strings.TrimSuffix(host, ":443")
+	}
+	parsed.Host = host
+
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	parsed.RawPath = strings.TrimRight(parsed.RawPath, "/")
+
+	if parsed.RawQuery != "" {
+		query, err := url.ParseQuery(parsed.RawQuery)
+		if err != nil {
+			return parsed.String()
+		}
+		for name := range query {
+			if trackingParams[strings.ToLower(name)] || strings.HasPrefix(strings.ToLower(name), "utm_") {
+				delete(query, name)
+			}
+		}
+		// Encode sorts the parameters by name
+		parsed.RawQuery = query.Encode()
+	}
+	parsed.ForceQuery = false
+	return parsed.String()
+}
@@ -0,0 +1,24 @@
+package utils
+
+import "testing"
+
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"https://example.com/", "https://example.com"},
+		{"HTTPS://EXAMPLE.COM", "https://example.com"},
+		{"https://example.com:443/docs/", "https://example.com/docs"},
+		{"http://example.com:80", "http://example.com"},
+		{"http://example.com:8080/", "http://example.com:8080"},
+		{"https://example.com/a?b=2&a=1", "https://example.com/a?a=1&b=2"},
+		{"https://example.com/a?utm_source=mail&id=7&fbclid=x&UTM_Medium=y", "https://example.com/a?id=7"},
+		{"https://example.com/?utm_campaign=spring", "https://example.com"},
+		{"https://example.com/Path/Case?", "https://example.com/Path/Case"},
+		{"https://example.com/page#Section", "https://example.com/page#Section"},
+		{"not a url", "not a url"},
+	}
+	for _, tt := range tests {
+		if got := CanonicalURL(tt.in); got != tt.want {
+			t.Errorf("CanonicalURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// AddQueryDefaults appends the parameters of query (URL encoded) that
+// rawURL does not set already, leaving its own query untouched
+func AddQueryDefaults(rawURL, query string) string {
+	if query == "" {
+		return rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	defaults, err := url.ParseQuery(query)
+	if err != nil {
+		return rawURL
+	}
+
+	existing := parsed.Query()
+	var missing []string
+	for name := range defaults {
+		if existing.Get(name) == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return rawURL
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		if parsed.RawQuery != "" {
+			parsed.RawQuery += "&"
+		}
+		parsed.RawQuery += url.QueryEscape(name) + "=" + url.QueryEscape(defaults.Get(name))
+	}
+	return parsed.String()
+}
+
+// URLMatchesDomain reports whether rawURL's host is domain or one of its subdomains
+func URLMatchesDomain(rawURL, domain string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	domain = strings.ToLower(domain)
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
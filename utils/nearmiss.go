@@ -0,0 +1,67 @@
+package utils
+
+// Characters a short code or custom alias may contain
+const codeCharset = charset + "-_"
+
+// NearMissCodes returns the codes one typo away from code: one character
+// deleted, inserted or replaced, or two neighbours swapped. Each is listed
+// once and code itself is left out.
+func NearMissCodes(code string) []string {
+	seen := map[string]bool{code: true}
+	var codes []string
+	add := func(candidate string) {
+		if candidate != "" && !seen[candidate] {
+			seen[candidate] = true
+			codes = append(codes, candidate)
+		}
+	}
+
+	for i := 0; i <= len(code); i++ {
+		if i < len(code) {
+			add(code[:i] + code[i+1:])
+		}
+		if i+1 < len(code) {
+			add(code[:i] + code[i+1:i+2] + code[i:i+1] + code[i+2:])
+		}
+		for j := 0; j < len(codeCharset); j++ {
+			c := codeCharset[j : j+1]
+			add(code[:i] + c + code[i:])
+			if i < len(code) {
+				add(code[:i] + c + code[i+1:])
+			}
+		}
+	}
+	return codes
+}
+
+// OneTypoApart reports whether b is one of NearMissCodes(a)
+func OneTypoApart(a, b string) bool {
+	switch {
+	case a == b:
+		return false
+	case len(a) == len(b):
+		first := -1
+		for i := 0; i < len(a); i++ {
+			if a[i] == b[i] {
+				continue
+			}
+			if first >= 0 {
+				// A second difference is only allowed as the swap of the first
+				return i == first+1 && a[first] == b[i] && a[i] == b[first] && a[i+1:] == b[i+1:]
+			}
+			first = i
+		}
+		return true
+	case len(a) == len(b)+1:
+		a, b = b, a
+		fallthrough
+	case len(a)+1 == len(b):
+		// b has one character more
+		i := 0
+		for i < len(a) && a[i] == b[i] {
+			i++
+		}
+		return a[i:] == b[i+1:]
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package utils
+
+import "testing"
+
+func TestOneTypoApart(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"abc123", "abc124", true},  // replaced
+		{"abc123", "abc12", true},   // deleted
+		{"abc123", "abxc123", true}, // inserted
+		{"abc123", "abc213", true},  // swapped
+		{"abc123", "abc123", false},
+		{"abc123", "abd124", false},
+		{"abc123", "ab123c", false},
+		{"abc123", "bc12", false},
+		{"ab", "ba", true},
+		{"", "a", true},
+	}
+	for _, tt := range tests {
+		if got := OneTypoApart(tt.a, tt.b); got != tt.want {
+			t.Errorf("OneTypoApart(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := OneTypoApart(tt.b, tt.a); got != tt.want {
+			t.Errorf("OneTypoApart(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestNearMissCodes(t *testing.T) {
+	codes := NearMissCodes("aB3")
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if seen[code] {
+			t.Errorf("NearMissCodes listed %q twice", code)
+		}
+		seen[code] = true
+		if !OneTypoApart("aB3", code) {
+			t.Errorf("NearMissCodes listed %q, which is not one typo away", code)
+		}
+	}
+	for _, code := range []string{"B3", "Ba3", "aB4", "aB3_", "xaB3"} {
+		if !seen[code] {
+			t.Errorf("NearMissCodes did not list %q", code)
+		}
+	}
+}
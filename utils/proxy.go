@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// ParseTrustedProxies parses a comma-separated list of proxy IP addresses
+// and CIDR ranges, returning nil for an empty list
+func ParseTrustedProxies(value string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR range", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				bits = 8 * net.IPv4len
+			}
+			entry = fmt.Sprintf("%s/%d", entry, bits)
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR range", entry)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// TrustedProxies returns the networks of TRUSTED_PROXIES, none when it is
+// unset or invalid
+func TrustedProxies() []string {
+	networks, _ := ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	proxies := make([]string, len(networks))
+	for i, network := range networks {
+		proxies[i] = network.String()
+	}
+	return proxies
+}
+
+// IsTrustedProxy reports whether the peer at ip may set forwarded headers:
+// only peers listed in TRUSTED_PROXIES, so none when it is unset or invalid
+func IsTrustedProxy(ip string) bool {
+	networks, err := ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return false
+	}
+	parsed := net.ParseIP(ip)
+	for _, network := range networks {
+		if parsed != nil && network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ForwardedValue returns the value of a forwarded header such as
+// X-Forwarded-Host when the peer at remoteIP is a trusted proxy. Proxies
+// append to these headers, so the last value is the one the trusted peer
+// set; the earlier ones came from further away, possibly the client.
+func ForwardedValue(header, remoteIP string) (string, bool) {
+	if header == "" || !IsTrustedProxy(remoteIP) {
+		return "", false
+	}
+	value := header
+	if i := strings.LastIndex(header, ","); i >= 0 {
+		value = header[i+1:]
+	}
+	value = strings.TrimSpace(value)
+	return value, value != ""
+}
@@ -5,12 +5,18 @@ import (
 	"math/big"
 )
 
-const (
-	// Character set for short codes (alphanumeric, case-sensitive)
-	charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	// Length of the short code
-	shortCodeLength = 6
-)
+// Character set for short codes (alphanumeric, case-sensitive)
+const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// Length of random and sequential short codes, SHORT_CODE_LENGTH
+var shortCodeLength = 6
+
+// SetShortCodeLength sets the length of random and sequential short codes.
+// Lowering it where codes are sequential eventually makes new codes collide
+// with those handed out before.
+func SetShortCodeLength(length int) {
+	shortCodeLength = length
+}
 
 // GenerateShortCode generates a random short code for URL shortening
 func GenerateShortCode() string {
@@ -24,3 +30,47 @@ func GenerateShortCode() string {
 
 	return string(shortCode)
 }
+
+// EncodeBase62 turns a sequence value (starting at 1) into a short code
+// using the characters of random codes. Codes have as many characters as
+// random ones until the values outgrow them, e.g. after about 55 billion
+// values for 6 characters, then grow by one.
+func EncodeBase62(n int64) string {
+	base := int64(len(charset))
+	// Offset so the first code already has shortCodeLength characters
+	offset := int64(1)
+	for i := 1; i < shortCodeLength; i++ {
+		offset *= base
+	}
+	n += offset - 1
+
+	var code []byte
+	for ; n > 0; n /= base {
+		code = append(code, charset[n%base])
+	}
+	for i, j := 0, len(code)-1; i < j; i, j = i+1, j-1 {
+		code[i], code[j] = code[j], code[i]
+	}
+	return string(code)
+}
+
+// SMS codes avoid look-alike characters and are case-insensitive, since
+// phones often capitalize or autocorrect them
+const smsCharset = "23456789abcdefghjkmnpqrstuvwxyz"
+
+// EncodeSMSCode turns a sequence value (starting at 1) into an SMS code.
+// Codes start at 3 characters and grow to 4 after about 28,800 links.
+func EncodeSMSCode(n int64) string {
+	base := int64(len(smsCharset))
+	// Offset so the first code already has 3 characters
+	n += base * base
+
+	var code []byte
+	for ; n > 0; n /= base {
+		code = append(code, smsCharset[n%base])
+	}
+	for i, j := 0, len(code)-1; i < j; i, j = i+1, j-1 {
+		code[i], code[j] = code[j], code[i]
+	}
+	return string(code)
+}
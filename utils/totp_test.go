@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// The SHA-1 test vectors of RFC 6238 appendix B, for the ASCII secret
+// "12345678901234567890", truncated to the 6 digits used here
+func TestMatchTOTPRFC6238Vectors(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	for _, vector := range []struct {
+		unix int64
+		code string
+		step int64
+	}{
+		{59, "287082", 0x1},
+		{1111111109, "081804", 0x23523EC},
+		{1111111111, "050471", 0x23523ED},
+		{1234567890, "005924", 0x273EF07},
+		{2000000000, "279037", 0x3F940AA},
+		{20000000000, "353130", 0x27BC86AA},
+	} {
+		at := time.Unix(vector.unix, 0)
+		step, ok := MatchTOTP(secret, vector.code, at)
+		if !ok || step != vector.step {
+			t.Errorf("MatchTOTP(%q) at %d = %d, %t, want step %d", vector.code, vector.unix, step, ok, vector.step)
+		}
+		// Codes stay valid a step before and after, for clock drift
+		if step, ok := MatchTOTP(secret, vector.code, at.Add(totpPeriod*time.Second)); !ok || step != vector.step {
+			t.Errorf("MatchTOTP(%q) a step later = %d, %t, want step %d", vector.code, step, ok, vector.step)
+		}
+		if _, ok := MatchTOTP(secret, vector.code, at.Add(3*totpPeriod*time.Second)); ok {
+			t.Errorf("MatchTOTP(%q) three steps later accepted", vector.code)
+		}
+	}
+
+	if _, ok := MatchTOTP(secret, "000000", time.Unix(59, 0)); ok {
+		t.Error("wrong code accepted")
+	}
+	if _, ok := MatchTOTP("not base32!", "287082", time.Unix(59, 0)); ok {
+		t.Error("code accepted for an invalid secret")
+	}
+}
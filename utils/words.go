@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strconv"
+)
+
+// Word lists for human-readable codes: short, common, easy to spell and
+// unambiguous when read aloud (no homophones such as "blue"/"blew")
+var (
+	codeAdjectives = []string{
+		"amber", "bold", "brave", "brisk", "calm", "clever", "cool", "cosy",
+		"crisp", "curly", "daring", "eager", "early", "easy", "fancy", "fast",
+		"fluffy", "fresh", "friendly", "frosty", "funny", "gentle", "giant", "glad",
+		"golden", "grand", "green", "happy", "honest", "humble", "jolly", "kind",
+		"lively", "lucky", "magic", "mellow", "merry", "mighty", "misty", "modern",
+		"noble", "orange", "patient", "plain", "polite", "proud", "purple", "quick",
+		"quiet", "rapid", "rosy", "royal", "rusty", "shiny", "silent", "silver",
+		"simple", "sleepy", "smart", "smooth", "snowy", "solid", "sunny", "super",
+		"sweet", "swift", "tidy", "tiny", "vivid", "warm", "wild", "windy",
+		"wise", "witty", "young", "zesty",
+	}
+	codeNouns = []string{
+		"apple", "badger", "banana", "beach", "bison", "breeze", "camel", "canyon",
+		"castle", "cedar", "cherry", "cloud", "comet", "coral", "cotton", "desert",
+		"dolphin", "dragon", "eagle", "falcon", "forest", "fox", "garden", "glacier",
+		"harbor", "hippo", "island", "jaguar", "jungle", "kettle", "koala", "lagoon",
+		"lemon", "lion", "lizard", "mango", "maple", "meadow", "melon", "monkey",
+		"moose", "mountain", "ocean", "olive", "orchid", "otter", "owl", "panda",
+		"parrot", "peach", "pebble", "penguin", "pepper", "pilot", "planet", "pony",
+		"puffin", "rabbit", "river", "robin", "rocket", "salmon", "sparrow", "spider",
+		"squirrel", "sunset", "tiger", "tomato", "tulip", "turtle", "valley", "violin",
+		"walrus", "whale", "willow", "wizard", "zebra",
+	}
+)
+
+// GenerateWordCode returns a pronounceable code made of an adjective, a
+// noun and a two digit number, e.g. "sunny-otter-42"
+func GenerateWordCode() string {
+	return randomWord(codeAdjectives) + "-" + randomWord(codeNouns) + "-" + strconv.Itoa(10+randomInt(90))
+}
+
+func randomWord(words []string) string {
+	return words[randomInt(len(words))]
+}
+
+func randomInt(n int) int {
+	value, _ := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	return int(value.Int64())
+}